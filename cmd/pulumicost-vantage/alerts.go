@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/alert"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/export"
+)
+
+// webhookTimeout bounds each alert webhook request.
+const webhookTimeout = 30 * time.Second
+
+// checkAlerts evaluates the configured alert rules against the sink after a
+// sync and notifies webhooks of new breaches, remembering what was sent in
+// store. Alerting never fails the sync: problems are logged as warnings and
+// the breaches sent so far are returned.
+func checkAlerts(cmd *cobra.Command, cfg *adapter.Config, store adapter.BookmarkStore) []alert.Breach {
+	if len(cfg.Alerts.Rules) == 0 {
+		return nil
+	}
+
+	ctx := cmd.Context()
+	logger := commandLogger(cmd)
+	records, err := readSinkRecords(ctx, cfg)
+	if err != nil {
+		logger.Warn(ctx, "Alert check failed", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "alert_check",
+			"attempt":   0,
+			"error":     fmt.Errorf("reading records for alerts: %w", err),
+		})
+		return nil
+	}
+
+	alerter := alert.NewAlerter(alert.NewNotifier(&http.Client{Timeout: webhookTimeout}), store)
+	sent, err := alerter.Check(ctx, cfg.Alerts, export.Reconcile(records), time.Now())
+	if err != nil {
+		logger.Warn(ctx, "Alert notification failed", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "alert_notify",
+			"attempt":   0,
+			"error":     err,
+		})
+	}
+	return sent
+}
+
+// printAlerts writes one line per breach sent.
+func printAlerts(out io.Writer, cfg *adapter.Config, breaches []alert.Breach) {
+	for _, breach := range breaches {
+		_, _ = fmt.Fprintf(out, "%s%s\n", profilePrefix(cfg), breach.Message())
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/export"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/report"
+)
+
+const (
+	defaultMoversTop   = 10
+	defaultIdleDays    = 30
+	defaultIdleMinDays = 14
+)
+
+func buildAnalyzeCmd() *cobra.Command {
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze cost records for triage",
+	}
+
+	moversCmd := &cobra.Command{
+		Use:   "movers",
+		Short: "List the groups whose spend changed the most",
+		Long: `Compare net cost per service (or another dimension or label, with --by) between
+the last complete period and the one before it, and list the groups with the
+largest absolute and percentage changes. Periods are days, Monday-based weeks,
+or calendar months, ending before --end (today by default). Records are read
+from the configured sink, or fetched live from Vantage with --live.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			if !slices.Contains(report.SupportedFormats(), format) {
+				return fmt.Errorf("invalid --format %q (valid: %s)", format, strings.Join(report.SupportedFormats(), ", "))
+			}
+			by, _ := cmd.Flags().GetString("by")
+			top, _ := cmd.Flags().GetInt("top")
+			if top < 1 {
+				return fmt.Errorf("invalid --top %d: must be at least 1", top)
+			}
+
+			end, err := analysisEnd(cmd)
+			if err != nil {
+				return err
+			}
+			period, _ := cmd.Flags().GetString("period")
+			previousStart, currentStart, currentEnd, err := report.MoverPeriods(period, end)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			records, err := analysisRecords(cmd, cfg, previousStart, currentEnd)
+			if err != nil {
+				return err
+			}
+
+			movers := report.BuildMovers(records, by, previousStart, currentStart, currentEnd, top)
+			return writeExport(cmd, func(out io.Writer) (int, error) {
+				return len(movers.ByAbsolute), report.WriteMovers(out, movers, format)
+			})
+		},
+	}
+
+	moversCmd.Flags().String("period", report.PeriodMonth, "Period to compare: day, week, or month")
+	moversCmd.Flags().String("by", "service", "Dimension or label key to group by")
+	moversCmd.Flags().Int("top", defaultMoversTop, "Number of groups listed in each ranking")
+	moversCmd.Flags().String("end", "", "Compare the last period ending on or before this day (YYYY-MM-DD); defaults to today")
+	moversCmd.Flags().String("format", report.FormatMarkdown, "Output format: csv, json, or markdown")
+	moversCmd.Flags().String("out", "-", "Output file, or - for stdout")
+	moversCmd.Flags().Bool("live", false, "Fetch records from Vantage instead of reading the sink")
+	_ = moversCmd.RegisterFlagCompletionFunc("period",
+		cobra.FixedCompletions(report.SupportedPeriods(), cobra.ShellCompDirectiveNoFileComp))
+	_ = moversCmd.RegisterFlagCompletionFunc("by",
+		cobra.FixedCompletions(report.SupportedDimensions(), cobra.ShellCompDirectiveNoFileComp))
+
+	idleCmd := &cobra.Command{
+		Use:   "idle",
+		Short: "List resources that cost money without being used",
+		Long: `Flag resources whose resource-level records show spend on at least --min-days
+days of the window but zero usage quantity, such as unattached volumes or
+unassociated IPs, grouped by provider and service with the costliest first.
+It needs records synced with the resource_id group_by and the usage metric,
+at day granularity; resources without usage data are never flagged. Records
+are read from the configured sink, or fetched live from Vantage with --live.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			if !slices.Contains(report.SupportedFormats(), format) {
+				return fmt.Errorf("invalid --format %q (valid: %s)", format, strings.Join(report.SupportedFormats(), ", "))
+			}
+			days, _ := cmd.Flags().GetInt("days")
+			minDays, _ := cmd.Flags().GetInt("min-days")
+			if days < 1 {
+				return fmt.Errorf("invalid --days %d: must be at least 1", days)
+			}
+			if minDays < 1 || minDays > days {
+				return fmt.Errorf("invalid --min-days %d: must be between 1 and --days (%d)", minDays, days)
+			}
+
+			end, err := analysisEnd(cmd)
+			if err != nil {
+				return err
+			}
+			start := end.AddDate(0, 0, -days)
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			records, err := analysisRecords(cmd, cfg, start, end)
+			if err != nil {
+				return err
+			}
+
+			idle := report.BuildIdle(records, start, end, minDays)
+			return writeExport(cmd, func(out io.Writer) (int, error) {
+				return len(idle.Resources), report.WriteIdle(out, idle, format)
+			})
+		},
+	}
+
+	idleCmd.Flags().Int("days", defaultIdleDays, "Length of the window in days")
+	idleCmd.Flags().Int("min-days", defaultIdleMinDays, "Days with spend needed to flag a resource")
+	idleCmd.Flags().String("end", "", "Day after the last one in the window (YYYY-MM-DD); defaults to today")
+	idleCmd.Flags().String("format", report.FormatCSV, "Output format: csv, json, or markdown")
+	idleCmd.Flags().String("out", "-", "Output file, or - for stdout")
+	idleCmd.Flags().Bool("live", false, "Fetch records from Vantage instead of reading the sink")
+
+	analyzeCmd.AddCommand(moversCmd)
+	analyzeCmd.AddCommand(idleCmd)
+	return analyzeCmd
+}
+
+// analysisEnd returns --end, or today.
+func analysisEnd(cmd *cobra.Command) (time.Time, error) {
+	endFlag, _ := cmd.Flags().GetString("end")
+	if endFlag == "" {
+		now := time.Now().UTC()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	end, err := time.Parse("2006-01-02", endFlag)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --end %q: expected YYYY-MM-DD", endFlag)
+	}
+	return end, nil
+}
+
+// analysisRecords returns the current records to analyze, read from the
+// sink or, with --live, fetched from Vantage for [start, end).
+func analysisRecords(cmd *cobra.Command, cfg *adapter.Config, start, end time.Time) ([]adapter.CostRecord, error) {
+	live, _ := cmd.Flags().GetBool("live")
+	if !live {
+		records, err := readSinkRecords(cmd.Context(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		return export.Reconcile(records), nil
+	}
+
+	cfg.StartDate = start
+	cfg.EndDate = &end
+	stopTracing, err := startTracing(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer stopTracing()
+
+	records, err := fetchLiveRecords(cmd.Context(), cfg, commandLogger(cmd))
+	if err != nil {
+		return nil, err
+	}
+	return export.Reconcile(records), nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bench"
+)
+
+func buildBenchCmd() *cobra.Command {
+	benchCmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure sync pipeline throughput on synthetic rows",
+		Long: `Push synthetic rows through mapping, normalization, idempotency hashing, and
+batching into a sink that discards them, and report rows per second and
+allocations. API pages are generated and cached by an untimed warm-up run,
+so the timed run measures the pipeline alone. No config or Vantage account
+is needed; --group-bys and --metrics shape the query hashed into each record.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var opts bench.Options
+			opts.Rows, _ = cmd.Flags().GetInt("rows")
+			opts.RowsPerBucket, _ = cmd.Flags().GetInt("rows-per-bucket")
+			opts.PageSize, _ = cmd.Flags().GetInt("page-size")
+			opts.BatchSize, _ = cmd.Flags().GetInt("batch-size")
+			opts.GroupBys, _ = cmd.Flags().GetStringSlice("group-bys")
+			opts.Metrics, _ = cmd.Flags().GetStringSlice("metrics")
+			opts.Seed, _ = cmd.Flags().GetInt64("seed")
+			opts.LineItemHash, _ = cmd.Flags().GetString("line-item-hash")
+
+			result, err := bench.Run(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return result.PrintJSON(cmd.OutOrStdout())
+			}
+			return result.Print(cmd.OutOrStdout())
+		},
+	}
+
+	benchCmd.Flags().Int("rows", bench.DefaultRows, "Rows to push through the pipeline, rounded up to whole days")
+	benchCmd.Flags().Int("rows-per-bucket", bench.DefaultRowsPerBucket, "Synthetic rows per day")
+	benchCmd.Flags().Int("batch-size", bench.DefaultBatchSize, "Records per sink write")
+	benchCmd.Flags().Int64("seed", 0, "Synthetic data seed")
+	benchCmd.Flags().String("line-item-hash", adapter.HashSHA256,
+		"Line item hash algorithm ("+strings.Join(adapter.HashAlgorithms(), ", ")+")")
+	benchCmd.Flags().Bool("json", false, "Print the result as JSON")
+
+	return benchCmd
+}
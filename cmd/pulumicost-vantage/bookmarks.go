@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+)
+
+// openBookmarkStore builds the store selected by the config's bookmarks
+// section. The returned close func must be called once the store is no
+// longer needed.
+func openBookmarkStore(ctx context.Context, cfg *adapter.Config) (adapter.BookmarkStore, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Bookmarks.Type {
+	case adapter.BookmarkStoreFile, "":
+		store, err := bookmark.NewFile(cfg.Bookmarks.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening file bookmark store: %w", err)
+		}
+		return store, noop, nil
+	case adapter.BookmarkStoreSQLite:
+		store, err := bookmark.NewSQLite(ctx, cfg.Bookmarks.Path, cfg.Bookmarks.Table)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening sqlite bookmark store: %w", err)
+		}
+		return store, store.Close, nil
+	case adapter.BookmarkStoreDynamoDB:
+		store, err := newDynamoDBBookmarkStore(cfg.Bookmarks)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening dynamodb bookmark store: %w", err)
+		}
+		return store, noop, nil
+	case adapter.BookmarkStoreMemory:
+		return bookmark.NewMemory(), noop, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported bookmark store: %s", cfg.Bookmarks.Type)
+	}
+}
+
+// newDynamoDBBookmarkStore builds a DynamoDB store over the client from
+// newDynamoDBClient. bookmarks.region overrides AWS_REGION.
+func newDynamoDBBookmarkStore(cfg adapter.BookmarkConfig) (*bookmark.DynamoDB, error) {
+	api, err := newDynamoDBClient(cfg.Region, "bookmarks.region")
+	if err != nil {
+		return nil, err
+	}
+	return bookmark.NewDynamoDB(api, cfg.Table)
+}
+
+// newDynamoDBClient builds a DynamoDB client from the standard AWS
+// environment variables (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN). A non-empty region, set by the config key regionKey,
+// overrides AWS_REGION.
+func newDynamoDBClient(region, regionKey string) (*dynamodb.Client, error) {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		return nil, fmt.Errorf("%s or AWS_REGION must be set", regionKey)
+	}
+
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	creds := aws.Credentials{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Source:          "environment",
+	}
+
+	return dynamodb.New(dynamodb.Options{
+		Region: region,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return creds, nil
+		}),
+	}), nil
+}
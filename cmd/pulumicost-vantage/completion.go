@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func buildCompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate a shell completion script",
+		Long: `Print a completion script for the given shell. Besides commands and flags, it
+completes --profile from the profiles in --config and the values of flags
+such as --granularity and --log-level.
+
+Bash (requires the bash-completion package):
+  source <(pulumicost-vantage completion bash)
+  pulumicost-vantage completion bash > /etc/bash_completion.d/pulumicost-vantage
+
+Zsh:
+  pulumicost-vantage completion zsh > "${fpath[1]}/_pulumicost-vantage"
+
+Fish:
+  pulumicost-vantage completion fish > ~/.config/fish/completions/pulumicost-vantage.fish
+
+PowerShell:
+  pulumicost-vantage completion powershell | Out-String | Invoke-Expression`,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, out := cmd.Root(), cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			case "powershell":
+				return root.GenPowerShellCompletionWithDesc(out)
+			default:
+				return fmt.Errorf("unsupported shell %q", args[0])
+			}
+		},
+	}
+}
+
+// registerFlagCompletions completes the values of the root command's
+// persistent flags.
+func registerFlagCompletions(rootCmd *cobra.Command) {
+	fixed := map[string][]string{
+		"granularity": {"day", "month"},
+		"log-level":   {"debug", "info", "warn", "error", "off"},
+		"log-format":  {logFormatConsole, logFormatJSON},
+		"sink-type":   adapter.SupportedSinkTypes(),
+	}
+	for flag, values := range fixed {
+		_ = rootCmd.RegisterFlagCompletionFunc(flag, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+	}
+
+	_ = rootCmd.RegisterFlagCompletionFunc("config",
+		func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+		})
+	_ = rootCmd.RegisterFlagCompletionFunc("profile",
+		func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			configPath, _ := cmd.Flags().GetString("config")
+			if configPath == "" {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			profiles, err := adapter.ListProfiles(configPath)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return profiles, cobra.ShellCompDirectiveNoFileComp
+		})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func buildConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+
+	renderCmd := &cobra.Command{
+		Use:   "render",
+		Short: "Print the effective config after extends and profiles are merged",
+		Long: `Print the config sections in effect once the files named by extends, the
+--profile (with the profiles it extends), and the override flags are merged
+in. Tokens, client secrets, DSNs, headers, and webhook URLs are masked.
+PULUMICOST_VANTAGE_* section variables are included, but
+PULUMICOST_VANTAGE_TOKEN and the other overrides applied while parsing are
+not.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			effective, err := adapter.EffectiveConfig(loadOptions(cmd))
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if asJSON {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(effective)
+			}
+			encoder := yaml.NewEncoder(out)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(effective); err != nil {
+				return fmt.Errorf("rendering config: %w", err)
+			}
+			return encoder.Close()
+		},
+	}
+	renderCmd.Flags().Bool("json", false, "Print the config as JSON instead of YAML")
+
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file against the config schema",
+		Long: `Check the --config file, and the files it extends, against the embedded JSON
+Schema. Reports unknown keys (typos the loader would silently ignore), values
+of the wrong type or outside the allowed set, and deprecated keys, each with
+its file and line. Exits non-zero when there are errors; deprecation warnings
+alone do not fail. No credentials or API access are needed; use the top-level
+validate command to check those.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if configPath == "" {
+				return errors.New("config validate requires --config")
+			}
+
+			issues, err := adapter.CheckConfigFile(configPath)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if asJSON {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				if issues == nil {
+					issues = []adapter.ConfigIssue{}
+				}
+				if err := encoder.Encode(issues); err != nil {
+					return err
+				}
+			} else {
+				for _, issue := range issues {
+					_, _ = fmt.Fprintln(out, issue)
+				}
+			}
+
+			var errorCount int
+			for _, issue := range issues {
+				if issue.Severity == adapter.IssueError {
+					errorCount++
+				}
+			}
+			if errorCount > 0 {
+				return fmt.Errorf("config has %d error(s)", errorCount)
+			}
+			if !asJSON {
+				_, _ = fmt.Fprintf(out, "%s: config is valid\n", configPath)
+			}
+			return nil
+		},
+	}
+	validateCmd.Flags().Bool("json", false, "Print the issues as JSON")
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the config file",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_, err := cmd.OutOrStdout().Write(adapter.ConfigSchema())
+			return err
+		},
+	}
+
+	configCmd.AddCommand(renderCmd, validateCmd, schemaCmd)
+	return configCmd
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+const (
+	costsFormatTable = "table"
+	costsFormatCSV   = "csv"
+	costsFormatJSON  = "json"
+)
+
+// costsRow is one row of costs output.
+type costsRow struct {
+	Date          string            `json:"date"`
+	Group         map[string]string `json:"group"`
+	Cost          float64           `json:"cost"`
+	UsageQuantity float64           `json:"usage_quantity,omitempty"`
+	UsageUnit     string            `json:"usage_unit,omitempty"`
+	Currency      string            `json:"currency,omitempty"`
+}
+
+func buildCostsCmd() *cobra.Command {
+	costsCmd := &cobra.Command{
+		Use:   "costs",
+		Short: "Query Vantage costs and print them",
+		Long: `Query costs for the configured workspace or cost report straight from Vantage
+and print them, to look at the data before setting up a sync. Nothing is
+written to the sink and no bookmark moves. The credentials, report, filter,
+and granularity come from the config (or flags and environment variables as
+usual); --group-by picks the dimensions to split costs by.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			formats := []string{costsFormatTable, costsFormatCSV, costsFormatJSON}
+			if !slices.Contains(formats, format) {
+				return fmt.Errorf("invalid --format %q (valid: %s)", format, strings.Join(formats, ", "))
+			}
+			groupBys, _ := cmd.Flags().GetStringSlice("group-by")
+			for _, gb := range groupBys {
+				if !slices.Contains(adapter.SupportedGroupBys(), gb) {
+					return fmt.Errorf("invalid --group-by value: %s (valid: %s)",
+						gb, strings.Join(adapter.SupportedGroupBys(), ", "))
+				}
+			}
+
+			start, end, err := reportPeriod(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			apiClient, err := newAPIClient(cfg, commandLogger(cmd))
+			if err != nil {
+				return fmt.Errorf("creating Vantage client: %w", err)
+			}
+
+			reportToken := cfg.CostReportToken
+			if reportToken == "" && cfg.CostReportName != "" {
+				if reportToken, err = adapter.ResolveCostReportName(cmd.Context(), apiClient,
+					cfg.WorkspaceToken, cfg.CostReportName); err != nil {
+					return err
+				}
+			}
+
+			query := client.Query{
+				WorkspaceToken:  cfg.WorkspaceToken,
+				CostReportToken: reportToken,
+				StartAt:         start,
+				EndAt:           end,
+				Granularity:     cfg.Granularity,
+				GroupBys:        groupBys,
+				Metrics:         []string{"cost", "usage"},
+				PageSize:        cfg.PageSize,
+				Filter:          cfg.Filter,
+			}
+			var rows []costsRow
+			for {
+				page, err := apiClient.Costs(cmd.Context(), query)
+				if err != nil {
+					return fmt.Errorf("querying costs: %w", err)
+				}
+				for _, row := range page.Data {
+					rows = append(rows, newCostsRow(row, groupBys))
+				}
+				if !page.HasMore || page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case costsFormatCSV:
+				return writeCostsCSV(out, rows, groupBys)
+			case costsFormatJSON:
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				if rows == nil {
+					rows = []costsRow{}
+				}
+				return encoder.Encode(rows)
+			default:
+				return writeCostsTable(out, rows, groupBys)
+			}
+		},
+	}
+
+	costsCmd.Flags().StringSlice("group-by", []string{"provider", "service"},
+		"Dimensions to split costs by (comma-separated)")
+	costsCmd.Flags().String("format", costsFormatTable, "Output format: table, csv, or json")
+	costsCmd.Flags().String("month", "", "Month to query (YYYY-MM); defaults to the previous month")
+	costsCmd.Flags().String("start", "", "First day to query (YYYY-MM-DD), instead of --month")
+	costsCmd.Flags().String("end", "", "Day after the last one to query (YYYY-MM-DD); defaults to today")
+	costsCmd.MarkFlagsMutuallyExclusive("month", "start")
+	costsCmd.MarkFlagsMutuallyExclusive("month", "end")
+	_ = costsCmd.RegisterFlagCompletionFunc("group-by",
+		cobra.FixedCompletions(adapter.SupportedGroupBys(), cobra.ShellCompDirectiveNoFileComp))
+	_ = costsCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(
+		[]string{costsFormatTable, costsFormatCSV, costsFormatJSON}, cobra.ShellCompDirectiveNoFileComp))
+
+	return costsCmd
+}
+
+// newCostsRow picks the groupBys dimensions out of row.
+func newCostsRow(row client.CostRow, groupBys []string) costsRow {
+	group := make(map[string]string, len(groupBys))
+	for _, gb := range groupBys {
+		switch gb {
+		case "provider":
+			group[gb] = row.Provider
+		case "service":
+			group[gb] = row.Service
+		case "account":
+			group[gb] = row.Account
+		case "billing_account":
+			group[gb] = row.BillingAccount
+		case "project":
+			group[gb] = row.Project
+		case "region":
+			group[gb] = row.Region
+		case "resource_id":
+			group[gb] = row.ResourceID
+		case "tags":
+			pairs := make([]string, 0, len(row.Tags))
+			for _, key := range slices.Sorted(maps.Keys(row.Tags)) {
+				pairs = append(pairs, key+"="+row.Tags[key])
+			}
+			group[gb] = strings.Join(pairs, ",")
+		}
+	}
+	return costsRow{
+		Date:          row.BucketStart.Format("2006-01-02"),
+		Group:         group,
+		Cost:          row.Cost,
+		UsageQuantity: row.UsageQuantity,
+		UsageUnit:     row.UsageUnit,
+		Currency:      row.Currency,
+	}
+}
+
+// writeCostsTable prints rows aligned in columns, followed by the total cost.
+func writeCostsTable(out io.Writer, rows []costsRow, groupBys []string) error {
+	w := tabwriter.NewWriter(out, 0, 0, tabPadding, ' ', 0)
+	header := []string{"DATE"}
+	for _, gb := range groupBys {
+		header = append(header, strings.ToUpper(gb))
+	}
+	header = append(header, "COST", "USAGE", "UNIT")
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	var total float64
+	for _, row := range rows {
+		fields := []string{row.Date}
+		for _, gb := range groupBys {
+			fields = append(fields, row.Group[gb])
+		}
+		fields = append(fields, fmt.Sprintf("%.2f", row.Cost), formatUsage(row.UsageQuantity), row.UsageUnit)
+		_, _ = fmt.Fprintln(w, strings.Join(fields, "\t"))
+		total += row.Cost
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "\n%d rows, total cost %.2f\n", len(rows), total)
+	return err
+}
+
+// writeCostsCSV writes rows as CSV with a header line.
+func writeCostsCSV(out io.Writer, rows []costsRow, groupBys []string) error {
+	w := csv.NewWriter(out)
+	header := append(append([]string{"date"}, groupBys...), "cost", "usage_quantity", "usage_unit", "currency")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{row.Date}
+		for _, gb := range groupBys {
+			record = append(record, row.Group[gb])
+		}
+		record = append(record,
+			strconv.FormatFloat(row.Cost, 'f', -1, 64),
+			strconv.FormatFloat(row.UsageQuantity, 'f', -1, 64),
+			row.UsageUnit,
+			row.Currency,
+		)
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// formatUsage prints a usage quantity, leaving zero blank.
+func formatUsage(quantity float64) string {
+	if quantity == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
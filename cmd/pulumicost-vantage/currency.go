@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/currency"
+)
+
+// newCurrencyConverter builds the converter selected by target_currency and
+// fx_source, or returns nil when no target currency is configured.
+func newCurrencyConverter(cfg *adapter.Config) (adapter.CurrencyConverter, error) {
+	if cfg.TargetCurrency == "" {
+		return nil, nil //nolint:nilnil // conversion is disabled
+	}
+
+	var provider currency.RateProvider
+	switch cfg.FXSource {
+	case adapter.FXSourceStatic:
+		static, err := currency.NewStatic(cfg.FXBaseCurrency, cfg.FXRates)
+		if err != nil {
+			return nil, fmt.Errorf("loading fx_rates: %w", err)
+		}
+		provider = static
+	case adapter.FXSourceFile:
+		file, err := currency.LoadFile(cfg.FXRatesFile)
+		if err != nil {
+			return nil, err
+		}
+		provider = file
+	case adapter.FXSourceECB, "":
+		provider = currency.NewECB(&http.Client{Timeout: cfg.Timeout}, "")
+	default:
+		return nil, fmt.Errorf("unsupported fx_source: %s", cfg.FXSource)
+	}
+
+	converter, err := currency.NewConverter(provider, cfg.TargetCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("creating currency converter: %w", err)
+	}
+	return converter, nil
+}
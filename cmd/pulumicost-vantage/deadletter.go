@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/deadletter"
+)
+
+// deadLetterQueue is a dead-letter queue whose letters can be read back and
+// replaced after a replay.
+type deadLetterQueue interface {
+	adapter.DeadLetterQueue
+	DeadLetters(ctx context.Context) ([]adapter.DeadLetter, error)
+	ReplaceDeadLetters(ctx context.Context, letters []adapter.DeadLetter) error
+}
+
+// openDeadLetterQueue builds the queue selected by the config's dead_letter
+// section. It returns a nil queue when dead-lettering is off. The returned
+// close func must be called once the queue is no longer needed.
+func openDeadLetterQueue(ctx context.Context, cfg *adapter.Config) (deadLetterQueue, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.DeadLetter.Type {
+	case adapter.DeadLetterNone, "":
+		return nil, noop, nil
+	case adapter.DeadLetterFile:
+		queue, err := deadletter.NewFile(cfg.DeadLetter.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening file dead-letter queue: %w", err)
+		}
+		return queue, noop, nil
+	case adapter.DeadLetterSQLite:
+		queue, err := deadletter.NewSQLite(ctx, cfg.DeadLetter.Path, cfg.DeadLetter.Table)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening sqlite dead-letter queue: %w", err)
+		}
+		return queue, queue.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported dead-letter type: %s", cfg.DeadLetter.Type)
+	}
+}
+
+func buildReplayDLQCmd() *cobra.Command {
+	replayCmd := &cobra.Command{
+		Use:   "replay-dlq",
+		Short: "Write the records in the dead-letter queue to the sink again",
+		Long: `Write the records kept in the configured dead-letter queue to the configured
+sink again, retrying as a sync does. Records written are removed from the
+queue; those still failing stay in it with their latest error and attempt
+count.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return forEachProfile(cmd, func(cfg *adapter.Config) error {
+				return runReplayDLQ(cmd, cfg)
+			})
+		},
+	}
+	replayCmd.Flags().Bool("all-profiles", false, "Replay for every profile in the config, one after another")
+	return replayCmd
+}
+
+// runReplayDLQ replays the dead letters of one profile.
+func runReplayDLQ(cmd *cobra.Command, cfg *adapter.Config) (err error) {
+	ctx := cmd.Context()
+	queue, closeQueue, err := openDeadLetterQueue(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if queue == nil {
+		return errors.New("no dead-letter queue configured; set dead_letter.type to file or sqlite")
+	}
+	defer func() {
+		if closeErr := closeQueue(); closeErr != nil && err == nil {
+			err = fmt.Errorf("closing dead-letter queue: %w", closeErr)
+		}
+	}()
+
+	letters, err := queue.DeadLetters(ctx)
+	if err != nil {
+		return fmt.Errorf("reading dead-letter queue: %w", err)
+	}
+	out := cmd.OutOrStdout()
+	if len(letters) == 0 {
+		_, _ = fmt.Fprintf(out, "%sNo dead letters to replay\n", profilePrefix(cfg))
+		return nil
+	}
+
+	s, closeSink, err := openSink(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeSink() }()
+
+	// The queue is replaced with what is still failing even when the replay
+	// stopped early, so records already written are not written twice.
+	remaining, replayErr := adapter.New(nil, commandLogger(cmd)).ReplayDeadLetters(ctx, *cfg, s, letters)
+	if replaceErr := queue.ReplaceDeadLetters(ctx, remaining); replaceErr != nil {
+		return errors.Join(replayErr, fmt.Errorf("updating dead-letter queue: %w", replaceErr))
+	}
+	if replayErr != nil {
+		return replayErr
+	}
+
+	_, _ = fmt.Fprintf(out, "%sReplayed %d records, %d still failing\n",
+		profilePrefix(cfg), len(letters)-len(remaining), len(remaining))
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const diagnosticsReportFilePerm = 0o600
+
+// writeDiagnosticsReport writes the sync's detailed diagnostics report to
+// diagnostics.report_path, when set. The report is written whether or not
+// the sync succeeded; a failed write is logged as a warning and never fails
+// the sync.
+func writeDiagnosticsReport(cmd *cobra.Command, cfg *adapter.Config, summary *adapter.DiagnosticsSummary) {
+	report := summary.Report(time.Now())
+	if cfg.Diagnostics.ReportPath == "" || report == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := adapter.WriteDiagnosticsReport(&buf, report, cfg.Diagnostics.ReportFormat)
+	if err == nil {
+		err = os.WriteFile(cfg.Diagnostics.ReportPath, buf.Bytes(), diagnosticsReportFilePerm)
+	}
+	if err != nil {
+		ctx := cmd.Context()
+		commandLogger(cmd).Warn(ctx, "Diagnostics report not written", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "diagnostics_report",
+			"attempt":   0,
+			"error":     fmt.Errorf("writing diagnostics report: %w", err),
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/preflight"
+)
+
+// doctorOptions holds the doctor command's thresholds.
+type doctorOptions struct {
+	maxDataAge     time.Duration
+	maxClockSkew   time.Duration
+	maxBookmarkAge time.Duration
+	qualityRuns    int
+	minQuality     float64
+}
+
+func buildDoctorCmd() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Run end-to-end preflight diagnostics",
+		Long: `Check token scopes, rate-limit headroom, clock skew, report token validity,
+provider integration status and data freshness, sink writability, bookmark
+state, and the data quality trend over recent syncs, printing a table of checks with severities. Useful for debugging
+scheduled sync failures. Exits non-zero when any check fails.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			var opts doctorOptions
+			opts.maxDataAge, _ = cmd.Flags().GetDuration("max-data-age")
+			opts.maxClockSkew, _ = cmd.Flags().GetDuration("max-clock-skew")
+			opts.maxBookmarkAge, _ = cmd.Flags().GetDuration("max-bookmark-age")
+			opts.qualityRuns, _ = cmd.Flags().GetInt("quality-runs")
+			opts.minQuality, _ = cmd.Flags().GetFloat64("min-quality-score")
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			report, err := runDoctor(cmd, cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				err = report.PrintJSON(cmd.OutOrStdout())
+			} else {
+				err = report.Print(cmd.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+			if report.Failed() {
+				return errors.New("one or more doctor checks failed")
+			}
+			return nil
+		},
+	}
+
+	doctorCmd.Flags().Duration("max-data-age", preflight.DefaultMaxDataAge,
+		"Warn when an integration has not synced data for longer than this")
+	doctorCmd.Flags().Duration("max-clock-skew", preflight.DefaultMaxClockSkew,
+		"Warn when the local clock differs from Vantage by more than this")
+	doctorCmd.Flags().Duration("max-bookmark-age", preflight.DefaultMaxBookmarkAge,
+		"Warn when the newest sync bookmark is older than this")
+	doctorCmd.Flags().Int("quality-runs", preflight.DefaultQualityRuns,
+		"Number of recent syncs shown in the data quality trend")
+	doctorCmd.Flags().Float64("min-quality-score", preflight.DefaultMinQualityScore,
+		"Warn when the latest sync's data quality score is below this")
+	doctorCmd.Flags().Bool("json", false, "Print the report as JSON")
+
+	return doctorCmd
+}
+
+// runDoctor runs every doctor check. API-dependent checks are skipped when
+// the API is unreachable, since they would all fail with the same error.
+func runDoctor(cmd *cobra.Command, cfg *adapter.Config, opts doctorOptions) (*preflight.Report, error) {
+	ctx := cmd.Context()
+	now := time.Now().UTC()
+	report := &preflight.Report{}
+
+	apiClient, err := newAPIClient(cfg, commandLogger(cmd))
+	if err != nil {
+		return nil, fmt.Errorf("creating Vantage client: %w", err)
+	}
+
+	apiResult := preflight.CheckAPI(ctx, apiClient)
+	report.Add(apiResult)
+	if apiResult.Status == preflight.StatusPass {
+		status := apiClient.ServerStatus()
+		report.Add(
+			preflight.CheckRateLimit(status, cfg.RateLimitRemainingThreshold),
+			preflight.CheckClockSkew(status, opts.maxClockSkew),
+		)
+		report.Add(preflight.CheckScopes(ctx, apiClient, cfg)...)
+		report.Add(preflight.CheckTokens(ctx, apiClient, cfg)...)
+		report.Add(preflight.CheckIntegrations(ctx, apiClient, cfg.WorkspaceToken, opts.maxDataAge, now)...)
+	}
+
+	s, closeSink, err := openSink(cfg)
+	if err != nil {
+		report.Add(preflight.Result{
+			Name:        "sink",
+			Status:      preflight.StatusFail,
+			Message:     err.Error(),
+			Remediation: "Check the sink section of the config file",
+		})
+		return report, nil
+	}
+	defer func() { _ = closeSink() }()
+	report.Add(preflight.CheckSink(ctx, s))
+
+	store, closeStore, err := openBookmarkStore(ctx, cfg)
+	if err != nil {
+		report.Add(preflight.Result{
+			Name:        "bookmarks",
+			Status:      preflight.StatusFail,
+			Message:     err.Error(),
+			Remediation: "Check the bookmarks section of the config file",
+		})
+		return report, nil
+	}
+	defer func() { _ = closeStore() }()
+	report.Add(preflight.CheckBookmarks(ctx, store, opts.maxBookmarkAge, now))
+	report.Add(preflight.CheckQualityTrend(ctx, store, cfg, opts.qualityRuns, opts.minQuality))
+
+	return report, nil
+}
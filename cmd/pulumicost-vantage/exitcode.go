@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/lock"
+)
+
+// Process exit codes. Schedulers can retry on exitTransient and alert on the
+// others.
+const (
+	exitOK = 0
+	// exitConfig covers invalid config or flags, and any failure not
+	// classified below.
+	exitConfig      = 1
+	exitAuth        = 2
+	exitTransient   = 3
+	exitSink        = 4
+	exitDataQuality = 5
+)
+
+// errDataQuality is returned under --strict when a sync completed but some
+// records carried diagnostics issues.
+var errDataQuality = errors.New("completed with data-quality issues")
+
+// exitCode maps a command error to the process exit code. When several
+// profiles failed, the most actionable code wins: auth, then sink, then
+// transient, then data quality.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case client.IsAuthError(err):
+		return exitAuth
+	case errors.Is(err, client.ErrNoFixture):
+		// A replay that was never recorded will not succeed on retry.
+		return exitConfig
+	case errors.Is(err, adapter.ErrSink):
+		return exitSink
+	case client.IsTransientError(err),
+		errors.Is(err, lock.ErrHeld),
+		errors.Is(err, adapter.ErrSyncLockLost),
+		errors.Is(err, errMaxDuration):
+		return exitTransient
+	case errors.Is(err, errDataQuality):
+		return exitDataQuality
+	default:
+		return exitConfig
+	}
+}
+
+// checkDataQuality returns errDataQuality under --strict when any record
+// synced had diagnostics issues.
+func checkDataQuality(cmd *cobra.Command, diagnostics *adapter.DiagnosticsSummary) error {
+	strict, _ := cmd.Flags().GetBool("strict")
+	if !strict || !diagnostics.HasIssues() {
+		return nil
+	}
+	return fmt.Errorf(
+		"%w: %d of %d records had missing fields or warnings",
+		errDataQuality,
+		diagnostics.RecordsWithIssues,
+		diagnostics.TotalRecords,
+	)
+}
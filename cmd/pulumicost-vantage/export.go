@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/export"
+)
+
+const exportFilePerm = 0o600
+
+func buildExportCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export cost records in interchange formats",
+		Long: `Convert cost records into formats read by other FinOps tools. Records are read
+from the configured sink, reduced to their current state (restatements and
+deletions applied), or fetched live from Vantage with --live.`,
+	}
+
+	focusCmd := &cobra.Command{
+		Use:   "focus",
+		Short: "Write records as a FOCUS 1.2 CSV",
+		Long: `Write cost records as CSV with the FOCUS 1.2 column names (BilledCost,
+EffectiveCost, ChargePeriodStart, ...). Forecast, budget, and recommendation
+records are not charges and are left out.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			records, err := exportRecords(cmd, cfg)
+			if err != nil {
+				return err
+			}
+
+			return writeExport(cmd, func(out io.Writer) (int, error) {
+				w := export.NewFOCUSWriter(out, cfg.Granularity)
+				written, writeErr := w.Write(records)
+				if writeErr != nil {
+					return written, writeErr
+				}
+				return written, w.Flush()
+			})
+		},
+	}
+
+	curCmd := &cobra.Command{
+		Use:   "cur",
+		Short: "Write records in the AWS Cost and Usage Report layout",
+		Long: `Write cost records as CSV with AWS Cost and Usage Report columns (lineItem/*,
+product/*, pricing/*, resourceTags/*), so queries written against CUR can read
+Vantage-sourced data. One resourceTags/ column is added per tag key.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			records, err := exportRecords(cmd, cfg)
+			if err != nil {
+				return err
+			}
+
+			return writeExport(cmd, func(out io.Writer) (int, error) {
+				return export.WriteCUR(out, records, cfg.Granularity)
+			})
+		},
+	}
+
+	exportCmd.PersistentFlags().String("out", "-", "Output file, or - for stdout")
+	exportCmd.PersistentFlags().Bool("live", false, "Fetch records from Vantage instead of reading the sink")
+	exportCmd.PersistentFlags().String("start", "",
+		"With --live, first day to fetch (YYYY-MM-DD); defaults to params.start_date")
+	exportCmd.PersistentFlags().String("end", "",
+		"With --live, day after the last one to fetch (YYYY-MM-DD); defaults to params.end_date or today")
+
+	exportCmd.AddCommand(focusCmd)
+	exportCmd.AddCommand(curCmd)
+	return exportCmd
+}
+
+// exportRecords returns the current records to export, read from the sink or,
+// with --live, fetched from Vantage.
+func exportRecords(cmd *cobra.Command, cfg *adapter.Config) ([]adapter.CostRecord, error) {
+	live, _ := cmd.Flags().GetBool("live")
+	if !live {
+		if cmd.Flags().Changed("start") || cmd.Flags().Changed("end") {
+			return nil, errors.New("--start and --end require --live")
+		}
+		reader, err := openRecordReader(cfg)
+		if err != nil {
+			return nil, err
+		}
+		var records []adapter.CostRecord
+		if err := reader.ReadRecords(cmd.Context(), func(record adapter.CostRecord) error {
+			records = append(records, record)
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("reading records: %w", err)
+		}
+		return export.Reconcile(records), nil
+	}
+
+	if err := applyExportRange(cmd, cfg); err != nil {
+		return nil, err
+	}
+	stopTracing, err := startTracing(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer stopTracing()
+
+	records, err := fetchLiveRecords(cmd.Context(), cfg, commandLogger(cmd))
+	if err != nil {
+		return nil, err
+	}
+	return export.Reconcile(records), nil
+}
+
+// applyExportRange sets the live fetch range from --start and --end, falling
+// back to the configured range and then to today.
+func applyExportRange(cmd *cobra.Command, cfg *adapter.Config) error {
+	if start, _ := cmd.Flags().GetString("start"); start != "" {
+		t, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return fmt.Errorf("invalid --start %q: expected YYYY-MM-DD", start)
+		}
+		cfg.StartDate = t
+	}
+	if end, _ := cmd.Flags().GetString("end"); end != "" {
+		t, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			return fmt.Errorf("invalid --end %q: expected YYYY-MM-DD", end)
+		}
+		cfg.EndDate = &t
+	}
+	if cfg.EndDate == nil {
+		now := time.Now().UTC()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		cfg.EndDate = &today
+	}
+	if !cfg.EndDate.After(cfg.StartDate) {
+		return fmt.Errorf("export range is empty: %s to %s",
+			cfg.StartDate.Format("2006-01-02"), cfg.EndDate.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// fetchLiveRecords syncs cfg's date range into memory. Sync state is kept in
+// memory too, so a live export never moves the persisted bookmarks.
+func fetchLiveRecords(ctx context.Context, cfg *adapter.Config, logger client.Logger) ([]adapter.CostRecord, error) {
+	apiClient, err := newAPIClient(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("creating Vantage client: %w", err)
+	}
+
+	converter, err := newCurrencyConverter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := &recordCollector{}
+	a := adapter.New(apiClient, logger)
+	a.SetBookmarkStore(bookmark.NewMemory())
+	a.SetCurrencyConverter(converter)
+	if err := a.Sync(ctx, *cfg, collector); err != nil {
+		return nil, err
+	}
+	return collector.records, nil
+}
+
+// writeExport runs write against the --out destination and reports the row
+// count on stderr, keeping stdout clean for the export itself.
+func writeExport(cmd *cobra.Command, write func(io.Writer) (int, error)) (err error) {
+	out, _ := cmd.Flags().GetString("out")
+
+	var w io.Writer = cmd.OutOrStdout()
+	if out != "-" {
+		file, openErr := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, exportFilePerm)
+		if openErr != nil {
+			return fmt.Errorf("creating %s: %w", out, openErr)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("closing %s: %w", out, closeErr)
+			}
+		}()
+		w = file
+	}
+
+	written, err := write(w)
+	if err != nil {
+		return err
+	}
+	if out != "-" {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d rows to %s\n", written, out)
+	}
+	return nil
+}
+
+// recordCollector is a Sink that keeps records in memory.
+type recordCollector struct {
+	records []adapter.CostRecord
+}
+
+// WriteRecords implements adapter.Sink.
+func (c *recordCollector) WriteRecords(_ context.Context, records []adapter.CostRecord) error {
+	c.records = append(c.records, records...)
+	return nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/preflight"
+)
+
+const (
+	defaultInitOutput = "config.yaml"
+	tokenEnvVar       = "PULUMICOST_VANTAGE_TOKEN"
+)
+
+// starterConfig is the config file init writes.
+type starterConfig struct {
+	Version     string            `yaml:"version"`
+	Source      string            `yaml:"source"`
+	Credentials map[string]string `yaml:"credentials"`
+	Params      starterParams     `yaml:"params"`
+	Sink        map[string]any    `yaml:"sink"`
+}
+
+type starterParams struct {
+	WorkspaceToken  string   `yaml:"workspace_token,omitempty"`
+	CostReportToken string   `yaml:"cost_report_token,omitempty"`
+	Granularity     string   `yaml:"granularity"`
+	GroupBys        []string `yaml:"group_bys,flow"`
+	Metrics         []string `yaml:"metrics,flow"`
+}
+
+func buildInitCmd() *cobra.Command {
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a starter config file interactively",
+		Long: `Prompt for the API token, check that it can reach the Vantage API, then pick a
+workspace and cost report from the ones the token can see, the granularity,
+and the sink, and write a starter config file.
+
+When PULUMICOST_VANTAGE_TOKEN is set its token is used without prompting.
+The token is only written into the file when asked to; otherwise the file
+reads it from PULUMICOST_VANTAGE_TOKEN.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			force, _ := cmd.Flags().GetBool("force")
+			if _, err := os.Stat(output); err == nil && !force {
+				return fmt.Errorf("%s already exists; pass --force to overwrite it", output)
+			}
+			return runInit(cmd, output)
+		},
+	}
+
+	initCmd.Flags().StringP("output", "o", defaultInitOutput, "Path of the config file to write")
+	initCmd.Flags().Bool("force", false, "Overwrite the output file if it exists")
+
+	return initCmd
+}
+
+func runInit(cmd *cobra.Command, output string) error {
+	ctx := cmd.Context()
+	out := cmd.OutOrStdout()
+	p := &prompter{in: bufio.NewReader(cmd.InOrStdin()), out: out}
+	cfg := starterConfig{
+		Version:     "0.1",
+		Source:      "vantage",
+		Credentials: map[string]string{"token_env": tokenEnvVar},
+		Params: starterParams{
+			GroupBys: []string{"provider", "service", "account", "region"},
+			Metrics:  []string{"cost", "usage"},
+		},
+	}
+
+	token := os.Getenv(tokenEnvVar)
+	if token != "" {
+		_, _ = fmt.Fprintf(out, "Using the API token in %s.\n", tokenEnvVar)
+	} else {
+		var err error
+		if token, err = p.ask("Vantage API token", ""); err != nil {
+			return err
+		}
+		if token == "" {
+			return errors.New("an API token is required")
+		}
+		save, err := p.confirm(fmt.Sprintf("Save the token in %s (otherwise export it as %s)?", output, tokenEnvVar), false)
+		if err != nil {
+			return err
+		}
+		if save {
+			cfg.Credentials = map[string]string{"token": token}
+		}
+	}
+
+	apiClient, err := client.New(client.DefaultConfig(token))
+	if err != nil {
+		return fmt.Errorf("creating Vantage client: %w", err)
+	}
+	_, _ = fmt.Fprint(out, "Checking API access... ")
+	if result := preflight.CheckAPI(ctx, apiClient); result.Status != preflight.StatusPass {
+		_, _ = fmt.Fprintln(out, "failed")
+		if result.Remediation != "" {
+			return fmt.Errorf("%s; %s", result.Message, result.Remediation)
+		}
+		return errors.New(result.Message)
+	}
+	_, _ = fmt.Fprintln(out, "ok")
+
+	workspaces, err := apiClient.ListWorkspaces(ctx)
+	if err != nil {
+		return fmt.Errorf("listing workspaces: %w", err)
+	}
+	if len(workspaces) == 0 {
+		return errors.New("the API token cannot see any workspaces")
+	}
+	names := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		names[i] = fmt.Sprintf("%s (%s)", ws.Name, ws.Token)
+	}
+	choice, err := p.choose("Workspace", names, 0)
+	if err != nil {
+		return err
+	}
+	workspace := workspaces[choice]
+
+	reports, err := apiClient.ListCostReports(ctx, workspace.Token)
+	if err != nil {
+		return fmt.Errorf("listing cost reports: %w", err)
+	}
+	options := []string{"Whole workspace (no cost report)"}
+	for _, report := range reports {
+		options = append(options, fmt.Sprintf("%s (%s)", report.Title, report.Token))
+	}
+	if choice, err = p.choose("Cost report", options, 0); err != nil {
+		return err
+	}
+	if choice == 0 {
+		cfg.Params.WorkspaceToken = workspace.Token
+	} else {
+		cfg.Params.CostReportToken = reports[choice-1].Token
+	}
+
+	granularities := []string{"day", "month"}
+	if choice, err = p.choose("Granularity", granularities, 0); err != nil {
+		return err
+	}
+	cfg.Params.Granularity = granularities[choice]
+
+	sinkTypes := adapter.SupportedSinkTypes()
+	if choice, err = p.choose("Sink type", sinkTypes, 0); err != nil {
+		return err
+	}
+	sinkPath, err := p.ask("Sink path", "./data")
+	if err != nil {
+		return err
+	}
+	cfg.Sink = map[string]any{"type": sinkTypes[choice], "path": sinkPath}
+	switch sinkTypes[choice] {
+	case adapter.SinkTypeBigQuery:
+		for _, key := range []string{"project", "dataset"} {
+			value, askErr := p.ask("BigQuery "+key, "")
+			if askErr != nil {
+				return askErr
+			}
+			cfg.Sink[key] = value
+		}
+	case adapter.SinkTypeKafka:
+		brokers, askErr := p.ask("Kafka brokers (comma-separated)", "localhost:9092")
+		if askErr != nil {
+			return askErr
+		}
+		list := strings.Split(brokers, ",")
+		for i := range list {
+			list[i] = strings.TrimSpace(list[i])
+		}
+		cfg.Sink["brokers"] = list
+	case adapter.SinkTypeClickHouse:
+		endpoint, askErr := p.ask("ClickHouse HTTP URL", "http://localhost:8123")
+		if askErr != nil {
+			return askErr
+		}
+		cfg.Sink["url"] = endpoint
+	}
+
+	if err := writeStarterConfig(output, cfg); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "\nWrote %s. Next, check it and run a first sync:\n", output)
+	if cfg.Credentials["token"] == "" && os.Getenv(tokenEnvVar) == "" {
+		_, _ = fmt.Fprintf(out, "  export %s=<token>\n", tokenEnvVar)
+	}
+	_, _ = fmt.Fprintf(out, "  pulumicost-vantage validate --config %s\n", output)
+	_, _ = fmt.Fprintf(out, "  pulumicost-vantage pull --config %s\n", output)
+	return nil
+}
+
+// writeStarterConfig writes cfg to path, readable only by the owner since it
+// may hold the token.
+func writeStarterConfig(path string, cfg starterConfig) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("rendering config: %w", err)
+	}
+	header := "# Generated by pulumicost-vantage init; see docs/CONFIG.md for every setting.\n"
+	if err := os.WriteFile(path, append([]byte(header), data...), 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// prompter asks questions on out and reads the answers, one line each,
+// from in.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask returns the answer to question, or def for an empty answer.
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		_, _ = fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		_, _ = fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("reading answer: %w", err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// confirm asks a yes/no question.
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := p.ask(fmt.Sprintf("%s (%s)", question, hint), "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// choose lists options, numbered from 1, and returns the index of the one
+// picked. A single option is picked without asking.
+func (p *prompter) choose(question string, options []string, def int) (int, error) {
+	if len(options) == 1 {
+		_, _ = fmt.Fprintf(p.out, "%s: %s\n", question, options[0])
+		return 0, nil
+	}
+
+	_, _ = fmt.Fprintf(p.out, "%s:\n", question)
+	for i, option := range options {
+		_, _ = fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+	}
+	for {
+		answer, err := p.ask("Choose", strconv.Itoa(def+1))
+		if err != nil {
+			return 0, err
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+		_, _ = fmt.Fprintf(p.out, "Enter a number from 1 to %d.\n", len(options))
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	// Registers the "pgx" database/sql driver for Postgres advisory locks.
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/lock"
+)
+
+// openLocker builds the locker selected by the config's lock section. It
+// returns a nil locker when locking is off. The returned close func must be
+// called once the locker is no longer needed.
+func openLocker(cfg *adapter.Config) (lock.Locker, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Lock.Type {
+	case adapter.LockTypeNone, "":
+		return nil, noop, nil
+	case adapter.LockTypeFile:
+		locker, err := lock.NewFile(cfg.Lock.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening file lock: %w", err)
+		}
+		return locker, noop, nil
+	case adapter.LockTypePostgres:
+		db, err := sql.Open("pgx", cfg.Lock.DSN)
+		if err != nil {
+			// The driver error may echo the DSN, password included.
+			return nil, nil, errors.New("opening postgres lock: invalid lock.dsn")
+		}
+		locker, err := lock.NewPostgres(db)
+		if err != nil {
+			_ = db.Close()
+			return nil, nil, fmt.Errorf("opening postgres lock: %w", err)
+		}
+		return locker, db.Close, nil
+	case adapter.LockTypeDynamoDB:
+		api, err := newDynamoDBClient(cfg.Lock.Region, "lock.region")
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening dynamodb lock: %w", err)
+		}
+		locker, err := lock.NewDynamoDB(api, cfg.Lock.Table, time.Duration(cfg.Lock.LeaseSeconds)*time.Second)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening dynamodb lock: %w", err)
+		}
+		return locker, noop, nil
+	case adapter.LockTypeMemory:
+		return lock.NewMemory(), noop, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported lock type: %s", cfg.Lock.Type)
+	}
+}
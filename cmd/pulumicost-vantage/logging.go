@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// Log formats accepted by --log-format.
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+)
+
+// loggerKey is the context key under which a command's logger is stored.
+type loggerKey struct{}
+
+// logLevelKey is the context key under which the logger's level is stored.
+type logLevelKey struct{}
+
+// setupLogger builds the logger selected by --log-level and --log-format and
+// attaches it to cmd's context. Logs go to stderr, leaving stdout for the
+// command's output, and every field is redacted first.
+func setupLogger(cmd *cobra.Command) error {
+	levelName, _ := cmd.Flags().GetString("log-level")
+	level, err := client.ParseLevel(levelName)
+	if err != nil {
+		return fmt.Errorf("invalid --log-level: %w", err)
+	}
+
+	var logger client.Logger
+	format, _ := cmd.Flags().GetString("log-format")
+	switch strings.ToLower(format) {
+	case logFormatConsole:
+		logger = client.NewConsoleLogger(cmd.ErrOrStderr(), client.LevelDebug)
+	case logFormatJSON:
+		logger = client.NewJSONLogger(cmd.ErrOrStderr(), client.LevelDebug)
+	default:
+		return fmt.Errorf("invalid --log-format %q (valid: %s, %s)", format, logFormatConsole, logFormatJSON)
+	}
+	levelVar := &client.LevelVar{}
+	levelVar.Set(level)
+	logger = client.NewLevelVarFilter(logger, levelVar)
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, logLevelKey{}, levelVar)
+	cmd.SetContext(context.WithValue(ctx, loggerKey{}, client.NewRedactingLogger(logger)))
+	return nil
+}
+
+// commandLogLevel returns the level of the logger set up for cmd, which
+// long-running commands may change, or nil when there is none.
+func commandLogLevel(cmd *cobra.Command) *client.LevelVar {
+	if cmd.Context() != nil {
+		if level, ok := cmd.Context().Value(logLevelKey{}).(*client.LevelVar); ok {
+			return level
+		}
+	}
+	return nil
+}
+
+// commandLogger returns the logger set up for cmd, or a no-op logger when
+// there is none.
+func commandLogger(cmd *cobra.Command) client.Logger {
+	if cmd.Context() != nil {
+		if logger, ok := cmd.Context().Value(loggerKey{}).(client.Logger); ok {
+			return logger
+		}
+	}
+	return client.NewNoopLogger()
+}
@@ -3,7 +3,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 
@@ -15,6 +14,7 @@ var version = "dev"
 
 const (
 	defaultBackfillMonths = 12
+	defaultForecastMonths = 1
 )
 
 func buildRootCmd() *cobra.Command {
@@ -24,51 +24,83 @@ func buildRootCmd() *cobra.Command {
 		Long: `A Go-based adapter that fetches normalized cost/usage data from Vantage's REST API
 and maps it into PulumiCost's internal schema with FinOps FOCUS 1.2 fields.`,
 		Version: version,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return setupLogger(cmd)
+		},
 	}
 
 	pullCmd := &cobra.Command{
 		Use:   "pull",
 		Short: "Perform incremental cost data sync",
-		Long:  `Fetch cost data incrementally using bookmarks. Defaults to D-3 to D-1 lag window.`,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			// TODO: implement pull logic
-			return errors.New("pull command not yet implemented")
+		Long: `Fetch cost data incrementally using bookmarks. Defaults to D-3 to D-1 lag window,
+widened by params.restatement_window_days to re-fetch restated costs.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runPull(cmd)
 		},
 	}
 
 	backfillCmd := &cobra.Command{
 		Use:   "backfill",
 		Short: "Backfill historical cost data",
-		Long:  `Fetch historical cost data for a specified number of months.`,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			// TODO: implement backfill logic
-			return errors.New("backfill command not yet implemented")
+		Long: `Fetch historical cost data for a specified number of months. Month chunks are
+checkpointed in the bookmark store, so re-running a failed backfill skips
+chunks that already completed.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runBackfill(cmd)
 		},
 	}
 
 	forecastCmd := &cobra.Command{
 		Use:   "forecast",
 		Short: "Generate forecast snapshot",
-		Long:  `Fetch and store forecast data as a separate metric family.`,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			// TODO: implement forecast logic
-			return errors.New("forecast command not yet implemented")
+		Long: `Fetch the cost report's forecast and store it as a snapshot with
+metric_type "forecast". Defaults to today through the end of next month;
+each day's snapshot is kept alongside earlier ones.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runForecast(cmd)
 		},
 	}
 
 	// Add common flags
-	rootCmd.PersistentFlags().String("config", "", "Path to configuration file")
-	if err := rootCmd.MarkPersistentFlagRequired("config"); err != nil {
-		panic(err)
-	}
+	rootCmd.PersistentFlags().String("config", "", "Path to configuration file (optional when configured through PULUMICOST_VANTAGE_* variables)")
+	rootCmd.PersistentFlags().String("profile", "", "Config profile to use instead of the top-level settings")
+	rootCmd.PersistentFlags().String("log-level", "info", "Minimum level logged to stderr: debug, info, warn, error, or off")
+	rootCmd.PersistentFlags().String("log-format", logFormatConsole, "Log format: console or json")
+	addOverrideFlags(rootCmd.PersistentFlags())
+	registerFlagCompletions(rootCmd)
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	// Add commands
 	rootCmd.AddCommand(pullCmd)
 	rootCmd.AddCommand(backfillCmd)
 	rootCmd.AddCommand(forecastCmd)
+	rootCmd.AddCommand(buildServeCmd())
+	rootCmd.AddCommand(buildWorkspacesCmd())
+	rootCmd.AddCommand(buildRecommendationsCmd())
+	rootCmd.AddCommand(buildDoctorCmd())
+	rootCmd.AddCommand(buildValidateCmd())
+	rootCmd.AddCommand(buildConfigCmd())
+	rootCmd.AddCommand(buildExportCmd())
+	rootCmd.AddCommand(buildReportCmd())
+	rootCmd.AddCommand(buildForecastVarianceCmd())
+	rootCmd.AddCommand(buildAnalyzeCmd())
+	rootCmd.AddCommand(buildCostsCmd())
+	rootCmd.AddCommand(buildQueryCmd())
+	rootCmd.AddCommand(buildInitCmd())
+	rootCmd.AddCommand(buildBenchCmd())
+	rootCmd.AddCommand(buildReplayDLQCmd())
+	rootCmd.AddCommand(buildCompletionCmd())
 
 	// Add command-specific flags
 	backfillCmd.Flags().Int("months", defaultBackfillMonths, "Number of months to backfill")
+	forecastCmd.Flags().Int("months", defaultForecastMonths, "Number of months after the current one to forecast")
+	for _, cmd := range []*cobra.Command{pullCmd, backfillCmd, forecastCmd} {
+		cmd.Flags().Bool("all-profiles", false, "Run for every profile in the config, one after another")
+		cmd.Flags().String("summary-json", "", "Write a JSON run summary to this file, or - for stdout")
+		cmd.Flags().String("metrics-file", "", "Write API request metrics to this file in the Prometheus text format")
+		cmd.Flags().Bool("strict", false, "Exit 5 when synced records have missing fields or warnings")
+		cmd.Flags().Duration("max-duration", 0, "Abort the run after this long, e.g. 2h (0 means no limit)")
+	}
 
 	return rootCmd
 }
@@ -78,6 +110,6 @@ func main() {
 	rootCmd := buildRootCmd()
 	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
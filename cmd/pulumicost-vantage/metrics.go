@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// metricsFilePerm lets a metrics collector running as another user, such
+// as the node_exporter textfile collector, read the file.
+const metricsFilePerm = 0o644
+
+// requestMetricsKey is the context key of the command's request metrics.
+type requestMetricsKey struct{}
+
+// metricsFile collects the API request metrics of every sync a command runs
+// and writes them to --metrics-file in the Prometheus text format.
+type metricsFile struct {
+	path    string
+	metrics *client.RequestMetrics
+}
+
+// newMetricsFile starts collecting request metrics for the command, or
+// returns nil when --metrics-file is not set. Every method is a no-op on
+// nil.
+func newMetricsFile(cmd *cobra.Command) *metricsFile {
+	path, _ := cmd.Flags().GetString("metrics-file")
+	if path == "" {
+		return nil
+	}
+	metrics := client.NewRequestMetrics()
+	cmd.SetContext(context.WithValue(cmd.Context(), requestMetricsKey{}, metrics))
+	return &metricsFile{path: path, metrics: metrics}
+}
+
+// commandRequestMetrics returns the request metrics --metrics-file collects
+// for cmd, or nil when it is not set.
+func commandRequestMetrics(cmd *cobra.Command) client.RequestObserver {
+	if cmd.Context() != nil {
+		if metrics, ok := cmd.Context().Value(requestMetricsKey{}).(*client.RequestMetrics); ok {
+			return metrics
+		}
+	}
+	return nil
+}
+
+// write writes the collected metrics, even when runErr is set, and returns
+// runErr joined with any write failure. The file is replaced atomically, so
+// a collector never reads it half-written.
+func (f *metricsFile) write(runErr error) error {
+	if f == nil {
+		return runErr
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return errors.Join(runErr, fmt.Errorf("writing metrics file: %w", err))
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	err = f.metrics.WritePrometheus(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), metricsFilePerm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		return errors.Join(runErr, fmt.Errorf("writing metrics file: %w", err))
+	}
+	return runErr
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// mockClientConfig points clientCfg at the synthetic data generator of the
+// mock section. No request leaves the process, so credentials, the proxy,
+// and fixture recording are not used.
+func mockClientConfig(clientCfg client.Config, mock adapter.MockConfig, logger client.Logger) client.Config {
+	clientCfg.BaseURL = client.MockScheme + "://vantage"
+	clientCfg.TokenProvider = nil
+	clientCfg.Transport = client.NewMockTransport(client.MockConfig{
+		Seed:          mock.Seed,
+		Providers:     mock.Providers,
+		Services:      mock.Services,
+		Accounts:      mock.Accounts,
+		RowsPerBucket: mock.RowsPerBucket,
+		DailyCost:     mock.DailyCost,
+		Seasonality:   mock.Seasonality,
+	})
+	logger.Info(context.Background(), "Serving synthetic cost data from the mock generator", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "mock",
+		"attempt":   0,
+		"seed":      mock.Seed,
+	})
+	return clientCfg
+}
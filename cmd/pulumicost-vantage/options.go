@@ -0,0 +1,81 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// Config override flags, each setting the params or sink key it names.
+var (
+	paramStringFlags = map[string]string{
+		"granularity":       "granularity",
+		"start-date":        "start_date",
+		"end-date":          "end_date",
+		"cost-report-token": "cost_report_token",
+		"cost-report-name":  "cost_report_name",
+		"workspace-token":   "workspace_token",
+	}
+	paramSliceFlags = map[string]string{
+		"group-bys": "group_bys",
+		"metrics":   "metrics",
+	}
+	sinkStringFlags = map[string]string{
+		"sink-type": "type",
+		"sink-path": "path",
+	}
+)
+
+// addOverrideFlags registers the flags that override config file settings.
+func addOverrideFlags(flags *pflag.FlagSet) {
+	flags.String("granularity", "", "Override params.granularity: day or month")
+	flags.StringSlice("group-bys", nil, "Override params.group_bys (comma-separated)")
+	flags.StringSlice("metrics", nil, "Override params.metrics (comma-separated)")
+	flags.Int("page-size", 0, "Override params.page_size")
+	flags.String("start-date", "", "Override params.start_date (YYYY-MM-DD)")
+	flags.String("end-date", "", "Override params.end_date (YYYY-MM-DD)")
+	flags.String("cost-report-token", "", "Override params.cost_report_token")
+	flags.String("cost-report-name", "", "Override params.cost_report_name")
+	flags.String("workspace-token", "", "Override params.workspace_token")
+	flags.String("sink-type", "", "Override sink.type")
+	flags.String("sink-path", "", "Override sink.path")
+	flags.Bool("mock", false, "Serve synthetic cost data instead of calling Vantage (sets mock.enabled)")
+}
+
+// loadOptions returns the --config, --profile, and override flags as load
+// options. Only flags given on the command line override the config.
+func loadOptions(cmd *cobra.Command) adapter.LoadOptions {
+	flags := cmd.Flags()
+	opts := adapter.LoadOptions{}
+	opts.Path, _ = flags.GetString("config")
+	opts.Profile, _ = flags.GetString("profile")
+
+	for flag, key := range paramStringFlags {
+		if flags.Changed(flag) {
+			value, _ := flags.GetString(flag)
+			opts.Overrides.SetParam(key, value)
+		}
+	}
+	for flag, key := range paramSliceFlags {
+		if flags.Changed(flag) {
+			value, _ := flags.GetStringSlice(flag)
+			opts.Overrides.SetParam(key, value)
+		}
+	}
+	if flags.Changed("page-size") {
+		value, _ := flags.GetInt("page-size")
+		opts.Overrides.SetParam("page_size", value)
+	}
+	if flags.Changed("mock") {
+		value, _ := flags.GetBool("mock")
+		opts.Overrides.SetMock("enabled", value)
+	}
+	for flag, key := range sinkStringFlags {
+		if flags.Changed(flag) {
+			value, _ := flags.GetString(flag)
+			opts.Overrides.SetSink(key, value)
+		}
+	}
+	return opts
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// loadConfig loads the --config file, applying --profile and the override
+// flags when given.
+func loadConfig(cmd *cobra.Command) (*adapter.Config, error) {
+	return adapter.Load(loadOptions(cmd))
+}
+
+// loadToken reads only the API token, applying --profile when given.
+func loadToken(cmd *cobra.Command) (string, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	profile, _ := cmd.Flags().GetString("profile")
+	return adapter.LoadProfileToken(configPath, profile)
+}
+
+// forEachProfile runs fn for the selected config, or with --all-profiles for
+// every profile in turn. Each profile gets a freshly loaded config, and a
+// failing profile does not stop the remaining ones; all failures are
+// returned together.
+func forEachProfile(cmd *cobra.Command, fn func(cfg *adapter.Config) error) error {
+	allProfiles, _ := cmd.Flags().GetBool("all-profiles")
+	if !allProfiles {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		return fn(cfg)
+	}
+
+	if cmd.Flags().Changed("profile") {
+		return errors.New("--profile and --all-profiles cannot be used together")
+	}
+
+	opts := loadOptions(cmd)
+	profiles, err := adapter.ListProfiles(opts.Path)
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		return errors.New("--all-profiles requires a profiles section in the config file")
+	}
+
+	var errs []error
+	for _, profile := range profiles {
+		opts.Profile = profile
+		cfg, loadErr := adapter.Load(opts)
+		if loadErr == nil {
+			loadErr = fn(cfg)
+		}
+		if loadErr != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "[%s] Error: %v\n", profile, loadErr)
+			errs = append(errs, fmt.Errorf("profile %s: %w", profile, loadErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// profilePrefix labels output lines with the profile they belong to.
+func profilePrefix(cfg *adapter.Config) string {
+	if cfg.Profile == "" {
+		return ""
+	}
+	return "[" + cfg.Profile + "] "
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/sink"
+)
+
+func buildQueryCmd() *cobra.Command {
+	queryCmd := &cobra.Command{
+		Use:   "query <sql>",
+		Short: "Run SQL over the local DuckDB cost database",
+		Long: `Run a SQL query over the records the duckdb sink has synced and print the
+result, to slice costs without any other infrastructure. The database is
+opened read-only, so the query cannot change it; it cannot be opened while a
+sync is writing to it. Records are in the table named by sink.table
+(vantage_costs by default), one column per record field.
+
+Example:
+  pulumicost-vantage query "select service, sum(net_cost) as cost from vantage_costs group by 1 order by 2 desc"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			formats := []string{costsFormatTable, costsFormatCSV, costsFormatJSON}
+			if !slices.Contains(formats, format) {
+				return fmt.Errorf("invalid --format %q (valid: %s)", format, strings.Join(formats, ", "))
+			}
+
+			database, _ := cmd.Flags().GetString("database")
+			if database == "" {
+				cfg, err := loadConfig(cmd)
+				if err != nil {
+					return err
+				}
+				if cfg.Sink.Type != adapter.SinkTypeDuckDB {
+					return fmt.Errorf("query reads the duckdb sink, but sink.type is %s; pass --database to name a database file",
+						cfg.Sink.Type)
+				}
+				database = cfg.Sink.Database
+			}
+
+			result, err := sink.QueryDuckDB(cmd.Context(), database, args[0])
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case costsFormatCSV:
+				return writeQueryCSV(out, result)
+			case costsFormatJSON:
+				return writeQueryJSON(out, result)
+			default:
+				return writeQueryTable(out, result)
+			}
+		},
+	}
+
+	queryCmd.Flags().String("format", costsFormatTable, "Output format: table, csv, or json")
+	queryCmd.Flags().String("database", "", "DuckDB database file to query, instead of the duckdb sink's")
+	_ = queryCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(
+		[]string{costsFormatTable, costsFormatCSV, costsFormatJSON}, cobra.ShellCompDirectiveNoFileComp))
+
+	return queryCmd
+}
+
+// writeQueryTable prints the result aligned in columns, followed by the
+// row count.
+func writeQueryTable(out io.Writer, result *sink.QueryResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, tabPadding, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.ToUpper(strings.Join(result.Columns, "\t")))
+	for _, row := range result.Rows {
+		fields := make([]string, len(row))
+		for i, value := range row {
+			if value == nil {
+				fields[i] = "NULL"
+				continue
+			}
+			fields[i] = formatQueryValue(value)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(fields, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "\n%d rows\n", len(result.Rows))
+	return err
+}
+
+// writeQueryCSV writes the result as CSV with a header line; NULL is an
+// empty field.
+func writeQueryCSV(out io.Writer, result *sink.QueryResult) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(result.Columns); err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value != nil {
+				record[i] = formatQueryValue(value)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeQueryJSON writes the result as a JSON array of objects keyed by
+// column name.
+func writeQueryJSON(out io.Writer, result *sink.QueryResult) error {
+	rows := make([]map[string]any, len(result.Rows))
+	for i, row := range result.Rows {
+		rows[i] = make(map[string]any, len(row))
+		for j, value := range row {
+			rows[i][result.Columns[j]] = queryJSONValue(value)
+		}
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// formatQueryValue formats a non-NULL value for table and CSV output.
+func formatQueryValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// queryJSONValue converts a value for JSON output: values with their own
+// JSON encoding, such as times and big integers, are kept, and other
+// driver types, such as decimals, become their text.
+func queryJSONValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case json.Marshaler:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
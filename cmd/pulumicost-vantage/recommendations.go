@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func buildRecommendationsCmd() *cobra.Command {
+	recommendationsCmd := &cobra.Command{
+		Use:   "recommendations",
+		Short: "Sync savings and commitment recommendations",
+		Long: `Fetch reserved instance, savings plan, and rightsizing recommendations from Vantage
+and write them to the configured sink as records with metric_type="recommendation",
+including estimated monthly savings, resource IDs, and provider.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			categories, _ := cmd.Flags().GetStringSlice("category")
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			logger := commandLogger(cmd)
+			apiClient, err := newAPIClient(cfg, logger)
+			if err != nil {
+				return fmt.Errorf("creating Vantage client: %w", err)
+			}
+
+			s, closeSink, err := openSink(cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = closeSink() }()
+
+			converter, err := newCurrencyConverter(cfg)
+			if err != nil {
+				return err
+			}
+
+			a := adapter.New(apiClient, logger)
+			a.SetCurrencyConverter(converter)
+			written, err := a.SyncRecommendations(cmd.Context(), *cfg, s, categories)
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d recommendation records\n", written)
+			return nil
+		},
+	}
+
+	recommendationsCmd.Flags().StringSlice("category", nil, fmt.Sprintf(
+		"Recommendation categories to sync (e.g. %s,%s,%s); defaults to all",
+		client.RecommendationCategoryReservedInstances,
+		client.RecommendationCategorySavingsPlans,
+		client.RecommendationCategoryRightsizing,
+	))
+
+	return recommendationsCmd
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// Environment variables selecting fixture mode for every API client.
+const (
+	// recordEnv names a directory to save sanitized API responses to.
+	recordEnv = "VANTAGE_RECORD"
+	// replayEnv names a directory of recorded responses to answer from,
+	// with no network access.
+	replayEnv = "VANTAGE_REPLAY"
+)
+
+// fixtureTransport returns the transport selected by VANTAGE_RECORD or
+// VANTAGE_REPLAY, or live to call the API as usual. Recording sends through
+// live.
+func fixtureTransport(logger client.Logger, live http.RoundTripper) (http.RoundTripper, error) {
+	recordDir := os.Getenv(recordEnv)
+	replayDir := os.Getenv(replayEnv)
+
+	switch {
+	case recordDir != "" && replayDir != "":
+		return nil, fmt.Errorf("%s and %s cannot be used together", recordEnv, replayEnv)
+	case replayDir != "":
+		transport, err := client.NewReplayTransport(replayDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", replayEnv, err)
+		}
+		logger.Info(context.Background(), "Replaying API responses from fixtures", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "replay",
+			"attempt":   0,
+			"dir":       replayDir,
+		})
+		return transport, nil
+	case recordDir != "":
+		transport, err := client.NewRecordingTransport(recordDir, live)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", recordEnv, err)
+		}
+		logger.Info(context.Background(), "Recording API responses to fixtures", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "record",
+			"attempt":   0,
+			"dir":       recordDir,
+		})
+		return transport, nil
+	default:
+		return live, nil
+	}
+}
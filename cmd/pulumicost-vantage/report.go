@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/export"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/report"
+)
+
+func buildReportCmd() *cobra.Command {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render a showback report from synced records",
+		Long: `Sum the cost records in the configured sink by a label (team, cost-center, ...)
+or a dimension (provider, service, account_id, project, region) over a period,
+with net, amortized, list, tax, credit, and refund totals and each group's
+share of spend. Records without the label are grouped as "(unlabeled)".`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			by, _ := cmd.Flags().GetString("by")
+			format, _ := cmd.Flags().GetString("format")
+			if !slices.Contains(report.SupportedFormats(), format) {
+				return fmt.Errorf("invalid --format %q (valid: %s)", format, strings.Join(report.SupportedFormats(), ", "))
+			}
+
+			start, end, err := reportPeriod(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			records, err := readSinkRecords(cmd.Context(), cfg)
+			if err != nil {
+				return err
+			}
+
+			showback := report.Build(export.Reconcile(records), by, start, end)
+			return writeExport(cmd, func(out io.Writer) (int, error) {
+				return len(showback.Rows), report.Write(out, showback, format)
+			})
+		},
+	}
+
+	reportCmd.Flags().String("by", "team", "Label key or dimension to group by")
+	reportCmd.Flags().String("format", report.FormatMarkdown, "Output format: csv, json, or markdown")
+	reportCmd.Flags().String("month", "", "Month to report (YYYY-MM); defaults to the previous month")
+	reportCmd.Flags().String("start", "", "First day to report (YYYY-MM-DD), instead of --month")
+	reportCmd.Flags().String("end", "", "Day after the last one to report (YYYY-MM-DD); defaults to today")
+	reportCmd.Flags().String("out", "-", "Output file, or - for stdout")
+	reportCmd.MarkFlagsMutuallyExclusive("month", "start")
+	reportCmd.MarkFlagsMutuallyExclusive("month", "end")
+
+	return reportCmd
+}
+
+// reportPeriod returns the [start, end) range selected by --month, or by
+// --start and --end, defaulting to the previous calendar month.
+func reportPeriod(cmd *cobra.Command) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	month, _ := cmd.Flags().GetString("month")
+	startFlag, _ := cmd.Flags().GetString("start")
+	endFlag, _ := cmd.Flags().GetString("end")
+
+	if startFlag == "" && endFlag == "" {
+		first := time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		if month != "" {
+			t, err := time.Parse("2006-01", month)
+			if err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid --month %q: expected YYYY-MM", month)
+			}
+			first = t
+		}
+		return first, first.AddDate(0, 1, 0), nil
+	}
+
+	if startFlag == "" {
+		return time.Time{}, time.Time{}, errors.New("--end requires --start")
+	}
+	start, err := time.Parse("2006-01-02", startFlag)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --start %q: expected YYYY-MM-DD", startFlag)
+	}
+	end := today
+	if endFlag != "" {
+		if end, err = time.Parse("2006-01-02", endFlag); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --end %q: expected YYYY-MM-DD", endFlag)
+		}
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("report period is empty: %s to %s",
+			start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	return start, end, nil
+}
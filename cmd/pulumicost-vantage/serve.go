@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/export"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/opencost"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/plugin"
+)
+
+const (
+	defaultListenAddress = "127.0.0.1:0"
+	defaultProbeInterval = 30 * time.Second
+
+	opencostReadHeaderTimeout = 10 * time.Second
+	opencostShutdownTimeout   = 5 * time.Second
+)
+
+// newAPIClient builds a Vantage API client from adapter configuration.
+func newAPIClient(cfg *adapter.Config, logger client.Logger) (client.Client, error) {
+	clientCfg, err := apiClientConfig(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(clientCfg)
+}
+
+// apiClientConfig builds the Vantage API client configuration from adapter
+// configuration.
+func apiClientConfig(cfg *adapter.Config, logger client.Logger) (client.Config, error) {
+	clientCfg := client.DefaultConfig(cfg.Token)
+	clientCfg.Timeout = cfg.Timeout
+	clientCfg.OperationTimeout = cfg.OperationTimeout
+	clientCfg.MaxRetries = cfg.MaxRetries
+	clientCfg.RetryBudget = cfg.RetryBudget
+	clientCfg.Logger = logger
+	clientCfg.RequestsPerSecond = cfg.RequestsPerSecond
+	clientCfg.Burst = cfg.Burst
+	clientCfg.RateLimitRemainingThreshold = cfg.RateLimitRemainingThreshold
+	if cfg.Cache.Enabled {
+		clientCfg.CacheDir = cfg.Cache.Path
+		clientCfg.CacheTTL = time.Duration(cfg.Cache.TTLSeconds) * time.Second
+	}
+	clientCfg.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	clientCfg.DisableCompression = cfg.DisableCompression
+	clientCfg.ProxyURL = cfg.ProxyURL
+	clientCfg.CABundle = cfg.CABundle
+	clientCfg.ClientCert = cfg.ClientCert
+	clientCfg.ClientKey = cfg.ClientKey
+	clientCfg.APIVersion = cfg.APIVersion
+	clientCfg.Kind = cfg.ClientKind
+	clientCfg.UserAgent = client.UserAgent(version, cfg.UserAgentSuffix)
+	clientCfg.Headers = cfg.Headers
+
+	if cfg.Mock.Enabled {
+		return mockClientConfig(clientCfg, cfg.Mock, logger), nil
+	}
+
+	transport, err := client.NewTransport(clientCfg)
+	if err != nil {
+		return client.Config{}, err
+	}
+
+	switch {
+	case cfg.OAuth2 != nil:
+		var provider client.TokenProvider
+		provider, err = client.NewOAuth2TokenProvider(client.OAuth2Config{
+			TokenURL:     cfg.OAuth2.TokenURL,
+			ClientID:     cfg.OAuth2.ClientID,
+			ClientSecret: cfg.OAuth2.ClientSecret,
+			Scopes:       cfg.OAuth2.Scopes,
+			Transport:    transport,
+		})
+		if err != nil {
+			return client.Config{}, err
+		}
+		clientCfg.TokenProvider = provider
+	case cfg.TokenEnv != "":
+		clientCfg.TokenProvider = client.NewEnvTokenProvider(cfg.TokenEnv, cfg.Token)
+	}
+
+	clientCfg.Transport, err = fixtureTransport(logger, transport)
+	if err != nil {
+		return client.Config{}, err
+	}
+	return clientCfg, nil
+}
+
+func buildServeCmd() *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run as a gRPC plugin server",
+		Long: `Serve gRPC health checks (grpc.health.v1) and plugin metadata so pulumicost-core
+can probe plugin readiness before dispatching queries. The bound port is
+printed to stdout as PORT=<port> once the server is listening.
+
+GetProjectedCost apportions the cost report's forecast for next month to the
+requested resources by their share of recent spend (see the serve section's
+projection_strategy and projection_lookback_days).
+
+With --opencost-listen, an OpenCost-compatible HTTP allocation API
+(/allocation) backed by the sink's records is served as well, and its port is
+printed as OPENCOST_PORT=<port>.
+
+While serving, the config file is watched (disable with --watch-config=false).
+Changes to the serve section, such as log_level, probe_interval_seconds, and
+projection_strategy, apply without a restart. Changes to the credentials,
+profile, or report tokens are rejected and logged, since the running client is
+bound to them.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			listen, _ := cmd.Flags().GetString("listen")
+			probeInterval, _ := cmd.Flags().GetDuration("probe-interval")
+			opencostListen, _ := cmd.Flags().GetString("opencost-listen")
+			watchConfig, _ := cmd.Flags().GetBool("watch-config")
+
+			opts := loadOptions(cmd)
+			cfg, err := adapter.Load(opts)
+			if err != nil {
+				return err
+			}
+
+			logger := commandLogger(cmd)
+			apiClient, err := newAPIClient(cfg, logger)
+			if err != nil {
+				return fmt.Errorf("creating Vantage client: %w", err)
+			}
+
+			lis, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("listening on %s: %w", listen, err)
+			}
+
+			tcpAddr, ok := lis.Addr().(*net.TCPAddr)
+			if ok {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "PORT=%d\n", tcpAddr.Port)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if opencostListen != "" {
+				stopOpenCost, serveErr := serveOpenCost(ctx, cmd, cfg, opencostListen, logger)
+				if serveErr != nil {
+					_ = lis.Close()
+					return serveErr
+				}
+				defer stopOpenCost()
+			}
+
+			reportToken := cfg.CostReportToken
+			if reportToken == "" && cfg.CostReportName != "" {
+				if reportToken, err = adapter.ResolveCostReportName(ctx, apiClient,
+					cfg.WorkspaceToken, cfg.CostReportName); err != nil {
+					_ = lis.Close()
+					return err
+				}
+			}
+
+			srv := plugin.NewServer(apiClient, logger, plugin.Config{
+				Version:         version,
+				ProbeInterval:   probeInterval,
+				CostReportToken: reportToken,
+				WorkspaceToken:  cfg.WorkspaceToken,
+			})
+			applyServeConfig(cmd, cfg.Serve, srv)
+			if watchConfig && opts.Path != "" {
+				go watchServeConfig(ctx, cmd, opts, cfg, srv)
+			}
+			return srv.Serve(ctx, lis)
+		},
+	}
+
+	serveCmd.Flags().String("listen", defaultListenAddress, "Address to listen on (host:port)")
+	serveCmd.Flags().String("opencost-listen", "",
+		"Also serve the OpenCost allocation API over HTTP on this address (host:port)")
+	serveCmd.Flags().Duration("probe-interval", defaultProbeInterval,
+		"Interval between Vantage API reachability probes (overrides serve.probe_interval_seconds)")
+	serveCmd.Flags().Bool("watch-config", true, "Reload the config file when it changes")
+
+	return serveCmd
+}
+
+// applyServeConfig applies the serve section to the logger and the running
+// server, except for settings given as flags.
+func applyServeConfig(cmd *cobra.Command, serve adapter.ServeConfig, srv *plugin.Server) {
+	if level := commandLogLevel(cmd); level != nil && serve.LogLevel != "" && !cmd.Flags().Changed("log-level") {
+		if parsed, err := client.ParseLevel(serve.LogLevel); err == nil {
+			level.Set(parsed)
+		}
+	}
+	if serve.ProbeIntervalSeconds > 0 && !cmd.Flags().Changed("probe-interval") {
+		srv.SetProbeInterval(time.Duration(serve.ProbeIntervalSeconds) * time.Second)
+	}
+	srv.SetProjection(plugin.Projection{
+		Strategy:     serve.ProjectionStrategy,
+		LookbackDays: serve.ProjectionLookbackDays,
+	})
+}
+
+// watchServeConfig reloads the config file whenever it changes until ctx is
+// done, applying the serve section of accepted reloads and logging the
+// rest.
+func watchServeConfig(
+	ctx context.Context,
+	cmd *cobra.Command,
+	opts adapter.LoadOptions,
+	cfg *adapter.Config,
+	srv *plugin.Server,
+) {
+	logger := commandLogger(cmd)
+	fields := func(extra map[string]interface{}) map[string]interface{} {
+		f := map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "config_reload",
+			"attempt":   0,
+			"config":    opts.Path,
+		}
+		maps.Copy(f, extra)
+		return f
+	}
+
+	err := adapter.WatchConfig(ctx, opts, cfg, func(reload adapter.ConfigReload) {
+		switch {
+		case reload.Err != nil:
+			logger.Error(ctx, "Config reload failed; keeping the current config",
+				fields(map[string]interface{}{"error": reload.Err.Error()}))
+		case len(reload.Rejected) > 0:
+			logger.Error(ctx, "Config change rejected; restart serve to change these settings",
+				fields(map[string]interface{}{"rejected": strings.Join(reload.Rejected, ", ")}))
+		default:
+			applyServeConfig(cmd, reload.Config.Serve, srv)
+			logger.Info(ctx, "Config reloaded",
+				fields(map[string]interface{}{"changed": strings.Join(reload.Changed, ", ")}))
+		}
+	})
+	if err != nil {
+		logger.Error(ctx, "Config watch stopped", fields(map[string]interface{}{"error": err.Error()}))
+	}
+}
+
+// serveOpenCost starts the OpenCost allocation API on listen in the
+// background. Each request reads the sink's current records. The returned
+// function shuts the server down.
+func serveOpenCost(
+	ctx context.Context,
+	cmd *cobra.Command,
+	cfg *adapter.Config,
+	listen string,
+	logger client.Logger,
+) (func(), error) {
+	reader, err := openRecordReader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	source := func(ctx context.Context) ([]adapter.CostRecord, error) {
+		var records []adapter.CostRecord
+		if readErr := reader.ReadRecords(ctx, func(record adapter.CostRecord) error {
+			records = append(records, record)
+			return nil
+		}); readErr != nil {
+			return nil, readErr
+		}
+		return export.Reconcile(records), nil
+	}
+
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", listen, err)
+	}
+	if tcpAddr, ok := lis.Addr().(*net.TCPAddr); ok {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "OPENCOST_PORT=%d\n", tcpAddr.Port)
+	}
+
+	srv := &http.Server{
+		Handler:           opencost.NewHandler(source, cfg.Granularity, logger),
+		ReadHeaderTimeout: opencostReadHeaderTimeout,
+	}
+	go func() {
+		if serveErr := srv.Serve(lis); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			logger.Error(ctx, "OpenCost allocation server stopped", map[string]interface{}{
+				"adapter":   "vantage",
+				"operation": "opencost_serve",
+				"attempt":   0,
+				"error":     serveErr.Error(),
+			})
+		}
+	}()
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), opencostShutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/sink"
+)
+
+// openSink builds the sink selected by the config's sink section. The
+// returned func releases its connections.
+func openSink(cfg *adapter.Config) (adapter.Sink, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Sink.Type {
+	case adapter.SinkTypeFile, "":
+		s, err := openFileSink(cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: opening file sink: %w", adapter.ErrSink, err)
+		}
+		return s, noop, nil
+	case adapter.SinkTypeBigQuery:
+		api, err := sink.NewBigQueryClient(cfg.Sink.Project, cfg.Sink.Dataset)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: opening bigquery sink: %w", adapter.ErrSink, err)
+		}
+		s, err := sink.NewBigQuery(api, cfg.Sink.Table, cfg.Sink.Upsert)
+		if err != nil {
+			_ = api.Close()
+			return nil, nil, fmt.Errorf("%w: opening bigquery sink: %w", adapter.ErrSink, err)
+		}
+		return s, api.Close, nil
+	case adapter.SinkTypeKafka:
+		w := sink.NewKafkaWriter(cfg.Sink.Brokers, cfg.Sink.Topic)
+		s, err := sink.NewKafka(w, cfg.Sink.Topic, cfg.Sink.Format, cfg.Sink.SchemaRegistryURL)
+		if err != nil {
+			_ = w.Close()
+			return nil, nil, fmt.Errorf("%w: opening kafka sink: %w", adapter.ErrSink, err)
+		}
+		return s, w.Close, nil
+	case adapter.SinkTypeClickHouse:
+		s, err := sink.NewClickHouse(sink.ClickHouseOptions{
+			URL:      cfg.Sink.URL,
+			Database: cfg.Sink.Database,
+			Table:    cfg.Sink.Table,
+			TTLDays:  cfg.Sink.TTLDays,
+			Codec:    cfg.Sink.Codec,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: opening clickhouse sink: %w", adapter.ErrSink, err)
+		}
+		return s, noop, nil
+	case adapter.SinkTypeDuckDB:
+		s, err := sink.NewDuckDB(cfg.Sink.Database, cfg.Sink.Table)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: opening duckdb sink: %w", adapter.ErrSink, err)
+		}
+		return s, s.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported sink type: %s", cfg.Sink.Type)
+	}
+}
+
+// openFileSink opens the file sink in sink.bucket_url when set, and
+// otherwise in the sink.path directory.
+func openFileSink(cfg *adapter.Config) (*sink.File, error) {
+	opts := sink.FileOptions{
+		Compression:  cfg.Sink.Compression,
+		MaxFileBytes: cfg.Sink.MaxFileBytes,
+		Manifest:     cfg.Sink.Manifest,
+	}
+	if cfg.Sink.BucketURL == "" {
+		return sink.NewFileWithOptions(cfg.Sink.Path, opts)
+	}
+	bucket, err := sink.OpenBucket(cfg.Sink.BucketURL)
+	if err != nil {
+		return nil, err
+	}
+	return sink.NewFileWithBucket(bucket, opts)
+}
+
+// recordReader reads back the records a sink has stored.
+type recordReader interface {
+	ReadRecords(ctx context.Context, fn func(adapter.CostRecord) error) error
+}
+
+// openRecordReader opens the configured sink for reading.
+func openRecordReader(cfg *adapter.Config) (recordReader, error) {
+	switch cfg.Sink.Type {
+	case adapter.SinkTypeFile, "":
+		s, err := openFileSink(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("opening file sink: %w", err)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("sink type %s cannot be read back", cfg.Sink.Type)
+	}
+}
+
+// readSinkRecords reads every record the configured sink has stored.
+func readSinkRecords(ctx context.Context, cfg *adapter.Config) ([]adapter.CostRecord, error) {
+	reader, err := openRecordReader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var records []adapter.CostRecord
+	if err := reader.ReadRecords(ctx, func(record adapter.CostRecord) error {
+		records = append(records, record)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("reading records: %w", err)
+	}
+	return records, nil
+}
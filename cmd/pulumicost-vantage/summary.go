@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/alert"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+const (
+	// summaryStdout is the --summary-json value that writes to stdout.
+	summaryStdout = "-"
+
+	summaryFilePerm = 0o600
+
+	summaryStatusSuccess = "success"
+	summaryStatusFailed  = "failed"
+)
+
+// syncResult is what one sync reports back for output and summaries.
+type syncResult struct {
+	Diagnostics *adapter.DiagnosticsSummary
+	Stats       adapter.SyncStats
+	Requests    client.RequestStats
+	Endpoints   []client.EndpointStats
+	Alerts      []alert.Breach
+}
+
+// runSummary is the --summary-json document, with one run per profile synced.
+type runSummary struct {
+	Command         string      `json:"command"`
+	Status          string      `json:"status"`
+	ExitCode        int         `json:"exit_code"`
+	StartedAt       time.Time   `json:"started_at"`
+	FinishedAt      time.Time   `json:"finished_at"`
+	DurationSeconds float64     `json:"duration_seconds"`
+	Runs            []runReport `json:"runs"`
+	path            string
+}
+
+// runReport summarizes one sync. A run that failed before syncing has no
+// counts.
+type runReport struct {
+	Profile   string `json:"profile,omitempty"`
+	Status    string `json:"status"`
+	ExitCode  int    `json:"exit_code"`
+	Error     string `json:"error,omitempty"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date,omitempty"`
+
+	adapter.SyncStats
+
+	APICalls    int64                       `json:"api_calls"`
+	Retries     int64                       `json:"retries"`
+	CacheHits   int64                       `json:"cache_hits"`
+	Endpoints   []client.EndpointStats      `json:"endpoints,omitempty"`
+	Diagnostics *adapter.DiagnosticsSummary `json:"diagnostics,omitempty"`
+	AlertsFired []alert.Breach              `json:"alerts_fired,omitempty"`
+}
+
+// newRunSummary starts the summary for command, or returns nil when
+// --summary-json is not set. Every method is a no-op on nil.
+func newRunSummary(cmd *cobra.Command, command string) *runSummary {
+	path, _ := cmd.Flags().GetString("summary-json")
+	if path == "" {
+		return nil
+	}
+	return &runSummary{
+		Command:   command,
+		StartedAt: time.Now().UTC(),
+		Runs:      []runReport{},
+		path:      path,
+	}
+}
+
+// add records the outcome of syncing cfg.
+func (s *runSummary) add(cfg *adapter.Config, result *syncResult, err error) {
+	if s == nil {
+		return
+	}
+
+	report := runReport{
+		Profile:   cfg.Profile,
+		Status:    summaryStatusSuccess,
+		StartDate: cfg.StartDate.Format("2006-01-02"),
+	}
+	if cfg.EndDate != nil {
+		report.EndDate = cfg.EndDate.Format("2006-01-02")
+	}
+	if err != nil {
+		report.Status = summaryStatusFailed
+		report.ExitCode = exitCode(err)
+		report.Error = client.RedactString(err.Error())
+	}
+	if result != nil {
+		report.SyncStats = result.Stats
+		report.APICalls = result.Requests.Requests
+		report.Retries = result.Requests.Retries
+		report.CacheHits = result.Requests.CacheHits
+		report.Endpoints = result.Endpoints
+		report.Diagnostics = result.Diagnostics
+		report.AlertsFired = result.Alerts
+	}
+	s.Runs = append(s.Runs, report)
+}
+
+// output is where human-readable progress goes: stderr when the summary
+// itself goes to stdout, so stdout stays valid JSON.
+func (s *runSummary) output(cmd *cobra.Command) io.Writer {
+	if s != nil && s.path == summaryStdout {
+		return cmd.ErrOrStderr()
+	}
+	return cmd.OutOrStdout()
+}
+
+// write finishes the summary with the command's result and writes it. The
+// summary is written even when runErr is set, and runErr is returned joined
+// with any write failure.
+func (s *runSummary) write(cmd *cobra.Command, runErr error) error {
+	if s == nil {
+		return runErr
+	}
+
+	s.FinishedAt = time.Now().UTC()
+	s.DurationSeconds = s.FinishedAt.Sub(s.StartedAt).Seconds()
+	s.Status = summaryStatusSuccess
+	s.ExitCode = exitCode(runErr)
+	if runErr != nil {
+		s.Status = summaryStatusFailed
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Join(runErr, fmt.Errorf("encoding run summary: %w", err))
+	}
+	data = append(data, '\n')
+
+	if s.path == summaryStdout {
+		_, err = cmd.OutOrStdout().Write(data)
+	} else {
+		err = os.WriteFile(s.path, data, summaryFilePerm)
+	}
+	if err != nil {
+		return errors.Join(runErr, fmt.Errorf("writing run summary: %w", err))
+	}
+	return runErr
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/journal"
+)
+
+// errMaxDuration is the context cause once --max-duration has elapsed.
+var errMaxDuration = errors.New("--max-duration exceeded")
+
+// applyMaxDuration bounds the command's context by --max-duration, so every
+// profile the command syncs shares one deadline. The returned func must be
+// called once the command ends.
+func applyMaxDuration(cmd *cobra.Command) (func(), error) {
+	maxDuration, _ := cmd.Flags().GetDuration("max-duration")
+	if maxDuration < 0 {
+		return nil, fmt.Errorf("--max-duration cannot be negative, got %s", maxDuration)
+	}
+	if maxDuration == 0 {
+		return func() {}, nil
+	}
+	ctx, cancel := context.WithTimeoutCause(cmd.Context(), maxDuration, errMaxDuration)
+	cmd.SetContext(ctx)
+	return cancel, nil
+}
+
+// runSync runs an adapter sync against the configured sink, keeping sync
+// state in the configured bookmark store and holding the configured lock.
+// Once the sync has started, its result is returned even when it fails.
+func runSync(cmd *cobra.Command, cfg *adapter.Config) (*syncResult, error) {
+	return runAdapter(cmd, cfg, (*adapter.Adapter).Sync)
+}
+
+// runForecastSync writes a forecast snapshot to the configured sink, set up
+// as runSync sets up a sync.
+func runForecastSync(cmd *cobra.Command, cfg *adapter.Config) (*syncResult, error) {
+	return runAdapter(cmd, cfg, (*adapter.Adapter).SyncForecast)
+}
+
+// runAdapter runs run with an adapter wired to the configured client, sink,
+// bookmark store, lock, dead-letter queue, and journal.
+func runAdapter(
+	cmd *cobra.Command,
+	cfg *adapter.Config,
+	run func(*adapter.Adapter, context.Context, adapter.Config, adapter.Sink) error,
+) (_ *syncResult, err error) {
+	if cause := context.Cause(cmd.Context()); errors.Is(cause, errMaxDuration) {
+		return nil, fmt.Errorf("sync not started: %w", cause)
+	}
+
+	stopTracing, err := startTracing(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer stopTracing()
+
+	logger := commandLogger(cmd)
+	clientCfg, err := apiClientConfig(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("creating Vantage client: %w", err)
+	}
+	requestMetrics := client.NewRequestMetrics()
+	clientCfg.Observer = client.Observers(requestMetrics, commandRequestMetrics(cmd))
+	apiClient, err := client.New(clientCfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating Vantage client: %w", err)
+	}
+
+	s, closeSink, err := openSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := closeSink(); closeErr != nil && err == nil {
+			err = fmt.Errorf("closing sink: %w", closeErr)
+		}
+	}()
+
+	converter, err := newCurrencyConverter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store, closeStore, err := openBookmarkStore(cmd.Context(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := closeStore(); closeErr != nil && err == nil {
+			err = fmt.Errorf("closing bookmark store: %w", closeErr)
+		}
+	}()
+
+	locker, closeLocker, err := openLocker(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := closeLocker(); closeErr != nil && err == nil {
+			err = fmt.Errorf("closing lock: %w", closeErr)
+		}
+	}()
+
+	deadLetters, closeDeadLetters, err := openDeadLetterQueue(cmd.Context(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := closeDeadLetters(); closeErr != nil && err == nil {
+			err = fmt.Errorf("closing dead-letter queue: %w", closeErr)
+		}
+	}()
+
+	a := adapter.New(apiClient, logger)
+	a.SetBookmarkStore(store)
+	if deadLetters != nil {
+		a.SetDeadLetterQueue(deadLetters)
+	}
+	if cfg.Sink.Journal {
+		j, journalErr := journal.NewFile(cfg.Sink.JournalPath)
+		if journalErr != nil {
+			return nil, fmt.Errorf("opening journal: %w", journalErr)
+		}
+		a.SetJournal(j)
+	}
+	if locker != nil {
+		a.SetLocker(locker, time.Duration(cfg.Lock.WaitSeconds)*time.Second)
+	}
+	a.SetCurrencyConverter(converter)
+	syncErr := run(a, cmd.Context(), *cfg, s)
+	if syncErr != nil && errors.Is(context.Cause(cmd.Context()), errMaxDuration) {
+		syncErr = fmt.Errorf("%w: %w", errMaxDuration, syncErr)
+	}
+	result := &syncResult{
+		Diagnostics: a.GetDiagnosticsSummary(),
+		Stats:       a.GetSyncStats(),
+		Requests:    apiClient.RequestStats(),
+		Endpoints:   requestMetrics.Endpoints(),
+	}
+	writeDiagnosticsReport(cmd, cfg, result.Diagnostics)
+	if syncErr == nil {
+		result.Alerts = checkAlerts(cmd, cfg, store)
+	}
+	return result, syncErr
+}
+
+// runPull performs an incremental sync. Any end_date in the config is
+// ignored, since pulls always cover the trailing lag window.
+func runPull(cmd *cobra.Command) error {
+	stopDeadline, err := applyMaxDuration(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopDeadline()
+
+	summary := newRunSummary(cmd, "pull")
+	out := summary.output(cmd)
+	metrics := newMetricsFile(cmd)
+
+	err = forEachProfile(cmd, func(cfg *adapter.Config) error {
+		cfg.EndDate = nil
+
+		result, err := runSync(cmd, cfg)
+		if err != nil {
+			summary.add(cfg, result, err)
+			return err
+		}
+
+		diagnostics := result.Diagnostics
+		_, _ = fmt.Fprintf(out, "%sPulled %d records", profilePrefix(cfg), diagnostics.TotalRecords)
+		restated, _ := diagnostics.SourceInfo["restated_rows"].(int)
+		deleted, _ := diagnostics.SourceInfo["deleted_rows"].(int)
+		if restated > 0 || deleted > 0 {
+			_, _ = fmt.Fprintf(out, " (%d restated, %d deleted)", restated, deleted)
+		}
+		_, _ = fmt.Fprintln(out)
+		printAlerts(out, cfg, result.Alerts)
+
+		err = checkDataQuality(cmd, diagnostics)
+		summary.add(cfg, result, err)
+		return err
+	})
+	return summary.write(cmd, metrics.write(err))
+}
+
+// runBackfill syncs the last --months months up to today. When --months is
+// not given and the config sets end_date, the configured range is used.
+// Completed month chunks are checkpointed, so re-running after a failure
+// resumes where the previous run stopped.
+func runBackfill(cmd *cobra.Command) error {
+	months, _ := cmd.Flags().GetInt("months")
+	if cmd.Flags().Changed("months") && months < 1 {
+		return fmt.Errorf("--months must be at least 1, got %d", months)
+	}
+	stopDeadline, err := applyMaxDuration(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopDeadline()
+
+	summary := newRunSummary(cmd, "backfill")
+	out := summary.output(cmd)
+	metrics := newMetricsFile(cmd)
+
+	err = forEachProfile(cmd, func(cfg *adapter.Config) error {
+		if cfg.EndDate == nil || cmd.Flags().Changed("months") {
+			if months < 1 {
+				return fmt.Errorf("--months must be at least 1, got %d", months)
+			}
+			now := time.Now().UTC()
+			end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			cfg.StartDate = time.Date(end.Year(), end.Month()-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+			cfg.EndDate = &end
+		}
+
+		result, err := runSync(cmd, cfg)
+		if err != nil {
+			if result != nil && len(result.Stats.ChunksRemaining) > 0 {
+				remaining := result.Stats.ChunksRemaining
+				_, _ = fmt.Fprintf(out, "%sBackfill stopped with %d chunks remaining from %s; re-run to resume\n",
+					profilePrefix(cfg), len(remaining), remaining[0].Start)
+			}
+			summary.add(cfg, result, err)
+			return err
+		}
+
+		_, _ = fmt.Fprintf(out, "%sBackfilled %s to %s: %d records", profilePrefix(cfg),
+			cfg.StartDate.Format("2006-01-02"), cfg.EndDate.Format("2006-01-02"), result.Diagnostics.TotalRecords)
+		if skipped := result.Stats.ChunksSkipped; skipped > 0 {
+			_, _ = fmt.Fprintf(out, " (%d completed chunks skipped)", skipped)
+		}
+		_, _ = fmt.Fprintln(out)
+		printAlerts(out, cfg, result.Alerts)
+
+		err = checkDataQuality(cmd, result.Diagnostics)
+		summary.add(cfg, result, err)
+		return err
+	})
+	return summary.write(cmd, metrics.write(err))
+}
+
+// runForecast writes a forecast snapshot covering today to the end of the
+// month --months ahead. When --months is not given and the config sets
+// end_date, the configured range is forecast instead.
+func runForecast(cmd *cobra.Command) error {
+	months, _ := cmd.Flags().GetInt("months")
+	if cmd.Flags().Changed("months") && months < 1 {
+		return fmt.Errorf("--months must be at least 1, got %d", months)
+	}
+	stopDeadline, err := applyMaxDuration(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopDeadline()
+
+	summary := newRunSummary(cmd, "forecast")
+	out := summary.output(cmd)
+	metrics := newMetricsFile(cmd)
+
+	err = forEachProfile(cmd, func(cfg *adapter.Config) error {
+		if cfg.EndDate == nil || cmd.Flags().Changed("months") {
+			if months < 1 {
+				return fmt.Errorf("--months must be at least 1, got %d", months)
+			}
+			now := time.Now().UTC()
+			cfg.StartDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			end := time.Date(now.Year(), now.Month()+time.Month(months)+1, 0, 0, 0, 0, 0, time.UTC)
+			cfg.EndDate = &end
+		}
+
+		result, err := runForecastSync(cmd, cfg)
+		if err != nil {
+			summary.add(cfg, result, err)
+			return err
+		}
+
+		_, _ = fmt.Fprintf(out, "%sForecast %s to %s: %d records\n", profilePrefix(cfg),
+			cfg.StartDate.Format("2006-01-02"), cfg.EndDate.Format("2006-01-02"), result.Stats.ForecastRecords)
+		printAlerts(out, cfg, result.Alerts)
+
+		err = checkDataQuality(cmd, result.Diagnostics)
+		summary.add(cfg, result, err)
+		return err
+	})
+	return summary.write(cmd, metrics.write(err))
+}
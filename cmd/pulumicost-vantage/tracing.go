@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/tracing"
+)
+
+// tracingShutdownTimeout bounds how long exiting waits for spans to flush.
+const tracingShutdownTimeout = 5 * time.Second
+
+// startTracing sets up span export from the config's tracing section. The
+// returned function flushes pending spans; a failed flush is reported on
+// stderr rather than failing a sync that already finished.
+func startTracing(cmd *cobra.Command, cfg *adapter.Config) (func(), error) {
+	shutdown, err := tracing.Setup(cmd.Context(), cfg.Tracing, version)
+	if err != nil {
+		return nil, fmt.Errorf("setting up tracing: %w", err)
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(cmd.Context()), tracingShutdownTimeout)
+		defer cancel()
+		if shutdownErr := shutdown(ctx); shutdownErr != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: exporting trace spans: %v\n", shutdownErr)
+		}
+	}, nil
+}
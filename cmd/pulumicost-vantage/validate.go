@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/preflight"
+)
+
+func buildValidateCmd() *cobra.Command {
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate configuration, credentials, and sink connectivity",
+		Long: `Run ValidateConfig, make a cheap authenticated API call, check that the configured
+cost report or workspace token exists, and verify the sink is writable. Prints a
+pass/fail report with remediation hints and exits non-zero if any check fails.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			report := runValidate(cmd)
+
+			var printErr error
+			if asJSON {
+				printErr = report.PrintJSON(cmd.OutOrStdout())
+			} else {
+				printErr = report.Print(cmd.OutOrStdout())
+			}
+			if printErr != nil {
+				return printErr
+			}
+			if report.Failed() {
+				return errors.New("validation failed")
+			}
+			return nil
+		},
+	}
+
+	validateCmd.Flags().Bool("json", false, "Print the report as JSON")
+
+	return validateCmd
+}
+
+// runValidate runs the validate checks, stopping early when a failure makes
+// later checks meaningless (an invalid config or an unreachable API).
+func runValidate(cmd *cobra.Command) *preflight.Report {
+	ctx := cmd.Context()
+	report := &preflight.Report{}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		report.Add(preflight.Result{
+			Name:        "config",
+			Status:      preflight.StatusFail,
+			Message:     err.Error(),
+			Remediation: "Fix the config file; see docs/CONFIG.md for every parameter",
+		})
+		return report
+	}
+	report.Add(preflight.CheckConfig(cfg))
+
+	apiClient, err := newAPIClient(cfg, commandLogger(cmd))
+	if err != nil {
+		report.Add(preflight.Result{
+			Name:    "api",
+			Status:  preflight.StatusFail,
+			Message: fmt.Sprintf("creating Vantage client: %v", err),
+		})
+		return report
+	}
+
+	apiResult := preflight.CheckAPI(ctx, apiClient)
+	report.Add(apiResult)
+	if apiResult.Status == preflight.StatusPass {
+		report.Add(preflight.CheckTokens(ctx, apiClient, cfg)...)
+	}
+
+	s, closeSink, err := openSink(cfg)
+	if err != nil {
+		report.Add(preflight.Result{
+			Name:        "sink",
+			Status:      preflight.StatusFail,
+			Message:     err.Error(),
+			Remediation: "Check the sink section of the config file",
+		})
+		return report
+	}
+	defer func() { _ = closeSink() }()
+	report.Add(preflight.CheckSink(ctx, s))
+
+	return report
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/export"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/report"
+)
+
+func buildForecastVarianceCmd() *cobra.Command {
+	varianceCmd := &cobra.Command{
+		Use:   "forecast-variance",
+		Short: "Compare past forecast snapshots with realized spend",
+		Long: `Compare every forecast snapshot synced with include_forecast against the net
+cost later synced for the same buckets, with the absolute and percent variance,
+how many days ahead each forecast was made, and the mean absolute percent error
+over the period. Only buckets that have ended and have synced spend are
+compared.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			if !slices.Contains(report.SupportedFormats(), format) {
+				return fmt.Errorf("invalid --format %q (valid: %s)", format, strings.Join(report.SupportedFormats(), ", "))
+			}
+
+			start, end, err := reportPeriod(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			records, err := readSinkRecords(cmd.Context(), cfg)
+			if err != nil {
+				return err
+			}
+
+			variance := report.BuildVariance(export.Reconcile(records), cfg.Granularity, start, end, time.Now().UTC())
+			return writeExport(cmd, func(out io.Writer) (int, error) {
+				return len(variance.Rows), report.WriteVariance(out, variance, format)
+			})
+		},
+	}
+
+	varianceCmd.Flags().String("format", report.FormatMarkdown, "Output format: csv, json, or markdown")
+	varianceCmd.Flags().String("month", "", "Month of buckets to compare (YYYY-MM); defaults to the previous month")
+	varianceCmd.Flags().String("start", "", "First bucket day to compare (YYYY-MM-DD), instead of --month")
+	varianceCmd.Flags().String("end", "", "Day after the last bucket to compare (YYYY-MM-DD); defaults to today")
+	varianceCmd.Flags().String("out", "-", "Output file, or - for stdout")
+	varianceCmd.MarkFlagsMutuallyExclusive("month", "start")
+	varianceCmd.MarkFlagsMutuallyExclusive("month", "end")
+
+	return varianceCmd
+}
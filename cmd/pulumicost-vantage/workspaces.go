@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+const tabPadding = 2
+
+func buildWorkspacesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "workspaces",
+		Short: "List available Vantage workspaces",
+		Long: `Print the workspace tokens and names visible to the configured API token, so the
+right params.workspace_token can be picked without leaving the tool. Only
+credentials are read from the config file.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			token, err := loadToken(cmd)
+			if err != nil {
+				return err
+			}
+
+			apiClient, err := client.New(client.DefaultConfig(token))
+			if err != nil {
+				return fmt.Errorf("creating Vantage client: %w", err)
+			}
+
+			workspaces, err := apiClient.ListWorkspaces(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("listing workspaces: %w", err)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, tabPadding, ' ', 0)
+			_, _ = fmt.Fprintln(w, "TOKEN\tNAME")
+			for _, ws := range workspaces {
+				_, _ = fmt.Fprintf(w, "%s\t%s\n", ws.Token, ws.Name)
+			}
+			return w.Flush()
+		},
+	}
+}
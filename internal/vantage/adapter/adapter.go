@@ -5,16 +5,27 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
 	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/lock"
 )
 
 // CostRecord represents a cost record in PulumiCost's internal schema with FOCUS 1.2 fields.
 type CostRecord struct {
+	// SchemaVersion is the record format version, set when the adapter
+	// writes records; unset reads as version 1. See DecodeRecord.
+	SchemaVersion int `json:"schema_version,omitempty"`
+
 	// Core dimensions.
 	Timestamp      time.Time         `json:"timestamp"`
 	Provider       string            `json:"provider,omitempty"`
@@ -25,11 +36,45 @@ type CostRecord struct {
 	Region         string            `json:"region,omitempty"`
 	ResourceID     string            `json:"resource_id,omitempty"`
 	Labels         map[string]string `json:"labels,omitempty"`
+	LabelsRaw      map[string]string `json:"labels_raw,omitempty"` // Provider tags as received, when tags.preserve_raw is set
+
+	// Billing hierarchy, as FOCUS names it: the account invoiced (an AWS
+	// payer account, Azure billing account, or GCP billing account) and the
+	// account the spend was incurred in (an AWS linked account, Azure
+	// subscription, or GCP project). See accountHierarchy.
+	BillingAccountID string `json:"billing_account_id,omitempty"`
+	SubAccountID     string `json:"sub_account_id,omitempty"`
+
+	// Resource ID components, set when ResourceID is an ARN, Azure resource
+	// ID, or GCP self-link (see ParseResourceID), for joining costs to stack
+	// resources by type and name.
+	ResourceType      string `json:"resource_type,omitempty"`
+	ResourceName      string `json:"resource_name,omitempty"`
+	ResourceAccountID string `json:"resource_account_id,omitempty"`
+
+	// Pulumi resource, set by the pulumi_stack transform when a stack
+	// manages the resource the cost was incurred by.
+	PulumiURN  string `json:"pulumi_urn,omitempty"`
+	PulumiType string `json:"pulumi_type,omitempty"`
+
+	// Region geography, set when region_geography is enabled and the region
+	// table lists Region: its continent and ISO 3166-1 alpha-2 country.
+	RegionContinent string `json:"region_continent,omitempty"`
+	RegionCountry   string `json:"region_country,omitempty"`
+
+	// ServiceCategory is the FOCUS category of Service, empty when no
+	// service_categories table lists it.
+	ServiceCategory string `json:"service_category,omitempty"`
 
 	// Usage metrics.
 	UsageAmount *float64 `json:"usage_amount,omitempty"`
 	UsageUnit   string   `json:"usage_unit,omitempty"`
 
+	// Pricing: the cost per PricingUnit actually paid, as FOCUS pricing
+	// columns name them.
+	EffectiveUnitPrice *float64 `json:"effective_unit_price,omitempty"`
+	PricingUnit        string   `json:"pricing_unit,omitempty"`
+
 	// Cost metrics.
 	ListCost      *float64 `json:"list_cost,omitempty"`
 	NetCost       *float64 `json:"net_cost,omitempty"`
@@ -38,27 +83,68 @@ type CostRecord struct {
 	CreditAmount  *float64 `json:"credit_amount,omitempty"`
 	RefundAmount  *float64 `json:"refund_amount,omitempty"`
 
+	// EffectiveCost is the cost on the configured cost basis (CostBasis:
+	// "net", "amortized", or "list"), set only when cost_basis or
+	// provider_cost_basis is configured.
+	EffectiveCost *float64 `json:"effective_cost,omitempty"`
+	CostBasis     string   `json:"cost_basis,omitempty"`
+
+	// Budget metrics (metric_type "budget" only).
+	BudgetToken              string   `json:"budget_token,omitempty"`
+	BudgetName               string   `json:"budget_name,omitempty"`
+	BudgetAmount             *float64 `json:"budget_amount,omitempty"`
+	BudgetUtilizationPercent *float64 `json:"budget_utilization_percent,omitempty"`
+
+	// Forecast snapshot (metric_type "forecast" only): the UTC day the
+	// forecast was fetched, so past forecasts can be compared with actuals.
+	ForecastSnapshotDate string `json:"forecast_snapshot_date,omitempty"`
+
+	// Recommendation metrics (metric_type "recommendation" only).
+	RecommendationToken       string   `json:"recommendation_token,omitempty"`
+	RecommendationCategory    string   `json:"recommendation_category,omitempty"`
+	RecommendationDescription string   `json:"recommendation_description,omitempty"`
+	EstimatedMonthlySavings   *float64 `json:"estimated_monthly_savings,omitempty"`
+
 	// Metadata.
-	Currency          string `json:"currency,omitempty"`
-	SourceReportToken string `json:"source_report_token,omitempty"`
-	QueryHash         string `json:"query_hash"`
-	LineItemID        string `json:"line_item_id"`          // FOCUS 1.2 idempotency key (report_token, date, dimensions, metrics hash)
-	MetricType        string `json:"metric_type,omitempty"` // "cost" or "forecast"
+	Currency           string   `json:"currency,omitempty"`
+	OriginalCurrency   string   `json:"original_currency,omitempty"` // Currency before conversion to target_currency
+	FXRate             *float64 `json:"fx_rate,omitempty"`           // Rate applied: one unit of OriginalCurrency in Currency
+	SourceReportToken  string   `json:"source_report_token,omitempty"`
+	SourceReportName   string   `json:"source_report_name,omitempty"` // cost_report_name the token was resolved from
+	QueryHash          string   `json:"query_hash"`
+	LineItemID         string   `json:"line_item_id"`                    // FOCUS 1.2 idempotency key (report_token, date, dimensions, metrics hash)
+	LineItemHash       string   `json:"line_item_hash,omitempty"`        // Hash algorithm of LineItemID when not sha256 (see line_item_hash)
+	RestatesLineItemID string   `json:"restates_line_item_id,omitempty"` // LineItemID of the earlier record this one replaces
+	MetricType         string   `json:"metric_type,omitempty"`           // "cost", "forecast", "budget", "recommendation", or "deletion"
+
+	// Allocation: set on records an allocation rule derived from a shared
+	// cost record.
+	AllocationRuleID        string `json:"allocation_rule_id,omitempty"`
+	AllocatedFromLineItemID string `json:"allocated_from_line_item_id,omitempty"`
 
 	// Diagnostics.
 	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
 }
 
+// ErrSink marks errors raised by a Sink, so callers can tell storage
+// failures from API failures.
+var ErrSink = errors.New("sink failure")
+
 // Sink defines the interface for persisting cost records.
 // This interface is assumed to exist in pulumicost-core.
 type Sink interface {
 	// WriteRecords writes cost records to the data store.
 	WriteRecords(ctx context.Context, records []CostRecord) error
+}
 
-	// GetBookmark retrieves the last successful sync bookmark.
+// BookmarkStore persists sync state (incremental bookmarks and backfill
+// checkpoints) separately from records, so records can go to an append-only
+// store while state lives somewhere transactional.
+type BookmarkStore interface {
+	// GetBookmark retrieves a bookmark, returning "" when it does not exist.
 	GetBookmark(ctx context.Context, key string) (string, error)
 
-	// SetBookmark stores the last successful sync bookmark.
+	// SetBookmark stores a bookmark.
 	SetBookmark(ctx context.Context, key string, value string) error
 }
 
@@ -67,15 +153,98 @@ type Adapter struct {
 	client             client.Client
 	logger             client.Logger
 	diagnosticsSummary *DiagnosticsSummary
+	stats              SyncStats
+	bookmarks          BookmarkStore
+	fallbackBookmarks  BookmarkStore
+	converter          CurrencyConverter
+	deadLetters        DeadLetterQueue
+	journal            Journal
+	journalKey         string              // set while a sync journals its batches
+	journalSeq         uint64              // last entry appended under journalKey
+	journaled          map[string]struct{} // LineItemIDs committed by an interrupted sync
+	tags               *tagFilter
+	locker             lock.Locker
+	lockWait           time.Duration
+	transforms         []Transformer
+	customTransforms   []Transformer
+	allocationRules    []allocationRule
+
+	// forecastReportToken is the report forecast for a workspace-only
+	// query, resolved once per sync.
+	forecastReportToken string
+
+	// reportTokens caches cost_report_name resolutions by workspace and
+	// lower-cased name; reportNames maps the tokens back to their names.
+	reportTokens map[string]string
+	reportNames  map[string]string
+
+	// fields decides which omitted fields diagnostics flag, per provider.
+	fields *fieldPolicy
+	// costBasis fills in EffectiveCost; nil leaves it unset.
+	costBasis *costBasisPolicy
+	// serviceCategories fills in ServiceCategory.
+	serviceCategories serviceCategories
+	// regionGeography fills in RegionContinent and RegionCountry.
+	regionGeography bool
+	// lineItemHash is the hash algorithm of cost record LineItemIDs.
+	lineItemHash string
+	// prefetchPages fetches each next costs page while the current one is
+	// mapped.
+	prefetchPages bool
+	// pageRetries is how many more times a failed costs page is requested.
+	pageRetries int
+	// sinkWriteRetries is how many more times records the sink failed to
+	// write are written, waiting sinkRetryBackoff, doubled each time.
+	sinkWriteRetries int
+	sinkRetryBackoff time.Duration
+
+	// sinkWriters and sinkWriteQueue size the pool writing batches of
+	// fetched records; with one writer and no queue, batches are written
+	// in line.
+	sinkWriters    int
+	sinkWriteQueue int
+	// correlationID identifies the current sync in logs, requests, and
+	// record diagnostics.
+	correlationID string
+	// unknownFields are the response fields the client did not map that
+	// the current sync has already logged.
+	unknownFields map[string]struct{}
+
+	// watermark tracks the latest final bucket fetched by the current
+	// sync; nil when the watermark is not advanced.
+	watermark *watermarkTracker
 }
 
-// New creates a new Vantage adapter.
-func New(client client.Client, logger client.Logger) *Adapter {
+// New creates a new Vantage adapter. Log fields are redacted before they
+// reach logger.
+func New(apiClient client.Client, logger client.Logger) *Adapter {
 	return &Adapter{
-		client:             client,
-		logger:             logger,
+		client:             apiClient,
+		logger:             client.NewRedactingLogger(logger),
 		diagnosticsSummary: NewDiagnosticsSummary(),
+		fallbackBookmarks:  bookmark.NewMemory(),
+		tags:               defaultTagFilter(),
+		serviceCategories:  newServiceCategories(nil),
+		sinkRetryBackoff:   defaultSinkRetryBackoff,
+	}
+}
+
+// SetBookmarkStore sets where sync state is persisted. Without a store the
+// adapter uses the sink when it also implements BookmarkStore, and otherwise
+// keeps bookmarks in memory for the lifetime of the adapter.
+func (a *Adapter) SetBookmarkStore(store BookmarkStore) {
+	a.bookmarks = store
+}
+
+// bookmarkStore resolves the store used for a sync into sink.
+func (a *Adapter) bookmarkStore(sink Sink) BookmarkStore {
+	if a.bookmarks != nil {
+		return a.bookmarks
 	}
+	if store, ok := sink.(BookmarkStore); ok {
+		return store
+	}
+	return a.fallbackBookmarks
 }
 
 // GetDiagnosticsSummary returns the aggregated diagnostics from the last sync operation.
@@ -89,9 +258,16 @@ func (a *Adapter) ResetDiagnosticsSummary() {
 }
 
 // Sync performs a cost data sync operation.
-func (a *Adapter) Sync(ctx context.Context, cfg Config, sink Sink) error {
-	// Reset diagnostics summary for this sync operation.
-	a.ResetDiagnosticsSummary()
+func (a *Adapter) Sync(ctx context.Context, cfg Config, sink Sink) (err error) {
+	ctx = a.startRun(ctx, cfg)
+
+	ctx, span := tracer().Start(ctx, "vantage.sync", trace.WithAttributes(
+		attribute.String("vantage.correlation_id", a.correlationID),
+		attribute.String("vantage.profile", cfg.Profile),
+		attribute.String("vantage.granularity", cfg.Granularity),
+		attribute.Bool(attrBackfill, cfg.EndDate != nil),
+	))
+	defer func() { finishSpan(span, err) }()
 
 	a.logger.Info(ctx, "Starting Vantage adapter sync", map[string]interface{}{
 		"adapter":   "vantage",
@@ -99,8 +275,47 @@ func (a *Adapter) Sync(ctx context.Context, cfg Config, sink Sink) error {
 		"attempt":   0,
 	})
 
+	if err := a.resolveCostReport(ctx, &cfg); err != nil {
+		return err
+	}
+
+	// Hold the report's lock so overlapping runs do not double-write
+	// records or race on bookmarks.
+	ctx, release, err := a.acquireSyncLock(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := a.applySettings(cfg); err != nil {
+		return err
+	}
+
+	// Finish any batch an interrupted sync of the report left unwritten
+	// before fetching.
+	if err := a.recoverJournal(ctx, cfg, sink); err != nil {
+		return err
+	}
+	defer func() { a.journalKey = "" }()
+
+	transforms, err := configuredTransforms(cfg)
+	if err != nil {
+		return err
+	}
+	a.transforms = transforms
+
+	allocationRules, err := compileAllocationRules(cfg.AllocationRules)
+	if err != nil {
+		return err
+	}
+	a.allocationRules = allocationRules
+
+	// Check tag configuration against the workspace before querying.
+	a.applyTagDiscovery(ctx, &cfg)
+	a.resolveForecastReport(ctx, cfg)
+	a.startWatermark(ctx, cfg, time.Now())
+
 	// Determine sync mode based on configuration.
-	var err error
 	if cfg.EndDate == nil {
 		// Incremental sync: D-3 to D-1.
 		err = a.syncIncremental(ctx, cfg, sink)
@@ -109,18 +324,88 @@ func (a *Adapter) Sync(ctx context.Context, cfg Config, sink Sink) error {
 		err = a.syncBackfill(ctx, cfg, sink)
 	}
 
+	// Budgets are synced once per run rather than per date range.
+	if err == nil {
+		a.handleBudgets(ctx, cfg, sink)
+		a.finishWatermark(ctx, cfg, sink)
+		a.resetJournal(ctx)
+	}
+
+	if err != nil && errors.Is(context.Cause(ctx), ErrSyncLockLost) {
+		err = fmt.Errorf("%w: %w", ErrSyncLockLost, err)
+	}
+
+	if len(a.tags.dropped) > 0 {
+		a.diagnosticsSummary.SourceInfo["tag_keys_dropped"] = a.tags.dropped
+	}
+	if cfg.IncludeUnallocated {
+		a.diagnosticsSummary.TrackUnallocated()
+	}
+	if err == nil {
+		a.recordQuality(ctx, cfg, sink, time.Now())
+	}
+
 	// Log diagnostic summary after sync completes, passing the error.
 	a.logDiagnosticsSummary(ctx, err)
 
 	return err
 }
 
-// syncIncremental performs incremental sync with D-3 to D-1 lag window.
+// startRun resets the diagnostics and counts for a new run and returns ctx
+// carrying the run's correlation ID.
+func (a *Adapter) startRun(ctx context.Context, cfg Config) context.Context {
+	a.ResetDiagnosticsSummary()
+	if cfg.Diagnostics.ReportPath != "" {
+		a.diagnosticsSummary.EnableBreakdown()
+	}
+	a.stats = SyncStats{Bookmarks: []BookmarkChange{}}
+	a.unknownFields = make(map[string]struct{})
+
+	// One correlation ID ties the run's log lines and API requests
+	// together; a caller may supply it through ctx.
+	a.correlationID = client.CorrelationID(ctx)
+	if a.correlationID == "" {
+		a.correlationID = client.NewCorrelationID()
+		ctx = client.WithCorrelationID(ctx, a.correlationID)
+	}
+	a.diagnosticsSummary.CorrelationID = a.correlationID
+	return ctx
+}
+
+// applySettings applies the config settings that shape how records are
+// mapped and written.
+func (a *Adapter) applySettings(cfg Config) error {
+	tags, err := newTagFilter(cfg.Tags)
+	if err != nil {
+		return err
+	}
+	a.tags = tags
+	a.fields = newFieldPolicy(cfg.Diagnostics)
+	a.costBasis = newCostBasisPolicy(cfg)
+	a.serviceCategories = newServiceCategories(cfg.ServiceCategories)
+	a.regionGeography = cfg.RegionGeography
+	a.lineItemHash = cfg.LineItemHash
+	a.prefetchPages = cfg.PrefetchPages
+	a.pageRetries = cfg.PageRetries
+	a.sinkWriteRetries = cfg.Sink.WriteRetries
+	a.sinkWriters = cfg.Sink.Writers
+	a.sinkWriteQueue = cfg.Sink.WriteQueue
+	return nil
+}
+
+// syncIncremental performs incremental sync with D-3 to D-1 lag window,
+// widened to the restatement window when one is configured.
 func (a *Adapter) syncIncremental(ctx context.Context, cfg Config, sink Sink) error {
 	now := time.Now().UTC()
 	startDate := now.AddDate(0, 0, -3) // D-3
 	endDate := now.AddDate(0, 0, -1)   // D-1
 
+	if cfg.RestatementWindowDays > 0 {
+		if windowStart := endDate.AddDate(0, 0, -cfg.RestatementWindowDays); windowStart.Before(startDate) {
+			startDate = windowStart
+		}
+	}
+
 	a.logger.Info(ctx, "Performing incremental sync", map[string]interface{}{
 		"adapter":    "vantage",
 		"operation":  "incremental_sync",
@@ -162,20 +447,55 @@ func (a *Adapter) syncDateRange(
 	}
 
 	// Single range sync.
-	return a.syncSingleRange(ctx, cfg, sink, startDate, endDate, isBackfill)
+	if err := a.syncSingleRange(ctx, cfg, sink, startDate, endDate, isBackfill); err != nil {
+		return err
+	}
+	a.resetJournal(ctx)
+	return nil
 }
 
-// syncChunked performs chunked sync by month for large date ranges.
+// syncChunked performs chunked sync by month for large date ranges. Each
+// completed chunk is checkpointed in the bookmark store so a re-run of
+// the same backfill skips chunks that already finished.
 func (a *Adapter) syncChunked(ctx context.Context, cfg Config, sink Sink, startDate, endDate time.Time) error {
-	current := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	backfillHash := a.generateQueryHash(client.Query{
+		WorkspaceToken:  cfg.WorkspaceToken,
+		CostReportToken: cfg.CostReportToken,
+		StartAt:         startDate,
+		EndAt:           endDate,
+		Granularity:     cfg.Granularity,
+		GroupBys:        cfg.GroupBys,
+		Metrics:         cfg.Metrics,
+		Filter:          cfg.Filter,
+
+		IncludeUnallocated: cfg.IncludeUnallocated,
+	})
+	// Rolled-up backfills chunk and write differently, so they keep their
+	// own checkpoints.
+	backfillHash += rollupCheckpointSuffix(cfg)
+
+	current := chunkStart(startDate, cfg.OutputGranularity)
+	skipped := 0
 
 	for current.Before(endDate) {
-		chunkEnd := time.Date(current.Year(), current.Month()+1, 1, 0, 0, 0, 0, time.UTC)
-		if chunkEnd.After(endDate) {
-			chunkEnd = endDate
+		chunkEnd := nextChunkEnd(current, endDate, cfg.OutputGranularity)
+
+		checkpointKey := chunkCheckpointKey(backfillHash, current, chunkEnd)
+		if a.chunkCompleted(ctx, sink, checkpointKey) {
+			a.logger.Info(ctx, "Skipping completed backfill chunk", map[string]interface{}{
+				"adapter":     "vantage",
+				"operation":   "backfill_chunk",
+				"attempt":     0,
+				"chunk_start": current.Format("2006-01-02"),
+				"chunk_end":   chunkEnd.Format("2006-01-02"),
+			})
+			skipped++
+			current = chunkEnd
+			continue
 		}
 
 		if err := a.syncSingleRange(ctx, cfg, sink, current, chunkEnd, true); err != nil {
+			a.recordRemainingChunks(ctx, current, endDate, cfg.OutputGranularity)
 			return fmt.Errorf(
 				"syncing chunk %s to %s: %w",
 				current.Format("2006-01-02"),
@@ -184,20 +504,90 @@ func (a *Adapter) syncChunked(ctx context.Context, cfg Config, sink Sink, startD
 			)
 		}
 
+		a.markChunkCompleted(ctx, sink, checkpointKey)
+		a.resetJournal(ctx)
 		current = chunkEnd
 	}
 
+	a.diagnosticsSummary.SourceInfo["backfill_chunks_skipped"] = skipped
+	a.stats.ChunksSkipped = skipped
+
 	return nil
 }
 
-// syncSingleRange syncs a single date range.
+// recordRemainingChunks notes the chunks from start to endDate as left
+// unsynced after a backfill stopped at start.
+func (a *Adapter) recordRemainingChunks(ctx context.Context, start, endDate time.Time, outputGranularity string) {
+	var remaining []ChunkRange
+	for current := start; current.Before(endDate); {
+		chunkEnd := nextChunkEnd(current, endDate, outputGranularity)
+		remaining = append(remaining, ChunkRange{
+			Start: current.Format("2006-01-02"),
+			End:   chunkEnd.Format("2006-01-02"),
+		})
+		current = chunkEnd
+	}
+	a.stats.ChunksRemaining = remaining
+
+	a.logger.Warn(ctx, "Backfill stopped with chunks remaining", map[string]interface{}{
+		"adapter":          "vantage",
+		"operation":        "backfill_chunk",
+		"attempt":          0,
+		"chunks_remaining": len(remaining),
+		"resume_from":      start.Format("2006-01-02"),
+	})
+}
+
+// chunkCheckpointKey builds the bookmark key marking a backfill chunk done.
+func chunkCheckpointKey(backfillHash string, chunkStart, chunkEnd time.Time) string {
+	return fmt.Sprintf(
+		"vantage_backfill_%s_%s_%s",
+		backfillHash,
+		chunkStart.Format("2006-01-02"),
+		chunkEnd.Format("2006-01-02"),
+	)
+}
+
+// chunkCompleted reports whether a checkpoint exists for the chunk. Lookup
+// errors are logged and treated as not completed, so the chunk is re-synced.
+func (a *Adapter) chunkCompleted(ctx context.Context, sink Sink, key string) bool {
+	value, err := a.bookmarkStore(sink).GetBookmark(ctx, key)
+	if err != nil {
+		a.logger.Warn(ctx, "Failed to read backfill checkpoint", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "backfill_checkpoint",
+			"attempt":   0,
+			"error":     err,
+		})
+		return false
+	}
+	return value != ""
+}
+
+// markChunkCompleted records a checkpoint for a finished chunk.
+func (a *Adapter) markChunkCompleted(ctx context.Context, sink Sink, key string) {
+	value := time.Now().UTC().Format(time.RFC3339)
+	if err := a.bookmarkStore(sink).SetBookmark(ctx, key, value); err != nil {
+		a.logger.Warn(ctx, "Failed to write backfill checkpoint", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "backfill_checkpoint",
+			"attempt":   0,
+			"error":     err,
+		})
+		return
+	}
+	a.stats.Bookmarks = append(a.stats.Bookmarks, BookmarkChange{Key: key, After: value})
+}
+
+// syncSingleRange syncs a single date range. Its span carries the query
+// hash and range, so each backfill chunk can be told apart in a trace.
 func (a *Adapter) syncSingleRange(
 	ctx context.Context,
 	cfg Config,
 	sink Sink,
 	startDate, endDate time.Time,
 	isBackfill bool,
-) error {
+) (err error) {
 	query := client.Query{
 		WorkspaceToken:  cfg.WorkspaceToken,
 		CostReportToken: cfg.CostReportToken,
@@ -207,37 +597,105 @@ func (a *Adapter) syncSingleRange(
 		GroupBys:        cfg.GroupBys,
 		Metrics:         cfg.Metrics,
 		PageSize:        cfg.PageSize,
+		Filter:          cfg.Filter,
+
+		IncludeUnallocated: cfg.IncludeUnallocated,
 	}
 
 	// Generate idempotency key.
 	queryHash := a.generateQueryHash(query)
 	bookmarkKey := fmt.Sprintf("vantage_%s", queryHash)
 
+	ctx, span := tracer().Start(ctx, "vantage.sync_range", trace.WithAttributes(
+		attribute.String(attrQueryHash, queryHash),
+		attribute.String(attrChunkStart, startDate.Format("2006-01-02")),
+		attribute.String(attrChunkEnd, endDate.Format("2006-01-02")),
+		attribute.Bool(attrBackfill, isBackfill),
+	))
+	defer func() { finishSpan(span, err) }()
+
 	// Apply bookmark for incremental sync.
-	a.applyBookmark(ctx, &query, sink, bookmarkKey, isBackfill)
+	previousBookmark := a.applyBookmark(ctx, &query, sink, bookmarkKey, isBackfill)
+
+	// Rolled-up week and quarter buckets are only written whole, so
+	// fetching starts at the start of the first bucket.
+	var rollup *aggregator
+	if rolledUp(cfg) {
+		if cfg.OutputGranularity != "" {
+			query.StartAt = bucketStart(query.StartAt, cfg.OutputGranularity)
+		}
+		rollup = newAggregator(cfg.OutputGranularity, rollupDimensions(cfg), query, queryHash)
+	}
+
+	var alloc *allocator
+	if len(a.allocationRules) > 0 {
+		alloc = newAllocator(a.allocationRules)
+	}
+
+	var gaps *gapFiller
+	if cfg.FillMissingBuckets {
+		gaps = newGapFiller(query.Granularity, query.StartAt, query.EndAt)
+	}
 
-	// Fetch and collect all records.
-	allRecords, pageCount, err := a.fetchAndCollectRecords(ctx, query, queryHash)
+	// Incremental pulls with a restatement window always re-fetch the whole
+	// window and compare rows against what earlier pulls wrote.
+	var tracker *restatementTracker
+	if !isBackfill && cfg.RestatementWindowDays > 0 {
+		query.StartAt = startDate
+		tracker = newRestatementTracker(a.bookmarkStore(sink), a.reportQueryHash(cfg), a.logger)
+	}
+
+	// Fetch pages and stream records to the sink in batches.
+	spend := a.diagnosticsSummary.netCost
+	pageCount, recordCount, err := a.fetchAndWriteRecords(ctx, query, queryHash, sink, cfg.BatchSize, tracker, rollup, alloc, gaps)
 	if err != nil {
 		return err
 	}
 
+	if cfg.VerifyTotals {
+		a.verifyTotals(ctx, query, a.diagnosticsSummary.netCost-spend, cfg.VerifyTotalsTolerance)
+	}
+
+	if gaps != nil && gaps.written > 0 {
+		filled, _ := a.diagnosticsSummary.SourceInfo["synthetic_zero_records"].(int)
+		a.diagnosticsSummary.SourceInfo["synthetic_zero_records"] = filled + gaps.written
+	}
+
+	if alloc != nil {
+		a.finishAllocation(ctx, alloc)
+	}
+
+	if rollup != nil && rollup.held > 0 {
+		held, _ := a.diagnosticsSummary.SourceInfo["incomplete_buckets_held"].(int)
+		a.diagnosticsSummary.SourceInfo["incomplete_buckets_held"] = held + rollup.held
+		a.logger.Info(ctx, "Holding back incomplete buckets until a later sync covers them", map[string]interface{}{
+			"adapter":            "vantage",
+			"operation":          "rollup",
+			"attempt":            0,
+			"output_granularity": cfg.OutputGranularity,
+			"buckets":            rollup.held,
+		})
+	}
+
+	if tracker != nil {
+		a.finishRestatement(ctx, tracker)
+	}
+
+	span.SetAttributes(attribute.Int(attrPages, pageCount), attribute.Int(attrRecords, recordCount))
+	a.stats.Chunks++
+	a.stats.Pages += pageCount
+
 	a.logger.Info(ctx, "Fetched cost data", map[string]interface{}{
 		"adapter":    "vantage",
 		"operation":  "fetch_cost_data",
 		"attempt":    0,
 		"pages":      pageCount,
-		"records":    len(allRecords),
+		"records":    recordCount,
 		"query_hash": queryHash,
 	})
 
-	// Write records.
-	if err = sink.WriteRecords(ctx, allRecords); err != nil {
-		return fmt.Errorf("writing records: %w", err)
-	}
-
 	// Update bookmark for incremental sync.
-	a.updateBookmark(ctx, sink, bookmarkKey, endDate, isBackfill)
+	a.updateBookmark(ctx, sink, bookmarkKey, previousBookmark, endDate, isBackfill)
 
 	// Handle forecast if enabled.
 	a.handleForecast(ctx, cfg, sink, startDate, endDate, queryHash)
@@ -245,20 +703,24 @@ func (a *Adapter) syncSingleRange(
 	return nil
 }
 
-// applyBookmark applies the last saved bookmark to resume from a previous sync.
+// applyBookmark applies the last saved bookmark to resume from a previous
+// sync, returning the bookmark ("" when none was found).
 func (a *Adapter) applyBookmark(
 	ctx context.Context,
 	query *client.Query,
 	sink Sink,
 	bookmarkKey string,
 	isBackfill bool,
-) {
+) string {
 	if isBackfill {
-		return
+		return ""
 	}
 
-	lastEndDate, err := sink.GetBookmark(ctx, bookmarkKey)
-	if err == nil && lastEndDate != "" {
+	lastEndDate, err := a.bookmarkStore(sink).GetBookmark(ctx, bookmarkKey)
+	if err != nil {
+		return ""
+	}
+	if lastEndDate != "" {
 		if parsed, parseErr := time.Parse(time.RFC3339, lastEndDate); parseErr == nil {
 			query.StartAt = parsed
 			a.logger.Info(ctx, "Resuming from bookmark", map[string]interface{}{
@@ -269,46 +731,298 @@ func (a *Adapter) applyBookmark(
 			})
 		}
 	}
+	return lastEndDate
 }
 
-// fetchAndCollectRecords fetches pages of data and collects them into records.
-func (a *Adapter) fetchAndCollectRecords(
+// fetchAndWriteRecords fetches pages of data, maps each page, and flushes
+// records to the sink whenever batchSize records have accumulated. Memory is
+// bounded by one page plus one batch regardless of the range size. With a
+// tracker, rows already written unchanged are skipped, restated rows carry
+// the LineItemID they replace, and vanished rows become tombstones. With a
+// rollup, records are summed into its buckets and only complete buckets are
+// written, once every page has been fetched. With an allocator, shared cost
+// records are replaced by their splits before any rollup. With a gap filler,
+// zero records are added for the buckets each dimension combination had no
+// data in.
+func (a *Adapter) fetchAndWriteRecords(
 	ctx context.Context,
 	query client.Query,
 	queryHash string,
-) ([]CostRecord, int, error) {
-	pager := client.NewPager(a.client, query, a.logger)
+	sink Sink,
+	batchSize int,
+	tracker *restatementTracker,
+	rollup *aggregator,
+	alloc *allocator,
+	gaps *gapFiller,
+) (int, int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
 
-	var allRecords []CostRecord
+	pager := client.NewPager(a.client, query, a.logger,
+		client.WithPrefetch(a.prefetchPages),
+		client.WithPageRetries(a.pageRetries),
+	)
+
+	batch := make([]CostRecord, 0, batchSize)
 	pageCount := 0
+	recordCount := 0
+	batchCount := 0
+
+	// Vantage can repeat a row on a later page; seen drops the repeats. It
+	// holds the last few pages' IDs, so memory stays bounded on long
+	// ranges.
+	seen := newRecentIDs(dedupeWindowPages)
+	duplicates := a.diagnosticsSummary.DuplicateRecords
+	defer func() {
+		if repeated := a.diagnosticsSummary.DuplicateRecords - duplicates; repeated > 0 {
+			a.logger.Warn(ctx, "Dropped duplicate rows returned across pages", map[string]interface{}{
+				"adapter":    "vantage",
+				"operation":  "fetch_cost_data",
+				"attempt":    0,
+				"duplicates": repeated,
+				"query_hash": queryHash,
+			})
+		}
+	}()
+
+	// With parallel writers, flush hands batches to the pool, blocking
+	// while its queue is full.
+	var writers *sinkWriters
+	if a.sinkWriters > 1 || a.sinkWriteQueue > 0 {
+		writers = a.newSinkWriters(ctx, sink, max(a.sinkWriters, 1), a.sinkWriteQueue)
+		defer func() {
+			if writers != nil {
+				_ = writers.wait(ctx)
+			}
+		}()
+	}
 
-	for pager.HasMore() || pageCount == 0 {
-		page, err := pager.NextPage(ctx)
+	flush := func() error {
+		var err error
+		if writers != nil {
+			err = writers.submit(ctx, batch)
+		} else {
+			err = a.writeRecords(ctx, sink, batch)
+		}
 		if err != nil {
-			return nil, 0, fmt.Errorf("fetching page: %w", err)
+			return fmt.Errorf("writing records: %w", err)
 		}
+		recordCount += len(batch)
+		batchCount++
+		batch = make([]CostRecord, 0, batchSize)
+		return nil
+	}
+
+	// emit queues a mapped record for the rollup or the next batch.
+	emit := func(record CostRecord) error {
+		if rollup != nil {
+			rollup.add(record)
+			return nil
+		}
+		batch = append(batch, record)
+		if len(batch) >= batchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	var previous *client.Page
+	for page, err := range pager.Pages(ctx) {
+		if err != nil {
+			return 0, 0, fmt.Errorf("fetching page: %w", err)
+		}
+
+		a.checkRetriedPage(ctx, page, previous, query, queryHash, seen)
+		previous = &page
+		seen.nextPage()
 
-		// Convert Vantage rows to CostRecords.
-		for _, row := range page.Data {
-			record := a.mapVantageRowToCostRecord(row, query, queryHash, "cost")
-			allRecords = append(allRecords, record)
+		for _, record := range a.mapPage(ctx, page.Data, query, queryHash, tracker, seen) {
 			a.diagnosticsSummary.AddRecordDiagnostics(record.Diagnostics)
+			a.diagnosticsSummary.AddRecordBreakdown(&record)
+			a.watermark.observe(&record)
+			if gaps != nil {
+				gaps.add(&record)
+			}
+			records := []CostRecord{record}
+			if alloc != nil {
+				records = alloc.add(record)
+			}
+			for _, r := range records {
+				if emitErr := emit(r); emitErr != nil {
+					return 0, 0, emitErr
+				}
+			}
 		}
 
 		pageCount++
-		if !page.HasMore {
-			break
+	}
+
+	if alloc != nil {
+		for _, record := range alloc.records() {
+			if emitErr := emit(record); emitErr != nil {
+				return 0, 0, emitErr
+			}
+		}
+	}
+
+	if gaps != nil {
+		for _, record := range gaps.records() {
+			if emitErr := emit(record); emitErr != nil {
+				return 0, 0, emitErr
+			}
 		}
 	}
 
-	return allRecords, pageCount, nil
+	if rollup != nil {
+		for _, record := range rollup.records() {
+			batch = append(batch, record)
+
+			if len(batch) >= batchSize {
+				if flushErr := flush(); flushErr != nil {
+					return 0, 0, flushErr
+				}
+			}
+		}
+	}
+
+	// Day-granularity re-pulls reconcile whole days, so rows missing from
+	// this pull are emitted as tombstones.
+	if tracker != nil && query.Granularity == "day" {
+		for _, tombstone := range tracker.tombstones(ctx, query.StartAt, query.EndAt, query.CostReportToken, queryHash) {
+			batch = append(batch, tombstone)
+			a.diagnosticsSummary.AddRecordDiagnostics(tombstone.Diagnostics)
+
+			if len(batch) >= batchSize {
+				if flushErr := flush(); flushErr != nil {
+					return 0, 0, flushErr
+				}
+			}
+		}
+	}
+
+	// Flush the remainder; an empty range still issues a single write so
+	// sinks observe every synced window.
+	if len(batch) > 0 || batchCount == 0 {
+		if err := flush(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if writers != nil {
+		err := writers.wait(ctx)
+		writers = nil
+		if err != nil {
+			return 0, 0, fmt.Errorf("writing records: %w", err)
+		}
+	}
+
+	return pageCount, recordCount, nil
+}
+
+// mapPage converts one page of Vantage rows to CostRecords. Rows whose
+// LineItemID is already in seen, on this or a recent page, are dropped as
+// duplicates and counted in the diagnostics summary. With a tracker, rows
+// already written unchanged are dropped and restated rows carry the
+// LineItemID they replace.
+func (a *Adapter) mapPage(
+	ctx context.Context,
+	rows []client.CostRow,
+	query client.Query,
+	queryHash string,
+	tracker *restatementTracker,
+	seen *recentIDs,
+) []CostRecord {
+	ctx, span := tracer().Start(ctx, "vantage.map_page", trace.WithAttributes(
+		attribute.String(attrQueryHash, queryHash),
+		attribute.Int(attrRows, len(rows)),
+	))
+	defer span.End()
+
+	records := make([]CostRecord, 0, len(rows))
+	for _, row := range rows {
+		lineItemID := a.lineItemID(row, query)
+		if seen.contains(lineItemID) {
+			a.diagnosticsSummary.DuplicateRecords++
+			continue
+		}
+		seen.add(lineItemID)
+		a.diagnosticsSummary.AddSpend(row.Cost, row.Unallocated)
+		record := a.mapVantageRowToCostRecord(row, query, queryHash, "cost")
+
+		if tracker != nil {
+			state, previousID := tracker.observe(
+				ctx,
+				row.BucketStart.UTC().Format("2006-01-02"),
+				generateRowKey(query.CostReportToken, row),
+				record.LineItemID,
+			)
+			if state == rowUnchanged {
+				continue
+			}
+			if state == rowRestated {
+				record.RestatesLineItemID = previousID
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	span.SetAttributes(attribute.Int(attrRecords, len(records)))
+	return records
+}
+
+// finishAllocation records how many records allocation rules derived, and
+// warns about shared records written unsplit for lack of labeled spend.
+func (a *Adapter) finishAllocation(ctx context.Context, alloc *allocator) {
+	allocated, _ := a.diagnosticsSummary.SourceInfo["allocated_records"].(int)
+	a.diagnosticsSummary.SourceInfo["allocated_records"] = allocated + alloc.allocated
+	if alloc.unallocated == 0 {
+		return
+	}
+	a.diagnosticsSummary.Warnings["allocation_without_spend"] += alloc.unallocated
+	a.logger.Warn(ctx, "Shared cost records had no labeled spend to allocate by", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "allocate",
+		"attempt":   0,
+		"records":   alloc.unallocated,
+	})
+}
+
+// finishRestatement saves the row state of a restatement-window pull and
+// records how many rows were restated. A failed save is logged rather than
+// failing the sync; the next pull then re-emits the window's rows.
+func (a *Adapter) finishRestatement(ctx context.Context, tracker *restatementTracker) {
+	a.diagnosticsSummary.SourceInfo["restated_rows"] = tracker.restated
+	a.diagnosticsSummary.SourceInfo["restatement_unchanged_rows"] = tracker.unchanged
+	a.diagnosticsSummary.SourceInfo["deleted_rows"] = tracker.deleted
+
+	if tracker.restated > 0 || tracker.deleted > 0 {
+		a.logger.Info(ctx, "Detected restated cost rows", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "restatement",
+			"attempt":   0,
+			"restated":  tracker.restated,
+			"deleted":   tracker.deleted,
+		})
+	}
+
+	if err := tracker.save(ctx); err != nil {
+		a.logger.Warn(ctx, "Failed to save restatement state", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "restatement",
+			"attempt":   0,
+			"error":     err,
+		})
+	}
 }
 
-// updateBookmark saves the last end date for incremental syncs.
+// updateBookmark saves the last end date for incremental syncs, replacing
+// previous.
 func (a *Adapter) updateBookmark(
 	ctx context.Context,
 	sink Sink,
-	bookmarkKey string,
+	bookmarkKey, previous string,
 	endDate time.Time,
 	isBackfill bool,
 ) {
@@ -317,17 +1031,24 @@ func (a *Adapter) updateBookmark(
 	}
 
 	bookmarkValue := endDate.Format(time.RFC3339)
-	if err := sink.SetBookmark(ctx, bookmarkKey, bookmarkValue); err != nil {
+	if err := a.bookmarkStore(sink).SetBookmark(ctx, bookmarkKey, bookmarkValue); err != nil {
 		a.logger.Warn(ctx, "Failed to update bookmark", map[string]interface{}{
 			"adapter":   "vantage",
 			"operation": "update_bookmark",
 			"attempt":   0,
 			"error":     err,
 		})
+		return
 	}
+	a.stats.Bookmarks = append(a.stats.Bookmarks, BookmarkChange{
+		Key:    bookmarkKey,
+		Before: previous,
+		After:  bookmarkValue,
+	})
 }
 
-// handleForecast syncs forecast data if enabled.
+// handleForecast syncs forecast data if enabled. Workspace-only queries
+// forecast the report resolveForecastReport picked, if any.
 func (a *Adapter) handleForecast(
 	ctx context.Context,
 	cfg Config,
@@ -335,9 +1056,15 @@ func (a *Adapter) handleForecast(
 	startDate, endDate time.Time,
 	queryHash string,
 ) {
-	if !cfg.IncludeForecast || cfg.CostReportToken == "" {
+	if !cfg.IncludeForecast {
 		return
 	}
+	if cfg.CostReportToken == "" {
+		if a.forecastReportToken == "" {
+			return
+		}
+		cfg.CostReportToken = a.forecastReportToken
+	}
 
 	if err := a.syncForecast(ctx, cfg, sink, startDate, endDate, queryHash); err != nil {
 		a.logger.Warn(ctx, "Forecast sync failed", map[string]interface{}{
@@ -349,7 +1076,9 @@ func (a *Adapter) handleForecast(
 	}
 }
 
-// syncForecast syncs forecast data for the given date range.
+// syncForecast syncs forecast data for the given date range. Records are
+// keyed by snapshot day and bucket, so each day's forecast is kept alongside
+// earlier ones and re-syncing on the same day replaces that day's snapshot.
 func (a *Adapter) syncForecast(
 	ctx context.Context,
 	cfg Config,
@@ -361,6 +1090,7 @@ func (a *Adapter) syncForecast(
 		StartAt:     startDate,
 		EndAt:       endDate,
 		Granularity: cfg.Granularity,
+		GroupBys:    forecastGroupBys(cfg.GroupBys),
 	}
 
 	forecast, err := a.client.Forecast(ctx, cfg.CostReportToken, forecastQuery)
@@ -368,17 +1098,23 @@ func (a *Adapter) syncForecast(
 		return fmt.Errorf("fetching forecast: %w", err)
 	}
 
+	snapshotDate := time.Now().UTC().Format("2006-01-02")
 	var forecastRecords []CostRecord
 	for _, row := range forecast.Data {
 		record := a.mapVantageRowToCostRecord(client.CostRow{
+			Provider:    row.Provider,
+			Service:     row.Service,
 			BucketStart: row.BucketStart,
 			BucketEnd:   row.BucketEnd,
 			Cost:        row.Cost,
 			Currency:    row.Currency,
+			Extra:       row.Extra,
 		}, client.Query{
 			CostReportToken: cfg.CostReportToken,
 			Granularity:     cfg.Granularity,
 		}, queryHash, "forecast")
+		record.ForecastSnapshotDate = snapshotDate
+		record.LineItemID = generateForecastLineItemID(cfg.CostReportToken, snapshotDate, row)
 		forecastRecords = append(forecastRecords, record)
 
 		// Collect diagnostics for summary.
@@ -393,7 +1129,34 @@ func (a *Adapter) syncForecast(
 		"query_hash": queryHash,
 	})
 
-	return sink.WriteRecords(ctx, forecastRecords)
+	if err := a.writeRecords(ctx, sink, forecastRecords); err != nil {
+		return err
+	}
+	a.stats.ForecastRecords += len(forecastRecords)
+	return nil
+}
+
+// generateForecastLineItemID keys forecast records by report, snapshot day,
+// bucket, and group.
+func generateForecastLineItemID(reportToken, snapshotDate string, row client.ForecastRow) string {
+	parts := []string{"forecast", reportToken, snapshotDate, row.BucketStart.UTC().Format(time.RFC3339)}
+	if row.Provider != "" || row.Service != "" {
+		parts = append(parts, row.Provider, row.Service)
+	}
+	hash := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(hash[:16])
+}
+
+// forecastGroupBys returns the configured group_bys a forecast can be split
+// by; forecasts are made per provider and service at most.
+func forecastGroupBys(groupBys []string) []string {
+	var supported []string
+	for _, groupBy := range groupBys {
+		if slices.Contains(client.SupportedForecastGroupBys(), groupBy) {
+			supported = append(supported, groupBy)
+		}
+	}
+	return supported
 }
 
 // generateQueryHash creates a stable hash for idempotency.
@@ -418,6 +1181,15 @@ func (a *Adapter) generateQueryHash(query client.Query) string {
 	sort.Strings(metrics)
 	parts = append(parts, strings.Join(metrics, ","))
 
+	// The filter and unallocated flag are only added when set, so hashes of
+	// queries without them, and the bookmarks keyed by those, are unchanged.
+	if query.Filter != "" {
+		parts = append(parts, "filter="+query.Filter)
+	}
+	if query.IncludeUnallocated {
+		parts = append(parts, "unallocated=true")
+	}
+
 	// Generate hash.
 	hash := sha256.Sum256([]byte(strings.Join(parts, "|")))
 	return hex.EncodeToString(hash[:16]) // First 32 hex chars
@@ -436,6 +1208,15 @@ func (a *Adapter) logDiagnosticsSummary(ctx context.Context, err error) {
 
 	// Log summary overview for successful sync.
 	a.logSyncSuccess(ctx, summary)
+
+	if summary.UnallocatedShare != nil {
+		a.logger.Info(ctx, "Unallocated spend", map[string]interface{}{
+			"adapter":           "vantage",
+			"operation":         "sync_summary",
+			"unallocated_cost":  *summary.UnallocatedCost,
+			"unallocated_share": *summary.UnallocatedShare,
+		})
+	}
 }
 
 // logSyncFailure logs the error summary when sync fails.
@@ -3,6 +3,7 @@ package adapter
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
 	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
 )
 
@@ -41,8 +43,10 @@ func (m *mockSink) SetBookmark(ctx context.Context, key string, value string) er
 	return args.Error(0)
 }
 
-// mockClient implements the client.Client interface for testing.
+// mockClient implements the client.Client interface for testing. Methods the
+// adapter does not exercise are satisfied by the embedded nil interface.
 type mockClient struct {
+	client.Client
 	mock.Mock
 }
 
@@ -60,6 +64,39 @@ func (m *mockClient) Forecast(
 	return args.Get(0).(client.Forecast), args.Error(1)
 }
 
+func (m *mockClient) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *mockClient) Recommendations(
+	ctx context.Context,
+	query client.RecommendationQuery,
+) ([]client.Recommendation, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]client.Recommendation), args.Error(1)
+}
+
+func (m *mockClient) ListTags(ctx context.Context, workspaceToken string) ([]client.Tag, error) {
+	args := m.Called(ctx, workspaceToken)
+	return args.Get(0).([]client.Tag), args.Error(1)
+}
+
+func (m *mockClient) Budgets(ctx context.Context, workspaceToken string) ([]client.Budget, error) {
+	args := m.Called(ctx, workspaceToken)
+	return args.Get(0).([]client.Budget), args.Error(1)
+}
+
+func (m *mockClient) ListCostReports(ctx context.Context, workspaceToken string) ([]client.CostReport, error) {
+	args := m.Called(ctx, workspaceToken)
+	return args.Get(0).([]client.CostReport), args.Error(1)
+}
+
+func (m *mockClient) ListIntegrations(ctx context.Context, workspaceToken string) ([]client.Integration, error) {
+	args := m.Called(ctx, workspaceToken)
+	return args.Get(0).([]client.Integration), args.Error(1)
+}
+
 func TestAdapter_mapVantageRowToCostRecord(t *testing.T) {
 	logger := client.NewNoopLogger()
 	adapter := New(&mockClient{}, logger)
@@ -100,6 +137,8 @@ func TestAdapter_mapVantageRowToCostRecord(t *testing.T) {
 	assert.Equal(t, "aws", record.Provider)
 	assert.Equal(t, "EC2", record.Service)
 	assert.Equal(t, "123456789", record.AccountID)
+	assert.Equal(t, "123456789", record.BillingAccountID, "an account without a payer bills itself")
+	assert.Equal(t, "123456789", record.SubAccountID)
 	assert.Equal(t, "my-project", record.Project)
 	assert.Equal(t, "us-east-1", record.Region)
 	assert.Equal(t, "i-1234567890abcdef0", record.ResourceID)
@@ -137,6 +176,28 @@ func TestAdapter_mapVantageRowToCostRecord(t *testing.T) {
 	assert.Nil(t, record.Diagnostics)
 }
 
+func TestAccountHierarchy(t *testing.T) {
+	tests := []struct {
+		name         string
+		row          client.CostRow
+		billing, sub string
+	}{
+		{"aws linked account", client.CostRow{Provider: "aws", Account: "222", BillingAccount: "111"}, "111", "222"},
+		{"aws standalone account", client.CostRow{Provider: "aws", Account: "222"}, "222", "222"},
+		{"azure subscription", client.CostRow{Provider: "azure", Account: "sub-1", BillingAccount: "ea-1"}, "ea-1", "sub-1"},
+		{"gcp project", client.CostRow{Provider: "GCP", Account: "0A-1B", Project: "web"}, "0A-1B", "web"},
+		{"gcp billing account", client.CostRow{Provider: "gcp", Account: "x", BillingAccount: "0A-1B", Project: "web"}, "0A-1B", "web"},
+		{"no account", client.CostRow{Provider: "datadog"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billing, sub := accountHierarchy(tt.row)
+			assert.Equal(t, tt.billing, billing)
+			assert.Equal(t, tt.sub, sub)
+		})
+	}
+}
+
 func TestAdapter_mapVantageRowToCostRecord_WithMissingFields(t *testing.T) {
 	logger := client.NewNoopLogger()
 	adapter := New(&mockClient{}, logger)
@@ -190,6 +251,16 @@ func TestAdapter_generateQueryHash(t *testing.T) {
 	query2.GroupBys = []string{"provider", "region"}
 	hash3 := adapter.generateQueryHash(query2)
 	assert.NotEqual(t, hash1, hash3)
+
+	// A filter changes the hash, so filtered and unfiltered syncs keep
+	// separate bookmarks.
+	filtered := query
+	filtered.Filter = "costs.provider = 'aws'"
+	assert.NotEqual(t, hash1, adapter.generateQueryHash(filtered))
+
+	unallocated := query
+	unallocated.IncludeUnallocated = true
+	assert.NotEqual(t, hash1, adapter.generateQueryHash(unallocated))
 }
 
 func TestNormalizeTagKey(t *testing.T) {
@@ -241,6 +312,55 @@ func TestNormalizeTags(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+func TestNormalizeTags_ConfiguredFilter(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	tags, err := newTagFilter(TagConfig{
+		Allow:           []string{"^user:", "^pod-uid$"},
+		Deny:            []string{},
+		MaxValuesPerKey: 2,
+	})
+	require.NoError(t, err)
+	adapter.tags = tags
+
+	rows := []map[string]string{
+		{"user:team": "a", "Pod_UID": "1", "environment": "prod"},
+		{"user:team": "b"},
+		{"user:team": "c"},
+		{"user:team": "a"},
+	}
+	var results []map[string]string
+	for _, row := range rows {
+		results = append(results, adapter.normalizeTags(row))
+	}
+
+	assert.Equal(t, map[string]string{"user:team": "a", "pod-uid": "1"}, results[0],
+		"empty deny list keeps pod-uid; environment is not allowed")
+	assert.Equal(t, map[string]string{"user:team": "b"}, results[1])
+	assert.Empty(t, results[2], "third distinct value exceeds the cap")
+	assert.Equal(t, map[string]string{"user:team": "a"}, results[3], "known values are kept")
+	assert.Equal(t, map[string]int{"environment": 1, "user:team": 1}, tags.dropped)
+}
+
+func TestAdapter_mapVantageRowToCostRecord_LabelsRaw(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	row := client.CostRow{
+		BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tags:        map[string]string{"Cost_Center": "Eng", "kubernetes.io/pod-uid": "123"},
+	}
+	query := client.Query{CostReportToken: "cr_test"}
+
+	record := adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Nil(t, record.LabelsRaw, "labels_raw is opt-in")
+
+	tags, err := newTagFilter(TagConfig{PreserveRaw: true})
+	require.NoError(t, err)
+	adapter.tags = tags
+
+	record = adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Equal(t, map[string]string{"cost-center": "Eng"}, record.Labels)
+	assert.Equal(t, map[string]string{"Cost_Center": "Eng"}, record.LabelsRaw)
+}
+
 func TestAdapter_SyncIncremental(t *testing.T) {
 	mockClient := &mockClient{}
 	mockSink := &mockSink{}
@@ -275,6 +395,126 @@ func TestAdapter_SyncIncremental(t *testing.T) {
 	mockSink.AssertExpectations(t)
 }
 
+func TestAdapter_SyncCorrelationID(t *testing.T) {
+	for _, supplied := range []string{"", "run-1234"} {
+		t.Run(fmt.Sprintf("supplied=%q", supplied), func(t *testing.T) {
+			mockClient := &mockClient{}
+			mockSink := &mockSink{}
+			adapter := New(mockClient, client.NewNoopLogger())
+
+			cfg := Config{
+				CostReportToken: "cr_test",
+				Granularity:     "day",
+				GroupBys:        []string{"provider", "service"},
+				Metrics:         []string{"cost"},
+				PageSize:        100,
+			}
+
+			var requested []string
+			mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).
+				Run(func(args mock.Arguments) {
+					requested = append(requested, client.CorrelationID(args.Get(0).(context.Context)))
+				}).
+				Return(client.Page{Data: []client.CostRow{{
+					BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					Provider:    "aws",
+					Service:     "EC2",
+					Cost:        10,
+				}}}, nil)
+
+			var written []CostRecord
+			mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+			mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				written = append(written, args.Get(1).([]CostRecord)...)
+			})
+			mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			ctx := context.Background()
+			if supplied != "" {
+				ctx = client.WithCorrelationID(ctx, supplied)
+			}
+			require.NoError(t, adapter.Sync(ctx, cfg, mockSink))
+
+			id := adapter.GetDiagnosticsSummary().CorrelationID
+			require.NotEmpty(t, id)
+			if supplied != "" {
+				assert.Equal(t, supplied, id)
+			}
+			assert.Equal(t, []string{id}, requested)
+
+			// The record lacks a region and currency, so it carries
+			// diagnostics.
+			require.Len(t, written, 1)
+			require.NotNil(t, written[0].Diagnostics)
+			assert.Equal(t, id, written[0].Diagnostics.SourceInfo["correlation_id"])
+		})
+	}
+}
+
+func TestAdapter_SyncIncremental_Unallocated(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken:    "cr_test",
+		Granularity:        "day",
+		Metrics:            []string{"cost"},
+		PageSize:           100,
+		IncludeUnallocated: true,
+	}
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.IncludeUnallocated
+	})).Return(client.Page{Data: []client.CostRow{
+		{Provider: "aws", Service: "EC2", Tags: map[string]string{"team": "core"}, Cost: 75, BucketStart: day},
+		{Provider: "aws", Service: "EC2", Cost: 25, BucketStart: day, Unallocated: true},
+	}}, nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+
+	require.Len(t, mockSink.records, 2)
+	assert.Equal(t, map[string]string{"team": "core"}, mockSink.records[0].Labels)
+	assert.Equal(t, map[string]string{"allocation": "unallocated"}, mockSink.records[1].Labels)
+	assert.NotEqual(t, mockSink.records[0].LineItemID, mockSink.records[1].LineItemID)
+
+	summary := adapter.GetDiagnosticsSummary()
+	require.NotNil(t, summary.UnallocatedShare)
+	assert.InDelta(t, 25.0, *summary.UnallocatedCost, 1e-9)
+	assert.InDelta(t, 0.25, *summary.UnallocatedShare, 1e-9)
+}
+
+func TestAdapter_SyncIncremental_SeparateBookmarkStore(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	store := bookmark.NewMemory()
+
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.SetBookmarkStore(store)
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		PageSize:        100,
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+	// Only records go to the sink; bookmark calls on it would fail the mock.
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+	mockSink.AssertExpectations(t)
+
+	bookmarks, err := store.Bookmarks(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, bookmarks, 2, "the sync bookmark and the quality history")
+	assert.Contains(t, bookmarks, QualityHistoryKey(cfg))
+}
+
 func TestAdapter_SyncBackfill(t *testing.T) {
 	mockClient := &mockClient{}
 	mockSink := &mockSink{}
@@ -302,6 +542,8 @@ func TestAdapter_SyncBackfill(t *testing.T) {
 
 	// Mock sink operations.
 	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	err := adapter.Sync(context.Background(), cfg, mockSink)
 
@@ -339,6 +581,8 @@ func TestAdapter_SyncChunked(t *testing.T) {
 	}, nil)
 
 	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	err := adapter.syncChunked(context.Background(), cfg, mockSink, startDate, endDate)
 
@@ -385,6 +629,8 @@ func TestAdapter_SyncChunked_MultipleChunks(t *testing.T) {
 	}, nil)
 
 	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	err := adapter.syncChunked(context.Background(), cfg, mockSink, startDate, endDate)
 
@@ -425,8 +671,11 @@ func TestAdapter_SyncForecast(t *testing.T) {
 			Data: forecastData,
 		}, nil)
 
+	snapshotDate := time.Now().UTC().Format("2006-01-02")
 	mockSink.On("WriteRecords", mock.Anything, mock.MatchedBy(func(records []CostRecord) bool {
-		return len(records) == 1 && *records[0].NetCost == 100.50
+		return len(records) == 1 && *records[0].NetCost == 100.50 &&
+			records[0].ForecastSnapshotDate == snapshotDate &&
+			records[0].LineItemID == generateForecastLineItemID("cr_test", snapshotDate, forecastData[0])
 	})).Return(nil)
 
 	err := adapter.syncForecast(context.Background(), cfg, mockSink, startDate, endDate, "query_hash")
@@ -579,6 +828,73 @@ func TestAdapter_SyncSingleRange_Pagination(t *testing.T) {
 	mockSink.AssertExpectations(t)
 }
 
+func TestAdapter_SyncSingleRange_DropsDuplicateRows(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		Metrics:         []string{"cost"},
+	}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ec2 := client.CostRow{Provider: "aws", Service: "ec2", Cost: 50.25, Currency: "USD", BucketStart: day}
+	s3 := client.CostRow{Provider: "aws", Service: "s3", Cost: 25.75, Currency: "USD", BucketStart: day}
+
+	// The second page repeats the last row of the first.
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.Cursor == ""
+	})).Return(client.Page{Data: []client.CostRow{ec2}, NextCursor: "cursor1", HasMore: true}, nil)
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.Cursor == "cursor1"
+	})).Return(client.Page{Data: []client.CostRow{ec2, s3}}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	err := adapter.syncSingleRange(context.Background(), cfg, mockSink, day, day.AddDate(0, 0, 1), true)
+	require.NoError(t, err)
+
+	require.Len(t, mockSink.records, 2)
+	assert.Equal(t, "ec2", mockSink.records[0].Service)
+	assert.Equal(t, "s3", mockSink.records[1].Service)
+	summary := adapter.GetDiagnosticsSummary()
+	assert.Equal(t, 1, summary.DuplicateRecords)
+	assert.Equal(t, 2, summary.TotalRecords)
+}
+
+func TestAdapter_SyncSingleRange_KeepsRowsDifferingByBillingAccount(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		GroupBys:        []string{"billing_account", "provider", "service"},
+		Metrics:         []string{"cost"},
+	}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	payer1 := client.CostRow{
+		Provider: "aws", Service: "ec2", BillingAccount: "payer-1",
+		Cost: 50.25, Currency: "USD", BucketStart: day,
+	}
+	payer2 := payer1
+	payer2.BillingAccount = "payer-2"
+
+	mockClient.On("Costs", mock.Anything, mock.Anything).
+		Return(client.Page{Data: []client.CostRow{payer1, payer2}}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	err := adapter.syncSingleRange(context.Background(), cfg, mockSink, day, day.AddDate(0, 0, 1), true)
+	require.NoError(t, err)
+
+	require.Len(t, mockSink.records, 2)
+	assert.Equal(t, "payer-1", mockSink.records[0].BillingAccountID)
+	assert.Equal(t, "payer-2", mockSink.records[1].BillingAccountID)
+	assert.NotEqual(t, mockSink.records[0].LineItemID, mockSink.records[1].LineItemID)
+	assert.Zero(t, adapter.GetDiagnosticsSummary().DuplicateRecords)
+}
+
 func TestAdapter_SyncSingleRange_Error(t *testing.T) {
 	mockClient := &mockClient{}
 	mockSink := &mockSink{}
@@ -643,6 +959,8 @@ func TestAdapter_SyncDateRange_BackfillChunking(t *testing.T) {
 	}, nil)
 
 	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	err := adapter.syncDateRange(context.Background(), cfg, mockSink, startDate, endDate, true)
 
@@ -711,3 +1029,166 @@ func TestDiagnostics(t *testing.T) {
 	diag2.SetSourceInfo("test_key", "test_value")
 	assert.Equal(t, "test_value", diag2.SourceInfo["test_key"])
 }
+
+func TestAdapter_SyncSingleRange_StreamsInBatches(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+
+	logger := client.NewNoopLogger()
+	adapter := New(mockClient, logger)
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		Metrics:         []string{"cost"},
+		PageSize:        2,
+		BatchSize:       2,
+	}
+
+	bucket := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	row := func(service string) client.CostRow {
+		return client.CostRow{BucketStart: bucket, Provider: "aws", Service: service, Cost: 1, Currency: "USD"}
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.Cursor == ""
+	})).Return(client.Page{
+		Data:       []client.CostRow{row("ec2"), row("s3")},
+		NextCursor: "page2",
+		HasMore:    true,
+	}, nil).Once()
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.Cursor == "page2"
+	})).Return(client.Page{
+		Data:    []client.CostRow{row("rds")},
+		HasMore: false,
+	}, nil).Once()
+
+	// Expect one full batch followed by the remainder.
+	mockSink.On("WriteRecords", mock.Anything, mock.MatchedBy(func(records []CostRecord) bool {
+		return len(records) == 2
+	})).Return(nil).Once()
+	mockSink.On("WriteRecords", mock.Anything, mock.MatchedBy(func(records []CostRecord) bool {
+		return len(records) == 1 && records[0].Service == "rds"
+	})).Return(nil).Once()
+
+	err := adapter.syncSingleRange(context.Background(), cfg, mockSink, bucket, bucket.AddDate(0, 0, 1), true)
+
+	require.NoError(t, err)
+	assert.Len(t, mockSink.records, 3)
+	assert.Equal(t, 3, adapter.GetDiagnosticsSummary().TotalRecords)
+	mockClient.AssertExpectations(t)
+	mockSink.AssertExpectations(t)
+}
+
+func TestAdapter_SyncSingleRange_BatchWriteError(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		BatchSize:       1,
+	}
+
+	bucket := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockClient.On("Costs", mock.Anything, mock.Anything).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: bucket, Provider: "aws", Service: "ec2", Cost: 1},
+			{BucketStart: bucket, Provider: "aws", Service: "s3", Cost: 2},
+		},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(errors.New("sink unavailable")).Once()
+
+	err := adapter.syncSingleRange(context.Background(), cfg, mockSink, bucket, bucket.AddDate(0, 0, 1), true)
+
+	require.ErrorIs(t, err, ErrSink)
+	assert.Contains(t, err.Error(), "writing records")
+	mockSink.AssertNumberOfCalls(t, "WriteRecords", 1)
+}
+
+func TestAdapter_SyncChunked_ResumesFromCheckpoints(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		PageSize:        100,
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	backfillHash := adapter.generateQueryHash(client.Query{
+		CostReportToken: "cr_test",
+		StartAt:         startDate,
+		EndAt:           endDate,
+		Granularity:     "day",
+	})
+	januaryKey := chunkCheckpointKey(backfillHash, startDate, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	februaryKey := chunkCheckpointKey(backfillHash, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), endDate)
+
+	// January completed in a previous run; only February is fetched.
+	mockSink.On("GetBookmark", mock.Anything, januaryKey).Return("2024-03-02T00:00:00Z", nil)
+	mockSink.On("GetBookmark", mock.Anything, februaryKey).Return("", nil)
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.StartAt.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	})).Return(client.Page{}, nil).Once()
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("SetBookmark", mock.Anything, februaryKey, mock.Anything).Return(nil).Once()
+
+	err := adapter.syncChunked(context.Background(), cfg, mockSink, startDate, endDate)
+	require.NoError(t, err)
+
+	mockClient.AssertExpectations(t)
+	mockSink.AssertExpectations(t)
+	assert.Equal(t, 1, adapter.GetDiagnosticsSummary().SourceInfo["backfill_chunks_skipped"])
+}
+
+func TestAdapter_SyncChunked_FailedChunkNotCheckpointed(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day", PageSize: 100}
+
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockClient.On("Costs", mock.Anything, mock.Anything).Return(client.Page{}, errors.New("boom"))
+
+	err := adapter.syncChunked(context.Background(), cfg, mockSink,
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.Error(t, err)
+	mockSink.AssertNotCalled(t, "SetBookmark", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// BenchmarkMapPage maps a page of 1,000 rows, as the sync loop does for each
+// costs page.
+func BenchmarkMapPage(b *testing.B) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	rows := make([]client.CostRow, 1000)
+	for i := range rows {
+		rows[i] = client.CostRow{
+			Provider:    "aws",
+			Service:     "AmazonEC2",
+			Account:     "123456789012",
+			Region:      "us-east-1",
+			ResourceID:  fmt.Sprintf("i-%017d", i),
+			Tags:        map[string]string{"Environment": "prod", "CostCenter": "platform"},
+			Cost:        float64(i) / 10,
+			Currency:    "USD",
+			BucketStart: start,
+			BucketEnd:   start.AddDate(0, 0, 1),
+		}
+	}
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day", Metrics: []string{"cost"}}
+	adapter := New(nil, client.NewNoopLogger())
+
+	b.ReportAllocs()
+	for b.Loop() {
+		adapter.mapPage(context.Background(), rows, query, "hash", nil, newRecentIDs(dedupeWindowPages))
+	}
+}
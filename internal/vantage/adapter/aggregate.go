@@ -0,0 +1,340 @@
+package adapter
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// Output granularities coarser than the API's, produced by rolling rows up
+// in the adapter before they are written.
+const (
+	outputGranularityWeek    = "week"
+	outputGranularityQuarter = "quarter"
+
+	// weeksPerChunk sizes backfill chunks when rolling up to weeks, so each
+	// chunk holds whole ISO weeks.
+	weeksPerChunk = 4
+)
+
+// Dimensions that drop_dimensions can remove before writing.
+const (
+	dimensionAccountID  = "account_id"
+	dimensionProject    = "project"
+	dimensionRegion     = "region"
+	dimensionResourceID = "resource_id"
+	dimensionLabels     = "labels"
+)
+
+// SupportedDropDimensions returns the dimensions drop_dimensions accepts.
+func SupportedDropDimensions() []string {
+	return []string{dimensionAccountID, dimensionProject, dimensionRegion, dimensionResourceID, dimensionLabels}
+}
+
+// rolledUp reports whether cfg writes aggregated records rather than rows
+// as fetched.
+func rolledUp(cfg Config) bool {
+	return cfg.OutputGranularity != "" || len(cfg.DropDimensions) > 0
+}
+
+// rollupDimensions returns the dimensions cfg drops, sorted; rolling up to
+// a coarser granularity always drops resource_id.
+func rollupDimensions(cfg Config) []string {
+	dropped := slices.Clone(cfg.DropDimensions)
+	if cfg.OutputGranularity != "" && !slices.Contains(dropped, dimensionResourceID) {
+		dropped = append(dropped, dimensionResourceID)
+	}
+	sort.Strings(dropped)
+	return slices.Compact(dropped)
+}
+
+// rollupCheckpointSuffix distinguishes the backfill checkpoints of rolled-up
+// syncs, which chunk and write differently, from those of plain syncs.
+func rollupCheckpointSuffix(cfg Config) string {
+	var suffix string
+	if cfg.OutputGranularity != "" {
+		suffix += "_" + cfg.OutputGranularity
+	}
+	if len(cfg.DropDimensions) > 0 {
+		suffix += "_drop=" + strings.Join(rollupDimensions(cfg), ",")
+	}
+	return suffix
+}
+
+// bucketStart returns the start of the output bucket holding t: the Monday
+// of its ISO week, or the first day of its quarter.
+func bucketStart(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch granularity {
+	case outputGranularityWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case outputGranularityQuarter:
+		return time.Date(day.Year(), day.Month()-(day.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// bucketEnd returns the exclusive end of the bucket starting at start.
+func bucketEnd(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case outputGranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case outputGranularityQuarter:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// chunkStart returns where a backfill from t begins: the first of its month,
+// or the start of its bucket when rolling up.
+func chunkStart(t time.Time, outputGranularity string) time.Time {
+	if outputGranularity != "" {
+		return bucketStart(t, outputGranularity)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// nextChunkEnd returns the exclusive end of the backfill chunk starting at
+// current, capped at endDate. Chunks are calendar months, or whole buckets
+// when rolling up so no bucket is split across chunks.
+func nextChunkEnd(current, endDate time.Time, outputGranularity string) time.Time {
+	var end time.Time
+	switch outputGranularity {
+	case outputGranularityWeek:
+		end = current.AddDate(0, 0, 7*weeksPerChunk)
+	case outputGranularityQuarter:
+		end = bucketEnd(current, outputGranularityQuarter)
+	default:
+		end = time.Date(current.Year(), current.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if end.After(endDate) {
+		return endDate
+	}
+	return end
+}
+
+// aggregator rolls cost records up, summing metrics across records that
+// share a bucket and every dimension not dropped. Buckets are ISO weeks or
+// quarters, or each record's own timestamp when granularity is "". Raw
+// labels are dropped, since rows with the same normalized labels can differ
+// in raw form. Memory grows with the number of distinct buckets and
+// dimensions, not with the number of rows.
+type aggregator struct {
+	granularity string
+	dropped     []string
+	query       client.Query
+	queryHash   string
+
+	buckets map[string]*CostRecord
+	order   []string
+
+	// held counts buckets left out because they end after the fetched
+	// range and so are not yet complete.
+	held int
+}
+
+// newAggregator returns an aggregator for the rows fetched by query,
+// clearing the dropped dimensions (see SupportedDropDimensions).
+func newAggregator(granularity string, dropped []string, query client.Query, queryHash string) *aggregator {
+	return &aggregator{
+		granularity: granularity,
+		dropped:     dropped,
+		query:       query,
+		queryHash:   queryHash,
+		buckets:     make(map[string]*CostRecord),
+	}
+}
+
+// add folds record into its bucket.
+func (g *aggregator) add(record CostRecord) {
+	start := record.Timestamp
+	if g.granularity != "" {
+		start = bucketStart(record.Timestamp, g.granularity)
+	}
+	g.project(&record)
+	key := strings.Join(rollupKeyParts(start, record), "|")
+
+	bucket, ok := g.buckets[key]
+	if !ok {
+		bucket = &CostRecord{
+			Timestamp:         start,
+			Provider:          record.Provider,
+			Service:           record.Service,
+			ServiceCategory:   record.ServiceCategory,
+			AccountID:         record.AccountID,
+			BillingAccountID:  record.BillingAccountID,
+			SubAccountID:      record.SubAccountID,
+			Project:           record.Project,
+			Region:            record.Region,
+			RegionContinent:   record.RegionContinent,
+			RegionCountry:     record.RegionCountry,
+			ResourceID:        record.ResourceID,
+			ResourceType:      record.ResourceType,
+			ResourceName:      record.ResourceName,
+			ResourceAccountID: record.ResourceAccountID,
+			Labels:            record.Labels,
+			UsageUnit:         record.UsageUnit,
+			PricingUnit:       record.PricingUnit,
+			Currency:          record.Currency,
+			SourceReportToken: record.SourceReportToken,
+			SourceReportName:  record.SourceReportName,
+			CostBasis:         record.CostBasis,
+			QueryHash:         g.queryHash,
+			MetricType:        record.MetricType,
+			AllocationRuleID:  record.AllocationRuleID,
+			LineItemHash:      record.LineItemHash,
+		}
+		g.buckets[key] = bucket
+		g.order = append(g.order, key)
+	}
+
+	bucket.UsageAmount = addMetric(bucket.UsageAmount, record.UsageAmount)
+	bucket.ListCost = addMetric(bucket.ListCost, record.ListCost)
+	bucket.NetCost = addMetric(bucket.NetCost, record.NetCost)
+	bucket.AmortizedCost = addMetric(bucket.AmortizedCost, record.AmortizedCost)
+	bucket.EffectiveCost = addMetric(bucket.EffectiveCost, record.EffectiveCost)
+	bucket.TaxCost = addMetric(bucket.TaxCost, record.TaxCost)
+	bucket.CreditAmount = addMetric(bucket.CreditAmount, record.CreditAmount)
+	bucket.RefundAmount = addMetric(bucket.RefundAmount, record.RefundAmount)
+}
+
+// project clears the dropped dimensions from record.
+func (g *aggregator) project(record *CostRecord) {
+	for _, dimension := range g.dropped {
+		switch dimension {
+		case dimensionAccountID:
+			// The account determines the billing account, and is the
+			// sub-account outside GCP (see accountHierarchy).
+			record.AccountID = ""
+			record.BillingAccountID = ""
+			if !strings.EqualFold(record.Provider, "gcp") {
+				record.SubAccountID = ""
+			}
+		case dimensionProject:
+			record.Project = ""
+			if strings.EqualFold(record.Provider, "gcp") {
+				record.SubAccountID = ""
+			}
+		case dimensionRegion:
+			record.Region = ""
+		case dimensionResourceID:
+			record.ResourceID = ""
+			record.ResourceType = ""
+			record.ResourceName = ""
+			record.ResourceAccountID = ""
+		case dimensionLabels:
+			record.Labels = nil
+		}
+	}
+}
+
+// records returns the rolled-up records for every complete bucket, in the
+// order their first row arrived. Week and quarter buckets ending after the
+// fetched range are left for a later sync that covers them whole.
+func (g *aggregator) records() []CostRecord {
+	records := make([]CostRecord, 0, len(g.order))
+	for _, key := range g.order {
+		bucket := g.buckets[key]
+		if g.granularity != "" && bucketEnd(bucket.Timestamp, g.granularity).After(g.query.EndAt) {
+			g.held++
+			continue
+		}
+		// A unit price does not sum; the bucket's is its cost per unit.
+		if bucket.PricingUnit != "" && bucket.UsageAmount != nil && *bucket.UsageAmount != 0 && bucket.NetCost != nil {
+			price := *bucket.NetCost / *bucket.UsageAmount
+			bucket.EffectiveUnitPrice = &price
+		}
+		bucket.LineItemID = g.lineItemID(key, bucket)
+		records = append(records, *bucket)
+	}
+	return records
+}
+
+// lineItemID is the idempotency key for a rolled-up record: the report,
+// output granularity, dropped dimensions, bucket and kept dimensions,
+// metrics requested, and summed values, so re-syncing a bucket with
+// unchanged data yields the same ID. It is hashed with the bucket's rows'
+// line item hash, as their own LineItemIDs are.
+func (g *aggregator) lineItemID(key string, bucket *CostRecord) string {
+	metrics := make([]string, len(g.query.Metrics))
+	copy(metrics, g.query.Metrics)
+	sort.Strings(metrics)
+
+	parts := []string{
+		g.query.CostReportToken,
+		g.granularity,
+		strings.Join(g.dropped, ","),
+		key,
+		strings.Join(metrics, ","),
+	}
+	for _, value := range []*float64{
+		bucket.NetCost, bucket.UsageAmount, bucket.ListCost, bucket.AmortizedCost,
+		bucket.TaxCost, bucket.CreditAmount, bucket.RefundAmount,
+	} {
+		if value == nil {
+			parts = append(parts, "")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%.16g", *value))
+	}
+
+	b := idBuilders.Get().(*idBuilder)
+	defer idBuilders.Put(b)
+	b.buf = append(b.buf[:0], strings.Join(parts, "|")...)
+	return b.sum(bucket.LineItemHash)
+}
+
+// rollupKeyParts returns the fields a record is rolled up by.
+func rollupKeyParts(start time.Time, record CostRecord) []string {
+	labels := make([]string, 0, len(record.Labels))
+	for k, v := range record.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+
+	parts := []string{
+		start.Format("2006-01-02"),
+		record.Provider,
+		record.Service,
+		record.AccountID,
+		record.Project,
+		record.Region,
+		record.ResourceID,
+		record.UsageUnit,
+		record.Currency,
+		record.MetricType,
+		record.AllocationRuleID,
+		strings.Join(labels, ";"),
+	}
+	// Geography outlives a dropped region, so rollups keep continents and
+	// countries apart; records without it keep their existing key.
+	if record.RegionContinent != "" || record.RegionCountry != "" {
+		parts = append(parts, record.RegionContinent, record.RegionCountry)
+	}
+	// Rows grouped by billing account can differ in it alone. A billing
+	// account that is the account itself (see accountHierarchy) adds
+	// nothing, so those records keep their existing key.
+	if record.BillingAccountID != "" && record.BillingAccountID != record.AccountID {
+		parts = append(parts, "billing_account="+record.BillingAccountID)
+	}
+	return parts
+}
+
+// addMetric sums two optional metrics; the result is nil only when both are.
+func addMetric(total, value *float64) *float64 {
+	if value == nil {
+		return total
+	}
+	sum := *value
+	if total != nil {
+		sum += *total
+	}
+	return &sum
+}
@@ -0,0 +1,333 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestBucketStart(t *testing.T) {
+	tests := []struct {
+		name        string
+		granularity string
+		t           time.Time
+		want        time.Time
+	}{
+		{
+			name:        "week from sunday",
+			granularity: outputGranularityWeek,
+			t:           time.Date(2024, 1, 7, 15, 0, 0, 0, time.UTC),
+			want:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "week from monday",
+			granularity: outputGranularityWeek,
+			t:           time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+			want:        time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "week across a year boundary",
+			granularity: outputGranularityWeek,
+			t:           time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			want:        time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "quarter",
+			granularity: outputGranularityQuarter,
+			t:           time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+			want:        time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, bucketStart(tt.t, tt.granularity))
+		})
+	}
+}
+
+func TestNextChunkEnd(t *testing.T) {
+	end := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), nextChunkEnd(start, end, ""))
+	assert.Equal(t, time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC), nextChunkEnd(start, end, outputGranularityWeek))
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), nextChunkEnd(start, end, outputGranularityQuarter))
+	assert.Equal(t, end, nextChunkEnd(time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), end, outputGranularityQuarter))
+}
+
+func TestAggregator_RollsUpCompleteBuckets(t *testing.T) {
+	query := client.Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		Metrics:         []string{"cost"},
+	}
+	g := newAggregator(outputGranularityWeek, []string{dimensionResourceID}, query, "hash")
+
+	cost := func(v float64) *float64 { return &v }
+	record := func(day int, resource string, net float64) CostRecord {
+		return CostRecord{
+			Timestamp:  time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+			Provider:   "aws",
+			Service:    "EC2",
+			ResourceID: resource,
+			Labels:     map[string]string{"team": "core"},
+			NetCost:    cost(net),
+			MetricType: "cost",
+		}
+	}
+	g.add(record(1, "i-1", 10))
+	g.add(record(3, "i-2", 5))
+	g.add(record(7, "i-1", 2.5))
+	g.add(record(8, "i-1", 4)) // week of Jan 8 ends after the range
+
+	records := g.records()
+	require.Len(t, records, 1)
+	week := records[0]
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), week.Timestamp)
+	assert.Empty(t, week.ResourceID)
+	assert.Equal(t, map[string]string{"team": "core"}, week.Labels)
+	require.NotNil(t, week.NetCost)
+	assert.InDelta(t, 17.5, *week.NetCost, 1e-9)
+	assert.Nil(t, week.UsageAmount)
+	assert.Equal(t, "hash", week.QueryHash)
+	assert.NotEmpty(t, week.LineItemID)
+	assert.Equal(t, 1, g.held)
+
+	// The same rows rolled up again keep the same LineItemID.
+	again := newAggregator(outputGranularityWeek, []string{dimensionResourceID}, query, "hash")
+	again.add(record(3, "i-2", 5))
+	again.add(record(7, "i-1", 2.5))
+	again.add(record(1, "i-1", 10))
+	assert.Equal(t, week.LineItemID, again.records()[0].LineItemID)
+}
+
+func TestAggregator_LineItemHash(t *testing.T) {
+	query := client.Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Metrics:         []string{"cost"},
+	}
+	net := 10.0
+	rollUp := func(hash string) CostRecord {
+		g := newAggregator("", []string{dimensionResourceID}, query, "hash")
+		g.add(CostRecord{
+			Timestamp:    query.StartAt,
+			Provider:     "aws",
+			ResourceID:   "i-1",
+			NetCost:      &net,
+			MetricType:   "cost",
+			LineItemHash: hash,
+		})
+		records := g.records()
+		require.Len(t, records, 1)
+		return records[0]
+	}
+
+	// Rolled-up records are hashed as their rows were.
+	sha := rollUp("")
+	assert.Len(t, sha.LineItemID, 32)
+	assert.Empty(t, sha.LineItemHash)
+	for _, hash := range []string{HashXXHash128, HashBLAKE3} {
+		record := rollUp(hash)
+		assert.Equal(t, hash, record.LineItemHash)
+		assert.Len(t, record.LineItemID, 32)
+		assert.NotEqual(t, sha.LineItemID, record.LineItemID, hash)
+	}
+}
+
+func TestAggregator_BillingAccount(t *testing.T) {
+	query := client.Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Metrics:         []string{"cost"},
+	}
+	rollUp := func(records ...CostRecord) []CostRecord {
+		g := newAggregator("", []string{dimensionResourceID}, query, "hash")
+		for _, record := range records {
+			g.add(record)
+		}
+		return g.records()
+	}
+	record := func(billing string) CostRecord {
+		net := 10.0
+		return CostRecord{
+			Timestamp:        query.StartAt,
+			Provider:         "aws",
+			AccountID:        "111",
+			BillingAccountID: billing,
+			ResourceID:       "i-1",
+			NetCost:          &net,
+			MetricType:       "cost",
+		}
+	}
+
+	// Accounts under different billing accounts are not summed together.
+	records := rollUp(record("payer-1"), record("payer-2"))
+	require.Len(t, records, 2)
+	assert.ElementsMatch(t, []string{"payer-1", "payer-2"},
+		[]string{records[0].BillingAccountID, records[1].BillingAccountID})
+	assert.NotEqual(t, records[0].LineItemID, records[1].LineItemID)
+
+	// An account billed to itself keeps the key it had without one.
+	self := rollUp(record("111"))
+	none := rollUp(record(""))
+	require.Len(t, self, 1)
+	require.Len(t, none, 1)
+	assert.Equal(t, none[0].LineItemID, self[0].LineItemID)
+}
+
+func TestAggregator_DropDimensions(t *testing.T) {
+	query := client.Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		Metrics:         []string{"cost", "usage"},
+	}
+	g := newAggregator("", []string{dimensionLabels, dimensionRegion, dimensionResourceID}, query, "hash")
+
+	value := func(v float64) *float64 { return &v }
+	record := func(day int, region, resource string, net, usage float64) CostRecord {
+		return CostRecord{
+			Timestamp:   time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+			Provider:    "aws",
+			Service:     "S3",
+			AccountID:   "123",
+			Region:      region,
+			ResourceID:  resource,
+			Labels:      map[string]string{"bucket": resource},
+			NetCost:     value(net),
+			UsageAmount: value(usage),
+			UsageUnit:   "GB",
+			LineItemID:  resource,
+			MetricType:  "cost",
+		}
+	}
+	g.add(record(1, "us-east-1", "logs", 1, 10))
+	g.add(record(1, "eu-west-1", "assets", 2, 20))
+	g.add(record(2, "us-east-1", "logs", 4, 40))
+
+	records := g.records()
+	require.Len(t, records, 2, "rows are summed per day, not rolled up to weeks")
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), records[0].Timestamp)
+	assert.InDelta(t, 3.0, *records[0].NetCost, 1e-9)
+	assert.InDelta(t, 30.0, *records[0].UsageAmount, 1e-9)
+	assert.InDelta(t, 4.0, *records[1].NetCost, 1e-9)
+	for _, r := range records {
+		assert.Equal(t, "123", r.AccountID, "kept dimensions stay")
+		assert.Empty(t, r.Region)
+		assert.Empty(t, r.ResourceID)
+		assert.Nil(t, r.Labels)
+		assert.NotContains(t, []string{"logs", "assets"}, r.LineItemID, "LineItemIDs are recomputed")
+	}
+	assert.NotEqual(t, records[0].LineItemID, records[1].LineItemID)
+	assert.Zero(t, g.held)
+}
+
+func TestRollupDimensions(t *testing.T) {
+	assert.Empty(t, rollupDimensions(Config{}))
+	assert.Equal(t, []string{dimensionResourceID}, rollupDimensions(Config{OutputGranularity: outputGranularityWeek}))
+	assert.Equal(t,
+		[]string{dimensionRegion, dimensionResourceID},
+		rollupDimensions(Config{
+			OutputGranularity: outputGranularityQuarter,
+			DropDimensions:    []string{dimensionResourceID, dimensionRegion},
+		}),
+	)
+
+	assert.Empty(t, rollupCheckpointSuffix(Config{}))
+	assert.Equal(t, "_week", rollupCheckpointSuffix(Config{OutputGranularity: outputGranularityWeek}))
+	assert.Equal(t, "_drop=region", rollupCheckpointSuffix(Config{DropDimensions: []string{dimensionRegion}}))
+}
+
+func TestAdapter_SyncSingleRange_OutputGranularity(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken:   "cr_test",
+		Granularity:       "day",
+		Metrics:           []string{"cost"},
+		PageSize:          100,
+		OutputGranularity: outputGranularityWeek,
+	}
+
+	row := func(day int, resource string, cost float64) client.CostRow {
+		return client.CostRow{
+			Provider:    "aws",
+			Service:     "EC2",
+			ResourceID:  resource,
+			Cost:        cost,
+			BucketStart: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	// The fetch starts at the Monday of the first week.
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.StartAt.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	})).Return(client.Page{Data: []client.CostRow{
+		row(2, "i-1", 3),
+		row(5, "i-2", 4),
+		row(9, "i-1", 1),
+	}}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	err := adapter.syncSingleRange(context.Background(), cfg, mockSink,
+		time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), true)
+	require.NoError(t, err)
+
+	require.Len(t, mockSink.records, 1)
+	assert.InDelta(t, 7.0, *mockSink.records[0].NetCost, 1e-9)
+	assert.Equal(t, 3, adapter.GetDiagnosticsSummary().TotalRecords, "diagnostics cover the fetched rows")
+	assert.Equal(t, 1, adapter.GetDiagnosticsSummary().SourceInfo["incomplete_buckets_held"])
+	mockClient.AssertExpectations(t)
+}
+
+func TestValidateConfigOutputGranularity(t *testing.T) {
+	base := Config{
+		Token:           "test-token",
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		PageSize:        100,
+		Timeout:         time.Minute,
+	}
+
+	cfg := base
+	cfg.OutputGranularity = outputGranularityWeek
+	require.NoError(t, ValidateConfig(&cfg))
+
+	cfg.Granularity = "month"
+	require.ErrorContains(t, ValidateConfig(&cfg), "requires granularity 'day'")
+
+	cfg.OutputGranularity = outputGranularityQuarter
+	require.NoError(t, ValidateConfig(&cfg))
+
+	cfg.RestatementWindowDays = 7
+	require.ErrorContains(t, ValidateConfig(&cfg), "restatement_window_days")
+
+	cfg = base
+	cfg.OutputGranularity = "year"
+	require.ErrorContains(t, ValidateConfig(&cfg), "output_granularity must be 'week' or 'quarter'")
+
+	cfg = base
+	cfg.DropDimensions = []string{dimensionResourceID, dimensionLabels}
+	require.NoError(t, ValidateConfig(&cfg))
+
+	cfg.RestatementWindowDays = 7
+	require.ErrorContains(t, ValidateConfig(&cfg), "restatement_window_days")
+
+	cfg = base
+	cfg.DropDimensions = []string{"service"}
+	require.ErrorContains(t, ValidateConfig(&cfg), "invalid drop_dimensions entry: service")
+}
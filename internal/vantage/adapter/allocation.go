@@ -0,0 +1,308 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Allocation methods for allocation_rules.
+const (
+	AllocationPercentage   = "percentage"
+	AllocationProportional = "proportional"
+
+	// allocationLabelPrefix marks a match key that compares a record label,
+	// as in "label.team".
+	allocationLabelPrefix = "label."
+)
+
+// SupportedAllocationMethods returns the valid allocation_rules[].method
+// values.
+func SupportedAllocationMethods() []string {
+	return []string{AllocationPercentage, AllocationProportional}
+}
+
+// SupportedAllocationMatchKeys returns the record dimensions an allocation
+// rule can match on, besides "label.<key>".
+func SupportedAllocationMatchKeys() []string {
+	return []string{"provider", "service", "account_id", "project", "region", "resource_id"}
+}
+
+// AllocationRule is one entry of the top-level allocation_rules section. It
+// splits shared cost records, those matching Match, across the values of the
+// Label key: by fixed Shares (percentage), or by each value's labeled spend
+// on the same day (proportional).
+type AllocationRule struct {
+	// ID names the rule on every record it derives.
+	ID string `yaml:"id" json:"id"`
+	// Match maps dimensions (see SupportedAllocationMatchKeys) or
+	// "label.<key>" to values, compared case-insensitively; a record is
+	// shared when every entry matches.
+	Match  map[string]string `yaml:"match"  json:"match"`
+	Label  string            `yaml:"label"  json:"label"`
+	Method string            `yaml:"method" json:"method"`
+	// Shares maps label values to percentages summing to 100 (percentage).
+	Shares map[string]float64 `yaml:"shares" json:"shares,omitempty"`
+	// Targets limits a proportional split to these label values; empty
+	// means every value with spend that day.
+	Targets []string `yaml:"targets" json:"targets,omitempty"`
+}
+
+// allocationRule is an AllocationRule with its label key normalized.
+type allocationRule struct {
+	AllocationRule
+	label string
+}
+
+// compileAllocationRules checks rules and normalizes their label keys.
+func compileAllocationRules(rules []AllocationRule) ([]allocationRule, error) {
+	compiled := make([]allocationRule, 0, len(rules))
+	ids := make(map[string]struct{}, len(rules))
+	for i, rule := range rules {
+		if err := validateAllocationRule(rule, ids); err != nil {
+			return nil, fmt.Errorf("allocation_rules[%d]: %w", i, err)
+		}
+		ids[rule.ID] = struct{}{}
+		compiled = append(compiled, allocationRule{AllocationRule: rule, label: kebabCase(rule.Label)})
+	}
+	return compiled, nil
+}
+
+// validateAllocationRule checks one rule; ids holds the IDs of the rules
+// before it.
+func validateAllocationRule(rule AllocationRule, ids map[string]struct{}) error {
+	if rule.ID == "" {
+		return errors.New("id is required")
+	}
+	if _, duplicate := ids[rule.ID]; duplicate {
+		return fmt.Errorf("duplicate id: %s", rule.ID)
+	}
+	if len(rule.Match) == 0 {
+		return fmt.Errorf("%s: match is required", rule.ID)
+	}
+	for key := range rule.Match {
+		label, isLabel := strings.CutPrefix(key, allocationLabelPrefix)
+		if (isLabel && kebabCase(label) != "") || slices.Contains(SupportedAllocationMatchKeys(), key) {
+			continue
+		}
+		return fmt.Errorf("%s: invalid match key: %s (valid: %s, label.<key>)",
+			rule.ID, key, strings.Join(SupportedAllocationMatchKeys(), ", "))
+	}
+	if kebabCase(rule.Label) == "" {
+		return fmt.Errorf("%s: label is required", rule.ID)
+	}
+
+	switch rule.Method {
+	case AllocationPercentage:
+		if len(rule.Shares) == 0 {
+			return fmt.Errorf("%s: percentage requires shares", rule.ID)
+		}
+		total := 0.0
+		for value, share := range rule.Shares {
+			if share <= 0 {
+				return fmt.Errorf("%s: share for %s must be positive", rule.ID, value)
+			}
+			total += share
+		}
+		if math.Abs(total-100) > 0.01 {
+			return fmt.Errorf("%s: shares must sum to 100, got %g", rule.ID, total)
+		}
+	case AllocationProportional:
+		if len(rule.Shares) > 0 {
+			return fmt.Errorf("%s: proportional does not take shares", rule.ID)
+		}
+	default:
+		return fmt.Errorf("%s: invalid method: %s (valid: %s)",
+			rule.ID, rule.Method, strings.Join(SupportedAllocationMethods(), ", "))
+	}
+	return nil
+}
+
+// matches reports whether record is a shared cost under rule.
+func (r allocationRule) matches(record *CostRecord) bool {
+	for key, want := range r.Match {
+		var got string
+		switch key {
+		case "provider":
+			got = record.Provider
+		case "service":
+			got = record.Service
+		case "account_id":
+			got = record.AccountID
+		case "project":
+			got = record.Project
+		case "region":
+			got = record.Region
+		case "resource_id":
+			got = record.ResourceID
+		default:
+			got = record.Labels[kebabCase(strings.TrimPrefix(key, allocationLabelPrefix))]
+		}
+		if !strings.EqualFold(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// spendKey identifies the labeled spend a proportional rule splits by.
+type spendKey struct {
+	rule  int
+	day   string
+	value string
+}
+
+// allocator applies allocation rules to the cost records of one fetched
+// range. Percentage splits are made as records arrive; proportional splits
+// wait until the whole range is fetched, so memory grows with the number of
+// shared records and label values per day, not with the number of rows.
+type allocator struct {
+	rules []allocationRule
+
+	spend   map[spendKey]float64
+	pending []pendingAllocation
+
+	// allocated counts derived records; unallocated counts shared records
+	// written as is because their day had no labeled spend to split by.
+	allocated   int
+	unallocated int
+}
+
+// pendingAllocation is a shared record waiting for a proportional split.
+type pendingAllocation struct {
+	rule   int
+	record CostRecord
+}
+
+// newAllocator returns an allocator for one range.
+func newAllocator(rules []allocationRule) *allocator {
+	return &allocator{rules: rules, spend: make(map[spendKey]float64)}
+}
+
+// add returns the records to write for record: its split when a
+// percentage rule matches, nothing while a proportional split waits, or the
+// record itself. The first matching rule wins; records not shared count
+// toward proportional rules' spend.
+func (al *allocator) add(record CostRecord) []CostRecord {
+	if record.MetricType != "cost" {
+		return []CostRecord{record}
+	}
+
+	for i, rule := range al.rules {
+		if !rule.matches(&record) {
+			continue
+		}
+		if rule.Method == AllocationProportional {
+			al.pending = append(al.pending, pendingAllocation{rule: i, record: record})
+			return nil
+		}
+		shares := make(map[string]float64, len(rule.Shares))
+		for value, percent := range rule.Shares {
+			shares[value] = percent / 100
+		}
+		return al.split(record, rule, shares)
+	}
+
+	if record.NetCost != nil {
+		day := record.Timestamp.UTC().Format("2006-01-02")
+		for i, rule := range al.rules {
+			value := record.Labels[rule.label]
+			if rule.Method != AllocationProportional || value == "" {
+				continue
+			}
+			if len(rule.Targets) > 0 && !slices.Contains(rule.Targets, value) {
+				continue
+			}
+			al.spend[spendKey{rule: i, day: day, value: value}] += *record.NetCost
+		}
+	}
+	return []CostRecord{record}
+}
+
+// records splits the shared records held for proportional rules, once every
+// page of the range has been added.
+func (al *allocator) records() []CostRecord {
+	var records []CostRecord
+	for _, p := range al.pending {
+		day := p.record.Timestamp.UTC().Format("2006-01-02")
+		weights := make(map[string]float64)
+		total := 0.0
+		for key, spend := range al.spend {
+			if key.rule == p.rule && key.day == day && spend > 0 {
+				weights[key.value] = spend
+				total += spend
+			}
+		}
+		if total == 0 {
+			al.unallocated++
+			records = append(records, p.record)
+			continue
+		}
+		for value := range weights {
+			weights[value] /= total
+		}
+		records = append(records, al.split(p.record, al.rules[p.rule], weights)...)
+	}
+	al.pending = nil
+	return records
+}
+
+// split derives one record per label value from a shared record, each with
+// its share of every metric, in label value order.
+func (al *allocator) split(record CostRecord, rule allocationRule, shares map[string]float64) []CostRecord {
+	values := make([]string, 0, len(shares))
+	for value := range shares {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	derived := make([]CostRecord, 0, len(values))
+	for _, value := range values {
+		share := shares[value]
+		d := record
+		d.UsageAmount = scaleMetric(record.UsageAmount, share)
+		d.ListCost = scaleMetric(record.ListCost, share)
+		d.NetCost = scaleMetric(record.NetCost, share)
+		d.AmortizedCost = scaleMetric(record.AmortizedCost, share)
+		d.EffectiveCost = scaleMetric(record.EffectiveCost, share)
+		d.TaxCost = scaleMetric(record.TaxCost, share)
+		d.CreditAmount = scaleMetric(record.CreditAmount, share)
+		d.RefundAmount = scaleMetric(record.RefundAmount, share)
+
+		d.Labels = make(map[string]string, len(record.Labels)+1)
+		for k, v := range record.Labels {
+			d.Labels[k] = v
+		}
+		d.Labels[rule.label] = value
+
+		d.AllocationRuleID = rule.ID
+		d.AllocatedFromLineItemID = record.LineItemID
+		d.LineItemID = allocationLineItemID(record.LineItemID, rule.ID, value, share)
+		derived = append(derived, d)
+	}
+	al.allocated += len(derived)
+	return derived
+}
+
+// allocationLineItemID is the idempotency key for a derived record: the
+// shared record, rule, label value, and share, so re-syncing unchanged data
+// yields the same ID.
+func allocationLineItemID(sourceID, ruleID, value string, share float64) string {
+	parts := []string{sourceID, ruleID, value, fmt.Sprintf("%.16g", share)}
+	hash := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(hash[:16])
+}
+
+// scaleMetric multiplies an optional metric by share.
+func scaleMetric(value *float64, share float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	scaled := *value * share
+	return &scaled
+}
@@ -0,0 +1,177 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestCompileAllocationRules_Invalid(t *testing.T) {
+	valid := AllocationRule{
+		ID:     "support",
+		Match:  map[string]string{"service": "AWS Support"},
+		Label:  "team",
+		Method: AllocationPercentage,
+		Shares: map[string]float64{"platform": 60, "payments": 40},
+	}
+
+	tests := []struct {
+		edit     func(*AllocationRule)
+		expected string
+	}{
+		{func(r *AllocationRule) { r.ID = "" }, "allocation_rules[0]: id is required"},
+		{func(r *AllocationRule) { r.Match = nil }, "support: match is required"},
+		{func(r *AllocationRule) { r.Match = map[string]string{"sku": "x"} }, "support: invalid match key: sku"},
+		{func(r *AllocationRule) { r.Label = "" }, "support: label is required"},
+		{func(r *AllocationRule) { r.Method = "even" }, "support: invalid method: even"},
+		{func(r *AllocationRule) { r.Shares = nil }, "support: percentage requires shares"},
+		{func(r *AllocationRule) { r.Shares = map[string]float64{"platform": 60} }, "support: shares must sum to 100, got 60"},
+		{func(r *AllocationRule) { r.Shares = map[string]float64{"a": 110, "b": -10} }, "support: share for b must be positive"},
+		{func(r *AllocationRule) { r.Method = AllocationProportional }, "support: proportional does not take shares"},
+	}
+	for _, tt := range tests {
+		rule := valid
+		tt.edit(&rule)
+		_, err := compileAllocationRules([]AllocationRule{rule})
+		require.ErrorContains(t, err, tt.expected)
+	}
+
+	_, err := compileAllocationRules([]AllocationRule{valid, valid})
+	require.ErrorContains(t, err, "allocation_rules[1]: duplicate id: support")
+
+	labelMatch := valid
+	labelMatch.Match = map[string]string{"label.cost_center": "shared"}
+	_, err = compileAllocationRules([]AllocationRule{labelMatch})
+	require.NoError(t, err)
+}
+
+func TestAllocator_Percentage(t *testing.T) {
+	rules, err := compileAllocationRules([]AllocationRule{{
+		ID:     "support",
+		Match:  map[string]string{"service": "aws support"},
+		Label:  "Team",
+		Method: AllocationPercentage,
+		Shares: map[string]float64{"platform": 75, "payments": 25},
+	}})
+	require.NoError(t, err)
+	alloc := newAllocator(rules)
+
+	shared := costRecord("aws", 100, map[string]string{"env": "prod"})
+	shared.Service = "AWS Support"
+	shared.LineItemID = "shared-1"
+	tax := 8.0
+	shared.TaxCost = &tax
+
+	records := alloc.add(shared)
+	require.Len(t, records, 2)
+	assert.Equal(t, "payments", records[0].Labels["team"])
+	assert.InDelta(t, 25.0, *records[0].NetCost, 1e-9)
+	assert.InDelta(t, 2.0, *records[0].TaxCost, 1e-9)
+	assert.Equal(t, "platform", records[1].Labels["team"])
+	assert.InDelta(t, 75.0, *records[1].NetCost, 1e-9)
+	for _, record := range records {
+		assert.Equal(t, "support", record.AllocationRuleID)
+		assert.Equal(t, "shared-1", record.AllocatedFromLineItemID)
+		assert.Equal(t, "prod", record.Labels["env"])
+		assert.NotEqual(t, "shared-1", record.LineItemID)
+	}
+	assert.NotEqual(t, records[0].LineItemID, records[1].LineItemID)
+	assert.NotContains(t, shared.Labels, "team", "the shared record's labels are not modified")
+
+	other := costRecord("aws", 10, nil)
+	other.Service = "EC2"
+	assert.Equal(t, []CostRecord{other}, alloc.add(other))
+	assert.Empty(t, alloc.records())
+	assert.Equal(t, 2, alloc.allocated)
+}
+
+func TestAllocator_Proportional(t *testing.T) {
+	rules, err := compileAllocationRules([]AllocationRule{{
+		ID:      "shared-vpc",
+		Match:   map[string]string{"label.cost-center": "shared"},
+		Label:   "team",
+		Method:  AllocationProportional,
+		Targets: []string{"platform", "payments"},
+	}})
+	require.NoError(t, err)
+	alloc := newAllocator(rules)
+
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	at := func(record CostRecord, day time.Time) CostRecord {
+		record.Timestamp = day
+		return record
+	}
+
+	shared := at(costRecord("aws", 30, map[string]string{"cost-center": "shared"}), day1)
+	assert.Empty(t, alloc.add(shared), "proportional splits wait for the whole range")
+	alloc.add(at(costRecord("aws", 60, map[string]string{"team": "platform"}), day1))
+	alloc.add(at(costRecord("gcp", 30, map[string]string{"team": "payments"}), day1))
+	alloc.add(at(costRecord("aws", 500, map[string]string{"team": "data"}), day1))
+	alloc.add(at(costRecord("aws", 500, map[string]string{"team": "platform"}), day2))
+	unsplit := at(costRecord("aws", 5, map[string]string{"cost-center": "shared"}), time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	alloc.add(unsplit)
+
+	records := alloc.records()
+	require.Len(t, records, 3)
+	assert.Equal(t, "payments", records[0].Labels["team"])
+	assert.InDelta(t, 10.0, *records[0].NetCost, 1e-9)
+	assert.Equal(t, "platform", records[1].Labels["team"])
+	assert.InDelta(t, 20.0, *records[1].NetCost, 1e-9)
+	// A day without labeled spend keeps the shared record whole.
+	assert.Equal(t, unsplit, records[2])
+	assert.Equal(t, 2, alloc.allocated)
+	assert.Equal(t, 1, alloc.unallocated)
+}
+
+func TestAdapter_SyncSingleRange_AllocatesSharedCosts(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		GroupBys:        []string{"provider", "service", "tags"},
+		Metrics:         []string{"cost"},
+		PageSize:        100,
+		AllocationRules: []AllocationRule{{
+			ID:     "support",
+			Match:  map[string]string{"service": "Support"},
+			Label:  "team",
+			Method: AllocationProportional,
+		}},
+	}
+	rules, err := compileAllocationRules(cfg.AllocationRules)
+	require.NoError(t, err)
+	adapter.allocationRules = rules
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: startDate, Provider: "aws", Service: "Support", Cost: 10},
+			{BucketStart: startDate, Provider: "aws", Service: "EC2", Cost: 75, Tags: map[string]string{"team": "web"}},
+			{BucketStart: startDate, Provider: "aws", Service: "RDS", Cost: 25, Tags: map[string]string{"team": "data"}},
+		},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.syncSingleRange(context.Background(), cfg, mockSink, startDate, startDate.AddDate(0, 0, 1), true))
+
+	require.Len(t, mockSink.records, 4)
+	byTeam := make(map[string]float64)
+	for _, record := range mockSink.records {
+		if record.AllocationRuleID == "support" {
+			byTeam[record.Labels["team"]] = *record.NetCost
+		}
+	}
+	assert.InDelta(t, 7.5, byTeam["web"], 1e-9)
+	assert.InDelta(t, 2.5, byTeam["data"], 1e-9)
+	assert.Equal(t, 2, adapter.GetDiagnosticsSummary().SourceInfo["allocated_records"])
+}
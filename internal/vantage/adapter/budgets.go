@@ -0,0 +1,115 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+const (
+	// metricTypeBudget marks budget-vs-actual records.
+	metricTypeBudget = "budget"
+
+	percentFactor = 100
+)
+
+// handleBudgets syncs budget records if enabled. Failures are logged rather
+// than failing the cost sync, matching forecast handling.
+func (a *Adapter) handleBudgets(ctx context.Context, cfg Config, sink Sink) {
+	if !cfg.IncludeBudgets {
+		return
+	}
+
+	if err := a.syncBudgets(ctx, cfg, sink); err != nil {
+		a.logger.Warn(ctx, "Budget sync failed", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "budget_sync",
+			"attempt":   0,
+			"error":     err,
+		})
+	}
+}
+
+// syncBudgets fetches budgets and writes one record per budget period. When a
+// cost report token is configured, only budgets for that report are synced.
+func (a *Adapter) syncBudgets(ctx context.Context, cfg Config, sink Sink) error {
+	budgets, err := a.client.Budgets(ctx, cfg.WorkspaceToken)
+	if err != nil {
+		return fmt.Errorf("fetching budgets: %w", err)
+	}
+
+	var records []CostRecord
+	for _, budget := range budgets {
+		if cfg.CostReportToken != "" && budget.CostReportToken != cfg.CostReportToken {
+			continue
+		}
+		for _, period := range budget.Periods {
+			records = append(records, a.mapBudgetPeriodToCostRecord(budget, period))
+		}
+	}
+
+	a.logger.Info(ctx, "Fetched budget data", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "fetch_budget_data",
+		"attempt":   0,
+		"budgets":   len(budgets),
+		"records":   len(records),
+	})
+
+	if len(records) == 0 {
+		return nil
+	}
+	return a.writeRecords(ctx, sink, records)
+}
+
+// mapBudgetPeriodToCostRecord converts a budget period into a budget record.
+// Actual spend is the sum of performance entries dated within the period and
+// is reported as NetCost so it lines up with cost records on dashboards.
+func (a *Adapter) mapBudgetPeriodToCostRecord(budget client.Budget, period client.BudgetPeriod) CostRecord {
+	amount := period.Amount
+
+	var actual float64
+	for _, perf := range budget.Performance {
+		if !perf.Date.Before(period.StartAt) && perf.Date.Before(period.EndAt) {
+			actual += perf.Actual
+		}
+	}
+
+	record := CostRecord{
+		Timestamp:         period.StartAt,
+		Currency:          budget.Currency,
+		SourceReportToken: budget.CostReportToken,
+		LineItemID:        generateBudgetLineItemID(budget.Token, period),
+		MetricType:        metricTypeBudget,
+		NetCost:           &actual,
+		BudgetToken:       budget.Token,
+		BudgetName:        budget.Name,
+		BudgetAmount:      &amount,
+	}
+
+	if amount > 0 {
+		utilization := actual / amount * percentFactor
+		record.BudgetUtilizationPercent = &utilization
+	}
+
+	return record
+}
+
+// generateBudgetLineItemID creates a stable key per (budget, period) so that
+// re-syncing a period updates the existing record as actuals accrue.
+func generateBudgetLineItemID(budgetToken string, period client.BudgetPeriod) string {
+	parts := []string{
+		metricTypeBudget,
+		budgetToken,
+		period.StartAt.UTC().Format(time.RFC3339),
+		period.EndAt.UTC().Format(time.RFC3339),
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(hash[:16])
+}
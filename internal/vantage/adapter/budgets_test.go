@@ -0,0 +1,113 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func testBudget() client.Budget {
+	return client.Budget{
+		Token:           "bdgt_1",
+		Name:            "Production",
+		CostReportToken: "cr_test",
+		Currency:        "USD",
+		Periods: []client.BudgetPeriod{
+			{
+				StartAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndAt:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				Amount:  1000,
+			},
+			{
+				StartAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				EndAt:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				Amount:  0,
+			},
+		},
+		Performance: []client.BudgetPerformance{
+			{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Actual: 400},
+			{Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Actual: 350},
+			{Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Actual: 20},
+		},
+	}
+}
+
+func TestAdapter_mapBudgetPeriodToCostRecord(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	budget := testBudget()
+
+	record := adapter.mapBudgetPeriodToCostRecord(budget, budget.Periods[0])
+
+	assert.Equal(t, "budget", record.MetricType)
+	assert.Equal(t, budget.Periods[0].StartAt, record.Timestamp)
+	assert.Equal(t, "bdgt_1", record.BudgetToken)
+	assert.Equal(t, "Production", record.BudgetName)
+	assert.Equal(t, "cr_test", record.SourceReportToken)
+	assert.Equal(t, "USD", record.Currency)
+	require.NotNil(t, record.BudgetAmount)
+	assert.InEpsilon(t, 1000.0, *record.BudgetAmount, 0.001)
+	require.NotNil(t, record.NetCost)
+	assert.InEpsilon(t, 750.0, *record.NetCost, 0.001)
+	require.NotNil(t, record.BudgetUtilizationPercent)
+	assert.InEpsilon(t, 75.0, *record.BudgetUtilizationPercent, 0.001)
+	assert.Len(t, record.LineItemID, 32)
+
+	// A zero budget amount has no meaningful utilization.
+	zero := adapter.mapBudgetPeriodToCostRecord(budget, budget.Periods[1])
+	assert.Nil(t, zero.BudgetUtilizationPercent)
+	assert.NotEqual(t, record.LineItemID, zero.LineItemID)
+}
+
+func TestAdapter_SyncBudgets_FiltersByCostReport(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	other := testBudget()
+	other.Token = "bdgt_other"
+	other.CostReportToken = "cr_other"
+
+	mockClient.On("Budgets", mock.Anything, "").Return([]client.Budget{testBudget(), other}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	err := adapter.syncBudgets(context.Background(), Config{CostReportToken: "cr_test"}, mockSink)
+	require.NoError(t, err)
+
+	require.Len(t, mockSink.records, 2)
+	for _, record := range mockSink.records {
+		assert.Equal(t, "bdgt_1", record.BudgetToken)
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestAdapter_Sync_BudgetFailureDoesNotFailSync(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+		PageSize:        100,
+		IncludeBudgets:  true,
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+	mockClient.On("Budgets", mock.Anything, "").Return([]client.Budget(nil), errors.New("forbidden"))
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+	mockClient.AssertExpectations(t)
+}
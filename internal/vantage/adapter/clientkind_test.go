@@ -0,0 +1,86 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// TestAdapter_ClientKinds syncs the same v2 responses through every client
+// kind this binary has; the openapi kind runs with -tags openapi.
+func TestAdapter_ClientKinds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v2/costs" && r.URL.Query().Get("page") == "":
+			_, _ = w.Write([]byte(`{
+				"links": {"next": "https://api.vantage.sh/v2/costs?page=2"},
+				"costs": [{"accrued_at": "2024-01-01", "provider": "aws", "service": "EC2",
+					"account_id": "123", "tags": {"Team": "core"}, "amount": "50.25",
+					"usage_quantity": "24", "usage_unit": "Hrs", "currency": "USD"}]
+			}`))
+		case r.URL.Path == "/v2/costs":
+			_, _ = w.Write([]byte(`{"links": {}, "costs": [
+				{"accrued_at": "2024-01-01", "provider": "gcp", "service": "GCE", "amount": "12", "currency": "USD"}]}`))
+		case r.URL.Path == "/v2/cost_reports/cr_test/forecasted_costs":
+			_, _ = w.Write([]byte(`{"forecasted_costs": [{"date": "2024-02-01", "amount": "300", "currency": "USD"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		GroupBys:        []string{"provider", "service"},
+		Metrics:         []string{"cost"},
+		PageSize:        100,
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	records := make(map[string][]CostRecord)
+	for _, kind := range client.SupportedClientKinds() {
+		t.Run(kind, func(t *testing.T) {
+			apiClient, err := client.New(client.Config{
+				BaseURL:    server.URL,
+				Token:      "test-token",
+				APIVersion: client.APIVersionV2,
+				Kind:       kind,
+			})
+			if errors.Is(err, client.ErrOpenAPINotBuilt) {
+				t.Skip("built without the openapi tag")
+			}
+			require.NoError(t, err)
+
+			sink := &mockSink{}
+			sink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+			adapter := New(apiClient, client.NewNoopLogger())
+
+			require.NoError(t, adapter.syncSingleRange(context.Background(), cfg, sink, start, end, true))
+			require.NoError(t, adapter.syncForecast(context.Background(), cfg, sink,
+				time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "query_hash"))
+
+			require.Len(t, sink.records, 3)
+			assert.InDelta(t, 50.25, *sink.records[0].NetCost, 1e-9)
+			assert.Equal(t, "core", sink.records[0].Labels["team"])
+			assert.Equal(t, "gcp", sink.records[1].Provider)
+			assert.InDelta(t, 300.0, *sink.records[2].NetCost, 1e-9)
+			records[kind] = sink.records
+		})
+	}
+
+	if openapi, ok := records[client.ClientKindOpenAPI]; ok {
+		assert.Equal(t, records[client.ClientKindHandwritten], openapi)
+	}
+}
@@ -3,11 +3,14 @@ package adapter
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/spf13/cast"
-	"github.com/spf13/viper"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/currency"
 )
 
 const (
@@ -15,10 +18,115 @@ const (
 	defaultPageSize       = 5000
 	maxPageSize           = 10000
 	defaultMaxRetries     = 5
+	defaultBatchSize      = 1000
+
+	// maxRestatementWindowDays bounds how far back incremental pulls reach.
+	maxRestatementWindowDays = 90
+
+	// maxProjectionLookbackDays bounds the spend GetProjectedCost fetches.
+	maxProjectionLookbackDays = 365
+
+	// defaultFinalityLagDays matches the incremental lag window: a day that
+	// ended two days ago is not fetched again by later pulls.
+	defaultFinalityLagDays = 2
+
+	// SinkTypeFile writes NDJSON records to a directory.
+	SinkTypeFile = "file"
+	// SinkTypeBigQuery writes records to a BigQuery table.
+	SinkTypeBigQuery = "bigquery"
+	// SinkTypeKafka publishes records to a Kafka topic.
+	SinkTypeKafka = "kafka"
+	// SinkTypeClickHouse inserts records into a ClickHouse table.
+	SinkTypeClickHouse = "clickhouse"
+	// SinkTypeDuckDB keeps records in a local DuckDB database.
+	SinkTypeDuckDB = "duckdb"
+
+	// Kafka message formats.
+	KafkaFormatJSON = "json"
+	KafkaFormatAvro = "avro"
+
+	// File sink compressions.
+	SinkCompressionNone = "none"
+	SinkCompressionGzip = "gzip"
+	SinkCompressionZstd = "zstd"
+
+	// File sink bucket URL schemes: Google Cloud Storage and Azure Blob
+	// Storage.
+	SinkBucketSchemeGCS       = "gs"
+	SinkBucketSchemeAzureBlob = "azblob"
+
+	defaultSinkPath = "./data"
+
+	defaultSinkTable          = "vantage_costs"
+	defaultKafkaTopic         = "vantage-costs"
+	defaultClickHouseDatabase = "default"
+	defaultDuckDBFile         = "costs.duckdb"
+
+	// Bookmark store types.
+	BookmarkStoreFile     = "file"
+	BookmarkStoreSQLite   = "sqlite"
+	BookmarkStoreDynamoDB = "dynamodb"
+	BookmarkStoreMemory   = "memory"
+
+	defaultBookmarkTable = "pulumicost_bookmarks"
+
+	// Sync lock types.
+	LockTypeNone     = "none"
+	LockTypeFile     = "file"
+	LockTypePostgres = "postgres"
+	LockTypeDynamoDB = "dynamodb"
+	LockTypeMemory   = "memory"
+
+	defaultLockTable = "pulumicost_locks"
+
+	// Dead-letter queue types.
+	DeadLetterNone   = "none"
+	DeadLetterFile   = "file"
+	DeadLetterSQLite = "sqlite"
+
+	defaultDeadLetterTable = "pulumicost_dead_letters"
+
+	// lockDSNEnv overrides lock.dsn, keeping database passwords out of the
+	// config file.
+	lockDSNEnv = "PULUMICOST_VANTAGE_LOCK_DSN"
+
+	// Tag merge policies for provider tags and kubernetes.io/ labels.
+	TagMergeKeepBoth       = "keep-both-with-prefix"
+	TagMergePreferProvider = "prefer-provider"
+	TagMergePreferK8s      = "prefer-k8s"
+
+	// Exchange-rate sources for target_currency conversion.
+	FXSourceStatic = "static"
+	FXSourceECB    = "ecb"
+	FXSourceFile   = "file"
+
+	// OTLP protocols for exporting trace spans.
+	TracingProtocolHTTP = "http"
+	TracingProtocolGRPC = "grpc"
+
+	defaultTracingServiceName = "pulumicost-vantage"
+
+	// defaultMockSeasonality lowers synthetic weekend spend by a fifth.
+	defaultMockSeasonality = 0.2
+
+	// Alert rule kinds, spend periods, and webhook types for the alerts
+	// section.
+	AlertKindSpend     = "spend"
+	AlertKindBudget    = "budget"
+	AlertPeriodDay     = "day"
+	AlertPeriodMonth   = "month"
+	WebhookTypeSlack   = "slack"
+	WebhookTypeGeneric = "generic"
+
+	// Conflict policies for static_labels keys a record already has.
+	StaticLabelsPreferRecord = "prefer-record"
+	StaticLabelsPreferStatic = "prefer-static"
+	StaticLabelsError        = "error"
 )
 
 // Config holds the configuration for the Vantage adapter.
 type Config struct {
+	Profile         string        `yaml:"profile,omitempty"           json:"profile,omitempty"`
 	Token           string        `yaml:"token"                       json:"token"`
 	WorkspaceToken  string        `yaml:"workspace_token,omitempty"   json:"workspace_token,omitempty"`
 	CostReportToken string        `yaml:"cost_report_token,omitempty" json:"cost_report_token,omitempty"`
@@ -27,66 +135,584 @@ type Config struct {
 	Granularity     string        `yaml:"granularity"                 json:"granularity"`
 	GroupBys        []string      `yaml:"group_bys"                   json:"group_bys"`
 	Metrics         []string      `yaml:"metrics"                     json:"metrics"`
+	Filter          string        `yaml:"filter"                      json:"filter,omitempty"`
 	IncludeForecast bool          `yaml:"include_forecast"            json:"include_forecast"`
 	PageSize        int           `yaml:"page_size"                   json:"page_size"`
 	Timeout         time.Duration `yaml:"timeout"                     json:"timeout"`
 	MaxRetries      int           `yaml:"max_retries"                 json:"max_retries"`
+	RetryBudget     int           `yaml:"retry_budget"                json:"retry_budget"`
+	BatchSize       int           `yaml:"batch_size"                  json:"batch_size"`
+	IncludeBudgets  bool          `yaml:"include_budgets"             json:"include_budgets"`
+
+	// PrefetchPages fetches each next costs page concurrently while the
+	// current one is mapped and written.
+	PrefetchPages bool `yaml:"prefetch_pages" json:"prefetch_pages"`
+	// PageRetries requests a costs page up to this many more times when it
+	// still fails after the client's request retries. Retried pages are
+	// checked for rows repeated or skipped by the cursor.
+	PageRetries int `yaml:"page_retries" json:"page_retries"`
+
+	// CostReportName selects the cost report by title instead of token. The
+	// adapter resolves it through the reports API at the start of a sync;
+	// CostReportToken takes precedence when both are set.
+	CostReportName string `yaml:"cost_report_name,omitempty" json:"cost_report_name,omitempty"`
+
+	// TokenEnv is credentials.token_env; the variable is re-read on every
+	// request, so a token rotated by the host process is picked up. OAuth2,
+	// when set, replaces Token with the OAuth2 client-credentials flow.
+	TokenEnv string             `yaml:"token_env,omitempty" json:"token_env,omitempty"`
+	OAuth2   *OAuth2Credentials `yaml:"oauth2,omitempty"    json:"oauth2,omitempty"`
+
+	// OperationTimeout bounds one API call, retries and backoff included,
+	// while Timeout bounds each attempt (0 means no bound).
+	OperationTimeout time.Duration `yaml:"operation_timeout" json:"operation_timeout"`
+
+	// IncludeUnallocated syncs spend cost allocation could not attribute as
+	// records labeled allocation=unallocated.
+	IncludeUnallocated bool `yaml:"include_unallocated" json:"include_unallocated"`
+
+	// CostBasis picks the cost metric copied into each record's
+	// EffectiveCost (net, amortized, or list), so records from different
+	// providers compare on one basis; ProviderCostBasis overrides it per
+	// provider. Unset, records carry no EffectiveCost.
+	CostBasis         string            `yaml:"cost_basis"          json:"cost_basis,omitempty"`
+	ProviderCostBasis map[string]string `yaml:"provider_cost_basis" json:"provider_cost_basis,omitempty"`
+
+	// ServiceCategories maps service names, keyed by lower-case provider
+	// (or "*" for any provider), to FOCUS service categories, over the
+	// built-in tables.
+	ServiceCategories map[string]map[string]string `yaml:"service_categories" json:"service_categories,omitempty"`
+
+	// RegionGeography sets each record's RegionContinent and RegionCountry
+	// from the built-in region table.
+	RegionGeography bool `yaml:"region_geography" json:"region_geography"`
+
+	// LineItemHash is the algorithm cost record LineItemIDs, rolled-up
+	// records' included, are hashed with: "sha256" (empty), "xxhash128", or
+	// "blake3".
+	LineItemHash string `yaml:"line_item_hash" json:"line_item_hash,omitempty"`
+
+	// Rollup before writing: OutputGranularity sums rows into "week" or
+	// "quarter" buckets, dropping resource_id, and DropDimensions clears the
+	// listed dimensions and sums rows that become identical.
+	OutputGranularity string   `yaml:"output_granularity" json:"output_granularity,omitempty"`
+	DropDimensions    []string `yaml:"drop_dimensions"    json:"drop_dimensions,omitempty"`
+
+	// StaticLabels are stamped on every record written. StaticLabelsPolicy
+	// decides keys a record already has; empty means StaticLabelsPreferRecord.
+	StaticLabels       map[string]string `yaml:"static_labels"        json:"static_labels,omitempty"`
+	StaticLabelsPolicy string            `yaml:"static_labels_policy" json:"static_labels_policy,omitempty"`
+
+	// Tag discovery: check tag filters against the workspace's tag keys
+	// before syncing, optionally adding "tags" to group_bys.
+	TagPrefixFilters []string `yaml:"tag_prefix_filters"  json:"tag_prefix_filters,omitempty"`
+	DiscoverTags     bool     `yaml:"discover_tags"       json:"discover_tags"`
+	AutoGroupByTags  bool     `yaml:"auto_group_by_tags"  json:"auto_group_by_tags"`
+
+	// Client-side rate limiting shared by all API requests (0 disables).
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	Burst             int     `yaml:"burst"               json:"burst"`
+
+	// RateLimitRemainingThreshold slows requests down once the remaining
+	// Vantage quota drops below this value (0 disables).
+	RateLimitRemainingThreshold int `yaml:"rate_limit_remaining_threshold" json:"rate_limit_remaining_threshold"`
+
+	// HTTP transport tuning: idle connections kept to the API (0 uses the
+	// client default) and whether to turn off gzip responses.
+	MaxIdleConnsPerHost int  `yaml:"max_idle_conns_per_host" json:"max_idle_conns_per_host"`
+	DisableCompression  bool `yaml:"disable_compression"     json:"disable_compression"`
+
+	// ProxyURL sends API requests through this proxy instead of the one
+	// HTTPS_PROXY names. CABundle, ClientCert, and ClientKey are PEM files
+	// of extra trusted certificate authorities and a client certificate.
+	ProxyURL   string `yaml:"proxy_url"   json:"-"`
+	CABundle   string `yaml:"ca_bundle"   json:"ca_bundle,omitempty"`
+	ClientCert string `yaml:"client_cert" json:"client_cert,omitempty"`
+	ClientKey  string `yaml:"client_key"  json:"client_key,omitempty"`
+
+	// APIVersion selects the Vantage API wire format for costs and
+	// forecasts: v1, v2, or auto to negotiate it with the API.
+	APIVersion string `yaml:"api_version" json:"api_version"`
+
+	// ClientKind selects the API client: handwritten, or openapi for the
+	// client generated from Vantage's OpenAPI spec (binaries built with
+	// -tags openapi only).
+	ClientKind string `yaml:"client_kind" json:"client_kind"`
+
+	// UserAgentSuffix is appended to the User-Agent header to name the
+	// caller, e.g. "acme-finops/2.1".
+	UserAgentSuffix string `yaml:"user_agent_suffix" json:"user_agent_suffix,omitempty"`
+
+	// Headers are extra headers sent with every API request, such as those
+	// an API gateway in front of Vantage requires.
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty"`
+
+	// RestatementWindowDays makes incremental pulls re-fetch the trailing N
+	// days so costs restated by the provider are picked up (0 disables).
+	RestatementWindowDays int `yaml:"restatement_window_days" json:"restatement_window_days"`
+
+	// FillMissingBuckets writes a zero-cost record for every bucket of a
+	// synced range in which a dimension combination seen in that range had
+	// no data.
+	FillMissingBuckets bool `yaml:"fill_missing_buckets" json:"fill_missing_buckets"`
+
+	// FinalityLagDays is how long after a bucket ends it counts as final
+	// for the sync watermark. With WatermarkIntegrationFreshness, buckets
+	// must also have ended before the stalest integration's last import.
+	FinalityLagDays               int  `yaml:"finality_lag_days"               json:"finality_lag_days"`
+	WatermarkIntegrationFreshness bool `yaml:"watermark_integration_freshness" json:"watermark_integration_freshness"`
+
+	// VerifyTotals re-queries each synced range without group_bys and
+	// flags a mismatch when the fetched rows differ from the report total
+	// by more than VerifyTotalsTolerance (a fraction of the total).
+	VerifyTotals          bool    `yaml:"verify_totals"           json:"verify_totals"`
+	VerifyTotalsTolerance float64 `yaml:"verify_totals_tolerance" json:"verify_totals_tolerance"`
+
+	// Currency conversion: when TargetCurrency is set, cost fields are
+	// converted using rates from FXSource before records are written.
+	TargetCurrency string             `yaml:"target_currency"  json:"target_currency,omitempty"`
+	FXSource       string             `yaml:"fx_source"        json:"fx_source,omitempty"`
+	FXRatesFile    string             `yaml:"fx_rates_file"    json:"fx_rates_file,omitempty"`
+	FXBaseCurrency string             `yaml:"fx_base_currency" json:"fx_base_currency,omitempty"`
+	FXRates        map[string]float64 `yaml:"fx_rates"         json:"fx_rates,omitempty"`
+
+	// Sink selects where CLI commands persist records.
+	Sink SinkConfig `yaml:"sink" json:"sink"`
+
+	// Bookmarks selects where sync state is persisted.
+	Bookmarks BookmarkConfig `yaml:"bookmarks" json:"bookmarks"`
+
+	// Lock keeps overlapping runs from syncing the same report at once.
+	Lock LockConfig `yaml:"lock" json:"lock"`
+
+	// Cache keeps API responses on disk between runs.
+	Cache CacheConfig `yaml:"cache" json:"cache"`
+
+	// Tags filters the labels attached to records.
+	Tags TagConfig `yaml:"tags" json:"tags"`
+
+	// Tracing exports OpenTelemetry spans for sync runs.
+	Tracing TracingConfig `yaml:"tracing" json:"tracing"`
+
+	// Transforms rewrite records before they are written, in order.
+	Transforms []TransformConfig `yaml:"transforms" json:"transforms,omitempty"`
+
+	// AllocationRules split shared costs across label values; the first
+	// matching rule wins.
+	AllocationRules []AllocationRule `yaml:"allocation_rules" json:"allocation_rules,omitempty"`
+
+	// Alerts notifies webhooks when spend or budget thresholds are crossed.
+	Alerts AlertsConfig `yaml:"alerts" json:"alerts"`
+
+	// Diagnostics adjusts which omitted record fields diagnostics flag.
+	Diagnostics DiagnosticsConfig `yaml:"diagnostics" json:"diagnostics"`
+
+	// Serve tunes the long-running serve command.
+	Serve ServeConfig `yaml:"serve" json:"serve"`
+
+	// Mock serves synthetic cost data instead of calling Vantage.
+	Mock MockConfig `yaml:"mock" json:"mock"`
+
+	// DeadLetter keeps records the sink failed to write for replay.
+	DeadLetter DeadLetterConfig `yaml:"dead_letter" json:"dead_letter"`
 }
 
-// rawConfig is an intermediate struct for unmarshaling YAML with flexible types.
-type rawConfig struct {
-	Credentials map[string]interface{} `yaml:"credentials"`
-	Params      map[string]interface{} `yaml:"params"`
+// SinkConfig holds the top-level sink section of the config file.
+type SinkConfig struct {
+	Type string `yaml:"type" json:"type"`
+	Path string `yaml:"path" json:"path"`
+	// WriteRetries is how many more times records the sink failed to write
+	// in a partial write are written again.
+	WriteRetries int `yaml:"write_retries" json:"write_retries"`
+	// Writers is how many batches are written to the sink at once, and
+	// WriteQueue how many more may wait for a writer before fetching
+	// pauses.
+	Writers    int `yaml:"writers"     json:"writers"`
+	WriteQueue int `yaml:"write_queue" json:"write_queue,omitempty"`
+	// Journal records each batch in a write-ahead journal under
+	// JournalPath before it is written, so a run interrupted part way
+	// neither loses nor repeats records.
+	Journal     bool   `yaml:"journal"      json:"journal,omitempty"`
+	JournalPath string `yaml:"journal_path" json:"journal_path,omitempty"`
+
+	// Project, Dataset, and Table locate the bigquery sink's table, which
+	// is created on first write; Table also names the clickhouse and duckdb
+	// sinks' tables.
+	// With Upsert, records replace those with the same LineItemID instead
+	// of being appended.
+	Project string `yaml:"project" json:"project,omitempty"`
+	Dataset string `yaml:"dataset" json:"dataset,omitempty"`
+	Table   string `yaml:"table"   json:"table,omitempty"`
+	Upsert  bool   `yaml:"upsert"  json:"upsert,omitempty"`
+
+	// Brokers and Topic locate the kafka sink's topic. Format is json or
+	// avro; avro values are registered with SchemaRegistryURL.
+	Brokers           []string `yaml:"brokers"             json:"brokers,omitempty"`
+	Topic             string   `yaml:"topic"               json:"topic,omitempty"`
+	Format            string   `yaml:"format"              json:"format,omitempty"`
+	SchemaRegistryURL string   `yaml:"schema_registry_url" json:"schema_registry_url,omitempty"`
+
+	// URL is the clickhouse sink's HTTP interface and may hold its
+	// password; Database holds its table, and for the duckdb sink is the
+	// database file. TTLDays expires rows that many days after their
+	// timestamp, and Codec compresses every column.
+	URL      string `yaml:"url"      json:"-"`
+	Database string `yaml:"database" json:"database,omitempty"`
+	TTLDays  int    `yaml:"ttl_days" json:"ttl_days,omitempty"`
+	Codec    string `yaml:"codec"    json:"codec,omitempty"`
+
+	// Compression, MaxFileBytes, and Manifest lay out the file sink's
+	// records: compressed with gzip or zstd, rotated to a new file once one
+	// reaches MaxFileBytes, and listed in manifest.json with their row
+	// counts and date ranges.
+	Compression  string `yaml:"compression"    json:"compression,omitempty"`
+	MaxFileBytes int64  `yaml:"max_file_bytes" json:"max_file_bytes,omitempty"`
+	Manifest     bool   `yaml:"manifest"       json:"manifest,omitempty"`
+	// BucketURL, gs://<bucket>/<prefix> or azblob://<container>/<prefix>,
+	// sends the file sink's records and manifest to a cloud bucket instead
+	// of Path, which still holds local state.
+	BucketURL string `yaml:"bucket_url" json:"bucket_url,omitempty"`
 }
 
-// parseCredentials extracts token from raw config and applies env overrides.
-func parseCredentials(raw *rawConfig) string {
-	var token string
-	if raw.Credentials != nil {
-		if t, ok := raw.Credentials["token"].(string); ok {
-			token = t
-		}
-	}
-	if envToken := os.Getenv("PULUMICOST_VANTAGE_TOKEN"); envToken != "" {
-		token = envToken
-	}
-	return token
+// BookmarkConfig holds the top-level bookmarks section of the config file.
+type BookmarkConfig struct {
+	Type   string `yaml:"type"   json:"type"`
+	Path   string `yaml:"path"   json:"path,omitempty"`   // file and sqlite
+	Table  string `yaml:"table"  json:"table,omitempty"`  // sqlite and dynamodb
+	Region string `yaml:"region" json:"region,omitempty"` // dynamodb
 }
 
-// parseParams extracts params from raw config.
-func parseParams(raw *rawConfig) (string, string, string, string, string, []string, []string, bool, int, int, int) {
-	var workspaceToken, costReportToken, granularityStr, startDateStr, endDateStr string
-	var groupBys, metrics []string
-	var includeForecast bool
-	var pageSize, requestTimeoutSeconds, maxRetries int
+// LockConfig holds the top-level lock section of the config file.
+type LockConfig struct {
+	Type   string `yaml:"type"   json:"type"`
+	Path   string `yaml:"path"   json:"path,omitempty"`   // file: directory of lock files
+	Table  string `yaml:"table"  json:"table,omitempty"`  // dynamodb
+	Region string `yaml:"region" json:"region,omitempty"` // dynamodb
+	DSN    string `yaml:"dsn"    json:"-"`                // postgres
+	// LeaseSeconds is how long a DynamoDB lease lasts without renewal.
+	LeaseSeconds int `yaml:"lease_seconds" json:"lease_seconds,omitempty"`
+	// WaitSeconds is how long a run waits for a held lock before failing.
+	WaitSeconds int `yaml:"wait_seconds" json:"wait_seconds,omitempty"`
+}
 
-	if raw.Params == nil {
-		return workspaceToken, costReportToken, granularityStr, startDateStr, endDateStr, groupBys, metrics, includeForecast, pageSize, requestTimeoutSeconds, maxRetries
-	}
+// DeadLetterConfig holds the top-level dead_letter section of the config
+// file.
+type DeadLetterConfig struct {
+	Type  string `yaml:"type"  json:"type"`
+	Path  string `yaml:"path"  json:"path,omitempty"`  // file and sqlite
+	Table string `yaml:"table" json:"table,omitempty"` // sqlite
+}
 
-	workspaceToken = cast.ToString(raw.Params["workspace_token"])
-	costReportToken = cast.ToString(raw.Params["cost_report_token"])
-	granularityStr = cast.ToString(raw.Params["granularity"])
-	startDateStr = cast.ToString(raw.Params["start_date"])
-	endDateStr = cast.ToString(raw.Params["end_date"])
-	groupBys = cast.ToStringSlice(raw.Params["group_bys"])
-	metrics = cast.ToStringSlice(raw.Params["metrics"])
-	includeForecast = cast.ToBool(raw.Params["include_forecast"])
-	pageSize = cast.ToInt(raw.Params["page_size"])
-	requestTimeoutSeconds = cast.ToInt(raw.Params["request_timeout_seconds"])
-	maxRetries = cast.ToInt(raw.Params["max_retries"])
+// CacheConfig holds the top-level cache section of the config file.
+type CacheConfig struct {
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	Path    string `yaml:"path"    json:"path,omitempty"`
+	// TTLSeconds is how long a cached response is served without asking
+	// the API whether it changed (0 always revalidates).
+	TTLSeconds int `yaml:"ttl_seconds" json:"ttl_seconds,omitempty"`
+}
 
-	return workspaceToken, costReportToken, granularityStr, startDateStr, endDateStr, groupBys, metrics, includeForecast, pageSize, requestTimeoutSeconds, maxRetries
+// MockConfig holds the top-level mock section. When enabled, API clients
+// answer from a synthetic data generator (see client.NewMockTransport)
+// and no credentials or report token are required.
+type MockConfig struct {
+	Enabled       bool     `yaml:"enabled"         json:"enabled"`
+	Seed          int64    `yaml:"seed"            json:"seed,omitempty"`
+	Providers     []string `yaml:"providers"       json:"providers,omitempty"`
+	Services      []string `yaml:"services"        json:"services,omitempty"`
+	Accounts      int      `yaml:"accounts"        json:"accounts,omitempty"`
+	RowsPerBucket int      `yaml:"rows_per_bucket" json:"rows_per_bucket,omitempty"`
+	DailyCost     float64  `yaml:"daily_cost"      json:"daily_cost,omitempty"`
+	// Seasonality is how much lower weekend spend is than weekday spend.
+	Seasonality float64 `yaml:"seasonality" json:"seasonality"`
 }
 
-// parseDates parses start and end dates with env overrides.
+// ServeConfig holds the top-level serve section. serve re-reads it when the
+// config file changes; its --log-level and --probe-interval flags, when
+// given, take precedence.
+type ServeConfig struct {
+	// LogLevel is the minimum level logged, as accepted by client.ParseLevel.
+	LogLevel string `yaml:"log_level" json:"log_level,omitempty"`
+	// ProbeIntervalSeconds is how often the Vantage API is re-probed.
+	ProbeIntervalSeconds int `yaml:"probe_interval_seconds" json:"probe_interval_seconds,omitempty"`
+	// ProjectionStrategy is how GetProjectedCost apportions the report
+	// forecast to resources (see SupportedProjectionStrategies).
+	ProjectionStrategy string `yaml:"projection_strategy" json:"projection_strategy,omitempty"`
+	// ProjectionLookbackDays is how many days of actual spend resource
+	// shares are computed over.
+	ProjectionLookbackDays int `yaml:"projection_lookback_days" json:"projection_lookback_days,omitempty"`
+}
+
+// Projection strategies for the serve section's projection_strategy.
+const (
+	// ProjectionProportional apportions the forecast by each resource's
+	// share of spend over the whole lookback window.
+	ProjectionProportional = "proportional"
+	// ProjectionLatest apportions the forecast by each resource's share of
+	// spend on the latest day of the window, so resources created or
+	// resized during it are weighted by their current footprint.
+	ProjectionLatest = "latest"
+)
+
+// SupportedProjectionStrategies returns the valid
+// serve.projection_strategy values.
+func SupportedProjectionStrategies() []string {
+	return []string{ProjectionProportional, ProjectionLatest}
+}
+
+// DiagnosticsConfig holds the top-level diagnostics section. The top-level
+// field lists apply to every provider; Providers entries, keyed by lower-case
+// provider name, are applied after them.
+type DiagnosticsConfig struct {
+	FieldPolicy `yaml:",inline"`
+	Providers   map[string]FieldPolicy `yaml:"providers" json:"providers,omitempty"`
+
+	// ReportPath, when set, is where CLI syncs write a detailed diagnostics
+	// report in ReportFormat (see SupportedDiagnosticsReportFormats).
+	ReportPath   string `yaml:"report_path"   json:"report_path,omitempty"`
+	ReportFormat string `yaml:"report_format" json:"report_format,omitempty"`
+
+	// UnitPriceTolerance is the relative difference between
+	// effective_unit_price × usage_amount and net_cost above which a record
+	// gets the unit_price_cost_mismatch warning (0 uses 1%).
+	UnitPriceTolerance float64 `yaml:"unit_price_tolerance" json:"unit_price_tolerance,omitempty"`
+}
+
+// FieldPolicy adds fields to (Required) or removes them from (Optional) the
+// record fields whose omission diagnostics flag. See
+// SupportedDiagnosticFields.
+type FieldPolicy struct {
+	Required []string `yaml:"required_fields" json:"required_fields,omitempty"`
+	Optional []string `yaml:"optional_fields" json:"optional_fields,omitempty"`
+}
+
+// TransformConfig is one entry of the top-level transforms section. Type
+// selects a built-in transform (see SupportedTransformTypes); the other
+// fields configure it.
+type TransformConfig struct {
+	Type string `yaml:"type" json:"type"`
+	// provider_filter: keep cost records from Providers, or drop them when
+	// Exclude is set.
+	Providers []string `yaml:"providers" json:"providers,omitempty"`
+	Exclude   bool     `yaml:"exclude"   json:"exclude,omitempty"`
+	// cost_threshold: drop cost records whose absolute net cost is below
+	// MinNetCost.
+	MinNetCost float64 `yaml:"min_net_cost" json:"min_net_cost,omitempty"`
+	// labels: add Labels to every cost record, replacing existing values
+	// only when Overwrite is set.
+	Labels    map[string]string `yaml:"labels"    json:"labels,omitempty"`
+	Overwrite bool              `yaml:"overwrite" json:"overwrite,omitempty"`
+	// pulumi_stack: stamp the Pulumi URN of the resource in StackFiles
+	// that each cost record's URNLabels or resource ID names.
+	StackFiles []string `yaml:"stack_files" json:"stack_files,omitempty"`
+	URNLabels  []string `yaml:"urn_labels"  json:"urn_labels,omitempty"`
+}
+
+// AlertsConfig holds the top-level alerts section: thresholds checked after
+// each pull or backfill, and the webhooks notified when one is crossed.
+type AlertsConfig struct {
+	Webhooks []WebhookConfig `yaml:"webhooks" json:"webhooks,omitempty"`
+	Rules    []AlertRule     `yaml:"rules"    json:"rules,omitempty"`
+}
+
+// WebhookConfig is one alert destination. Type is WebhookTypeSlack or
+// WebhookTypeGeneric (default); URL is read from the URLEnv variable when
+// set, since webhook URLs are secrets.
+type WebhookConfig struct {
+	Name   string `yaml:"name"    json:"name"`
+	Type   string `yaml:"type"    json:"type"`
+	URL    string `yaml:"url"     json:"-"`
+	URLEnv string `yaml:"url_env" json:"url_env,omitempty"`
+}
+
+// AlertRule is one alert threshold. A spend rule fires when a group's net
+// cost over the current day or month reaches Threshold; a budget rule fires
+// when a budget's utilization reaches Percent.
+type AlertRule struct {
+	ID   string `yaml:"id"   json:"id"`
+	Kind string `yaml:"kind" json:"kind"`
+	// Spend rules: GroupBy is a dimension or label key as in the report
+	// command (empty means total spend); Period defaults to month.
+	GroupBy   string  `yaml:"group_by"  json:"group_by,omitempty"`
+	Period    string  `yaml:"period"    json:"period,omitempty"`
+	Threshold float64 `yaml:"threshold" json:"threshold,omitempty"`
+	// Budget rules: Budget limits the rule to one budget, by name or token.
+	Percent float64 `yaml:"percent" json:"percent,omitempty"`
+	Budget  string  `yaml:"budget"  json:"budget,omitempty"`
+	// Webhooks names the destinations; empty means every webhook.
+	Webhooks []string `yaml:"webhooks" json:"webhooks,omitempty"`
+	// Link is sent with the alert for context, such as a dashboard URL.
+	Link string `yaml:"link" json:"link,omitempty"`
+}
+
+// SupportedAlertKinds returns the valid alerts.rules[].kind values.
+func SupportedAlertKinds() []string {
+	return []string{AlertKindSpend, AlertKindBudget}
+}
+
+// SupportedWebhookTypes returns the valid alerts.webhooks[].type values.
+func SupportedWebhookTypes() []string {
+	return []string{WebhookTypeSlack, WebhookTypeGeneric}
+}
+
+// TagConfig holds the top-level tags section of the config file. Patterns are
+// regular expressions matched against normalized (lower-kebab-case) tag keys.
+type TagConfig struct {
+	// Allow keeps only keys matching one of the patterns; empty keeps all.
+	Allow []string `yaml:"allow" json:"allow,omitempty"`
+	// Deny drops keys matching any pattern. Nil applies DefaultTagDenyPatterns.
+	Deny []string `yaml:"deny" json:"deny,omitempty"`
+	// MaxValuesPerKey drops a key from records once it has this many
+	// distinct values in a sync (0 disables).
+	MaxValuesPerKey int `yaml:"max_values_per_key" json:"max_values_per_key,omitempty"`
+	// PreserveRaw copies the original keys and values of kept tags into
+	// CostRecord.LabelsRaw.
+	PreserveRaw bool `yaml:"preserve_raw" json:"preserve_raw,omitempty"`
+
+	// Value rules, applied after keys are normalized and before filtering.
+	TrimValues      bool `yaml:"trim_values"      json:"trim_values,omitempty"`
+	LowercaseValues bool `yaml:"lowercase_values" json:"lowercase_values,omitempty"`
+	// Synonyms maps a tag key ("*" for any key) to values and their
+	// replacements, matched case-insensitively.
+	Synonyms map[string]map[string]string `yaml:"synonyms" json:"synonyms,omitempty"`
+	// Coalesce maps a canonical key to source keys folded into it. The
+	// canonical key wins, then sources in listed order.
+	Coalesce map[string][]string `yaml:"coalesce" json:"coalesce,omitempty"`
+
+	// MergePolicy decides between a provider tag and a kubernetes.io/ label
+	// with the same name. Empty means TagMergeKeepBoth.
+	MergePolicy string `yaml:"merge_policy" json:"merge_policy,omitempty"`
+}
+
+// TracingConfig holds the top-level tracing section of the config file.
+// Unset exporter settings fall back to the standard OTEL_EXPORTER_OTLP_*
+// environment variables.
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Protocol is TracingProtocolHTTP (default) or TracingProtocolGRPC.
+	Protocol string `yaml:"protocol" json:"protocol,omitempty"`
+	// Endpoint is the collector's host:port.
+	Endpoint string            `yaml:"endpoint" json:"endpoint,omitempty"`
+	Insecure bool              `yaml:"insecure" json:"insecure,omitempty"`
+	Headers  map[string]string `yaml:"headers"  json:"-"`
+	// SampleRatio is the fraction of sync runs traced, from 0 to 1.
+	SampleRatio float64 `yaml:"sample_ratio" json:"sample_ratio"`
+	ServiceName string  `yaml:"service_name" json:"service_name,omitempty"`
+}
+
+// DefaultTagDenyPatterns returns the deny patterns used when tags.deny is not
+// configured. They match keys that usually carry one value per pod,
+// container, or node.
+func DefaultTagDenyPatterns() []string {
+	return []string{`.*pod.*uid.*`, `.*container.*id.*`, `.*node.*name.*`}
+}
+
+// SupportedTagMergePolicies returns the accepted tags.merge_policy values.
+func SupportedTagMergePolicies() []string {
+	return []string{TagMergeKeepBoth, TagMergePreferProvider, TagMergePreferK8s}
+}
+
+// SupportedFXSources returns the accepted fx_source values.
+func SupportedFXSources() []string {
+	return []string{FXSourceStatic, FXSourceECB, FXSourceFile}
+}
+
+// SupportedSinkTypes returns the accepted sink.type values.
+func SupportedSinkTypes() []string {
+	return []string{SinkTypeFile, SinkTypeBigQuery, SinkTypeKafka, SinkTypeClickHouse, SinkTypeDuckDB}
+}
+
+// SupportedSinkCompressions returns the accepted sink.compression values.
+func SupportedSinkCompressions() []string {
+	return []string{SinkCompressionNone, SinkCompressionGzip, SinkCompressionZstd}
+}
+
+// SupportedSinkBucketSchemes returns the accepted sink.bucket_url schemes.
+func SupportedSinkBucketSchemes() []string {
+	return []string{SinkBucketSchemeGCS, SinkBucketSchemeAzureBlob}
+}
+
+// SupportedKafkaFormats returns the accepted sink.format values.
+func SupportedKafkaFormats() []string {
+	return []string{KafkaFormatJSON, KafkaFormatAvro}
+}
+
+// SupportedBookmarkStores returns the accepted bookmarks.type values.
+func SupportedBookmarkStores() []string {
+	return []string{BookmarkStoreFile, BookmarkStoreSQLite, BookmarkStoreDynamoDB, BookmarkStoreMemory}
+}
+
+// SupportedLockTypes returns the accepted lock.type values.
+func SupportedLockTypes() []string {
+	return []string{LockTypeNone, LockTypeFile, LockTypePostgres, LockTypeDynamoDB, LockTypeMemory}
+}
+
+// SupportedDeadLetterTypes returns the accepted dead_letter.type values.
+func SupportedDeadLetterTypes() []string {
+	return []string{DeadLetterNone, DeadLetterFile, DeadLetterSQLite}
+}
+
+// SupportedStaticLabelsPolicies returns the accepted static_labels_policy
+// values.
+func SupportedStaticLabelsPolicies() []string {
+	return []string{StaticLabelsPreferRecord, StaticLabelsPreferStatic, StaticLabelsError}
+}
+
+// SupportedTracingProtocols returns the accepted tracing.protocol values.
+func SupportedTracingProtocols() []string {
+	return []string{TracingProtocolHTTP, TracingProtocolGRPC}
+}
+
+// rawSections are the config sections shared by the top level and profiles.
+type rawSections struct {
+	Credentials map[string]interface{}   `yaml:"credentials"`
+	Params      map[string]interface{}   `yaml:"params"`
+	Sink        map[string]interface{}   `yaml:"sink"`
+	Bookmarks   map[string]interface{}   `yaml:"bookmarks"`
+	Lock        map[string]interface{}   `yaml:"lock"`
+	Cache       map[string]interface{}   `yaml:"cache"`
+	Tags        map[string]interface{}   `yaml:"tags"`
+	Tracing     map[string]interface{}   `yaml:"tracing"`
+	Transforms  []map[string]interface{} `yaml:"transforms"`
+	Allocations []map[string]interface{} `yaml:"allocation_rules" mapstructure:"allocation_rules"`
+	Alerts      map[string]interface{}   `yaml:"alerts"`
+	Diagnostics map[string]interface{}   `yaml:"diagnostics"`
+	Serve       map[string]interface{}   `yaml:"serve"`
+	Mock        map[string]interface{}   `yaml:"mock"`
+	DeadLetter  map[string]interface{}   `yaml:"dead_letter" mapstructure:"dead_letter"`
+}
+
+// rawConfig is an intermediate struct for unmarshaling YAML with flexible types.
+type rawConfig struct {
+	rawSections `mapstructure:",squash"`
+
+	// Extends lists the files this one is merged over, relative to it.
+	Extends  []string              `yaml:"extends"`
+	Profiles map[string]rawProfile `yaml:"profiles"`
+
+	// profile is the selected profile name; profileCredentials is set when
+	// that profile supplies its own credentials.
+	profile            string
+	profileCredentials bool
+}
+
+// OAuth2Credentials configures the OAuth2 client-credentials flow.
+type OAuth2Credentials struct {
+	TokenURL     string   `yaml:"token_url"        json:"token_url"`
+	ClientID     string   `yaml:"client_id"        json:"client_id"`
+	ClientSecret string   `yaml:"-"                json:"-"`
+	Scopes       []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+// parseDates parses start and end dates with env overrides. The default
+// start is a year before the current UTC day, so loads on the same day agree.
 func parseDates(startDateStr, endDateStr string) (time.Time, *time.Time, error) {
 	var startDate time.Time
 	if envStartDate := os.Getenv("PULUMICOST_VANTAGE_START_DATE"); envStartDate != "" {
 		startDateStr = envStartDate
 	}
 	if startDateStr == "" {
-		startDate = time.Now().UTC().AddDate(-1, 0, 0)
+		startDate = time.Now().UTC().Truncate(24*time.Hour).AddDate(-1, 0, 0)
 	} else {
 		var err error
 		startDate, err = time.Parse("2006-01-02", startDateStr)
@@ -109,72 +735,74 @@ func parseDates(startDateStr, endDateStr string) (time.Time, *time.Time, error)
 	return startDate, endDate, nil
 }
 
-// LoadConfig loads and parses the config from a YAML file, applying environment variable overrides.
-func LoadConfig(filePath string) (*Config, error) {
-	if filePath == "" {
-		return nil, errors.New("config file path cannot be empty")
-	}
+// LoadToken reads only the API token from the config file, applying the
+// PULUMICOST_VANTAGE_TOKEN override. Unlike LoadConfig it does not require
+// sync params, so it can be used by discovery commands run before a
+// workspace or cost report has been chosen.
+func LoadToken(filePath string) (string, error) {
+	return LoadProfileToken(filePath, "")
+}
 
-	// Check if file exists.
-	if _, err := os.Stat(filePath); err != nil {
-		return nil, fmt.Errorf("config file not found: %s", filePath)
+// LoadProfileToken is LoadToken for a named profile; an empty profile reads
+// the top-level credentials.
+func LoadProfileToken(filePath, profile string) (string, error) {
+	raw, err := readProfile(LoadOptions{Path: filePath, Profile: profile})
+	if err != nil {
+		return "", err
 	}
 
-	// Parse YAML file.
-	v := viper.New()
-	v.SetConfigFile(filePath)
-	v.SetConfigType("yaml")
-
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	token, err := parseCredentials(raw)
+	if err != nil {
+		return "", err
 	}
-
-	// Unmarshal into intermediate struct.
-	var raw rawConfig
-	if err := v.Unmarshal(&raw); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+	if token == "" {
+		return "", errors.New(
+			"credentials.token is required (set via YAML, credentials.token_ref, or PULUMICOST_VANTAGE_TOKEN environment variable)",
+		)
 	}
+	return token, nil
+}
+
+// LoadConfig loads and parses the config from a YAML file, applying environment variable overrides.
+func LoadConfig(filePath string) (*Config, error) {
+	return LoadProfileConfig(filePath, "")
+}
 
-	token := parseCredentials(&raw)
-	workspaceToken, costReportToken, granularityStr, startDateStr, endDateStr, groupBys, metrics, includeForecast, pageSize, requestTimeoutSeconds, maxRetries := parseParams(
-		&raw,
-	)
+// LoadProfileConfig loads the config with the named profile's sections
+// merged over the top-level ones. An empty profile loads the top level only.
+func LoadProfileConfig(filePath, profile string) (*Config, error) {
+	return Load(LoadOptions{Path: filePath, Profile: profile})
+}
 
-	startDate, endDate, err := parseDates(startDateStr, endDateStr)
+// Load loads the config selected by opts, with its overrides applied.
+func Load(opts LoadOptions) (*Config, error) {
+	raw, err := readProfile(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// Build Config struct.
-	cfg := &Config{
-		Token:           token,
-		WorkspaceToken:  workspaceToken,
-		CostReportToken: costReportToken,
-		StartDate:       startDate,
-		EndDate:         endDate,
-		Granularity:     granularityStr,
-		GroupBys:        groupBys,
-		Metrics:         metrics,
-		IncludeForecast: includeForecast,
-		PageSize:        pageSize,
-		MaxRetries:      maxRetries,
-	}
-
-	// Set timeout (convert seconds to duration).
-	if requestTimeoutSeconds > 0 {
-		cfg.Timeout = time.Duration(requestTimeoutSeconds) * time.Second
-	} else {
-		cfg.Timeout = defaultTimeoutSeconds * time.Second
+	token, err := parseCredentials(raw)
+	if err != nil {
+		return nil, err
 	}
-
-	// Set page size default.
-	if cfg.PageSize <= 0 {
-		cfg.PageSize = defaultPageSize
+	params, err := parseParams(raw)
+	if err != nil {
+		return nil, err
+	}
+	startDate, endDate, err := parseDates(params.StartDate, params.EndDate)
+	if err != nil {
+		return nil, err
 	}
 
-	// Set max retries default.
-	if cfg.MaxRetries <= 0 {
-		cfg.MaxRetries = defaultMaxRetries
+	cfg := &Config{
+		Profile:   raw.profile,
+		Token:     token,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	params.apply(cfg)
+	if err := parseSections(raw, cfg); err != nil {
+		return nil, err
 	}
 
 	// Validate the config.
@@ -191,16 +819,23 @@ func ValidateConfig(cfg *Config) error {
 		return errors.New("config is nil")
 	}
 
-	// Token validation.
-	if cfg.Token == "" {
+	// Token validation. The mock generator needs neither credentials nor a
+	// report.
+	switch {
+	case cfg.Mock.Enabled:
+	case cfg.OAuth2 != nil:
+		if cfg.OAuth2.TokenURL == "" || cfg.OAuth2.ClientID == "" || cfg.OAuth2.ClientSecret == "" {
+			return errors.New("credentials.oauth2 requires token_url, client_id, and client_secret (or client_secret_env)")
+		}
+	case cfg.Token == "":
 		return errors.New(
-			"credentials.token is required (set via YAML or PULUMICOST_VANTAGE_TOKEN environment variable)",
+			"credentials.token is required (set via YAML, credentials.token_ref, or PULUMICOST_VANTAGE_TOKEN environment variable)",
 		)
 	}
 
 	// At least one token type must be provided.
-	if cfg.WorkspaceToken == "" && cfg.CostReportToken == "" {
-		return errors.New("either workspace_token or cost_report_token must be specified in params")
+	if !cfg.Mock.Enabled && cfg.WorkspaceToken == "" && cfg.CostReportToken == "" && cfg.CostReportName == "" {
+		return errors.New("one of workspace_token, cost_report_token, or cost_report_name must be specified in params")
 	}
 
 	// Granularity validation.
@@ -233,50 +868,499 @@ func ValidateConfig(cfg *Config) error {
 	if cfg.Timeout < 1*time.Second {
 		return errors.New("timeout must be at least 1 second")
 	}
+	if cfg.OperationTimeout < 0 {
+		return errors.New("operation_timeout_seconds cannot be negative")
+	}
+	if cfg.OperationTimeout > 0 && cfg.OperationTimeout < cfg.Timeout {
+		return fmt.Errorf(
+			"operation_timeout_seconds (%s) must be at least request_timeout_seconds (%s)",
+			cfg.OperationTimeout,
+			cfg.Timeout,
+		)
+	}
 
 	// Max retries validation.
 	if cfg.MaxRetries < 0 {
 		return errors.New("max_retries cannot be negative")
 	}
+	if cfg.RetryBudget < 0 {
+		return errors.New("retry_budget cannot be negative")
+	}
+	if cfg.PageRetries < 0 {
+		return errors.New("page_retries cannot be negative")
+	}
+	if cfg.MaxIdleConnsPerHost < 0 {
+		return errors.New("max_idle_conns_per_host cannot be negative")
+	}
+	if cfg.ProxyURL != "" {
+		if _, err := client.ParseProxyURL(cfg.ProxyURL); err != nil {
+			return fmt.Errorf("invalid proxy_url: %w", err)
+		}
+	}
+	if (cfg.ClientCert == "") != (cfg.ClientKey == "") {
+		return errors.New("client_cert and client_key must be set together")
+	}
+	if err := validateFilter(cfg.Filter); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+
+	// Batch size validation (zero means use the default).
+	if cfg.BatchSize < 0 {
+		return errors.New("batch_size cannot be negative")
+	}
+
+	// Rate limit validation.
+	if cfg.RequestsPerSecond < 0 {
+		return errors.New("requests_per_second cannot be negative")
+	}
+	if cfg.Burst < 0 {
+		return errors.New("burst cannot be negative")
+	}
+	if cfg.RateLimitRemainingThreshold < 0 {
+		return errors.New("rate_limit_remaining_threshold cannot be negative")
+	}
+	if cfg.APIVersion != "" && !slices.Contains(client.SupportedAPIVersions(), cfg.APIVersion) {
+		return fmt.Errorf("invalid api_version: %s (valid: %s)",
+			cfg.APIVersion, strings.Join(client.SupportedAPIVersions(), ", "))
+	}
+	if cfg.ClientKind != "" && !slices.Contains(client.SupportedClientKinds(), cfg.ClientKind) {
+		return fmt.Errorf("invalid client_kind: %s (valid: %s)",
+			cfg.ClientKind, strings.Join(client.SupportedClientKinds(), ", "))
+	}
+	if cfg.ClientKind == client.ClientKindOpenAPI && cfg.APIVersion != client.APIVersionV2 {
+		return fmt.Errorf("client_kind %s requires api_version %s", client.ClientKindOpenAPI, client.APIVersionV2)
+	}
+	if strings.ContainsAny(cfg.UserAgentSuffix, "\r\n") {
+		return errors.New("user_agent_suffix cannot contain line breaks")
+	}
+	if err := client.ValidateHeaders(cfg.Headers); err != nil {
+		return fmt.Errorf("invalid headers: %w", err)
+	}
+
+	// Restatement window validation.
+	if cfg.RestatementWindowDays < 0 {
+		return errors.New("restatement_window_days cannot be negative")
+	}
+	if cfg.RestatementWindowDays > maxRestatementWindowDays {
+		return fmt.Errorf("restatement_window_days cannot exceed %d", maxRestatementWindowDays)
+	}
+	if cfg.FinalityLagDays < 0 {
+		return errors.New("finality_lag_days cannot be negative")
+	}
+	if cfg.FinalityLagDays > maxRestatementWindowDays {
+		return fmt.Errorf("finality_lag_days cannot exceed %d", maxRestatementWindowDays)
+	}
+	if cfg.VerifyTotalsTolerance < 0 || cfg.VerifyTotalsTolerance >= 1 {
+		return fmt.Errorf("invalid verify_totals_tolerance: %g (valid: 0 to less than 1)", cfg.VerifyTotalsTolerance)
+	}
+
+	if cfg.LineItemHash != "" && !slices.Contains(HashAlgorithms(), cfg.LineItemHash) {
+		return fmt.Errorf("invalid line_item_hash: %s (valid: %s)",
+			cfg.LineItemHash, strings.Join(HashAlgorithms(), ", "))
+	}
+
+	if err := validateRollupConfig(cfg); err != nil {
+		return err
+	}
+
+	if err := validateCurrencyConfig(cfg); err != nil {
+		return err
+	}
+	if err := validateServiceCategories(cfg); err != nil {
+		return err
+	}
+	if err := validateCostBasis(cfg); err != nil {
+		return err
+	}
+	if _, _, err := compileTagPatterns(cfg.Tags); err != nil {
+		return err
+	}
+	if err := validateTagRules(cfg.Tags); err != nil {
+		return err
+	}
+	if err := validateDiagnosticsConfig(cfg.Diagnostics); err != nil {
+		return err
+	}
+	if _, err := configuredTransforms(*cfg); err != nil {
+		return err
+	}
+	if _, err := compileAllocationRules(cfg.AllocationRules); err != nil {
+		return err
+	}
+	if len(cfg.AllocationRules) > 0 && cfg.RestatementWindowDays > 0 {
+		return errors.New("allocation_rules cannot be combined with restatement_window_days")
+	}
+	if cfg.FillMissingBuckets && cfg.RestatementWindowDays > 0 {
+		return errors.New("fill_missing_buckets cannot be combined with restatement_window_days")
+	}
+	if err := validateTracingConfig(cfg.Tracing); err != nil {
+		return err
+	}
+	if err := validateAlertsConfig(cfg.Alerts); err != nil {
+		return err
+	}
+	if err := validateLockConfig(cfg.Lock); err != nil {
+		return err
+	}
+	if err := validateServeConfig(cfg.Serve); err != nil {
+		return err
+	}
+	if err := validateCacheConfig(cfg.Cache); err != nil {
+		return err
+	}
+	if err := validateDeadLetterConfig(cfg.DeadLetter); err != nil {
+		return err
+	}
+	if err := validateMockConfig(cfg.Mock); err != nil {
+		return err
+	}
+	if err := validateSinkConfig(cfg.Sink); err != nil {
+		return err
+	}
+	if err := validateBookmarkConfig(cfg.Bookmarks); err != nil {
+		return err
+	}
 
 	// Group bys validation (should not be empty if specified).
 	// Empty list is allowed (will use defaults), but if present should have valid values.
-	validGroupBys := map[string]bool{
-		"provider":    true,
-		"service":     true,
-		"account":     true,
-		"project":     true,
-		"region":      true,
-		"resource_id": true,
-		"tags":        true,
-	}
 	for _, gb := range cfg.GroupBys {
-		if !validGroupBys[gb] {
+		if !slices.Contains(SupportedGroupBys(), gb) {
 			return fmt.Errorf(
-				"invalid group_by value: %s (valid: provider, service, account, project, region, resource_id, tags)",
+				"invalid group_by value: %s (valid: %s)",
 				gb,
+				strings.Join(SupportedGroupBys(), ", "),
 			)
 		}
 	}
 
 	// Metrics validation.
-	validMetrics := map[string]bool{
-		"cost":                 true,
-		"usage":                true,
-		"effective_unit_price": true,
-		"amortized_cost":       true,
-		"taxes":                true,
-		"credits":              true,
-		"refunds":              true,
-	}
 	for _, m := range cfg.Metrics {
-		if !validMetrics[m] {
+		if !slices.Contains(SupportedMetrics(), m) {
 			return fmt.Errorf(
-				"invalid metric value: %s (valid: cost, usage, effective_unit_price, amortized_cost, taxes, credits, refunds)",
+				"invalid metric value: %s (valid: %s)",
 				m,
+				strings.Join(SupportedMetrics(), ", "),
 			)
 		}
 	}
 
 	return nil
 }
+
+// SupportedGroupBys returns the group_by dimensions accepted by the adapter.
+func SupportedGroupBys() []string {
+	return []string{"provider", "service", "account", "billing_account", "project", "region", "resource_id", "tags"}
+}
+
+// SupportedMetrics returns the metrics accepted by the adapter.
+func SupportedMetrics() []string {
+	return []string{
+		"cost",
+		"usage",
+		"effective_unit_price",
+		"amortized_cost",
+		"taxes",
+		"credits",
+		"refunds",
+	}
+}
+
+// validateTracingConfig checks the tracing section. An empty protocol is
+// left for callers that build the Config directly.
+func validateTracingConfig(tracing TracingConfig) error {
+	if tracing.Protocol != "" && !slices.Contains(SupportedTracingProtocols(), tracing.Protocol) {
+		return fmt.Errorf(
+			"invalid tracing.protocol: %s (valid: %s)",
+			tracing.Protocol,
+			strings.Join(SupportedTracingProtocols(), ", "),
+		)
+	}
+	if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got: %g", tracing.SampleRatio)
+	}
+	return nil
+}
+
+// validateAlertsConfig checks the alerts section: every webhook needs a
+// URL, and every rule a threshold and known webhooks.
+func validateAlertsConfig(alerts AlertsConfig) error {
+	names := make(map[string]struct{}, len(alerts.Webhooks))
+	for i, webhook := range alerts.Webhooks {
+		if webhook.Name == "" {
+			return fmt.Errorf("alerts.webhooks[%d]: name is required", i)
+		}
+		if _, duplicate := names[webhook.Name]; duplicate {
+			return fmt.Errorf("alerts.webhooks[%d]: duplicate name: %s", i, webhook.Name)
+		}
+		names[webhook.Name] = struct{}{}
+		if !slices.Contains(SupportedWebhookTypes(), webhook.Type) {
+			return fmt.Errorf("alerts.webhooks[%d]: invalid type: %s (valid: %s)",
+				i, webhook.Type, strings.Join(SupportedWebhookTypes(), ", "))
+		}
+		if webhook.URL == "" {
+			return fmt.Errorf("alerts.webhooks[%d]: url or url_env is required", i)
+		}
+	}
+
+	ids := make(map[string]struct{}, len(alerts.Rules))
+	for i, rule := range alerts.Rules {
+		if err := validateAlertRule(rule, ids, names); err != nil {
+			return fmt.Errorf("alerts.rules[%d]: %w", i, err)
+		}
+		ids[rule.ID] = struct{}{}
+	}
+	if len(alerts.Rules) > 0 && len(alerts.Webhooks) == 0 {
+		return errors.New("alerts.rules requires at least one alerts.webhooks entry")
+	}
+	return nil
+}
+
+// validateAlertRule checks one alert rule; ids holds the IDs of the rules
+// before it and webhooks the configured webhook names.
+func validateAlertRule(rule AlertRule, ids, webhooks map[string]struct{}) error {
+	if rule.ID == "" {
+		return errors.New("id is required")
+	}
+	if _, duplicate := ids[rule.ID]; duplicate {
+		return fmt.Errorf("duplicate id: %s", rule.ID)
+	}
+	switch rule.Kind {
+	case AlertKindSpend:
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("%s: spend requires a positive threshold", rule.ID)
+		}
+		if rule.Period != AlertPeriodDay && rule.Period != AlertPeriodMonth {
+			return fmt.Errorf("%s: period must be '%s' or '%s', got: %s",
+				rule.ID, AlertPeriodDay, AlertPeriodMonth, rule.Period)
+		}
+	case AlertKindBudget:
+		if rule.Percent <= 0 {
+			return fmt.Errorf("%s: budget requires a positive percent", rule.ID)
+		}
+	default:
+		return fmt.Errorf("%s: invalid kind: %s (valid: %s)",
+			rule.ID, rule.Kind, strings.Join(SupportedAlertKinds(), ", "))
+	}
+	for _, name := range rule.Webhooks {
+		if _, ok := webhooks[name]; !ok {
+			return fmt.Errorf("%s: unknown webhook: %s", rule.ID, name)
+		}
+	}
+	return nil
+}
+
+// validateSinkConfig checks the sink section. An empty type is left for
+// callers that build the Config directly and never open a sink.
+func validateSinkConfig(sink SinkConfig) error {
+	if sink.Type != "" && !slices.Contains(SupportedSinkTypes(), sink.Type) {
+		return fmt.Errorf(
+			"invalid sink.type: %s (valid: %s)",
+			sink.Type,
+			strings.Join(SupportedSinkTypes(), ", "),
+		)
+	}
+	if sink.Type == SinkTypeBigQuery && (sink.Project == "" || sink.Dataset == "") {
+		return fmt.Errorf("sink.project and sink.dataset are required when sink.type is '%s'", SinkTypeBigQuery)
+	}
+	if sink.Type == SinkTypeKafka {
+		if len(sink.Brokers) == 0 {
+			return fmt.Errorf("sink.brokers is required when sink.type is '%s'", SinkTypeKafka)
+		}
+		if sink.Format != "" && !slices.Contains(SupportedKafkaFormats(), sink.Format) {
+			return fmt.Errorf(
+				"invalid sink.format: %s (valid: %s)",
+				sink.Format,
+				strings.Join(SupportedKafkaFormats(), ", "),
+			)
+		}
+		if sink.Format == KafkaFormatAvro && sink.SchemaRegistryURL == "" {
+			return fmt.Errorf("sink.schema_registry_url is required when sink.format is '%s'", KafkaFormatAvro)
+		}
+	}
+	if sink.Type == SinkTypeClickHouse && sink.URL == "" {
+		return fmt.Errorf("sink.url is required when sink.type is '%s'", SinkTypeClickHouse)
+	}
+	if sink.TTLDays < 0 {
+		return errors.New("sink.ttl_days cannot be negative")
+	}
+	if sink.Compression != "" && !slices.Contains(SupportedSinkCompressions(), sink.Compression) {
+		return fmt.Errorf(
+			"invalid sink.compression: %s (valid: %s)",
+			sink.Compression,
+			strings.Join(SupportedSinkCompressions(), ", "),
+		)
+	}
+	if sink.MaxFileBytes < 0 {
+		return errors.New("sink.max_file_bytes cannot be negative")
+	}
+	if sink.BucketURL != "" {
+		bucketURL, err := url.Parse(sink.BucketURL)
+		if err != nil || !slices.Contains(SupportedSinkBucketSchemes(), bucketURL.Scheme) || bucketURL.Host == "" {
+			return fmt.Errorf(
+				"invalid sink.bucket_url: %s (valid: gs://<bucket>/<prefix> or azblob://<container>/<prefix>)",
+				sink.BucketURL,
+			)
+		}
+	}
+	if sink.WriteRetries < 0 {
+		return errors.New("sink.write_retries cannot be negative")
+	}
+	if sink.Writers < 0 {
+		return errors.New("sink.writers cannot be negative")
+	}
+	if sink.WriteQueue < 0 {
+		return errors.New("sink.write_queue cannot be negative")
+	}
+	return nil
+}
+
+// validateBookmarkConfig checks the bookmarks section. An empty type is
+// left for callers that build the Config directly.
+func validateBookmarkConfig(bookmarks BookmarkConfig) error {
+	if bookmarks.Type != "" && !slices.Contains(SupportedBookmarkStores(), bookmarks.Type) {
+		return fmt.Errorf(
+			"invalid bookmarks.type: %s (valid: %s)",
+			bookmarks.Type,
+			strings.Join(SupportedBookmarkStores(), ", "),
+		)
+	}
+	return nil
+}
+
+// validateServeConfig checks the serve section.
+func validateServeConfig(serve ServeConfig) error {
+	if serve.LogLevel != "" {
+		if _, err := client.ParseLevel(serve.LogLevel); err != nil {
+			return fmt.Errorf("invalid serve.log_level: %w", err)
+		}
+	}
+	if serve.ProbeIntervalSeconds < 0 {
+		return errors.New("serve.probe_interval_seconds cannot be negative")
+	}
+	if serve.ProjectionStrategy != "" && !slices.Contains(SupportedProjectionStrategies(), serve.ProjectionStrategy) {
+		return fmt.Errorf(
+			"invalid serve.projection_strategy: %s (valid: %s)",
+			serve.ProjectionStrategy,
+			strings.Join(SupportedProjectionStrategies(), ", "),
+		)
+	}
+	if serve.ProjectionLookbackDays < 0 || serve.ProjectionLookbackDays > maxProjectionLookbackDays {
+		return fmt.Errorf("serve.projection_lookback_days must be between 0 and %d", maxProjectionLookbackDays)
+	}
+	return nil
+}
+
+// validateCacheConfig checks the cache section.
+func validateCacheConfig(cache CacheConfig) error {
+	if cache.TTLSeconds < 0 {
+		return errors.New("cache.ttl_seconds cannot be negative")
+	}
+	return nil
+}
+
+// validateMockConfig checks the mock section.
+func validateMockConfig(mock MockConfig) error {
+	switch {
+	case mock.Accounts < 0:
+		return errors.New("mock.accounts cannot be negative")
+	case mock.RowsPerBucket < 0:
+		return errors.New("mock.rows_per_bucket cannot be negative")
+	case mock.DailyCost < 0:
+		return errors.New("mock.daily_cost cannot be negative")
+	case mock.Seasonality < 0 || mock.Seasonality > 1:
+		return errors.New("mock.seasonality must be between 0 and 1")
+	}
+	return nil
+}
+
+// validateLockConfig checks the lock section. An empty type is left for
+// callers that build the Config directly and never lock.
+func validateLockConfig(lock LockConfig) error {
+	if lock.Type != "" && !slices.Contains(SupportedLockTypes(), lock.Type) {
+		return fmt.Errorf(
+			"invalid lock.type: %s (valid: %s)",
+			lock.Type,
+			strings.Join(SupportedLockTypes(), ", "),
+		)
+	}
+	if lock.Type == LockTypePostgres && lock.DSN == "" {
+		return fmt.Errorf("lock.dsn or %s is required when lock.type is '%s'", lockDSNEnv, LockTypePostgres)
+	}
+	if lock.LeaseSeconds < 0 {
+		return fmt.Errorf("lock.lease_seconds cannot be negative, got: %d", lock.LeaseSeconds)
+	}
+	if lock.WaitSeconds < 0 {
+		return fmt.Errorf("lock.wait_seconds cannot be negative, got: %d", lock.WaitSeconds)
+	}
+	return nil
+}
+
+// validateDeadLetterConfig checks the dead_letter section.
+func validateDeadLetterConfig(deadLetter DeadLetterConfig) error {
+	if deadLetter.Type != "" && !slices.Contains(SupportedDeadLetterTypes(), deadLetter.Type) {
+		return fmt.Errorf(
+			"invalid dead_letter.type: %s (valid: %s)",
+			deadLetter.Type,
+			strings.Join(SupportedDeadLetterTypes(), ", "),
+		)
+	}
+	return nil
+}
+
+// validateRollupConfig checks output_granularity against the fetched
+// granularity and drop_dimensions against the supported dimensions.
+// Rolled-up records cannot be reconciled row by row, so the restatement
+// window is not supported with either.
+func validateRollupConfig(cfg *Config) error {
+	switch cfg.OutputGranularity {
+	case "", outputGranularityQuarter:
+	case outputGranularityWeek:
+		if cfg.Granularity != "day" {
+			return errors.New("output_granularity 'week' requires granularity 'day'")
+		}
+	default:
+		return fmt.Errorf("output_granularity must be 'week' or 'quarter', got: %s", cfg.OutputGranularity)
+	}
+	for _, dimension := range cfg.DropDimensions {
+		if !slices.Contains(SupportedDropDimensions(), dimension) {
+			return fmt.Errorf(
+				"invalid drop_dimensions entry: %s (valid: %s)",
+				dimension,
+				strings.Join(SupportedDropDimensions(), ", "),
+			)
+		}
+	}
+	if rolledUp(*cfg) && cfg.RestatementWindowDays > 0 {
+		return errors.New("output_granularity and drop_dimensions cannot be combined with restatement_window_days")
+	}
+	return nil
+}
+
+// validateCurrencyConfig checks the target_currency and fx_* params.
+func validateCurrencyConfig(cfg *Config) error {
+	if cfg.TargetCurrency == "" {
+		return nil
+	}
+	if _, err := currency.NormalizeCode(cfg.TargetCurrency); err != nil {
+		return fmt.Errorf("invalid target_currency: %w", err)
+	}
+
+	switch cfg.FXSource {
+	case FXSourceECB:
+	case FXSourceStatic:
+		if len(cfg.FXRates) == 0 {
+			return errors.New("fx_rates is required when fx_source is 'static'")
+		}
+	case FXSourceFile:
+		if cfg.FXRatesFile == "" {
+			return errors.New("fx_rates_file is required when fx_source is 'file'")
+		}
+	default:
+		return fmt.Errorf(
+			"invalid fx_source: %s (valid: %s)",
+			cfg.FXSource,
+			strings.Join(SupportedFXSources(), ", "),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package adapter
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Config issue severities. Errors are keys or values the loader ignores or
+// rejects; warnings are deprecated keys that still work.
+const (
+	IssueError   = "error"
+	IssueWarning = "warning"
+)
+
+//go:embed config.schema.json
+var configSchemaJSON []byte
+
+// ConfigSchema returns the JSON Schema of the config file.
+func ConfigSchema() []byte {
+	return slices.Clone(configSchemaJSON)
+}
+
+// ConfigIssue is one problem CheckConfigFile found in a config file.
+type ConfigIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	// Path locates the value, such as params.page_size or transforms[0].type.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (i ConfigIssue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", i.File, i.Line, i.Column, i.Severity, i.Message)
+}
+
+// CheckConfigFile checks filePath and the files it extends against the
+// config schema, reporting unknown keys, type errors, values outside the
+// allowed set, and deprecated keys, sorted by line within each file. Unlike
+// LoadConfig it does not resolve credentials or apply the environment, so it
+// needs no secrets. The error is for files that cannot be read or parsed.
+func CheckConfigFile(filePath string) ([]ConfigIssue, error) {
+	schema, err := parseConfigSchema(configSchemaJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ConfigIssue
+	seen := map[string]bool{}
+	pending := []string{filePath}
+	for len(pending) > 0 {
+		path := pending[0]
+		pending = pending[1:]
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("resolving config path %s: %w", path, err)
+		}
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config file not found: %s", path)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML config %s: %w", path, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		c := &schemaChecker{root: schema, file: path}
+		c.check(doc.Content[0], schema, "")
+		sort.SliceStable(c.issues, func(i, j int) bool {
+			if c.issues[i].Line != c.issues[j].Line {
+				return c.issues[i].Line < c.issues[j].Line
+			}
+			return c.issues[i].Column < c.issues[j].Column
+		})
+		issues = append(issues, c.issues...)
+
+		for _, base := range extendsPaths(doc.Content[0]) {
+			if !filepath.IsAbs(base) {
+				base = filepath.Join(filepath.Dir(abs), base)
+			}
+			pending = append(pending, base)
+		}
+	}
+
+	return issues, nil
+}
+
+// extendsPaths returns the top-level extends entries of a document.
+func extendsPaths(root *yaml.Node) []string {
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if !strings.EqualFold(root.Content[i].Value, "extends") {
+			continue
+		}
+		value := root.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			return []string{value.Value}
+		case yaml.SequenceNode:
+			paths := make([]string, 0, len(value.Content))
+			for _, item := range value.Content {
+				if item.Kind == yaml.ScalarNode {
+					paths = append(paths, item.Value)
+				}
+			}
+			return paths
+		}
+	}
+	return nil
+}
+
+// jsonSchema is the subset of JSON Schema the config schema uses.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []string               `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	Deprecated           bool                   `json:"deprecated"`
+	Description          string                 `json:"description"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+}
+
+// schemaTypes is a type keyword, either one name or a list of names.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// additionalProperties is false, true, or a schema for the other keys.
+type additionalProperties struct {
+	allowed bool
+	schema  *jsonSchema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+func parseConfigSchema(data []byte) (*jsonSchema, error) {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("parsing config schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// schemaChecker collects the issues of one file.
+type schemaChecker struct {
+	root   *jsonSchema
+	file   string
+	issues []ConfigIssue
+}
+
+func (c *schemaChecker) report(node *yaml.Node, severity, path, format string, args ...interface{}) {
+	c.issues = append(c.issues, ConfigIssue{
+		File:     c.file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Severity: severity,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// resolve follows a "#/$defs/name" reference.
+func (c *schemaChecker) resolve(schema *jsonSchema) *jsonSchema {
+	for schema.Ref != "" {
+		def, ok := c.root.Defs[strings.TrimPrefix(schema.Ref, "#/$defs/")]
+		if !ok {
+			return &jsonSchema{}
+		}
+		schema = def
+	}
+	return schema
+}
+
+// check validates node against schema. Keys are compared lower-cased and
+// enum values case-insensitively, as the loader does; null values are
+// accepted everywhere, since the loader treats them as unset.
+func (c *schemaChecker) check(node *yaml.Node, schema *jsonSchema, path string) {
+	schema = c.resolve(schema)
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+
+	if len(schema.Enum) > 0 {
+		if node.Kind != yaml.ScalarNode || !slices.ContainsFunc(schema.Enum, func(v string) bool {
+			return strings.EqualFold(v, node.Value)
+		}) {
+			c.report(node, IssueError, path, "invalid %s: %s (valid: %s)",
+				path, nodeDescription(node), strings.Join(schema.Enum, ", "))
+		}
+		return
+	}
+
+	kind := nodeType(node)
+	if len(schema.Type) > 0 && !slices.ContainsFunc(schema.Type, func(t string) bool {
+		return t == kind || (t == "number" && kind == "integer")
+	}) {
+		c.report(node, IssueError, path, "%s must be %s, got %s",
+			path, strings.Join(schema.Type, " or "), kind)
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		c.checkMapping(node, schema, path)
+	case yaml.SequenceNode:
+		if schema.Items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			c.check(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case yaml.ScalarNode:
+		c.checkRange(node, schema, path)
+	}
+}
+
+func (c *schemaChecker) checkMapping(node *yaml.Node, schema *jsonSchema, path string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, value := node.Content[i], node.Content[i+1]
+		key := strings.ToLower(keyNode.Value)
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		if property, ok := schema.Properties[key]; ok {
+			if resolved := c.resolve(property); resolved.Deprecated {
+				c.report(keyNode, IssueWarning, keyPath, "%s is deprecated: %s", keyPath, resolved.Description)
+			}
+			c.check(value, property, keyPath)
+			continue
+		}
+		if schema.AdditionalProperties == nil || schema.AdditionalProperties.allowed {
+			if schema.AdditionalProperties != nil && schema.AdditionalProperties.schema != nil {
+				c.check(value, schema.AdditionalProperties.schema, keyPath)
+			}
+			continue
+		}
+
+		message := "unknown key " + keyPath
+		if suggestion := closestKey(key, schema.Properties); suggestion != "" {
+			message += fmt.Sprintf(" (did you mean %s?)", suggestion)
+		}
+		c.report(keyNode, IssueError, keyPath, "%s", message)
+	}
+}
+
+func (c *schemaChecker) checkRange(node *yaml.Node, schema *jsonSchema, path string) {
+	if schema.Minimum == nil && schema.Maximum == nil {
+		return
+	}
+	value, err := strconv.ParseFloat(node.Value, 64)
+	if err != nil {
+		return
+	}
+	if schema.Minimum != nil && value < *schema.Minimum {
+		c.report(node, IssueError, path, "%s must be at least %s, got %s", path, formatBound(*schema.Minimum), node.Value)
+	}
+	if schema.Maximum != nil && value > *schema.Maximum {
+		c.report(node, IssueError, path, "%s must be at most %s, got %s", path, formatBound(*schema.Maximum), node.Value)
+	}
+}
+
+func formatBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'f', -1, 64)
+}
+
+// nodeType names the JSON Schema type of a YAML node. Timestamps count as
+// strings, since dates are written unquoted.
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case "!!bool":
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+func nodeDescription(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	return nodeType(node)
+}
+
+// closestKey returns the known key within two edits of key, if any.
+func closestKey(key string, properties map[string]*jsonSchema) string {
+	best, bestDistance := "", 3
+	for _, candidate := range slices.Sorted(maps.Keys(properties)) {
+		if d := editDistance(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
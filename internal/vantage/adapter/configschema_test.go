@@ -0,0 +1,94 @@
+package adapter
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func TestConfigSchema_IsValidJSON(t *testing.T) {
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(ConfigSchema(), &schema))
+	assert.Equal(t, "object", schema["type"])
+}
+
+func TestCheckConfigFile_ExampleConfig(t *testing.T) {
+	issues, err := CheckConfigFile(filepath.Join("..", "..", "..", "config.example.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestCheckConfigFile_ReportsIssues(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yaml": `
+params:
+  granularty: day
+`,
+		"config.yaml": `extends: base.yaml
+credentials:
+  token: abc
+params:
+  cost_report_token: cr_test
+  pge_size: 100
+  page_size: "lots"
+  include_budgets: yes please
+  api_version: V2
+  restatement_window_days: 120
+  start_date: 2024-01-01
+  group_bys: provider
+transforms:
+  - type: uppercase
+profiles:
+  prod:
+    sink:
+      pth: ./data
+`,
+	})
+
+	issues, err := CheckConfigFile(filepath.Join(dir, "config.yaml"))
+	require.NoError(t, err)
+
+	var got []string
+	for _, issue := range issues {
+		assert.Equal(t, IssueError, issue.Severity)
+		got = append(got, filepath.Base(issue.File)+":"+issue.Message)
+	}
+	assert.Equal(t, []string{
+		"config.yaml:unknown key params.pge_size (did you mean page_size?)",
+		"config.yaml:params.page_size must be integer, got string",
+		"config.yaml:params.include_budgets must be boolean, got string",
+		"config.yaml:params.restatement_window_days must be at most 90, got 120",
+		"config.yaml:params.group_bys must be array, got string",
+		"config.yaml:invalid transforms[0].type: uppercase (valid: provider_filter, cost_threshold, labels, pulumi_stack)",
+		"config.yaml:unknown key profiles.prod.sink.pth (did you mean path?)",
+		"base.yaml:unknown key params.granularty (did you mean granularity?)",
+	}, got)
+	assert.Equal(t, 6, issues[0].Line)
+	assert.Equal(t, 3, issues[0].Column)
+	assert.Equal(t, "params.pge_size", issues[0].Path)
+}
+
+func TestSchemaChecker_Deprecated(t *testing.T) {
+	schema, err := parseConfigSchema([]byte(`{
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"timeout": {"type": "integer", "deprecated": true, "description": "use request_timeout_seconds"},
+			"request_timeout_seconds": {"type": "integer"}
+		}
+	}`))
+	require.NoError(t, err)
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("request_timeout_seconds: 5\ntimeout: 30\n"), &doc))
+
+	c := &schemaChecker{root: schema, file: "config.yaml"}
+	c.check(doc.Content[0], schema, "")
+	require.Len(t, c.issues, 1)
+	assert.Equal(t, IssueWarning, c.issues[0].Severity)
+	assert.Equal(t, 2, c.issues[0].Line)
+	assert.Equal(t, "timeout is deprecated: use request_timeout_seconds", c.issues[0].Message)
+}
@@ -244,25 +244,24 @@ func TestContract_Pagination(t *testing.T) {
 		Metrics:         []string{"cost", "usage"},
 	}
 
-	pager := client.NewPager(testClient, query, client.NewNoopLogger())
+	var pages []client.Page
+	for page, err := range client.NewPager(testClient, query, client.NewNoopLogger()).Pages(context.Background()) {
+		require.NoError(t, err)
+		pages = append(pages, page)
+	}
+	require.Len(t, pages, 2, "Iteration should stop after the last page")
 
 	// First page.
-	page1, err := pager.NextPage(context.Background())
-	require.NoError(t, err)
+	page1 := pages[0]
 	assert.True(t, page1.HasMore, "First page should indicate more pages available")
 	assert.Equal(t, "page2_cursor_abc123", page1.NextCursor, "First page should have correct cursor")
 	assert.Len(t, page1.Data, 2, "First page should have 2 records")
 
 	// Second page.
-	page2, err := pager.NextPage(context.Background())
-	require.NoError(t, err)
+	page2 := pages[1]
 	assert.False(t, page2.HasMore, "Second page should indicate no more pages")
 	assert.Empty(t, page2.NextCursor, "Second page should have empty cursor")
 	assert.Len(t, page2.Data, 1, "Second page should have 1 record")
-
-	// Third page should fail.
-	_, err = pager.NextPage(context.Background())
-	assert.Error(t, err, "Third page should not exist")
 }
 
 // Helper functions.
@@ -0,0 +1,97 @@
+package adapter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Cost bases for CostRecord.EffectiveCost.
+const (
+	CostBasisNet       = "net"
+	CostBasisAmortized = "amortized"
+	CostBasisList      = "list"
+)
+
+// SupportedCostBases returns the accepted cost_basis values.
+func SupportedCostBases() []string {
+	return []string{CostBasisNet, CostBasisAmortized, CostBasisList}
+}
+
+// costBasisPolicy decides which cost metric becomes a record's
+// EffectiveCost: cost_basis, or the provider's entry in
+// provider_cost_basis.
+type costBasisPolicy struct {
+	basis     string
+	providers map[string]string
+}
+
+// newCostBasisPolicy returns the policy configured in cfg, or nil when
+// neither cost_basis nor provider_cost_basis is set, so records carry no
+// EffectiveCost.
+func newCostBasisPolicy(cfg Config) *costBasisPolicy {
+	if cfg.CostBasis == "" && len(cfg.ProviderCostBasis) == 0 {
+		return nil
+	}
+	policy := &costBasisPolicy{basis: cfg.CostBasis, providers: cfg.ProviderCostBasis}
+	if policy.basis == "" {
+		policy.basis = CostBasisNet
+	}
+	return policy
+}
+
+// apply sets record's EffectiveCost from the cost metric of its provider's
+// basis, and CostBasis to that basis.
+func (p *costBasisPolicy) apply(record *CostRecord) {
+	if p == nil {
+		return
+	}
+
+	basis := p.basis
+	if override, ok := p.providers[strings.ToLower(record.Provider)]; ok {
+		basis = override
+	}
+	record.CostBasis = basis
+
+	var source *float64
+	switch basis {
+	case CostBasisAmortized:
+		source = record.AmortizedCost
+	case CostBasisList:
+		source = record.ListCost
+	default:
+		source = record.NetCost
+	}
+	record.EffectiveCost = nil
+	if source != nil {
+		value := *source
+		record.EffectiveCost = &value
+	}
+}
+
+// validateCostBasis checks cost_basis and provider_cost_basis. An amortized
+// basis needs the amortized_cost metric when metrics are listed.
+func validateCostBasis(cfg *Config) error {
+	check := func(key, basis string) error {
+		if !slices.Contains(SupportedCostBases(), basis) {
+			return fmt.Errorf("invalid %s: %s (valid: %s)", key, basis, strings.Join(SupportedCostBases(), ", "))
+		}
+		if basis == CostBasisAmortized && len(cfg.Metrics) > 0 && !slices.Contains(cfg.Metrics, "amortized_cost") {
+			return fmt.Errorf("%s %s needs amortized_cost in metrics", key, basis)
+		}
+		return nil
+	}
+
+	if cfg.CostBasis != "" {
+		if err := check("cost_basis", cfg.CostBasis); err != nil {
+			return err
+		}
+	}
+	for _, provider := range slices.Sorted(maps.Keys(cfg.ProviderCostBasis)) {
+		if err := check("provider_cost_basis."+provider, cfg.ProviderCostBasis[provider]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
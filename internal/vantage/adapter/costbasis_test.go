@@ -0,0 +1,70 @@
+package adapter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestCostBasisPolicy_Apply(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day"}
+	row := func(provider string) client.CostRow {
+		return client.CostRow{Provider: provider, Cost: 100, AmortizedCost: 90, ListCost: 120, Currency: "USD"}
+	}
+
+	record := adapter.mapVantageRowToCostRecord(row("aws"), query, "hash", "cost")
+	assert.Nil(t, record.EffectiveCost, "no cost basis configured")
+	assert.Empty(t, record.CostBasis)
+
+	adapter.costBasis = newCostBasisPolicy(Config{
+		CostBasis:         CostBasisAmortized,
+		ProviderCostBasis: map[string]string{"gcp": CostBasisList},
+	})
+	record = adapter.mapVantageRowToCostRecord(row("aws"), query, "hash", "cost")
+	require.NotNil(t, record.EffectiveCost)
+	assert.InDelta(t, 90.0, *record.EffectiveCost, 1e-9)
+	assert.InDelta(t, 100.0, *record.NetCost, 1e-9, "net cost is left as reported")
+	assert.Equal(t, CostBasisAmortized, record.CostBasis)
+
+	record = adapter.mapVantageRowToCostRecord(row("GCP"), query, "hash", "cost")
+	require.NotNil(t, record.EffectiveCost)
+	assert.InDelta(t, 120.0, *record.EffectiveCost, 1e-9)
+	assert.Equal(t, CostBasisList, record.CostBasis)
+
+	// Only overrides configured: other providers use net.
+	adapter.costBasis = newCostBasisPolicy(Config{ProviderCostBasis: map[string]string{"gcp": CostBasisList}})
+	record = adapter.mapVantageRowToCostRecord(row("azure"), query, "hash", "cost")
+	require.NotNil(t, record.EffectiveCost)
+	assert.InDelta(t, 100.0, *record.EffectiveCost, 1e-9)
+	assert.Equal(t, CostBasisNet, record.CostBasis)
+}
+
+func TestLoadConfig_CostBasis(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	load := func(params string) (*Config, error) {
+		dir := writeConfigFiles(t, map[string]string{
+			"config.yaml": "credentials:\n  token: test-token\nparams:\n  cost_report_token: cr_test\n" +
+				"  granularity: day\n" + params,
+		})
+		return LoadConfig(filepath.Join(dir, "config.yaml"))
+	}
+
+	cfg, err := load("  cost_basis: Amortized\n  provider_cost_basis:\n    AWS: list\n")
+	require.NoError(t, err)
+	assert.Equal(t, CostBasisAmortized, cfg.CostBasis)
+	assert.Equal(t, map[string]string{"aws": CostBasisList}, cfg.ProviderCostBasis)
+
+	_, err = load("  cost_basis: blended\n")
+	require.EqualError(t, err, "invalid cost_basis: blended (valid: net, amortized, list)")
+
+	_, err = load("  provider_cost_basis:\n    aws: blended\n")
+	require.EqualError(t, err, "invalid provider_cost_basis.aws: blended (valid: net, amortized, list)")
+
+	_, err = load("  metrics: [cost]\n  cost_basis: amortized\n")
+	require.EqualError(t, err, "cost_basis amortized needs amortized_cost in metrics")
+}
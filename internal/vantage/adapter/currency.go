@@ -0,0 +1,144 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CurrencyConverter supplies exchange rates into a single target currency.
+type CurrencyConverter interface {
+	// Target returns the ISO 4217 code records are converted to.
+	Target() string
+
+	// Rate returns the multiplier converting one unit of from into the
+	// target currency on date.
+	Rate(ctx context.Context, from string, date time.Time) (float64, error)
+}
+
+// SetCurrencyConverter enables conversion of every record's cost fields into
+// the converter's target currency before records are written.
+func (a *Adapter) SetCurrencyConverter(converter CurrencyConverter) {
+	a.converter = converter
+}
+
+// writeRecords converts records to the target currency, when one is
+// configured, and writes them to sink, retrying records of a partial write.
+// With a journal, the batch is journaled first and committed once written.
+func (a *Adapter) writeRecords(ctx context.Context, sink Sink, records []CostRecord) (err error) {
+	ctx, span := tracer().Start(ctx, "vantage.sink_write", trace.WithAttributes(
+		attribute.Int(attrRecords, len(records)),
+	))
+	defer func() { finishSpan(span, err) }()
+
+	batch, err := a.prepareBatch(ctx, sink, records)
+	if err != nil || batch == nil {
+		return err
+	}
+	written, failed, err := a.writeToSink(ctx, sink, batch.records)
+	return a.finishBatch(ctx, batch, written, failed, err)
+}
+
+// sinkBatch is a batch of records ready to be written to the sink.
+type sinkBatch struct {
+	records []CostRecord
+	// seq is the batch's journal entry, or 0 when it is not journaled.
+	seq uint64
+}
+
+// prepareBatch transforms and converts records and journals them, returning
+// nil when the journal shows every record was already written.
+func (a *Adapter) prepareBatch(ctx context.Context, sink Sink, records []CostRecord) (*sinkBatch, error) {
+	records, err := a.applyTransforms(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+	if a.converter != nil {
+		for i := range records {
+			if convertErr := a.convertRecord(ctx, &records[i]); convertErr != nil {
+				return nil, convertErr
+			}
+		}
+	}
+	for i := range records {
+		records[i].SchemaVersion = CurrentSchemaVersion
+	}
+	if len(records) > 0 {
+		if records = a.skipJournaled(records); len(records) == 0 {
+			return nil, nil
+		}
+	}
+	seq, err := a.appendJournal(ctx, sink, records)
+	if err != nil {
+		return nil, err
+	}
+	return &sinkBatch{records: records, seq: seq}, nil
+}
+
+// finishBatch accounts for a batch writeToSink returned from, handling its
+// failed records and committing its journal entry.
+func (a *Adapter) finishBatch(
+	ctx context.Context,
+	batch *sinkBatch,
+	written int,
+	failed []failedRecord,
+	err error,
+) error {
+	a.stats.RecordsWritten += written
+	if err == nil {
+		err = a.handleFailedRecords(ctx, batch.records, failed)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSink, err)
+	}
+	return a.commitJournal(ctx, batch.seq)
+}
+
+// convertRecord rewrites a record's monetary fields into the target currency,
+// keeping the original currency and applied rate for auditability. Records
+// without a currency, or already in the target currency, are left as is.
+func (a *Adapter) convertRecord(ctx context.Context, record *CostRecord) error {
+	target := a.converter.Target()
+	if record.Currency == "" || strings.EqualFold(record.Currency, target) {
+		return nil
+	}
+
+	rate, err := a.converter.Rate(ctx, record.Currency, record.Timestamp)
+	if err != nil {
+		return fmt.Errorf(
+			"converting %s to %s for %s: %w",
+			record.Currency,
+			target,
+			record.Timestamp.Format("2006-01-02"),
+			err,
+		)
+	}
+
+	amounts := []**float64{
+		&record.ListCost,
+		&record.NetCost,
+		&record.AmortizedCost,
+		&record.EffectiveCost,
+		&record.EffectiveUnitPrice,
+		&record.TaxCost,
+		&record.CreditAmount,
+		&record.RefundAmount,
+		&record.BudgetAmount,
+		&record.EstimatedMonthlySavings,
+	}
+	for _, amount := range amounts {
+		if *amount != nil {
+			converted := **amount * rate
+			*amount = &converted
+		}
+	}
+
+	record.OriginalCurrency = record.Currency
+	record.FXRate = &rate
+	record.Currency = target
+	return nil
+}
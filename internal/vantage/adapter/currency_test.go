@@ -0,0 +1,104 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/currency"
+)
+
+// stubConverter converts everything into USD at a fixed rate.
+type stubConverter struct {
+	rate float64
+	err  error
+}
+
+func (s stubConverter) Target() string { return "USD" }
+
+func (s stubConverter) Rate(_ context.Context, _ string, _ time.Time) (float64, error) {
+	return s.rate, s.err
+}
+
+func TestAdapter_WriteRecords_ConvertsCurrency(t *testing.T) {
+	mockSink := &mockSink{}
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	adapter.SetCurrencyConverter(stubConverter{rate: 1.1})
+
+	net, list := 10.0, 20.0
+	usd := 5.0
+	records := []CostRecord{
+		{Currency: "EUR", NetCost: &net, ListCost: &list},
+		{Currency: "usd", NetCost: &usd},
+		{LineItemID: "tombstone", MetricType: MetricTypeDeletion},
+	}
+	require.NoError(t, adapter.writeRecords(context.Background(), mockSink, records))
+
+	converted := mockSink.records[0]
+	assert.Equal(t, "USD", converted.Currency)
+	assert.Equal(t, "EUR", converted.OriginalCurrency)
+	require.NotNil(t, converted.FXRate)
+	assert.InDelta(t, 1.1, *converted.FXRate, 1e-12)
+	assert.InDelta(t, 11.0, *converted.NetCost, 1e-12)
+	assert.InDelta(t, 22.0, *converted.ListCost, 1e-12)
+	assert.Nil(t, converted.AmortizedCost)
+	assert.InDelta(t, 10.0, net, 1e-12, "source amounts are not modified in place")
+
+	assert.Empty(t, mockSink.records[1].OriginalCurrency)
+	assert.Nil(t, mockSink.records[1].FXRate)
+	assert.Empty(t, mockSink.records[2].Currency)
+}
+
+func TestAdapter_WriteRecords_ConversionError(t *testing.T) {
+	mockSink := &mockSink{}
+
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	adapter.SetCurrencyConverter(stubConverter{err: errors.New("no rate")})
+
+	err := adapter.writeRecords(context.Background(), mockSink, []CostRecord{{Currency: "EUR"}})
+	require.ErrorContains(t, err, "converting EUR to USD")
+	mockSink.AssertNotCalled(t, "WriteRecords", mock.Anything, mock.Anything)
+}
+
+func TestAdapter_Sync_ConvertsCurrency(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+
+	provider, err := currency.NewStatic("USD", map[string]float64{"EUR": 0.8})
+	require.NoError(t, err)
+	converter, err := currency.NewConverter(provider, "USD")
+	require.NoError(t, err)
+
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.SetCurrencyConverter(converter)
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+		Granularity:     "day",
+		PageSize:        100,
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.Anything).Return(client.Page{
+		Data: []client.CostRow{{BucketStart: cfg.StartDate, Provider: "aws", Service: "ec2", Cost: 8, Currency: "EUR"}},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+	require.Len(t, mockSink.records, 1)
+	assert.Equal(t, "USD", mockSink.records[0].Currency)
+	assert.InDelta(t, 10.0, *mockSink.records[0].NetCost, 1e-12)
+	assert.InDelta(t, 1.25, *mockSink.records[0].FXRate, 1e-12)
+}
@@ -0,0 +1,152 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// DeadLetter is a record the sink failed to write, kept with why so it can
+// be written again later instead of being lost.
+type DeadLetter struct {
+	Record CostRecord `json:"record"`
+	Error  string     `json:"error"`
+	// Rejected is set when the sink rejected the record (see
+	// ErrRecordRejected), so replaying it fails again unless the sink or
+	// its schema changed.
+	Rejected bool `json:"rejected,omitempty"`
+	// Attempts counts every write of the record, replays included.
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+	// CorrelationID identifies the sync or replay that last failed it.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// DeadLetterQueue keeps records the sink failed to write.
+type DeadLetterQueue interface {
+	// AddDeadLetters stores letters, after any already stored.
+	AddDeadLetters(ctx context.Context, letters []DeadLetter) error
+}
+
+// SetDeadLetterQueue sets where records the sink rejects, or still fails
+// to write after its retries, are kept. The sync then continues instead of
+// failing; without a queue rejected records are dropped and other failures
+// fail the sync.
+func (a *Adapter) SetDeadLetterQueue(queue DeadLetterQueue) {
+	a.deadLetters = queue
+}
+
+// addDeadLetters adds the failed records to the dead-letter queue.
+func (a *Adapter) addDeadLetters(ctx context.Context, records []CostRecord, failed []failedRecord) error {
+	now := time.Now().UTC()
+	letters := make([]DeadLetter, 0, len(failed))
+	for _, f := range failed {
+		// A record JSON cannot encode, such as one with a NaN amount, could
+		// never be stored or replayed; it was rejected, so it is dropped.
+		if _, err := json.Marshal(&records[f.index]); err != nil {
+			a.logger.Warn(ctx, "Dropped a record the dead-letter queue cannot encode", map[string]interface{}{
+				"adapter":      "vantage",
+				"operation":    "write_records",
+				"line_item_id": records[f.index].LineItemID,
+				"error":        err.Error(),
+			})
+			continue
+		}
+		letters = append(letters, DeadLetter{
+			Record:        records[f.index],
+			Error:         f.err.Error(),
+			Rejected:      f.rejected(),
+			Attempts:      f.attempts,
+			FailedAt:      now,
+			CorrelationID: a.correlationID,
+		})
+	}
+	if len(letters) == 0 {
+		return nil
+	}
+	if err := a.deadLetters.AddDeadLetters(ctx, letters); err != nil {
+		return fmt.Errorf("adding %d records to the dead-letter queue: %w", len(letters), err)
+	}
+
+	a.diagnosticsSummary.DeadLetterRecords += len(letters)
+	a.logger.Warn(ctx, "Added records the sink failed to write to the dead-letter queue", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "write_records",
+		"records":   len(letters),
+		"error":     letters[0].Error,
+	})
+	return nil
+}
+
+// DecodeDeadLetter decodes a JSON dead letter, upgrading its record to the
+// current schema as DecodeRecord does.
+func DecodeDeadLetter(data []byte) (DeadLetter, error) {
+	var raw struct {
+		DeadLetter
+		Record json.RawMessage `json:"record"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return DeadLetter{}, err
+	}
+	letter := raw.DeadLetter
+	record, err := DecodeRecord(raw.Record)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	letter.Record = record
+	return letter, nil
+}
+
+// ReplayDeadLetters writes the records of letters to sink again, in batches
+// of cfg.BatchSize, retrying as a sync does. It returns the letters still
+// failing, with their latest error, followed by any not attempted when a
+// failed write stopped the replay; the caller replaces the queue with
+// them.
+func (a *Adapter) ReplayDeadLetters(
+	ctx context.Context,
+	cfg Config,
+	sink Sink,
+	letters []DeadLetter,
+) ([]DeadLetter, error) {
+	a.ResetDiagnosticsSummary()
+	a.stats = SyncStats{Bookmarks: []BookmarkChange{}}
+	a.correlationID = client.CorrelationID(ctx)
+	if a.correlationID == "" {
+		a.correlationID = client.NewCorrelationID()
+	}
+	a.sinkWriteRetries = cfg.Sink.WriteRetries
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var remaining []DeadLetter
+	for start := 0; start < len(letters); start += batchSize {
+		batch := letters[start:min(start+batchSize, len(letters))]
+		records := make([]CostRecord, len(batch))
+		for i := range batch {
+			records[i] = batch[i].Record
+			records[i].SchemaVersion = CurrentSchemaVersion
+		}
+
+		written, failed, err := a.writeToSink(ctx, sink, records)
+		a.stats.RecordsWritten += written
+		if err != nil {
+			return append(remaining, letters[start:]...), fmt.Errorf("%w: %w", ErrSink, err)
+		}
+		now := time.Now().UTC()
+		for _, f := range failed {
+			letter := batch[f.index]
+			letter.Error = f.err.Error()
+			letter.Rejected = f.rejected()
+			letter.Attempts += f.attempts
+			letter.FailedAt = now
+			letter.CorrelationID = a.correlationID
+			remaining = append(remaining, letter)
+		}
+	}
+	return remaining, nil
+}
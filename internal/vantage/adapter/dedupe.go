@@ -0,0 +1,80 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// dedupeWindowPages is how many pages' LineItemIDs are kept to drop rows
+// Vantage repeats. A repeat comes from a cursor that slipped back over a
+// page boundary, so it lands on a page close after the original; keeping
+// only recent pages bounds memory to a few pages however long the range.
+const dedupeWindowPages = 4
+
+// recentIDs is the set of LineItemIDs on the last few pages of a fetch,
+// kept as 16-byte digests rather than strings.
+type recentIDs struct {
+	pages []map[[16]byte]struct{}
+	limit int
+}
+
+// newRecentIDs returns an empty set remembering the IDs of up to pages
+// pages.
+func newRecentIDs(pages int) *recentIDs {
+	return &recentIDs{limit: max(pages, 1)}
+}
+
+// nextPage starts a new page, forgetting the IDs of the oldest page once
+// the window is full.
+func (r *recentIDs) nextPage() {
+	if len(r.pages) == r.limit {
+		// Reuse the oldest page's map for the new page.
+		oldest := r.pages[0]
+		clear(oldest)
+		r.pages = append(r.pages[1:], oldest)
+		return
+	}
+	r.pages = append(r.pages, make(map[[16]byte]struct{}))
+}
+
+// contains reports whether id is on a page in the window.
+func (r *recentIDs) contains(id string) bool {
+	key := idDigest(id)
+	for _, page := range r.pages {
+		if _, ok := page[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// add records id on the current page.
+func (r *recentIDs) add(id string) {
+	if len(r.pages) == 0 {
+		r.nextPage()
+	}
+	r.pages[len(r.pages)-1][idDigest(id)] = struct{}{}
+}
+
+// len returns how many IDs the window holds.
+func (r *recentIDs) len() int {
+	n := 0
+	for _, page := range r.pages {
+		n += len(page)
+	}
+	return n
+}
+
+// idDigest returns the 16 bytes a LineItemID, 32 hex characters, encodes;
+// any other ID is hashed.
+func idDigest(id string) [16]byte {
+	var key [16]byte
+	if len(id) == hex.EncodedLen(len(key)) {
+		if _, err := hex.Decode(key[:], []byte(id)); err == nil {
+			return key
+		}
+	}
+	sum := sha256.Sum256([]byte(id))
+	copy(key[:], sum[:16])
+	return key
+}
@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestRecentIDs_Bounded(t *testing.T) {
+	const pageSize = 100
+	seen := newRecentIDs(dedupeWindowPages)
+	id := func(n int) string { return fmt.Sprintf("%032x", n) }
+
+	// However many pages go by, only the window's IDs are kept.
+	for page := range 50 {
+		seen.nextPage()
+		for row := range pageSize {
+			seen.add(id(page*pageSize + row))
+		}
+		assert.LessOrEqual(t, seen.len(), dedupeWindowPages*pageSize)
+	}
+	assert.Equal(t, dedupeWindowPages*pageSize, seen.len())
+	assert.Len(t, seen.pages, dedupeWindowPages)
+
+	assert.True(t, seen.contains(id(49*pageSize)), "the current page")
+	assert.True(t, seen.contains(id((50-dedupeWindowPages)*pageSize)), "the oldest page in the window")
+	assert.False(t, seen.contains(id((49-dedupeWindowPages)*pageSize)), "a page past the window")
+
+	// IDs that are not 32 hex characters are hashed.
+	seen.add("not-a-line-item-id")
+	assert.True(t, seen.contains("not-a-line-item-id"))
+	assert.False(t, seen.contains("another-id"))
+}
+
+func TestAdapter_SyncSingleRange_DuplicateWindow(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day", Metrics: []string{"cost"}}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	row := func(service string) client.CostRow {
+		return client.CostRow{Provider: "aws", Service: service, Cost: 1, Currency: "USD", BucketStart: day}
+	}
+
+	// Page 0 holds "first"; page 1 repeats it, and the last page repeats it
+	// again once page 0 has left the window.
+	pages := dedupeWindowPages + 2
+	for i := range pages {
+		data := []client.CostRow{row(fmt.Sprintf("svc-%d", i))}
+		if i == 0 || i == 1 || i == pages-1 {
+			data = append(data, row("first"))
+		}
+		next := ""
+		if i < pages-1 {
+			next = fmt.Sprintf("cursor%d", i+1)
+		}
+		cursor := ""
+		if i > 0 {
+			cursor = fmt.Sprintf("cursor%d", i)
+		}
+		mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+			return q.Cursor == cursor
+		})).Return(client.Page{Data: data, NextCursor: next, HasMore: next != ""}, nil)
+	}
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	err := adapter.syncSingleRange(context.Background(), cfg, mockSink, day, day.AddDate(0, 0, 1), true)
+	require.NoError(t, err)
+
+	var first int
+	for _, record := range mockSink.records {
+		if record.Service == "first" {
+			first++
+		}
+	}
+	assert.Equal(t, 2, first, "a repeat on the next page is dropped; one past the window is not")
+	assert.Equal(t, 1, adapter.GetDiagnosticsSummary().DuplicateRecords)
+	assert.Len(t, mockSink.records, pages+2)
+}
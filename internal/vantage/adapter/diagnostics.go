@@ -49,12 +49,29 @@ func (d *Diagnostics) HasIssues() bool {
 
 // DiagnosticsSummary aggregates diagnostic information across multiple records.
 type DiagnosticsSummary struct {
+	// CorrelationID identifies the sync in its log lines and in the
+	// X-Request-Id header of its API requests.
+	CorrelationID string `json:"correlation_id,omitempty"`
+
 	// TotalRecords is the total number of records processed.
 	TotalRecords int `json:"total_records"`
 
 	// RecordsWithIssues is the number of records that had diagnostic issues.
 	RecordsWithIssues int `json:"records_with_issues"`
 
+	// DuplicateRecords is the number of rows dropped because an earlier
+	// page of the same query already returned their LineItemID.
+	DuplicateRecords int `json:"duplicate_records,omitempty"`
+
+	// RejectedRecords is the number of records the sink rejected (see
+	// ErrRecordRejected): dropped, or dead-lettered when a dead-letter
+	// queue is set.
+	RejectedRecords int `json:"rejected_records,omitempty"`
+
+	// DeadLetterRecords is the number of records added to the dead-letter
+	// queue, rejected ones included.
+	DeadLetterRecords int `json:"dead_letter_records,omitempty"`
+
 	// MissingFields maps field names to the number of records missing that field.
 	MissingFields map[string]int `json:"missing_fields,omitempty"`
 
@@ -63,6 +80,27 @@ type DiagnosticsSummary struct {
 
 	// SourceInfo provides aggregated information about data sources.
 	SourceInfo map[string]interface{} `json:"source_info,omitempty"`
+
+	// UnallocatedCost is the net cost of unallocated rows, and
+	// UnallocatedShare its fraction of all net cost fetched (0 to 1). Both
+	// are set only when unallocated rows were requested, so tagging
+	// coverage can be tracked across runs.
+	UnallocatedCost  *float64 `json:"unallocated_cost,omitempty"`
+	UnallocatedShare *float64 `json:"unallocated_share,omitempty"`
+
+	// TotalsMismatches lists the ranges whose fetched rows did not add up
+	// to the report total, when verify_totals is enabled.
+	TotalsMismatches []TotalsMismatch `json:"totals_mismatches,omitempty"`
+
+	// QualityScore rates the sync's data from 0 to 100 (see
+	// ComputeQualityScore); set once a sync succeeds.
+	QualityScore *float64 `json:"quality_score,omitempty"`
+
+	// netCost is all net cost fetched, unallocated included.
+	netCost float64
+
+	// breakdown collects the detailed report's counts when enabled.
+	breakdown *diagnosticsBreakdown
 }
 
 // NewDiagnosticsSummary creates a new diagnostics summary.
@@ -98,6 +136,31 @@ func (ds *DiagnosticsSummary) AddRecordDiagnostics(diag *Diagnostics) {
 	}
 }
 
+// AddSpend adds a fetched row's net cost to the unallocated share.
+func (ds *DiagnosticsSummary) AddSpend(netCost float64, unallocated bool) {
+	ds.netCost += netCost
+	if !unallocated {
+		return
+	}
+	if ds.UnallocatedCost == nil {
+		ds.UnallocatedCost = new(float64)
+	}
+	*ds.UnallocatedCost += netCost
+}
+
+// TrackUnallocated reports the unallocated share of the spend added so far,
+// zero when none was unallocated. It is left unset when there is no spend.
+func (ds *DiagnosticsSummary) TrackUnallocated() {
+	if ds.UnallocatedCost == nil {
+		ds.UnallocatedCost = new(float64)
+	}
+	if ds.netCost == 0 {
+		return
+	}
+	share := *ds.UnallocatedCost / ds.netCost
+	ds.UnallocatedShare = &share
+}
+
 // HasIssues returns true if any records had issues.
 func (ds *DiagnosticsSummary) HasIssues() bool {
 	return ds.RecordsWithIssues > 0
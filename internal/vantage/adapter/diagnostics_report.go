@@ -0,0 +1,270 @@
+package adapter
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Diagnostics report formats.
+const (
+	DiagnosticsReportJSON = "json"
+	DiagnosticsReportHTML = "html"
+)
+
+// diagnosticsReportTopN caps each breakdown list in the report.
+const diagnosticsReportTopN = 20
+
+// SupportedDiagnosticsReportFormats returns the accepted
+// diagnostics.report_format values.
+func SupportedDiagnosticsReportFormats() []string {
+	return []string{DiagnosticsReportJSON, DiagnosticsReportHTML}
+}
+
+// DimensionCount is the records and net cost for one dimension value.
+type DimensionCount struct {
+	Key     string  `json:"key"`
+	Records int     `json:"records"`
+	NetCost float64 `json:"net_cost"`
+}
+
+// TagCoverage is how much of the fetched spend carries tags. The synthetic
+// allocation label on unallocated spend does not count as a tag.
+type TagCoverage struct {
+	Records       int     `json:"records"`
+	TaggedRecords int     `json:"tagged_records"`
+	RecordPercent float64 `json:"record_percent"`
+	NetCost       float64 `json:"net_cost"`
+	TaggedNetCost float64 `json:"tagged_net_cost"`
+	CostPercent   float64 `json:"cost_percent"`
+	// Keys lists the most common tag keys with the percent of records
+	// carrying each.
+	Keys []TagKeyCoverage `json:"keys"`
+}
+
+// TagKeyCoverage is the records carrying one tag key.
+type TagKeyCoverage struct {
+	Key     string  `json:"key"`
+	Records int     `json:"records"`
+	Percent float64 `json:"percent"`
+}
+
+// DiagnosticsReport is the detailed diagnostics of one sync: the summary
+// counts plus per-dimension breakdowns of where the issues are.
+type DiagnosticsReport struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Summary     *DiagnosticsSummary `json:"summary"`
+	// MissingResourceIDByService lists the services with the most records
+	// flagged missing_resource_id.
+	MissingResourceIDByService []DimensionCount `json:"missing_resource_id_by_service"`
+	// NegativeCostByProvider lists the providers with negative net cost
+	// records, most records first.
+	NegativeCostByProvider []DimensionCount `json:"negative_cost_by_provider"`
+	// UnmappedServices lists the provider/service names with the most
+	// records flagged unmapped_service_category.
+	UnmappedServices []DimensionCount `json:"unmapped_services"`
+	TagCoverage      TagCoverage      `json:"tag_coverage"`
+}
+
+// diagnosticsBreakdown collects the per-dimension counts of a report.
+type diagnosticsBreakdown struct {
+	missingResourceID map[string]*DimensionCount
+	negativeCost      map[string]*DimensionCount
+	unmappedServices  map[string]*DimensionCount
+	tagKeys           map[string]int
+	coverage          TagCoverage
+}
+
+// EnableBreakdown makes the summary collect the per-dimension counts
+// Report needs.
+func (ds *DiagnosticsSummary) EnableBreakdown() {
+	ds.breakdown = &diagnosticsBreakdown{
+		missingResourceID: make(map[string]*DimensionCount),
+		negativeCost:      make(map[string]*DimensionCount),
+		unmappedServices:  make(map[string]*DimensionCount),
+		tagKeys:           make(map[string]int),
+	}
+}
+
+// AddRecordBreakdown adds a fetched record to the per-dimension counts. It
+// is a no-op unless EnableBreakdown was called.
+func (ds *DiagnosticsSummary) AddRecordBreakdown(record *CostRecord) {
+	b := ds.breakdown
+	if b == nil {
+		return
+	}
+
+	netCost := valueOrZero(record.NetCost)
+	count := func(counts map[string]*DimensionCount, key string) {
+		c, ok := counts[key]
+		if !ok {
+			c = &DimensionCount{Key: key}
+			counts[key] = c
+		}
+		c.Records++
+		c.NetCost += netCost
+	}
+
+	if record.Diagnostics != nil && slices.Contains(record.Diagnostics.Warnings, "missing_resource_id") {
+		count(b.missingResourceID, record.Service)
+	}
+	if record.Diagnostics != nil && slices.Contains(record.Diagnostics.Warnings, "unmapped_service_category") {
+		count(b.unmappedServices, record.Provider+"/"+record.Service)
+	}
+	if netCost < 0 {
+		count(b.negativeCost, record.Provider)
+	}
+
+	b.coverage.Records++
+	b.coverage.NetCost += netCost
+	tagged := false
+	for key := range record.Labels {
+		if key == allocationLabel {
+			continue
+		}
+		tagged = true
+		b.tagKeys[key]++
+	}
+	if tagged {
+		b.coverage.TaggedRecords++
+		b.coverage.TaggedNetCost += netCost
+	}
+}
+
+// Report builds the detailed report, or returns nil unless EnableBreakdown
+// was called.
+func (ds *DiagnosticsSummary) Report(now time.Time) *DiagnosticsReport {
+	b := ds.breakdown
+	if b == nil {
+		return nil
+	}
+
+	coverage := b.coverage
+	coverage.RecordPercent = percentOf(float64(coverage.TaggedRecords), float64(coverage.Records))
+	coverage.CostPercent = percentOf(coverage.TaggedNetCost, coverage.NetCost)
+	coverage.Keys = make([]TagKeyCoverage, 0, len(b.tagKeys))
+	for key, records := range b.tagKeys {
+		coverage.Keys = append(coverage.Keys, TagKeyCoverage{
+			Key:     key,
+			Records: records,
+			Percent: percentOf(float64(records), float64(coverage.Records)),
+		})
+	}
+	slices.SortFunc(coverage.Keys, func(a, b TagKeyCoverage) int {
+		return cmp.Or(cmp.Compare(b.Records, a.Records), cmp.Compare(a.Key, b.Key))
+	})
+	if len(coverage.Keys) > diagnosticsReportTopN {
+		coverage.Keys = coverage.Keys[:diagnosticsReportTopN]
+	}
+
+	return &DiagnosticsReport{
+		GeneratedAt:                now.UTC(),
+		Summary:                    ds,
+		MissingResourceIDByService: topDimensions(b.missingResourceID),
+		NegativeCostByProvider:     topDimensions(b.negativeCost),
+		UnmappedServices:           topDimensions(b.unmappedServices),
+		TagCoverage:                coverage,
+	}
+}
+
+// topDimensions returns the diagnosticsReportTopN values with the most
+// records.
+func topDimensions(counts map[string]*DimensionCount) []DimensionCount {
+	top := make([]DimensionCount, 0, len(counts))
+	for _, key := range slices.Sorted(maps.Keys(counts)) {
+		top = append(top, *counts[key])
+	}
+	slices.SortStableFunc(top, func(a, b DimensionCount) int { return cmp.Compare(b.Records, a.Records) })
+	if len(top) > diagnosticsReportTopN {
+		top = top[:diagnosticsReportTopN]
+	}
+	return top
+}
+
+// percentOf returns part as a percentage of total, 0 when total is 0.
+func percentOf(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total * 100
+}
+
+// valueOrZero dereferences v, treating nil as 0.
+func valueOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// diagnosticsReportTemplate renders the HTML report.
+var diagnosticsReportTemplate = template.Must(template.New("diagnostics").Funcs(template.FuncMap{
+	"amount":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Vantage sync diagnostics</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Vantage sync diagnostics</h1>
+<p>Generated {{.GeneratedAt.Format "2006-01-02T15:04:05Z07:00"}}: {{.Summary.TotalRecords}} records, {{.Summary.RecordsWithIssues}} with issues.</p>
+<h2>Missing fields</h2>
+<table><tr><th>Field</th><th>Records</th></tr>
+{{range $field, $count := .Summary.MissingFields}}<tr><td>{{$field}}</td><td>{{$count}}</td></tr>
+{{end}}</table>
+<h2>Warnings</h2>
+<table><tr><th>Warning</th><th>Records</th></tr>
+{{range $warning, $count := .Summary.Warnings}}<tr><td>{{$warning}}</td><td>{{$count}}</td></tr>
+{{end}}</table>
+<h2>Services missing resource_id</h2>
+<table><tr><th>Service</th><th>Records</th><th>Net cost</th></tr>
+{{range .MissingResourceIDByService}}<tr><td>{{.Key}}</td><td>{{.Records}}</td><td>{{amount .NetCost}}</td></tr>
+{{end}}</table>
+<h2>Negative net cost by provider</h2>
+<table><tr><th>Provider</th><th>Records</th><th>Net cost</th></tr>
+{{range .NegativeCostByProvider}}<tr><td>{{.Key}}</td><td>{{.Records}}</td><td>{{amount .NetCost}}</td></tr>
+{{end}}</table>
+<h2>Services without a service category</h2>
+<table><tr><th>Provider/service</th><th>Records</th><th>Net cost</th></tr>
+{{range .UnmappedServices}}<tr><td>{{.Key}}</td><td>{{.Records}}</td><td>{{amount .NetCost}}</td></tr>
+{{end}}</table>
+<h2>Tag coverage</h2>
+<p>{{.TagCoverage.TaggedRecords}} of {{.TagCoverage.Records}} records tagged ({{percent .TagCoverage.RecordPercent}}), covering {{percent .TagCoverage.CostPercent}} of net cost.</p>
+<table><tr><th>Tag key</th><th>Records</th><th>Coverage</th></tr>
+{{range .TagCoverage.Keys}}<tr><td>{{.Key}}</td><td>{{.Records}}</td><td>{{percent .Percent}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteDiagnosticsReport renders report in format (see
+// SupportedDiagnosticsReportFormats).
+func WriteDiagnosticsReport(w io.Writer, report *DiagnosticsReport, format string) error {
+	switch format {
+	case DiagnosticsReportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case DiagnosticsReportHTML:
+		if err := diagnosticsReportTemplate.Execute(w, report); err != nil {
+			return fmt.Errorf("rendering diagnostics report: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid diagnostics report format: %s (valid: %s)",
+			format, strings.Join(SupportedDiagnosticsReportFormats(), ", "))
+	}
+}
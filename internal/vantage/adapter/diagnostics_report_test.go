@@ -0,0 +1,111 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func breakdownSummary() *DiagnosticsSummary {
+	cost := func(v float64) *float64 { return &v }
+	missing := &Diagnostics{Warnings: []string{"missing_resource_id"}}
+
+	summary := NewDiagnosticsSummary()
+	summary.EnableBreakdown()
+	for _, record := range []CostRecord{
+		{Provider: "aws", Service: "EC2", NetCost: cost(30), Diagnostics: missing, Labels: map[string]string{"team": "web"}},
+		{Provider: "aws", Service: "EC2", NetCost: cost(10), Diagnostics: missing},
+		{Provider: "aws", Service: "S3", NetCost: cost(40), Diagnostics: missing, Labels: map[string]string{"team": "data", "env": "prod"}},
+		{Provider: "aws", Service: "Credits", NetCost: cost(-5), Diagnostics: &Diagnostics{Warnings: []string{"unmapped_service_category"}}},
+		{Provider: "gcp", Service: "GCE", NetCost: cost(-1), Labels: map[string]string{allocationLabel: allocationUnallocated}},
+	} {
+		summary.AddRecordDiagnostics(record.Diagnostics)
+		summary.AddRecordBreakdown(&record)
+	}
+	return summary
+}
+
+func TestDiagnosticsSummary_Report(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.Nil(t, NewDiagnosticsSummary().Report(now), "no report unless breakdowns are enabled")
+
+	report := breakdownSummary().Report(now)
+	require.NotNil(t, report)
+	assert.Equal(t, now, report.GeneratedAt)
+	assert.Equal(t, 5, report.Summary.TotalRecords)
+
+	assert.Equal(t, []DimensionCount{
+		{Key: "EC2", Records: 2, NetCost: 40},
+		{Key: "S3", Records: 1, NetCost: 40},
+	}, report.MissingResourceIDByService)
+	assert.Equal(t, []DimensionCount{
+		{Key: "aws", Records: 1, NetCost: -5},
+		{Key: "gcp", Records: 1, NetCost: -1},
+	}, report.NegativeCostByProvider)
+	assert.Equal(t, []DimensionCount{{Key: "aws/Credits", Records: 1, NetCost: -5}}, report.UnmappedServices)
+
+	coverage := report.TagCoverage
+	assert.Equal(t, 2, coverage.TaggedRecords)
+	assert.InDelta(t, 40.0, coverage.RecordPercent, 1e-9)
+	assert.InDelta(t, 70.0/74.0*100, coverage.CostPercent, 1e-9)
+	assert.Equal(t, []TagKeyCoverage{
+		{Key: "team", Records: 2, Percent: 40},
+		{Key: "env", Records: 1, Percent: 20},
+	}, coverage.Keys)
+}
+
+func TestWriteDiagnosticsReport(t *testing.T) {
+	report := breakdownSummary().Report(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDiagnosticsReport(&buf, report, DiagnosticsReportJSON))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Contains(t, decoded, "missing_resource_id_by_service")
+	assert.Equal(t, 3.0, decoded["summary"].(map[string]interface{})["warnings"].(map[string]interface{})["missing_resource_id"])
+
+	buf.Reset()
+	require.NoError(t, WriteDiagnosticsReport(&buf, report, DiagnosticsReportHTML))
+	assert.Contains(t, buf.String(), "<td>EC2</td><td>2</td><td>40.00</td>")
+	assert.Contains(t, buf.String(), "2 of 5 records tagged (40.0%)")
+
+	require.ErrorContains(t, WriteDiagnosticsReport(&buf, report, "pdf"), "invalid diagnostics report format: pdf")
+}
+
+func TestAdapter_Sync_CollectsReportBreakdown(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.SetBookmarkStore(bookmark.NewMemory())
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+		Granularity:     "day",
+		PageSize:        100,
+		Diagnostics:     DiagnosticsConfig{ReportPath: "diagnostics.json", ReportFormat: DiagnosticsReportJSON},
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{{BucketStart: cfg.StartDate, Provider: "aws", Service: "EC2", Cost: -3}},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+
+	report := adapter.GetDiagnosticsSummary().Report(time.Now())
+	require.NotNil(t, report)
+	assert.Equal(t, []DimensionCount{{Key: "aws", Records: 1, NetCost: -3}}, report.NegativeCostByProvider)
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDiagnostics_AddMissingField(t *testing.T) {
@@ -329,3 +330,27 @@ func TestDiagnosticsSummary_LargeScaleAggregation(t *testing.T) {
 	assert.Equal(t, 20, summary.MissingFields["currency"])
 	assert.Equal(t, 15, summary.Warnings["negative_net_cost"])
 }
+
+func TestDiagnosticsSummary_UnallocatedShare(t *testing.T) {
+	summary := NewDiagnosticsSummary()
+	summary.AddSpend(30, false)
+	summary.AddSpend(10, true)
+	summary.TrackUnallocated()
+
+	require.NotNil(t, summary.UnallocatedShare)
+	assert.InDelta(t, 10.0, *summary.UnallocatedCost, 1e-9)
+	assert.InDelta(t, 0.25, *summary.UnallocatedShare, 1e-9)
+
+	// Fully tagged spend reports a zero share rather than none.
+	tagged := NewDiagnosticsSummary()
+	tagged.AddSpend(30, false)
+	tagged.TrackUnallocated()
+	require.NotNil(t, tagged.UnallocatedShare)
+	assert.Zero(t, *tagged.UnallocatedShare)
+
+	// Without tracking, nothing is reported.
+	untracked := NewDiagnosticsSummary()
+	untracked.AddSpend(30, false)
+	assert.Nil(t, untracked.UnallocatedCost)
+	assert.Nil(t, untracked.UnallocatedShare)
+}
@@ -0,0 +1,134 @@
+package adapter
+
+import (
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// envPrefix starts every environment variable read as configuration.
+const envPrefix = "PULUMICOST_VANTAGE_"
+
+// envAliases map short variable names, after envPrefix, to params keys.
+var envAliases = map[string]string{
+	"WS_TOKEN":          "workspace_token",
+	"WORKSPACE_TOKEN":   "workspace_token",
+	"CR_TOKEN":          "cost_report_token",
+	"COST_REPORT_TOKEN": "cost_report_token",
+	"CR_NAME":           "cost_report_name",
+	"COST_REPORT_NAME":  "cost_report_name",
+	"GRANULARITY":       "granularity",
+	"GROUP_BYS":         "group_bys",
+	"METRICS":           "metrics",
+	"TIMEOUT":           "request_timeout_seconds",
+	"PAGE_SIZE":         "page_size",
+	"MAX_RETRIES":       "max_retries",
+}
+
+// envListKeys are keys whose plain environment values are split on commas.
+var envListKeys = map[string]bool{
+	"group_bys":          true,
+	"metrics":            true,
+	"drop_dimensions":    true,
+	"tag_prefix_filters": true,
+	"allow":              true,
+	"deny":               true,
+	"scopes":             true,
+	"brokers":            true,
+}
+
+// applyEnv overlays configuration from the environment onto raw, so the
+// adapter can run with no config file at all. Besides the envAliases,
+// PULUMICOST_VANTAGE_<SECTION>_<KEY> sets one key of a section, such as
+// PULUMICOST_VANTAGE_SINK_PATH for sink.path or
+// PULUMICOST_VANTAGE_DEAD_LETTER_TYPE for dead_letter.type, and
+// PULUMICOST_VANTAGE_TRANSFORMS or PULUMICOST_VANTAGE_ALLOCATION_RULES
+// replaces a whole list. A value starting with [ or { is read as YAML flow
+// syntax; otherwise it is a string, split on commas for list keys.
+//
+// PULUMICOST_VANTAGE_TOKEN, START_DATE, END_DATE, and LOCK_DSN keep their
+// own handling in parseCredentials, parseDates, and parseLock.
+func applyEnv(raw *rawConfig, environ []string) {
+	sections := map[string]*map[string]interface{}{
+		"CREDENTIALS": &raw.Credentials,
+		"PARAMS":      &raw.Params,
+		"SINK":        &raw.Sink,
+		"BOOKMARKS":   &raw.Bookmarks,
+		"LOCK":        &raw.Lock,
+		"CACHE":       &raw.Cache,
+		"TAGS":        &raw.Tags,
+		"TRACING":     &raw.Tracing,
+		"ALERTS":      &raw.Alerts,
+		"DIAGNOSTICS": &raw.Diagnostics,
+		"SERVE":       &raw.Serve,
+		"MOCK":        &raw.Mock,
+		"DEAD_LETTER": &raw.DeadLetter,
+	}
+	lists := map[string]*[]map[string]interface{}{
+		"TRANSFORMS":       &raw.Transforms,
+		"ALLOCATION_RULES": &raw.Allocations,
+	}
+
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		name, ok := strings.CutPrefix(name, envPrefix)
+		if !ok || value == "" {
+			continue
+		}
+
+		if list, ok := lists[name]; ok {
+			if items, ok := envValue("", value).([]interface{}); ok {
+				*list = envList(items)
+			}
+			continue
+		}
+
+		section, key := &raw.Params, envAliases[name]
+		if key == "" {
+			// No section name is another's prefix followed by "_", so at
+			// most one matches.
+			for prefix, s := range sections {
+				if rest, found := strings.CutPrefix(name, prefix+"_"); found && rest != "" {
+					section, key = s, strings.ToLower(rest)
+				}
+			}
+			if key == "" {
+				continue
+			}
+		}
+		if *section == nil {
+			*section = make(map[string]interface{})
+		}
+		(*section)[key] = envValue(key, value)
+	}
+}
+
+// envValue decodes one environment value for key.
+func envValue(key, value string) interface{} {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		var decoded interface{}
+		if err := yaml.Unmarshal([]byte(trimmed), &decoded); err == nil {
+			return decoded
+		}
+	}
+	if envListKeys[key] {
+		parts := strings.Split(trimmed, ",")
+		for i, part := range parts {
+			parts[i] = strings.TrimSpace(part)
+		}
+		return parts
+	}
+	return value
+}
+
+// envList keeps the map items of a decoded list section.
+func envList(items []interface{}) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			list = append(list, m)
+		}
+	}
+	return list
+}
@@ -0,0 +1,70 @@
+package adapter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_EnvironmentOnly(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "env-token")
+	t.Setenv("PULUMICOST_VANTAGE_CR_TOKEN", "cr_env")
+	t.Setenv("PULUMICOST_VANTAGE_GRANULARITY", "month")
+	t.Setenv("PULUMICOST_VANTAGE_GROUP_BYS", "provider, service")
+	t.Setenv("PULUMICOST_VANTAGE_PAGE_SIZE", "250")
+	t.Setenv("PULUMICOST_VANTAGE_PARAMS_INCLUDE_BUDGETS", "true")
+	t.Setenv("PULUMICOST_VANTAGE_PARAMS_STATIC_LABELS", "{env: prod}")
+	t.Setenv("PULUMICOST_VANTAGE_PARAMS_COST_BASIS", "amortized")
+	t.Setenv("PULUMICOST_VANTAGE_PARAMS_PROVIDER_COST_BASIS", "{gcp: net}")
+	t.Setenv("PULUMICOST_VANTAGE_SINK_PATH", "/var/lib/vantage")
+	t.Setenv("PULUMICOST_VANTAGE_DEAD_LETTER_TYPE", "sqlite")
+	t.Setenv("PULUMICOST_VANTAGE_TRANSFORMS", "[{type: provider_filter, providers: [aws, gcp]}]")
+
+	cfg, err := LoadConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", cfg.Token)
+	assert.Equal(t, "cr_env", cfg.CostReportToken)
+	assert.Equal(t, "month", cfg.Granularity)
+	assert.Equal(t, []string{"provider", "service"}, cfg.GroupBys)
+	assert.Equal(t, 250, cfg.PageSize)
+	assert.True(t, cfg.IncludeBudgets)
+	assert.Equal(t, map[string]string{"env": "prod"}, cfg.StaticLabels)
+	assert.Equal(t, "amortized", cfg.CostBasis)
+	assert.Equal(t, map[string]string{"gcp": "net"}, cfg.ProviderCostBasis)
+	assert.Equal(t, "/var/lib/vantage", cfg.Sink.Path)
+	assert.Equal(t, DeadLetterConfig{
+		Type:  DeadLetterSQLite,
+		Path:  "/var/lib/vantage/dead_letter.db",
+		Table: defaultDeadLetterTable,
+	}, cfg.DeadLetter)
+	require.Len(t, cfg.Transforms, 1)
+	assert.Equal(t, []string{"aws", "gcp"}, cfg.Transforms[0].Providers)
+}
+
+func TestLoadProfileConfig_EnvironmentOverridesFile(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := writeConfigFiles(t, map[string]string{"config.yaml": `
+credentials:
+  token: file-token
+params:
+  cost_report_token: cr_file
+  granularity: day
+  page_size: 100
+profiles:
+  prod:
+    params:
+      cost_report_token: cr_prod
+      page_size: 500
+`})
+	t.Setenv("PULUMICOST_VANTAGE_PARAMS_PAGE_SIZE", "900")
+	t.Setenv("PULUMICOST_VANTAGE_VERBOSE", "true")
+
+	cfg, err := LoadProfileConfig(filepath.Join(dir, "config.yaml"), "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "file-token", cfg.Token)
+	assert.Equal(t, "cr_prod", cfg.CostReportToken)
+	assert.Equal(t, 900, cfg.PageSize, "environment overrides the profile")
+	assert.Equal(t, "day", cfg.Granularity)
+}
@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// renderRedacted replaces secret values in a rendered config.
+const renderRedacted = "****"
+
+// readRawConfig reads the config file merged over the files it extends. An
+// empty filePath reads no file, leaving the configuration to the
+// environment.
+func readRawConfig(filePath string) (*rawConfig, error) {
+	if filePath == "" {
+		return &rawConfig{}, nil
+	}
+	return readExtendedConfig(filePath, nil)
+}
+
+// readExtendedConfig reads filePath and merges it over the files listed in
+// its extends, in order, each resolved relative to filePath. chain holds the
+// files already being read, to report cycles.
+func readExtendedConfig(filePath string, chain []string) (*rawConfig, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("resolving config path %s: %w", filePath, err)
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("config extends cycle: %s", strings.Join(append(chain, abs), " -> "))
+	}
+	chain = append(chain, abs)
+
+	raw, err := readConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &rawConfig{}
+	for _, base := range raw.Extends {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(abs), base)
+		}
+		baseRaw, err := readExtendedConfig(base, chain)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeRawConfigs(merged, baseRaw)
+	}
+	return mergeRawConfigs(merged, raw), nil
+}
+
+// mergeRawConfigs returns base overlaid with override: sections as in
+// profiles, and profiles of the same name merged the same way.
+func mergeRawConfigs(base, override *rawConfig) *rawConfig {
+	merged := &rawConfig{rawSections: mergeSections(base.rawSections, override.rawSections)}
+	if base.Profiles == nil && override.Profiles == nil {
+		return merged
+	}
+
+	merged.Profiles = maps.Clone(base.Profiles)
+	if merged.Profiles == nil {
+		merged.Profiles = make(map[string]rawProfile, len(override.Profiles))
+	}
+	for name, p := range override.Profiles {
+		if baseProfile, ok := merged.Profiles[name]; ok {
+			if p.Extends == "" {
+				p.Extends = baseProfile.Extends
+			}
+			p.rawSections = mergeSections(baseProfile.rawSections, p.rawSections)
+		}
+		merged.Profiles[name] = p
+	}
+	return merged
+}
+
+// readConfigFile reads one config file, without following extends.
+func readConfigFile(filePath string) (*rawConfig, error) {
+	// Check if file exists.
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, fmt.Errorf("config file not found: %s", filePath)
+	}
+
+	// Parse YAML file.
+	v := viper.New()
+	v.SetConfigFile(filePath)
+	v.SetConfigType("yaml")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Unmarshal into intermediate struct.
+	var raw rawConfig
+	if err := v.Unmarshal(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+	}
+
+	return &raw, nil
+}
+
+// EffectiveConfig returns the config sections in effect for opts after
+// merging extended files, the profile, and the overrides, as a map ready to
+// print. Tokens, client secrets, DSNs, headers, and webhook URLs
+// are masked. PULUMICOST_VANTAGE_* section variables are applied, but not
+// the overrides handled while parsing, such as PULUMICOST_VANTAGE_TOKEN.
+func EffectiveConfig(opts LoadOptions) (map[string]interface{}, error) {
+	raw, err := readProfile(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]interface{}{
+		"credentials":      raw.Credentials,
+		"params":           raw.Params,
+		"sink":             raw.Sink,
+		"bookmarks":        raw.Bookmarks,
+		"lock":             raw.Lock,
+		"cache":            raw.Cache,
+		"tags":             raw.Tags,
+		"tracing":          raw.Tracing,
+		"transforms":       raw.Transforms,
+		"allocation_rules": raw.Allocations,
+		"alerts":           raw.Alerts,
+		"diagnostics":      raw.Diagnostics,
+		"serve":            raw.Serve,
+		"mock":             raw.Mock,
+		"dead_letter":      raw.DeadLetter,
+	}
+	effective := make(map[string]interface{}, len(sections))
+	for name, section := range sections {
+		if value := redactRendered(section); value != nil {
+			effective[name] = value
+		}
+	}
+	return effective, nil
+}
+
+// redactRendered copies v, masking the values of secret keys. Empty
+// sections become nil.
+func redactRendered(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			switch strings.ToLower(key) {
+			case "token", "client_secret", "dsn", "headers", "url":
+				out[key] = renderRedacted
+			default:
+				out[key] = redactRendered(value)
+			}
+		}
+		return out
+	case []map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactRendered(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactRendered(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
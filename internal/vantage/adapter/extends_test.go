@@ -0,0 +1,151 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFiles writes files, keyed by path relative to a temp dir, and
+// returns the dir.
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestLoadConfig_Extends(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := writeConfigFiles(t, map[string]string{
+		"base/common.yaml": `
+credentials:
+  token: base-token
+params:
+  granularity: month
+  group_bys: [provider, service]
+  page_size: 500
+transforms:
+  - type: provider_filter
+    providers: [aws]
+profiles:
+  prod:
+    params:
+      cost_report_token: cr_prod
+`,
+		"envs/staging.yaml": `
+extends: ../base/common.yaml
+params:
+  cost_report_token: cr_staging
+  page_size: 100
+profiles:
+  prod:
+    sink:
+      path: ./data/prod
+`,
+	})
+	configPath := filepath.Join(dir, "envs", "staging.yaml")
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "base-token", cfg.Token)
+	assert.Equal(t, "cr_staging", cfg.CostReportToken)
+	assert.Equal(t, "month", cfg.Granularity)
+	assert.Equal(t, []string{"provider", "service"}, cfg.GroupBys)
+	assert.Equal(t, 100, cfg.PageSize)
+	assert.Len(t, cfg.Transforms, 1)
+
+	cfg, err = LoadProfileConfig(configPath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "cr_prod", cfg.CostReportToken, "profiles merge across files")
+	assert.Equal(t, "./data/prod", cfg.Sink.Path)
+}
+
+func TestLoadConfig_ExtendsCycle(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yaml": "extends: [b.yaml]\n",
+		"b.yaml": "extends: [a.yaml]\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+	require.ErrorContains(t, err, "config extends cycle")
+
+	_, err = LoadConfig(writeConfigFiles(t, map[string]string{"c.yaml": "extends: missing.yaml\n"}) + "/c.yaml")
+	require.ErrorContains(t, err, "config file not found")
+}
+
+func TestLoadProfileConfig_ProfileExtends(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := writeConfigFiles(t, map[string]string{"config.yaml": `
+credentials:
+  token: base-token
+params:
+  granularity: day
+profiles:
+  shared:
+    params:
+      cost_report_token: cr_shared
+      page_size: 250
+  prod:
+    extends: shared
+    params:
+      page_size: 1000
+  loop-a:
+    extends: loop-b
+  loop-b:
+    extends: loop-a
+  orphan:
+    extends: nowhere
+`})
+	configPath := filepath.Join(dir, "config.yaml")
+
+	cfg, err := LoadProfileConfig(configPath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "cr_shared", cfg.CostReportToken)
+	assert.Equal(t, 1000, cfg.PageSize)
+
+	_, err = LoadProfileConfig(configPath, "loop-a")
+	require.ErrorContains(t, err, "profile extends cycle: loop-a -> loop-b -> loop-a")
+
+	_, err = LoadProfileConfig(configPath, "orphan")
+	require.ErrorContains(t, err, `profile "orphan" extends unknown profile "nowhere"`)
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yaml": `
+credentials:
+  token: secret-token
+params:
+  granularity: day
+alerts:
+  webhooks:
+    - name: ops
+      url: https://hooks.slack.com/services/secret
+`,
+		"config.yaml": `
+extends: base.yaml
+params:
+  cost_report_token: cr_test
+`,
+	})
+
+	effective, err := EffectiveConfig(LoadOptions{Path: filepath.Join(dir, "config.yaml")})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"token": "****"}, effective["credentials"])
+	assert.Equal(t, map[string]interface{}{"granularity": "day", "cost_report_token": "cr_test"}, effective["params"])
+	assert.NotContains(t, effective, "sink")
+
+	alerts, ok := effective["alerts"].(map[string]interface{})
+	require.True(t, ok)
+	webhooks, ok := alerts["webhooks"].([]interface{})
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"name": "ops", "url": "****"}, webhooks[0])
+}
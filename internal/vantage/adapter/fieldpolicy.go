@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// defaultExpectedFields are the record fields whose omission diagnostics
+// flag unless the diagnostics section marks them optional. The provider is
+// always expected, since field policies are keyed by it.
+var defaultExpectedFields = []string{"service", "account_id", "region", "currency", "net_cost", "resource_id"}
+
+// defaultUnitPriceTolerance is the relative difference between
+// effective_unit_price × usage_amount and net_cost that diagnostics accept
+// when diagnostics.unit_price_tolerance is unset.
+const defaultUnitPriceTolerance = 0.01
+
+// unitPriceSlack is the absolute difference always accepted, so rounding of
+// small costs is not flagged.
+const unitPriceSlack = 0.01
+
+// SupportedDiagnosticFields returns the fields a diagnostics field policy
+// can mark required or optional.
+func SupportedDiagnosticFields() []string {
+	return []string{"service", "account_id", "project", "region", "resource_id", "currency", "net_cost"}
+}
+
+// validateDiagnosticsConfig checks that field policies name supported
+// fields and do not list a field as both required and optional, and that
+// the report format is known.
+func validateDiagnosticsConfig(diagnostics DiagnosticsConfig) error {
+	if diagnostics.ReportFormat != "" && !slices.Contains(SupportedDiagnosticsReportFormats(), diagnostics.ReportFormat) {
+		return fmt.Errorf("invalid diagnostics.report_format: %s (valid: %s)",
+			diagnostics.ReportFormat, strings.Join(SupportedDiagnosticsReportFormats(), ", "))
+	}
+	if diagnostics.UnitPriceTolerance < 0 || diagnostics.UnitPriceTolerance >= 1 {
+		return fmt.Errorf("invalid diagnostics.unit_price_tolerance: %g (valid: 0 to less than 1)",
+			diagnostics.UnitPriceTolerance)
+	}
+	if err := validateFieldPolicy("diagnostics", diagnostics.FieldPolicy); err != nil {
+		return err
+	}
+	for provider, policy := range diagnostics.Providers {
+		if err := validateFieldPolicy("diagnostics.providers."+provider, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFieldPolicy checks one field policy; section names it in errors.
+func validateFieldPolicy(section string, policy FieldPolicy) error {
+	for _, field := range slices.Concat(policy.Required, policy.Optional) {
+		if !slices.Contains(SupportedDiagnosticFields(), field) {
+			return fmt.Errorf("invalid %s field: %s (valid: %s)",
+				section, field, strings.Join(SupportedDiagnosticFields(), ", "))
+		}
+	}
+	for _, field := range policy.Required {
+		if slices.Contains(policy.Optional, field) {
+			return fmt.Errorf("%s lists %s as both required and optional", section, field)
+		}
+	}
+	return nil
+}
+
+// fieldPolicy resolves which fields are expected for each provider, and
+// how far a record's unit price may disagree with its cost.
+type fieldPolicy struct {
+	expected           map[string]bool
+	providers          map[string]map[string]bool
+	unitPriceTolerance float64
+}
+
+// newFieldPolicy applies the diagnostics section over
+// defaultExpectedFields: the top-level lists first, then each provider's.
+func newFieldPolicy(cfg DiagnosticsConfig) *fieldPolicy {
+	apply := func(base map[string]bool, policy FieldPolicy) map[string]bool {
+		expected := make(map[string]bool, len(base)+len(policy.Required))
+		for field, ok := range base {
+			expected[field] = ok
+		}
+		for _, field := range policy.Required {
+			expected[field] = true
+		}
+		for _, field := range policy.Optional {
+			expected[field] = false
+		}
+		return expected
+	}
+
+	defaults := make(map[string]bool, len(defaultExpectedFields))
+	for _, field := range defaultExpectedFields {
+		defaults[field] = true
+	}
+
+	policy := &fieldPolicy{
+		expected:           apply(defaults, cfg.FieldPolicy),
+		providers:          make(map[string]map[string]bool, len(cfg.Providers)),
+		unitPriceTolerance: cfg.UnitPriceTolerance,
+	}
+	for provider, providerPolicy := range cfg.Providers {
+		policy.providers[provider] = apply(policy.expected, providerPolicy)
+	}
+	return policy
+}
+
+// expects reports whether field's omission is flagged for provider. A nil
+// policy expects defaultExpectedFields.
+func (p *fieldPolicy) expects(provider, field string) bool {
+	if p == nil {
+		return slices.Contains(defaultExpectedFields, field)
+	}
+	if expected, ok := p.providers[strings.ToLower(provider)]; ok {
+		return expected[field]
+	}
+	return p.expected[field]
+}
+
+// priceTolerance returns the accepted relative difference between unit
+// price × quantity and cost. A nil policy, or an unset tolerance, uses
+// defaultUnitPriceTolerance.
+func (p *fieldPolicy) priceTolerance() float64 {
+	if p == nil || p.unitPriceTolerance == 0 {
+		return defaultUnitPriceTolerance
+	}
+	return p.unitPriceTolerance
+}
@@ -0,0 +1,138 @@
+package adapter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestFieldPolicy_Expects(t *testing.T) {
+	policy := newFieldPolicy(DiagnosticsConfig{
+		FieldPolicy: FieldPolicy{Optional: []string{"resource_id"}},
+		Providers: map[string]FieldPolicy{
+			"datadog":    {Optional: []string{"account_id", "region"}},
+			"kubernetes": {Required: []string{"project", "resource_id"}},
+		},
+	})
+
+	assert.True(t, policy.expects("aws", "region"))
+	assert.False(t, policy.expects("aws", "resource_id"))
+	assert.False(t, policy.expects("aws", "project"))
+	assert.False(t, policy.expects("Datadog", "region"))
+	assert.True(t, policy.expects("datadog", "currency"))
+	assert.True(t, policy.expects("kubernetes", "project"))
+	assert.True(t, policy.expects("kubernetes", "resource_id"))
+
+	var defaults *fieldPolicy
+	assert.True(t, defaults.expects("aws", "region"))
+	assert.False(t, defaults.expects("aws", "project"))
+}
+
+func TestAdapter_addDiagnostics_FieldPolicy(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	adapter.fields = newFieldPolicy(DiagnosticsConfig{
+		Providers: map[string]FieldPolicy{
+			"datadog": {Optional: []string{"account_id", "region", "resource_id"}},
+		},
+	})
+
+	row := client.CostRow{
+		BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Provider:    "datadog",
+		Service:     "APM",
+		Currency:    "USD",
+		Cost:        12,
+	}
+	query := client.Query{CostReportToken: "cr_test"}
+
+	record := adapter.mapVantageRowToCostRecord(row, query, "test-hash", "cost")
+	assert.Nil(t, record.Diagnostics, "omissions datadog legitimately has are not flagged")
+
+	row.Provider = "aws"
+	record = adapter.mapVantageRowToCostRecord(row, query, "test-hash", "cost")
+	require.NotNil(t, record.Diagnostics)
+	assert.Contains(t, record.Diagnostics.MissingFields, "account_id")
+	assert.Contains(t, record.Diagnostics.MissingFields, "region")
+	assert.Contains(t, record.Diagnostics.Warnings, "missing_resource_id")
+}
+
+func TestValidateDiagnosticsConfig(t *testing.T) {
+	require.NoError(t, validateDiagnosticsConfig(DiagnosticsConfig{
+		FieldPolicy: FieldPolicy{Required: []string{"project"}},
+	}))
+
+	err := validateDiagnosticsConfig(DiagnosticsConfig{
+		Providers: map[string]FieldPolicy{"gcp": {Optional: []string{"zone"}}},
+	})
+	require.ErrorContains(t, err, "invalid diagnostics.providers.gcp field: zone (valid: ")
+
+	err = validateDiagnosticsConfig(DiagnosticsConfig{
+		FieldPolicy: FieldPolicy{Required: []string{"region"}, Optional: []string{"region"}},
+	})
+	require.ErrorContains(t, err, "diagnostics lists region as both required and optional")
+
+	err = validateDiagnosticsConfig(DiagnosticsConfig{UnitPriceTolerance: 1})
+	require.EqualError(t, err, "invalid diagnostics.unit_price_tolerance: 1 (valid: 0 to less than 1)")
+}
+
+func TestAdapter_addDiagnostics_UnitPrice(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day"}
+	row := func(price, quantity, cost float64) client.CostRow {
+		return client.CostRow{
+			Provider: "aws", Service: "EC2", Currency: "USD", UsageUnit: "Hrs",
+			EffectiveUnitPrice: price, UsageQuantity: quantity, Cost: cost,
+		}
+	}
+
+	record := adapter.mapVantageRowToCostRecord(row(0.1, 24, 2.4), query, "hash", "cost")
+	require.NotNil(t, record.EffectiveUnitPrice)
+	assert.InDelta(t, 0.1, *record.EffectiveUnitPrice, 1e-9)
+	assert.Equal(t, "Hrs", record.PricingUnit)
+	assert.NotContains(t, record.Diagnostics.Warnings, "unit_price_cost_mismatch")
+
+	record = adapter.mapVantageRowToCostRecord(row(0.1, 24, 3), query, "hash", "cost")
+	assert.Contains(t, record.Diagnostics.Warnings, "unit_price_cost_mismatch")
+
+	// Small absolute differences are rounding, whatever the ratio.
+	record = adapter.mapVantageRowToCostRecord(row(0.001, 1, 0.005), query, "hash", "cost")
+	assert.NotContains(t, record.Diagnostics.Warnings, "unit_price_cost_mismatch")
+
+	adapter.fields = newFieldPolicy(DiagnosticsConfig{UnitPriceTolerance: 0.5})
+	record = adapter.mapVantageRowToCostRecord(row(0.1, 24, 3), query, "hash", "cost")
+	assert.NotContains(t, record.Diagnostics.Warnings, "unit_price_cost_mismatch")
+
+	record = adapter.mapVantageRowToCostRecord(row(0, 24, 3), query, "hash", "cost")
+	assert.Nil(t, record.EffectiveUnitPrice)
+	assert.Empty(t, record.PricingUnit)
+}
+
+func TestAdapter_mapVantageRowToCostRecord_UnknownFields(t *testing.T) {
+	var logs bytes.Buffer
+	adapter := New(&mockClient{}, client.NewConsoleLogger(&logs, client.LevelInfo))
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day"}
+	row := client.CostRow{
+		Provider: "aws", Service: "EC2", Account: "123", Region: "us-east-1", Currency: "USD",
+		ResourceID: "i-0abc", Cost: 2,
+		Extra: map[string]interface{}{"charge_type": "Usage"},
+	}
+
+	record := adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	require.NotNil(t, record.Diagnostics, "unknown fields keep diagnostics without issues")
+	assert.False(t, record.Diagnostics.HasIssues())
+	assert.Equal(t, map[string]interface{}{"charge_type": "Usage"}, record.Diagnostics.SourceInfo["unknown_fields"])
+
+	// Each field name is logged once per sync.
+	adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Equal(t, 1, strings.Count(logs.String(), "fields=charge_type"))
+
+	row.Extra = nil
+	record = adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Nil(t, record.Diagnostics)
+}
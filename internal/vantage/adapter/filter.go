@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// validateFilter checks a VQL filter expression for balanced syntax before it
+// is sent to the API: quotes must close and parentheses must pair up outside
+// quoted strings. It does not check field names or operators, which the API
+// validates. An empty filter is valid.
+func validateFilter(filter string) error {
+	if filter == "" {
+		return nil
+	}
+
+	depth := 0
+	var quote rune
+	quoteStart := 0
+	escaped := false
+	for i, r := range filter {
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '\'', '"':
+			quote = r
+			quoteStart = i
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unmatched ')' at position %d", i+1)
+			}
+		}
+	}
+
+	if quote != 0 {
+		return fmt.Errorf("unterminated %c string starting at position %d", quote, quoteStart+1)
+	}
+	if depth > 0 {
+		return fmt.Errorf("%d unclosed '('", depth)
+	}
+	if strings.Trim(filter, "() \t\n") == "" {
+		return errors.New("filter has no conditions")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		wantErr string
+	}{
+		{name: "empty", filter: ""},
+		{name: "simple", filter: `costs.provider = 'aws'`},
+		{
+			name:   "nested groups",
+			filter: `(costs.provider = 'aws' AND (costs.service = 'EC2' OR costs.service = 'S3'))`,
+		},
+		{name: "parentheses inside quotes", filter: `tags.name = 'team (platform'`},
+		{name: "escaped quote", filter: `tags.value = 'it\'s'`},
+		{name: "double quotes", filter: `costs.region = "us-east-1"`},
+		{name: "unclosed group", filter: `(costs.provider = 'aws'`, wantErr: "1 unclosed '('"},
+		{name: "unmatched close", filter: `costs.provider = 'aws')`, wantErr: "unmatched ')' at position 23"},
+		{name: "close before open", filter: `)(`, wantErr: "unmatched ')' at position 1"},
+		{
+			name:    "unterminated string",
+			filter:  `costs.provider = 'aws`,
+			wantErr: "unterminated ' string starting at position 18",
+		},
+		{name: "only parentheses", filter: `( () )`, wantErr: "filter has no conditions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFilter(tt.filter)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
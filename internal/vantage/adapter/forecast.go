@@ -0,0 +1,158 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// SyncForecast writes a forecast snapshot of cfg.StartDate to cfg.EndDate
+// into sink, without syncing costs. Workspace-only configs forecast the
+// report resolveForecastReport picks, and fail when there is none.
+func (a *Adapter) SyncForecast(ctx context.Context, cfg Config, sink Sink) (err error) {
+	if cfg.EndDate == nil {
+		return errors.New("forecast requires an end date")
+	}
+	ctx = a.startRun(ctx, cfg)
+
+	ctx, span := tracer().Start(ctx, "vantage.forecast", trace.WithAttributes(
+		attribute.String("vantage.correlation_id", a.correlationID),
+		attribute.String("vantage.profile", cfg.Profile),
+		attribute.String("vantage.granularity", cfg.Granularity),
+	))
+	defer func() { finishSpan(span, err) }()
+
+	a.logger.Info(ctx, "Starting Vantage forecast sync", map[string]interface{}{
+		"adapter":    "vantage",
+		"operation":  "forecast_sync",
+		"attempt":    0,
+		"start_date": cfg.StartDate.Format("2006-01-02"),
+		"end_date":   cfg.EndDate.Format("2006-01-02"),
+	})
+
+	if err := a.resolveCostReport(ctx, &cfg); err != nil {
+		return err
+	}
+
+	ctx, release, err := a.acquireSyncLock(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := a.applySettings(cfg); err != nil {
+		return err
+	}
+	if err := a.recoverJournal(ctx, cfg, sink); err != nil {
+		return err
+	}
+	defer func() { a.journalKey = "" }()
+
+	transforms, err := configuredTransforms(cfg)
+	if err != nil {
+		return err
+	}
+	a.transforms = transforms
+
+	cfg.IncludeForecast = true
+	a.resolveForecastReport(ctx, cfg)
+	if cfg.CostReportToken == "" {
+		if a.forecastReportToken == "" {
+			reason, _ := a.diagnosticsSummary.SourceInfo["forecast_unavailable_reason"].(string)
+			err = fmt.Errorf("forecast unavailable for workspace: %s", reason)
+			a.logDiagnosticsSummary(ctx, err)
+			return err
+		}
+		cfg.CostReportToken = a.forecastReportToken
+	}
+
+	queryHash := a.generateQueryHash(client.Query{
+		WorkspaceToken:  cfg.WorkspaceToken,
+		CostReportToken: cfg.CostReportToken,
+		StartAt:         cfg.StartDate,
+		EndAt:           *cfg.EndDate,
+		Granularity:     cfg.Granularity,
+		GroupBys:        forecastGroupBys(cfg.GroupBys),
+	})
+	err = a.syncForecast(ctx, cfg, sink, cfg.StartDate, *cfg.EndDate, queryHash)
+	if err == nil {
+		a.resetJournal(ctx)
+	}
+	if err != nil && errors.Is(context.Cause(ctx), ErrSyncLockLost) {
+		err = fmt.Errorf("%w: %w", ErrSyncLockLost, err)
+	}
+
+	a.logDiagnosticsSummary(ctx, err)
+	return err
+}
+
+// resolveForecastReport picks the cost report whose forecast stands in for
+// a workspace-only query: the workspace's oldest report without filters,
+// which Vantage creates with the workspace and which covers all of its
+// spend. When none can be used, forecasts are skipped for the run with a
+// forecast_unavailable warning and the reason in the diagnostics.
+func (a *Adapter) resolveForecastReport(ctx context.Context, cfg Config) {
+	a.forecastReportToken = ""
+	if !cfg.IncludeForecast || cfg.CostReportToken != "" {
+		return
+	}
+
+	token, err := a.defaultWorkspaceReport(ctx, cfg)
+	if err != nil {
+		a.diagnosticsSummary.Warnings["forecast_unavailable"]++
+		a.diagnosticsSummary.SourceInfo["forecast_unavailable_reason"] = err.Error()
+		a.logger.Warn(ctx, "Forecast unavailable for workspace; set cost_report_token to forecast a report", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "resolve_forecast_report",
+			"attempt":   0,
+			"error":     err,
+		})
+		return
+	}
+
+	a.forecastReportToken = token
+	a.diagnosticsSummary.SourceInfo["forecast_report_token"] = token
+	a.logger.Info(ctx, "Forecasting workspace through its default cost report", map[string]interface{}{
+		"adapter":      "vantage",
+		"operation":    "resolve_forecast_report",
+		"attempt":      0,
+		"report_token": token,
+	})
+}
+
+// defaultWorkspaceReport returns the token of the workspace's oldest
+// unfiltered cost report. A query filter would make that report's forecast
+// cover spend the sync does not, so filtered queries have no default.
+func (a *Adapter) defaultWorkspaceReport(ctx context.Context, cfg Config) (string, error) {
+	if cfg.Filter != "" {
+		return "", errors.New("workspace query has a filter the workspace's default report would not apply")
+	}
+
+	reports, err := a.client.ListCostReports(ctx, cfg.WorkspaceToken)
+	if err != nil {
+		return "", fmt.Errorf("listing workspace cost reports: %w", err)
+	}
+
+	var found *client.CostReport
+	for i := range reports {
+		report := &reports[i]
+		if report.Filter != "" || len(report.SavedFilterTokens) > 0 {
+			continue
+		}
+		if report.WorkspaceToken != "" && report.WorkspaceToken != cfg.WorkspaceToken {
+			continue
+		}
+		if found == nil || report.CreatedAt.Before(found.CreatedAt) {
+			found = report
+		}
+	}
+	if found == nil {
+		return "", fmt.Errorf("workspace %s has no unfiltered cost report to forecast", cfg.WorkspaceToken)
+	}
+	return found.Token, nil
+}
@@ -0,0 +1,225 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func workspaceForecastConfig() Config {
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	return Config{
+		WorkspaceToken:  "wrkspc_test",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+		PageSize:        100,
+		IncludeForecast: true,
+	}
+}
+
+func TestAdapter_Sync_ForecastsWorkspaceDefaultReport(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	mockClient.On("ListCostReports", mock.Anything, "wrkspc_test").Return([]client.CostReport{
+		{Token: "cr_filtered", Filter: "costs.provider = 'aws'", CreatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Token: "cr_newer", CreatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Token: "cr_all", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil).Once()
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+	mockClient.On("Forecast", mock.Anything, "cr_all", mock.AnythingOfType("client.ForecastQuery")).
+		Return(client.Forecast{Data: []client.ForecastRow{
+			{BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Cost: 42, Currency: "USD"},
+		}}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), workspaceForecastConfig(), mockSink))
+
+	mockClient.AssertExpectations(t)
+	require.Len(t, mockSink.records, 1)
+	assert.Equal(t, "cr_all", mockSink.records[0].SourceReportToken)
+	assert.Equal(t, "cr_all", adapter.GetDiagnosticsSummary().SourceInfo["forecast_report_token"])
+	assert.Equal(t, 1, adapter.GetSyncStats().ForecastRecords)
+}
+
+func TestAdapter_Sync_ForecastUnavailableForWorkspace(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		reports []client.CostReport
+		err     error
+		reason  string
+	}{
+		{
+			name:    "no unfiltered report",
+			reports: []client.CostReport{{Token: "cr_filtered", SavedFilterTokens: []string{"svd_fltr_1"}}},
+			reason:  "workspace wrkspc_test has no unfiltered cost report to forecast",
+		},
+		{
+			name:   "listing fails",
+			err:    errors.New("forbidden"),
+			reason: "listing workspace cost reports: forbidden",
+		},
+		{
+			name:   "query filter",
+			filter: "costs.provider = 'aws'",
+			reason: "workspace query has a filter the workspace's default report would not apply",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockClient{}
+			mockSink := &mockSink{}
+			adapter := New(mockClient, client.NewNoopLogger())
+
+			if tt.filter == "" {
+				mockClient.On("ListCostReports", mock.Anything, "wrkspc_test").Return(tt.reports, tt.err)
+			}
+			mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+			mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+			mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+			mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			cfg := workspaceForecastConfig()
+			cfg.Filter = tt.filter
+			require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+
+			mockClient.AssertNotCalled(t, "Forecast", mock.Anything, mock.Anything, mock.Anything)
+			diagnostics := adapter.GetDiagnosticsSummary()
+			assert.Equal(t, 1, diagnostics.Warnings["forecast_unavailable"])
+			assert.Equal(t, tt.reason, diagnostics.SourceInfo["forecast_unavailable_reason"])
+		})
+	}
+}
+
+func TestAdapter_SyncForecast_Grouped(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		GroupBys:        []string{"provider", "service", "region"},
+	}
+	bucket := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mockClient.On("Forecast", mock.Anything, "cr_test", mock.MatchedBy(func(query client.ForecastQuery) bool {
+		return assert.ObjectsAreEqual([]string{"provider", "service"}, query.GroupBys)
+	})).Return(client.Forecast{Data: []client.ForecastRow{
+		{BucketStart: bucket, Cost: 100, Currency: "USD", Provider: "aws", Service: "Amazon EC2"},
+		{BucketStart: bucket, Cost: 25, Currency: "USD", Provider: "aws", Service: "Amazon S3"},
+	}}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.syncForecast(context.Background(), cfg, mockSink, bucket, bucket.AddDate(0, 0, 1), "query_hash"))
+
+	mockClient.AssertExpectations(t)
+	require.Len(t, mockSink.records, 2)
+	assert.Equal(t, "aws", mockSink.records[0].Provider)
+	assert.Equal(t, "Amazon EC2", mockSink.records[0].Service)
+	assert.Equal(t, "Amazon S3", mockSink.records[1].Service)
+	assert.NotEqual(t, mockSink.records[0].LineItemID, mockSink.records[1].LineItemID)
+	assert.Empty(t, adapter.GetDiagnosticsSummary().MissingFields["service"])
+}
+
+func TestAdapter_SyncForecast_WritesSnapshot(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := workspaceForecastConfig()
+	cfg.CostReportToken = "cr_test"
+	cfg.IncludeForecast = false
+
+	mockClient.On("Forecast", mock.Anything, "cr_test", mock.MatchedBy(func(query client.ForecastQuery) bool {
+		return query.StartAt.Equal(cfg.StartDate) && query.EndAt.Equal(*cfg.EndDate)
+	})).Return(client.Forecast{Data: []client.ForecastRow{
+		{BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Cost: 42, Currency: "USD"},
+		{BucketStart: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Cost: 43, Currency: "USD"},
+	}}, nil).Once()
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.SyncForecast(context.Background(), cfg, mockSink))
+
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "Costs", mock.Anything, mock.Anything)
+	require.Len(t, mockSink.records, 2)
+	for _, record := range mockSink.records {
+		assert.Equal(t, "forecast", record.MetricType)
+		assert.NotEmpty(t, record.ForecastSnapshotDate)
+	}
+	stats := adapter.GetSyncStats()
+	assert.Equal(t, 2, stats.ForecastRecords)
+	assert.Equal(t, 2, stats.RecordsWritten)
+	assert.Equal(t, 2, adapter.GetDiagnosticsSummary().TotalRecords)
+}
+
+func TestAdapter_SyncForecast_WorkspaceDefaultReport(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	mockClient.On("ListCostReports", mock.Anything, "wrkspc_test").Return([]client.CostReport{
+		{Token: "cr_all", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil).Once()
+	mockClient.On("Forecast", mock.Anything, "cr_all", mock.AnythingOfType("client.ForecastQuery")).
+		Return(client.Forecast{Data: []client.ForecastRow{
+			{BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Cost: 42, Currency: "USD"},
+		}}, nil).Once()
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.SyncForecast(context.Background(), workspaceForecastConfig(), mockSink))
+
+	mockClient.AssertExpectations(t)
+	require.Len(t, mockSink.records, 1)
+	assert.Equal(t, "cr_all", mockSink.records[0].SourceReportToken)
+}
+
+func TestAdapter_SyncForecast_Errors(t *testing.T) {
+	t.Run("no end date", func(t *testing.T) {
+		adapter := New(&mockClient{}, client.NewNoopLogger())
+		cfg := workspaceForecastConfig()
+		cfg.EndDate = nil
+
+		err := adapter.SyncForecast(context.Background(), cfg, &mockSink{})
+		require.EqualError(t, err, "forecast requires an end date")
+	})
+
+	t.Run("workspace without a report to forecast", func(t *testing.T) {
+		mockClient := &mockClient{}
+		mockSink := &mockSink{}
+		adapter := New(mockClient, client.NewNoopLogger())
+		mockClient.On("ListCostReports", mock.Anything, "wrkspc_test").Return([]client.CostReport{}, nil)
+
+		err := adapter.SyncForecast(context.Background(), workspaceForecastConfig(), mockSink)
+		require.EqualError(t, err,
+			"forecast unavailable for workspace: workspace wrkspc_test has no unfiltered cost report to forecast")
+		mockClient.AssertNotCalled(t, "Forecast", mock.Anything, mock.Anything, mock.Anything)
+		assert.Empty(t, mockSink.records)
+	})
+
+	t.Run("forecast request fails", func(t *testing.T) {
+		mockClient := &mockClient{}
+		adapter := New(mockClient, client.NewNoopLogger())
+		cfg := workspaceForecastConfig()
+		cfg.CostReportToken = "cr_test"
+		mockClient.On("Forecast", mock.Anything, "cr_test", mock.AnythingOfType("client.ForecastQuery")).
+			Return(client.Forecast{}, errors.New("boom"))
+
+		err := adapter.SyncForecast(context.Background(), cfg, &mockSink{})
+		require.EqualError(t, err, "fetching forecast: boom")
+	})
+}
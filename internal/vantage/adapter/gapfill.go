@@ -0,0 +1,174 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// syntheticZeroFlag is the diagnostics source_info key marking a zero record
+// written for a bucket without data.
+const syntheticZeroFlag = "synthetic_zero"
+
+// gapFiller remembers, for each dimension combination seen in one fetched
+// range, which buckets had data, so every combination can be written for
+// every bucket of the range. Memory grows with the number of combinations
+// and buckets, not with the number of rows.
+type gapFiller struct {
+	granularity string
+	start, end  time.Time
+
+	combos  map[string]*gapCombo
+	order   []string
+	written int
+}
+
+// gapCombo is one dimension combination: a record to copy zeros from, and
+// the buckets that had data.
+type gapCombo struct {
+	template CostRecord
+	buckets  map[time.Time]struct{}
+}
+
+// newGapFiller returns a filler for the buckets of granularity ("day" or
+// "month") lying wholly within [start, end).
+func newGapFiller(granularity string, start, end time.Time) *gapFiller {
+	return &gapFiller{
+		granularity: granularity,
+		start:       start.UTC(),
+		end:         end.UTC(),
+		combos:      make(map[string]*gapCombo),
+	}
+}
+
+// add records that record's combination had data in its bucket. Only cost
+// records are tracked.
+func (g *gapFiller) add(record *CostRecord) {
+	if record.MetricType != "cost" {
+		return
+	}
+
+	key := gapComboKey(record)
+	combo, ok := g.combos[key]
+	if !ok {
+		combo = &gapCombo{template: *record, buckets: make(map[time.Time]struct{})}
+		g.combos[key] = combo
+		g.order = append(g.order, key)
+	}
+	combo.buckets[g.bucket(record.Timestamp)] = struct{}{}
+}
+
+// records returns a zero-cost record for every combination and bucket of
+// the range without data, flagged synthetic_zero in their diagnostics.
+func (g *gapFiller) records() []CostRecord {
+	buckets := g.buckets()
+
+	var records []CostRecord
+	for _, key := range g.order {
+		combo := g.combos[key]
+		for _, bucket := range buckets {
+			if _, ok := combo.buckets[bucket]; ok {
+				continue
+			}
+			records = append(records, syntheticZero(combo.template, key, bucket))
+		}
+	}
+	g.written += len(records)
+	return records
+}
+
+// bucket returns the start of the bucket holding t.
+func (g *gapFiller) bucket(t time.Time) time.Time {
+	t = t.UTC()
+	if g.granularity == "month" {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// next returns the start of the bucket after the one starting at bucket.
+func (g *gapFiller) next(bucket time.Time) time.Time {
+	if g.granularity == "month" {
+		return bucket.AddDate(0, 1, 0)
+	}
+	return bucket.AddDate(0, 0, 1)
+}
+
+// buckets returns the starts of the buckets wholly within the range.
+func (g *gapFiller) buckets() []time.Time {
+	bucket := g.bucket(g.start)
+	if bucket.Before(g.start) {
+		bucket = g.next(bucket)
+	}
+
+	var buckets []time.Time
+	for ; !g.next(bucket).After(g.end); bucket = g.next(bucket) {
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// syntheticZero copies template's dimensions into a zero-cost record for
+// bucket. Its LineItemID is stable, so re-syncing the same gap writes the
+// same record.
+func syntheticZero(template CostRecord, comboKey string, bucket time.Time) CostRecord {
+	zero := 0.0
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		syntheticZeroFlag, template.SourceReportToken, bucket.Format("2006-01-02"), comboKey,
+	}, "|")))
+
+	record := CostRecord{
+		Timestamp:         bucket,
+		Provider:          template.Provider,
+		Service:           template.Service,
+		ServiceCategory:   template.ServiceCategory,
+		AccountID:         template.AccountID,
+		SubscriptionID:    template.SubscriptionID,
+		BillingAccountID:  template.BillingAccountID,
+		SubAccountID:      template.SubAccountID,
+		Project:           template.Project,
+		Region:            template.Region,
+		RegionContinent:   template.RegionContinent,
+		RegionCountry:     template.RegionCountry,
+		ResourceID:        template.ResourceID,
+		ResourceType:      template.ResourceType,
+		ResourceName:      template.ResourceName,
+		ResourceAccountID: template.ResourceAccountID,
+		Labels:            template.Labels,
+		LabelsRaw:         template.LabelsRaw,
+		UsageUnit:         template.UsageUnit,
+		NetCost:           &zero,
+		Currency:          template.Currency,
+		SourceReportToken: template.SourceReportToken,
+		SourceReportName:  template.SourceReportName,
+		CostBasis:         template.CostBasis,
+		QueryHash:         template.QueryHash,
+		LineItemID:        hex.EncodeToString(hash[:16]),
+		MetricType:        template.MetricType,
+		Diagnostics:       &Diagnostics{},
+	}
+	if template.CostBasis != "" {
+		record.EffectiveCost = &zero
+	}
+	record.Diagnostics.SetSourceInfo(syntheticZeroFlag, true)
+	return record
+}
+
+// gapComboKey identifies a record's dimension combination: every dimension,
+// label, and currency, but not its bucket or metrics.
+func gapComboKey(record *CostRecord) string {
+	parts := []string{
+		record.Provider, record.Service, record.AccountID, record.SubscriptionID,
+		record.Project, record.Region, record.ResourceID, record.Currency,
+	}
+	labels := make([]string, 0, len(record.Labels))
+	for k, v := range record.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(labels)
+	parts = append(parts, labels...)
+	return strings.Join(parts, "|")
+}
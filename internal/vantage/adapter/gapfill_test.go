@@ -0,0 +1,102 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestGapFiller_Buckets(t *testing.T) {
+	start := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}, newGapFiller("day", start, end).buckets(), "partial days at either end are not filled")
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}, newGapFiller("month", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)).buckets())
+}
+
+func TestGapFiller_Records(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	gaps := newGapFiller("day", day(1), day(4))
+
+	ec2 := costRecord("aws", 10, map[string]string{"team": "web"})
+	ec2.Service = "EC2"
+	ec2.Currency = "USD"
+	ec2.SourceReportToken = "cr_test"
+	for _, d := range []int{1, 3} {
+		record := ec2
+		record.Timestamp = day(d)
+		gaps.add(&record)
+	}
+	s3 := costRecord("aws", 5, nil)
+	s3.Service = "S3"
+	s3.Timestamp = day(2)
+	gaps.add(&s3)
+	forecast := CostRecord{MetricType: "forecast", Provider: "gcp", Timestamp: day(1)}
+	gaps.add(&forecast)
+
+	records := gaps.records()
+
+	require.Len(t, records, 3)
+	assert.Equal(t, "EC2", records[0].Service)
+	assert.Equal(t, day(2), records[0].Timestamp)
+	assert.Equal(t, map[string]string{"team": "web"}, records[0].Labels)
+	assert.Equal(t, "USD", records[0].Currency)
+	assert.Equal(t, "cost", records[0].MetricType)
+	require.NotNil(t, records[0].NetCost)
+	assert.Zero(t, *records[0].NetCost)
+	assert.Equal(t, true, records[0].Diagnostics.SourceInfo[syntheticZeroFlag])
+
+	assert.Equal(t, "S3", records[1].Service)
+	assert.Equal(t, day(1), records[1].Timestamp)
+	assert.Equal(t, day(3), records[2].Timestamp)
+	assert.Equal(t, 3, gaps.written)
+
+	again := newGapFiller("day", day(1), day(4))
+	again.add(&s3)
+	assert.Equal(t, records[1].LineItemID, again.records()[0].LineItemID, "line item IDs are stable across syncs")
+}
+
+func TestAdapter_SyncSingleRange_FillsMissingBuckets(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{
+		CostReportToken:    "cr_test",
+		Granularity:        "day",
+		GroupBys:           []string{"provider", "service"},
+		Metrics:            []string{"cost"},
+		PageSize:           100,
+		FillMissingBuckets: true,
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: startDate, Provider: "aws", Service: "EC2", Cost: 10},
+			{BucketStart: startDate.AddDate(0, 0, 2), Provider: "aws", Service: "EC2", Cost: 12},
+		},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.syncSingleRange(context.Background(), cfg, mockSink, startDate, startDate.AddDate(0, 0, 3), true))
+
+	require.Len(t, mockSink.records, 3)
+	filled := mockSink.records[2]
+	assert.Equal(t, startDate.AddDate(0, 0, 1), filled.Timestamp)
+	assert.Zero(t, *filled.NetCost)
+	assert.Equal(t, 1, adapter.GetDiagnosticsSummary().SourceInfo["synthetic_zero_records"])
+}
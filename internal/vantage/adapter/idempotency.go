@@ -1,15 +1,51 @@
 package adapter
 
 import (
+	"cmp"
 	"crypto/sha256"
 	"encoding/hex"
-	"fmt"
-	"sort"
-	"strings"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/zeebo/xxh3"
+	"lukechampine.com/blake3"
 
 	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
 )
 
+// Line item hash algorithms. SHA-256 is the default; the others are faster
+// on CPUs without SHA instructions, but give every row a different
+// LineItemID, so records hashed with them carry the algorithm in
+// LineItemHash.
+const (
+	HashSHA256    = "sha256"
+	HashXXHash128 = "xxhash128"
+	HashBLAKE3    = "blake3"
+)
+
+// HashAlgorithms returns the supported line item hash algorithms.
+func HashAlgorithms() []string {
+	return []string{HashSHA256, HashXXHash128, HashBLAKE3}
+}
+
+// idBuilder holds the scratch space for building one key. Keys are hashed
+// for every row synced, so builders are pooled rather than allocated.
+type idBuilder struct {
+	buf     []byte
+	tags    []tagPair
+	metrics []string
+}
+
+// tagPair is a tag being sorted into a key.
+type tagPair struct {
+	key, value string
+}
+
+var idBuilders = sync.Pool{
+	New: func() any { return &idBuilder{buf: make([]byte, 0, 256)} },
+}
+
 // GenerateLineItemID creates a deterministic idempotency key for a cost record.
 // The key is based on the hash of (report_token, date, dimensions, metrics).
 // This ensures that identical cost records always produce the same ID, enabling.
@@ -19,51 +55,159 @@ func GenerateLineItemID(
 	row client.CostRow,
 	metrics []string,
 ) string {
-	// Create a stable string representation with all relevant fields.
-	parts := []string{
-		reportToken,
-		row.BucketStart.Format("2006-01-02"), // Date only, not time
+	return GenerateLineItemIDWithHash(HashSHA256, reportToken, row, metrics)
+}
+
+// GenerateLineItemIDWithHash is GenerateLineItemID with the given hash
+// algorithm; an empty or unknown algorithm is SHA-256.
+func GenerateLineItemIDWithHash(
+	algorithm string,
+	reportToken string,
+	row client.CostRow,
+	metrics []string,
+) string {
+	b := idBuilders.Get().(*idBuilder)
+	defer idBuilders.Put(b)
+
+	// Create a stable representation with all relevant fields, separated
+	// by "|".
+	b.writeDimensions(reportToken, row)
+
+	// Add metrics in sorted order by value for consistency.
+	b.metrics = append(b.metrics[:0], metrics...)
+	slices.Sort(b.metrics)
+	b.buf = append(b.buf, '|')
+	for i, metric := range b.metrics {
+		if i > 0 {
+			b.buf = append(b.buf, ',')
+		}
+		b.buf = append(b.buf, metric...)
+	}
+
+	// Add metric values in a consistent order.
+	for _, value := range [...]float64{
+		row.Cost,
+		row.UsageQuantity,
+		row.EffectiveUnitPrice,
+		row.ListCost,
+		row.AmortizedCost,
+		row.Tax,
+		row.Credit,
+		row.Refund,
+	} {
+		b.buf = append(b.buf, '|')
+		// Formats as fmt's %.16g does.
+		b.buf = strconv.AppendFloat(b.buf, value, 'g', 16, 64)
 	}
+	b.buf = append(b.buf, '|')
+	b.buf = append(b.buf, row.UsageUnit...)
+	b.buf = append(b.buf, '|')
+	b.buf = append(b.buf, row.Currency...)
+
+	return b.sum(algorithm) // First 32 hex chars (128 bits)
+}
+
+// generateRowKey identifies a row by report, date, and dimensions only, so a
+// row whose costs were restated keeps its key while its LineItemID changes.
+// Row keys are always SHA-256, so changing the line item hash restates
+// every row rather than losing track of them.
+func generateRowKey(reportToken string, row client.CostRow) string {
+	b := idBuilders.Get().(*idBuilder)
+	defer idBuilders.Put(b)
+
+	b.writeDimensions(reportToken, row)
+	return b.sum(HashSHA256)
+}
+
+// writeDimensions resets the builder to the identity fields shared by
+// GenerateLineItemID and generateRowKey.
+func (b *idBuilder) writeDimensions(reportToken string, row client.CostRow) {
+	b.buf = append(b.buf[:0], reportToken...)
+	b.buf = append(b.buf, '|')
+	b.buf = row.BucketStart.AppendFormat(b.buf, "2006-01-02") // Date only, not time
 
 	// Add dimensions in fixed order for consistency.
-	parts = append(parts, row.Provider)
-	parts = append(parts, row.Service)
-	parts = append(parts, row.Account)
-	parts = append(parts, row.Project)
-	parts = append(parts, row.Region)
-	parts = append(parts, row.ResourceID)
-
-	// Add tags in sorted order by key.
-	if len(row.Tags) > 0 {
-		tagParts := make([]string, 0, len(row.Tags))
-		for k, v := range row.Tags {
-			tagParts = append(tagParts, fmt.Sprintf("%s=%s", k, v))
+	for _, dimension := range [...]string{
+		row.Provider,
+		row.Service,
+		row.Account,
+		row.Project,
+		row.Region,
+		row.ResourceID,
+	} {
+		b.buf = append(b.buf, '|')
+		b.buf = append(b.buf, dimension...)
+	}
+
+	// Add tags as "key=value" in sorted order, separated by ";".
+	b.tags = b.tags[:0]
+	for k, v := range row.Tags {
+		b.tags = append(b.tags, tagPair{key: k, value: v})
+	}
+	slices.SortFunc(b.tags, compareTags)
+	b.buf = append(b.buf, '|')
+	for i, tag := range b.tags {
+		if i > 0 {
+			b.buf = append(b.buf, ';')
 		}
-		sort.Strings(tagParts)
-		parts = append(parts, strings.Join(tagParts, ";"))
-	} else {
-		parts = append(parts, "")
+		b.buf = append(b.buf, tag.key...)
+		b.buf = append(b.buf, '=')
+		b.buf = append(b.buf, tag.value...)
 	}
 
-	// Add metrics in sorted order by value for consistency.
-	sortedMetrics := make([]string, len(metrics))
-	copy(sortedMetrics, metrics)
-	sort.Strings(sortedMetrics)
-	parts = append(parts, strings.Join(sortedMetrics, ","))
+	// Unallocated rows can share every dimension with an allocated row, so
+	// they are told apart; allocated rows keep their existing identity.
+	if row.Unallocated {
+		b.buf = append(b.buf, "|unallocated"...)
+	}
 
-	// Add metric values in a consistent order.
-	parts = append(parts, fmt.Sprintf("%.16g", row.Cost))
-	parts = append(parts, fmt.Sprintf("%.16g", row.UsageQuantity))
-	parts = append(parts, fmt.Sprintf("%.16g", row.EffectiveUnitPrice))
-	parts = append(parts, fmt.Sprintf("%.16g", row.ListCost))
-	parts = append(parts, fmt.Sprintf("%.16g", row.AmortizedCost))
-	parts = append(parts, fmt.Sprintf("%.16g", row.Tax))
-	parts = append(parts, fmt.Sprintf("%.16g", row.Credit))
-	parts = append(parts, fmt.Sprintf("%.16g", row.Refund))
-	parts = append(parts, row.UsageUnit)
-	parts = append(parts, row.Currency)
-
-	// Generate hash.
-	hash := sha256.Sum256([]byte(strings.Join(parts, "|")))
-	return hex.EncodeToString(hash[:16]) // First 32 hex chars (128 bits)
+	// Rows grouped by billing account can differ in it alone; rows without
+	// one keep their existing identity.
+	if row.BillingAccount != "" {
+		b.buf = append(b.buf, "|billing_account="...)
+		b.buf = append(b.buf, row.BillingAccount...)
+	}
+}
+
+// sum returns the hex of the first 16 bytes of the buffer's hash.
+func (b *idBuilder) sum(algorithm string) string {
+	var hash [16]byte
+	switch algorithm {
+	case HashXXHash128:
+		hash = xxh3.Hash128(b.buf).Bytes()
+	case HashBLAKE3:
+		sum := blake3.Sum256(b.buf)
+		copy(hash[:], sum[:16])
+	default:
+		sum := sha256.Sum256(b.buf)
+		copy(hash[:], sum[:16])
+	}
+	var out [32]byte
+	hex.Encode(out[:], hash[:])
+	return string(out[:])
+}
+
+// compareTags orders tags as their "key=value" strings sort, without
+// building them. Sorting by key alone differs when one key prefixes
+// another: "a.b=2" sorts before "a=1".
+func compareTags(a, b tagPair) int {
+	la, lb := len(a.key)+1+len(a.value), len(b.key)+1+len(b.value)
+	for i := range min(la, lb) {
+		if ca, cb := a.at(i), b.at(i); ca != cb {
+			return cmp.Compare(ca, cb)
+		}
+	}
+	return cmp.Compare(la, lb)
+}
+
+// at returns byte i of the tag's "key=value" string.
+func (t tagPair) at(i int) byte {
+	switch {
+	case i < len(t.key):
+		return t.key[i]
+	case i == len(t.key):
+		return '='
+	default:
+		return t.value[i-len(t.key)-1]
+	}
 }
@@ -1,10 +1,15 @@
 package adapter
 
 import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
 )
@@ -154,6 +159,32 @@ func TestGenerateLineItemID_DifferentAccount(t *testing.T) {
 	assert.NotEqual(t, id1, id2, "different accounts should produce different IDs")
 }
 
+func TestGenerateLineItemID_DifferentBillingAccount(t *testing.T) {
+	row1 := client.CostRow{
+		Provider:       "aws",
+		Service:        "EC2",
+		Account:        "111111111",
+		BillingAccount: "900000001",
+		BucketStart:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Cost:           100.0,
+	}
+	row2 := row1
+	row2.BillingAccount = "900000002"
+	metrics := []string{"cost"}
+	reportToken := "cr_test"
+
+	assert.NotEqual(t, GenerateLineItemID(reportToken, row1, metrics), GenerateLineItemID(reportToken, row2, metrics),
+		"different billing accounts should produce different IDs")
+	assert.NotEqual(t, generateRowKey(reportToken, row1), generateRowKey(reportToken, row2),
+		"different billing accounts should produce different row keys")
+
+	// A row without a billing account keeps the identity it had before
+	// billing accounts were read.
+	row3 := row1
+	row3.BillingAccount = ""
+	assert.NotEqual(t, GenerateLineItemID(reportToken, row1, metrics), GenerateLineItemID(reportToken, row3, metrics))
+}
+
 // TestGenerateLineItemID_DifferentProject produces different IDs.
 func TestGenerateLineItemID_DifferentProject(t *testing.T) {
 	row1 := client.CostRow{
@@ -914,3 +945,162 @@ func TestGenerateLineItemID_ConsistencyAcrossMultipleCalls_100Variations(t *test
 	// We skip this check because structs with maps cannot be directly compared.
 	// and the test is primarily about determinism, which we've verified above
 }
+
+func TestGenerateLineItemID_Golden(t *testing.T) {
+	for _, tc := range lineItemIDGoldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.lineItemID, GenerateLineItemID(tc.reportToken, tc.row, tc.metrics))
+			assert.Equal(t, tc.rowKey, generateRowKey(tc.reportToken, tc.row))
+		})
+	}
+}
+
+func TestGenerateLineItemIDWithHash(t *testing.T) {
+	tc := lineItemIDGoldenCases()[1]
+	for algorithm, want := range map[string]string{
+		"":            tc.lineItemID,
+		HashSHA256:    tc.lineItemID,
+		HashXXHash128: "c17877657a53ec130f353e5a76100981",
+		HashBLAKE3:    "f0e78e71d61b2b5bce7666b4bdd455e1",
+	} {
+		assert.Equal(t, want, GenerateLineItemIDWithHash(algorithm, tc.reportToken, tc.row, tc.metrics), algorithm)
+	}
+
+	// Row keys stay SHA-256 whatever the line item hash.
+	assert.Equal(t, tc.rowKey, generateRowKey(tc.reportToken, tc.row))
+}
+
+// The key's amounts must keep formatting exactly as %.16g did, or every
+// existing LineItemID would change.
+func TestGenerateLineItemID_FloatFormatMatchesSprintf(t *testing.T) {
+	values := []float64{
+		0, math.Copysign(0, -1), 1, -1, 0.1, 1e-5, 1e-4, 1e15, 1e16, 1e17,
+		123456789.123456789, math.Inf(1), math.Inf(-1), math.NaN(),
+		math.MaxFloat64, math.SmallestNonzeroFloat64,
+	}
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 10000 {
+		values = append(values, math.Float64frombits(r.Uint64()), r.NormFloat64()*1e6)
+	}
+	for _, v := range values {
+		require.Equal(t, fmt.Sprintf("%.16g", v), string(strconv.AppendFloat(nil, v, 'g', 16, 64)))
+	}
+}
+
+func BenchmarkGenerateLineItemID(b *testing.B) {
+	row := client.CostRow{
+		Provider:    "aws",
+		Service:     "EC2",
+		Account:     "123456789",
+		Region:      "us-east-1",
+		ResourceID:  "i-1234567890abcdef0",
+		Tags:        map[string]string{"env": "prod", "team": "platform"},
+		Cost:        100.50,
+		BucketStart: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		BucketEnd:   time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+	}
+	metrics := []string{"cost", "usage"}
+	for _, algorithm := range HashAlgorithms() {
+		b.Run(algorithm, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				GenerateLineItemIDWithHash(algorithm, "cr_test123", row, metrics)
+			}
+		})
+	}
+}
+
+// lineItemIDGoldenCase is a row whose LineItemID and row key were recorded,
+// so changes to the hashing cannot silently change existing IDs.
+type lineItemIDGoldenCase struct {
+	name        string
+	reportToken string
+	row         client.CostRow
+	metrics     []string
+	lineItemID  string
+	rowKey      string
+}
+
+func lineItemIDGoldenCases() []lineItemIDGoldenCase {
+	day := time.Date(2024, 1, 15, 13, 45, 0, 0, time.UTC)
+	return []lineItemIDGoldenCase{
+		{
+			name:        "empty",
+			lineItemID:  "232d714aca6e48bff9c9169ac06a14c2",
+			rowKey:      "b5dbb3cd7dcc720e75fdd81d34427ea0",
+			reportToken: "",
+		},
+		{
+			name:        "full",
+			lineItemID:  "a53e9fddfd987f5ef3c39aa36e846fe5",
+			rowKey:      "3c712914387d76d51c2fc421bbe05650",
+			reportToken: "cr_test123",
+			row: client.CostRow{
+				Provider:           "aws",
+				Service:            "EC2",
+				Account:            "123456789",
+				Project:            "my-project",
+				Region:             "us-east-1",
+				ResourceID:         "i-1234567890abcdef0",
+				Tags:               map[string]string{"env": "prod", "team": "platform"},
+				Cost:               100.50,
+				UsageQuantity:      720.0,
+				EffectiveUnitPrice: 0.1395833,
+				ListCost:           120,
+				AmortizedCost:      98.25,
+				Tax:                8.04,
+				Credit:             -5,
+				Refund:             -0.01,
+				UsageUnit:          "hours",
+				Currency:           "USD",
+				BucketStart:        day,
+				BucketEnd:          day.AddDate(0, 0, 1),
+			},
+			metrics: []string{"usage", "cost"},
+		},
+		{
+			// "a.b=2" sorts before "a=1", though "a" sorts before "a.b".
+			name:        "tag keys sharing a prefix",
+			lineItemID:  "7f7787384bcc89fbed092121852db48d",
+			rowKey:      "b18c973aa087cdbcab59099a4175b7e0",
+			reportToken: "cr_tags",
+			row: client.CostRow{
+				Provider:    "gcp",
+				Tags:        map[string]string{"a": "1", "a.b": "2", "a=b": "c", "Z": "", "é": "ü"},
+				Cost:        0.1 + 0.2,
+				BucketStart: day,
+			},
+			metrics: []string{"cost"},
+		},
+		{
+			name:        "unallocated",
+			lineItemID:  "fcafb264c0b5373d88640f2d474b5db8",
+			rowKey:      "afb72ca43773e294832fae3b0b07c7d6",
+			reportToken: "cr_test123",
+			row: client.CostRow{
+				Provider:    "azure",
+				Service:     "Storage",
+				Cost:        1e21,
+				ListCost:    1e-7,
+				Tax:         math.Copysign(0, -1),
+				Unallocated: true,
+				BucketStart: day,
+			},
+		},
+		{
+			name:        "non-finite amounts",
+			lineItemID:  "aa37f90456450c84db87be47877dd1fe",
+			rowKey:      "fed5b73549ab1cf4317e4abe15d13b3a",
+			reportToken: "cr_test123",
+			row: client.CostRow{
+				Cost:          math.Inf(1),
+				UsageQuantity: math.Inf(-1),
+				ListCost:      math.NaN(),
+				AmortizedCost: math.MaxFloat64,
+				Credit:        math.SmallestNonzeroFloat64,
+				BucketStart:   day,
+			},
+			metrics: []string{"cost", "cost", "amortized_cost"},
+		},
+	}
+}
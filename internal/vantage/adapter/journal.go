@@ -0,0 +1,216 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+)
+
+// JournalEntry is a batch of records recorded in a Journal before it is
+// written to the sink.
+type JournalEntry struct {
+	// Seq orders the entries of a journal key.
+	Seq uint64 `json:"seq"`
+	// Checkpoint is the sink's position before the batch was written, set
+	// when the sink is a CheckpointSink.
+	Checkpoint string       `json:"checkpoint,omitempty"`
+	Records    []CostRecord `json:"records,omitempty"`
+	// Committed is set once the sink has acknowledged the batch.
+	Committed bool `json:"committed,omitempty"`
+}
+
+// Journal is a write-ahead log of the batches a sync writes. Each batch is
+// appended before it is handed to the sink and committed once the sink has
+// written it, so after a crash the next sync of the report finishes writing
+// the batch left uncommitted and skips records already committed.
+type Journal interface {
+	// Append durably records entry under key before its batch is written.
+	Append(ctx context.Context, key string, entry JournalEntry) error
+	// Commit marks the entry seq of key as written.
+	Commit(ctx context.Context, key string, seq uint64) error
+	// Entries returns the entries of key since its last Reset, in order.
+	Entries(ctx context.Context, key string) ([]JournalEntry, error)
+	// Reset drops the entries of key once their range is bookmarked.
+	Reset(ctx context.Context, key string) error
+}
+
+// CheckpointSink is a Sink that can tell which records reached it after a
+// point, so a batch interrupted by a crash is finished without writing any
+// of its records twice. Without one, the whole batch is written again.
+type CheckpointSink interface {
+	Sink
+	// Checkpoint returns an opaque position after every record written so
+	// far.
+	Checkpoint(ctx context.Context) (string, error)
+	// RecordsSince repairs a write torn after checkpoint and returns how
+	// many records with each LineItemID were written after it.
+	RecordsSince(ctx context.Context, checkpoint string) (map[string]int, error)
+}
+
+// SetJournal makes every sync record its batches in journal before writing
+// them. Without a journal a sync interrupted part way re-writes the records
+// of its unfinished range on the next run.
+func (a *Adapter) SetJournal(journal Journal) {
+	a.journal = journal
+}
+
+// recoverJournal finishes writing the batches a previous sync of the report
+// left uncommitted, and loads the records committed since the report's
+// last completed range so this sync skips them.
+func (a *Adapter) recoverJournal(ctx context.Context, cfg Config, sink Sink) error {
+	a.journalKey = ""
+	a.journalSeq = 0
+	a.journaled = nil
+	if a.journal == nil {
+		return nil
+	}
+
+	key := "vantage_journal_" + a.reportQueryHash(cfg)
+	entries, err := a.journal.Entries(ctx, key)
+	if err != nil {
+		return fmt.Errorf("reading journal: %w", err)
+	}
+
+	a.journaled = make(map[string]struct{})
+	recovered := 0
+	for _, entry := range entries {
+		a.journalSeq = max(a.journalSeq, entry.Seq)
+		if !entry.Committed {
+			written, recoverErr := a.recoverJournalEntry(ctx, sink, entry)
+			if recoverErr != nil {
+				return fmt.Errorf("recovering journal entry %d: %w", entry.Seq, recoverErr)
+			}
+			if commitErr := a.journal.Commit(ctx, key, entry.Seq); commitErr != nil {
+				return fmt.Errorf("committing journal entry %d: %w", entry.Seq, commitErr)
+			}
+			recovered += written
+		}
+		for i := range entry.Records {
+			a.journaled[entry.Records[i].LineItemID] = struct{}{}
+		}
+	}
+	a.journalKey = key
+
+	if recovered > 0 {
+		a.diagnosticsSummary.SourceInfo["journal_recovered_records"] = recovered
+		a.logger.Info(ctx, "Wrote records an interrupted sync left unwritten", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "journal_recover",
+			"attempt":   0,
+			"records":   recovered,
+		})
+	}
+	return nil
+}
+
+// recoverJournalEntry writes the records of an uncommitted entry that did
+// not reach the sink, returning how many it wrote.
+func (a *Adapter) recoverJournalEntry(ctx context.Context, sink Sink, entry JournalEntry) (int, error) {
+	records := entry.Records
+	if checkpoints, ok := sink.(CheckpointSink); ok && entry.Checkpoint != "" {
+		written, err := checkpoints.RecordsSince(ctx, entry.Checkpoint)
+		if err != nil {
+			return 0, err
+		}
+		records = make([]CostRecord, 0, len(entry.Records))
+		for _, record := range entry.Records {
+			if written[record.LineItemID] > 0 {
+				written[record.LineItemID]--
+				continue
+			}
+			records = append(records, record)
+		}
+	} else {
+		a.logger.Warn(ctx, "Sink cannot report which journaled records it wrote; writing the whole batch again",
+			map[string]interface{}{
+				"adapter":   "vantage",
+				"operation": "journal_recover",
+				"attempt":   0,
+				"seq":       entry.Seq,
+				"records":   len(records),
+			})
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	written, failed, err := a.writeToSink(ctx, sink, records)
+	a.stats.RecordsWritten += written
+	if err == nil {
+		err = a.handleFailedRecords(ctx, records, failed)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrSink, err)
+	}
+	return written, nil
+}
+
+// skipJournaled drops the records already committed to the journal by an
+// interrupted sync of the same range, counting them.
+func (a *Adapter) skipJournaled(records []CostRecord) []CostRecord {
+	if len(a.journaled) == 0 {
+		return records
+	}
+	kept := make([]CostRecord, 0, len(records))
+	for _, record := range records {
+		if _, ok := a.journaled[record.LineItemID]; ok {
+			skipped, _ := a.diagnosticsSummary.SourceInfo["journal_skipped_records"].(int)
+			a.diagnosticsSummary.SourceInfo["journal_skipped_records"] = skipped + 1
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+// appendJournal records a batch in the journal before it is written,
+// returning its sequence number, or 0 when batches are not journaled.
+func (a *Adapter) appendJournal(ctx context.Context, sink Sink, records []CostRecord) (uint64, error) {
+	if a.journalKey == "" || len(records) == 0 {
+		return 0, nil
+	}
+
+	entry := JournalEntry{Seq: a.journalSeq + 1, Records: records}
+	if checkpoints, ok := sink.(CheckpointSink); ok {
+		checkpoint, err := checkpoints.Checkpoint(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("reading sink checkpoint: %w", err)
+		}
+		entry.Checkpoint = checkpoint
+	}
+	if err := a.journal.Append(ctx, a.journalKey, entry); err != nil {
+		return 0, fmt.Errorf("appending to journal: %w", err)
+	}
+	a.journalSeq = entry.Seq
+	return entry.Seq, nil
+}
+
+// commitJournal marks a written batch committed.
+func (a *Adapter) commitJournal(ctx context.Context, seq uint64) error {
+	if seq == 0 {
+		return nil
+	}
+	if err := a.journal.Commit(ctx, a.journalKey, seq); err != nil {
+		return fmt.Errorf("committing journal entry %d: %w", seq, err)
+	}
+	return nil
+}
+
+// resetJournal drops the journal's entries once the range they were written
+// for is bookmarked, so a later sync writes its records again as usual. A
+// failure is logged: the entries only make the next sync skip records that
+// were written.
+func (a *Adapter) resetJournal(ctx context.Context) {
+	if a.journalKey == "" {
+		return
+	}
+	if err := a.journal.Reset(ctx, a.journalKey); err != nil {
+		a.logger.Warn(ctx, "Failed to reset the journal", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "journal_reset",
+			"attempt":   0,
+			"error":     err,
+		})
+		return
+	}
+	clear(a.journaled)
+}
@@ -0,0 +1,221 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+var errCrashed = errors.New("process crashed")
+
+// memoryJournal is an in-memory Journal.
+type memoryJournal struct {
+	entries map[string][]JournalEntry
+}
+
+func newMemoryJournal() *memoryJournal {
+	return &memoryJournal{entries: make(map[string][]JournalEntry)}
+}
+
+func (j *memoryJournal) Append(_ context.Context, key string, entry JournalEntry) error {
+	entry.Records = append([]CostRecord(nil), entry.Records...)
+	j.entries[key] = append(j.entries[key], entry)
+	return nil
+}
+
+func (j *memoryJournal) Commit(_ context.Context, key string, seq uint64) error {
+	for i := range j.entries[key] {
+		if j.entries[key][i].Seq == seq {
+			j.entries[key][i].Committed = true
+		}
+	}
+	return nil
+}
+
+func (j *memoryJournal) Entries(_ context.Context, key string) ([]JournalEntry, error) {
+	return append([]JournalEntry(nil), j.entries[key]...), nil
+}
+
+func (j *memoryJournal) Reset(_ context.Context, key string) error {
+	delete(j.entries, key)
+	return nil
+}
+
+// storedSink keeps written records in order. With crashAfter set, a write
+// stores that many more records and then fails as a crash would.
+type storedSink struct {
+	records    []CostRecord
+	crashAfter int
+}
+
+func (s *storedSink) WriteRecords(_ context.Context, records []CostRecord) error {
+	if s.crashAfter > 0 && len(records) > s.crashAfter {
+		s.records = append(s.records, records[:s.crashAfter]...)
+		return errCrashed
+	}
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *storedSink) ids() []string {
+	ids := make([]string, len(s.records))
+	for i, record := range s.records {
+		ids[i] = record.LineItemID
+	}
+	return ids
+}
+
+// checkpointedSink is a storedSink that reports its checkpoints.
+type checkpointedSink struct {
+	*storedSink
+}
+
+func (s checkpointedSink) Checkpoint(context.Context) (string, error) {
+	return strconv.Itoa(len(s.records)), nil
+}
+
+func (s checkpointedSink) RecordsSince(_ context.Context, checkpoint string) (map[string]int, error) {
+	offset, err := strconv.Atoi(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	written := make(map[string]int)
+	for _, record := range s.records[offset:] {
+		written[record.LineItemID]++
+	}
+	return written, nil
+}
+
+func journalSyncConfig() Config {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	return Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       start,
+		EndDate:         &end,
+		Metrics:         []string{"cost"},
+		BatchSize:       2,
+	}
+}
+
+func journalClient() *mockClient {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockClient := &mockClient{}
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: day, Provider: "aws", Service: "EC2", Cost: 10},
+			{BucketStart: day, Provider: "aws", Service: "S3", Cost: 2},
+			{BucketStart: day, Provider: "aws", Service: "RDS", Cost: 7},
+		},
+	}, nil)
+	return mockClient
+}
+
+func TestSync_JournalFinishesInterruptedBatch(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		checkpoint bool
+		// written is how many records the sink holds in the end.
+		written int
+	}{
+		{name: "checkpoint sink", checkpoint: true, written: 3},
+		// Without checkpoints the interrupted batch is written again whole.
+		{name: "plain sink", checkpoint: false, written: 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := journalSyncConfig()
+			journal := newMemoryJournal()
+			stored := &storedSink{crashAfter: 1}
+			var sink Sink = stored
+			if tt.checkpoint {
+				sink = checkpointedSink{stored}
+			}
+
+			first := New(journalClient(), client.NewNoopLogger())
+			first.sinkRetryBackoff = 0
+			first.SetJournal(journal)
+			require.ErrorIs(t, first.Sync(context.Background(), cfg, sink), errCrashed)
+			require.Len(t, stored.records, 1, "the crash left the first batch half written")
+
+			stored.crashAfter = 0
+			second := New(journalClient(), client.NewNoopLogger())
+			second.SetJournal(journal)
+			require.NoError(t, second.Sync(context.Background(), cfg, sink))
+
+			assert.Len(t, stored.records, tt.written)
+			assert.Len(t, uniqueIDs(stored.ids()), 3)
+			summary := second.GetDiagnosticsSummary()
+			assert.Equal(t, 2, summary.SourceInfo["journal_skipped_records"], "the recovered batch is not refetched")
+			assert.Empty(t, journal.entries, "the journal is reset once the range completes")
+		})
+	}
+}
+
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func TestSync_JournalSkipsCommittedBatches(t *testing.T) {
+	cfg := journalSyncConfig()
+	journal := newMemoryJournal()
+	stored := &storedSink{}
+	sink := checkpointedSink{stored}
+
+	// The first batch is written and committed; writing the second fails,
+	// leaving it journaled but uncommitted.
+	a := New(journalClient(), client.NewNoopLogger())
+	a.SetJournal(journal)
+	writes := 0
+	failing := &failingAfterSink{checkpointedSink: sink, writes: &writes, failOn: 2}
+	require.ErrorIs(t, a.Sync(context.Background(), cfg, failing), errCrashed)
+	require.Len(t, stored.records, 2)
+
+	require.NoError(t, a.Sync(context.Background(), cfg, sink))
+	assert.Len(t, uniqueIDs(stored.ids()), 3)
+	assert.Len(t, stored.records, 3, "committed records are not written twice")
+	summary := a.GetDiagnosticsSummary()
+	assert.Equal(t, 1, summary.SourceInfo["journal_recovered_records"])
+	assert.Equal(t, 3, summary.SourceInfo["journal_skipped_records"])
+}
+
+// failingAfterSink fails its failOn-th write without writing anything.
+type failingAfterSink struct {
+	checkpointedSink
+	writes *int
+	failOn int
+}
+
+func (s *failingAfterSink) WriteRecords(ctx context.Context, records []CostRecord) error {
+	*s.writes++
+	if *s.writes == s.failOn {
+		return errCrashed
+	}
+	return s.checkpointedSink.WriteRecords(ctx, records)
+}
+
+func TestSync_WithoutJournal(t *testing.T) {
+	stored := &storedSink{}
+	a := New(journalClient(), client.NewNoopLogger())
+
+	require.NoError(t, a.Sync(context.Background(), journalSyncConfig(), stored))
+	require.NoError(t, a.Sync(context.Background(), journalSyncConfig(), stored))
+	assert.Len(t, stored.records, 6, "without a journal every sync writes its records")
+	assert.NotContains(t, a.GetDiagnosticsSummary().SourceInfo, "journal_skipped_records")
+}
@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/lock"
+)
+
+// lockReleaseTimeout bounds releasing the sync lock after a run, which may
+// happen after the run's own context was cancelled.
+const lockReleaseTimeout = 10 * time.Second
+
+// ErrSyncLockLost is returned when a sync loses its lock part way, so
+// another run may now be writing the same report.
+var ErrSyncLockLost = errors.New("sync lock lost")
+
+// SetLocker makes every sync hold a lock keyed by its report, waiting up to
+// wait for a run that already holds it. Without a locker syncs do not lock.
+func (a *Adapter) SetLocker(locker lock.Locker, wait time.Duration) {
+	a.locker = locker
+	a.lockWait = wait
+}
+
+// syncLockKey is the lock key for a report. It leaves out the date range so
+// incremental pulls and backfills of the same report exclude each other.
+func (a *Adapter) syncLockKey(cfg Config) string {
+	return "vantage_lock_" + a.reportQueryHash(cfg)
+}
+
+// reportQueryHash hashes the query fields that identify a report, without
+// a date range.
+func (a *Adapter) reportQueryHash(cfg Config) string {
+	return a.generateQueryHash(client.Query{
+		WorkspaceToken:  cfg.WorkspaceToken,
+		CostReportToken: cfg.CostReportToken,
+		Granularity:     cfg.Granularity,
+		GroupBys:        cfg.GroupBys,
+		Metrics:         cfg.Metrics,
+		Filter:          cfg.Filter,
+
+		IncludeUnallocated: cfg.IncludeUnallocated,
+	})
+}
+
+// acquireSyncLock takes the report's sync lock when a locker is set. The
+// returned context is cancelled with ErrSyncLockLost if the lock is lost;
+// release must be called once the sync ends.
+func (a *Adapter) acquireSyncLock(ctx context.Context, cfg Config) (context.Context, func(), error) {
+	if a.locker == nil {
+		return ctx, func() {}, nil
+	}
+
+	key := a.syncLockKey(cfg)
+	held, err := lock.AcquireWithin(ctx, a.locker, key, a.lockWait)
+	if errors.Is(err, lock.ErrHeld) {
+		return nil, nil, fmt.Errorf("another sync is running for this report (lock %s): %w", key, err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("acquiring sync lock: %w", err)
+	}
+
+	a.logger.Info(ctx, "Acquired sync lock", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "sync_lock",
+		"attempt":   0,
+		"lock_key":  key,
+	})
+
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-held.Lost():
+			cancel(ErrSyncLockLost)
+		case <-done:
+		}
+	}()
+
+	release := func() {
+		close(done)
+		cancel(nil)
+
+		releaseCtx, cancelRelease := context.WithTimeout(context.WithoutCancel(ctx), lockReleaseTimeout)
+		defer cancelRelease()
+		if err := held.Release(releaseCtx); err != nil {
+			a.logger.Warn(ctx, "Failed to release sync lock", map[string]interface{}{
+				"adapter":   "vantage",
+				"operation": "sync_lock",
+				"attempt":   0,
+				"lock_key":  key,
+				"error":     err.Error(),
+			})
+		}
+	}
+	return lockCtx, release, nil
+}
@@ -0,0 +1,109 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/lock"
+)
+
+// lostLocker hands out locks that are already lost.
+type lostLocker struct{}
+
+func (lostLocker) Acquire(context.Context, string) (lock.Lock, error) {
+	return lostLock{}, nil
+}
+
+type lostLock struct{}
+
+func (lostLock) Lost() <-chan struct{} {
+	lost := make(chan struct{})
+	close(lost)
+	return lost
+}
+
+func (lostLock) Release(context.Context) error { return nil }
+
+func lockTestConfig() Config {
+	return Config{
+		WorkspaceToken:  "wrkspc_test",
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		PageSize:        100,
+	}
+}
+
+func TestAdapter_SyncLockKey(t *testing.T) {
+	a := New(&mockClient{}, client.NewNoopLogger())
+	cfg := lockTestConfig()
+
+	key := a.syncLockKey(cfg)
+	assert.Contains(t, key, "vantage_lock_")
+
+	// The date range does not change the key.
+	cfg.StartDate = cfg.StartDate.AddDate(-1, 0, 0)
+	assert.Equal(t, key, a.syncLockKey(cfg))
+
+	cfg.CostReportToken = "cr_other"
+	assert.NotEqual(t, key, a.syncLockKey(cfg))
+}
+
+func TestAdapter_Sync_LockHeld(t *testing.T) {
+	mockClient := &mockClient{}
+	a := New(mockClient, client.NewNoopLogger())
+	a.SetBookmarkStore(bookmark.NewMemory())
+	locker := lock.NewMemory()
+	a.SetLocker(locker, 0)
+	cfg := lockTestConfig()
+
+	held, err := locker.Acquire(context.Background(), a.syncLockKey(cfg))
+	require.NoError(t, err)
+	defer func() { _ = held.Release(context.Background()) }()
+
+	err = a.Sync(context.Background(), cfg, &mockSink{})
+	require.ErrorIs(t, err, lock.ErrHeld)
+	mockClient.AssertNotCalled(t, "Costs", mock.Anything, mock.Anything)
+}
+
+func TestAdapter_Sync_ReleasesLock(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	a := New(mockClient, client.NewNoopLogger())
+	a.SetBookmarkStore(bookmark.NewMemory())
+	locker := lock.NewMemory()
+	a.SetLocker(locker, 0)
+	cfg := lockTestConfig()
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, a.Sync(context.Background(), cfg, mockSink))
+
+	held, err := locker.Acquire(context.Background(), a.syncLockKey(cfg))
+	require.NoError(t, err)
+	require.NoError(t, held.Release(context.Background()))
+}
+
+func TestAdapter_Sync_LockLost(t *testing.T) {
+	mockClient := &mockClient{}
+	a := New(mockClient, client.NewNoopLogger())
+	a.SetBookmarkStore(bookmark.NewMemory())
+	a.SetLocker(lostLocker{}, 0)
+
+	// The page fetch blocks until the sync context is cancelled.
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).
+		Run(func(args mock.Arguments) {
+			ctx, _ := args.Get(0).(context.Context)
+			<-ctx.Done()
+		}).
+		Return(client.Page{}, context.Canceled)
+
+	err := a.Sync(context.Background(), lockTestConfig(), &mockSink{})
+	require.ErrorIs(t, err, ErrSyncLockLost)
+}
@@ -2,10 +2,53 @@ package adapter
 
 import (
 	"context"
+	"math"
+	"slices"
+	"strings"
 
 	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
 )
 
+const (
+	// allocationLabel and allocationUnallocated are the synthetic label
+	// set on records for unallocated spend.
+	allocationLabel       = "allocation"
+	allocationUnallocated = "unallocated"
+)
+
+// accountHierarchy returns the FOCUS billing account and sub-account of
+// row. For GCP, Vantage's account is the billing account and the project is
+// the sub-account; elsewhere the account is the sub-account, invoiced to
+// BillingAccount. An account without a billing account is billed itself.
+func accountHierarchy(row client.CostRow) (billing, sub string) {
+	billing, sub = row.BillingAccount, row.Account
+	if strings.EqualFold(row.Provider, "gcp") {
+		if billing == "" {
+			billing = row.Account
+		}
+		sub = row.Project
+	}
+	if billing == "" {
+		billing = sub
+	}
+	return billing, sub
+}
+
+// lineItemID returns the LineItemID of row, hashed with the configured
+// algorithm.
+func (a *Adapter) lineItemID(row client.CostRow, query client.Query) string {
+	return GenerateLineItemIDWithHash(a.lineItemHash, query.CostReportToken, row, query.Metrics)
+}
+
+// recordedLineItemHash returns the LineItemHash of cost records: empty for
+// SHA-256, so records keep the format they had before the option existed.
+func (a *Adapter) recordedLineItemHash() string {
+	if a.lineItemHash == HashSHA256 {
+		return ""
+	}
+	return a.lineItemHash
+}
+
 // mapVantageRowToCostRecord converts a Vantage CostRow to a PulumiCost CostRecord.
 func (a *Adapter) mapVantageRowToCostRecord(
 	row client.CostRow,
@@ -13,7 +56,7 @@ func (a *Adapter) mapVantageRowToCostRecord(
 	queryHash, metricType string,
 ) CostRecord {
 	// Generate idempotency key for line_item_id (FOCUS 1.2 requirement).
-	lineItemID := GenerateLineItemID(query.CostReportToken, row, query.Metrics)
+	lineItemID := a.lineItemID(row, query)
 
 	record := CostRecord{
 		Timestamp:         row.BucketStart,
@@ -21,21 +64,36 @@ func (a *Adapter) mapVantageRowToCostRecord(
 		Service:           row.Service,
 		AccountID:         row.Account,
 		Project:           row.Project,
-		Region:            row.Region,
+		Region:            canonicalRegion(row.Provider, row.Region),
 		ResourceID:        row.ResourceID,
 		Currency:          row.Currency,
 		SourceReportToken: query.CostReportToken,
+		SourceReportName:  a.reportNames[query.CostReportToken],
 		QueryHash:         queryHash,
 		LineItemID:        lineItemID,
+		LineItemHash:      a.recordedLineItemHash(),
 		MetricType:        metricType,
 		Diagnostics:       &Diagnostics{},
 	}
 
+	record.BillingAccountID, record.SubAccountID = accountHierarchy(row)
+	record.ServiceCategory = a.serviceCategories.category(row.Provider, row.Service)
+	if ref, ok := ParseResourceID(row.ResourceID); ok {
+		record.ResourceType, record.ResourceName, record.ResourceAccountID = ref.Type, ref.Name, ref.Account
+	}
+	if a.regionGeography {
+		applyRegionGeography(&record)
+	}
+
 	// Map usage metrics.
 	if row.UsageQuantity != 0 {
 		record.UsageAmount = &row.UsageQuantity
 	}
 	record.UsageUnit = row.UsageUnit
+	if row.EffectiveUnitPrice != 0 {
+		record.EffectiveUnitPrice = &row.EffectiveUnitPrice
+		record.PricingUnit = row.UsageUnit
+	}
 
 	// Map cost metrics.
 	if row.ListCost != 0 {
@@ -56,9 +114,26 @@ func (a *Adapter) mapVantageRowToCostRecord(
 	if row.Refund != 0 {
 		record.RefundAmount = &row.Refund
 	}
+	a.costBasis.apply(&record)
 
 	// Normalize and map tags.
-	record.Labels = a.normalizeTags(row.Tags)
+	record.Labels, record.LabelsRaw = a.normalizeTagsWithRaw(row.Tags)
+
+	// Label spend cost allocation could not attribute, so it can be
+	// queried apart from tagged spend.
+	if row.Unallocated {
+		if record.Labels == nil {
+			record.Labels = make(map[string]string, 1)
+		}
+		record.Labels[allocationLabel] = allocationUnallocated
+	}
+
+	// Pass through response fields the client does not map, so new Vantage
+	// fields reach the sink before the plugin maps them.
+	if len(row.Extra) > 0 {
+		record.Diagnostics.SetSourceInfo("unknown_fields", row.Extra)
+		a.logUnknownFields(row.Extra, metricType)
+	}
 
 	// Add diagnostics for missing fields.
 	a.addDiagnostics(&record, row)
@@ -76,30 +151,26 @@ func (a *Adapter) addDiagnostics(record *CostRecord, _ client.CostRow) {
 		diag.AddMissingField("provider", reason)
 		a.logMissingField("provider", reason, record)
 	}
-	if record.Service == "" {
-		reason := "required FOCUS 1.2 field service_name is empty"
-		diag.AddMissingField("service", reason)
-		a.logMissingField("service", reason, record)
-	}
-	if record.AccountID == "" {
-		reason := "FOCUS 1.2 field billing_account_id is empty"
-		diag.AddMissingField("account_id", reason)
-		a.logMissingField("account_id", reason, record)
-	}
-	if record.Region == "" {
-		reason := "FOCUS 1.2 field region is empty"
-		diag.AddMissingField("region", reason)
-		a.logMissingField("region", reason, record)
-	}
-	if record.Currency == "" {
-		reason := "FOCUS 1.2 field billing_currency is empty"
-		diag.AddMissingField("currency", reason)
-		a.logMissingField("currency", reason, record)
+
+	// Other fields are checked when the field policy expects them for the
+	// record's provider.
+	checks := []struct {
+		field   string
+		missing bool
+		reason  string
+	}{
+		{"service", record.Service == "", "required FOCUS 1.2 field service_name is empty"},
+		{"account_id", record.AccountID == "", "FOCUS 1.2 field billing_account_id is empty"},
+		{"project", record.Project == "", "field project is empty"},
+		{"region", record.Region == "", "FOCUS 1.2 field region is empty"},
+		{"currency", record.Currency == "", "FOCUS 1.2 field billing_currency is empty"},
+		{"net_cost", record.NetCost == nil || *record.NetCost == 0, "required FOCUS 1.2 field net_cost is nil or zero"},
 	}
-	if record.NetCost == nil || *record.NetCost == 0 {
-		reason := "required FOCUS 1.2 field net_cost is nil or zero"
-		diag.AddMissingField("net_cost", reason)
-		a.logMissingField("net_cost", reason, record)
+	for _, check := range checks {
+		if check.missing && a.fields.expects(record.Provider, check.field) {
+			diag.AddMissingField(check.field, check.reason)
+			a.logMissingField(check.field, check.reason, record)
+		}
 	}
 
 	// Check for usage metric inconsistencies.
@@ -114,6 +185,23 @@ func (a *Adapter) addDiagnostics(record *CostRecord, _ client.CostRow) {
 		a.logWarning(warning, "FOCUS 1.2 field usage_amount missing when usage_unit is present", record)
 	}
 
+	// Check that the service has a FOCUS category.
+	if record.Service != "" && record.ServiceCategory == "" {
+		warning := "unmapped_service_category"
+		diag.AddWarning(warning)
+		a.logWarning(warning, "service has no FOCUS service category; add it to service_categories", record)
+	}
+
+	// Check that the unit price agrees with the cost it prices.
+	if record.EffectiveUnitPrice != nil && record.UsageAmount != nil && record.NetCost != nil {
+		diff := math.Abs(*record.EffectiveUnitPrice**record.UsageAmount - *record.NetCost)
+		if diff > unitPriceSlack && diff > a.fields.priceTolerance()*math.Abs(*record.NetCost) {
+			warning := "unit_price_cost_mismatch"
+			diag.AddWarning(warning)
+			a.logWarning(warning, "effective_unit_price times usage_amount differs from net_cost", record)
+		}
+	}
+
 	// Check for unusual cost values.
 	if record.NetCost != nil && *record.NetCost < 0 {
 		warning := "negative_net_cost"
@@ -127,21 +215,60 @@ func (a *Adapter) addDiagnostics(record *CostRecord, _ client.CostRow) {
 	}
 
 	// Check for resource identification issues.
-	if record.ResourceID == "" && record.Service != "" {
+	if record.ResourceID == "" && record.Service != "" && a.fields.expects(record.Provider, "resource_id") {
 		warning := "missing_resource_id"
 		diag.AddWarning(warning)
 		a.logWarning(warning, "FOCUS 1.2 field resource_id is empty for service", record)
 	}
 
 	// If no diagnostics were added, set to nil.
-	if !diag.HasIssues() {
+	if !diag.HasIssues() && len(diag.SourceInfo) == 0 {
 		record.Diagnostics = nil
+		return
 	}
+	if a.correlationID != "" {
+		diag.SetSourceInfo("correlation_id", a.correlationID)
+	}
+}
+
+// logContext carries the sync's correlation ID to log lines written
+// outside a request's context.
+func (a *Adapter) logContext() context.Context {
+	if a.correlationID == "" {
+		return context.Background()
+	}
+	return client.WithCorrelationID(context.Background(), a.correlationID)
+}
+
+// logUnknownFields logs the response fields of a row the client did not
+// map, once per field name and sync.
+func (a *Adapter) logUnknownFields(extra map[string]interface{}, metricType string) {
+	if a.unknownFields == nil {
+		a.unknownFields = make(map[string]struct{})
+	}
+	var names []string
+	for name := range extra {
+		if _, logged := a.unknownFields[name]; !logged {
+			a.unknownFields[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	slices.Sort(names)
+	a.logger.Info(a.logContext(), "Vantage returned fields this plugin does not map; passing them through in source_info", map[string]interface{}{
+		"adapter":     "vantage",
+		"operation":   "field_validation",
+		"attempt":     0,
+		"metric_type": metricType,
+		"fields":      strings.Join(names, ","),
+	})
 }
 
 // logMissingField logs a missing field diagnostic with structured fields.
 func (a *Adapter) logMissingField(fieldName, reason string, record *CostRecord) {
-	a.logger.Warn(context.TODO(), "Missing field detected", map[string]interface{}{
+	a.logger.Warn(a.logContext(), "Missing field detected", map[string]interface{}{
 		"adapter":   "vantage",
 		"operation": "field_validation",
 		"field":     fieldName,
@@ -154,7 +281,7 @@ func (a *Adapter) logMissingField(fieldName, reason string, record *CostRecord)
 
 // logWarning logs a diagnostic warning with structured fields.
 func (a *Adapter) logWarning(warning, description string, record *CostRecord) {
-	a.logger.Warn(context.TODO(), "Data quality warning", map[string]interface{}{
+	a.logger.Warn(a.logContext(), "Data quality warning", map[string]interface{}{
 		"adapter":     "vantage",
 		"operation":   "data_validation",
 		"warning":     warning,
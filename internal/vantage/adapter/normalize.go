@@ -1,33 +1,65 @@
 package adapter
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 )
 
 // normalizeTags normalizes tag keys and applies filtering.
 func (a *Adapter) normalizeTags(tags map[string]string) map[string]string {
+	normalized, _ := a.normalizeTagsWithRaw(tags)
+	return normalized
+}
+
+// normalizeTagsWithRaw normalizes, rewrites, and filters tags, also
+// returning the kept tags with their original keys when tags.preserve_raw is
+// set.
+func (a *Adapter) normalizeTagsWithRaw(tags map[string]string) (map[string]string, map[string]string) {
 	if tags == nil {
-		return nil
+		return nil, nil
+	}
+
+	// Resolve each tag to its canonical key, keeping the preferred source
+	// when several land on one key.
+	candidates := make(map[string]tagCandidate, len(tags))
+	for key, value := range tags {
+		canonical, c := a.tags.rules.candidate(key, a.normalizeTagKey(key), value)
+		if current, ok := candidates[canonical]; ok && !a.tags.rules.prefer(c, current) {
+			continue
+		}
+		candidates[canonical] = c
 	}
 
 	normalized := make(map[string]string)
+	var raw map[string]string
+	if a.tags.preserveRaw {
+		raw = make(map[string]string)
+	}
 
-	for key, value := range tags {
-		// Normalize key to lower-kebab-case.
-		normalizedKey := a.normalizeTagKey(key)
+	for key, c := range candidates {
+		value := a.tags.rules.value(key, c.value)
 
 		// Apply filters.
-		if a.shouldIncludeTag(normalizedKey, value) {
-			normalized[normalizedKey] = value
+		if a.tags.include(key, value) {
+			normalized[key] = value
+			if raw != nil {
+				raw[c.rawKey] = c.value
+			}
 		}
 	}
 
-	return normalized
+	return normalized, raw
 }
 
 // normalizeTagKey converts tag keys to lower-kebab-case.
 func (a *Adapter) normalizeTagKey(key string) string {
+	return kebabCase(key)
+}
+
+// kebabCase converts a tag key to lower-kebab-case.
+func kebabCase(key string) string {
 	// Convert to lowercase.
 	key = strings.ToLower(key)
 
@@ -46,31 +78,119 @@ func (a *Adapter) normalizeTagKey(key string) string {
 	return key
 }
 
-// shouldIncludeTag determines if a tag should be included based on filters.
-func (a *Adapter) shouldIncludeTag(key, _ string) bool {
-	// Denylist high-cardinality patterns first.
-	denyPatterns := []*regexp.Regexp{
-		regexp.MustCompile(`.*pod.*uid.*`),      // Pod UIDs
-		regexp.MustCompile(`.*container.*id.*`), // Container IDs
-		regexp.MustCompile(`.*node.*name.*`),    // Node names (often high cardinality)
+// tagFilter applies the tags section of the config. Distinct values and
+// dropped keys are tracked for the duration of one sync.
+type tagFilter struct {
+	allow           []*regexp.Regexp
+	deny            []*regexp.Regexp
+	maxValuesPerKey int
+	preserveRaw     bool
+	rules           tagRules
+	values          map[string]map[string]struct{}
+	dropped         map[string]int
+}
+
+// defaultTagFilter returns a filter applying only the default deny patterns.
+func defaultTagFilter() *tagFilter {
+	f, err := newTagFilter(TagConfig{})
+	if err != nil {
+		panic(err) // the default patterns are constants
 	}
+	return f
+}
 
-	for _, pattern := range denyPatterns {
-		if pattern.MatchString(key) {
-			return false
+// newTagFilter compiles cfg into a tagFilter.
+func newTagFilter(cfg TagConfig) (*tagFilter, error) {
+	allow, deny, err := compileTagPatterns(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &tagFilter{
+		allow:           allow,
+		deny:            deny,
+		maxValuesPerKey: cfg.MaxValuesPerKey,
+		preserveRaw:     cfg.PreserveRaw,
+		rules:           newTagRules(cfg),
+		values:          make(map[string]map[string]struct{}),
+		dropped:         make(map[string]int),
+	}, nil
+}
+
+// compileTagPatterns compiles the allow and deny lists, applying the default
+// deny patterns when none are configured.
+func compileTagPatterns(cfg TagConfig) ([]*regexp.Regexp, []*regexp.Regexp, error) {
+	if cfg.MaxValuesPerKey < 0 {
+		return nil, nil, errors.New("tags.max_values_per_key cannot be negative")
+	}
+
+	denyExprs := cfg.Deny
+	if denyExprs == nil {
+		denyExprs = DefaultTagDenyPatterns()
+	}
+
+	allow, err := compilePatterns("tags.allow", cfg.Allow)
+	if err != nil {
+		return nil, nil, err
+	}
+	deny, err := compilePatterns("tags.deny", denyExprs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return allow, deny, nil
+}
+
+// compilePatterns compiles the regular expressions of one config list.
+func compilePatterns(field string, exprs []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(exprs))
+	for i, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s[%d] pattern %q: %w", field, i, expr, err)
 		}
+		compiled = append(compiled, re)
 	}
+	return compiled, nil
+}
 
-	// Default allowlist (can be made configurable).
-	allowPrefixes := []string{"user:", "kubernetes.io/"}
+// include reports whether a normalized tag should be kept, counting the key
+// as dropped when it is not.
+func (f *tagFilter) include(key, value string) bool {
+	if f.excluded(key) {
+		f.dropped[key]++
+		return false
+	}
 
-	// Check allowlist.
-	for _, prefix := range allowPrefixes {
-		if strings.HasPrefix(key, prefix) {
-			return true
+	if f.maxValuesPerKey > 0 {
+		seen := f.values[key]
+		if seen == nil {
+			seen = make(map[string]struct{})
+			f.values[key] = seen
+		}
+		if _, ok := seen[value]; !ok {
+			if len(seen) >= f.maxValuesPerKey {
+				f.dropped[key]++
+				return false
+			}
+			seen[value] = struct{}{}
 		}
 	}
+	return true
+}
 
-	// Allow other tags by default.
+// excluded reports whether key is denied or missing from a non-empty allow list.
+func (f *tagFilter) excluded(key string) bool {
+	for _, pattern := range f.deny {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	if len(f.allow) == 0 {
+		return false
+	}
+	for _, pattern := range f.allow {
+		if pattern.MatchString(key) {
+			return false
+		}
+	}
 	return true
 }
@@ -0,0 +1,51 @@
+package adapter
+
+// LoadOptions selects the configuration to load. Precedence, highest first,
+// is the PULUMICOST_VANTAGE_* environment, Overrides, the profile, and the
+// config file.
+type LoadOptions struct {
+	// Path is the config file; empty reads no file.
+	Path string
+	// Profile is merged over the top-level sections when set.
+	Profile string
+	// Overrides are values given on the command line.
+	Overrides Overrides
+}
+
+// Overrides are config keys set outside the config file, such as by CLI
+// flags, keyed as in the params and sink sections. Each key set replaces the
+// key of the same name.
+type Overrides struct {
+	Params map[string]interface{}
+	Sink   map[string]interface{}
+	Mock   map[string]interface{}
+}
+
+// SetParam sets params.key.
+func (o *Overrides) SetParam(key string, value interface{}) {
+	if o.Params == nil {
+		o.Params = make(map[string]interface{})
+	}
+	o.Params[key] = value
+}
+
+// SetSink sets sink.key.
+func (o *Overrides) SetSink(key string, value interface{}) {
+	if o.Sink == nil {
+		o.Sink = make(map[string]interface{})
+	}
+	o.Sink[key] = value
+}
+
+// SetMock sets mock.key.
+func (o *Overrides) SetMock(key string, value interface{}) {
+	if o.Mock == nil {
+		o.Mock = make(map[string]interface{})
+	}
+	o.Mock[key] = value
+}
+
+// sections returns the overrides as config sections.
+func (o Overrides) sections() rawSections {
+	return rawSections{Params: o.Params, Sink: o.Sink, Mock: o.Mock}
+}
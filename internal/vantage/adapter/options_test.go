@@ -0,0 +1,169 @@
+package adapter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Precedence(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := writeConfigFiles(t, map[string]string{"config.yaml": `
+credentials:
+  token: file-token
+params:
+  cost_report_token: cr_file
+  granularity: day
+  group_bys: [provider]
+  page_size: 100
+  start_date: "2024-01-01"
+sink:
+  path: ./data/file
+profiles:
+  prod:
+    params:
+      cost_report_token: cr_profile
+      page_size: 200
+      metrics: [cost]
+`})
+	configPath := filepath.Join(dir, "config.yaml")
+
+	var overrides Overrides
+	overrides.SetParam("page_size", 300)
+	overrides.SetParam("granularity", "month")
+	overrides.SetParam("group_bys", []string{"service", "region"})
+	overrides.SetSink("path", "./data/flags")
+
+	tests := []struct {
+		name            string
+		opts            LoadOptions
+		env             map[string]string
+		wantReport      string
+		wantGranularity string
+		wantPageSize    int
+		wantGroupBys    []string
+		wantSinkPath    string
+	}{
+		{
+			name:            "file",
+			opts:            LoadOptions{Path: configPath},
+			wantReport:      "cr_file",
+			wantGranularity: "day",
+			wantPageSize:    100,
+			wantGroupBys:    []string{"provider"},
+			wantSinkPath:    "./data/file",
+		},
+		{
+			name:            "profile over file",
+			opts:            LoadOptions{Path: configPath, Profile: "prod"},
+			wantReport:      "cr_profile",
+			wantGranularity: "day",
+			wantPageSize:    200,
+			wantGroupBys:    []string{"provider"},
+			wantSinkPath:    "./data/file",
+		},
+		{
+			name:            "flags over profile",
+			opts:            LoadOptions{Path: configPath, Profile: "prod", Overrides: overrides},
+			wantReport:      "cr_profile",
+			wantGranularity: "month",
+			wantPageSize:    300,
+			wantGroupBys:    []string{"service", "region"},
+			wantSinkPath:    "./data/flags",
+		},
+		{
+			name: "environment over flags",
+			opts: LoadOptions{Path: configPath, Profile: "prod", Overrides: overrides},
+			env: map[string]string{
+				"PULUMICOST_VANTAGE_PAGE_SIZE":   "400",
+				"PULUMICOST_VANTAGE_CR_TOKEN":    "cr_env",
+				"PULUMICOST_VANTAGE_SINK_PATH":   "./data/env",
+				"PULUMICOST_VANTAGE_GRANULARITY": "day",
+			},
+			wantReport:      "cr_env",
+			wantGranularity: "day",
+			wantPageSize:    400,
+			wantGroupBys:    []string{"service", "region"},
+			wantSinkPath:    "./data/env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, "file-token", cfg.Token)
+			assert.Equal(t, tt.wantReport, cfg.CostReportToken)
+			assert.Equal(t, tt.wantGranularity, cfg.Granularity)
+			assert.Equal(t, tt.wantPageSize, cfg.PageSize)
+			assert.Equal(t, tt.wantGroupBys, cfg.GroupBys)
+			assert.Equal(t, tt.wantSinkPath, cfg.Sink.Path)
+		})
+	}
+}
+
+func TestLoad_OverridesDoNotChangeFileConfig(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := writeConfigFiles(t, map[string]string{"config.yaml": `
+credentials:
+  token: file-token
+params:
+  cost_report_token: cr_file
+  granularity: day
+  start_date: "2024-01-01"
+`})
+	configPath := filepath.Join(dir, "config.yaml")
+
+	var overrides Overrides
+	overrides.SetParam("end_date", "2024-02-01")
+	cfg, err := Load(LoadOptions{Path: configPath, Overrides: overrides})
+	require.NoError(t, err)
+	require.NotNil(t, cfg.EndDate)
+	assert.Equal(t, "2024-02-01", cfg.EndDate.Format("2006-01-02"))
+
+	cfg, err = Load(LoadOptions{Path: configPath})
+	require.NoError(t, err)
+	assert.Nil(t, cfg.EndDate)
+}
+
+func TestLoad_MockOverride(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := writeConfigFiles(t, map[string]string{"config.yaml": `
+params:
+  granularity: day
+  start_date: "2024-01-01"
+mock:
+  seed: 42
+  providers: [aws, gcp]
+  rows_per_bucket: 10
+`})
+	configPath := filepath.Join(dir, "config.yaml")
+
+	// Without mock, credentials and a report are still required.
+	_, err := Load(LoadOptions{Path: configPath})
+	require.ErrorContains(t, err, "credentials.token is required")
+
+	var overrides Overrides
+	overrides.SetMock("enabled", true)
+	cfg, err := Load(LoadOptions{Path: configPath, Overrides: overrides})
+	require.NoError(t, err)
+	assert.Equal(t, MockConfig{
+		Enabled:       true,
+		Seed:          42,
+		Providers:     []string{"aws", "gcp"},
+		RowsPerBucket: 10,
+		Seasonality:   defaultMockSeasonality,
+	}, cfg.Mock)
+
+	cfg.Mock.Seasonality = 1.5
+	assert.ErrorContains(t, ValidateConfig(cfg), "mock.seasonality must be between 0 and 1")
+	cfg.Mock.Seasonality = 0
+	cfg.Mock.RowsPerBucket = -1
+	assert.ErrorContains(t, ValidateConfig(cfg), "mock.rows_per_bucket cannot be negative")
+}
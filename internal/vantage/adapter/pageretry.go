@@ -0,0 +1,62 @@
+package adapter
+
+import (
+	"context"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// checkRetriedPage checks a page fetched after a retry continues where the
+// previous page ended, since a cursor Vantage advanced on the failed request
+// can skip or repeat rows. A first row already seen means the page repeats
+// earlier rows; no rows where the previous page promised more means rows
+// were skipped. Either is a warning: repeats are dropped as duplicates, and
+// verify_totals measures what a gap lost.
+func (a *Adapter) checkRetriedPage(
+	ctx context.Context,
+	page client.Page,
+	previous *client.Page,
+	query client.Query,
+	queryHash string,
+	seen *recentIDs,
+) {
+	if page.Attempts <= 1 {
+		return
+	}
+	retried, _ := a.diagnosticsSummary.SourceInfo["retried_pages"].(int)
+	a.diagnosticsSummary.SourceInfo["retried_pages"] = retried + 1
+
+	cursor := query.Cursor
+	if previous != nil {
+		cursor = previous.NextCursor
+	}
+	fields := map[string]interface{}{
+		"adapter":    "vantage",
+		"operation":  "fetch_cost_data",
+		"attempt":    page.Attempts - 1,
+		"cursor":     cursor,
+		"query_hash": queryHash,
+	}
+
+	if len(page.Data) == 0 {
+		if previous != nil && previous.HasMore {
+			a.diagnosticsSummary.Warnings["page_retry_gap"]++
+			a.logger.Warn(ctx, "Retried page returned no rows where more were expected; rows may be missing", fields)
+		}
+		return
+	}
+
+	first := a.lineItemID(page.Data[0], query)
+	if !seen.contains(first) {
+		return
+	}
+	var rows int
+	for _, row := range page.Data {
+		if seen.contains(a.lineItemID(row, query)) {
+			rows++
+		}
+	}
+	a.diagnosticsSummary.Warnings["page_retry_repeat"]++
+	fields["repeated_rows"] = rows
+	a.logger.Warn(ctx, "Retried page repeats rows of earlier pages", fields)
+}
@@ -0,0 +1,124 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func cursorQuery(cursor string) interface{} {
+	return mock.MatchedBy(func(q client.Query) bool { return q.Cursor == cursor })
+}
+
+func TestAdapter_SyncSingleRange_RetriedPageRepeatsRows(t *testing.T) {
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 2)
+	ec2 := client.CostRow{BucketStart: startDate, Provider: "aws", Service: "EC2", Cost: 10}
+	s3 := client.CostRow{BucketStart: startDate, Provider: "aws", Service: "S3", Cost: 5}
+
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.pageRetries = 1
+
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		GroupBys:        []string{"provider", "service"},
+		Metrics:         []string{"cost"},
+		PageSize:        100,
+	}
+
+	// The failed request advanced the cursor, so the retry starts over.
+	mockClient.On("Costs", mock.Anything, cursorQuery("")).
+		Return(client.Page{Data: []client.CostRow{ec2}, NextCursor: "c2", HasMore: true}, nil)
+	mockClient.On("Costs", mock.Anything, cursorQuery("c2")).
+		Return(client.Page{}, errors.New("connection reset")).Once()
+	mockClient.On("Costs", mock.Anything, cursorQuery("c2")).
+		Return(client.Page{Data: []client.CostRow{ec2, s3}}, nil)
+
+	var written []CostRecord
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		written = append(written, args.Get(1).([]CostRecord)...)
+	})
+
+	require.NoError(t, adapter.syncSingleRange(context.Background(), cfg, mockSink, startDate, endDate, true))
+
+	summary := adapter.GetDiagnosticsSummary()
+	assert.Equal(t, 1, summary.SourceInfo["retried_pages"])
+	assert.Equal(t, 1, summary.Warnings["page_retry_repeat"])
+	assert.Equal(t, 1, summary.DuplicateRecords)
+	assert.Len(t, written, 2)
+}
+
+func TestAdapter_CheckRetriedPage(t *testing.T) {
+	row := client.CostRow{BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Service: "EC2", Cost: 10}
+	query := client.Query{CostReportToken: "cr_test", Metrics: []string{"cost"}}
+	seenRow := newRecentIDs(dedupeWindowPages)
+	seenRow.add(GenerateLineItemID("cr_test", row, query.Metrics))
+	more := &client.Page{NextCursor: "c2", HasMore: true}
+
+	tests := []struct {
+		name     string
+		page     client.Page
+		previous *client.Page
+		seen     *recentIDs
+		warning  string
+		retried  interface{}
+	}{
+		{
+			name:     "first attempt is not checked",
+			page:     client.Page{Attempts: 1},
+			previous: more,
+		},
+		{
+			name:     "continues with new rows",
+			page:     client.Page{Data: []client.CostRow{row}, Attempts: 2},
+			previous: more,
+			seen:     newRecentIDs(dedupeWindowPages),
+			retried:  1,
+		},
+		{
+			name:     "repeats a seen row",
+			page:     client.Page{Data: []client.CostRow{row}, Attempts: 2},
+			previous: more,
+			seen:     seenRow,
+			warning:  "page_retry_repeat",
+			retried:  1,
+		},
+		{
+			name:     "no rows where more were promised",
+			page:     client.Page{Attempts: 3},
+			previous: more,
+			warning:  "page_retry_gap",
+			retried:  1,
+		},
+		{
+			name:    "empty first page",
+			page:    client.Page{Attempts: 2},
+			retried: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := New(&mockClient{}, client.NewNoopLogger())
+			adapter.checkRetriedPage(context.Background(), tt.page, tt.previous, query, "hash", tt.seen)
+
+			summary := adapter.GetDiagnosticsSummary()
+			assert.Equal(t, tt.retried, summary.SourceInfo["retried_pages"])
+			if tt.warning == "" {
+				assert.Empty(t, summary.Warnings)
+				return
+			}
+			assert.Equal(t, map[string]int{tt.warning: 1}, summary.Warnings)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package adapter
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// rawProfile is one entry of the top-level profiles section. Each section
+// present is merged key by key over the matching top-level section; list
+// sections such as transforms and allocation_rules replace the top-level list.
+// Extends names another profile whose sections are merged in first.
+type rawProfile struct {
+	rawSections `mapstructure:",squash"`
+
+	Extends string `yaml:"extends"`
+}
+
+// ListProfiles returns the profile names defined in the config file, sorted.
+// Names are lower-cased, since config keys are case-insensitive.
+func ListProfiles(filePath string) ([]string, error) {
+	raw, err := readRawConfig(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(raw.Profiles)), nil
+}
+
+// readProfile reads the config file and, when a profile is set, merges that
+// profile over the top-level sections. The command-line overrides and then
+// the environment are applied last, so precedence is environment > flags >
+// file.
+func readProfile(opts LoadOptions) (*rawConfig, error) {
+	raw, err := readRawConfig(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &rawConfig{rawSections: raw.rawSections}
+	if opts.Profile != "" {
+		name := strings.ToLower(opts.Profile)
+		p, err := resolveProfile(raw.Profiles, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		merged.rawSections = mergeSections(raw.rawSections, p.rawSections)
+		merged.profile = name
+		merged.profileCredentials = len(p.Credentials) > 0
+	}
+
+	merged.rawSections = mergeSections(merged.rawSections, opts.Overrides.sections())
+	applyEnv(merged, os.Environ())
+	return merged, nil
+}
+
+// resolveProfile returns the named profile with the profiles it extends
+// merged in. chain holds the profiles already being resolved, to report
+// cycles.
+func resolveProfile(profiles map[string]rawProfile, name string, chain []string) (rawProfile, error) {
+	if slices.Contains(chain, name) {
+		return rawProfile{}, fmt.Errorf("profile extends cycle: %s", strings.Join(append(chain, name), " -> "))
+	}
+
+	p, ok := profiles[name]
+	if !ok {
+		if len(chain) > 0 {
+			return rawProfile{}, fmt.Errorf("profile %q extends unknown profile %q", chain[len(chain)-1], name)
+		}
+		available := slices.Sorted(maps.Keys(profiles))
+		if len(available) == 0 {
+			return rawProfile{}, fmt.Errorf("profile %q not found: config defines no profiles", name)
+		}
+		return rawProfile{}, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(available, ", "))
+	}
+	if p.Extends == "" {
+		return p, nil
+	}
+
+	parent, err := resolveProfile(profiles, strings.ToLower(p.Extends), append(chain, name))
+	if err != nil {
+		return rawProfile{}, err
+	}
+	return rawProfile{rawSections: mergeSections(parent.rawSections, p.rawSections)}, nil
+}
+
+// mergeSections returns base overlaid with override section by section.
+func mergeSections(base, override rawSections) rawSections {
+	return rawSections{
+		Credentials: mergeSection(base.Credentials, override.Credentials),
+		Params:      mergeSection(base.Params, override.Params),
+		Sink:        mergeSection(base.Sink, override.Sink),
+		Bookmarks:   mergeSection(base.Bookmarks, override.Bookmarks),
+		Lock:        mergeSection(base.Lock, override.Lock),
+		Cache:       mergeSection(base.Cache, override.Cache),
+		Tags:        mergeSection(base.Tags, override.Tags),
+		Tracing:     mergeSection(base.Tracing, override.Tracing),
+		Transforms:  mergeList(base.Transforms, override.Transforms),
+		Allocations: mergeList(base.Allocations, override.Allocations),
+		Alerts:      mergeSection(base.Alerts, override.Alerts),
+		Diagnostics: mergeSection(base.Diagnostics, override.Diagnostics),
+		Serve:       mergeSection(base.Serve, override.Serve),
+		Mock:        mergeSection(base.Mock, override.Mock),
+		DeadLetter:  mergeSection(base.DeadLetter, override.DeadLetter),
+	}
+}
+
+// mergeSection returns base overlaid with override, sharing neither map.
+func mergeSection(base, override map[string]interface{}) map[string]interface{} {
+	if base == nil && override == nil {
+		return nil
+	}
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]interface{}, len(override))
+	}
+	maps.Copy(merged, override)
+	return merged
+}
+
+// mergeList returns override when the profile sets the list, else base.
+func mergeList(base, override []map[string]interface{}) []map[string]interface{} {
+	if override != nil {
+		return override
+	}
+	return base
+}
@@ -0,0 +1,91 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesConfig = `
+credentials:
+  token: base-token
+params:
+  cost_report_token: cr_base
+  granularity: day
+  page_size: 500
+sink:
+  path: ./data
+profiles:
+  Prod:
+    credentials:
+      token_env: VANTAGE_PROD_TOKEN
+    params:
+      cost_report_token: cr_prod
+    sink:
+      path: ./data/prod
+  staging:
+    params:
+      cost_report_token: cr_staging
+`
+
+func writeProfilesConfig(t *testing.T) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(profilesConfig), 0600))
+	return configPath
+}
+
+func TestListProfiles(t *testing.T) {
+	profiles, err := ListProfiles(writeProfilesConfig(t))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod", "staging"}, profiles)
+}
+
+func TestLoadProfileConfig_MergesOverTopLevel(t *testing.T) {
+	t.Setenv("VANTAGE_PROD_TOKEN", "prod-token")
+	configPath := writeProfilesConfig(t)
+
+	cfg, err := LoadProfileConfig(configPath, "PROD")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cfg.Profile)
+	assert.Equal(t, "prod-token", cfg.Token)
+	assert.Equal(t, "cr_prod", cfg.CostReportToken)
+	assert.Equal(t, 500, cfg.PageSize, "unset params come from the top level")
+	assert.Equal(t, "./data/prod", cfg.Sink.Path)
+	assert.Equal(t, filepath.Join("./data/prod", "bookmarks.json"), cfg.Bookmarks.Path)
+
+	// The top level is unaffected by profile merges.
+	base, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, base.Profile)
+	assert.Equal(t, "cr_base", base.CostReportToken)
+	assert.Equal(t, "./data", base.Sink.Path)
+}
+
+func TestLoadProfileConfig_GlobalTokenOverride(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "env-token")
+	t.Setenv("VANTAGE_PROD_TOKEN", "prod-token")
+	configPath := writeProfilesConfig(t)
+
+	// Profiles with their own credentials ignore the global override.
+	prod, err := LoadProfileConfig(configPath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-token", prod.Token)
+
+	// Profiles inheriting credentials keep the usual override.
+	staging, err := LoadProfileConfig(configPath, "staging")
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", staging.Token)
+
+	token, err := LoadProfileToken(configPath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-token", token)
+}
+
+func TestLoadProfileConfig_UnknownProfile(t *testing.T) {
+	_, err := LoadProfileConfig(writeProfilesConfig(t), "dev")
+	require.ErrorContains(t, err, "available: prod, staging")
+}
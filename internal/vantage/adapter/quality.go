@@ -0,0 +1,127 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Quality score weights: the most each kind of issue can take off 100.
+const (
+	qualityWeightCritical    = 45
+	qualityWeightOther       = 15
+	qualityWeightWarnings    = 20
+	qualityWeightUnallocated = 20
+)
+
+// qualityHistoryLimit is how many runs the quality history keeps.
+const qualityHistoryLimit = 30
+
+// criticalFields are the fields a record is unusable without.
+var criticalFields = []string{"provider", "service", "net_cost", "currency"}
+
+// QualityRun is the data quality of one successful sync.
+type QualityRun struct {
+	At      time.Time `json:"at"`
+	Score   float64   `json:"score"`
+	Records int       `json:"records"`
+}
+
+// ComputeQualityScore rates the summary from 0 to 100. Missing critical
+// fields, other missing fields, warnings, and the unallocated share each
+// take off up to their weight, in proportion to how many records they
+// affect. A summary without records scores 100.
+func (ds *DiagnosticsSummary) ComputeQualityScore() float64 {
+	if ds.TotalRecords == 0 {
+		return 100
+	}
+
+	var critical, other, warnings int
+	for field, count := range ds.MissingFields {
+		if isCriticalField(field) {
+			critical += count
+		} else {
+			other += count
+		}
+	}
+	for _, count := range ds.Warnings {
+		warnings += count
+	}
+	share := func(count int) float64 {
+		return math.Min(1, float64(count)/float64(ds.TotalRecords))
+	}
+
+	score := 100 -
+		qualityWeightCritical*share(critical) -
+		qualityWeightOther*share(other) -
+		qualityWeightWarnings*share(warnings)
+	if ds.UnallocatedShare != nil {
+		score -= qualityWeightUnallocated * math.Min(1, math.Max(0, *ds.UnallocatedShare))
+	}
+	return math.Round(score*10) / 10
+}
+
+// isCriticalField reports whether field is one of criticalFields.
+func isCriticalField(field string) bool {
+	for _, critical := range criticalFields {
+		if field == critical {
+			return true
+		}
+	}
+	return false
+}
+
+// QualityHistoryKey builds the bookmark key holding the quality history of
+// cfg's report.
+func QualityHistoryKey(cfg Config) string {
+	return "vantage_quality_" + (&Adapter{}).reportQueryHash(cfg)
+}
+
+// ReadQualityHistory returns the quality runs saved under key, oldest
+// first.
+func ReadQualityHistory(ctx context.Context, store BookmarkStore, key string) ([]QualityRun, error) {
+	value, err := store.GetBookmark(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("reading quality history: %w", err)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	var runs []QualityRun
+	if err := json.Unmarshal([]byte(value), &runs); err != nil {
+		return nil, fmt.Errorf("decoding quality history: %w", err)
+	}
+	return runs, nil
+}
+
+// recordQuality scores the sync and appends the score to the report's
+// quality history, keeping the last qualityHistoryLimit runs. Failing to
+// save the history is logged and does not fail the sync.
+func (a *Adapter) recordQuality(ctx context.Context, cfg Config, sink Sink, now time.Time) {
+	score := a.diagnosticsSummary.ComputeQualityScore()
+	a.diagnosticsSummary.QualityScore = &score
+
+	store := a.bookmarkStore(sink)
+	key := QualityHistoryKey(cfg)
+	runs, err := ReadQualityHistory(ctx, store, key)
+	if err == nil {
+		runs = append(runs, QualityRun{At: now.UTC(), Score: score, Records: a.diagnosticsSummary.TotalRecords})
+		if len(runs) > qualityHistoryLimit {
+			runs = runs[len(runs)-qualityHistoryLimit:]
+		}
+		var value []byte
+		if value, err = json.Marshal(runs); err == nil {
+			err = store.SetBookmark(ctx, key, string(value))
+		}
+	}
+	if err != nil {
+		a.logger.Warn(ctx, "Failed to save data quality history", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "quality_history",
+			"attempt":   0,
+			"error":     err,
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestDiagnosticsSummary_ComputeQualityScore(t *testing.T) {
+	assert.InDelta(t, 100.0, NewDiagnosticsSummary().ComputeQualityScore(), 1e-9)
+
+	summary := NewDiagnosticsSummary()
+	summary.TotalRecords = 10
+	summary.MissingFields["service"] = 2
+	summary.MissingFields["region"] = 5
+	summary.Warnings["missing_resource_id"] = 3
+	share := 0.25
+	summary.UnallocatedShare = &share
+
+	// 100 - 45*0.2 - 15*0.5 - 20*0.3 - 20*0.25
+	assert.InDelta(t, 72.5, summary.ComputeQualityScore(), 1e-9)
+
+	summary.MissingFields["net_cost"] = 40
+	assert.InDelta(t, 100.0-45-7.5-6-5, summary.ComputeQualityScore(), 1e-9, "each penalty is capped at its weight")
+}
+
+func TestAdapter_RecordQuality_KeepsHistory(t *testing.T) {
+	store := bookmark.NewMemory()
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	adapter.SetBookmarkStore(store)
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day"}
+	key := QualityHistoryKey(cfg)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < qualityHistoryLimit+2; i++ {
+		adapter.ResetDiagnosticsSummary()
+		adapter.diagnosticsSummary.TotalRecords = 10
+		adapter.diagnosticsSummary.MissingFields["region"] = i % 2
+		adapter.recordQuality(context.Background(), cfg, nil, start.AddDate(0, 0, i))
+	}
+
+	require.NotNil(t, adapter.GetDiagnosticsSummary().QualityScore)
+	assert.InDelta(t, 98.5, *adapter.GetDiagnosticsSummary().QualityScore, 1e-9)
+
+	runs, err := ReadQualityHistory(context.Background(), store, key)
+	require.NoError(t, err)
+	require.Len(t, runs, qualityHistoryLimit)
+	assert.Equal(t, start.AddDate(0, 0, 2), runs[0].At, "the oldest runs are dropped")
+	assert.Equal(t, QualityRun{At: start.AddDate(0, 0, qualityHistoryLimit+1), Score: 98.5, Records: 10}, runs[len(runs)-1])
+
+	require.NoError(t, store.SetBookmark(context.Background(), key, "not json"))
+	_, err = ReadQualityHistory(context.Background(), store, key)
+	require.ErrorContains(t, err, "decoding quality history")
+}
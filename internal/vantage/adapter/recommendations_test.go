@@ -0,0 +1,81 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestAdapter_mapRecommendationToCostRecord(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	snapshot := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	record := adapter.mapRecommendationToCostRecord(client.Recommendation{
+		Token:             "rcmmndtn_1",
+		Category:          "aws:ec2:rightsizing",
+		Provider:          "aws",
+		ProviderAccountID: "123456789012",
+		Service:           "EC2",
+		ResourceID:        "i-0abc",
+		Description:       "Downsize m5.2xlarge to m5.xlarge",
+		PotentialSavings:  142.5,
+		Currency:          "USD",
+	}, snapshot)
+
+	assert.Equal(t, "recommendation", record.MetricType)
+	assert.Equal(t, snapshot, record.Timestamp)
+	assert.Equal(t, "aws", record.Provider)
+	assert.Equal(t, "123456789012", record.AccountID)
+	assert.Equal(t, "i-0abc", record.ResourceID)
+	assert.Equal(t, "aws:ec2:rightsizing", record.RecommendationCategory)
+	require.NotNil(t, record.EstimatedMonthlySavings)
+	assert.InEpsilon(t, 142.5, *record.EstimatedMonthlySavings, 0.001)
+	assert.Equal(t, generateRecommendationLineItemID("rcmmndtn_1"), record.LineItemID)
+}
+
+func TestAdapter_SyncRecommendations_PerCategory(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	cfg := Config{WorkspaceToken: "wrkspc_1"}
+
+	mockClient.On("Recommendations", mock.Anything, client.RecommendationQuery{
+		WorkspaceToken: "wrkspc_1",
+		Category:       client.RecommendationCategoryRightsizing,
+	}).Return([]client.Recommendation{{Token: "r1"}, {Token: "r2"}}, nil)
+	mockClient.On("Recommendations", mock.Anything, client.RecommendationQuery{
+		WorkspaceToken: "wrkspc_1",
+		Category:       client.RecommendationCategorySavingsPlans,
+	}).Return([]client.Recommendation(nil), nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil).Once()
+
+	written, err := adapter.SyncRecommendations(context.Background(), cfg, mockSink, []string{
+		client.RecommendationCategoryRightsizing,
+		client.RecommendationCategorySavingsPlans,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+	assert.Len(t, mockSink.records, 2)
+	mockClient.AssertExpectations(t)
+	mockSink.AssertExpectations(t)
+}
+
+func TestAdapter_SyncRecommendations_Error(t *testing.T) {
+	mockClient := &mockClient{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	mockClient.On("Recommendations", mock.Anything, client.RecommendationQuery{}).
+		Return([]client.Recommendation(nil), errors.New("forbidden"))
+
+	_, err := adapter.SyncRecommendations(context.Background(), Config{}, &mockSink{}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fetching recommendations")
+}
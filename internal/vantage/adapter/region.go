@@ -0,0 +1,142 @@
+package adapter
+
+import (
+	"strings"
+)
+
+// Continents a region's geography can report.
+const (
+	ContinentAfrica       = "Africa"
+	ContinentAsia         = "Asia"
+	ContinentEurope       = "Europe"
+	ContinentNorthAmerica = "North America"
+	ContinentOceania      = "Oceania"
+	ContinentSouthAmerica = "South America"
+)
+
+// regionInfo is one provider region: its canonical ID, the display names
+// providers also report it by, and where it is. Country is an ISO 3166-1
+// alpha-2 code.
+type regionInfo struct {
+	provider  string
+	id        string
+	names     []string
+	continent string
+	country   string
+}
+
+// regionTable lists the regions of the major providers.
+var regionTable = []regionInfo{
+	{"aws", "us-east-1", []string{"US East (N. Virginia)"}, ContinentNorthAmerica, "US"},
+	{"aws", "us-east-2", []string{"US East (Ohio)"}, ContinentNorthAmerica, "US"},
+	{"aws", "us-west-1", []string{"US West (N. California)"}, ContinentNorthAmerica, "US"},
+	{"aws", "us-west-2", []string{"US West (Oregon)"}, ContinentNorthAmerica, "US"},
+	{"aws", "ca-central-1", []string{"Canada (Central)"}, ContinentNorthAmerica, "CA"},
+	{"aws", "sa-east-1", []string{"South America (Sao Paulo)", "South America (São Paulo)"}, ContinentSouthAmerica, "BR"},
+	{"aws", "eu-west-1", []string{"EU (Ireland)", "Europe (Ireland)"}, ContinentEurope, "IE"},
+	{"aws", "eu-west-2", []string{"EU (London)", "Europe (London)"}, ContinentEurope, "GB"},
+	{"aws", "eu-west-3", []string{"EU (Paris)", "Europe (Paris)"}, ContinentEurope, "FR"},
+	{"aws", "eu-central-1", []string{"EU (Frankfurt)", "Europe (Frankfurt)"}, ContinentEurope, "DE"},
+	{"aws", "eu-north-1", []string{"EU (Stockholm)", "Europe (Stockholm)"}, ContinentEurope, "SE"},
+	{"aws", "eu-south-1", []string{"EU (Milan)", "Europe (Milan)"}, ContinentEurope, "IT"},
+	{"aws", "ap-northeast-1", []string{"Asia Pacific (Tokyo)"}, ContinentAsia, "JP"},
+	{"aws", "ap-northeast-2", []string{"Asia Pacific (Seoul)"}, ContinentAsia, "KR"},
+	{"aws", "ap-northeast-3", []string{"Asia Pacific (Osaka)"}, ContinentAsia, "JP"},
+	{"aws", "ap-southeast-1", []string{"Asia Pacific (Singapore)"}, ContinentAsia, "SG"},
+	{"aws", "ap-southeast-2", []string{"Asia Pacific (Sydney)"}, ContinentOceania, "AU"},
+	{"aws", "ap-south-1", []string{"Asia Pacific (Mumbai)"}, ContinentAsia, "IN"},
+	{"aws", "ap-east-1", []string{"Asia Pacific (Hong Kong)"}, ContinentAsia, "HK"},
+	{"aws", "me-south-1", []string{"Middle East (Bahrain)"}, ContinentAsia, "BH"},
+	{"aws", "af-south-1", []string{"Africa (Cape Town)"}, ContinentAfrica, "ZA"},
+
+	{"azure", "eastus", []string{"East US"}, ContinentNorthAmerica, "US"},
+	{"azure", "eastus2", []string{"East US 2"}, ContinentNorthAmerica, "US"},
+	{"azure", "centralus", []string{"Central US"}, ContinentNorthAmerica, "US"},
+	{"azure", "northcentralus", []string{"North Central US"}, ContinentNorthAmerica, "US"},
+	{"azure", "southcentralus", []string{"South Central US"}, ContinentNorthAmerica, "US"},
+	{"azure", "westus", []string{"West US"}, ContinentNorthAmerica, "US"},
+	{"azure", "westus2", []string{"West US 2"}, ContinentNorthAmerica, "US"},
+	{"azure", "westus3", []string{"West US 3"}, ContinentNorthAmerica, "US"},
+	{"azure", "canadacentral", []string{"Canada Central"}, ContinentNorthAmerica, "CA"},
+	{"azure", "brazilsouth", []string{"Brazil South"}, ContinentSouthAmerica, "BR"},
+	{"azure", "northeurope", []string{"North Europe"}, ContinentEurope, "IE"},
+	{"azure", "westeurope", []string{"West Europe"}, ContinentEurope, "NL"},
+	{"azure", "uksouth", []string{"UK South"}, ContinentEurope, "GB"},
+	{"azure", "francecentral", []string{"France Central"}, ContinentEurope, "FR"},
+	{"azure", "germanywestcentral", []string{"Germany West Central"}, ContinentEurope, "DE"},
+	{"azure", "swedencentral", []string{"Sweden Central"}, ContinentEurope, "SE"},
+	{"azure", "japaneast", []string{"Japan East"}, ContinentAsia, "JP"},
+	{"azure", "koreacentral", []string{"Korea Central"}, ContinentAsia, "KR"},
+	{"azure", "southeastasia", []string{"Southeast Asia"}, ContinentAsia, "SG"},
+	{"azure", "eastasia", []string{"East Asia"}, ContinentAsia, "HK"},
+	{"azure", "centralindia", []string{"Central India"}, ContinentAsia, "IN"},
+	{"azure", "australiaeast", []string{"Australia East"}, ContinentOceania, "AU"},
+	{"azure", "uaenorth", []string{"UAE North"}, ContinentAsia, "AE"},
+	{"azure", "southafricanorth", []string{"South Africa North"}, ContinentAfrica, "ZA"},
+
+	{"gcp", "us-central1", []string{"Iowa"}, ContinentNorthAmerica, "US"},
+	{"gcp", "us-east1", []string{"South Carolina"}, ContinentNorthAmerica, "US"},
+	{"gcp", "us-east4", []string{"Northern Virginia"}, ContinentNorthAmerica, "US"},
+	{"gcp", "us-west1", []string{"Oregon"}, ContinentNorthAmerica, "US"},
+	{"gcp", "us-west2", []string{"Los Angeles"}, ContinentNorthAmerica, "US"},
+	{"gcp", "northamerica-northeast1", []string{"Montreal", "Montréal"}, ContinentNorthAmerica, "CA"},
+	{"gcp", "southamerica-east1", []string{"Sao Paulo", "São Paulo"}, ContinentSouthAmerica, "BR"},
+	{"gcp", "europe-west1", []string{"Belgium"}, ContinentEurope, "BE"},
+	{"gcp", "europe-west2", []string{"London"}, ContinentEurope, "GB"},
+	{"gcp", "europe-west3", []string{"Frankfurt"}, ContinentEurope, "DE"},
+	{"gcp", "europe-west4", []string{"Netherlands"}, ContinentEurope, "NL"},
+	{"gcp", "europe-north1", []string{"Finland"}, ContinentEurope, "FI"},
+	{"gcp", "asia-east1", []string{"Taiwan"}, ContinentAsia, "TW"},
+	{"gcp", "asia-northeast1", []string{"Tokyo"}, ContinentAsia, "JP"},
+	{"gcp", "asia-southeast1", []string{"Singapore"}, ContinentAsia, "SG"},
+	{"gcp", "asia-south1", []string{"Mumbai"}, ContinentAsia, "IN"},
+	{"gcp", "australia-southeast1", []string{"Sydney"}, ContinentOceania, "AU"},
+	{"gcp", "me-west1", []string{"Tel Aviv"}, ContinentAsia, "IL"},
+	{"gcp", "africa-south1", []string{"Johannesburg"}, ContinentAfrica, "ZA"},
+}
+
+// regionIndex maps a lower-cased provider and region ID or display name to
+// its regionTable entry.
+var regionIndex = buildRegionIndex()
+
+// buildRegionIndex indexes regionTable by ID and every display name.
+func buildRegionIndex() map[string]map[string]*regionInfo {
+	index := make(map[string]map[string]*regionInfo)
+	for i := range regionTable {
+		region := &regionTable[i]
+		if index[region.provider] == nil {
+			index[region.provider] = make(map[string]*regionInfo)
+		}
+		index[region.provider][region.id] = region
+		for _, name := range region.names {
+			index[region.provider][strings.ToLower(name)] = region
+		}
+	}
+	return index
+}
+
+// lookupRegion returns the regionTable entry of region as reported by
+// provider, matching IDs and display names case-insensitively, or nil when
+// the table does not list it.
+func lookupRegion(provider, region string) *regionInfo {
+	return regionIndex[strings.ToLower(provider)][strings.ToLower(strings.TrimSpace(region))]
+}
+
+// canonicalRegion returns the canonical ID of region as reported by
+// provider, so "US East (N. Virginia)" and "us-east-1" group together.
+// Regions the table does not list are returned unchanged.
+func canonicalRegion(provider, region string) string {
+	if info := lookupRegion(provider, region); info != nil {
+		return info.id
+	}
+	return region
+}
+
+// applyRegionGeography sets record's region continent and country from
+// the region table, leaving them empty for unlisted regions.
+func applyRegionGeography(record *CostRecord) {
+	if info := lookupRegion(record.Provider, record.Region); info != nil {
+		record.RegionContinent = info.continent
+		record.RegionCountry = info.country
+	}
+}
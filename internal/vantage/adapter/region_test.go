@@ -0,0 +1,105 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestCanonicalRegion(t *testing.T) {
+	tests := []struct {
+		provider, region, want string
+	}{
+		{"aws", "US East (N. Virginia)", "us-east-1"},
+		{"AWS", "us-east-1", "us-east-1"},
+		{"aws", "EU (Frankfurt)", "eu-central-1"},
+		{"azure", "East US 2", "eastus2"},
+		{"azure", "WESTEUROPE", "westeurope"},
+		{"gcp", "Iowa", "us-central1"},
+		{"gcp", " us-central1 ", "us-central1"},
+		{"aws", "Iowa", "Iowa"},
+		{"aws", "global", "global"},
+		{"datadog", "", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, canonicalRegion(tt.provider, tt.region), "%s %q", tt.provider, tt.region)
+	}
+}
+
+func TestRegionTable_IDsAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, region := range regionTable {
+		key := region.provider + "/" + region.id
+		assert.False(t, seen[key], "duplicate region %s", key)
+		seen[key] = true
+		assert.NotEmpty(t, region.continent, key)
+		assert.Len(t, region.country, 2, key)
+	}
+}
+
+func TestAdapter_mapVantageRowToCostRecord_RegionGeography(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day"}
+	row := client.CostRow{Provider: "aws", Service: "EC2", Region: "Europe (Ireland)", Cost: 1}
+
+	record := adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Equal(t, "eu-west-1", record.Region)
+	assert.Empty(t, record.RegionContinent, "geography is opt-in")
+
+	adapter.regionGeography = true
+	record = adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Equal(t, ContinentEurope, record.RegionContinent)
+	assert.Equal(t, "IE", record.RegionCountry)
+
+	row.Region = "mars-north-1"
+	record = adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Equal(t, "mars-north-1", record.Region)
+	assert.Empty(t, record.RegionContinent)
+	assert.Empty(t, record.RegionCountry)
+}
+
+func TestAdapter_mapVantageRowToCostRecord_LineItemHash(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day", Metrics: []string{"cost"}}
+	row := client.CostRow{Provider: "aws", Service: "EC2", Cost: 1}
+
+	record := adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Equal(t, GenerateLineItemID("cr_test", row, query.Metrics), record.LineItemID)
+	assert.Empty(t, record.LineItemHash)
+
+	adapter.lineItemHash = HashSHA256
+	assert.Empty(t, adapter.mapVantageRowToCostRecord(row, query, "hash", "cost").LineItemHash,
+		"sha256 records keep their format")
+
+	adapter.lineItemHash = HashBLAKE3
+	record = adapter.mapVantageRowToCostRecord(row, query, "hash", "cost")
+	assert.Equal(t, GenerateLineItemIDWithHash(HashBLAKE3, "cr_test", row, query.Metrics), record.LineItemID)
+	assert.Equal(t, HashBLAKE3, record.LineItemHash)
+}
+
+func TestAggregator_KeepsGeographyApart(t *testing.T) {
+	query := client.Query{CostReportToken: "cr_test", Metrics: []string{"cost"}}
+	g := newAggregator("", []string{dimensionRegion}, query, "hash")
+
+	cost := func(v float64) *float64 { return &v }
+	for _, r := range []struct{ region, continent, country string }{
+		{"eu-west-1", ContinentEurope, "IE"},
+		{"northeurope", ContinentEurope, "IE"},
+		{"us-east-1", ContinentNorthAmerica, "US"},
+	} {
+		g.add(CostRecord{
+			Provider: "aws", Service: "EC2", Region: r.region, RegionContinent: r.continent, RegionCountry: r.country,
+			NetCost: cost(1), MetricType: "cost",
+		})
+	}
+
+	records := g.records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "IE", records[0].RegionCountry)
+	assert.InDelta(t, 2.0, *records[0].NetCost, 1e-9)
+	assert.Empty(t, records[0].Region)
+	assert.Equal(t, "US", records[1].RegionCountry)
+}
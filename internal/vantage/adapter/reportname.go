@@ -0,0 +1,74 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// ResolveCostReportName returns the token of the cost report titled name,
+// compared case-insensitively, among the reports in workspaceToken (or all
+// visible reports when workspaceToken is empty). It fails when no report or
+// more than one report has that title.
+func ResolveCostReportName(ctx context.Context, c client.Client, workspaceToken, name string) (string, error) {
+	reports, err := c.ListCostReports(ctx, workspaceToken)
+	if err != nil {
+		return "", fmt.Errorf("listing cost reports to resolve %q: %w", name, err)
+	}
+
+	want := strings.TrimSpace(name)
+	var matches []string
+	for _, report := range reports {
+		if strings.EqualFold(strings.TrimSpace(report.Title), want) {
+			matches = append(matches, report.Token)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no cost report named %q", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("cost report name %q is ambiguous (matches %s); set cost_report_token instead",
+			name, strings.Join(matches, ", "))
+	}
+}
+
+// resolveCostReport sets cfg.CostReportToken from cfg.CostReportName when
+// only the name is configured. Resolved names are cached for the lifetime
+// of the adapter, so syncing several ranges or profiles lists reports once.
+func (a *Adapter) resolveCostReport(ctx context.Context, cfg *Config) error {
+	if cfg.CostReportName == "" || cfg.CostReportToken != "" {
+		return nil
+	}
+
+	key := cfg.WorkspaceToken + "/" + strings.ToLower(strings.TrimSpace(cfg.CostReportName))
+	token, ok := a.reportTokens[key]
+	if !ok {
+		var err error
+		token, err = ResolveCostReportName(ctx, a.client, cfg.WorkspaceToken, cfg.CostReportName)
+		if err != nil {
+			return err
+		}
+		if a.reportTokens == nil {
+			a.reportTokens = make(map[string]string)
+			a.reportNames = make(map[string]string)
+		}
+		a.reportTokens[key] = token
+		a.reportNames[token] = cfg.CostReportName
+
+		a.logger.Info(ctx, "Resolved cost report name", map[string]interface{}{
+			"adapter":      "vantage",
+			"operation":    "resolve_cost_report",
+			"attempt":      0,
+			"report_name":  cfg.CostReportName,
+			"report_token": token,
+		})
+	}
+
+	cfg.CostReportToken = token
+	return nil
+}
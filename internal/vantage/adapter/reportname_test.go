@@ -0,0 +1,70 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestResolveCostReportName(t *testing.T) {
+	mockClient := &mockClient{}
+	mockClient.On("ListCostReports", mock.Anything, "wrkspc_test").Return([]client.CostReport{
+		{Token: "cr_eng", Title: "Engineering Monthly"},
+		{Token: "cr_ops_1", Title: "Ops"},
+		{Token: "cr_ops_2", Title: "ops"},
+	}, nil)
+	ctx := context.Background()
+
+	token, err := ResolveCostReportName(ctx, mockClient, "wrkspc_test", " engineering monthly ")
+	require.NoError(t, err)
+	assert.Equal(t, "cr_eng", token)
+
+	_, err = ResolveCostReportName(ctx, mockClient, "wrkspc_test", "Finance")
+	require.EqualError(t, err, `no cost report named "Finance"`)
+
+	_, err = ResolveCostReportName(ctx, mockClient, "wrkspc_test", "Ops")
+	require.ErrorContains(t, err, `cost report name "Ops" is ambiguous (matches cr_ops_1, cr_ops_2)`)
+}
+
+func TestAdapter_Sync_CostReportName(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		WorkspaceToken: "wrkspc_test",
+		CostReportName: "Engineering Monthly",
+		Granularity:    "day",
+		StartDate:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:        &endDate,
+		PageSize:       100,
+	}
+
+	mockClient.On("ListCostReports", mock.Anything, "wrkspc_test").Return([]client.CostReport{
+		{Token: "cr_eng", Title: "Engineering Monthly"},
+	}, nil).Once()
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.CostReportToken == "cr_eng"
+	})).Return(client.Page{Data: []client.CostRow{
+		{Provider: "aws", Service: "EC2", Cost: 10, BucketStart: cfg.StartDate},
+	}}, nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// The second sync reuses the cached resolution.
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+
+	mockClient.AssertExpectations(t)
+	require.NotEmpty(t, mockSink.records)
+	assert.Equal(t, "cr_eng", mockSink.records[0].SourceReportToken)
+	assert.Equal(t, "Engineering Monthly", mockSink.records[0].SourceReportName)
+}
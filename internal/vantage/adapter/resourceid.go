@@ -0,0 +1,156 @@
+package adapter
+
+import (
+	"slices"
+	"strings"
+)
+
+// ResourceRef is the structured form of a provider resource ID: the
+// resource's type, its name, and the account (AWS account, Azure
+// subscription, or GCP project) it lives in.
+type ResourceRef struct {
+	// Type is "<service>:<type>" for AWS ("ec2:instance"),
+	// "<namespace>/<type>" for Azure ("Microsoft.Compute/virtualMachines"),
+	// and "<service>/<collection>" for GCP ("compute/instances").
+	Type    string
+	Name    string
+	Account string
+}
+
+// ParseResourceID parses an AWS ARN, an Azure resource ID, or a GCP self-link
+// or full resource name. It reports false for IDs in none of those forms,
+// such as bare EC2 instance IDs.
+func ParseResourceID(id string) (ResourceRef, bool) {
+	id = strings.TrimSpace(id)
+	switch {
+	case strings.HasPrefix(id, "arn:"):
+		return parseARN(id)
+	case strings.HasPrefix(strings.ToLower(id), "/subscriptions/"):
+		return parseAzureResourceID(id)
+	case strings.HasPrefix(id, "https://") || strings.HasPrefix(id, "//"):
+		return parseGCPResourceName(id)
+	default:
+		return ResourceRef{}, false
+	}
+}
+
+// parseARN parses arn:partition:service:region:account:resource, where
+// resource is "type/name", "type:name", or a bare name typed by the service.
+func parseARN(arn string) (ResourceRef, bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] == "" || parts[5] == "" {
+		return ResourceRef{}, false
+	}
+	service, account, resource := parts[2], parts[4], parts[5]
+
+	if service == "s3" {
+		// Buckets are billed, not objects: arn:aws:s3:::bucket/key.
+		name, _, _ := strings.Cut(resource, "/")
+		return ResourceRef{Type: "s3:bucket", Name: name, Account: account}, true
+	}
+
+	resourceType, name, ok := strings.Cut(resource, "/")
+	if colonType, colonName, colon := strings.Cut(resource, ":"); colon && (!ok || len(colonType) < len(resourceType)) {
+		resourceType, name, ok = colonType, colonName, true
+	}
+	if !ok {
+		// A bare name, typed by its service: arn:aws:sns:us-east-1:123:topic.
+		resourceType, name = defaultARNResourceType(service), resource
+	}
+	// Drop qualifiers such as a Lambda version or alias.
+	if service == "lambda" {
+		name, _, _ = strings.Cut(name, ":")
+	}
+	if name == "" {
+		return ResourceRef{}, false
+	}
+	return ResourceRef{Type: service + ":" + resourceType, Name: name, Account: account}, true
+}
+
+// defaultARNResourceType is the resource type of an ARN whose resource has
+// no type prefix.
+func defaultARNResourceType(service string) string {
+	switch service {
+	case "sns":
+		return "topic"
+	case "sqs":
+		return "queue"
+	default:
+		return service
+	}
+}
+
+// parseAzureResourceID parses
+// /subscriptions/{sub}/resourceGroups/{group}/providers/{namespace}/{type}/{name}
+// with any child type and name pairs after it, which extend the type and
+// name as Azure does ("Microsoft.Sql/servers/databases", "srv/db").
+func parseAzureResourceID(id string) (ResourceRef, bool) {
+	segments := strings.Split(strings.Trim(id, "/"), "/")
+	if len(segments) < 2 || !strings.EqualFold(segments[0], "subscriptions") {
+		return ResourceRef{}, false
+	}
+	ref := ResourceRef{Account: segments[1]}
+
+	providers := -1
+	for i, segment := range segments {
+		if strings.EqualFold(segment, "providers") {
+			providers = i
+		}
+	}
+	if providers < 0 {
+		// A resource group or subscription.
+		if len(segments) == 4 && strings.EqualFold(segments[2], "resourceGroups") {
+			ref.Type, ref.Name = "Microsoft.Resources/resourceGroups", segments[3]
+			return ref, true
+		}
+		return ResourceRef{}, false
+	}
+
+	rest := segments[providers+1:]
+	if len(rest) < 3 || len(rest)%2 != 1 {
+		return ResourceRef{}, false
+	}
+	types := []string{rest[0]}
+	var names []string
+	for i := 1; i < len(rest); i += 2 {
+		types = append(types, rest[i])
+		names = append(names, rest[i+1])
+	}
+	ref.Type = strings.Join(types, "/")
+	ref.Name = strings.Join(names, "/")
+	return ref, true
+}
+
+// parseGCPResourceName parses a self-link
+// (https://www.googleapis.com/compute/v1/projects/{p}/zones/{z}/instances/{n})
+// or full resource name (//compute.googleapis.com/projects/{p}/...), typed
+// by its service and the collection holding the resource.
+func parseGCPResourceName(id string) (ResourceRef, bool) {
+	host, path, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(id, "https:"), "//"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	service, _, _ := strings.Cut(host, ".")
+	if host == "www.googleapis.com" {
+		service = segments[0]
+	}
+
+	// Skip the service and version a self-link's path leads with.
+	projects := slices.Index(segments, "projects")
+	if service == "" || projects < 0 {
+		return ResourceRef{}, false
+	}
+	segments = segments[projects:]
+	if len(segments) < 4 || len(segments)%2 != 0 {
+		return ResourceRef{}, false
+	}
+
+	ref := ResourceRef{
+		Type:    service + "/" + segments[len(segments)-2],
+		Name:    segments[len(segments)-1],
+		Account: segments[1],
+	}
+	if ref.Account == "_" {
+		// Globally named resources, such as buckets, leave the project out.
+		ref.Account = ""
+	}
+	return ref, true
+}
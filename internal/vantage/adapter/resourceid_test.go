@@ -0,0 +1,81 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestParseResourceID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want ResourceRef
+		ok   bool
+	}{
+		{"arn:aws:ec2:us-east-1:123456789012:instance/i-0abc", ResourceRef{"ec2:instance", "i-0abc", "123456789012"}, true},
+		{"arn:aws:lambda:us-east-1:123456789012:function:api:live", ResourceRef{"lambda:function", "api", "123456789012"}, true},
+		{"arn:aws:s3:::logs-bucket", ResourceRef{"s3:bucket", "logs-bucket", ""}, true},
+		{"arn:aws:s3:::logs-bucket/2024/01/a.gz", ResourceRef{"s3:bucket", "logs-bucket", ""}, true},
+		{"arn:aws:sns:us-east-1:123456789012:alerts", ResourceRef{"sns:topic", "alerts", "123456789012"}, true},
+		{
+			"arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/50dc6c495c0c9188",
+			ResourceRef{"elasticloadbalancing:loadbalancer", "app/web/50dc6c495c0c9188", "123456789012"}, true,
+		},
+		{
+			"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/api",
+			ResourceRef{"logs:log-group", "/aws/lambda/api", "123456789012"}, true,
+		},
+		{
+			"/subscriptions/0000-1111/resourceGroups/web/providers/Microsoft.Compute/virtualMachines/vm-1",
+			ResourceRef{"Microsoft.Compute/virtualMachines", "vm-1", "0000-1111"}, true,
+		},
+		{
+			"/subscriptions/0000-1111/resourcegroups/data/providers/microsoft.sql/servers/srv/databases/orders",
+			ResourceRef{"microsoft.sql/servers/databases", "srv/orders", "0000-1111"}, true,
+		},
+		{
+			"/subscriptions/0000-1111/resourceGroups/web",
+			ResourceRef{"Microsoft.Resources/resourceGroups", "web", "0000-1111"}, true,
+		},
+		{
+			"https://www.googleapis.com/compute/v1/projects/shop/zones/us-central1-a/instances/web-1",
+			ResourceRef{"compute/instances", "web-1", "shop"}, true,
+		},
+		{
+			"//container.googleapis.com/projects/shop/locations/us-central1/clusters/prod",
+			ResourceRef{"container/clusters", "prod", "shop"}, true,
+		},
+		{"//storage.googleapis.com/projects/_/buckets/assets", ResourceRef{"storage/buckets", "assets", ""}, true},
+		{"i-0abc", ResourceRef{}, false},
+		{"", ResourceRef{}, false},
+		{"arn:aws:ec2", ResourceRef{}, false},
+		{"/subscriptions/0000-1111/resourceGroups/web/providers/Microsoft.Compute", ResourceRef{}, false},
+		{"https://example.com/web", ResourceRef{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseResourceID(tt.id)
+		assert.Equal(t, tt.ok, ok, tt.id)
+		assert.Equal(t, tt.want, got, tt.id)
+	}
+}
+
+func TestAdapter_mapVantageRowToCostRecord_ResourceComponents(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day"}
+
+	record := adapter.mapVantageRowToCostRecord(client.CostRow{
+		Provider: "aws", Service: "AWS Lambda", ResourceID: "arn:aws:lambda:us-east-1:123:function:api", Cost: 1,
+	}, query, "hash", "cost")
+	assert.Equal(t, "lambda:function", record.ResourceType)
+	assert.Equal(t, "api", record.ResourceName)
+	assert.Equal(t, "123", record.ResourceAccountID)
+
+	record = adapter.mapVantageRowToCostRecord(client.CostRow{
+		Provider: "aws", Service: "EC2", ResourceID: "i-0abc", Cost: 1,
+	}, query, "hash", "cost")
+	assert.Equal(t, "i-0abc", record.ResourceID)
+	assert.Empty(t, record.ResourceType)
+	assert.Empty(t, record.ResourceName)
+}
@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// MetricTypeDeletion marks a tombstone for a previously written record that
+// a re-pull no longer returns.
+const MetricTypeDeletion = "deletion"
+
+// rowState classifies a pulled row against the previous pull of its day.
+type rowState int
+
+const (
+	// rowNew has not been written for this day before.
+	rowNew rowState = iota
+	// rowUnchanged was written before with the same LineItemID.
+	rowUnchanged
+	// rowRestated was written before with different costs.
+	rowRestated
+)
+
+// restatementTracker remembers, per day, which LineItemID was written for
+// each row key so re-pulled rows can be classified. State lives in the
+// bookmark store under one key per day.
+type restatementTracker struct {
+	store     BookmarkStore
+	reportKey string
+	logger    client.Logger
+
+	// previous holds the state loaded for each day; current the rows seen
+	// in this pull. Both map day -> row key -> LineItemID.
+	previous map[string]map[string]string
+	current  map[string]map[string]string
+
+	restated  int
+	unchanged int
+	deleted   int
+}
+
+// newRestatementTracker creates a tracker for one report configuration.
+func newRestatementTracker(store BookmarkStore, reportKey string, logger client.Logger) *restatementTracker {
+	return &restatementTracker{
+		store:     store,
+		reportKey: reportKey,
+		logger:    logger,
+		previous:  make(map[string]map[string]string),
+		current:   make(map[string]map[string]string),
+	}
+}
+
+// restatementStateKey returns the bookmark key holding a day's row state.
+func restatementStateKey(reportKey, day string) string {
+	return fmt.Sprintf("vantage_rows_%s_%s", reportKey, day)
+}
+
+// observe records a pulled row and reports how it compares with the previous
+// pull of the same day, along with the previously written LineItemID.
+func (t *restatementTracker) observe(ctx context.Context, day, rowKey, lineItemID string) (rowState, string) {
+	previous := t.load(ctx, day)
+
+	if t.current[day] == nil {
+		t.current[day] = make(map[string]string)
+	}
+	t.current[day][rowKey] = lineItemID
+
+	previousID, ok := previous[rowKey]
+	switch {
+	case !ok:
+		return rowNew, ""
+	case previousID == lineItemID:
+		t.unchanged++
+		return rowUnchanged, previousID
+	default:
+		t.restated++
+		return rowRestated, previousID
+	}
+}
+
+// load returns the stored state for day, reading it at most once per pull.
+// Unreadable state is logged and treated as empty, so the day's rows are
+// written again rather than failing the sync.
+func (t *restatementTracker) load(ctx context.Context, day string) map[string]string {
+	if state, ok := t.previous[day]; ok {
+		return state
+	}
+
+	state := make(map[string]string)
+	value, err := t.store.GetBookmark(ctx, restatementStateKey(t.reportKey, day))
+	if err == nil && value != "" {
+		err = json.Unmarshal([]byte(value), &state)
+	}
+	if err != nil {
+		t.logger.Warn(ctx, "Failed to load restatement state", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "restatement",
+			"attempt":   0,
+			"day":       day,
+			"error":     err,
+		})
+		state = make(map[string]string)
+	}
+
+	t.previous[day] = state
+	return state
+}
+
+// tombstones returns deletion records for rows written by an earlier pull
+// that this pull no longer returned. Only days wholly inside [start, end) are
+// reconciled, since a partially covered day may legitimately return fewer
+// rows. Reconciled days are marked as seen so save records their new state.
+func (t *restatementTracker) tombstones(
+	ctx context.Context,
+	start, end time.Time,
+	reportToken, queryHash string,
+) []CostRecord {
+	var records []CostRecord
+	for _, day := range coveredDays(start, end) {
+		key := day.Format("2006-01-02")
+		previous := t.load(ctx, key)
+		if t.current[key] == nil {
+			t.current[key] = make(map[string]string)
+		}
+
+		removed := make([]string, 0)
+		for rowKey, lineItemID := range previous {
+			if _, ok := t.current[key][rowKey]; !ok {
+				removed = append(removed, lineItemID)
+			}
+		}
+		sort.Strings(removed)
+
+		for _, lineItemID := range removed {
+			records = append(records, CostRecord{
+				Timestamp:         day,
+				SourceReportToken: reportToken,
+				QueryHash:         queryHash,
+				LineItemID:        lineItemID,
+				MetricType:        MetricTypeDeletion,
+				Diagnostics:       &Diagnostics{},
+			})
+		}
+	}
+	t.deleted += len(records)
+	return records
+}
+
+// coveredDays returns the UTC days that lie entirely within [start, end).
+func coveredDays(start, end time.Time) []time.Time {
+	start, end = start.UTC(), end.UTC()
+	day := start.Truncate(24 * time.Hour)
+	if day.Before(start) {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	var days []time.Time
+	for ; !day.AddDate(0, 0, 1).After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// save persists the rows seen in this pull as the new state for each day.
+func (t *restatementTracker) save(ctx context.Context) error {
+	for day, rows := range t.current {
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return fmt.Errorf("encoding row state for %s: %w", day, err)
+		}
+		if setErr := t.store.SetBookmark(ctx, restatementStateKey(t.reportKey, day), string(data)); setErr != nil {
+			return fmt.Errorf("writing row state for %s: %w", day, setErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestRestatementTracker_Observe(t *testing.T) {
+	ctx := context.Background()
+	store := bookmark.NewMemory()
+
+	first := newRestatementTracker(store, "report", client.NewNoopLogger())
+	state, _ := first.observe(ctx, "2024-01-01", "row-a", "id-a1")
+	assert.Equal(t, rowNew, state)
+	state, _ = first.observe(ctx, "2024-01-01", "row-b", "id-b1")
+	assert.Equal(t, rowNew, state)
+	require.NoError(t, first.save(ctx))
+
+	second := newRestatementTracker(store, "report", client.NewNoopLogger())
+	state, previousID := second.observe(ctx, "2024-01-01", "row-a", "id-a2")
+	assert.Equal(t, rowRestated, state)
+	assert.Equal(t, "id-a1", previousID)
+	state, _ = second.observe(ctx, "2024-01-01", "row-b", "id-b1")
+	assert.Equal(t, rowUnchanged, state)
+	state, _ = second.observe(ctx, "2024-01-02", "row-a", "id-a3")
+	assert.Equal(t, rowNew, state)
+
+	assert.Equal(t, 1, second.restated)
+	assert.Equal(t, 1, second.unchanged)
+}
+
+func TestRestatementTracker_CorruptStateTreatedAsEmpty(t *testing.T) {
+	ctx := context.Background()
+	store := bookmark.NewMemory()
+	require.NoError(t, store.SetBookmark(ctx, restatementStateKey("report", "2024-01-01"), "{not json"))
+
+	tracker := newRestatementTracker(store, "report", client.NewNoopLogger())
+	state, _ := tracker.observe(ctx, "2024-01-01", "row-a", "id-a1")
+	assert.Equal(t, rowNew, state)
+}
+
+func TestAdapter_SyncIncremental_RestatementWindow(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	store := bookmark.NewMemory()
+
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.SetBookmarkStore(store)
+
+	cfg := Config{
+		CostReportToken:       "cr_test",
+		Granularity:           "day",
+		Metrics:               []string{"cost"},
+		PageSize:              100,
+		RestatementWindowDays: 7,
+	}
+
+	bucket := time.Now().UTC().AddDate(0, 0, -5).Truncate(24 * time.Hour)
+	row := func(service string, cost float64) client.CostRow {
+		return client.CostRow{BucketStart: bucket, Provider: "aws", Service: service, Cost: cost, Currency: "USD"}
+	}
+
+	var queries []client.Query
+	capture := func(args mock.Arguments) { queries = append(queries, args.Get(1).(client.Query)) }
+	mockClient.On("Costs", mock.Anything, mock.Anything).Return(client.Page{
+		Data: []client.CostRow{row("ec2", 10), row("s3", 5)},
+	}, nil).Run(capture).Once()
+	mockClient.On("Costs", mock.Anything, mock.Anything).Return(client.Page{
+		Data: []client.CostRow{row("ec2", 12), row("s3", 5)},
+	}, nil).Run(capture).Once()
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	ctx := context.Background()
+	require.NoError(t, adapter.Sync(ctx, cfg, mockSink))
+	require.Len(t, mockSink.records, 2)
+	originalEC2 := mockSink.records[0]
+	assert.Equal(t, 0, adapter.GetDiagnosticsSummary().SourceInfo["restated_rows"])
+
+	// The window reaches back N days before D-1, beyond the default D-3.
+	windowStart := time.Now().UTC().AddDate(0, 0, -1-cfg.RestatementWindowDays)
+	assert.WithinDuration(t, windowStart, queries[0].StartAt, time.Minute)
+
+	// The re-pull emits only the restated row, linked to the record it replaces.
+	require.NoError(t, adapter.Sync(ctx, cfg, mockSink))
+	require.Len(t, mockSink.records, 3)
+	restated := mockSink.records[2]
+	assert.Equal(t, "ec2", restated.Service)
+	assert.Equal(t, originalEC2.LineItemID, restated.RestatesLineItemID)
+	assert.NotEqual(t, originalEC2.LineItemID, restated.LineItemID)
+
+	summary := adapter.GetDiagnosticsSummary()
+	assert.Equal(t, 1, summary.SourceInfo["restated_rows"])
+	assert.Equal(t, 1, summary.SourceInfo["restatement_unchanged_rows"])
+	assert.Equal(t, 1, summary.TotalRecords)
+	mockClient.AssertExpectations(t)
+}
+
+func TestCoveredDays(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	// Partial boundary days are excluded.
+	start := day(1).Add(6 * time.Hour)
+	end := day(4).Add(6 * time.Hour)
+	assert.Equal(t, []time.Time{day(2), day(3)}, coveredDays(start, end))
+
+	// Midnight boundaries cover whole days.
+	assert.Equal(t, []time.Time{day(1), day(2)}, coveredDays(day(1), day(3)))
+	assert.Empty(t, coveredDays(start, start.Add(12*time.Hour)))
+}
+
+func TestAdapter_SyncIncremental_TombstonesVanishedRows(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.SetBookmarkStore(bookmark.NewMemory())
+
+	cfg := Config{
+		CostReportToken:       "cr_test",
+		Granularity:           "day",
+		Metrics:               []string{"cost"},
+		PageSize:              100,
+		RestatementWindowDays: 7,
+	}
+
+	bucket := time.Now().UTC().AddDate(0, 0, -5).Truncate(24 * time.Hour)
+	ec2 := client.CostRow{BucketStart: bucket, Provider: "aws", Service: "ec2", Cost: 10, Currency: "USD"}
+	credit := client.CostRow{BucketStart: bucket, Provider: "aws", Service: "ec2", Credit: -3, Currency: "USD",
+		Tags: map[string]string{"credit": "promo"}}
+
+	mockClient.On("Costs", mock.Anything, mock.Anything).Return(client.Page{
+		Data: []client.CostRow{ec2, credit},
+	}, nil).Once()
+	mockClient.On("Costs", mock.Anything, mock.Anything).Return(client.Page{
+		Data: []client.CostRow{ec2},
+	}, nil).Twice()
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	ctx := context.Background()
+	require.NoError(t, adapter.Sync(ctx, cfg, mockSink))
+	require.Len(t, mockSink.records, 2)
+	creditRecord := mockSink.records[1]
+
+	// The corrected credit disappears, so it is tombstoned by LineItemID.
+	require.NoError(t, adapter.Sync(ctx, cfg, mockSink))
+	require.Len(t, mockSink.records, 3)
+	tombstone := mockSink.records[2]
+	assert.Equal(t, MetricTypeDeletion, tombstone.MetricType)
+	assert.Equal(t, creditRecord.LineItemID, tombstone.LineItemID)
+	assert.Equal(t, bucket, tombstone.Timestamp)
+	assert.Equal(t, 1, adapter.GetDiagnosticsSummary().SourceInfo["deleted_rows"])
+
+	// The tombstone is emitted once; the next pull has nothing to reconcile.
+	require.NoError(t, adapter.Sync(ctx, cfg, mockSink))
+	assert.Len(t, mockSink.records, 3)
+	assert.Equal(t, 0, adapter.GetDiagnosticsSummary().SourceInfo["deleted_rows"])
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,139 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// CurrentSchemaVersion is the CostRecord schema version this plugin writes.
+const CurrentSchemaVersion = 4
+
+// ErrUnsupportedSchemaVersion is returned for a record written by a newer
+// plugin than this one, which cannot be read without losing fields.
+var ErrUnsupportedSchemaVersion = errors.New("unsupported record schema version")
+
+// SchemaVersion describes one version of the CostRecord schema. Upgrade
+// converts a decoded record of the previous version to this one; it is nil
+// for the first version.
+type SchemaVersion struct {
+	Version     int
+	Description string
+	Upgrade     func(record map[string]interface{}) error
+}
+
+// schemaVersions is the registry of record schema versions, oldest first.
+// Changing the record format means appending a version with an Upgrade that
+// rewrites older records, and bumping CurrentSchemaVersion.
+var schemaVersions = []SchemaVersion{
+	{
+		Version:     1,
+		Description: "records written before schema versioning, without schema_version",
+	},
+	{
+		Version:     2,
+		Description: "adds schema_version",
+		Upgrade:     func(map[string]interface{}) error { return nil },
+	},
+	{
+		Version:     3,
+		Description: "adds allocation_rule_id and allocated_from_line_item_id",
+		Upgrade:     func(map[string]interface{}) error { return nil },
+	},
+	{
+		Version:     4,
+		Description: "adds billing_account_id and sub_account_id",
+		Upgrade:     upgradeAccountHierarchy,
+	},
+}
+
+// upgradeAccountHierarchy derives billing_account_id and sub_account_id from
+// the account_id, project, and subscription_id of a version 3 record, as
+// accountHierarchy does for a fetched row without a billing account.
+func upgradeAccountHierarchy(record map[string]interface{}) error {
+	text := func(key string) string {
+		value, _ := record[key].(string)
+		return value
+	}
+	billing, sub := accountHierarchy(client.CostRow{
+		Provider: text("provider"),
+		Account:  text("account_id"),
+		Project:  text("project"),
+	})
+	if subscription := text("subscription_id"); subscription != "" {
+		sub = subscription
+	}
+	if billing != "" {
+		record["billing_account_id"] = billing
+	}
+	if sub != "" {
+		record["sub_account_id"] = sub
+	}
+	return nil
+}
+
+// SchemaVersions returns the registered record schema versions, oldest
+// first.
+func SchemaVersions() []SchemaVersion {
+	return append([]SchemaVersion(nil), schemaVersions...)
+}
+
+// DecodeRecord decodes a JSON record written by this or any older plugin
+// version, upgrading it to CurrentSchemaVersion. A record without
+// schema_version is version 1.
+func DecodeRecord(data []byte) (CostRecord, error) {
+	// UseNumber keeps numbers exactly as written through the round trip.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return CostRecord{}, fmt.Errorf("decoding record: %w", err)
+	}
+
+	version, err := recordSchemaVersion(fields)
+	if err != nil {
+		return CostRecord{}, err
+	}
+
+	if version != CurrentSchemaVersion {
+		for _, schema := range schemaVersions[version:] {
+			if err := schema.Upgrade(fields); err != nil {
+				return CostRecord{}, fmt.Errorf("upgrading record to schema version %d: %w", schema.Version, err)
+			}
+		}
+		fields["schema_version"] = CurrentSchemaVersion
+		if data, err = json.Marshal(fields); err != nil {
+			return CostRecord{}, fmt.Errorf("encoding upgraded record: %w", err)
+		}
+	}
+
+	var record CostRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return CostRecord{}, fmt.Errorf("decoding record: %w", err)
+	}
+	return record, nil
+}
+
+// recordSchemaVersion returns the schema_version of a decoded record.
+func recordSchemaVersion(fields map[string]interface{}) (int, error) {
+	raw, ok := fields["schema_version"]
+	if !ok || raw == nil {
+		return 1, nil
+	}
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid schema_version: %v", raw)
+	}
+	version, err := number.Int64()
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid schema_version: %s", number)
+	}
+	if version > CurrentSchemaVersion {
+		return 0, fmt.Errorf("%w %d (this plugin supports up to %d)",
+			ErrUnsupportedSchemaVersion, version, CurrentSchemaVersion)
+	}
+	return int(version), nil
+}
@@ -0,0 +1,89 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestSchemaVersions(t *testing.T) {
+	versions := SchemaVersions()
+	require.NotEmpty(t, versions)
+	for i, schema := range versions {
+		assert.Equal(t, i+1, schema.Version, "versions are contiguous from 1")
+		assert.NotEmpty(t, schema.Description)
+		if i > 0 {
+			assert.NotNil(t, schema.Upgrade, "version %d needs an upgrade", schema.Version)
+		}
+	}
+	assert.Equal(t, CurrentSchemaVersion, versions[len(versions)-1].Version)
+}
+
+func TestDecodeRecord(t *testing.T) {
+	// A record written before schema versioning.
+	record, err := DecodeRecord([]byte(`{
+		"timestamp": "2024-01-01T00:00:00Z",
+		"provider": "aws",
+		"net_cost": 12.5,
+		"query_hash": "h",
+		"line_item_id": "id1"
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, CurrentSchemaVersion, record.SchemaVersion)
+	assert.Equal(t, "aws", record.Provider)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), record.Timestamp)
+	require.NotNil(t, record.NetCost)
+	assert.InDelta(t, 12.5, *record.NetCost, 1e-9)
+
+	// A current record round-trips unchanged.
+	cost := 3.25
+	current := CostRecord{SchemaVersion: CurrentSchemaVersion, Provider: "gcp", NetCost: &cost, LineItemID: "id2"}
+	data, err := json.Marshal(current)
+	require.NoError(t, err)
+	decoded, err := DecodeRecord(data)
+	require.NoError(t, err)
+	assert.Equal(t, current, decoded)
+
+	_, err = DecodeRecord([]byte(`{"schema_version": 99}`))
+	require.ErrorIs(t, err, ErrUnsupportedSchemaVersion)
+
+	_, err = DecodeRecord([]byte(`{"schema_version": "two"}`))
+	require.ErrorContains(t, err, "invalid schema_version")
+
+	_, err = DecodeRecord([]byte(`{bad`))
+	require.ErrorContains(t, err, "decoding record")
+}
+
+func TestDecodeRecord_UpgradesAccountHierarchy(t *testing.T) {
+	record, err := DecodeRecord([]byte(`{"schema_version": 3, "provider": "aws", "account_id": "123"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "123", record.BillingAccountID)
+	assert.Equal(t, "123", record.SubAccountID)
+
+	record, err = DecodeRecord([]byte(`{"schema_version": 3, "provider": "gcp", "account_id": "0A-1B", "project": "web"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "0A-1B", record.BillingAccountID)
+	assert.Equal(t, "web", record.SubAccountID)
+
+	record, err = DecodeRecord([]byte(`{"schema_version": 3, "provider": "azure", "account_id": "ea-1", "subscription_id": "sub-1"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "ea-1", record.BillingAccountID)
+	assert.Equal(t, "sub-1", record.SubAccountID)
+}
+
+func TestAdapter_WriteRecordsStampsSchemaVersion(t *testing.T) {
+	mockSink := &mockSink{}
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+
+	require.NoError(t, adapter.writeRecords(context.Background(), mockSink, []CostRecord{{LineItemID: "a"}}))
+	require.Len(t, mockSink.records, 1)
+	assert.Equal(t, CurrentSchemaVersion, mockSink.records[0].SchemaVersion)
+}
@@ -0,0 +1,701 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/secrets"
+)
+
+// decodeSection decodes the raw section name into out, a pointer to a
+// struct whose yaml tags name the section's keys. Scalars convert the way
+// YAML users expect ("500" for an int, a space-separated string for a
+// list), but a value that cannot be converted is an error naming its key.
+// Unknown keys are ignored here; config validate reports them.
+func decodeSection(name string, input, out interface{}) error {
+	if value := reflect.ValueOf(input); !value.IsValid() ||
+		(value.Kind() == reflect.Map || value.Kind() == reflect.Slice) && value.IsNil() {
+		return nil
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "yaml",
+		Squash:           true,
+		WeaklyTypedInput: true,
+		DecodeHook:       splitStringHook,
+		Result:           out,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
+// splitStringHook splits a string decoded into a []string on whitespace.
+func splitStringHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() == reflect.String && to == reflect.TypeOf([]string(nil)) {
+		return strings.Fields(data.(string)), nil
+	}
+	return data, nil
+}
+
+// parseSections decodes every section but credentials and params into cfg,
+// each with its defaults filled in. Sections defaulting paths under the sink
+// are decoded after it.
+func parseSections(raw *rawConfig, cfg *Config) error {
+	var err error
+	if cfg.TokenEnv, err = parseTokenEnv(raw); err != nil {
+		return err
+	}
+	if cfg.OAuth2, err = parseOAuth2(raw); err != nil {
+		return err
+	}
+	if cfg.Sink, err = parseSink(raw); err != nil {
+		return err
+	}
+	if cfg.Bookmarks, err = parseBookmarks(raw, cfg.Sink); err != nil {
+		return err
+	}
+	if cfg.Lock, err = parseLock(raw, cfg.Sink); err != nil {
+		return err
+	}
+	if cfg.Cache, err = parseCache(raw, cfg.Sink); err != nil {
+		return err
+	}
+	if cfg.Tags, err = parseTags(raw); err != nil {
+		return err
+	}
+	if cfg.Tracing, err = parseTracing(raw); err != nil {
+		return err
+	}
+	if cfg.Transforms, err = parseTransforms(raw); err != nil {
+		return err
+	}
+	if cfg.AllocationRules, err = parseAllocationRules(raw); err != nil {
+		return err
+	}
+	if cfg.Alerts, err = parseAlerts(raw); err != nil {
+		return err
+	}
+	if cfg.Diagnostics, err = parseDiagnostics(raw); err != nil {
+		return err
+	}
+	if cfg.Serve, err = parseServe(raw); err != nil {
+		return err
+	}
+	if cfg.DeadLetter, err = parseDeadLetter(raw, cfg.Sink); err != nil {
+		return err
+	}
+	cfg.Mock, err = parseMock(raw)
+	return err
+}
+
+// credentialsSection is the credentials section as written.
+type credentialsSection struct {
+	Token    string                 `yaml:"token"`
+	TokenEnv string                 `yaml:"token_env"`
+	TokenRef string                 `yaml:"token_ref"`
+	OAuth2   map[string]interface{} `yaml:"oauth2"`
+}
+
+// oauth2Section is credentials.oauth2 as written; client_secret_env names a
+// variable to read the client secret from.
+type oauth2Section struct {
+	TokenURL        string   `yaml:"token_url"`
+	ClientID        string   `yaml:"client_id"`
+	ClientSecret    string   `yaml:"client_secret"`
+	ClientSecretEnv string   `yaml:"client_secret_env"`
+	Scopes          []string `yaml:"scopes"`
+}
+
+// parseCredentials extracts the token and applies env overrides.
+// credentials.token_env names a variable to read the token from. The
+// PULUMICOST_VANTAGE_TOKEN override is skipped for profiles with their own
+// credentials, so one variable cannot point every profile at one workspace.
+// When none of these yields a token, credentials.token_ref is resolved
+// through its secrets backend.
+func parseCredentials(raw *rawConfig) (string, error) {
+	var credentials credentialsSection
+	if err := decodeSection("credentials", raw.Credentials, &credentials); err != nil {
+		return "", err
+	}
+
+	token := credentials.Token
+	if credentials.TokenEnv != "" {
+		if envToken := os.Getenv(credentials.TokenEnv); envToken != "" {
+			token = envToken
+		}
+	}
+	if !raw.profileCredentials {
+		if envToken := os.Getenv("PULUMICOST_VANTAGE_TOKEN"); envToken != "" {
+			token = envToken
+		}
+	}
+	if token != "" || credentials.TokenRef == "" {
+		return token, nil
+	}
+
+	token, err := secrets.DefaultResolvers().Resolve(context.Background(), credentials.TokenRef)
+	if err != nil {
+		return "", fmt.Errorf("resolving credentials.token_ref: %w", err)
+	}
+	return token, nil
+}
+
+// parseTokenEnv returns credentials.token_env unless PULUMICOST_VANTAGE_TOKEN
+// takes precedence over it (see parseCredentials).
+func parseTokenEnv(raw *rawConfig) (string, error) {
+	var credentials credentialsSection
+	if err := decodeSection("credentials", raw.Credentials, &credentials); err != nil {
+		return "", err
+	}
+	if !raw.profileCredentials && os.Getenv("PULUMICOST_VANTAGE_TOKEN") != "" {
+		return "", nil
+	}
+	return credentials.TokenEnv, nil
+}
+
+// parseOAuth2 extracts credentials.oauth2, or nil when it is not set.
+func parseOAuth2(raw *rawConfig) (*OAuth2Credentials, error) {
+	if raw.Credentials == nil || raw.Credentials["oauth2"] == nil {
+		return nil, nil
+	}
+
+	var section oauth2Section
+	if err := decodeSection("credentials.oauth2", raw.Credentials["oauth2"], &section); err != nil {
+		return nil, err
+	}
+	oauth2 := &OAuth2Credentials{
+		TokenURL:     section.TokenURL,
+		ClientID:     section.ClientID,
+		ClientSecret: section.ClientSecret,
+		Scopes:       section.Scopes,
+	}
+	if section.ClientSecretEnv != "" {
+		if secret := os.Getenv(section.ClientSecretEnv); secret != "" {
+			oauth2.ClientSecret = secret
+		}
+	}
+	return oauth2, nil
+}
+
+// paramsSection is the params section as written. Pointer fields are the
+// ones whose zero value is valid and differs from the default.
+type paramsSection struct {
+	WorkspaceToken  string   `yaml:"workspace_token"`
+	CostReportToken string   `yaml:"cost_report_token"`
+	CostReportName  string   `yaml:"cost_report_name"`
+	StartDate       string   `yaml:"start_date"`
+	EndDate         string   `yaml:"end_date"`
+	Granularity     string   `yaml:"granularity"`
+	GroupBys        []string `yaml:"group_bys"`
+	Metrics         []string `yaml:"metrics"`
+	Filter          string   `yaml:"filter"`
+
+	IncludeForecast    bool `yaml:"include_forecast"`
+	IncludeBudgets     bool `yaml:"include_budgets"`
+	IncludeUnallocated bool `yaml:"include_unallocated"`
+
+	PageSize                    int     `yaml:"page_size"`
+	RequestTimeoutSeconds       int     `yaml:"request_timeout_seconds"`
+	OperationTimeoutSeconds     int     `yaml:"operation_timeout_seconds"`
+	MaxRetries                  int     `yaml:"max_retries"`
+	RetryBudget                 int     `yaml:"retry_budget"`
+	BatchSize                   int     `yaml:"batch_size"`
+	PrefetchPages               bool    `yaml:"prefetch_pages"`
+	PageRetries                 int     `yaml:"page_retries"`
+	RequestsPerSecond           float64 `yaml:"requests_per_second"`
+	Burst                       int     `yaml:"burst"`
+	RateLimitRemainingThreshold *int    `yaml:"rate_limit_remaining_threshold"`
+	MaxIdleConnsPerHost         int     `yaml:"max_idle_conns_per_host"`
+	DisableCompression          bool    `yaml:"disable_compression"`
+	ProxyURL                    string  `yaml:"proxy_url"`
+	CABundle                    string  `yaml:"ca_bundle"`
+	ClientCert                  string  `yaml:"client_cert"`
+	ClientKey                   string  `yaml:"client_key"`
+	APIVersion                  string  `yaml:"api_version"`
+	ClientKind                  string  `yaml:"client_kind"`
+	UserAgentSuffix             string  `yaml:"user_agent_suffix"`
+
+	Headers map[string]string `yaml:"headers"`
+
+	OutputGranularity  string            `yaml:"output_granularity"`
+	DropDimensions     []string          `yaml:"drop_dimensions"`
+	StaticLabels       map[string]string `yaml:"static_labels"`
+	StaticLabelsPolicy string            `yaml:"static_labels_policy"`
+
+	TagPrefixFilters []string `yaml:"tag_prefix_filters"`
+	DiscoverTags     bool     `yaml:"discover_tags"`
+	AutoGroupByTags  bool     `yaml:"auto_group_by_tags"`
+
+	RestatementWindowDays         int      `yaml:"restatement_window_days"`
+	FillMissingBuckets            bool     `yaml:"fill_missing_buckets"`
+	FinalityLagDays               *int     `yaml:"finality_lag_days"`
+	WatermarkIntegrationFreshness bool     `yaml:"watermark_integration_freshness"`
+	VerifyTotals                  bool     `yaml:"verify_totals"`
+	VerifyTotalsTolerance         *float64 `yaml:"verify_totals_tolerance"`
+
+	CostBasis         string            `yaml:"cost_basis"`
+	ProviderCostBasis map[string]string `yaml:"provider_cost_basis"`
+
+	ServiceCategories map[string]map[string]string `yaml:"service_categories"`
+	RegionGeography   bool                         `yaml:"region_geography"`
+
+	LineItemHash string `yaml:"line_item_hash"`
+
+	TargetCurrency string             `yaml:"target_currency"`
+	FXSource       string             `yaml:"fx_source"`
+	FXRatesFile    string             `yaml:"fx_rates_file"`
+	FXBaseCurrency string             `yaml:"fx_base_currency"`
+	FXRates        map[string]float64 `yaml:"fx_rates"`
+}
+
+// parseParams decodes the params section.
+func parseParams(raw *rawConfig) (paramsSection, error) {
+	var params paramsSection
+	err := decodeSection("params", raw.Params, &params)
+	return params, err
+}
+
+// apply sets the params on cfg, filling in defaults for unset values. Dates
+// are parsed separately, since they have their own env overrides.
+func (p paramsSection) apply(cfg *Config) {
+	cfg.WorkspaceToken = p.WorkspaceToken
+	cfg.CostReportToken = p.CostReportToken
+	cfg.CostReportName = strings.TrimSpace(p.CostReportName)
+	cfg.Granularity = p.Granularity
+	cfg.GroupBys = p.GroupBys
+	cfg.Metrics = p.Metrics
+	cfg.Filter = strings.TrimSpace(p.Filter)
+	cfg.IncludeForecast = p.IncludeForecast
+	cfg.IncludeBudgets = p.IncludeBudgets
+	cfg.IncludeUnallocated = p.IncludeUnallocated
+
+	cfg.PageSize = valueOr(p.PageSize, defaultPageSize)
+	cfg.Timeout = time.Duration(valueOr(p.RequestTimeoutSeconds, defaultTimeoutSeconds)) * time.Second
+	cfg.OperationTimeout = time.Duration(p.OperationTimeoutSeconds) * time.Second
+	cfg.MaxRetries = valueOr(p.MaxRetries, defaultMaxRetries)
+	cfg.RetryBudget = p.RetryBudget
+	cfg.BatchSize = valueOr(p.BatchSize, defaultBatchSize)
+	cfg.PrefetchPages = p.PrefetchPages
+	cfg.PageRetries = p.PageRetries
+	cfg.RequestsPerSecond = p.RequestsPerSecond
+	cfg.Burst = p.Burst
+	cfg.RateLimitRemainingThreshold = client.DefaultRateLimitRemainingThreshold
+	if p.RateLimitRemainingThreshold != nil {
+		cfg.RateLimitRemainingThreshold = *p.RateLimitRemainingThreshold
+	}
+	cfg.MaxIdleConnsPerHost = p.MaxIdleConnsPerHost
+	cfg.DisableCompression = p.DisableCompression
+	cfg.ProxyURL = strings.TrimSpace(p.ProxyURL)
+	cfg.CABundle = p.CABundle
+	cfg.ClientCert = p.ClientCert
+	cfg.ClientKey = p.ClientKey
+	cfg.ClientKind = client.ClientKindHandwritten
+	if v := strings.ToLower(strings.TrimSpace(p.ClientKind)); v != "" {
+		cfg.ClientKind = v
+	}
+	// The generated client speaks only v2, so it defaults to v2.
+	cfg.APIVersion = client.APIVersionV1
+	if cfg.ClientKind == client.ClientKindOpenAPI {
+		cfg.APIVersion = client.APIVersionV2
+	}
+	if v := strings.ToLower(strings.TrimSpace(p.APIVersion)); v != "" {
+		cfg.APIVersion = v
+	}
+	cfg.UserAgentSuffix = strings.TrimSpace(p.UserAgentSuffix)
+	// Header names are case-insensitive, and config loading lower-cases
+	// map keys, so they are canonicalized.
+	if len(p.Headers) > 0 {
+		cfg.Headers = make(map[string]string, len(p.Headers))
+		for name, value := range p.Headers {
+			cfg.Headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+
+	cfg.OutputGranularity = strings.ToLower(strings.TrimSpace(p.OutputGranularity))
+	cfg.DropDimensions = p.DropDimensions
+	cfg.StaticLabels = p.StaticLabels
+	cfg.StaticLabelsPolicy = strings.ToLower(strings.TrimSpace(p.StaticLabelsPolicy))
+	cfg.TagPrefixFilters = p.TagPrefixFilters
+	cfg.DiscoverTags = p.DiscoverTags
+	cfg.AutoGroupByTags = p.AutoGroupByTags
+
+	cfg.RestatementWindowDays = p.RestatementWindowDays
+	cfg.FillMissingBuckets = p.FillMissingBuckets
+	cfg.FinalityLagDays = defaultFinalityLagDays
+	if p.FinalityLagDays != nil {
+		cfg.FinalityLagDays = *p.FinalityLagDays
+	}
+	cfg.WatermarkIntegrationFreshness = p.WatermarkIntegrationFreshness
+	cfg.VerifyTotals = p.VerifyTotals
+	cfg.VerifyTotalsTolerance = defaultVerifyTotalsTolerance
+	if p.VerifyTotalsTolerance != nil {
+		cfg.VerifyTotalsTolerance = *p.VerifyTotalsTolerance
+	}
+
+	cfg.CostBasis = strings.ToLower(strings.TrimSpace(p.CostBasis))
+	if p.ProviderCostBasis != nil {
+		cfg.ProviderCostBasis = make(map[string]string, len(p.ProviderCostBasis))
+		for provider, basis := range p.ProviderCostBasis {
+			cfg.ProviderCostBasis[strings.ToLower(provider)] = strings.ToLower(strings.TrimSpace(basis))
+		}
+	}
+	cfg.RegionGeography = p.RegionGeography
+	cfg.LineItemHash = HashSHA256
+	if hash := strings.ToLower(strings.TrimSpace(p.LineItemHash)); hash != "" {
+		cfg.LineItemHash = hash
+	}
+	if p.ServiceCategories != nil {
+		cfg.ServiceCategories = make(map[string]map[string]string, len(p.ServiceCategories))
+		for provider, services := range p.ServiceCategories {
+			cfg.ServiceCategories[strings.ToLower(provider)] = services
+		}
+	}
+
+	p.applyCurrency(cfg)
+}
+
+// applyCurrency sets the target_currency and fx_* params. The rate source
+// defaults to ECB, and a static table's base to the target currency.
+func (p paramsSection) applyCurrency(cfg *Config) {
+	cfg.TargetCurrency = strings.ToUpper(p.TargetCurrency)
+	if cfg.TargetCurrency == "" {
+		return
+	}
+
+	cfg.FXSource = strings.ToLower(p.FXSource)
+	if cfg.FXSource == "" {
+		cfg.FXSource = FXSourceECB
+	}
+	cfg.FXRatesFile = p.FXRatesFile
+	cfg.FXBaseCurrency = strings.ToUpper(p.FXBaseCurrency)
+	if cfg.FXBaseCurrency == "" {
+		cfg.FXBaseCurrency = cfg.TargetCurrency
+	}
+	if p.FXRates != nil {
+		cfg.FXRates = make(map[string]float64, len(p.FXRates))
+		for code, rate := range p.FXRates {
+			cfg.FXRates[strings.ToUpper(code)] = rate
+		}
+	}
+}
+
+// valueOr returns value, or fallback when value is not positive.
+func valueOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// parseSink decodes the sink section, defaulting to a file sink under ./data.
+func parseSink(raw *rawConfig) (SinkConfig, error) {
+	sink := SinkConfig{WriteRetries: defaultSinkWriteRetries, Writers: 1}
+	if err := decodeSection("sink", raw.Sink, &sink); err != nil {
+		return sink, err
+	}
+	sink.Type = strings.ToLower(sink.Type)
+	if sink.Type == "" {
+		sink.Type = SinkTypeFile
+	}
+	if sink.Path == "" {
+		sink.Path = defaultSinkPath
+	}
+	sink.Compression = strings.ToLower(sink.Compression)
+	switch sink.Type {
+	case SinkTypeBigQuery:
+		if sink.Table == "" {
+			sink.Table = defaultSinkTable
+		}
+	case SinkTypeClickHouse:
+		if sink.Database == "" {
+			sink.Database = defaultClickHouseDatabase
+		}
+		if sink.Table == "" {
+			sink.Table = defaultSinkTable
+		}
+	case SinkTypeDuckDB:
+		if sink.Database == "" {
+			sink.Database = filepath.Join(sink.Path, defaultDuckDBFile)
+		}
+		if sink.Table == "" {
+			sink.Table = defaultSinkTable
+		}
+	case SinkTypeKafka:
+		if sink.Topic == "" {
+			sink.Topic = defaultKafkaTopic
+		}
+		sink.Format = strings.ToLower(sink.Format)
+		if sink.Format == "" {
+			sink.Format = KafkaFormatJSON
+		}
+	}
+	if sink.Journal && sink.JournalPath == "" {
+		sink.JournalPath = filepath.Join(sink.Path, "journal")
+	}
+	return sink, nil
+}
+
+// parseBookmarks decodes the bookmarks section. By default bookmarks are
+// kept in a JSON file next to the sink's records.
+func parseBookmarks(raw *rawConfig, sink SinkConfig) (BookmarkConfig, error) {
+	var bookmarks BookmarkConfig
+	if err := decodeSection("bookmarks", raw.Bookmarks, &bookmarks); err != nil {
+		return bookmarks, err
+	}
+	bookmarks.Type = strings.ToLower(bookmarks.Type)
+	if bookmarks.Type == "" {
+		bookmarks.Type = BookmarkStoreFile
+	}
+
+	switch bookmarks.Type {
+	case BookmarkStoreFile:
+		if bookmarks.Path == "" {
+			bookmarks.Path = filepath.Join(sink.Path, "bookmarks.json")
+		}
+	case BookmarkStoreSQLite:
+		if bookmarks.Path == "" {
+			bookmarks.Path = filepath.Join(sink.Path, "bookmarks.db")
+		}
+		if bookmarks.Table == "" {
+			bookmarks.Table = defaultBookmarkTable
+		}
+	case BookmarkStoreDynamoDB:
+		if bookmarks.Table == "" {
+			bookmarks.Table = defaultBookmarkTable
+		}
+	}
+	return bookmarks, nil
+}
+
+// parseDeadLetter decodes the dead_letter section. The queue is off unless a
+// type is set; it defaults to a file or database next to the records.
+func parseDeadLetter(raw *rawConfig, sink SinkConfig) (DeadLetterConfig, error) {
+	var deadLetter DeadLetterConfig
+	if err := decodeSection("dead_letter", raw.DeadLetter, &deadLetter); err != nil {
+		return deadLetter, err
+	}
+	deadLetter.Type = strings.ToLower(deadLetter.Type)
+	switch deadLetter.Type {
+	case "":
+		deadLetter.Type = DeadLetterNone
+	case DeadLetterFile:
+		if deadLetter.Path == "" {
+			deadLetter.Path = filepath.Join(sink.Path, "dead_letter.ndjson")
+		}
+	case DeadLetterSQLite:
+		if deadLetter.Path == "" {
+			deadLetter.Path = filepath.Join(sink.Path, "dead_letter.db")
+		}
+		if deadLetter.Table == "" {
+			deadLetter.Table = defaultDeadLetterTable
+		}
+	}
+	return deadLetter, nil
+}
+
+// parseLock decodes the lock section. Locking is off unless a type is set;
+// a file lock defaults to a locks directory next to the records.
+func parseLock(raw *rawConfig, sink SinkConfig) (LockConfig, error) {
+	var lock LockConfig
+	if err := decodeSection("lock", raw.Lock, &lock); err != nil {
+		return lock, err
+	}
+	lock.Type = strings.ToLower(lock.Type)
+	if lock.Type == "" {
+		lock.Type = LockTypeNone
+	}
+	if envDSN := os.Getenv(lockDSNEnv); envDSN != "" {
+		lock.DSN = envDSN
+	}
+
+	switch lock.Type {
+	case LockTypeFile:
+		if lock.Path == "" {
+			lock.Path = filepath.Join(sink.Path, "locks")
+		}
+	case LockTypeDynamoDB:
+		if lock.Table == "" {
+			lock.Table = defaultLockTable
+		}
+	}
+	return lock, nil
+}
+
+// parseCache decodes the cache section. Caching is off unless enabled, and
+// entries live under the sink path by default.
+func parseCache(raw *rawConfig, sink SinkConfig) (CacheConfig, error) {
+	var cache CacheConfig
+	if err := decodeSection("cache", raw.Cache, &cache); err != nil {
+		return cache, err
+	}
+	if cache.Enabled && cache.Path == "" {
+		cache.Path = filepath.Join(sink.Path, "cache")
+	}
+	return cache, nil
+}
+
+// parseTags decodes the tags section. An explicit empty deny list disables
+// the default deny patterns.
+func parseTags(raw *rawConfig) (TagConfig, error) {
+	var tags TagConfig
+	if err := decodeSection("tags", raw.Tags, &tags); err != nil {
+		return tags, err
+	}
+	if _, ok := raw.Tags["deny"]; ok && tags.Deny == nil {
+		tags.Deny = []string{}
+	}
+	tags.MergePolicy = strings.ToLower(tags.MergePolicy)
+	return tags, nil
+}
+
+// parseTracing decodes the tracing section. Tracing is off unless enabled,
+// and samples every sync run by default.
+func parseTracing(raw *rawConfig) (TracingConfig, error) {
+	tracing := TracingConfig{SampleRatio: 1}
+	if err := decodeSection("tracing", raw.Tracing, &tracing); err != nil {
+		return tracing, err
+	}
+	tracing.Protocol = strings.ToLower(tracing.Protocol)
+	if tracing.Protocol == "" {
+		tracing.Protocol = TracingProtocolHTTP
+	}
+	if tracing.ServiceName == "" {
+		tracing.ServiceName = defaultTracingServiceName
+	}
+	return tracing, nil
+}
+
+// parseTransforms decodes the transforms section, keeping its order.
+func parseTransforms(raw *rawConfig) ([]TransformConfig, error) {
+	if len(raw.Transforms) == 0 {
+		return nil, nil
+	}
+	var transforms []TransformConfig
+	if err := decodeSection("transforms", raw.Transforms, &transforms); err != nil {
+		return nil, err
+	}
+	for i := range transforms {
+		transforms[i].Type = strings.ToLower(transforms[i].Type)
+	}
+	return transforms, nil
+}
+
+// parseAllocationRules decodes the allocation_rules section, keeping its
+// order.
+func parseAllocationRules(raw *rawConfig) ([]AllocationRule, error) {
+	if len(raw.Allocations) == 0 {
+		return nil, nil
+	}
+	var rules []AllocationRule
+	if err := decodeSection("allocation_rules", raw.Allocations, &rules); err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		rules[i].Method = strings.ToLower(rules[i].Method)
+	}
+	return rules, nil
+}
+
+// parseDiagnostics decodes the diagnostics section. Field and provider
+// names are lower-cased. The report format defaults to html for .html and
+// .htm paths and to json otherwise.
+func parseDiagnostics(raw *rawConfig) (DiagnosticsConfig, error) {
+	var diagnostics DiagnosticsConfig
+	if err := decodeSection("diagnostics", raw.Diagnostics, &diagnostics); err != nil {
+		return diagnostics, err
+	}
+
+	diagnostics.FieldPolicy = diagnostics.FieldPolicy.normalized()
+	diagnostics.ReportFormat = strings.ToLower(diagnostics.ReportFormat)
+	if diagnostics.ReportFormat == "" && diagnostics.ReportPath != "" {
+		diagnostics.ReportFormat = DiagnosticsReportJSON
+		switch strings.ToLower(filepath.Ext(diagnostics.ReportPath)) {
+		case ".html", ".htm":
+			diagnostics.ReportFormat = DiagnosticsReportHTML
+		}
+	}
+	if diagnostics.Providers != nil {
+		providers := make(map[string]FieldPolicy, len(diagnostics.Providers))
+		for provider, policy := range diagnostics.Providers {
+			providers[strings.ToLower(provider)] = policy.normalized()
+		}
+		diagnostics.Providers = providers
+	}
+	return diagnostics, nil
+}
+
+// normalized returns the policy with field names trimmed and lower-cased.
+func (p FieldPolicy) normalized() FieldPolicy {
+	lower := func(fields []string) []string {
+		for i := range fields {
+			fields[i] = strings.ToLower(strings.TrimSpace(fields[i]))
+		}
+		return fields
+	}
+	return FieldPolicy{Required: lower(p.Required), Optional: lower(p.Optional)}
+}
+
+// parseAlerts decodes the alerts section, resolving webhook URLs from
+// url_env. Webhooks default to the generic type and spend rules to monthly
+// periods.
+func parseAlerts(raw *rawConfig) (AlertsConfig, error) {
+	var alerts AlertsConfig
+	if err := decodeSection("alerts", raw.Alerts, &alerts); err != nil {
+		return alerts, err
+	}
+
+	for i := range alerts.Webhooks {
+		webhook := &alerts.Webhooks[i]
+		webhook.Type = strings.ToLower(webhook.Type)
+		if webhook.Type == "" {
+			webhook.Type = WebhookTypeGeneric
+		}
+		if webhook.URLEnv != "" {
+			if url := os.Getenv(webhook.URLEnv); url != "" {
+				webhook.URL = url
+			}
+		}
+	}
+	for i := range alerts.Rules {
+		rule := &alerts.Rules[i]
+		rule.Kind = strings.ToLower(rule.Kind)
+		rule.Period = strings.ToLower(rule.Period)
+		if rule.Kind == AlertKindSpend && rule.Period == "" {
+			rule.Period = AlertPeriodMonth
+		}
+	}
+	return alerts, nil
+}
+
+// parseMock decodes the mock section. Weekend spend dips by a fifth unless
+// seasonality is set.
+func parseMock(raw *rawConfig) (MockConfig, error) {
+	mock := MockConfig{Seasonality: defaultMockSeasonality}
+	err := decodeSection("mock", raw.Mock, &mock)
+	return mock, err
+}
+
+// parseServe decodes the serve section.
+func parseServe(raw *rawConfig) (ServeConfig, error) {
+	var serve ServeConfig
+	if err := decodeSection("serve", raw.Serve, &serve); err != nil {
+		return serve, err
+	}
+	serve.LogLevel = strings.ToLower(strings.TrimSpace(serve.LogLevel))
+	serve.ProjectionStrategy = strings.ToLower(strings.TrimSpace(serve.ProjectionStrategy))
+	return serve, nil
+}
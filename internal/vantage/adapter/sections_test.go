@@ -0,0 +1,94 @@
+package adapter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSection(t *testing.T) {
+	var params paramsSection
+	err := decodeSection("params", map[string]interface{}{
+		"page_size":                      "500",
+		"group_bys":                      "provider service",
+		"include_forecast":               "true",
+		"rate_limit_remaining_threshold": 0,
+		"unknown_key":                    "ignored",
+	}, &params)
+	require.NoError(t, err)
+	assert.Equal(t, 500, params.PageSize)
+	assert.Equal(t, []string{"provider", "service"}, params.GroupBys)
+	assert.True(t, params.IncludeForecast)
+	require.NotNil(t, params.RateLimitRemainingThreshold, "an explicit zero is kept")
+	assert.Equal(t, 0, *params.RateLimitRemainingThreshold)
+	assert.Nil(t, params.FinalityLagDays)
+
+	err = decodeSection("params", map[string]interface{}{"page_size": "lots"}, &params)
+	require.ErrorContains(t, err, "invalid params")
+	assert.ErrorContains(t, err, "page_size")
+
+	var oauth2 oauth2Section
+	require.ErrorContains(t, decodeSection("credentials.oauth2", "not-a-map", &oauth2),
+		"invalid credentials.oauth2")
+	require.NoError(t, decodeSection("credentials.oauth2", nil, &oauth2))
+}
+
+func TestLoadConfig_SectionTypeErrors(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "params",
+			config:  "params:\n  cost_report_token: cr_test\n  max_retries: often\n",
+			wantErr: "invalid params",
+		},
+		{
+			name:    "cache",
+			config:  "params:\n  cost_report_token: cr_test\ncache:\n  ttl_seconds: [1]\n",
+			wantErr: "invalid cache",
+		},
+		{
+			name:    "transforms",
+			config:  "params:\n  cost_report_token: cr_test\ntransforms:\n  - type: cost_threshold\n    min_net_cost: cheap\n",
+			wantErr: "invalid transforms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{
+				"config.yaml": "credentials:\n  token: test-token\n" + tt.config,
+			})
+			_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadConfig_SectionDefaults(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := writeConfigFiles(t, map[string]string{"config.yaml": `
+credentials:
+  token: test-token
+params:
+  cost_report_token: cr_test
+  granularity: day
+  finality_lag_days: 0
+sink:
+  path: ./out
+`})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, defaultPageSize, cfg.PageSize)
+	assert.Equal(t, defaultMaxRetries, cfg.MaxRetries)
+	assert.Equal(t, 0, cfg.FinalityLagDays, "an explicit zero overrides the default")
+	assert.Equal(t, defaultVerifyTotalsTolerance, cfg.VerifyTotalsTolerance)
+	assert.Equal(t, SinkTypeFile, cfg.Sink.Type)
+	assert.Equal(t, filepath.Join("./out", "bookmarks.json"), cfg.Bookmarks.Path)
+}
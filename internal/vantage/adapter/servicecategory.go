@@ -0,0 +1,294 @@
+package adapter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// serviceCategoriesAnyProvider is the service_categories key whose entries
+// apply to every provider.
+const serviceCategoriesAnyProvider = "*"
+
+// FOCUS ServiceCategory values.
+const (
+	ServiceCategoryAI             = "AI and Machine Learning"
+	ServiceCategoryAnalytics      = "Analytics"
+	ServiceCategoryBusiness       = "Business Applications"
+	ServiceCategoryCompute        = "Compute"
+	ServiceCategoryDatabases      = "Databases"
+	ServiceCategoryDeveloperTools = "Developer Tools"
+	ServiceCategoryMulticloud     = "Multicloud"
+	ServiceCategoryIdentity       = "Identity"
+	ServiceCategoryIntegration    = "Integration"
+	ServiceCategoryIoT            = "Internet of Things"
+	ServiceCategoryManagement     = "Management and Governance"
+	ServiceCategoryMedia          = "Media"
+	ServiceCategoryMigration      = "Migration"
+	ServiceCategoryMobile         = "Mobile"
+	ServiceCategoryNetworking     = "Networking"
+	ServiceCategorySecurity       = "Security"
+	ServiceCategoryStorage        = "Storage"
+	ServiceCategoryWeb            = "Web"
+	ServiceCategoryOther          = "Other"
+)
+
+// SupportedServiceCategories returns the FOCUS service categories
+// service_categories entries may map to.
+func SupportedServiceCategories() []string {
+	return []string{
+		ServiceCategoryAI, ServiceCategoryAnalytics, ServiceCategoryBusiness, ServiceCategoryCompute,
+		ServiceCategoryDatabases, ServiceCategoryDeveloperTools, ServiceCategoryMulticloud, ServiceCategoryIdentity,
+		ServiceCategoryIntegration, ServiceCategoryIoT, ServiceCategoryManagement, ServiceCategoryMedia,
+		ServiceCategoryMigration, ServiceCategoryMobile, ServiceCategoryNetworking, ServiceCategorySecurity,
+		ServiceCategoryStorage, ServiceCategoryWeb, ServiceCategoryOther,
+	}
+}
+
+// builtinServiceCategories maps the service names Vantage reports for the
+// major providers, lower-cased and keyed by lower-cased provider, to their
+// FOCUS service category. Both display names and billing codes are listed,
+// since providers report either depending on the integration.
+var builtinServiceCategories = map[string]map[string]string{
+	"aws": {
+		"amazon elastic compute cloud - compute": ServiceCategoryCompute,
+		"amazon elastic compute cloud":           ServiceCategoryCompute,
+		"ec2 other":                              ServiceCategoryCompute,
+		"ec2":                                    ServiceCategoryCompute,
+		"amazonec2":                              ServiceCategoryCompute,
+		"aws lambda":                             ServiceCategoryCompute,
+		"awslambda":                              ServiceCategoryCompute,
+		"amazon elastic container service":       ServiceCategoryCompute,
+		"amazonecs":                              ServiceCategoryCompute,
+		"amazon elastic kubernetes service":      ServiceCategoryCompute,
+		"amazoneks":                              ServiceCategoryCompute,
+		"amazon simple storage service":          ServiceCategoryStorage,
+		"s3":                                     ServiceCategoryStorage,
+		"amazons3":                               ServiceCategoryStorage,
+		"amazon elastic block store":             ServiceCategoryStorage,
+		"ebs":                                    ServiceCategoryStorage,
+		"amazon elastic file system":             ServiceCategoryStorage,
+		"amazonefs":                              ServiceCategoryStorage,
+		"amazon relational database service":     ServiceCategoryDatabases,
+		"rds":                                    ServiceCategoryDatabases,
+		"amazonrds":                              ServiceCategoryDatabases,
+		"amazon dynamodb":                        ServiceCategoryDatabases,
+		"amazondynamodb":                         ServiceCategoryDatabases,
+		"amazon elasticache":                     ServiceCategoryDatabases,
+		"amazonelasticache":                      ServiceCategoryDatabases,
+		"amazon redshift":                        ServiceCategoryAnalytics,
+		"amazonredshift":                         ServiceCategoryAnalytics,
+		"amazon athena":                          ServiceCategoryAnalytics,
+		"amazonathena":                           ServiceCategoryAnalytics,
+		"aws glue":                               ServiceCategoryAnalytics,
+		"awsglue":                                ServiceCategoryAnalytics,
+		"amazon kinesis":                         ServiceCategoryAnalytics,
+		"amazonkinesis":                          ServiceCategoryAnalytics,
+		"amazon opensearch service":              ServiceCategoryAnalytics,
+		"amazones":                               ServiceCategoryAnalytics,
+		"amazon sagemaker":                       ServiceCategoryAI,
+		"amazonsagemaker":                        ServiceCategoryAI,
+		"amazon bedrock":                         ServiceCategoryAI,
+		"amazonbedrock":                          ServiceCategoryAI,
+		"amazon cloudfront":                      ServiceCategoryNetworking,
+		"amazoncloudfront":                       ServiceCategoryNetworking,
+		"amazon virtual private cloud":           ServiceCategoryNetworking,
+		"amazonvpc":                              ServiceCategoryNetworking,
+		"amazon route 53":                        ServiceCategoryNetworking,
+		"amazonroute53":                          ServiceCategoryNetworking,
+		"elastic load balancing":                 ServiceCategoryNetworking,
+		"awselb":                                 ServiceCategoryNetworking,
+		"aws data transfer":                      ServiceCategoryNetworking,
+		"awsdatatransfer":                        ServiceCategoryNetworking,
+		"amazon simple queue service":            ServiceCategoryIntegration,
+		"awsqueueservice":                        ServiceCategoryIntegration,
+		"amazon simple notification service":     ServiceCategoryIntegration,
+		"amazonsns":                              ServiceCategoryIntegration,
+		"amazon cloudwatch":                      ServiceCategoryManagement,
+		"amazoncloudwatch":                       ServiceCategoryManagement,
+		"aws cloudtrail":                         ServiceCategoryManagement,
+		"awscloudtrail":                          ServiceCategoryManagement,
+		"aws config":                             ServiceCategoryManagement,
+		"awsconfig":                              ServiceCategoryManagement,
+		"aws key management service":             ServiceCategorySecurity,
+		"awskms":                                 ServiceCategorySecurity,
+		"aws secrets manager":                    ServiceCategorySecurity,
+		"awssecretsmanager":                      ServiceCategorySecurity,
+		"aws waf":                                ServiceCategorySecurity,
+		"awswaf":                                 ServiceCategorySecurity,
+		"amazon guardduty":                       ServiceCategorySecurity,
+		"amazonguardduty":                        ServiceCategorySecurity,
+		"amazon cognito":                         ServiceCategoryIdentity,
+		"amazoncognito":                          ServiceCategoryIdentity,
+		"aws identity and access management":     ServiceCategoryIdentity,
+		"amazon elastic container registry":      ServiceCategoryDeveloperTools,
+		"amazonecr":                              ServiceCategoryDeveloperTools,
+		"aws codebuild":                          ServiceCategoryDeveloperTools,
+		"codebuild":                              ServiceCategoryDeveloperTools,
+	},
+	"azure": {
+		"microsoft.compute":                 ServiceCategoryCompute,
+		"virtual machines":                  ServiceCategoryCompute,
+		"microsoft.containerservice":        ServiceCategoryCompute,
+		"azure kubernetes service":          ServiceCategoryCompute,
+		"microsoft.containerinstance":       ServiceCategoryCompute,
+		"container instances":               ServiceCategoryCompute,
+		"microsoft.web":                     ServiceCategoryWeb,
+		"azure app service":                 ServiceCategoryWeb,
+		"functions":                         ServiceCategoryCompute,
+		"microsoft.storage":                 ServiceCategoryStorage,
+		"storage":                           ServiceCategoryStorage,
+		"microsoft.sql":                     ServiceCategoryDatabases,
+		"sql database":                      ServiceCategoryDatabases,
+		"microsoft.documentdb":              ServiceCategoryDatabases,
+		"azure cosmos db":                   ServiceCategoryDatabases,
+		"microsoft.dbforpostgresql":         ServiceCategoryDatabases,
+		"azure database for postgresql":     ServiceCategoryDatabases,
+		"microsoft.dbformysql":              ServiceCategoryDatabases,
+		"azure database for mysql":          ServiceCategoryDatabases,
+		"microsoft.cache":                   ServiceCategoryDatabases,
+		"redis cache":                       ServiceCategoryDatabases,
+		"microsoft.synapse":                 ServiceCategoryAnalytics,
+		"azure synapse analytics":           ServiceCategoryAnalytics,
+		"microsoft.databricks":              ServiceCategoryAnalytics,
+		"azure databricks":                  ServiceCategoryAnalytics,
+		"microsoft.cognitiveservices":       ServiceCategoryAI,
+		"cognitive services":                ServiceCategoryAI,
+		"microsoft.machinelearningservices": ServiceCategoryAI,
+		"microsoft.network":                 ServiceCategoryNetworking,
+		"virtual network":                   ServiceCategoryNetworking,
+		"bandwidth":                         ServiceCategoryNetworking,
+		"load balancer":                     ServiceCategoryNetworking,
+		"microsoft.cdn":                     ServiceCategoryNetworking,
+		"content delivery network":          ServiceCategoryNetworking,
+		"microsoft.servicebus":              ServiceCategoryIntegration,
+		"service bus":                       ServiceCategoryIntegration,
+		"microsoft.eventhub":                ServiceCategoryIntegration,
+		"event hubs":                        ServiceCategoryIntegration,
+		"microsoft.insights":                ServiceCategoryManagement,
+		"azure monitor":                     ServiceCategoryManagement,
+		"microsoft.operationalinsights":     ServiceCategoryManagement,
+		"log analytics":                     ServiceCategoryManagement,
+		"microsoft.keyvault":                ServiceCategorySecurity,
+		"key vault":                         ServiceCategorySecurity,
+		"microsoft.security":                ServiceCategorySecurity,
+		"microsoft defender for cloud":      ServiceCategorySecurity,
+		"microsoft.containerregistry":       ServiceCategoryDeveloperTools,
+		"container registry":                ServiceCategoryDeveloperTools,
+	},
+	"datadog": {
+		"apm":                  ServiceCategoryManagement,
+		"infrastructure hosts": ServiceCategoryManagement,
+		"logs":                 ServiceCategoryManagement,
+		"log management":       ServiceCategoryManagement,
+		"synthetics":           ServiceCategoryManagement,
+		"real user monitoring": ServiceCategoryManagement,
+		"custom metrics":       ServiceCategoryManagement,
+		"cloud security":       ServiceCategorySecurity,
+	},
+	"gcp": {
+		"compute engine":                     ServiceCategoryCompute,
+		"kubernetes engine":                  ServiceCategoryCompute,
+		"cloud run":                          ServiceCategoryCompute,
+		"cloud run functions":                ServiceCategoryCompute,
+		"cloud functions":                    ServiceCategoryCompute,
+		"app engine":                         ServiceCategoryWeb,
+		"cloud storage":                      ServiceCategoryStorage,
+		"filestore":                          ServiceCategoryStorage,
+		"cloud sql":                          ServiceCategoryDatabases,
+		"cloud spanner":                      ServiceCategoryDatabases,
+		"cloud bigtable":                     ServiceCategoryDatabases,
+		"firestore":                          ServiceCategoryDatabases,
+		"memorystore for redis":              ServiceCategoryDatabases,
+		"bigquery":                           ServiceCategoryAnalytics,
+		"dataflow":                           ServiceCategoryAnalytics,
+		"cloud dataproc":                     ServiceCategoryAnalytics,
+		"vertex ai":                          ServiceCategoryAI,
+		"networking":                         ServiceCategoryNetworking,
+		"cloud dns":                          ServiceCategoryNetworking,
+		"cloud cdn":                          ServiceCategoryNetworking,
+		"cloud load balancing":               ServiceCategoryNetworking,
+		"cloud pub/sub":                      ServiceCategoryIntegration,
+		"cloud logging":                      ServiceCategoryManagement,
+		"cloud monitoring":                   ServiceCategoryManagement,
+		"cloud key management service (kms)": ServiceCategorySecurity,
+		"secret manager":                     ServiceCategorySecurity,
+		"artifact registry":                  ServiceCategoryDeveloperTools,
+		"cloud build":                        ServiceCategoryDeveloperTools,
+	},
+	"mongodb": {
+		"atlas": ServiceCategoryDatabases,
+	},
+	"snowflake": {
+		"compute":        ServiceCategoryAnalytics,
+		"warehouse":      ServiceCategoryAnalytics,
+		"storage":        ServiceCategoryStorage,
+		"data transfer":  ServiceCategoryNetworking,
+		"cloud services": ServiceCategoryAnalytics,
+	},
+}
+
+// serviceCategories maps lower-cased provider and service names to FOCUS
+// service categories: params.service_categories entries over the built-in
+// tables, with serviceCategoriesAnyProvider entries used for services no
+// provider table lists.
+type serviceCategories map[string]map[string]string
+
+// newServiceCategories merges overrides, keyed by provider (or
+// serviceCategoriesAnyProvider) and service name, over
+// builtinServiceCategories.
+func newServiceCategories(overrides map[string]map[string]string) serviceCategories {
+	categories := make(serviceCategories, len(builtinServiceCategories)+len(overrides))
+	for provider, services := range builtinServiceCategories {
+		categories[provider] = maps.Clone(services)
+	}
+	for provider, services := range overrides {
+		provider = strings.ToLower(provider)
+		if categories[provider] == nil {
+			categories[provider] = make(map[string]string, len(services))
+		}
+		for service, category := range services {
+			categories[provider][strings.ToLower(strings.TrimSpace(service))] = canonicalServiceCategory(category)
+		}
+	}
+	return categories
+}
+
+// category returns the FOCUS service category of service as reported by
+// provider, or "" when no table lists it.
+func (c serviceCategories) category(provider, service string) string {
+	service = strings.ToLower(strings.TrimSpace(service))
+	if category, ok := c[strings.ToLower(provider)][service]; ok {
+		return category
+	}
+	return c[serviceCategoriesAnyProvider][service]
+}
+
+// canonicalServiceCategory returns the supported category matching name
+// case-insensitively, or name unchanged when none does.
+func canonicalServiceCategory(name string) string {
+	name = strings.TrimSpace(name)
+	for _, category := range SupportedServiceCategories() {
+		if strings.EqualFold(category, name) {
+			return category
+		}
+	}
+	return name
+}
+
+// validateServiceCategories checks that every service_categories entry maps
+// to a FOCUS service category.
+func validateServiceCategories(cfg *Config) error {
+	for _, provider := range slices.Sorted(maps.Keys(cfg.ServiceCategories)) {
+		services := cfg.ServiceCategories[provider]
+		for _, service := range slices.Sorted(maps.Keys(services)) {
+			category := canonicalServiceCategory(services[service])
+			if !slices.Contains(SupportedServiceCategories(), category) {
+				return fmt.Errorf("invalid service_categories.%s.%s: %s (valid: %s)",
+					provider, service, services[service], strings.Join(SupportedServiceCategories(), ", "))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package adapter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestServiceCategories_Category(t *testing.T) {
+	categories := newServiceCategories(map[string]map[string]string{
+		"aws": {"Amazon Elastic Compute Cloud - Compute": "storage"},
+		"*":   {"Support": "management and governance", "Amazon Simple Storage Service": "Other"},
+	})
+
+	assert.Equal(t, ServiceCategoryDatabases, categories.category("AWS", "Amazon Relational Database Service"))
+	assert.Equal(t, ServiceCategoryCompute, categories.category("azure", "Microsoft.Compute"))
+	assert.Equal(t, ServiceCategoryStorage, categories.category("aws", "amazon elastic compute cloud - compute"),
+		"overrides win over the built-in table")
+	assert.Equal(t, ServiceCategoryManagement, categories.category("gcp", "Support"))
+	assert.Equal(t, ServiceCategoryStorage, categories.category("aws", "Amazon Simple Storage Service"),
+		"provider tables win over * entries")
+	assert.Empty(t, categories.category("gcp", "Quantum Annealer"))
+
+	assert.Empty(t, newServiceCategories(nil).category("aws", "Support"))
+}
+
+func TestAdapter_mapVantageRowToCostRecord_ServiceCategory(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	query := client.Query{CostReportToken: "cr_test", Granularity: "day"}
+
+	record := adapter.mapVantageRowToCostRecord(client.CostRow{Provider: "gcp", Service: "BigQuery", Cost: 1}, query, "hash", "cost")
+	assert.Equal(t, ServiceCategoryAnalytics, record.ServiceCategory)
+	if record.Diagnostics != nil {
+		assert.NotContains(t, record.Diagnostics.Warnings, "unmapped_service_category")
+	}
+
+	record = adapter.mapVantageRowToCostRecord(client.CostRow{Provider: "gcp", Service: "Gemini", Cost: 1}, query, "hash", "cost")
+	assert.Empty(t, record.ServiceCategory)
+	require.NotNil(t, record.Diagnostics)
+	assert.Contains(t, record.Diagnostics.Warnings, "unmapped_service_category")
+
+	adapter.serviceCategories = newServiceCategories(map[string]map[string]string{"gcp": {"gemini": "AI and Machine Learning"}})
+	record = adapter.mapVantageRowToCostRecord(client.CostRow{Provider: "gcp", Service: "Gemini", Cost: 1}, query, "hash", "cost")
+	assert.Equal(t, ServiceCategoryAI, record.ServiceCategory)
+}
+
+func TestLoadConfig_ServiceCategories(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	load := func(params string) (*Config, error) {
+		dir := writeConfigFiles(t, map[string]string{
+			"config.yaml": "credentials:\n  token: test-token\nparams:\n  cost_report_token: cr_test\n" +
+				"  granularity: day\n" + params,
+		})
+		return LoadConfig(filepath.Join(dir, "config.yaml"))
+	}
+
+	cfg, err := load("  service_categories:\n    AWS:\n      AWS Support (Business): management and governance\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"aws": {"aws support (business)": "management and governance"},
+	}, cfg.ServiceCategories)
+
+	_, err = load("  service_categories:\n    aws:\n      EC2: Servers\n")
+	require.ErrorContains(t, err, "invalid service_categories.aws.ec2: Servers (valid: AI and Machine Learning, ")
+}
@@ -0,0 +1,184 @@
+package adapter
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	// defaultSinkWriteRetries is how many more times records a sink failed
+	// to write are retried, unless sink.write_retries is set.
+	defaultSinkWriteRetries = 2
+
+	// defaultSinkRetryBackoff is the wait before the first retry of failed
+	// records; it doubles with each further retry.
+	defaultSinkRetryBackoff = 500 * time.Millisecond
+)
+
+// ErrRecordRejected marks a record error a sink will never accept, such as
+// a constraint violation or a record it cannot encode. Rejected records are
+// dropped and counted rather than retried.
+var ErrRecordRejected = errors.New("record rejected by sink")
+
+// PartialWriteError is returned by a Sink that wrote some records of a batch
+// but not others. Failed maps the batch index of each record not written to
+// why; every other record was written. The adapter writes the failed records
+// again, except those whose error wraps ErrRecordRejected, instead of the
+// whole batch.
+//
+// Any other error from WriteRecords means the batch may not have been
+// written at all, and fails the sync.
+type PartialWriteError struct {
+	Failed map[int]error
+}
+
+// Error implements error.
+func (e *PartialWriteError) Error() string {
+	indexes := e.indexes()
+	if len(indexes) == 0 {
+		return "partial write: no records failed"
+	}
+	first := indexes[0]
+	return fmt.Sprintf("%d records not written: record %d: %v", len(indexes), first, e.Failed[first])
+}
+
+// Unwrap returns the record errors in batch order, so errors.Is finds a
+// cause shared by any of them.
+func (e *PartialWriteError) Unwrap() []error {
+	indexes := e.indexes()
+	errs := make([]error, 0, len(indexes))
+	for _, i := range indexes {
+		errs = append(errs, e.Failed[i])
+	}
+	return errs
+}
+
+// indexes returns the failed record indexes in ascending order.
+func (e *PartialWriteError) indexes() []int {
+	indexes := make([]int, 0, len(e.Failed))
+	for i := range e.Failed {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+	return indexes
+}
+
+// failedRecord is a record the sink did not write: rejected, or still
+// failing after the last retry.
+type failedRecord struct {
+	// index is the record's position in the records written.
+	index    int
+	err      error
+	attempts int
+}
+
+// rejected reports whether the sink rejected the record.
+func (f failedRecord) rejected() bool {
+	return errors.Is(f.err, ErrRecordRejected)
+}
+
+// writeToSink writes records to sink, returning how many it wrote and the
+// records it did not, in order. After a partial write, only the failed
+// records are written again, up to sinkWriteRetries more times; records the
+// sink rejected are not retried. The error is set only when a write failed
+// as a whole.
+func (a *Adapter) writeToSink(ctx context.Context, sink Sink, records []CostRecord) (int, []failedRecord, error) {
+	indexes := make([]int, len(records))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	written := 0
+	var failed []failedRecord
+	backoff := a.sinkRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := sink.WriteRecords(ctx, records)
+		if err == nil {
+			written += len(records)
+			break
+		}
+		var partial *PartialWriteError
+		if !errors.As(err, &partial) {
+			return written, failed, err
+		}
+
+		var retry []CostRecord
+		var retryIndexes []int
+		var retryErrs []error
+		for _, i := range partial.indexes() {
+			if i < 0 || i >= len(records) {
+				return written, failed, fmt.Errorf("sink reported record %d of a batch of %d as failed", i, len(records))
+			}
+			if errors.Is(partial.Failed[i], ErrRecordRejected) {
+				failed = append(failed, failedRecord{index: indexes[i], err: partial.Failed[i], attempts: attempt})
+				continue
+			}
+			retry = append(retry, records[i])
+			retryIndexes = append(retryIndexes, indexes[i])
+			retryErrs = append(retryErrs, partial.Failed[i])
+		}
+		written += len(records) - len(partial.Failed)
+		if len(retry) == 0 {
+			break
+		}
+		if attempt > a.sinkWriteRetries {
+			for j, index := range retryIndexes {
+				failed = append(failed, failedRecord{index: index, err: retryErrs[j], attempts: attempt})
+			}
+			break
+		}
+
+		a.logger.Warn(ctx, "Sink failed to write some records; retrying them", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "write_records",
+			"attempt":   attempt,
+			"failed":    len(retry),
+			"batch":     len(records),
+			"error":     err.Error(),
+		})
+		select {
+		case <-ctx.Done():
+			return written, failed, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		records, indexes = retry, retryIndexes
+	}
+
+	slices.SortFunc(failed, func(x, y failedRecord) int { return cmp.Compare(x.index, y.index) })
+	return written, failed, nil
+}
+
+// handleFailedRecords counts the records the sink rejected and, with a
+// dead-letter queue, adds every failed record to it. Without one, records
+// that failed other than by rejection fail the sync.
+func (a *Adapter) handleFailedRecords(ctx context.Context, records []CostRecord, failed []failedRecord) error {
+	var unwritten []failedRecord
+	for _, f := range failed {
+		if !f.rejected() {
+			unwritten = append(unwritten, f)
+			continue
+		}
+		a.diagnosticsSummary.RejectedRecords++
+		a.logger.Warn(ctx, "Sink rejected a record", map[string]interface{}{
+			"adapter":      "vantage",
+			"operation":    "write_records",
+			"line_item_id": records[f.index].LineItemID,
+			"metric_type":  records[f.index].MetricType,
+			"error":        f.err.Error(),
+		})
+	}
+
+	if a.deadLetters != nil && len(failed) > 0 {
+		return a.addDeadLetters(ctx, records, failed)
+	}
+	if len(unwritten) > 0 {
+		return fmt.Errorf("writing %d records failed after %d retries: %w",
+			len(unwritten), a.sinkWriteRetries, unwritten[0].err)
+	}
+	return nil
+}
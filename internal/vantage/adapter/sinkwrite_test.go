@@ -0,0 +1,240 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+var errThrottled = errors.New("throttled")
+
+// partialSink fails each record whose LineItemID is in fail, as many times
+// as fail says, rejecting those in reject, and records what it wrote.
+type partialSink struct {
+	fail    map[string]int
+	reject  map[string]bool
+	err     error
+	calls   [][]string
+	written []string
+}
+
+func (s *partialSink) WriteRecords(_ context.Context, records []CostRecord) error {
+	if s.err != nil {
+		return s.err
+	}
+	var call []string
+	failed := make(map[int]error)
+	for i, record := range records {
+		call = append(call, record.LineItemID)
+		switch {
+		case s.reject[record.LineItemID]:
+			failed[i] = errors.Join(ErrRecordRejected, errors.New("constraint violated"))
+		case s.fail[record.LineItemID] > 0:
+			s.fail[record.LineItemID]--
+			failed[i] = errThrottled
+		default:
+			s.written = append(s.written, record.LineItemID)
+		}
+	}
+	s.calls = append(s.calls, call)
+	if len(failed) > 0 {
+		return &PartialWriteError{Failed: failed}
+	}
+	return nil
+}
+
+func sinkWriteAdapter(retries int) *Adapter {
+	a := New(&mockClient{}, client.NewNoopLogger())
+	a.sinkWriteRetries = retries
+	a.sinkRetryBackoff = 0
+	return a
+}
+
+func recordsWithIDs(ids ...string) []CostRecord {
+	records := make([]CostRecord, len(ids))
+	for i, id := range ids {
+		records[i].LineItemID = id
+	}
+	return records
+}
+
+func TestWriteRecords_RetriesOnlyFailedRecords(t *testing.T) {
+	a := sinkWriteAdapter(2)
+	sink := &partialSink{fail: map[string]int{"b": 1, "d": 2}}
+
+	require.NoError(t, a.writeRecords(context.Background(), sink, recordsWithIDs("a", "b", "c", "d")))
+	assert.Equal(t, [][]string{{"a", "b", "c", "d"}, {"b", "d"}, {"d"}}, sink.calls)
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, sink.written)
+	assert.Equal(t, 4, a.stats.RecordsWritten)
+}
+
+func TestWriteRecords_DropsRejectedRecords(t *testing.T) {
+	a := sinkWriteAdapter(2)
+	sink := &partialSink{reject: map[string]bool{"b": true}}
+
+	require.NoError(t, a.writeRecords(context.Background(), sink, recordsWithIDs("a", "b", "c")))
+	assert.Len(t, sink.calls, 1, "rejected records are not retried")
+	assert.Equal(t, []string{"a", "c"}, sink.written)
+	assert.Equal(t, 2, a.stats.RecordsWritten)
+	assert.Equal(t, 1, a.diagnosticsSummary.RejectedRecords)
+}
+
+func TestWriteRecords_RetriesExhausted(t *testing.T) {
+	a := sinkWriteAdapter(1)
+	sink := &partialSink{fail: map[string]int{"b": 5}}
+
+	err := a.writeRecords(context.Background(), sink, recordsWithIDs("a", "b"))
+	require.ErrorIs(t, err, ErrSink)
+	require.ErrorIs(t, err, errThrottled)
+	assert.Contains(t, err.Error(), "writing 1 records failed after 1 retries: throttled")
+	assert.Len(t, sink.calls, 2)
+	assert.Equal(t, 1, a.stats.RecordsWritten)
+}
+
+func TestWriteRecords_WholeBatchFailure(t *testing.T) {
+	a := sinkWriteAdapter(2)
+	sink := &partialSink{err: errors.New("disk full")}
+
+	err := a.writeRecords(context.Background(), sink, recordsWithIDs("a", "b"))
+	require.ErrorIs(t, err, ErrSink)
+	assert.Contains(t, err.Error(), "disk full")
+	assert.Zero(t, a.stats.RecordsWritten)
+}
+
+type outOfRangeSink struct{}
+
+func (outOfRangeSink) WriteRecords(context.Context, []CostRecord) error {
+	return &PartialWriteError{Failed: map[int]error{5: errThrottled}}
+}
+
+func TestWriteRecords_PartialWriteOutOfRange(t *testing.T) {
+	a := sinkWriteAdapter(2)
+
+	err := a.writeRecords(context.Background(), outOfRangeSink{}, recordsWithIDs("a"))
+	require.ErrorIs(t, err, ErrSink)
+	assert.Contains(t, err.Error(), "sink reported record 5 of a batch of 1 as failed")
+}
+
+func TestPartialWriteError(t *testing.T) {
+	rejected := errors.Join(ErrRecordRejected, errors.New("bad row"))
+	err := &PartialWriteError{Failed: map[int]error{3: errThrottled, 1: rejected}}
+
+	assert.Equal(t, "2 records not written: record 1: record rejected by sink\nbad row", err.Error())
+	assert.Equal(t, []error{rejected, errThrottled}, err.Unwrap())
+	assert.ErrorIs(t, err, ErrRecordRejected)
+	assert.ErrorIs(t, err, errThrottled)
+	assert.Equal(t, "partial write: no records failed", (&PartialWriteError{}).Error())
+}
+
+// memoryDeadLetters is an in-memory DeadLetterQueue.
+type memoryDeadLetters struct {
+	letters []DeadLetter
+	err     error
+}
+
+func (q *memoryDeadLetters) AddDeadLetters(_ context.Context, letters []DeadLetter) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.letters = append(q.letters, letters...)
+	return nil
+}
+
+func TestWriteRecords_DeadLettersFailedRecords(t *testing.T) {
+	a := sinkWriteAdapter(1)
+	a.correlationID = "corr-1"
+	queue := &memoryDeadLetters{}
+	a.SetDeadLetterQueue(queue)
+	sink := &partialSink{fail: map[string]int{"c": 5}, reject: map[string]bool{"a": true}}
+
+	require.NoError(t, a.writeRecords(context.Background(), sink, recordsWithIDs("a", "b", "c")))
+	assert.Equal(t, []string{"b"}, sink.written)
+	require.Len(t, queue.letters, 2)
+
+	rejected, failed := queue.letters[0], queue.letters[1]
+	assert.Equal(t, "a", rejected.Record.LineItemID)
+	assert.True(t, rejected.Rejected)
+	assert.Equal(t, 1, rejected.Attempts)
+	assert.Contains(t, rejected.Error, "constraint violated")
+	assert.Equal(t, "c", failed.Record.LineItemID)
+	assert.False(t, failed.Rejected)
+	assert.Equal(t, 2, failed.Attempts)
+	assert.Equal(t, "throttled", failed.Error)
+	assert.Equal(t, "corr-1", failed.CorrelationID)
+	assert.False(t, failed.FailedAt.IsZero())
+
+	assert.Equal(t, 1, a.diagnosticsSummary.RejectedRecords)
+	assert.Equal(t, 2, a.diagnosticsSummary.DeadLetterRecords)
+}
+
+func TestWriteRecords_DeadLetterQueueFailure(t *testing.T) {
+	a := sinkWriteAdapter(0)
+	a.SetDeadLetterQueue(&memoryDeadLetters{err: errors.New("queue unavailable")})
+	sink := &partialSink{fail: map[string]int{"a": 1}}
+
+	err := a.writeRecords(context.Background(), sink, recordsWithIDs("a"))
+	require.ErrorIs(t, err, ErrSink)
+	assert.Contains(t, err.Error(), "adding 1 records to the dead-letter queue: queue unavailable")
+}
+
+func TestWriteRecords_DeadLetterDropsUnencodableRecords(t *testing.T) {
+	a := sinkWriteAdapter(0)
+	queue := &memoryDeadLetters{}
+	a.SetDeadLetterQueue(queue)
+	sink := &partialSink{reject: map[string]bool{"nan": true}}
+	records := recordsWithIDs("nan")
+	nan := math.NaN()
+	records[0].NetCost = &nan
+
+	require.NoError(t, a.writeRecords(context.Background(), sink, records))
+	assert.Empty(t, queue.letters)
+	assert.Equal(t, 1, a.diagnosticsSummary.RejectedRecords)
+	assert.Zero(t, a.diagnosticsSummary.DeadLetterRecords)
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	letters := make([]DeadLetter, 3)
+	for i, id := range []string{"a", "b", "c"} {
+		letters[i] = DeadLetter{Record: CostRecord{LineItemID: id}, Error: "throttled", Attempts: 3}
+	}
+	sink := &partialSink{fail: map[string]int{"b": 5}}
+	a := New(nil, client.NewNoopLogger())
+	a.sinkRetryBackoff = 0
+
+	remaining, err := a.ReplayDeadLetters(context.Background(), Config{BatchSize: 2, Sink: SinkConfig{WriteRetries: 1}}, sink, letters)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"b"}, {"c"}}, sink.calls)
+	assert.Equal(t, []string{"a", "c"}, sink.written)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "b", remaining[0].Record.LineItemID)
+	assert.Equal(t, 5, remaining[0].Attempts, "replay attempts add to earlier ones")
+	assert.NotEmpty(t, remaining[0].CorrelationID)
+	assert.Equal(t, 2, a.GetSyncStats().RecordsWritten)
+}
+
+func TestReplayDeadLetters_WholeBatchFailure(t *testing.T) {
+	letters := []DeadLetter{{Record: CostRecord{LineItemID: "a"}}, {Record: CostRecord{LineItemID: "b"}}}
+	a := New(nil, client.NewNoopLogger())
+
+	remaining, err := a.ReplayDeadLetters(context.Background(), Config{}, &partialSink{err: errors.New("disk full")}, letters)
+	require.ErrorIs(t, err, ErrSink)
+	assert.Equal(t, letters, remaining, "letters not written stay queued")
+}
+
+func TestDecodeDeadLetter(t *testing.T) {
+	letter, err := DecodeDeadLetter([]byte(`{"record":{"line_item_id":"x"},"error":"too long","rejected":true,"attempts":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, "x", letter.Record.LineItemID)
+	assert.Equal(t, CurrentSchemaVersion, letter.Record.SchemaVersion)
+	assert.Equal(t, "too long", letter.Error)
+	assert.True(t, letter.Rejected)
+
+	_, err = DecodeDeadLetter([]byte(`{"record":`))
+	require.Error(t, err)
+}
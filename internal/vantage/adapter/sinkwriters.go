@@ -0,0 +1,143 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sinkWriters writes batches to the sink from a pool of goroutines, so a
+// slow sink's writes overlap each other and fetching. Batches wait in a
+// bounded queue; once it is full, submit blocks, which stops the page loop
+// and with it fetching until a writer catches up, so memory stays bounded
+// by the queue however far the sink lags.
+//
+// Only the sink writes run on the pool: batches are prepared and their
+// results handled on the submitting goroutine, which owns the adapter's
+// state.
+type sinkWriters struct {
+	a    *Adapter
+	sink Sink
+
+	queue   chan *sinkBatch
+	results chan sinkWriteResult
+	wg      sync.WaitGroup
+	// pending counts batches submitted whose results are not yet handled.
+	pending int
+	err     error
+	// waited is how long submit blocked on a full queue.
+	waited time.Duration
+}
+
+// sinkWriteResult is the outcome of writing one batch.
+type sinkWriteResult struct {
+	batch   *sinkBatch
+	written int
+	failed  []failedRecord
+	err     error
+}
+
+// newSinkWriters starts writers goroutines writing batches to sink, with up
+// to queueSize batches waiting for one.
+func (a *Adapter) newSinkWriters(ctx context.Context, sink Sink, writers, queueSize int) *sinkWriters {
+	w := &sinkWriters{
+		a:     a,
+		sink:  sink,
+		queue: make(chan *sinkBatch, queueSize),
+		// Room for every batch queued or being written, so a writer never
+		// blocks handing back a result.
+		results: make(chan sinkWriteResult, queueSize+writers),
+	}
+	for range writers {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for batch := range w.queue {
+				w.results <- w.write(ctx, batch)
+			}
+		}()
+	}
+	return w
+}
+
+// write writes one batch to the sink.
+func (w *sinkWriters) write(ctx context.Context, batch *sinkBatch) sinkWriteResult {
+	ctx, span := tracer().Start(ctx, "vantage.sink_write", trace.WithAttributes(
+		attribute.Int(attrRecords, len(batch.records)),
+	))
+	written, failed, err := w.a.writeToSink(ctx, w.sink, batch.records)
+	finishSpan(span, err)
+	return sinkWriteResult{batch: batch, written: written, failed: failed, err: err}
+}
+
+// submit prepares records and queues them for a writer, blocking while the
+// queue is full. It returns the error of any batch written since the last
+// call, after which nothing more may be submitted.
+func (w *sinkWriters) submit(ctx context.Context, records []CostRecord) error {
+	if err := w.handle(ctx, false); err != nil {
+		return err
+	}
+	batch, err := w.a.prepareBatch(ctx, w.sink, records)
+	if err != nil || batch == nil {
+		return err
+	}
+
+	select {
+	case w.queue <- batch:
+	default:
+		start := time.Now()
+		select {
+		case w.queue <- batch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		w.waited += time.Since(start)
+	}
+	w.pending++
+	return nil
+}
+
+// wait lets the writers finish every queued batch, handles their results,
+// and returns the first error.
+func (w *sinkWriters) wait(ctx context.Context) error {
+	close(w.queue)
+	err := w.handle(ctx, true)
+	w.wg.Wait()
+
+	if w.waited > 0 {
+		waited, _ := w.a.diagnosticsSummary.SourceInfo["sink_backpressure_seconds"].(float64)
+		w.a.diagnosticsSummary.SourceInfo["sink_backpressure_seconds"] = waited + w.waited.Seconds()
+		w.a.logger.Debug(ctx, "Fetching waited for the sink to catch up", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "write_records",
+			"attempt":   0,
+			"waited_ms": w.waited.Milliseconds(),
+		})
+	}
+	return err
+}
+
+// handle handles the results of finished batches, waiting for every pending
+// batch when all is set.
+func (w *sinkWriters) handle(ctx context.Context, all bool) error {
+	for w.pending > 0 {
+		var result sinkWriteResult
+		if all {
+			result = <-w.results
+		} else {
+			select {
+			case result = <-w.results:
+			default:
+				return w.err
+			}
+		}
+		w.pending--
+		if err := w.a.finishBatch(ctx, result.batch, result.written, result.failed, result.err); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+	return w.err
+}
@@ -0,0 +1,160 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// gatedSink holds every write until release is closed, counting writes in
+// flight.
+type gatedSink struct {
+	release chan struct{}
+	err     error
+
+	mu          sync.Mutex
+	records     []CostRecord
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	started     chan struct{}
+}
+
+func newGatedSink() *gatedSink {
+	return &gatedSink{release: make(chan struct{}), started: make(chan struct{}, 100)}
+}
+
+func (s *gatedSink) WriteRecords(ctx context.Context, records []CostRecord) error {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		peak := s.maxInFlight.Load()
+		if n <= peak || s.maxInFlight.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	s.started <- struct{}{}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func TestSinkWriters_Backpressure(t *testing.T) {
+	ctx := context.Background()
+	a := New(&mockClient{}, client.NewNoopLogger())
+	sink := newGatedSink()
+	writers := a.newSinkWriters(ctx, sink, 1, 1)
+
+	require.NoError(t, writers.submit(ctx, recordsWithIDs("a")))
+	<-sink.started
+	require.NoError(t, writers.submit(ctx, recordsWithIDs("b")), "one batch may wait for the writer")
+
+	submitted := make(chan error)
+	go func() { submitted <- writers.submit(ctx, recordsWithIDs("c")) }()
+	select {
+	case <-submitted:
+		t.Fatal("submit returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sink.release)
+	require.NoError(t, <-submitted)
+	require.NoError(t, writers.wait(ctx))
+
+	assert.Len(t, sink.records, 3)
+	assert.Equal(t, 3, a.stats.RecordsWritten)
+	assert.Positive(t, a.diagnosticsSummary.SourceInfo["sink_backpressure_seconds"])
+}
+
+func TestSinkWriters_WritesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	a := New(&mockClient{}, client.NewNoopLogger())
+	sink := newGatedSink()
+	writers := a.newSinkWriters(ctx, sink, 3, 0)
+
+	done := make(chan error)
+	go func() {
+		for _, id := range []string{"a", "b", "c"} {
+			if err := writers.submit(ctx, recordsWithIDs(id)); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for range 3 {
+		<-sink.started
+	}
+	close(sink.release)
+	require.NoError(t, <-done)
+	require.NoError(t, writers.wait(ctx))
+
+	assert.Equal(t, int32(3), sink.maxInFlight.Load())
+	assert.Equal(t, 3, a.stats.RecordsWritten)
+}
+
+func TestSinkWriters_Error(t *testing.T) {
+	ctx := context.Background()
+	a := New(&mockClient{}, client.NewNoopLogger())
+	sink := newGatedSink()
+	sink.err = errors.New("connection reset")
+	close(sink.release)
+	writers := a.newSinkWriters(ctx, sink, 2, 2)
+
+	require.NoError(t, writers.submit(ctx, recordsWithIDs("a")))
+	err := writers.wait(ctx)
+	require.ErrorIs(t, err, ErrSink)
+	assert.Contains(t, err.Error(), "connection reset")
+}
+
+func TestAdapter_SyncSingleRange_ParallelWriters(t *testing.T) {
+	mockClient := &mockClient{}
+	a := New(mockClient, client.NewNoopLogger())
+	a.sinkWriters = 4
+	a.sinkWriteQueue = 2
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rows []client.CostRow
+	for _, service := range []string{"EC2", "S3", "RDS", "Lambda", "EBS", "CloudFront", "SQS"} {
+		rows = append(rows, client.CostRow{BucketStart: startDate, Provider: "aws", Service: service, Cost: 1})
+	}
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{Data: rows}, nil)
+	sink := &storedSink{}
+	lockedSink := &lockingSink{sink: sink}
+
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day", Metrics: []string{"cost"}, BatchSize: 2}
+	require.NoError(t, a.syncSingleRange(context.Background(), cfg, lockedSink, startDate, startDate.AddDate(0, 0, 1), true))
+
+	assert.Len(t, uniqueIDs(sink.ids()), 7)
+	assert.Equal(t, 7, a.stats.RecordsWritten)
+}
+
+// lockingSink serializes writes to a sink that is not safe for concurrent
+// use.
+type lockingSink struct {
+	mu   sync.Mutex
+	sink Sink
+}
+
+func (s *lockingSink) WriteRecords(ctx context.Context, records []CostRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sink.WriteRecords(ctx, records)
+}
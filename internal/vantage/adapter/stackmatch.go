@@ -0,0 +1,72 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/stack"
+)
+
+// defaultURNLabels are the labels the pulumi_stack transform reads a URN
+// from when urn_labels is unset: a "pulumi:urn" or "pulumi_urn" tag, as
+// normalized into record labels.
+var defaultURNLabels = []string{"pulumi:urn", "pulumi-urn"}
+
+// stackMatcher stamps each cost record with the Pulumi URN and type of the
+// stack resource it was incurred by. A URN label naming an indexed resource
+// wins; otherwise the record's resource ID, then its parsed resource name,
+// must name exactly one indexed resource.
+type stackMatcher struct {
+	index     *stack.Index
+	urnLabels []string
+}
+
+// newStackMatcher loads and indexes the resources of files. Label keys are
+// normalized like provider tag keys so they meet the record's labels.
+func newStackMatcher(files, urnLabels []string) (stackMatcher, error) {
+	var resources []stack.Resource
+	for _, file := range files {
+		loaded, err := stack.Load(file)
+		if err != nil {
+			return stackMatcher{}, err
+		}
+		resources = append(resources, loaded...)
+	}
+
+	if len(urnLabels) == 0 {
+		urnLabels = defaultURNLabels
+	}
+	labels := make([]string, 0, len(urnLabels))
+	for _, key := range urnLabels {
+		normalized := kebabCase(key)
+		if normalized == "" {
+			return stackMatcher{}, fmt.Errorf("urn_labels: invalid key %q", key)
+		}
+		labels = append(labels, normalized)
+	}
+	return stackMatcher{index: stack.NewIndex(resources), urnLabels: labels}, nil
+}
+
+// Transform implements Transformer.
+func (m stackMatcher) Transform(_ context.Context, records []CostRecord) ([]CostRecord, error) {
+	for i := range records {
+		if records[i].MetricType != "cost" {
+			continue
+		}
+		if resource, ok := m.match(&records[i]); ok {
+			records[i].PulumiURN = resource.URN
+			records[i].PulumiType = resource.Type
+		}
+	}
+	return records, nil
+}
+
+// match finds the stack resource record was incurred by.
+func (m stackMatcher) match(record *CostRecord) (stack.Resource, bool) {
+	for _, key := range m.urnLabels {
+		if resource, ok := m.index.Lookup(record.Labels[key]); ok {
+			return resource, true
+		}
+	}
+	return m.index.Match(record.ResourceID, record.ResourceName)
+}
@@ -0,0 +1,101 @@
+package adapter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStackExport = `{
+  "version": 3,
+  "deployment": {
+    "resources": [
+      {"urn": "urn:pulumi:dev::shop::pulumi:pulumi:Stack::shop-dev", "type": "pulumi:pulumi:Stack"},
+      {
+        "urn": "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web",
+        "type": "aws:ec2/instance:Instance",
+        "id": "i-0abc",
+        "outputs": {"arn": "arn:aws:ec2:us-east-1:123456789012:instance/i-0abc"}
+      },
+      {
+        "urn": "urn:pulumi:dev::shop::aws:s3/bucket:Bucket::assets",
+        "type": "aws:s3/bucket:Bucket",
+        "id": "shop-assets"
+      }
+    ]
+  }
+}`
+
+func writeStackExport(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stack.json")
+	require.NoError(t, os.WriteFile(path, []byte(testStackExport), 0o600))
+	return path
+}
+
+func TestStackMatcher_Transform(t *testing.T) {
+	transforms, err := newTransforms([]TransformConfig{{Type: TransformPulumiStack, StackFiles: []string{writeStackExport(t)}}})
+	require.NoError(t, err)
+
+	records := []CostRecord{
+		// Matched by ARN.
+		{MetricType: "cost", ResourceID: "arn:aws:ec2:us-east-1:123456789012:instance/i-0abc"},
+		// Matched by the bucket name parsed from its ARN.
+		{MetricType: "cost", ResourceID: "arn:aws:s3:::shop-assets", ResourceName: "shop-assets"},
+		// The URN label wins over the resource ID.
+		{
+			MetricType: "cost",
+			ResourceID: "i-0abc",
+			Labels:     map[string]string{"pulumi-urn": "urn:pulumi:dev::shop::aws:s3/bucket:Bucket::assets"},
+		},
+		// A URN label naming no stack resource falls back to the resource ID.
+		{
+			MetricType: "cost",
+			ResourceID: "i-0abc",
+			Labels:     map[string]string{"pulumi:urn": "urn:pulumi:prod::shop::aws:ec2/instance:Instance::web"},
+		},
+		{MetricType: "cost", ResourceID: "i-0def"},
+		{MetricType: "forecast", ResourceID: "i-0abc"},
+	}
+	records, err = transforms[0].Transform(context.Background(), records)
+	require.NoError(t, err)
+
+	web, bucket := "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web", "urn:pulumi:dev::shop::aws:s3/bucket:Bucket::assets"
+	assert.Equal(t, web, records[0].PulumiURN)
+	assert.Equal(t, "aws:ec2/instance:Instance", records[0].PulumiType)
+	assert.Equal(t, bucket, records[1].PulumiURN)
+	assert.Equal(t, "aws:s3/bucket:Bucket", records[1].PulumiType)
+	assert.Equal(t, bucket, records[2].PulumiURN)
+	assert.Equal(t, web, records[3].PulumiURN)
+	assert.Empty(t, records[4].PulumiURN)
+	assert.Empty(t, records[5].PulumiURN, "only cost records are matched")
+}
+
+func TestStackMatcher_URNLabels(t *testing.T) {
+	matcher, err := newStackMatcher([]string{writeStackExport(t)}, []string{"Stack_URN"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stack-urn"}, matcher.urnLabels)
+
+	records, err := matcher.Transform(context.Background(), []CostRecord{
+		{MetricType: "cost", Labels: map[string]string{"stack-urn": "urn:pulumi:dev::shop::aws:s3/bucket:Bucket::assets"}},
+		{MetricType: "cost", Labels: map[string]string{"pulumi-urn": "urn:pulumi:dev::shop::aws:s3/bucket:Bucket::assets"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "aws:s3/bucket:Bucket", records[0].PulumiType)
+	assert.Empty(t, records[1].PulumiURN, "urn_labels replaces the default labels")
+
+	_, err = newStackMatcher([]string{writeStackExport(t)}, []string{"--"})
+	assert.EqualError(t, err, `urn_labels: invalid key "--"`)
+}
+
+func TestStackMatcher_MissingFile(t *testing.T) {
+	_, err := newTransforms([]TransformConfig{{
+		Type:       TransformPulumiStack,
+		StackFiles: []string{filepath.Join(t.TempDir(), "missing.json")},
+	}})
+	assert.ErrorContains(t, err, "transforms[0]: reading stack file")
+}
@@ -0,0 +1,45 @@
+package adapter
+
+// SyncStats counts the work done by one sync, for run summaries.
+type SyncStats struct {
+	// RecordsWritten is every record the sink accepted, forecasts included.
+	RecordsWritten int `json:"records_written"`
+	// ForecastRecords is how many of RecordsWritten were forecast records.
+	ForecastRecords int `json:"forecast_records"`
+	// RecordsDropped is the records transforms removed before writing.
+	RecordsDropped int `json:"records_dropped"`
+	// Pages is the cost pages fetched.
+	Pages int `json:"pages"`
+	// Chunks is the date ranges synced; ChunksSkipped those a backfill
+	// skipped because an earlier run checkpointed them.
+	Chunks        int `json:"chunks"`
+	ChunksSkipped int `json:"chunks_skipped"`
+	// ChunksRemaining lists the date ranges a backfill did not finish
+	// because it stopped early; a rerun resumes from the first.
+	ChunksRemaining []ChunkRange `json:"chunks_remaining,omitempty"`
+	// Bookmarks lists each bookmark or checkpoint the sync wrote, apart
+	// from the data quality history.
+	Bookmarks []BookmarkChange `json:"bookmarks"`
+	// Watermark is the report's latest final bucket, set when the sync
+	// fetched at least one final bucket.
+	Watermark *Watermark `json:"watermark,omitempty"`
+}
+
+// BookmarkChange is one bookmark written by a sync. Before is "" when the
+// bookmark did not exist.
+type BookmarkChange struct {
+	Key    string `json:"key"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ChunkRange is one backfill chunk, [Start, End) as YYYY-MM-DD.
+type ChunkRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// GetSyncStats returns the counts from the last sync operation.
+func (a *Adapter) GetSyncStats() SyncStats {
+	return a.stats
+}
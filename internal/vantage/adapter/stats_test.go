@@ -0,0 +1,114 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestAdapter_SyncStats_Incremental(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	store := bookmark.NewMemory()
+
+	a := New(mockClient, client.NewNoopLogger())
+	a.SetBookmarkStore(store)
+
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day", PageSize: 100}
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Service: "ec2", Cost: 1},
+			{BucketStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Service: "s3", Cost: 2},
+		},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, a.Sync(context.Background(), cfg, mockSink))
+
+	stats := a.GetSyncStats()
+	assert.Equal(t, 2, stats.RecordsWritten)
+	assert.Equal(t, 1, stats.Pages)
+	assert.Equal(t, 1, stats.Chunks)
+	require.Len(t, stats.Bookmarks, 2)
+	assert.Empty(t, stats.Bookmarks[0].Before)
+
+	saved, err := store.GetBookmark(context.Background(), stats.Bookmarks[0].Key)
+	require.NoError(t, err)
+	assert.Equal(t, saved, stats.Bookmarks[0].After)
+
+	require.NotNil(t, stats.Watermark)
+	assert.Equal(t, "2024-01-01", stats.Watermark.BucketStart)
+	assert.Equal(t, watermarkBookmarkKey(stats.Watermark.QueryHash), stats.Bookmarks[1].Key)
+}
+
+func TestAdapter_SyncStats_ResumedBackfill(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	store := bookmark.NewMemory()
+
+	a := New(mockClient, client.NewNoopLogger())
+	a.SetBookmarkStore(store)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day", PageSize: 100, StartDate: startDate, EndDate: &endDate}
+
+	// January was checkpointed by an earlier run.
+	backfillHash := a.generateQueryHash(client.Query{
+		CostReportToken: cfg.CostReportToken,
+		StartAt:         startDate,
+		EndAt:           endDate,
+		Granularity:     cfg.Granularity,
+	})
+	januaryKey := chunkCheckpointKey(backfillHash, startDate, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, store.SetBookmark(context.Background(), januaryKey, "2024-02-02T00:00:00Z"))
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, a.Sync(context.Background(), cfg, mockSink))
+
+	stats := a.GetSyncStats()
+	assert.Equal(t, 1, stats.Chunks)
+	assert.Equal(t, 1, stats.ChunksSkipped)
+	require.Len(t, stats.Bookmarks, 1)
+	assert.Contains(t, stats.Bookmarks[0].Key, "vantage_backfill_")
+	assert.NotEmpty(t, stats.Bookmarks[0].After)
+}
+
+func TestAdapter_SyncStats_ChunksRemaining(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+
+	a := New(mockClient, client.NewNoopLogger())
+	a.SetBookmarkStore(bookmark.NewMemory())
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day", PageSize: 100, StartDate: startDate, EndDate: &endDate}
+
+	// January syncs, then February runs out of retries.
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return q.StartAt.Month() == time.January
+	})).Return(client.Page{}, nil)
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).
+		Return(client.Page{}, client.ErrRetryBudgetExhausted)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	err := a.Sync(context.Background(), cfg, mockSink)
+	require.ErrorIs(t, err, client.ErrRetryBudgetExhausted)
+
+	stats := a.GetSyncStats()
+	assert.Equal(t, []ChunkRange{
+		{Start: "2024-02-01", End: "2024-03-01"},
+		{Start: "2024-03-01", End: "2024-03-15"},
+	}, stats.ChunksRemaining)
+}
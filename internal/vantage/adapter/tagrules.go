@@ -0,0 +1,165 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// synonymsAnyKey is the tags.synonyms key whose mappings apply to every tag.
+	synonymsAnyKey = "*"
+
+	// kubernetesLabelPrefix marks Kubernetes labels among provider tags.
+	kubernetesLabelPrefix = "kubernetes.io/"
+)
+
+// tagRules rewrites normalized tags before they are filtered: source keys are
+// coalesced into canonical keys, then values are trimmed, lower-cased, and
+// mapped through synonyms, in that order.
+type tagRules struct {
+	trim        bool
+	lowercase   bool
+	mergePolicy string
+
+	// synonyms maps a tag key (or synonymsAnyKey) to lower-cased values and
+	// their replacements.
+	synonyms map[string]map[string]string
+
+	// sources maps a coalesced source key to its canonical key.
+	sources map[string]coalesceSource
+}
+
+// coalesceSource is one source key of a tags.coalesce entry. Lower priority
+// wins when a record carries several sources of the same canonical key; the
+// canonical key itself has priority 0.
+type coalesceSource struct {
+	key      string
+	priority int
+}
+
+// newTagRules builds the rules of cfg, normalizing every configured key.
+func newTagRules(cfg TagConfig) tagRules {
+	rules := tagRules{
+		trim:        cfg.TrimValues,
+		lowercase:   cfg.LowercaseValues,
+		mergePolicy: cfg.MergePolicy,
+		synonyms:    make(map[string]map[string]string, len(cfg.Synonyms)),
+		sources:     make(map[string]coalesceSource),
+	}
+	if rules.mergePolicy == "" {
+		rules.mergePolicy = TagMergeKeepBoth
+	}
+
+	for key, mappings := range cfg.Synonyms {
+		if key != synonymsAnyKey {
+			key = kebabCase(key)
+		}
+		lowered := make(map[string]string, len(mappings))
+		for from, to := range mappings {
+			lowered[strings.ToLower(strings.TrimSpace(from))] = to
+		}
+		rules.synonyms[key] = lowered
+	}
+
+	for canonical, sources := range cfg.Coalesce {
+		canonical = kebabCase(canonical)
+		for i, source := range sources {
+			rules.sources[kebabCase(source)] = coalesceSource{key: canonical, priority: i + 1}
+		}
+	}
+
+	return rules
+}
+
+// validateTagRules rejects unknown merge policies and coalesce entries that
+// are empty or that claim the same source key for two canonical keys.
+func validateTagRules(cfg TagConfig) error {
+	if cfg.MergePolicy != "" && !slices.Contains(SupportedTagMergePolicies(), cfg.MergePolicy) {
+		return fmt.Errorf(
+			"invalid tags.merge_policy: %s (valid: %s)",
+			cfg.MergePolicy,
+			strings.Join(SupportedTagMergePolicies(), ", "),
+		)
+	}
+
+	owners := make(map[string]string)
+	for canonical, sources := range cfg.Coalesce {
+		if kebabCase(canonical) == "" {
+			return errors.New("tags.coalesce has an empty canonical key")
+		}
+		if len(sources) == 0 {
+			return fmt.Errorf("tags.coalesce.%s must list at least one source key", canonical)
+		}
+		for _, source := range sources {
+			key := kebabCase(source)
+			if key == "" {
+				return fmt.Errorf("tags.coalesce.%s has an empty source key", canonical)
+			}
+			if owner, ok := owners[key]; ok && owner != canonical {
+				return fmt.Errorf("tags.coalesce: source key %q is listed under both %s and %s", source, owner, canonical)
+			}
+			owners[key] = canonical
+		}
+	}
+	return nil
+}
+
+// tagCandidate is one source tag resolved to a canonical key.
+type tagCandidate struct {
+	rawKey   string
+	value    string
+	priority int  // coalesce priority, lower wins
+	fromK8s  bool // a kubernetes.io/ label merged into the provider namespace
+}
+
+// candidate resolves a source tag to its canonical key. Unless the merge
+// policy keeps both, Kubernetes labels lose their kubernetes.io/ prefix so
+// they share keys with provider tags.
+func (r tagRules) candidate(rawKey, normalized, value string) (string, tagCandidate) {
+	c := tagCandidate{rawKey: rawKey, value: value}
+	if r.mergePolicy != TagMergeKeepBoth {
+		if name, ok := strings.CutPrefix(normalized, kubernetesLabelPrefix); ok && name != "" {
+			normalized = name
+			c.fromK8s = true
+		}
+	}
+	if source, ok := r.sources[normalized]; ok {
+		c.priority = source.priority
+		return source.key, c
+	}
+	return normalized, c
+}
+
+// prefer reports whether a should win over b for the same canonical key:
+// lower coalesce priority first, then the source favored by the merge
+// policy, then the smaller original key so results are deterministic.
+func (r tagRules) prefer(a, b tagCandidate) bool {
+	if a.priority != b.priority {
+		return a.priority < b.priority
+	}
+	if a.fromK8s != b.fromK8s {
+		return a.fromK8s == (r.mergePolicy == TagMergePreferK8s)
+	}
+	return a.rawKey < b.rawKey
+}
+
+// value applies the value rules to one tag of key.
+func (r tagRules) value(key, value string) string {
+	if r.trim {
+		value = strings.TrimSpace(value)
+	}
+	if r.lowercase {
+		value = strings.ToLower(value)
+	}
+
+	lookup := strings.ToLower(strings.TrimSpace(value))
+	if replacement, ok := r.synonyms[key][lookup]; ok {
+		return replacement
+	}
+	if replacement, ok := r.synonyms[synonymsAnyKey][lookup]; ok {
+		return replacement
+	}
+	return value
+}
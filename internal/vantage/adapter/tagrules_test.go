@@ -0,0 +1,140 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestTagRules_Value(t *testing.T) {
+	rules := newTagRules(TagConfig{
+		TrimValues:      true,
+		LowercaseValues: true,
+		Synonyms: map[string]map[string]string{
+			"Environment": {"prod": "production", "Stg": "staging"},
+			"*":           {"n/a": ""},
+		},
+	})
+
+	tests := []struct {
+		key, value, expected string
+	}{
+		{"environment", " PROD ", "production"},
+		{"environment", "stg", "staging"},
+		{"environment", "Dev", "dev"},
+		{"team", "prod", "prod"},
+		{"team", "N/A", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, rules.value(tt.key, tt.value), "%s=%q", tt.key, tt.value)
+	}
+
+	// Without trim or lowercase, values pass through unless a synonym matches.
+	rules = newTagRules(TagConfig{Synonyms: map[string]map[string]string{"env": {"prod": "production"}}})
+	assert.Equal(t, " Dev ", rules.value("env", " Dev "))
+	assert.Equal(t, "production", rules.value("env", "Prod"))
+}
+
+func TestTagRules_Candidate(t *testing.T) {
+	rules := newTagRules(TagConfig{Coalesce: map[string][]string{"Team": {"Owner", "squad_name"}}})
+
+	key, c := rules.candidate("Owner", "owner", "payments")
+	assert.Equal(t, "team", key)
+	assert.Equal(t, 1, c.priority)
+
+	key, c = rules.candidate("squad_name", "squad-name", "billing")
+	assert.Equal(t, "team", key)
+	assert.Equal(t, 2, c.priority)
+
+	key, c = rules.candidate("team", "team", "core")
+	assert.Equal(t, "team", key)
+	assert.Equal(t, 0, c.priority)
+
+	// Kubernetes labels keep their prefix by default.
+	key, c = rules.candidate("kubernetes.io/app", "kubernetes.io/app", "web")
+	assert.Equal(t, "kubernetes.io/app", key)
+	assert.False(t, c.fromK8s)
+}
+
+func TestNormalizeTags_MergePolicy(t *testing.T) {
+	input := map[string]string{
+		"App":                "provider-app",
+		"kubernetes.io/app":  "k8s-app",
+		"kubernetes.io/tier": "frontend",
+	}
+
+	keepBoth := map[string]string{
+		"app":                "provider-app",
+		"kubernetes.io/app":  "k8s-app",
+		"kubernetes.io/tier": "frontend",
+	}
+
+	tests := []struct {
+		policy   string
+		expected map[string]string
+	}{
+		{"", keepBoth},
+		{TagMergeKeepBoth, keepBoth},
+		{TagMergePreferProvider, map[string]string{"app": "provider-app", "tier": "frontend"}},
+		{TagMergePreferK8s, map[string]string{"app": "k8s-app", "tier": "frontend"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			adapter := New(&mockClient{}, client.NewNoopLogger())
+			tags, err := newTagFilter(TagConfig{MergePolicy: tt.policy})
+			require.NoError(t, err)
+			adapter.tags = tags
+
+			assert.Equal(t, tt.expected, adapter.normalizeTags(input))
+		})
+	}
+}
+
+func TestNormalizeTags_CollisionIsDeterministic(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	for range 20 {
+		result := adapter.normalizeTags(map[string]string{"Team": "a", "team": "b", "TEAM": "c"})
+		assert.Equal(t, map[string]string{"team": "c"}, result)
+	}
+}
+
+func TestValidateTagRules(t *testing.T) {
+	require.NoError(t, validateTagRules(TagConfig{Coalesce: map[string][]string{"team": {"owner"}}}))
+
+	err := validateTagRules(TagConfig{Coalesce: map[string][]string{"team": {}}})
+	require.ErrorContains(t, err, "at least one source key")
+
+	err = validateTagRules(TagConfig{Coalesce: map[string][]string{"team": {"owner"}, "group": {"Owner"}}})
+	require.ErrorContains(t, err, "is listed under both")
+
+	err = validateTagRules(TagConfig{MergePolicy: "prefer-newest"})
+	require.ErrorContains(t, err, "invalid tags.merge_policy")
+}
+
+func TestNormalizeTags_Rules(t *testing.T) {
+	adapter := New(&mockClient{}, client.NewNoopLogger())
+	tags, err := newTagFilter(TagConfig{
+		TrimValues:      true,
+		LowercaseValues: true,
+		Synonyms:        map[string]map[string]string{"env": {"prod": "production"}},
+		Coalesce:        map[string][]string{"team": {"owner", "squad"}},
+		PreserveRaw:     true,
+	})
+	require.NoError(t, err)
+	adapter.tags = tags
+
+	result, raw := adapter.normalizeTagsWithRaw(map[string]string{
+		"Env":   "Prod ",
+		"Owner": "Payments",
+		"squad": "Billing",
+	})
+	assert.Equal(t, map[string]string{"env": "production", "team": "payments"}, result)
+	assert.Equal(t, map[string]string{"Env": "Prod ", "Owner": "Payments"}, raw)
+
+	// The canonical key itself wins over every source.
+	result = adapter.normalizeTags(map[string]string{"Team": "Core", "Owner": "Payments"})
+	assert.Equal(t, map[string]string{"team": "core"}, result)
+}
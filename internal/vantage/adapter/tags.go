@@ -0,0 +1,89 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TagDiscovery is the result of checking tag configuration against the tag
+// keys available in Vantage.
+type TagDiscovery struct {
+	// Keys are the visible tag keys, normalized to lower-kebab-case.
+	Keys []string `json:"keys"`
+	// UnmatchedFilters are configured tag_prefix_filters that match no key.
+	UnmatchedFilters []string `json:"unmatched_filters,omitempty"`
+}
+
+// DiscoverTags fetches the workspace's tag keys and reports configured tag
+// filters that reference keys Vantage has never seen.
+func (a *Adapter) DiscoverTags(ctx context.Context, cfg Config) (TagDiscovery, error) {
+	tags, err := a.client.ListTags(ctx, cfg.WorkspaceToken)
+	if err != nil {
+		return TagDiscovery{}, fmt.Errorf("listing tags: %w", err)
+	}
+
+	var discovery TagDiscovery
+	for _, tag := range tags {
+		if tag.Hidden {
+			continue
+		}
+		discovery.Keys = append(discovery.Keys, a.normalizeTagKey(tag.Key))
+	}
+
+	for _, filter := range cfg.TagPrefixFilters {
+		prefix := strings.ToLower(filter)
+		matched := slices.ContainsFunc(discovery.Keys, func(key string) bool {
+			return strings.HasPrefix(key, prefix)
+		})
+		if !matched {
+			discovery.UnmatchedFilters = append(discovery.UnmatchedFilters, filter)
+		}
+	}
+
+	return discovery, nil
+}
+
+// applyTagDiscovery runs tag discovery before a sync when enabled, logging a
+// warning for each unmatched filter and adding "tags" to group_bys when
+// auto_group_by_tags is set and the workspace has tags. Discovery failures
+// are logged and never fail the sync.
+func (a *Adapter) applyTagDiscovery(ctx context.Context, cfg *Config) {
+	if !cfg.DiscoverTags {
+		return
+	}
+
+	discovery, err := a.DiscoverTags(ctx, *cfg)
+	if err != nil {
+		a.logger.Warn(ctx, "Tag discovery failed", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "discover_tags",
+			"attempt":   0,
+			"error":     err,
+		})
+		return
+	}
+
+	a.diagnosticsSummary.SourceInfo["tag_keys_discovered"] = len(discovery.Keys)
+
+	for _, filter := range discovery.UnmatchedFilters {
+		a.diagnosticsSummary.Warnings["tag_filter_unmatched"]++
+		a.logger.Warn(ctx, "Tag filter matches no tag keys in workspace", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "discover_tags",
+			"attempt":   0,
+			"filter":    filter,
+		})
+	}
+
+	if cfg.AutoGroupByTags && len(discovery.Keys) > 0 && !slices.Contains(cfg.GroupBys, "tags") {
+		cfg.GroupBys = append(slices.Clone(cfg.GroupBys), "tags")
+		a.logger.Info(ctx, "Added tags to group_bys", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "discover_tags",
+			"attempt":   0,
+			"tag_keys":  len(discovery.Keys),
+		})
+	}
+}
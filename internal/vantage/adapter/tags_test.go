@@ -0,0 +1,91 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestAdapter_DiscoverTags(t *testing.T) {
+	mockClient := &mockClient{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	mockClient.On("ListTags", mock.Anything, "wrkspc_1").Return([]client.Tag{
+		{Key: "user:Team"},
+		{Key: "kubernetes.io/app"},
+		{Key: "internal", Hidden: true},
+	}, nil)
+
+	discovery, err := adapter.DiscoverTags(context.Background(), Config{
+		WorkspaceToken:   "wrkspc_1",
+		TagPrefixFilters: []string{"user:", "Kubernetes.io/", "cost-center:", "internal"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"user:team", "kubernetes.io/app"}, discovery.Keys)
+	assert.Equal(t, []string{"cost-center:", "internal"}, discovery.UnmatchedFilters)
+}
+
+func TestAdapter_Sync_AutoGroupByTags(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken:  "cr_test",
+		Granularity:      "day",
+		GroupBys:         []string{"provider"},
+		StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:          &endDate,
+		PageSize:         100,
+		TagPrefixFilters: []string{"missing:"},
+		DiscoverTags:     true,
+		AutoGroupByTags:  true,
+	}
+
+	mockClient.On("ListTags", mock.Anything, "").Return([]client.Tag{{Key: "team"}}, nil)
+	mockClient.On("Costs", mock.Anything, mock.MatchedBy(func(q client.Query) bool {
+		return assert.ObjectsAreEqual([]string{"provider", "tags"}, q.GroupBys)
+	})).Return(client.Page{}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+	assert.Equal(t, 1, adapter.GetDiagnosticsSummary().Warnings["tag_filter_unmatched"])
+	assert.Equal(t, []string{"provider"}, cfg.GroupBys)
+	mockClient.AssertExpectations(t)
+}
+
+func TestAdapter_Sync_TagDiscoveryFailureDoesNotFailSync(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+		PageSize:        100,
+		DiscoverTags:    true,
+	}
+
+	mockClient.On("ListTags", mock.Anything, "").Return([]client.Tag(nil), errors.New("forbidden"))
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,37 @@
+package adapter
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attribute keys shared by the sync pipeline's spans.
+const (
+	attrQueryHash  = "vantage.query_hash"
+	attrChunkStart = "vantage.chunk_start"
+	attrChunkEnd   = "vantage.chunk_end"
+	attrBackfill   = "vantage.backfill"
+	attrRows       = "vantage.rows"
+	attrRecords    = "vantage.records"
+	attrPages      = "vantage.pages"
+)
+
+// tracerName identifies the instrumentation scope of these spans.
+const tracerName = "github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+
+// tracer creates the adapter's spans. The global provider is looked up on
+// each call, so spans are no-ops until tracing is set up and follow a
+// provider replaced between profiles.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// finishSpan records err on span, if any, and ends it.
+func finishSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,122 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// recordSpans installs a tracer provider recording ended spans for the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// spanAttributes returns the attributes of the first ended span named name.
+func spanAttributes(t *testing.T, recorder *tracetest.SpanRecorder, name string) map[attribute.Key]attribute.Value {
+	t.Helper()
+	for _, span := range recorder.Ended() {
+		if span.Name() != name {
+			continue
+		}
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		return attrs
+	}
+	require.Failf(t, "span not found", "no %s span was ended", name)
+	return nil
+}
+
+func TestAdapter_SyncTracesPipeline(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       startDate,
+		EndDate:         &endDate,
+		PageSize:        100,
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: startDate, Provider: "aws", Service: "ec2", Cost: 1, Currency: "USD"},
+			{BucketStart: startDate, Provider: "aws", Service: "s3", Cost: 2, Currency: "USD"},
+		},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+
+	names := make([]string, 0, len(recorder.Ended()))
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	assert.Subset(t, names, []string{"vantage.sync", "vantage.sync_range", "vantage.map_page", "vantage.sink_write"})
+
+	rangeAttrs := spanAttributes(t, recorder, "vantage.sync_range")
+	assert.NotEmpty(t, rangeAttrs[attrQueryHash].AsString())
+	assert.Equal(t, "2024-01-01", rangeAttrs[attrChunkStart].AsString())
+	assert.Equal(t, "2024-01-02", rangeAttrs[attrChunkEnd].AsString())
+	assert.Equal(t, int64(2), rangeAttrs[attrRecords].AsInt64())
+
+	mapAttrs := spanAttributes(t, recorder, "vantage.map_page")
+	assert.Equal(t, rangeAttrs[attrQueryHash], mapAttrs[attrQueryHash])
+	assert.Equal(t, int64(2), mapAttrs[attrRows].AsInt64())
+
+	writeAttrs := spanAttributes(t, recorder, "vantage.sink_write")
+	assert.Equal(t, int64(2), writeAttrs[attrRecords].AsInt64())
+}
+
+func TestAdapter_SyncTracesError(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).
+		Return(client.Page{}, assert.AnError)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+
+	require.Error(t, adapter.Sync(context.Background(), cfg, mockSink))
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "vantage.sync" || span.Name() == "vantage.sync_range" {
+			assert.Equal(t, codes.Error, span.Status().Code, span.Name())
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Built-in transform types for the transforms section.
+const (
+	TransformProviderFilter = "provider_filter"
+	TransformCostThreshold  = "cost_threshold"
+	TransformLabels         = "labels"
+	TransformPulumiStack    = "pulumi_stack"
+)
+
+// SupportedTransformTypes returns the valid transforms[].type values.
+func SupportedTransformTypes() []string {
+	return []string{TransformProviderFilter, TransformCostThreshold, TransformLabels, TransformPulumiStack}
+}
+
+// Transformer rewrites records after mapping and before they are written to
+// the sink. It may drop, change, or add records, and runs once per batch, so
+// it must not rely on seeing a whole sync at once. Records of every metric
+// type pass through it.
+type Transformer interface {
+	Transform(ctx context.Context, records []CostRecord) ([]CostRecord, error)
+}
+
+// TransformerFunc adapts a function to Transformer.
+type TransformerFunc func(ctx context.Context, records []CostRecord) ([]CostRecord, error)
+
+// Transform implements Transformer.
+func (f TransformerFunc) Transform(ctx context.Context, records []CostRecord) ([]CostRecord, error) {
+	return f(ctx, records)
+}
+
+// SetTransformers adds transformers that run after those configured in the
+// transforms section, in the order given.
+func (a *Adapter) SetTransformers(transformers ...Transformer) {
+	a.customTransforms = transformers
+}
+
+// configuredTransforms builds the transforms a sync applies: static_labels
+// first, then the transforms section in order.
+func configuredTransforms(cfg Config) ([]Transformer, error) {
+	transforms, err := newTransforms(cfg.Transforms)
+	if err != nil {
+		return nil, err
+	}
+	staticLabels, err := newStaticLabels(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if staticLabels != nil {
+		transforms = slices.Insert(transforms, 0, staticLabels)
+	}
+	return transforms, nil
+}
+
+// newTransforms builds the transforms section's chain, in config order.
+func newTransforms(configs []TransformConfig) ([]Transformer, error) {
+	transforms := make([]Transformer, 0, len(configs))
+	for i, cfg := range configs {
+		t, err := newTransform(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("transforms[%d]: %w", i, err)
+		}
+		transforms = append(transforms, t)
+	}
+	return transforms, nil
+}
+
+// newTransform builds one built-in transform.
+func newTransform(cfg TransformConfig) (Transformer, error) {
+	switch cfg.Type {
+	case TransformProviderFilter:
+		if len(cfg.Providers) == 0 {
+			return nil, fmt.Errorf("%s requires providers", cfg.Type)
+		}
+		return providerFilter{providers: cfg.Providers, exclude: cfg.Exclude}, nil
+	case TransformCostThreshold:
+		if cfg.MinNetCost <= 0 {
+			return nil, fmt.Errorf("%s requires a positive min_net_cost", cfg.Type)
+		}
+		return costThreshold{min: cfg.MinNetCost}, nil
+	case TransformLabels:
+		if len(cfg.Labels) == 0 {
+			return nil, fmt.Errorf("%s requires labels", cfg.Type)
+		}
+		return labelInjector{labels: cfg.Labels, overwrite: cfg.Overwrite}, nil
+	case TransformPulumiStack:
+		if len(cfg.StackFiles) == 0 {
+			return nil, fmt.Errorf("%s requires stack_files", cfg.Type)
+		}
+		return newStackMatcher(cfg.StackFiles, cfg.URNLabels)
+	case "":
+		return nil, fmt.Errorf("type is required (valid: %s)", strings.Join(SupportedTransformTypes(), ", "))
+	default:
+		return nil, fmt.Errorf("invalid type: %s (valid: %s)", cfg.Type, strings.Join(SupportedTransformTypes(), ", "))
+	}
+}
+
+// newStaticLabels builds the static_labels transform, or returns nil when
+// none are configured. Keys are normalized like provider tag keys so they
+// meet a record's labels of the same name.
+func newStaticLabels(cfg Config) (Transformer, error) {
+	policy := cfg.StaticLabelsPolicy
+	if policy == "" {
+		policy = StaticLabelsPreferRecord
+	}
+	if !slices.Contains(SupportedStaticLabelsPolicies(), policy) {
+		return nil, fmt.Errorf(
+			"invalid static_labels_policy: %s (valid: %s)",
+			cfg.StaticLabelsPolicy,
+			strings.Join(SupportedStaticLabelsPolicies(), ", "),
+		)
+	}
+	if len(cfg.StaticLabels) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(cfg.StaticLabels))
+	for key, value := range cfg.StaticLabels {
+		normalized := kebabCase(key)
+		if normalized == "" {
+			return nil, fmt.Errorf("static_labels: invalid key %q", key)
+		}
+		labels[normalized] = value
+	}
+	return labelInjector{
+		labels:     labels,
+		overwrite:  policy == StaticLabelsPreferStatic,
+		strict:     policy == StaticLabelsError,
+		allMetrics: true,
+	}, nil
+}
+
+// applyTransforms runs records through the configured transforms and then
+// the custom ones, counting the records they drop.
+func (a *Adapter) applyTransforms(ctx context.Context, records []CostRecord) ([]CostRecord, error) {
+	before := len(records)
+	for _, t := range slices.Concat(a.transforms, a.customTransforms) {
+		var err error
+		if records, err = t.Transform(ctx, records); err != nil {
+			return nil, fmt.Errorf("transforming records: %w", err)
+		}
+	}
+	if dropped := before - len(records); dropped > 0 {
+		a.stats.RecordsDropped += dropped
+	}
+	return records, nil
+}
+
+// keepCostRecords returns the records for which keep is true, always
+// keeping records that are not cost records.
+func keepCostRecords(records []CostRecord, keep func(*CostRecord) bool) []CostRecord {
+	kept := records[:0]
+	for i := range records {
+		if records[i].MetricType != "cost" || keep(&records[i]) {
+			kept = append(kept, records[i])
+		}
+	}
+	return kept
+}
+
+// providerFilter keeps cost records from the listed providers, or drops
+// them when exclude is set. Providers match case-insensitively.
+type providerFilter struct {
+	providers []string
+	exclude   bool
+}
+
+// Transform implements Transformer.
+func (f providerFilter) Transform(_ context.Context, records []CostRecord) ([]CostRecord, error) {
+	return keepCostRecords(records, func(record *CostRecord) bool {
+		listed := slices.ContainsFunc(f.providers, func(p string) bool {
+			return strings.EqualFold(p, record.Provider)
+		})
+		return listed != f.exclude
+	}), nil
+}
+
+// costThreshold drops cost records whose absolute net cost is below min,
+// so credits and refunds are kept as readily as charges.
+type costThreshold struct {
+	min float64
+}
+
+// Transform implements Transformer.
+func (t costThreshold) Transform(_ context.Context, records []CostRecord) ([]CostRecord, error) {
+	return keepCostRecords(records, func(record *CostRecord) bool {
+		return record.NetCost != nil && math.Abs(*record.NetCost) >= t.min
+	}), nil
+}
+
+// labelInjector adds labels to every cost record, or to every record when
+// allMetrics is set. A record's own label wins over an injected one with the
+// same key unless overwrite is set; with strict, a differing value fails the
+// batch instead.
+type labelInjector struct {
+	labels     map[string]string
+	overwrite  bool
+	strict     bool
+	allMetrics bool
+}
+
+// Transform implements Transformer.
+func (l labelInjector) Transform(_ context.Context, records []CostRecord) ([]CostRecord, error) {
+	for i := range records {
+		if records[i].MetricType != "cost" && !l.allMetrics {
+			continue
+		}
+		// Rolled-up records can share a labels map, so each record gets
+		// its own copy.
+		labels := make(map[string]string, len(records[i].Labels)+len(l.labels))
+		for key, value := range records[i].Labels {
+			labels[key] = value
+		}
+		for key, value := range l.labels {
+			if existing, exists := labels[key]; exists && !l.overwrite {
+				if l.strict && existing != value {
+					return nil, fmt.Errorf("label %s: record %s has %q, static_labels sets %q",
+						key, records[i].LineItemID, existing, value)
+				}
+				continue
+			}
+			labels[key] = value
+		}
+		records[i].Labels = labels
+	}
+	return records, nil
+}
@@ -0,0 +1,217 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func costRecord(provider string, netCost float64, labels map[string]string) CostRecord {
+	return CostRecord{MetricType: "cost", Provider: provider, NetCost: &netCost, Labels: labels}
+}
+
+func TestNewTransforms_Invalid(t *testing.T) {
+	tests := []struct {
+		cfg      TransformConfig
+		expected string
+	}{
+		{TransformConfig{}, "transforms[0]: type is required"},
+		{TransformConfig{Type: "rename"}, "transforms[0]: invalid type: rename"},
+		{TransformConfig{Type: TransformProviderFilter}, "provider_filter requires providers"},
+		{TransformConfig{Type: TransformCostThreshold, MinNetCost: -1}, "cost_threshold requires a positive min_net_cost"},
+		{TransformConfig{Type: TransformLabels}, "labels requires labels"},
+		{TransformConfig{Type: TransformPulumiStack}, "pulumi_stack requires stack_files"},
+	}
+	for _, tt := range tests {
+		_, err := newTransforms([]TransformConfig{tt.cfg})
+		require.ErrorContains(t, err, tt.expected)
+	}
+}
+
+func TestTransforms_BuiltIns(t *testing.T) {
+	forecast := CostRecord{MetricType: "forecast", Provider: "gcp"}
+	records := []CostRecord{
+		costRecord("aws", 12.5, map[string]string{"team": "payments"}),
+		costRecord("AWS", 0.004, nil),
+		costRecord("gcp", 40, nil),
+		costRecord("aws", -3, nil),
+		forecast,
+	}
+
+	transforms, err := newTransforms([]TransformConfig{
+		{Type: TransformProviderFilter, Providers: []string{"aws"}},
+		{Type: TransformCostThreshold, MinNetCost: 0.01},
+		{Type: TransformLabels, Labels: map[string]string{"team": "platform", "env": "prod"}},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, transform := range transforms {
+		records, err = transform.Transform(ctx, records)
+		require.NoError(t, err)
+	}
+
+	// Only cost records are filtered or labeled; credits pass the threshold.
+	require.Len(t, records, 3)
+	assert.Equal(t, map[string]string{"team": "payments", "env": "prod"}, records[0].Labels)
+	assert.InDelta(t, -3.0, *records[1].NetCost, 0)
+	assert.Equal(t, map[string]string{"team": "platform", "env": "prod"}, records[1].Labels)
+	assert.Equal(t, forecast, records[2])
+}
+
+func TestTransforms_ProviderFilterExclude(t *testing.T) {
+	filter, err := newTransform(TransformConfig{Type: TransformProviderFilter, Providers: []string{"GCP"}, Exclude: true})
+	require.NoError(t, err)
+
+	records, err := filter.Transform(context.Background(), []CostRecord{
+		costRecord("aws", 1, nil),
+		costRecord("gcp", 1, nil),
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "aws", records[0].Provider)
+}
+
+func TestTransforms_LabelsOverwriteAndSharedMaps(t *testing.T) {
+	injector, err := newTransform(TransformConfig{
+		Type:      TransformLabels,
+		Labels:    map[string]string{"team": "platform"},
+		Overwrite: true,
+	})
+	require.NoError(t, err)
+
+	shared := map[string]string{"team": "payments"}
+	records, err := injector.Transform(context.Background(), []CostRecord{
+		costRecord("aws", 1, shared),
+		costRecord("aws", 2, shared),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "platform", records[0].Labels["team"])
+	assert.Equal(t, "platform", records[1].Labels["team"])
+	assert.Equal(t, "payments", shared["team"])
+}
+
+func TestAdapter_Sync_AppliesTransforms(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+
+	var seen int
+	adapter.SetTransformers(TransformerFunc(func(_ context.Context, records []CostRecord) ([]CostRecord, error) {
+		seen += len(records)
+		return records, nil
+	}))
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+		PageSize:        100,
+		Transforms: []TransformConfig{
+			{Type: TransformProviderFilter, Providers: []string{"aws"}},
+			{Type: TransformLabels, Labels: map[string]string{"team": "platform"}},
+		},
+	}
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: cfg.StartDate, Provider: "aws", Service: "EC2", Cost: 10},
+			{BucketStart: cfg.StartDate, Provider: "gcp", Service: "GCE", Cost: 5},
+		},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+	mockSink.On("GetBookmark", mock.Anything, mock.Anything).Return("", nil)
+	mockSink.On("SetBookmark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+	require.Len(t, mockSink.records, 1)
+	assert.Equal(t, "aws", mockSink.records[0].Provider)
+	assert.Equal(t, "platform", mockSink.records[0].Labels["team"])
+	assert.Equal(t, 1, seen, "custom transforms run after configured ones")
+	assert.Equal(t, 1, adapter.GetSyncStats().RecordsDropped)
+}
+
+func TestAdapter_Sync_TransformError(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.SetTransformers(TransformerFunc(func(context.Context, []CostRecord) ([]CostRecord, error) {
+		return nil, errors.New("boom")
+	}))
+
+	endDate := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		CostReportToken: "cr_test",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:         &endDate,
+		PageSize:        100,
+	}
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{}, nil)
+
+	err := adapter.Sync(context.Background(), cfg, mockSink)
+	require.ErrorContains(t, err, "transforming records: boom")
+	mockSink.AssertNotCalled(t, "WriteRecords", mock.Anything, mock.Anything)
+}
+
+func TestStaticLabels_Policies(t *testing.T) {
+	newRecords := func() []CostRecord {
+		return []CostRecord{
+			costRecord("aws", 1, map[string]string{"environment": "staging"}),
+			{MetricType: "forecast", LineItemID: "forecast-1"},
+		}
+	}
+	static := map[string]string{"Environment": "production", "business_unit": "retail"}
+
+	tests := []struct {
+		policy      string
+		environment string
+	}{
+		{"", "staging"},
+		{StaticLabelsPreferRecord, "staging"},
+		{StaticLabelsPreferStatic, "production"},
+	}
+	for _, tt := range tests {
+		transform, err := newStaticLabels(Config{StaticLabels: static, StaticLabelsPolicy: tt.policy})
+		require.NoError(t, err)
+
+		records, err := transform.Transform(context.Background(), newRecords())
+		require.NoError(t, err)
+		assert.Equal(t, tt.environment, records[0].Labels["environment"], "policy %q", tt.policy)
+		assert.Equal(t, "retail", records[0].Labels["business-unit"])
+		// Static labels stamp every record, not only cost records.
+		assert.Equal(t, map[string]string{"environment": "production", "business-unit": "retail"}, records[1].Labels)
+	}
+
+	transform, err := newStaticLabels(Config{StaticLabels: static, StaticLabelsPolicy: StaticLabelsError})
+	require.NoError(t, err)
+	_, err = transform.Transform(context.Background(), newRecords())
+	require.ErrorContains(t, err, `label environment: record  has "staging", static_labels sets "production"`)
+
+	// A matching value is not a conflict.
+	transform, err = newStaticLabels(Config{StaticLabels: map[string]string{"environment": "staging"}, StaticLabelsPolicy: StaticLabelsError})
+	require.NoError(t, err)
+	_, err = transform.Transform(context.Background(), newRecords())
+	require.NoError(t, err)
+}
+
+func TestStaticLabels_Invalid(t *testing.T) {
+	_, err := newStaticLabels(Config{StaticLabels: map[string]string{"a": "b"}, StaticLabelsPolicy: "merge"})
+	require.ErrorContains(t, err, "invalid static_labels_policy: merge")
+
+	_, err = newStaticLabels(Config{StaticLabels: map[string]string{"__": "b"}})
+	require.ErrorContains(t, err, `static_labels: invalid key "__"`)
+
+	transform, err := newStaticLabels(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, transform)
+}
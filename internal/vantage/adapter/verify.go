@@ -0,0 +1,82 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// defaultVerifyTotalsTolerance is the relative difference between a range's
+// report total and its fetched rows that verify_totals accepts.
+const defaultVerifyTotalsTolerance = 0.001
+
+// minTotalsDifference is the absolute difference below which totals always
+// match, so rounding on near-zero ranges is not flagged.
+const minTotalsDifference = 0.01
+
+// TotalsMismatch is a synced range whose fetched rows do not add up to the
+// report's total for the range.
+type TotalsMismatch struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Expected is the report total; Fetched the net cost of the distinct
+	// rows the sync fetched.
+	Expected   float64 `json:"expected"`
+	Fetched    float64 `json:"fetched"`
+	Difference float64 `json:"difference"`
+}
+
+// verifyTotals fetches query's range again without group_bys and compares
+// its total net cost with fetched, flagging a mismatch beyond tolerance.
+// The check never fails the sync: a failed request is a warning.
+func (a *Adapter) verifyTotals(ctx context.Context, query client.Query, fetched, tolerance float64) {
+	totalQuery := query
+	totalQuery.GroupBys = nil
+	totalQuery.Metrics = []string{"cost"}
+
+	rows, err := client.NewPager(a.client, totalQuery, a.logger).AllPages(ctx)
+	if err != nil {
+		a.diagnosticsSummary.Warnings["totals_unverified"]++
+		a.logger.Warn(ctx, "Could not fetch report totals to verify the sync", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "verify_totals",
+			"attempt":   0,
+			"error":     err,
+		})
+		return
+	}
+
+	var expected float64
+	for _, row := range rows {
+		expected += row.Cost
+	}
+
+	verified, _ := a.diagnosticsSummary.SourceInfo["totals_verified_ranges"].(int)
+	a.diagnosticsSummary.SourceInfo["totals_verified_ranges"] = verified + 1
+
+	difference := fetched - expected
+	if math.Abs(difference) <= math.Max(tolerance*math.Abs(expected), minTotalsDifference) {
+		return
+	}
+
+	mismatch := TotalsMismatch{
+		Start:      query.StartAt.Format("2006-01-02"),
+		End:        query.EndAt.Format("2006-01-02"),
+		Expected:   expected,
+		Fetched:    fetched,
+		Difference: difference,
+	}
+	a.diagnosticsSummary.Warnings["totals_mismatch"]++
+	a.diagnosticsSummary.TotalsMismatches = append(a.diagnosticsSummary.TotalsMismatches, mismatch)
+	a.logger.Warn(ctx, "Fetched rows do not add up to the report total", map[string]interface{}{
+		"adapter":    "vantage",
+		"operation":  "verify_totals",
+		"attempt":    0,
+		"start_date": mismatch.Start,
+		"end_date":   mismatch.End,
+		"expected":   fmt.Sprintf("%.2f", expected),
+		"fetched":    fmt.Sprintf("%.2f", fetched),
+	})
+}
@@ -0,0 +1,103 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// groupedQuery matches the sync's own query; totalsQuery the verification
+// query without group_bys.
+var (
+	groupedQuery = mock.MatchedBy(func(q client.Query) bool { return len(q.GroupBys) > 0 })
+	totalsQuery  = mock.MatchedBy(func(q client.Query) bool {
+		return len(q.GroupBys) == 0 && len(q.Metrics) == 1 && q.Metrics[0] == "cost"
+	})
+)
+
+func TestAdapter_SyncSingleRange_VerifyTotals(t *testing.T) {
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 2)
+	rows := []client.CostRow{
+		{BucketStart: startDate, Provider: "aws", Service: "EC2", Cost: 10},
+		{BucketStart: startDate, Provider: "aws", Service: "S3", Cost: 5},
+		{BucketStart: startDate.AddDate(0, 0, 1), Provider: "aws", Service: "EC2", Cost: 10},
+	}
+
+	tests := []struct {
+		name      string
+		totals    []client.CostRow
+		err       error
+		mismatch  bool
+		unchecked bool
+	}{
+		{
+			name: "totals match within tolerance",
+			totals: []client.CostRow{
+				{BucketStart: startDate, Cost: 15.01},
+				{BucketStart: startDate.AddDate(0, 0, 1), Cost: 10},
+			},
+		},
+		{
+			name: "a dropped page",
+			totals: []client.CostRow{
+				{BucketStart: startDate, Cost: 15},
+				{BucketStart: startDate.AddDate(0, 0, 1), Cost: 40},
+			},
+			mismatch: true,
+		},
+		{
+			name:      "totals request fails",
+			err:       errors.New("boom"),
+			unchecked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockClient{}
+			mockSink := &mockSink{}
+			adapter := New(mockClient, client.NewNoopLogger())
+
+			cfg := Config{
+				CostReportToken:       "cr_test",
+				Granularity:           "day",
+				GroupBys:              []string{"provider", "service"},
+				Metrics:               []string{"cost", "usage"},
+				PageSize:              100,
+				VerifyTotals:          true,
+				VerifyTotalsTolerance: defaultVerifyTotalsTolerance,
+			}
+
+			mockClient.On("Costs", mock.Anything, groupedQuery).Return(client.Page{Data: rows}, nil)
+			mockClient.On("Costs", mock.Anything, totalsQuery).Return(client.Page{Data: tt.totals}, tt.err)
+			mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+			require.NoError(t, adapter.syncSingleRange(context.Background(), cfg, mockSink, startDate, endDate, true))
+
+			summary := adapter.GetDiagnosticsSummary()
+			if tt.unchecked {
+				assert.Equal(t, 1, summary.Warnings["totals_unverified"])
+				assert.Nil(t, summary.SourceInfo["totals_verified_ranges"])
+				return
+			}
+			assert.Equal(t, 1, summary.SourceInfo["totals_verified_ranges"])
+			if !tt.mismatch {
+				assert.Empty(t, summary.TotalsMismatches)
+				assert.Zero(t, summary.Warnings["totals_mismatch"])
+				return
+			}
+			assert.Equal(t, 1, summary.Warnings["totals_mismatch"])
+			assert.Equal(t, []TotalsMismatch{{
+				Start: "2024-01-01", End: "2024-01-03", Expected: 55, Fetched: 25, Difference: -30,
+			}}, summary.TotalsMismatches)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce batches the bursts of events an editor makes on one save.
+const reloadDebounce = 250 * time.Millisecond
+
+// identityKeys are the Config keys that decide which workspace and report a
+// process reads. Changing one needs a restart, so a reload changing one is
+// rejected.
+var identityKeys = []string{
+	"token", "token_env", "oauth2", "workspace_token", "cost_report_token", "cost_report_name",
+}
+
+// ConfigReload is the outcome of re-reading a watched config file.
+type ConfigReload struct {
+	// Config is the reloaded config, or nil when the reload failed (Err)
+	// or was rejected (Rejected).
+	Config *Config
+	// Changed lists the keys that differ from the config in effect, by
+	// their yaml names; a section such as tags counts as one key.
+	Changed []string
+	// Rejected lists the identity keys in Changed.
+	Rejected []string
+	// Err is why the config could not be loaded.
+	Err error
+}
+
+// ChangedConfigKeys returns the keys, by yaml name, whose values differ
+// between old and updated.
+func ChangedConfigKeys(old, updated *Config) []string {
+	oldValue, updatedValue := reflect.ValueOf(*old), reflect.ValueOf(*updated)
+	var changed []string
+	for i := range oldValue.NumField() {
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), updatedValue.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(oldValue.Type().Field(i).Tag.Get("yaml"), ",")
+		changed = append(changed, name)
+	}
+	return changed
+}
+
+// reloadConfig loads opts again and compares the result with current.
+func reloadConfig(opts LoadOptions, current *Config) ConfigReload {
+	updated, err := Load(opts)
+	if err != nil {
+		return ConfigReload{Err: err}
+	}
+
+	reload := ConfigReload{Changed: ChangedConfigKeys(current, updated)}
+	for _, key := range reload.Changed {
+		if slices.Contains(identityKeys, key) {
+			reload.Rejected = append(reload.Rejected, key)
+		}
+	}
+	if len(reload.Rejected) == 0 {
+		reload.Config = updated
+	}
+	return reload
+}
+
+// WatchConfig watches the config file opts.Path, and the files it extends,
+// until ctx is done. After each change it loads opts again and passes
+// onReload the outcome compared with current; an accepted reload becomes
+// current. Saves that leave the config as it was are not reported.
+// Directories are watched rather than files, so editors that save by
+// replacing the file are followed.
+func WatchConfig(ctx context.Context, opts LoadOptions, current *Config, onReload func(ConfigReload)) error {
+	if opts.Path == "" {
+		return errors.New("no config file to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watching config: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	files, err := watchConfigFiles(watcher, opts.Path)
+	if err != nil {
+		return err
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if files[filepath.Clean(event.Name)] && event.Op != fsnotify.Chmod {
+				debounce = time.After(reloadDebounce)
+			}
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			onReload(ConfigReload{Err: fmt.Errorf("watching config: %w", watchErr)})
+		case <-debounce:
+			debounce = nil
+			reload := reloadConfig(opts, current)
+			if reload.Err == nil && len(reload.Changed) == 0 {
+				continue
+			}
+			if reload.Config != nil {
+				current = reload.Config
+			}
+			onReload(reload)
+
+			// The extends list may have changed too.
+			if updated, watchErr := watchConfigFiles(watcher, opts.Path); watchErr == nil {
+				files = updated
+			}
+		}
+	}
+}
+
+// watchConfigFiles watches the directories of filePath and the files it
+// extends, returning the set of their absolute paths.
+func watchConfigFiles(watcher *fsnotify.Watcher, filePath string) (map[string]bool, error) {
+	files := map[string]bool{}
+	pending := []string{filePath}
+	for len(pending) > 0 {
+		abs, err := filepath.Abs(pending[0])
+		pending = pending[1:]
+		if err != nil {
+			return nil, fmt.Errorf("resolving config path: %w", err)
+		}
+		if files[abs] {
+			continue
+		}
+		files[abs] = true
+
+		if err := watcher.Add(filepath.Dir(abs)); err != nil {
+			return nil, fmt.Errorf("watching %s: %w", filepath.Dir(abs), err)
+		}
+		raw, err := readConfigFile(abs)
+		if err != nil {
+			return nil, err
+		}
+		for _, base := range raw.Extends {
+			if !filepath.IsAbs(base) {
+				base = filepath.Join(filepath.Dir(abs), base)
+			}
+			pending = append(pending, base)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const watchTestConfig = `
+credentials:
+  token: test-token
+params:
+  cost_report_token: %s
+  granularity: day
+serve:
+  log_level: %s
+`
+
+func TestChangedConfigKeys(t *testing.T) {
+	old := &Config{Token: "a", PageSize: 100, Tags: TagConfig{Allow: []string{"team"}}}
+	updated := &Config{Token: "a", PageSize: 200, Tags: TagConfig{Allow: []string{"team", "env"}}}
+
+	assert.Equal(t, []string{"page_size", "tags"}, ChangedConfigKeys(old, updated))
+	assert.Empty(t, ChangedConfigKeys(old, old))
+}
+
+func TestWatchConfig(t *testing.T) {
+	t.Setenv("PULUMICOST_VANTAGE_TOKEN", "")
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	writeConfig := func(costReportToken, logLevel string) {
+		t.Helper()
+		content := []byte(fmt.Sprintf(watchTestConfig, costReportToken, logLevel))
+		require.NoError(t, os.WriteFile(configPath, content, 0o600))
+	}
+	writeConfig("cr_one", "info")
+
+	opts := LoadOptions{Path: configPath}
+	current, err := Load(opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reloads := make(chan ConfigReload, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchConfig(ctx, opts, current, func(reload ConfigReload) { reloads <- reload })
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	// Give the watcher time to start before the first write.
+	time.Sleep(100 * time.Millisecond)
+
+	next := func() ConfigReload {
+		t.Helper()
+		select {
+		case reload := <-reloads:
+			return reload
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reload reported")
+			return ConfigReload{}
+		}
+	}
+
+	writeConfig("cr_one", "debug")
+	reload := next()
+	require.NoError(t, reload.Err)
+	assert.Equal(t, []string{"serve"}, reload.Changed)
+	require.NotNil(t, reload.Config)
+	assert.Equal(t, "debug", reload.Config.Serve.LogLevel)
+
+	writeConfig("cr_two", "debug")
+	reload = next()
+	assert.Equal(t, []string{"cost_report_token"}, reload.Rejected)
+	assert.Nil(t, reload.Config)
+
+	writeConfig("cr_one", "verbose")
+	reload = next()
+	require.ErrorContains(t, reload.Err, "invalid serve.log_level")
+}
@@ -0,0 +1,165 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Watermark is the latest bucket a report has synced that is final: later
+// syncs will not fetch or restate it again.
+type Watermark struct {
+	// QueryHash identifies the report query, without a date range.
+	QueryHash string `json:"query_hash"`
+	// BucketStart is the start of the latest final bucket, as YYYY-MM-DD.
+	BucketStart string `json:"bucket_start"`
+	// Cutoff is when a bucket must have ended by to count as final in this
+	// sync.
+	Cutoff time.Time `json:"cutoff"`
+}
+
+// watermarkTracker finds the latest final bucket among the cost records a
+// sync fetched.
+type watermarkTracker struct {
+	granularity string
+	cutoff      time.Time
+	latest      time.Time
+}
+
+// observe records record's bucket when it ended by the cutoff. It is a
+// no-op on a nil tracker.
+func (w *watermarkTracker) observe(record *CostRecord) {
+	if w == nil || record.MetricType != "cost" {
+		return
+	}
+
+	bucket := record.Timestamp.UTC()
+	end := bucket.AddDate(0, 0, 1)
+	if w.granularity == "month" {
+		end = bucket.AddDate(0, 1, 0)
+	}
+	if !end.After(w.cutoff) && bucket.After(w.latest) {
+		w.latest = bucket
+	}
+}
+
+// watermarkBookmarkKey builds the bookmark key holding a report's
+// watermark.
+func watermarkBookmarkKey(reportHash string) string {
+	return "vantage_watermark_" + reportHash
+}
+
+// startWatermark sets up the tracker for a sync. A bucket is final once it
+// ended FinalityLagDays ago, and, with a restatement window, once it is
+// older than the window incremental pulls re-fetch. With
+// WatermarkIntegrationFreshness it must also have ended before the stalest
+// integration's last import; when that cannot be determined the watermark
+// is not advanced.
+func (a *Adapter) startWatermark(ctx context.Context, cfg Config, now time.Time) {
+	a.watermark = nil
+
+	lagDays := cfg.FinalityLagDays
+	if cfg.RestatementWindowDays+1 > lagDays {
+		lagDays = cfg.RestatementWindowDays + 1
+	}
+	cutoff := now.UTC().AddDate(0, 0, -lagDays)
+
+	if cfg.WatermarkIntegrationFreshness {
+		lastImport, err := a.stalestIntegrationImport(ctx, cfg.WorkspaceToken)
+		if err != nil {
+			a.diagnosticsSummary.Warnings["watermark_unavailable"]++
+			a.diagnosticsSummary.SourceInfo["watermark_unavailable_reason"] = err.Error()
+			a.logger.Warn(ctx, "Not advancing the watermark", map[string]interface{}{
+				"adapter":   "vantage",
+				"operation": "watermark",
+				"attempt":   0,
+				"error":     err,
+			})
+			return
+		}
+		if lastImport.Before(cutoff) {
+			cutoff = lastImport
+		}
+	}
+
+	a.watermark = &watermarkTracker{granularity: cfg.Granularity, cutoff: cutoff}
+}
+
+// stalestIntegrationImport returns the oldest last import among the active
+// provider integrations.
+func (a *Adapter) stalestIntegrationImport(ctx context.Context, workspaceToken string) (time.Time, error) {
+	integrations, err := a.client.ListIntegrations(ctx, workspaceToken)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("listing integrations: %w", err)
+	}
+
+	var stalest time.Time
+	for _, integration := range integrations {
+		switch strings.ToLower(integration.Status) {
+		case "connected", "active":
+		default:
+			continue
+		}
+		if integration.LastSyncedAt.IsZero() {
+			return time.Time{}, fmt.Errorf("integration %s has not imported data yet", integration.Provider)
+		}
+		if stalest.IsZero() || integration.LastSyncedAt.Before(stalest) {
+			stalest = integration.LastSyncedAt.UTC()
+		}
+	}
+	if stalest.IsZero() {
+		return time.Time{}, errors.New("no active integrations")
+	}
+	return stalest, nil
+}
+
+// finishWatermark saves the sync's watermark in the bookmark store and the
+// sync stats. The watermark never moves back, so a backfill of older dates
+// keeps the one a later sync saved.
+func (a *Adapter) finishWatermark(ctx context.Context, cfg Config, sink Sink) {
+	if a.watermark == nil || a.watermark.latest.IsZero() {
+		return
+	}
+
+	reportHash := a.reportQueryHash(cfg)
+	key := watermarkBookmarkKey(reportHash)
+	store := a.bookmarkStore(sink)
+
+	previous, err := store.GetBookmark(ctx, key)
+	if err != nil {
+		a.logger.Warn(ctx, "Failed to read watermark", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "watermark",
+			"attempt":   0,
+			"error":     err,
+		})
+		return
+	}
+
+	latest := a.watermark.latest
+	if saved, parseErr := time.Parse(time.RFC3339, previous); parseErr == nil && !latest.After(saved) {
+		latest = saved
+	}
+	a.stats.Watermark = &Watermark{
+		QueryHash:   reportHash,
+		BucketStart: latest.Format("2006-01-02"),
+		Cutoff:      a.watermark.cutoff,
+	}
+
+	value := latest.Format(time.RFC3339)
+	if value == previous {
+		return
+	}
+	if err := store.SetBookmark(ctx, key, value); err != nil {
+		a.logger.Warn(ctx, "Failed to update watermark", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "watermark",
+			"attempt":   0,
+			"error":     err,
+		})
+		return
+	}
+	a.stats.Bookmarks = append(a.stats.Bookmarks, BookmarkChange{Key: key, Before: previous, After: value})
+}
@@ -0,0 +1,135 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+func TestWatermarkTracker_Observe(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	tracker := &watermarkTracker{granularity: "day", cutoff: day(10).Add(6 * time.Hour)}
+
+	for _, d := range []int{7, 9, 10, 8} {
+		tracker.observe(&CostRecord{Timestamp: day(d), MetricType: "cost"})
+	}
+	tracker.observe(&CostRecord{Timestamp: day(9).Add(24 * time.Hour), MetricType: "forecast"})
+	assert.Equal(t, day(9), tracker.latest, "the 10th has not ended by the cutoff")
+
+	monthly := &watermarkTracker{granularity: "month", cutoff: day(31)}
+	monthly.observe(&CostRecord{Timestamp: day(1), MetricType: "cost"})
+	assert.True(t, monthly.latest.IsZero(), "January ends after the cutoff")
+
+	var none *watermarkTracker
+	none.observe(&CostRecord{Timestamp: day(1), MetricType: "cost"})
+}
+
+func TestAdapter_StartWatermark_Cutoff(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		cfg          Config
+		integrations []client.Integration
+		err          error
+		want         time.Time
+	}{
+		{
+			name: "finality lag",
+			cfg:  Config{FinalityLagDays: 2},
+			want: now.AddDate(0, 0, -2),
+		},
+		{
+			name: "restatement window re-fetches recent days",
+			cfg:  Config{FinalityLagDays: 2, RestatementWindowDays: 7},
+			want: now.AddDate(0, 0, -8),
+		},
+		{
+			name: "stalest active integration",
+			cfg:  Config{FinalityLagDays: 2, WatermarkIntegrationFreshness: true},
+			integrations: []client.Integration{
+				{Provider: "aws", Status: "active", LastSyncedAt: now.Add(-time.Hour)},
+				{Provider: "gcp", Status: "connected", LastSyncedAt: now.AddDate(0, 0, -4)},
+				{Provider: "azure", Status: "error", LastSyncedAt: now.AddDate(0, 0, -30)},
+			},
+			want: now.AddDate(0, 0, -4),
+		},
+		{
+			name: "integration never imported",
+			cfg:  Config{WatermarkIntegrationFreshness: true},
+			integrations: []client.Integration{
+				{Provider: "aws", Status: "active"},
+			},
+		},
+		{
+			name: "listing fails",
+			cfg:  Config{WatermarkIntegrationFreshness: true},
+			err:  errors.New("forbidden"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockClient{}
+			if tt.cfg.WatermarkIntegrationFreshness {
+				mockClient.On("ListIntegrations", mock.Anything, "").Return(tt.integrations, tt.err)
+			}
+			adapter := New(mockClient, client.NewNoopLogger())
+
+			adapter.startWatermark(context.Background(), tt.cfg, now)
+
+			if tt.want.IsZero() {
+				assert.Nil(t, adapter.watermark)
+				assert.Equal(t, 1, adapter.GetDiagnosticsSummary().Warnings["watermark_unavailable"])
+				return
+			}
+			require.NotNil(t, adapter.watermark)
+			assert.Equal(t, tt.want, adapter.watermark.cutoff)
+		})
+	}
+}
+
+func TestAdapter_Sync_WatermarkNeverMovesBack(t *testing.T) {
+	mockClient := &mockClient{}
+	mockSink := &mockSink{}
+	store := bookmark.NewMemory()
+
+	adapter := New(mockClient, client.NewNoopLogger())
+	adapter.SetBookmarkStore(store)
+
+	cfg := Config{CostReportToken: "cr_test", Granularity: "day", PageSize: 100, FinalityLagDays: 2}
+	key := watermarkBookmarkKey(adapter.reportQueryHash(cfg))
+	require.NoError(t, store.SetBookmark(context.Background(), key, "2024-06-30T00:00:00Z"))
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	cfg.StartDate = startDate
+	cfg.EndDate = &endDate
+
+	mockClient.On("Costs", mock.Anything, mock.AnythingOfType("client.Query")).Return(client.Page{
+		Data: []client.CostRow{
+			{BucketStart: startDate, Service: "ec2", Cost: 1},
+			{BucketStart: startDate.AddDate(0, 0, 1), Service: "ec2", Cost: 2},
+		},
+	}, nil)
+	mockSink.On("WriteRecords", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, adapter.Sync(context.Background(), cfg, mockSink))
+
+	stats := adapter.GetSyncStats()
+	require.NotNil(t, stats.Watermark)
+	assert.Equal(t, "2024-06-30", stats.Watermark.BucketStart, "a backfill of older dates keeps the saved watermark")
+	assert.Empty(t, stats.Bookmarks)
+
+	saved, err := store.GetBookmark(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-30T00:00:00Z", saved)
+}
@@ -0,0 +1,239 @@
+// Package alert checks synced cost records against the configured spend and
+// budget thresholds and notifies webhooks when one is crossed.
+package alert
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/report"
+)
+
+// Breach is one crossed threshold: a spend group over its threshold, or a
+// budget over its utilization percent.
+type Breach struct {
+	RuleID string `json:"rule_id"`
+	Kind   string `json:"kind"`
+	// GroupBy and Group name the offending dimension value; both are empty
+	// for a total spend rule. Budget rules group by budget name.
+	GroupBy  string  `json:"group_by,omitempty"`
+	Group    string  `json:"group,omitempty"`
+	Period   string  `json:"period"`
+	Currency string  `json:"currency,omitempty"`
+	Spend    float64 `json:"spend"`
+	// Threshold is the spend threshold or the budget amount.
+	Threshold float64 `json:"threshold"`
+	// Percent is the budget utilization; zero for spend rules.
+	Percent float64 `json:"percent,omitempty"`
+	Link    string  `json:"link,omitempty"`
+}
+
+// Message describes the breach in one line.
+func (b Breach) Message() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "Alert %s: ", b.RuleID)
+	switch {
+	case b.Kind == adapter.AlertKindBudget:
+		fmt.Fprintf(&msg, "budget %s is %.1f%% used (%s of %s) for %s",
+			b.Group, b.Percent, formatAmount(b.Spend, b.Currency), formatAmount(b.Threshold, b.Currency), b.Period)
+	case b.GroupBy != "":
+		fmt.Fprintf(&msg, "%s %s spent %s for %s, over the %s threshold",
+			b.GroupBy, b.Group, formatAmount(b.Spend, b.Currency), b.Period, formatAmount(b.Threshold, b.Currency))
+	default:
+		fmt.Fprintf(&msg, "total spend is %s for %s, over the %s threshold",
+			formatAmount(b.Spend, b.Currency), b.Period, formatAmount(b.Threshold, b.Currency))
+	}
+	if b.Link != "" {
+		fmt.Fprintf(&msg, " (%s)", b.Link)
+	}
+	return msg.String()
+}
+
+func formatAmount(v float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.2f %s", v, currency)
+}
+
+// Evaluate returns every threshold rules crosses in records as of now.
+// Spend rules sum net cost over the current UTC month, or over the previous
+// UTC day since the current one is still incomplete; budget rules read each
+// budget's latest period started by now. Records are expected to be
+// reconciled already.
+func Evaluate(rules []adapter.AlertRule, records []adapter.CostRecord, now time.Time) []Breach {
+	var breaches []Breach
+	for _, rule := range rules {
+		switch rule.Kind {
+		case adapter.AlertKindSpend:
+			breaches = append(breaches, evaluateSpend(rule, records, now)...)
+		case adapter.AlertKindBudget:
+			breaches = append(breaches, evaluateBudget(rule, records, now)...)
+		}
+	}
+	return breaches
+}
+
+// evaluateSpend returns the groups over a spend rule's threshold.
+func evaluateSpend(rule adapter.AlertRule, records []adapter.CostRecord, now time.Time) []Breach {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	period := start.Format("2006-01")
+	if rule.Period == adapter.AlertPeriodDay {
+		end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		start = end.AddDate(0, 0, -1)
+		period = start.Format(time.DateOnly)
+	}
+
+	showback := report.Build(records, rule.GroupBy, start, end)
+	rows := showback.Rows
+	if rule.GroupBy == "" {
+		rows = showback.Totals
+	}
+
+	var breaches []Breach
+	for _, row := range rows {
+		if row.NetCost < rule.Threshold {
+			continue
+		}
+		breach := Breach{
+			RuleID:    rule.ID,
+			Kind:      rule.Kind,
+			Period:    period,
+			Currency:  row.Currency,
+			Spend:     row.NetCost,
+			Threshold: rule.Threshold,
+			Link:      rule.Link,
+		}
+		if rule.GroupBy != "" {
+			breach.GroupBy = rule.GroupBy
+			breach.Group = row.Group
+		}
+		breaches = append(breaches, breach)
+	}
+	return breaches
+}
+
+// evaluateBudget returns the budgets at or over a budget rule's percent.
+func evaluateBudget(rule adapter.AlertRule, records []adapter.CostRecord, now time.Time) []Breach {
+	latest := make(map[string]*adapter.CostRecord)
+	for i := range records {
+		record := &records[i]
+		if record.MetricType != "budget" || record.Timestamp.After(now) {
+			continue
+		}
+		if rule.Budget != "" && rule.Budget != record.BudgetToken && rule.Budget != record.BudgetName {
+			continue
+		}
+		if current, ok := latest[record.BudgetToken]; !ok || record.Timestamp.After(current.Timestamp) {
+			latest[record.BudgetToken] = record
+		}
+	}
+
+	var breaches []Breach
+	for _, record := range latest {
+		if record.BudgetUtilizationPercent == nil || *record.BudgetUtilizationPercent < rule.Percent {
+			continue
+		}
+		breach := Breach{
+			RuleID:   rule.ID,
+			Kind:     rule.Kind,
+			GroupBy:  "budget",
+			Group:    cmp.Or(record.BudgetName, record.BudgetToken),
+			Period:   record.Timestamp.UTC().Format(time.DateOnly),
+			Currency: record.Currency,
+			Percent:  *record.BudgetUtilizationPercent,
+			Link:     rule.Link,
+		}
+		if record.NetCost != nil {
+			breach.Spend = *record.NetCost
+		}
+		if record.BudgetAmount != nil {
+			breach.Threshold = *record.BudgetAmount
+		}
+		breaches = append(breaches, breach)
+	}
+	slices.SortFunc(breaches, func(a, b Breach) int { return cmp.Compare(a.Group, b.Group) })
+	return breaches
+}
+
+// Alerter notifies webhooks of breaches once per rule, group, and period,
+// remembering what it sent in a bookmark store.
+type Alerter struct {
+	notifier *Notifier
+	store    adapter.BookmarkStore
+}
+
+// NewAlerter creates an alerter sending through notifier and deduplicating
+// through store.
+func NewAlerter(notifier *Notifier, store adapter.BookmarkStore) *Alerter {
+	return &Alerter{notifier: notifier, store: store}
+}
+
+// Check evaluates cfg's rules against records and notifies each rule's
+// webhooks of breaches not already sent. A breach is marked sent only once
+// every webhook accepted it, so a failed delivery is retried on the next
+// check. It returns the breaches sent, and every failure joined.
+func (al *Alerter) Check(ctx context.Context, cfg adapter.AlertsConfig, records []adapter.CostRecord, now time.Time) ([]Breach, error) {
+	webhooks := make(map[string]adapter.WebhookConfig, len(cfg.Webhooks))
+	for _, webhook := range cfg.Webhooks {
+		webhooks[webhook.Name] = webhook
+	}
+	rules := make(map[string]adapter.AlertRule, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		rules[rule.ID] = rule
+	}
+
+	var sent []Breach
+	var errs []error
+	for _, breach := range Evaluate(cfg.Rules, records, now) {
+		key := breachKey(breach)
+		previous, err := al.store.GetBookmark(ctx, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reading alert state for %s: %w", breach.RuleID, err))
+			continue
+		}
+		if previous != "" {
+			continue
+		}
+
+		targets := rules[breach.RuleID].Webhooks
+		if len(targets) == 0 {
+			for _, webhook := range cfg.Webhooks {
+				targets = append(targets, webhook.Name)
+			}
+		}
+		delivered := true
+		for _, name := range targets {
+			if err := al.notifier.Send(ctx, webhooks[name], breach); err != nil {
+				errs = append(errs, err)
+				delivered = false
+			}
+		}
+		if !delivered {
+			continue
+		}
+
+		if err := al.store.SetBookmark(ctx, key, now.UTC().Format(time.RFC3339)); err != nil {
+			errs = append(errs, fmt.Errorf("saving alert state for %s: %w", breach.RuleID, err))
+		}
+		sent = append(sent, breach)
+	}
+	return sent, errors.Join(errs...)
+}
+
+// breachKey builds the bookmark key marking a breach as sent.
+func breachKey(b Breach) string {
+	parts := []string{b.GroupBy, b.Group, b.Period, b.Currency}
+	hash := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return fmt.Sprintf("vantage_alert_%s_%s", b.RuleID, hex.EncodeToString(hash[:8]))
+}
@@ -0,0 +1,189 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+)
+
+func float(v float64) *float64 { return &v }
+
+var now = time.Date(2024, 3, 15, 6, 0, 0, 0, time.UTC)
+
+func testRecords() []adapter.CostRecord {
+	return []adapter.CostRecord{
+		{
+			Timestamp: now.AddDate(0, 0, -1).Truncate(24 * time.Hour), Currency: "USD", MetricType: "cost",
+			Labels: map[string]string{"team": "platform"}, NetCost: float(800),
+		},
+		{
+			Timestamp: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Currency: "USD", MetricType: "cost",
+			Labels: map[string]string{"team": "platform"}, NetCost: float(400),
+		},
+		{
+			Timestamp: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Currency: "USD", MetricType: "cost",
+			Labels: map[string]string{"team": "payments"}, NetCost: float(300),
+		},
+		// Last month does not count toward this month's spend.
+		{
+			Timestamp: time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC), Currency: "USD", MetricType: "cost",
+			Labels: map[string]string{"team": "payments"}, NetCost: float(5000),
+		},
+		{
+			Timestamp: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Currency: "USD", MetricType: "budget",
+			BudgetToken: "bdgt_1", BudgetName: "Prod", BudgetAmount: float(1000),
+			NetCost: float(1200), BudgetUtilizationPercent: float(120),
+		},
+		{
+			Timestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Currency: "USD", MetricType: "budget",
+			BudgetToken: "bdgt_1", BudgetName: "Prod", BudgetAmount: float(1000),
+			NetCost: float(850), BudgetUtilizationPercent: float(85),
+		},
+		{
+			Timestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Currency: "USD", MetricType: "budget",
+			BudgetToken: "bdgt_2", BudgetName: "Dev", BudgetAmount: float(100),
+			NetCost: float(10), BudgetUtilizationPercent: float(10),
+		},
+	}
+}
+
+func TestEvaluate_SpendByGroup(t *testing.T) {
+	rules := []adapter.AlertRule{
+		{ID: "team-monthly", Kind: adapter.AlertKindSpend, GroupBy: "team", Period: adapter.AlertPeriodMonth, Threshold: 1000},
+	}
+
+	breaches := Evaluate(rules, testRecords(), now)
+
+	require.Len(t, breaches, 1)
+	assert.Equal(t, "team", breaches[0].GroupBy)
+	assert.Equal(t, "platform", breaches[0].Group)
+	assert.Equal(t, "2024-03", breaches[0].Period)
+	assert.InDelta(t, 1200.0, breaches[0].Spend, 1e-9)
+	assert.Equal(t, "Alert team-monthly: team platform spent 1200.00 USD for 2024-03, over the 1000.00 USD threshold",
+		breaches[0].Message())
+}
+
+func TestEvaluate_DailyTotal(t *testing.T) {
+	rules := []adapter.AlertRule{
+		{ID: "daily", Kind: adapter.AlertKindSpend, Period: adapter.AlertPeriodDay, Threshold: 500, Link: "https://example.com/d"},
+	}
+
+	breaches := Evaluate(rules, testRecords(), now)
+
+	require.Len(t, breaches, 1)
+	assert.Empty(t, breaches[0].Group)
+	assert.Equal(t, "2024-03-14", breaches[0].Period)
+	assert.InDelta(t, 800.0, breaches[0].Spend, 1e-9)
+	assert.Contains(t, breaches[0].Message(), "total spend is 800.00 USD")
+	assert.Contains(t, breaches[0].Message(), "(https://example.com/d)")
+}
+
+func TestEvaluate_BudgetUsesLatestPeriod(t *testing.T) {
+	rules := []adapter.AlertRule{{ID: "budgets", Kind: adapter.AlertKindBudget, Percent: 80}}
+
+	breaches := Evaluate(rules, testRecords(), now)
+
+	require.Len(t, breaches, 1)
+	assert.Equal(t, "Prod", breaches[0].Group)
+	assert.Equal(t, "2024-03-01", breaches[0].Period)
+	assert.InDelta(t, 85.0, breaches[0].Percent, 1e-9)
+
+	rules[0].Budget = "bdgt_2"
+	assert.Empty(t, Evaluate(rules, testRecords(), now))
+}
+
+// webhookServer records the bodies posted to it and answers with status.
+func webhookServer(t *testing.T, status int) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var mu sync.Mutex
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func TestAlerter_NotifiesOncePerPeriod(t *testing.T) {
+	slack, slackBodies := webhookServer(t, http.StatusOK)
+	generic, genericBodies := webhookServer(t, http.StatusAccepted)
+	cfg := adapter.AlertsConfig{
+		Webhooks: []adapter.WebhookConfig{
+			{Name: "slack", Type: adapter.WebhookTypeSlack, URL: slack.URL},
+			{Name: "pager", Type: adapter.WebhookTypeGeneric, URL: generic.URL},
+		},
+		Rules: []adapter.AlertRule{
+			{ID: "team-monthly", Kind: adapter.AlertKindSpend, GroupBy: "team", Period: adapter.AlertPeriodMonth, Threshold: 1000, Webhooks: []string{"slack"}},
+			{ID: "budgets", Kind: adapter.AlertKindBudget, Percent: 80},
+		},
+	}
+	alerter := NewAlerter(NewNotifier(nil), bookmark.NewMemory())
+
+	sent, err := alerter.Check(context.Background(), cfg, testRecords(), now)
+	require.NoError(t, err)
+	require.Len(t, sent, 2)
+
+	require.Len(t, *slackBodies, 2)
+	assert.Contains(t, (*slackBodies)[0]["text"], "team platform spent")
+	require.Len(t, *genericBodies, 1)
+	assert.Equal(t, "budgets", (*genericBodies)[0]["rule_id"])
+	assert.Equal(t, "Prod", (*genericBodies)[0]["group"])
+	assert.Contains(t, (*genericBodies)[0]["message"], "budget Prod is 85.0% used")
+
+	sent, err = alerter.Check(context.Background(), cfg, testRecords(), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+	assert.Len(t, *slackBodies, 2)
+
+	records := append(testRecords(), adapter.CostRecord{
+		Timestamp: time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC), Currency: "USD", MetricType: "cost",
+		Labels: map[string]string{"team": "platform"}, NetCost: float(1500),
+	})
+	sent, err = alerter.Check(context.Background(), cfg, records, now.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Len(t, sent, 1, "a new month is a new period; the budget's period is unchanged")
+	assert.Equal(t, "2024-04", sent[0].Period)
+}
+
+func TestAlerter_RetriesFailedDelivery(t *testing.T) {
+	server, bodies := webhookServer(t, http.StatusInternalServerError)
+	cfg := adapter.AlertsConfig{
+		Webhooks: []adapter.WebhookConfig{{Name: "hook", Type: adapter.WebhookTypeGeneric, URL: server.URL + "/secret-token"}},
+		Rules:    []adapter.AlertRule{{ID: "budgets", Kind: adapter.AlertKindBudget, Percent: 80}},
+	}
+	alerter := NewAlerter(NewNotifier(nil), bookmark.NewMemory())
+
+	sent, err := alerter.Check(context.Background(), cfg, testRecords(), now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notifying webhook hook: unexpected status 500")
+	assert.Empty(t, sent)
+
+	_, err = alerter.Check(context.Background(), cfg, testRecords(), now)
+	require.Error(t, err)
+	assert.Len(t, *bodies, 2)
+}
+
+func TestNotifier_ErrorOmitsURL(t *testing.T) {
+	webhook := adapter.WebhookConfig{Name: "hook", URL: "http://127.0.0.1:1/secret-token"}
+
+	err := NewNotifier(nil).Send(context.Background(), webhook, Breach{RuleID: "r"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notifying webhook hook")
+	assert.NotContains(t, err.Error(), "secret-token")
+}
@@ -0,0 +1,82 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// Notifier posts breaches to webhooks.
+type Notifier struct {
+	httpClient *http.Client
+}
+
+// NewNotifier creates a notifier sending through httpClient, or
+// http.DefaultClient when it is nil.
+func NewNotifier(httpClient *http.Client) *Notifier {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Notifier{httpClient: httpClient}
+}
+
+// slackMessage is the body of a Slack incoming webhook.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// genericMessage is the body of a generic webhook: the breach with its
+// message.
+type genericMessage struct {
+	Message string `json:"message"`
+	Breach
+}
+
+// Send posts breach to webhook: a Slack message for Slack webhooks, and a
+// JSON document with every breach field otherwise. Any status outside 2xx
+// is an error. Errors name the webhook, never its URL.
+func (n *Notifier) Send(ctx context.Context, webhook adapter.WebhookConfig, breach Breach) error {
+	var payload interface{} = genericMessage{Message: breach.Message(), Breach: breach}
+	if webhook.Type == adapter.WebhookTypeSlack {
+		payload = slackMessage{Text: breach.Message()}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding alert for webhook %s: %w", webhook.Name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request for webhook %s: %w", webhook.Name, withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifying webhook %s: %w", webhook.Name, withoutURL(err))
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("notifying webhook %s: unexpected status %d", webhook.Name, resp.StatusCode)
+	}
+	return nil
+}
+
+// withoutURL strips the URL a url.Error repeats, since webhook URLs hold
+// their secret.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,236 @@
+// Package bench measures the sync pipeline: mapping, normalization,
+// idempotency hashing, and batching of synthetic rows into a sink that
+// discards them. It backs the bench command and the package benchmarks.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// Defaults for zero Options fields.
+const (
+	DefaultRows          = 100000
+	DefaultRowsPerBucket = 100
+	DefaultPageSize      = 5000
+	DefaultBatchSize     = 1000
+)
+
+const tabPadding = 2
+
+// benchStart is the first day synced; a fixed date keeps runs comparable.
+var benchStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Options sizes a run.
+type Options struct {
+	// Rows is the least number of rows pushed through the pipeline; it is
+	// rounded up to whole days of RowsPerBucket rows.
+	Rows          int `json:"rows"`
+	RowsPerBucket int `json:"rows_per_bucket"`
+	PageSize      int `json:"page_size"`
+	BatchSize     int `json:"batch_size"`
+	// GroupBys and Metrics are set on the query, so their share of the
+	// idempotency hash is measured; the synthetic rows ignore them.
+	GroupBys []string `json:"group_bys,omitempty"`
+	Metrics  []string `json:"metrics,omitempty"`
+	Seed     int64    `json:"seed"`
+	// LineItemHash is the line item hash algorithm; empty is SHA-256.
+	LineItemHash string `json:"line_item_hash,omitempty"`
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	Options Options `json:"options"`
+	// Rows is how many rows were mapped and Records how many records the
+	// sink received.
+	Rows    int `json:"rows"`
+	Records int `json:"records"`
+	Pages   int `json:"pages"`
+
+	Duration      time.Duration `json:"duration_ns"`
+	RowsPerSecond float64       `json:"rows_per_second"`
+
+	// Allocations made during the timed sync, by every goroutine.
+	Allocs       uint64  `json:"allocs"`
+	AllocBytes   uint64  `json:"alloc_bytes"`
+	AllocsPerRow float64 `json:"allocs_per_row"`
+	BytesPerRow  float64 `json:"bytes_per_row"`
+}
+
+// Run syncs the synthetic rows twice: once to generate and cache the API
+// pages, and once timed, so the result covers the pipeline rather than the
+// generator or JSON decoding.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	opts = withDefaults(opts)
+	if opts.LineItemHash != "" && !slices.Contains(adapter.HashAlgorithms(), opts.LineItemHash) {
+		return Result{}, fmt.Errorf("invalid line item hash: %s (valid: %s)",
+			opts.LineItemHash, strings.Join(adapter.HashAlgorithms(), ", "))
+	}
+	days := (opts.Rows + opts.RowsPerBucket - 1) / opts.RowsPerBucket
+	end := benchStart.AddDate(0, 0, days)
+
+	mock, err := client.New(client.Config{
+		BaseURL: client.MockScheme + "://vantage",
+		Transport: client.NewMockTransport(client.MockConfig{
+			Seed:          opts.Seed,
+			RowsPerBucket: opts.RowsPerBucket,
+		}),
+		Logger: client.NewNoopLogger(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	api := &pageCache{Client: mock, pages: make(map[pageKey]client.Page)}
+
+	cfg := adapter.Config{
+		CostReportToken: "rprt_bench",
+		Granularity:     "day",
+		StartDate:       benchStart,
+		EndDate:         &end,
+		PageSize:        opts.PageSize,
+		BatchSize:       opts.BatchSize,
+		GroupBys:        opts.GroupBys,
+		Metrics:         opts.Metrics,
+		LineItemHash:    opts.LineItemHash,
+	}
+
+	// Warm up: fill the page cache.
+	if err := adapter.New(api, client.NewNoopLogger()).Sync(ctx, cfg, &nullSink{}); err != nil {
+		return Result{}, fmt.Errorf("warming up: %w", err)
+	}
+
+	sink := &nullSink{}
+	a := adapter.New(api, client.NewNoopLogger())
+	api.served.Store(0)
+	api.rows.Store(0)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	err = a.Sync(ctx, cfg, sink)
+	duration := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Options:    opts,
+		Rows:       int(api.rows.Load()),
+		Records:    sink.records,
+		Pages:      int(api.served.Load()),
+		Duration:   duration,
+		Allocs:     after.Mallocs - before.Mallocs,
+		AllocBytes: after.TotalAlloc - before.TotalAlloc,
+	}
+	if seconds := duration.Seconds(); seconds > 0 {
+		result.RowsPerSecond = float64(result.Rows) / seconds
+	}
+	if result.Rows > 0 {
+		result.AllocsPerRow = float64(result.Allocs) / float64(result.Rows)
+		result.BytesPerRow = float64(result.AllocBytes) / float64(result.Rows)
+	}
+	return result, nil
+}
+
+// withDefaults fills in zero options.
+func withDefaults(opts Options) Options {
+	if opts.Rows <= 0 {
+		opts.Rows = DefaultRows
+	}
+	if opts.RowsPerBucket <= 0 {
+		opts.RowsPerBucket = DefaultRowsPerBucket
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	return opts
+}
+
+// Print writes the result as a table.
+func (r Result) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, tabPadding, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Rows\t%d\n", r.Rows)
+	_, _ = fmt.Fprintf(tw, "Records\t%d\n", r.Records)
+	_, _ = fmt.Fprintf(tw, "Pages\t%d (page_size %d, batch_size %d)\n", r.Pages, r.Options.PageSize, r.Options.BatchSize)
+	if r.Options.LineItemHash != "" {
+		_, _ = fmt.Fprintf(tw, "Line item hash\t%s\n", r.Options.LineItemHash)
+	}
+	_, _ = fmt.Fprintf(tw, "Duration\t%s\n", r.Duration.Round(time.Millisecond))
+	_, _ = fmt.Fprintf(tw, "Rows/sec\t%.0f\n", r.RowsPerSecond)
+	_, _ = fmt.Fprintf(tw, "Allocs\t%d (%.1f per row)\n", r.Allocs, r.AllocsPerRow)
+	_, _ = fmt.Fprintf(tw, "Allocated\t%.1f MiB (%.0f B per row)\n", float64(r.AllocBytes)/(1<<20), r.BytesPerRow)
+	return tw.Flush()
+}
+
+// PrintJSON writes the result as indented JSON.
+func (r Result) PrintJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// pageKey identifies a costs page: the adapter chunks long ranges by month,
+// so cursors repeat across queries.
+type pageKey struct {
+	start, end time.Time
+	cursor     string
+}
+
+// pageCache serves each costs page from memory after the first request,
+// counting the pages and rows it serves.
+type pageCache struct {
+	client.Client
+
+	mu    sync.Mutex
+	pages map[pageKey]client.Page
+
+	served, rows atomic.Int64
+}
+
+// Costs implements client.Client.
+func (c *pageCache) Costs(ctx context.Context, query client.Query) (client.Page, error) {
+	key := pageKey{start: query.StartAt, end: query.EndAt, cursor: query.Cursor}
+	c.mu.Lock()
+	page, ok := c.pages[key]
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		if page, err = c.Client.Costs(ctx, query); err != nil {
+			return client.Page{}, err
+		}
+		c.mu.Lock()
+		c.pages[key] = page
+		c.mu.Unlock()
+	}
+	c.served.Add(1)
+	c.rows.Add(int64(len(page.Data)))
+	return page, nil
+}
+
+// nullSink counts and discards records.
+type nullSink struct {
+	records int
+}
+
+// WriteRecords implements adapter.Sink.
+func (s *nullSink) WriteRecords(_ context.Context, records []adapter.CostRecord) error {
+	s.records += len(records)
+	return nil
+}
@@ -0,0 +1,64 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func TestRun(t *testing.T) {
+	result, err := Run(context.Background(), Options{Rows: 950, RowsPerBucket: 100, PageSize: 300, BatchSize: 250})
+	require.NoError(t, err)
+
+	// 950 rows round up to 10 days of 100 rows, fetched in pages of 300.
+	assert.Equal(t, 1000, result.Rows)
+	assert.Equal(t, 1000, result.Records)
+	assert.Equal(t, 4, result.Pages)
+	assert.Positive(t, result.RowsPerSecond)
+	assert.Positive(t, result.Allocs)
+
+	var out bytes.Buffer
+	require.NoError(t, result.Print(&out))
+	assert.Contains(t, out.String(), "Rows/sec")
+	out.Reset()
+	require.NoError(t, result.PrintJSON(&out))
+	assert.Contains(t, out.String(), `"rows_per_second"`)
+}
+
+func TestRun_LineItemHash(t *testing.T) {
+	result, err := Run(context.Background(), Options{Rows: 100, LineItemHash: adapter.HashXXHash128})
+	require.NoError(t, err)
+	assert.Equal(t, 100, result.Records)
+
+	var out bytes.Buffer
+	require.NoError(t, result.Print(&out))
+	assert.Contains(t, out.String(), "xxhash128")
+
+	_, err = Run(context.Background(), Options{Rows: 100, LineItemHash: "md5"})
+	assert.ErrorContains(t, err, "invalid line item hash: md5")
+}
+
+// BenchmarkSync pushes 10,000 rows through the pipeline per iteration.
+func BenchmarkSync(b *testing.B) {
+	for _, metrics := range [][]string{nil, {"cost", "usage"}} {
+		b.Run(fmt.Sprintf("metrics=%d", len(metrics)), func(b *testing.B) {
+			var rows int
+			var seconds float64
+			for b.Loop() {
+				result, err := Run(context.Background(), Options{Rows: 10000, Metrics: metrics})
+				if err != nil {
+					b.Fatal(err)
+				}
+				rows += result.Rows
+				seconds += result.Duration.Seconds()
+			}
+			b.ReportMetric(float64(rows)/seconds, "rows/s")
+		})
+	}
+}
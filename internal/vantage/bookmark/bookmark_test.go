@@ -0,0 +1,156 @@
+package bookmark_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/bookmark"
+)
+
+var (
+	_ adapter.BookmarkStore = (*bookmark.Memory)(nil)
+	_ adapter.BookmarkStore = (*bookmark.File)(nil)
+	_ adapter.BookmarkStore = (*bookmark.SQLite)(nil)
+	_ adapter.BookmarkStore = (*bookmark.DynamoDB)(nil)
+)
+
+// lister is the enumeration method every store in this package provides.
+type lister interface {
+	adapter.BookmarkStore
+	Bookmarks(ctx context.Context) (map[string]string, error)
+}
+
+// exerciseStore checks the shared get/set/overwrite/list contract.
+func exerciseStore(t *testing.T, store lister) {
+	t.Helper()
+	ctx := context.Background()
+
+	value, err := store.GetBookmark(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	require.NoError(t, store.SetBookmark(ctx, "vantage_abc", "2024-01-31"))
+	require.NoError(t, store.SetBookmark(ctx, "vantage_def", "2024-02-29"))
+	require.NoError(t, store.SetBookmark(ctx, "vantage_abc", "2024-03-31"))
+
+	value, err = store.GetBookmark(ctx, "vantage_abc")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-31", value)
+
+	all, err := store.Bookmarks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"vantage_abc": "2024-03-31", "vantage_def": "2024-02-29"}, all)
+}
+
+func TestMemory(t *testing.T) {
+	exerciseStore(t, bookmark.NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "bookmarks.json")
+	store, err := bookmark.NewFile(path)
+	require.NoError(t, err)
+	exerciseStore(t, store)
+
+	// A fresh store over the same file sees persisted bookmarks.
+	reopened, err := bookmark.NewFile(path)
+	require.NoError(t, err)
+	value, err := reopened.GetBookmark(context.Background(), "vantage_def")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02-29", value)
+}
+
+func TestNewFile_EmptyPath(t *testing.T) {
+	_, err := bookmark.NewFile("")
+	require.Error(t, err)
+}
+
+func TestSQLite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	store, err := bookmark.NewSQLite(ctx, path, "pulumicost_bookmarks")
+	require.NoError(t, err)
+	exerciseStore(t, store)
+	require.NoError(t, store.Close())
+
+	reopened, err := bookmark.NewSQLite(ctx, path, "pulumicost_bookmarks")
+	require.NoError(t, err)
+	defer reopened.Close()
+	value, err := reopened.GetBookmark(ctx, "vantage_def")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02-29", value)
+}
+
+func TestNewSQLite_InvalidTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	_, err := bookmark.NewSQLite(context.Background(), path, "bookmarks; DROP TABLE x")
+	require.Error(t, err)
+}
+
+// fakeDynamoDB is an in-memory stand-in for the DynamoDB item API that pages
+// Scan results one item at a time.
+type fakeDynamoDB struct {
+	items map[string]map[string]types.AttributeValue
+	order []string
+}
+
+func (f *fakeDynamoDB) GetItem(
+	_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options),
+) (*dynamodb.GetItemOutput, error) {
+	key := in.Key["key"].(*types.AttributeValueMemberS).Value
+	return &dynamodb.GetItemOutput{Item: f.items[key]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(
+	_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	if f.items == nil {
+		f.items = make(map[string]map[string]types.AttributeValue)
+	}
+	key := in.Item["key"].(*types.AttributeValueMemberS).Value
+	if _, ok := f.items[key]; !ok {
+		f.order = append(f.order, key)
+	}
+	f.items[key] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) Scan(
+	_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options),
+) (*dynamodb.ScanOutput, error) {
+	next := 0
+	if in.ExclusiveStartKey != nil {
+		last := in.ExclusiveStartKey["key"].(*types.AttributeValueMemberS).Value
+		next = slices.Index(f.order, last) + 1
+	}
+	if next >= len(f.order) {
+		return &dynamodb.ScanOutput{}, nil
+	}
+	item := f.items[f.order[next]]
+	return &dynamodb.ScanOutput{
+		Items:            []map[string]types.AttributeValue{item},
+		LastEvaluatedKey: map[string]types.AttributeValue{"key": item["key"]},
+	}, nil
+}
+
+func TestDynamoDB(t *testing.T) {
+	store, err := bookmark.NewDynamoDB(&fakeDynamoDB{}, "pulumicost_bookmarks")
+	require.NoError(t, err)
+	exerciseStore(t, store)
+}
+
+func TestNewDynamoDB_Validation(t *testing.T) {
+	_, err := bookmark.NewDynamoDB(nil, "pulumicost_bookmarks")
+	require.Error(t, err)
+
+	_, err = bookmark.NewDynamoDB(&fakeDynamoDB{}, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package bookmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB attribute names. The table's partition key must be "key" (string).
+const (
+	dynamoKeyAttr       = "key"
+	dynamoValueAttr     = "value"
+	dynamoUpdatedAtAttr = "updated_at"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by the store.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Scan(ctx context.Context, in *dynamodb.ScanInput, opts ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// DynamoDB stores bookmarks as items in a DynamoDB table, so several runners
+// can share sync state. Reads are strongly consistent.
+type DynamoDB struct {
+	api   DynamoDBAPI
+	table string
+}
+
+// NewDynamoDB creates a store over an existing table.
+func NewDynamoDB(api DynamoDBAPI, table string) (*DynamoDB, error) {
+	if api == nil {
+		return nil, errors.New("dynamodb client cannot be nil")
+	}
+	if table == "" {
+		return nil, errors.New("dynamodb table cannot be empty")
+	}
+	return &DynamoDB{api: api, table: table}, nil
+}
+
+// GetBookmark implements adapter.BookmarkStore. A missing bookmark returns "".
+func (d *DynamoDB) GetBookmark(ctx context.Context, key string) (string, error) {
+	out, err := d.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            map[string]types.AttributeValue{dynamoKeyAttr: &types.AttributeValueMemberS{Value: key}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("reading bookmark: %w", err)
+	}
+	return stringAttr(out.Item, dynamoValueAttr), nil
+}
+
+// SetBookmark implements adapter.BookmarkStore.
+func (d *DynamoDB) SetBookmark(ctx context.Context, key string, value string) error {
+	_, err := d.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]types.AttributeValue{
+			dynamoKeyAttr:       &types.AttributeValueMemberS{Value: key},
+			dynamoValueAttr:     &types.AttributeValueMemberS{Value: value},
+			dynamoUpdatedAtAttr: &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("writing bookmark: %w", err)
+	}
+	return nil
+}
+
+// Bookmarks returns every stored bookmark. It scans the whole table, so it is
+// meant for diagnostics rather than the sync path.
+func (d *DynamoDB) Bookmarks(ctx context.Context) (map[string]string, error) {
+	bookmarks := make(map[string]string)
+	input := &dynamodb.ScanInput{TableName: aws.String(d.table), ConsistentRead: aws.Bool(true)}
+	for {
+		out, err := d.api.Scan(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("listing bookmarks: %w", err)
+		}
+		for _, item := range out.Items {
+			bookmarks[stringAttr(item, dynamoKeyAttr)] = stringAttr(item, dynamoValueAttr)
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return bookmarks, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// stringAttr returns the string attribute name from item, or "" when absent.
+func stringAttr(item map[string]types.AttributeValue, name string) string {
+	if s, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
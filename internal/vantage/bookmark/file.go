@@ -0,0 +1,99 @@
+package bookmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	dirPerm  = 0o750
+	filePerm = 0o600
+)
+
+// File stores bookmarks as a JSON object in a single file, replaced
+// atomically on every update.
+type File struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFile creates a file store at path, creating its directory if needed.
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("bookmark file path cannot be empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return nil, fmt.Errorf("creating bookmark directory: %w", err)
+	}
+	return &File{path: path}, nil
+}
+
+// GetBookmark implements adapter.BookmarkStore. A missing bookmark returns "".
+func (f *File) GetBookmark(_ context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bookmarks, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	return bookmarks[key], nil
+}
+
+// SetBookmark implements adapter.BookmarkStore.
+func (f *File) SetBookmark(_ context.Context, key string, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bookmarks, err := f.read()
+	if err != nil {
+		return err
+	}
+	bookmarks[key] = value
+
+	data, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding bookmarks: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a torn file.
+	tmp := f.path + ".tmp"
+	if writeErr := os.WriteFile(tmp, data, filePerm); writeErr != nil {
+		return fmt.Errorf("writing bookmarks: %w", writeErr)
+	}
+	if renameErr := os.Rename(tmp, f.path); renameErr != nil {
+		return fmt.Errorf("replacing bookmarks: %w", renameErr)
+	}
+	return nil
+}
+
+// Bookmarks returns a copy of every stored bookmark.
+func (f *File) Bookmarks(_ context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.read()
+}
+
+// read loads the bookmark file; callers must hold f.mu.
+func (f *File) read() (map[string]string, error) {
+	bookmarks := make(map[string]string)
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return bookmarks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading bookmarks: %w", err)
+	}
+
+	if unmarshalErr := json.Unmarshal(data, &bookmarks); unmarshalErr != nil {
+		return nil, fmt.Errorf("parsing bookmarks: %w", unmarshalErr)
+	}
+	return bookmarks, nil
+}
@@ -0,0 +1,43 @@
+// Package bookmark provides BookmarkStore implementations for persisting sync
+// state independently of where records are written.
+package bookmark
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Memory is an in-process bookmark store. State is lost when the process
+// exits, so it suits tests and one-shot backfills only.
+type Memory struct {
+	mu        sync.RWMutex
+	bookmarks map[string]string
+}
+
+// NewMemory creates an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{bookmarks: make(map[string]string)}
+}
+
+// GetBookmark implements adapter.BookmarkStore. A missing bookmark returns "".
+func (m *Memory) GetBookmark(_ context.Context, key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.bookmarks[key], nil
+}
+
+// SetBookmark implements adapter.BookmarkStore.
+func (m *Memory) SetBookmark(_ context.Context, key string, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bookmarks[key] = value
+	return nil
+}
+
+// Bookmarks returns a copy of every stored bookmark.
+func (m *Memory) Bookmarks(_ context.Context) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.bookmarks), nil
+}
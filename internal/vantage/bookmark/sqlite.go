@@ -0,0 +1,107 @@
+package bookmark
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" driver
+)
+
+// validTableName restricts table names to plain identifiers, since they are
+// interpolated into SQL statements.
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLite stores bookmarks in a key/value table of a local SQLite database.
+// Concurrent writers are serialised by SQLite's own locking.
+type SQLite struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLite opens (or creates) the database at path and ensures the bookmark
+// table exists.
+func NewSQLite(ctx context.Context, path, table string) (*SQLite, error) {
+	if path == "" {
+		return nil, errors.New("bookmark database path cannot be empty")
+	}
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid bookmark table name: %q", table)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return nil, fmt.Errorf("creating bookmark directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening bookmark database: %w", err)
+	}
+
+	create := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (key TEXT PRIMARY KEY, value TEXT NOT NULL, updated_at TEXT NOT NULL)",
+		table,
+	)
+	if _, execErr := db.ExecContext(ctx, create); execErr != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating bookmark table: %w", execErr)
+	}
+	return &SQLite{db: db, table: table}, nil
+}
+
+// GetBookmark implements adapter.BookmarkStore. A missing bookmark returns "".
+func (s *SQLite) GetBookmark(ctx context.Context, key string) (string, error) {
+	var value string
+	query := fmt.Sprintf("SELECT value FROM %s WHERE key = ?", s.table)
+	err := s.db.QueryRowContext(ctx, query, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading bookmark: %w", err)
+	}
+	return value, nil
+}
+
+// SetBookmark implements adapter.BookmarkStore.
+func (s *SQLite) SetBookmark(ctx context.Context, key string, value string) error {
+	upsert := fmt.Sprintf(
+		"INSERT INTO %s (key, value, updated_at) VALUES (?, ?, datetime('now')) "+
+			"ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at",
+		s.table,
+	)
+	if _, err := s.db.ExecContext(ctx, upsert, key, value); err != nil {
+		return fmt.Errorf("writing bookmark: %w", err)
+	}
+	return nil
+}
+
+// Bookmarks returns a copy of every stored bookmark.
+func (s *SQLite) Bookmarks(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT key, value FROM %s", s.table))
+	if err != nil {
+		return nil, fmt.Errorf("listing bookmarks: %w", err)
+	}
+	defer rows.Close()
+
+	bookmarks := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if scanErr := rows.Scan(&key, &value); scanErr != nil {
+			return nil, fmt.Errorf("listing bookmarks: %w", scanErr)
+		}
+		bookmarks[key] = value
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("listing bookmarks: %w", rowsErr)
+	}
+	return bookmarks, nil
+}
+
+// Close releases the database handle.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
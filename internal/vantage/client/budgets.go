@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// Budgets implements Client.Budgets.
+func (c *client) Budgets(ctx context.Context, workspaceToken string) ([]Budget, error) {
+	params := url.Values{}
+	if workspaceToken != "" {
+		params.Set("workspace_token", workspaceToken)
+	}
+
+	return listAll(ctx, c.httpClient, "list_budgets", "/budgets", params,
+		func(resp *budgetsResponse) ([]Budget, string) {
+			return resp.Budgets, resp.Links.Next
+		})
+}
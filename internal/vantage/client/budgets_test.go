@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Budgets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/budgets", r.URL.Path)
+		assert.Equal(t, "wrkspc_1", r.URL.Query().Get("workspace_token"))
+
+		_, _ = w.Write([]byte(`{
+			"budgets": [{
+				"token": "bdgt_1",
+				"name": "Production",
+				"cost_report_token": "rprt_1",
+				"periods": [{"start_at": "2024-01-01T00:00:00Z", "end_at": "2024-02-01T00:00:00Z", "amount": 1000}],
+				"performance": [{"date": "2024-01-01T00:00:00Z", "actual": 250.5, "amount": 1000}]
+			}],
+			"links": {}
+		}`))
+	}))
+	defer server.Close()
+
+	budgets, err := newTestClient(t, server.URL, 0).Budgets(context.Background(), "wrkspc_1")
+	require.NoError(t, err)
+
+	require.Len(t, budgets, 1)
+	assert.Equal(t, "rprt_1", budgets[0].CostReportToken)
+	require.Len(t, budgets[0].Periods, 1)
+	assert.InEpsilon(t, 1000.0, budgets[0].Periods[0].Amount, 0.001)
+	require.Len(t, budgets[0].Performance, 1)
+	assert.InEpsilon(t, 250.5, budgets[0].Performance[0].Actual, 0.001)
+}
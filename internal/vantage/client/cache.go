@@ -0,0 +1,204 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	cacheDirPerm  = 0o750
+	cacheFilePerm = 0o600
+)
+
+// responseCache keeps successful GET responses on disk so repeated runs over
+// the same windows revalidate with If-None-Match / If-Modified-Since instead
+// of downloading again. Only responses carrying an ETag or Last-Modified
+// header are stored.
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cacheEntry is one stored response. URL is redacted, so entries can be
+// inspected or shared without exposing tokens.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	Body         []byte    `json:"body"`
+}
+
+// newResponseCache opens a cache in dir, creating it if needed. Entries
+// younger than ttl are served without contacting the API; zero always
+// revalidates.
+func newResponseCache(dir string, ttl time.Duration) (*responseCache, error) {
+	if err := os.MkdirAll(dir, cacheDirPerm); err != nil {
+		return nil, fmt.Errorf("creating response cache directory: %w", err)
+	}
+	return &responseCache{dir: dir, ttl: ttl}, nil
+}
+
+// path is the entry file for req. The name digests the full URL and the
+// request's API token, so different reports and workspaces never share an
+// entry although their redacted URLs match, and neither token appears on
+// disk. A renewed token starts afresh.
+func (rc *responseCache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(bearerToken(req) + "\n" + req.URL.String()))
+	return filepath.Join(rc.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the stored entry for req, or nil when there is none or it
+// cannot be read.
+func (rc *responseCache) load(req *http.Request) *cacheEntry {
+	data, err := os.ReadFile(rc.path(req))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+// store writes entry for req, replacing any earlier one atomically.
+func (rc *responseCache) store(req *http.Request, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding cache entry: %w", err)
+	}
+	path := rc.path(req)
+	tmp := path + ".tmp"
+	if writeErr := os.WriteFile(tmp, data, cacheFilePerm); writeErr != nil {
+		return fmt.Errorf("writing cache entry: %w", writeErr)
+	}
+	if renameErr := os.Rename(tmp, path); renameErr != nil {
+		return fmt.Errorf("replacing cache entry: %w", renameErr)
+	}
+	return nil
+}
+
+// fresh reports whether entry can be served without revalidating.
+func (rc *responseCache) fresh(entry *cacheEntry) bool {
+	return rc.ttl > 0 && time.Since(entry.StoredAt) < rc.ttl
+}
+
+// revalidate adds the entry's validators to req.
+func (entry *cacheEntry) revalidate(req *http.Request) {
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+}
+
+// response rebuilds a 200 response for req from the entry.
+func (entry *cacheEntry) response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if entry.ContentType != "" {
+		header.Set("Content-Type", entry.ContentType)
+	}
+	if entry.ETag != "" {
+		header.Set("ETag", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		header.Set("Last-Modified", entry.LastModified)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+// do serves req through the cache when it is enabled, sending it with send
+// otherwise. A 304 is answered from the stored entry; a 200 with validators
+// replaces it. Failing to write the cache does not fail the request.
+func (c *httpClient) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return c.send(ctx, req)
+	}
+
+	entry := c.cache.load(req)
+	if entry != nil && c.cache.fresh(entry) {
+		c.cacheHits.Add(1)
+		c.logger.Debug(ctx, "Served response from cache", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "response_cache",
+			"attempt":   0,
+			"url":       entry.URL,
+		})
+		return entry.response(req), nil
+	}
+	if entry != nil {
+		entry.revalidate(req)
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		_ = resp.Body.Close()
+		c.cacheHits.Add(1)
+		c.logger.Debug(ctx, "Response not modified, served from cache", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "response_cache",
+			"attempt":   0,
+			"url":       entry.URL,
+		})
+		entry.StoredAt = time.Now().UTC()
+		c.storeCacheEntry(ctx, req, entry)
+		return entry.response(req), nil
+
+	case resp.StatusCode == http.StatusOK &&
+		(resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		body, readErr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if readErr != nil {
+			return nil, fmt.Errorf("reading response body: %w", readErr)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		c.storeCacheEntry(ctx, req, &cacheEntry{
+			URL:          RedactString(req.URL.String()),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ContentType:  resp.Header.Get("Content-Type"),
+			StoredAt:     time.Now().UTC(),
+			Body:         body,
+		})
+	}
+	return resp, nil
+}
+
+// storeCacheEntry writes entry, logging rather than returning failures.
+func (c *httpClient) storeCacheEntry(ctx context.Context, req *http.Request, entry *cacheEntry) {
+	if err := c.cache.store(req, entry); err != nil {
+		c.logger.Warn(ctx, "Failed to write response cache", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "response_cache",
+			"attempt":   0,
+			"error":     err.Error(),
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// etagServer serves one costs row per report with an ETag, answering 304
+// when the client already has it.
+func etagServer(t *testing.T, calls *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		etag := `"` + r.URL.Query().Get("cost_report_token") + `-v1"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CostsResponse{Data: []CostRow{
+			{Service: r.URL.Query().Get("cost_report_token"), Cost: 1},
+		}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newCachingClient(t *testing.T, baseURL, dir string, ttl time.Duration) Client {
+	t.Helper()
+	c, err := New(Config{
+		BaseURL:  baseURL,
+		Token:    "test-token",
+		Timeout:  5 * time.Second,
+		Logger:   NewNoopLogger(),
+		CacheDir: dir,
+		CacheTTL: ttl,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func cacheQuery(reportToken string) Query {
+	return Query{
+		CostReportToken: reportToken,
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Granularity:     "day",
+	}
+}
+
+func TestResponseCache_Revalidates(t *testing.T) {
+	calls := 0
+	server := etagServer(t, &calls)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	first, err := newCachingClient(t, server.URL, dir, 0).Costs(ctx, cacheQuery("cr_secret"))
+	require.NoError(t, err)
+
+	// A later run revalidates and is answered from disk.
+	c := newCachingClient(t, server.URL, dir, 0)
+	second, err := c.Costs(ctx, cacheQuery("cr_secret"))
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, RequestStats{Requests: 1, CacheHits: 1}, c.RequestStats())
+
+	// Entries carry neither the API token nor report tokens.
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.NotContains(t, filepath.Base(files[0]), "cr_secret")
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var entry cacheEntry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.NotContains(t, entry.URL, "cr_secret")
+	assert.NotContains(t, string(data), "test-token")
+}
+
+func TestResponseCache_FreshWithinTTL(t *testing.T) {
+	calls := 0
+	server := etagServer(t, &calls)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	_, err := newCachingClient(t, server.URL, dir, time.Hour).Costs(ctx, cacheQuery("cr_a"))
+	require.NoError(t, err)
+
+	c := newCachingClient(t, server.URL, dir, time.Hour)
+	page, err := c.Costs(ctx, cacheQuery("cr_a"))
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, 1, calls, "fresh entries are served without a request")
+	assert.Equal(t, RequestStats{CacheHits: 1}, c.RequestStats())
+}
+
+func TestResponseCache_KeysByFullURL(t *testing.T) {
+	calls := 0
+	server := etagServer(t, &calls)
+	c := newCachingClient(t, server.URL, t.TempDir(), time.Hour)
+	ctx := context.Background()
+
+	// Both URLs redact to the same string but must not share an entry.
+	a, err := c.Costs(ctx, cacheQuery("cr_a"))
+	require.NoError(t, err)
+	b, err := c.Costs(ctx, cacheQuery("cr_b"))
+	require.NoError(t, err)
+	assert.Equal(t, "cr_a", a.Data[0].Service)
+	assert.Equal(t, "cr_b", b.Data[0].Service)
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_PingBypasses(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	c := newCachingClient(t, server.URL, dir, time.Hour)
+
+	// Pings always reach the API.
+	require.NoError(t, c.Ping(context.Background()))
+	require.NoError(t, c.Ping(context.Background()))
+	assert.Equal(t, 2, calls)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestResponseCache_NoValidators(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CostsResponse{})
+	}))
+	defer server.Close()
+	c := newCachingClient(t, server.URL, t.TempDir(), time.Hour)
+
+	for range 2 {
+		_, err := c.Costs(context.Background(), cacheQuery("cr_a"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, calls, "responses without ETag or Last-Modified are not cached")
+}
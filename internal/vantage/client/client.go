@@ -4,12 +4,19 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 )
 
 const (
 	defaultTimeout = 60 * time.Second
 	defaultRetries = 5
+
+	// DefaultRateLimitRemainingThreshold is the remaining-quota level below
+	// which requests are proactively slowed down.
+	DefaultRateLimitRemainingThreshold = 5
 )
 
 // Client defines the interface for interacting with Vantage API.
@@ -18,15 +25,151 @@ type Client interface {
 	Costs(ctx context.Context, query Query) (Page, error)
 	// Forecast fetches forecast data for a cost report.
 	Forecast(ctx context.Context, reportToken string, query ForecastQuery) (Forecast, error)
+	// Ping performs a cheap authenticated request to verify API reachability.
+	Ping(ctx context.Context) error
+
+	// ListCostReports lists cost reports, optionally scoped to a workspace.
+	ListCostReports(ctx context.Context, workspaceToken string) ([]CostReport, error)
+	// GetCostReport fetches a single cost report by token.
+	GetCostReport(ctx context.Context, token string) (CostReport, error)
+	// CreateCostReport provisions a new cost report.
+	CreateCostReport(ctx context.Context, req CreateCostReportRequest) (CostReport, error)
+	// DeleteCostReport deletes a cost report by token.
+	DeleteCostReport(ctx context.Context, token string) error
+
+	// ListWorkspaces lists the workspaces visible to the API token.
+	ListWorkspaces(ctx context.Context) ([]Workspace, error)
+
+	// Budgets lists budgets with their periods and performance, optionally
+	// scoped to a workspace.
+	Budgets(ctx context.Context, workspaceToken string) ([]Budget, error)
+
+	// Recommendations lists reserved instance, savings plan, and rightsizing
+	// recommendations matching the query.
+	Recommendations(ctx context.Context, query RecommendationQuery) ([]Recommendation, error)
+
+	// ListTags lists the tag keys available in a workspace.
+	ListTags(ctx context.Context, workspaceToken string) ([]Tag, error)
+	// ListTagValues lists the values seen for a tag key.
+	ListTagValues(ctx context.Context, workspaceToken, key string) ([]TagValue, error)
+
+	// ListIntegrations lists the provider accounts connected to Vantage.
+	ListIntegrations(ctx context.Context, workspaceToken string) ([]Integration, error)
+
+	// ServerStatus reports rate-limit quota and server time from the most
+	// recent API response.
+	ServerStatus() ServerStatus
+	// RequestStats counts the HTTP requests sent so far.
+	RequestStats() RequestStats
+}
+
+// RequestStats counts the HTTP requests a client has sent over its lifetime.
+type RequestStats struct {
+	// Requests is every request sent, retries included.
+	Requests int64 `json:"requests"`
+	// Retries is how many of those requests repeated a failed one.
+	Retries int64 `json:"retries"`
+	// CacheHits is how many responses came from the response cache, either
+	// fresh or confirmed unchanged by a 304.
+	CacheHits int64 `json:"cache_hits"`
+}
+
+// ServerStatus is what the client learned from the most recent response
+// headers. Quota values are -1 until a response carrying them is seen, and
+// ServerTime is zero until a response with a Date header is seen.
+type ServerStatus struct {
+	RateLimitRemaining int
+	RateLimitLimit     int
+	ServerTime         time.Time
+	ObservedAt         time.Time
+}
+
+// ClockSkew returns how far the local clock was ahead of the server's at
+// ObservedAt, or zero when no server time is known.
+func (s ServerStatus) ClockSkew() time.Duration {
+	if s.ServerTime.IsZero() {
+		return 0
+	}
+	return s.ObservedAt.Sub(s.ServerTime)
 }
 
 // Config holds client configuration.
 type Config struct {
-	BaseURL    string
-	Token      string
-	Timeout    time.Duration
-	MaxRetries int
-	Logger     Logger
+	BaseURL string
+	Token   string
+	// TokenProvider, when set, supplies the bearer token of each request
+	// instead of Token, renewing it when it expires or is rejected with a
+	// 401.
+	TokenProvider TokenProvider
+	// Timeout bounds each HTTP attempt; OperationTimeout bounds a whole
+	// call, retries and backoff included (zero means no bound).
+	Timeout          time.Duration
+	OperationTimeout time.Duration
+	MaxRetries       int
+	Logger           Logger
+
+	// RetryBudget caps the retries made across all requests through the
+	// client, so a long sync stops once the API keeps failing instead of
+	// spending MaxRetries on every request. Zero means no cap.
+	RetryBudget int
+
+	// RequestsPerSecond and Burst configure a token-bucket limiter shared by
+	// all requests made through the client. Zero disables limiting.
+	RequestsPerSecond float64
+	Burst             int
+
+	// RateLimiter, when set, is used instead of building a limiter from
+	// RequestsPerSecond/Burst, allowing several clients to share one budget.
+	RateLimiter *RateLimiter
+
+	// RateLimitRemainingThreshold pauses requests once X-RateLimit-Remaining
+	// drops below this value. Zero disables proactive slowdown.
+	RateLimitRemainingThreshold int
+
+	// CacheDir, when set, keeps GET responses that carry an ETag or
+	// Last-Modified header on disk and revalidates them on later requests.
+	// Responses younger than CacheTTL are served without a request.
+	CacheDir string
+	CacheTTL time.Duration
+
+	// MaxIdleConnsPerHost and DisableCompression tune the transport built
+	// by NewTransport. Zero MaxIdleConnsPerHost uses
+	// DefaultMaxIdleConnsPerHost.
+	MaxIdleConnsPerHost int
+	DisableCompression  bool
+
+	// ProxyURL, when set, sends requests through this proxy instead of the
+	// one HTTPS_PROXY names. CABundle is a PEM file of certificate
+	// authorities trusted besides the system's, and ClientCert and
+	// ClientKey are PEM files of a client certificate for mutual TLS. All
+	// are used by NewTransport.
+	ProxyURL   string
+	CABundle   string
+	ClientCert string
+	ClientKey  string
+
+	// Transport, when set, replaces the transport built by NewTransport,
+	// e.g. with NewRecordingTransport or NewReplayTransport.
+	Transport http.RoundTripper
+
+	// APIVersion selects the wire format of the costs and forecast
+	// endpoints (see SupportedAPIVersions). Empty means APIVersionV1.
+	APIVersion string
+
+	// Kind selects the client implementation (see SupportedClientKinds).
+	// Empty means ClientKindHandwritten.
+	Kind string
+
+	// Observer, when set, is told about every API call, e.g. a
+	// RequestMetrics collecting per-endpoint latency.
+	Observer RequestObserver
+
+	// UserAgent is sent with every request; empty means UserAgent("", "").
+	UserAgent string
+	// Headers are extra headers sent with every request, such as those an
+	// API gateway in front of Vantage requires. They cannot replace the
+	// headers the client sets itself (see ValidateHeaders).
+	Headers map[string]string
 }
 
 // DefaultConfig returns a default client configuration.
@@ -37,6 +180,8 @@ func DefaultConfig(token string) Config {
 		Timeout:    defaultTimeout,
 		MaxRetries: defaultRetries,
 		Logger:     NewNoopLogger(),
+
+		RateLimitRemainingThreshold: DefaultRateLimitRemainingThreshold,
 	}
 }
 
@@ -48,30 +193,104 @@ type client struct {
 
 // New creates a new Vantage API client.
 func New(config Config) (Client, error) {
-	if config.Token == "" {
-		return nil, errors.New("token is required")
+	if isMockURL(config.BaseURL) && config.Transport == nil {
+		mock, err := ParseMockURL(config.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		config.Transport = NewMockTransport(mock)
+		// The generator settings are not part of request URLs.
+		config.BaseURL = MockScheme + "://vantage"
+	}
+	if config.TokenProvider == nil && config.Token == "" && isMockURL(config.BaseURL) {
+		// The mock transport needs no credentials; the placeholder is
+		// not set as Token so logs are not redacted for it.
+		config.TokenProvider = NewStaticTokenProvider("mock")
+	}
+	if config.TokenProvider == nil {
+		if config.Token == "" {
+			return nil, errors.New("token is required")
+		}
+		config.TokenProvider = NewStaticTokenProvider(config.Token)
 	}
 
 	// Defensive defaults in case callers don't use DefaultConfig
 	if config.Logger == nil {
 		config.Logger = NewNoopLogger()
 	}
+	// Every message is redacted, including the token wherever it appears.
+	// Tokens from a provider are added as requests obtain them.
+	config.Logger = NewRedactingLogger(config.Logger, config.Token)
 	if config.Timeout <= 0 {
 		config.Timeout = defaultTimeout
 	}
 	if config.MaxRetries < 0 {
 		config.MaxRetries = defaultRetries
 	}
+	if config.RetryBudget < 0 {
+		config.RetryBudget = 0
+	}
+	if config.OperationTimeout < 0 {
+		config.OperationTimeout = 0
+	}
 	if config.BaseURL == "" {
 		config.BaseURL = "https://api.vantage.sh"
 	}
+	if config.UserAgent == "" {
+		config.UserAgent = UserAgent("", "")
+	}
+	if err := ValidateHeaders(config.Headers); err != nil {
+		return nil, err
+	}
+	if config.RateLimiter == nil {
+		config.RateLimiter = NewRateLimiter(config.RequestsPerSecond, config.Burst)
+	}
+	if config.Transport == nil {
+		transport, err := NewTransport(config)
+		if err != nil {
+			return nil, err
+		}
+		config.Transport = transport
+	}
 
 	httpClient := newHTTPClient(config)
+	switch config.APIVersion {
+	case "", APIVersionV1:
+		httpClient.api = v1API{}
+	case APIVersionV2:
+		httpClient.api = v2API{}
+	case APIVersionAuto:
+		// Negotiated on the first costs or forecast request.
+	default:
+		return nil, fmt.Errorf("invalid API version: %s (valid: %s)",
+			config.APIVersion, strings.Join(SupportedAPIVersions(), ", "))
+	}
+	if config.CacheDir != "" {
+		cache, err := newResponseCache(config.CacheDir, config.CacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.cache = cache
+	}
 
-	return &client{
+	c := &client{
 		httpClient: httpClient,
 		logger:     config.Logger,
-	}, nil
+	}
+	switch config.Kind {
+	case "", ClientKindHandwritten:
+		return c, nil
+	case ClientKindOpenAPI:
+		// The generated client is built from the v2 spec only.
+		if config.APIVersion != "" && config.APIVersion != APIVersionV2 {
+			return nil, fmt.Errorf("the %s client supports only API version %s, not %s",
+				ClientKindOpenAPI, APIVersionV2, config.APIVersion)
+		}
+		return newOpenAPIClient(c)
+	default:
+		return nil, fmt.Errorf("invalid client kind: %s (valid: %s)",
+			config.Kind, strings.Join(SupportedClientKinds(), ", "))
+	}
 }
 
 // Costs implements Client.Costs.
@@ -83,3 +302,28 @@ func (c *client) Costs(ctx context.Context, query Query) (Page, error) {
 func (c *client) Forecast(ctx context.Context, reportToken string, query ForecastQuery) (Forecast, error) {
 	return c.httpClient.doForecastRequest(ctx, reportToken, query)
 }
+
+// Ping implements Client.Ping.
+func (c *client) Ping(ctx context.Context) error {
+	// Pings always reach the API, since they check connectivity.
+	return c.httpClient.doRequest(ctx, apiRequest{
+		operation: "ping_request",
+		method:    http.MethodGet,
+		path:      "/ping",
+		noCache:   true,
+	})
+}
+
+// ServerStatus implements Client.ServerStatus.
+func (c *client) ServerStatus() ServerStatus {
+	return c.httpClient.quota.status()
+}
+
+// RequestStats implements Client.RequestStats.
+func (c *client) RequestStats() RequestStats {
+	return RequestStats{
+		Requests:  c.httpClient.requests.Load(),
+		Retries:   c.httpClient.retries.Load(),
+		CacheHits: c.httpClient.cacheHits.Load(),
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -118,6 +120,66 @@ func TestClient_Costs(t *testing.T) {
 	assert.True(t, page.HasMore)
 }
 
+func TestClient_CostsFilter(t *testing.T) {
+	var filters []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, sent := r.URL.Query()["filter"]
+		if sent {
+			filters = append(filters, r.URL.Query().Get("filter"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CostsResponse{})
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, Token: "test-token", Logger: NewNoopLogger()})
+	require.NoError(t, err)
+
+	query := Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Granularity:     "day",
+	}
+	_, err = c.Costs(context.Background(), query)
+	require.NoError(t, err)
+	assert.Empty(t, filters, "no filter parameter without a filter")
+
+	query.Filter = "(costs.provider = 'aws' AND costs.service = 'EC2')"
+	_, err = c.Costs(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []string{query.Filter}, filters)
+}
+
+func TestClient_CostsIncludeUnallocated(t *testing.T) {
+	var settings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings = append(settings, r.URL.Query().Get("settings[unallocated]"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": [{"service": "EC2", "cost": 4.5, "unallocated": true}]}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, Token: "test-token", Logger: NewNoopLogger()})
+	require.NoError(t, err)
+
+	query := Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Granularity:     "day",
+	}
+	_, err = c.Costs(context.Background(), query)
+	require.NoError(t, err)
+
+	query.IncludeUnallocated = true
+	page, err := c.Costs(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "true"}, settings)
+	require.Len(t, page.Data, 1)
+	assert.True(t, page.Data[0].Unallocated)
+}
+
 func TestClient_Forecast(t *testing.T) {
 	// Mock server response.
 	mockResponse := ForecastResponse{
@@ -165,6 +227,43 @@ func TestClient_Forecast(t *testing.T) {
 	assert.Equal(t, "USD", forecast.Data[0].Currency)
 }
 
+func TestClient_ForecastGrouped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"provider", "service"}, r.URL.Query()["group_bys[]"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": [
+			{"bucket_start": "2024-02-01T00:00:00Z", "bucket_end": "2024-02-02T00:00:00Z",
+			 "cost": 100, "currency": "USD", "provider": "aws", "service": "Amazon EC2"},
+			{"bucket_start": "2024-02-01T00:00:00Z", "bucket_end": "2024-02-02T00:00:00Z",
+			 "cost": 25, "currency": "USD", "provider": "gcp", "service": "Compute Engine"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		Timeout:    time.Second * 5,
+		MaxRetries: 0,
+		Logger:     NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	forecast, err := client.Forecast(context.Background(), "test-report-token", ForecastQuery{
+		StartAt:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:       time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC),
+		Granularity: "day",
+		GroupBys:    []string{"provider", "service"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, forecast.Data, 2)
+	assert.Equal(t, "aws", forecast.Data[0].Provider)
+	assert.Equal(t, "Amazon EC2", forecast.Data[0].Service)
+	assert.Equal(t, "Compute Engine", forecast.Data[1].Service)
+}
+
 func TestClient_RetryOn5xx(t *testing.T) {
 	callCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -199,6 +298,82 @@ func TestClient_RetryOn5xx(t *testing.T) {
 	_, err = client.Costs(context.Background(), query)
 	require.NoError(t, err)
 	assert.Equal(t, 2, callCount) // Should have retried once
+	assert.Equal(t, RequestStats{Requests: 2, Retries: 1}, client.RequestStats())
+}
+
+func TestClient_RetryBudget(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		callCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		BaseURL:     server.URL,
+		Token:       "test-token",
+		Timeout:     time.Second * 5,
+		MaxRetries:  5,
+		RetryBudget: 1,
+		Logger:      NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	query := Query{
+		WorkspaceToken: "test-workspace",
+		StartAt:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:          time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Granularity:    "day",
+	}
+
+	// The first request spends the whole budget on one retry.
+	_, err = client.Costs(context.Background(), query)
+	require.ErrorIs(t, err, ErrRetryBudgetExhausted)
+	assert.True(t, IsTransientError(err))
+	assert.Equal(t, 2, callCount)
+
+	// Later requests are not retried at all.
+	_, err = client.Costs(context.Background(), query)
+	require.ErrorIs(t, err, ErrRetryBudgetExhausted)
+	assert.Equal(t, 3, callCount)
+	assert.Equal(t, RequestStats{Requests: 3, Retries: 1}, client.RequestStats())
+}
+
+func TestClient_OperationTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		BaseURL:          server.URL,
+		Token:            "test-token",
+		Timeout:          5 * time.Second,
+		OperationTimeout: 200 * time.Millisecond,
+		MaxRetries:       5,
+		Logger:           NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	// The first backoff outlasts the operation deadline, so the call stops
+	// there instead of running through every retry.
+	start := time.Now()
+	_, err = client.Costs(context.Background(), Query{
+		WorkspaceToken: "test-workspace",
+		StartAt:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:          time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Granularity:    "day",
+	})
+	require.ErrorIs(t, err, ErrOperationTimeout)
+	assert.True(t, IsTransientError(err))
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// A caller's own deadline is not reported as the operation timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = client.Ping(ctx)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrOperationTimeout)
 }
 
 func TestClient_RateLimitHandling(t *testing.T) {
@@ -230,7 +405,7 @@ func TestClient_RateLimitHandling(t *testing.T) {
 	assert.Contains(t, err.Error(), "rate limited")
 }
 
-func TestPager_NextPage(t *testing.T) {
+func TestPager_Pages(t *testing.T) {
 	// First page response.
 	firstResponse := CostsResponse{
 		Data: []CostRow{
@@ -278,17 +453,22 @@ func TestPager_NextPage(t *testing.T) {
 		Granularity:    "day",
 	}, NewNoopLogger())
 
+	var pages []Page
+	for page, err := range pager.Pages(context.Background()) {
+		require.NoError(t, err)
+		pages = append(pages, page)
+	}
+	require.Len(t, pages, 2)
+
 	// First page.
-	page1, err := pager.NextPage(context.Background())
-	require.NoError(t, err)
+	page1 := pages[0]
 	assert.Len(t, page1.Data, 1)
 	assert.Equal(t, "aws", page1.Data[0].Provider)
 	assert.Equal(t, "cursor-2", page1.NextCursor)
 	assert.True(t, page1.HasMore)
 
 	// Second page.
-	page2, err := pager.NextPage(context.Background())
-	require.NoError(t, err)
+	page2 := pages[1]
 	assert.Len(t, page2.Data, 1)
 	assert.Equal(t, "gcp", page2.Data[0].Provider)
 	assert.Empty(t, page2.NextCursor)
@@ -307,25 +487,6 @@ func TestDefaultConfig(t *testing.T) {
 	assert.NotNil(t, config.Logger)
 }
 
-func TestPager_HasMore(t *testing.T) {
-	client, err := New(Config{
-		BaseURL:    "https://api.vantage.sh",
-		Token:      "test-token",
-		Timeout:    time.Second * 5,
-		MaxRetries: 0,
-		Logger:     NewNoopLogger(),
-	})
-	require.NoError(t, err)
-
-	// Test with empty cursor (should return false initially).
-	pager := NewPager(client, Query{}, NewNoopLogger())
-	assert.False(t, pager.HasMore())
-
-	// Test with cursor (should return true).
-	pager.query.Cursor = "test-cursor"
-	assert.True(t, pager.HasMore())
-}
-
 func TestPager_AllPages(t *testing.T) {
 	// Mock server response with multiple pages.
 	callCount := 0
@@ -544,25 +705,6 @@ func TestClient_ForecastContextCancellation(t *testing.T) {
 	assert.Contains(t, err.Error(), "context canceled")
 }
 
-func TestRedactURL(t *testing.T) {
-	// Create a test HTTP client directly.
-	httpClient := &httpClient{
-		token:  "secret-token",
-		logger: NewNoopLogger(),
-	}
-
-	// Test URL without sensitive data.
-	originalURL := "https://api.vantage.sh/costs?param=value"
-	redacted := httpClient.redactURL(originalURL)
-	assert.Equal(t, originalURL, redacted)
-
-	// Test URL with Authorization parameter.
-	urlWithAuth := "https://api.vantage.sh/costs?Authorization=Bearer%20secret-token&param=value"
-	redacted = httpClient.redactURL(urlWithAuth)
-	expected := "https://api.vantage.sh/costs?Authorization=Bearer%20****&param=value"
-	assert.Equal(t, expected, redacted)
-}
-
 func TestClient_ContextCancellationDuringRetry(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		// Always return 503 to trigger retries.
@@ -1015,3 +1157,326 @@ func ExampleClient_Forecast() {
 		fmt.Printf("Forecast cost: %.2f at %s\n", row.Cost, row.BucketStart.Format("2006-01-02"))
 	}
 }
+
+func TestRateLimiter_NilDisablesLimiting(t *testing.T) {
+	limiter := NewRateLimiter(0, 10)
+	assert.Nil(t, limiter)
+	require.NoError(t, limiter.Wait(context.Background()))
+}
+
+func TestRateLimiter_BurstThenThrottle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+	limiter.lastFill = now
+
+	// Burst tokens are available immediately.
+	assert.Zero(t, limiter.reserve())
+	assert.Zero(t, limiter.reserve())
+
+	// Bucket is empty: next token arrives after 1/rate seconds.
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve())
+
+	// Advancing time refills the bucket.
+	now = now.Add(500 * time.Millisecond)
+	assert.Zero(t, limiter.reserve())
+}
+
+func TestRateLimiter_WaitHonorsContext(t *testing.T) {
+	limiter := NewRateLimiter(0.001, 1)
+	require.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestClient_SharedRateLimiter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.URL.Path, "forecast") {
+			_ = json.NewEncoder(w).Encode(ForecastResponse{})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(CostsResponse{})
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		BaseURL:           server.URL,
+		Token:             "test-token",
+		Timeout:           5 * time.Second,
+		Logger:            NewNoopLogger(),
+		RequestsPerSecond: 20,
+		Burst:             1,
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.Costs(context.Background(), Query{Granularity: "day"})
+	require.NoError(t, err)
+	_, err = client.Forecast(context.Background(), "cr_test", ForecastQuery{Granularity: "day"})
+	require.NoError(t, err)
+	_, err = client.Costs(context.Background(), Query{Granularity: "day"})
+	require.NoError(t, err)
+
+	// Burst of 1 at 20 req/s: the second and third calls each wait ~50ms,
+	// regardless of which endpoint they target.
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestQuotaTracker_ObserveSchedulesPause(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newQuotaTracker(5)
+	tracker.now = func() time.Time { return now }
+
+	healthy := &http.Response{Header: http.Header{}}
+	healthy.Header.Set("X-RateLimit-Remaining", "100")
+	healthy.Header.Set("X-RateLimit-Limit", "1000")
+	assert.Zero(t, tracker.observe(healthy))
+
+	low := &http.Response{Header: http.Header{}}
+	low.Header.Set("X-RateLimit-Remaining", "3")
+	low.Header.Set("X-RateLimit-Reset", "8")
+	// 8 seconds spread across the remaining 3 requests (+1).
+	assert.Equal(t, 2*time.Second, tracker.observe(low))
+	assert.Equal(t, 3, tracker.remaining)
+	assert.Equal(t, 1000, tracker.limit)
+	assert.Equal(t, now.Add(2*time.Second), tracker.pauseUntil)
+
+	noReset := &http.Response{Header: http.Header{}}
+	noReset.Header.Set("X-RateLimit-Remaining", "0")
+	assert.Equal(t, defaultQuotaPause, tracker.observe(noReset))
+}
+
+func TestQuotaTracker_DisabledAndMissingHeaders(t *testing.T) {
+	disabled := newQuotaTracker(0)
+	low := &http.Response{Header: http.Header{}}
+	low.Header.Set("X-RateLimit-Remaining", "0")
+	assert.Zero(t, disabled.observe(low))
+
+	tracker := newQuotaTracker(5)
+	assert.Zero(t, tracker.observe(&http.Response{Header: http.Header{}}))
+
+	delay, err := tracker.wait(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, delay)
+}
+
+func TestQuotaTracker_StatusAndClockSkew(t *testing.T) {
+	local := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	tracker := newQuotaTracker(5)
+	tracker.now = func() time.Time { return local }
+
+	status := tracker.status()
+	assert.Equal(t, -1, status.RateLimitRemaining)
+	assert.Zero(t, status.ClockSkew())
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Date", "Mon, 01 Jan 2024 12:00:00 GMT")
+	resp.Header.Set("X-RateLimit-Remaining", "40")
+	resp.Header.Set("X-RateLimit-Limit", "50")
+	tracker.observe(resp)
+
+	status = tracker.status()
+	assert.Equal(t, 40, status.RateLimitRemaining)
+	assert.Equal(t, 50, status.RateLimitLimit)
+	assert.Equal(t, 30*time.Second, status.ClockSkew())
+}
+
+func TestClient_SlowsDownWhenQuotaLow(t *testing.T) {
+	var requestTimes []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requestTimes = append(requestTimes, time.Now())
+		w.Header().Set("X-RateLimit-Remaining", "1")
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CostsResponse{})
+	}))
+	defer server.Close()
+
+	client, err := New(Config{
+		BaseURL:                     server.URL,
+		Token:                       "test-token",
+		Timeout:                     5 * time.Second,
+		Logger:                      NewNoopLogger(),
+		RateLimitRemainingThreshold: 2,
+	})
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = client.Costs(context.Background(), Query{Granularity: "day"})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, requestTimes, 2)
+	assert.GreaterOrEqual(t, requestTimes[1].Sub(requestTimes[0]), defaultQuotaPause)
+}
+
+// cursorClient serves pages keyed by the query cursor. Unimplemented Client
+// methods panic through the nil embedded interface.
+type cursorClient struct {
+	Client
+	pages map[string]Page
+	calls atomic.Int32
+	// fetched receives the cursor of each Costs call when set.
+	fetched chan string
+}
+
+func (c *cursorClient) Costs(ctx context.Context, query Query) (Page, error) {
+	c.calls.Add(1)
+	if c.fetched != nil {
+		c.fetched <- query.Cursor
+	}
+	if err := ctx.Err(); err != nil {
+		return Page{}, err
+	}
+	page, ok := c.pages[query.Cursor]
+	if !ok {
+		return Page{}, fmt.Errorf("unknown cursor %q", query.Cursor)
+	}
+	return page, nil
+}
+
+func threePages() map[string]Page {
+	return map[string]Page{
+		"":   {Data: []CostRow{{Cost: 1}}, NextCursor: "c2", HasMore: true},
+		"c2": {Data: []CostRow{{Cost: 2}}, NextCursor: "c3", HasMore: true},
+		"c3": {Data: []CostRow{{Cost: 3}}},
+	}
+}
+
+func TestPager_PagesPrefetch(t *testing.T) {
+	c := &cursorClient{pages: threePages(), fetched: make(chan string, 3)}
+	pager := NewPager(c, Query{}, NewNoopLogger(), WithPrefetch(true))
+
+	var costs []float64
+	for page, err := range pager.Pages(context.Background()) {
+		require.NoError(t, err)
+		if len(costs) == 0 {
+			assert.Empty(t, <-c.fetched)
+		}
+		// The next page is requested before the current one is handled.
+		if page.HasMore {
+			assert.Equal(t, page.NextCursor, <-c.fetched)
+		}
+		costs = append(costs, page.Data[0].Cost)
+	}
+	assert.Equal(t, []float64{1, 2, 3}, costs)
+	assert.Equal(t, int32(3), c.calls.Load())
+}
+
+func TestPager_PagesEarlyBreak(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prefetch=%v", prefetch), func(t *testing.T) {
+			c := &cursorClient{pages: threePages()}
+			pager := NewPager(c, Query{}, NewNoopLogger(), WithPrefetch(prefetch))
+
+			for page, err := range pager.Pages(context.Background()) {
+				require.NoError(t, err)
+				assert.Equal(t, 1.0, page.Data[0].Cost)
+				break
+			}
+
+			// At most the page fetched ahead was requested, and it finished
+			// before Pages returned.
+			assert.LessOrEqual(t, c.calls.Load(), int32(2))
+
+			// Each iteration starts again from the query's cursor.
+			rows, err := pager.AllPages(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, rows, 3)
+		})
+	}
+}
+
+func TestPager_PagesMissingCursor(t *testing.T) {
+	c := &cursorClient{pages: map[string]Page{
+		"": {Data: []CostRow{{Cost: 1}}, HasMore: true},
+	}}
+	pager := NewPager(c, Query{}, NewNoopLogger())
+
+	_, err := pager.AllPages(context.Background())
+	require.ErrorIs(t, err, errMissingCursor)
+	assert.Equal(t, int32(1), c.calls.Load())
+}
+
+func TestPager_PagesError(t *testing.T) {
+	pages := threePages()
+	delete(pages, "c2")
+	c := &cursorClient{pages: pages}
+	pager := NewPager(c, Query{}, NewNoopLogger(), WithPrefetch(true))
+
+	var seen int
+	var lastErr error
+	for _, err := range pager.Pages(context.Background()) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		seen++
+	}
+	assert.Equal(t, 1, seen)
+	require.Error(t, lastErr)
+	assert.Contains(t, lastErr.Error(), "fetching costs page")
+}
+
+// failingClient fails the first failures Costs calls for each cursor.
+type failingClient struct {
+	cursorClient
+	failures int
+	failed   map[string]int
+}
+
+func (c *failingClient) Costs(ctx context.Context, query Query) (Page, error) {
+	if c.failed[query.Cursor] < c.failures {
+		c.failed[query.Cursor]++
+		c.calls.Add(1)
+		return Page{}, fmt.Errorf("connection reset")
+	}
+	return c.cursorClient.Costs(ctx, query)
+}
+
+func TestPager_PageRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  int
+		failures int
+		wantErr  bool
+		attempts []int
+	}{
+		{name: "no failures", retries: 2, attempts: []int{1, 1, 1}},
+		{name: "retried pages", retries: 2, failures: 2, attempts: []int{3, 3, 3}},
+		{name: "retries exhausted", retries: 1, failures: 2, wantErr: true},
+		{name: "retries disabled", failures: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &failingClient{
+				cursorClient: cursorClient{pages: threePages()},
+				failures:     tt.failures,
+				failed:       make(map[string]int),
+			}
+			pager := NewPager(c, Query{}, NewNoopLogger(), WithPageRetries(tt.retries))
+			pager.retryDelay = 0
+
+			var attempts []int
+			for page, err := range pager.Pages(context.Background()) {
+				if tt.wantErr {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), "connection reset")
+					assert.Equal(t, int32(min(tt.failures, tt.retries+1)), c.calls.Load())
+					return
+				}
+				require.NoError(t, err)
+				attempts = append(attempts, page.Attempts)
+			}
+			assert.False(t, tt.wantErr, "expected an error")
+			assert.Equal(t, tt.attempts, attempts)
+		})
+	}
+}
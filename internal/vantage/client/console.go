@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// consoleLogger writes one human-readable line per message:
+//
+//	15:04:05.000 INF Fetched cost data adapter=vantage operation=fetch_cost_data attempt=0 pages=2
+type consoleLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// NewConsoleLogger returns a Logger writing readable lines to w, for
+// interactive use, dropping messages below level.
+func NewConsoleLogger(w io.Writer, level Level) Logger {
+	return NewLevelFilter(&consoleLogger{w: w, now: time.Now}, level)
+}
+
+func (c *consoleLogger) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.write("DBG", msg, withCorrelationField(ctx, fields))
+}
+
+func (c *consoleLogger) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.write("INF", msg, withCorrelationField(ctx, fields))
+}
+
+func (c *consoleLogger) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.write("WRN", msg, withCorrelationField(ctx, fields))
+}
+
+func (c *consoleLogger) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	c.write("ERR", msg, withCorrelationField(ctx, fields))
+}
+
+func (c *consoleLogger) write(level, msg string, fields map[string]interface{}) {
+	var line strings.Builder
+	line.WriteString(c.now().Format("15:04:05.000"))
+	line.WriteByte(' ')
+	line.WriteString(level)
+	line.WriteByte(' ')
+	line.WriteString(msg)
+	for _, key := range orderedKeys(fields) {
+		line.WriteByte(' ')
+		line.WriteString(key)
+		line.WriteByte('=')
+		line.WriteString(consoleValue(fields[key]))
+	}
+	line.WriteByte('\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = io.WriteString(c.w, line.String())
+}
+
+// consoleValue formats a field value, quoting it when it would not read as
+// a single token.
+func consoleValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
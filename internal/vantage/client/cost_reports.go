@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ListCostReports implements Client.ListCostReports.
+func (c *client) ListCostReports(ctx context.Context, workspaceToken string) ([]CostReport, error) {
+	params := url.Values{}
+	if workspaceToken != "" {
+		params.Set("workspace_token", workspaceToken)
+	}
+
+	return listAll(ctx, c.httpClient, "list_cost_reports", "/cost_reports", params,
+		func(resp *costReportsResponse) ([]CostReport, string) {
+			return resp.CostReports, resp.Links.Next
+		})
+}
+
+// GetCostReport implements Client.GetCostReport.
+func (c *client) GetCostReport(ctx context.Context, token string) (CostReport, error) {
+	if token == "" {
+		return CostReport{}, errors.New("cost report token is required")
+	}
+
+	var report CostReport
+	err := c.httpClient.doGet(ctx, "get_cost_report", "/cost_reports/"+url.PathEscape(token), nil, &report)
+	return report, err
+}
+
+// CreateCostReport implements Client.CreateCostReport.
+func (c *client) CreateCostReport(ctx context.Context, req CreateCostReportRequest) (CostReport, error) {
+	if req.Title == "" {
+		return CostReport{}, errors.New("cost report title is required")
+	}
+
+	var report CostReport
+	err := c.httpClient.doRequest(ctx, apiRequest{
+		operation: "create_cost_report",
+		method:    http.MethodPost,
+		path:      "/cost_reports",
+		body:      req,
+		out:       &report,
+	})
+	return report, err
+}
+
+// DeleteCostReport implements Client.DeleteCostReport.
+func (c *client) DeleteCostReport(ctx context.Context, token string) error {
+	if token == "" {
+		return errors.New("cost report token is required")
+	}
+
+	return c.httpClient.doRequest(ctx, apiRequest{
+		operation: "delete_cost_report",
+		method:    http.MethodDelete,
+		path:      "/cost_reports/" + url.PathEscape(token),
+	})
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, baseURL string, maxRetries int) Client {
+	t.Helper()
+
+	c, err := New(Config{
+		BaseURL:    baseURL,
+		Token:      "test-token",
+		Timeout:    time.Second * 5,
+		MaxRetries: maxRetries,
+		Logger:     NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_ListCostReports_FollowsPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/cost_reports", r.URL.Path)
+		assert.Equal(t, "wrkspc_1", r.URL.Query().Get("workspace_token"))
+
+		resp := costReportsResponse{}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			resp.CostReports = []CostReport{{Token: "rprt_1", Title: "First"}}
+			resp.Links.Next = "https://api.vantage.sh/v2/cost_reports?page=2"
+		case "2":
+			resp.CostReports = []CostReport{{Token: "rprt_2", Title: "Second", SavedFilterTokens: []string{"svd_1"}}}
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	reports, err := newTestClient(t, server.URL, 0).ListCostReports(context.Background(), "wrkspc_1")
+	require.NoError(t, err)
+
+	require.Len(t, reports, 2)
+	assert.Equal(t, "rprt_1", reports[0].Token)
+	assert.Equal(t, []string{"svd_1"}, reports[1].SavedFilterTokens)
+}
+
+func TestClient_GetCostReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/cost_reports/rprt_1", r.URL.Path)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+		_ = json.NewEncoder(w).Encode(CostReport{
+			Token:  "rprt_1",
+			Title:  "Production",
+			Filter: "costs.provider = 'aws'",
+		})
+	}))
+	defer server.Close()
+
+	report, err := newTestClient(t, server.URL, 0).GetCostReport(context.Background(), "rprt_1")
+	require.NoError(t, err)
+	assert.Equal(t, "Production", report.Title)
+	assert.Equal(t, "costs.provider = 'aws'", report.Filter)
+}
+
+func TestClient_CreateCostReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/cost_reports", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req CreateCostReportRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "PulumiCost", req.Title)
+		assert.Equal(t, []string{"svd_1"}, req.SavedFilterTokens)
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(CostReport{Token: "rprt_new", Title: req.Title})
+	}))
+	defer server.Close()
+
+	report, err := newTestClient(t, server.URL, 0).CreateCostReport(context.Background(), CreateCostReportRequest{
+		Title:             "PulumiCost",
+		WorkspaceToken:    "wrkspc_1",
+		SavedFilterTokens: []string{"svd_1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rprt_new", report.Token)
+}
+
+func TestClient_CreateCostReport_NotRetriedOnServerError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server.URL, 3).CreateCostReport(context.Background(), CreateCostReportRequest{
+		Title: "PulumiCost",
+	})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_DeleteCostReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/cost_reports/rprt_1", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := newTestClient(t, server.URL, 0).DeleteCostReport(context.Background(), "rprt_1")
+	require.NoError(t, err)
+}
+
+func TestClient_CostReportValidation(t *testing.T) {
+	c := newTestClient(t, "http://127.0.0.1:0", 0)
+
+	_, err := c.GetCostReport(context.Background(), "")
+	require.Error(t, err)
+
+	_, err = c.CreateCostReport(context.Background(), CreateCostReportRequest{})
+	require.Error(t, err)
+
+	require.Error(t, c.DeleteCostReport(context.Background(), ""))
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrRetryBudgetExhausted is returned when a request fails after the
+// client's retry budget has been spent by earlier retries.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// ErrOperationTimeout is returned when a call, retries included, runs past
+// Config.OperationTimeout.
+var ErrOperationTimeout = errors.New("operation timed out")
+
+// APIError is a non-success HTTP response from the Vantage API.
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RequestID is Vantage's ID for the failed request, from the response's
+	// X-Request-Id header, for support tickets to reference.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("API request failed with status %d (request ID %s): %s", e.StatusCode, e.RequestID, e.Body)
+	}
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsAuthError reports whether err came from a 401 or 403 response.
+func IsAuthError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsTransientError reports whether err is likely to clear if the request is
+// tried again later: rate limiting, a 5xx response, a timeout, or a network
+// failure.
+func IsTransientError(err error) bool {
+	if errors.Is(err, ErrRetryBudgetExhausted) || errors.Is(err, ErrOperationTimeout) {
+		return true
+	}
+	var rateLimitErr *rateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		auth      bool
+		transient bool
+	}{
+		{name: "401", err: &APIError{StatusCode: 401}, auth: true},
+		{name: "403 wrapped", err: fmt.Errorf("listing: %w", &APIError{StatusCode: 403}), auth: true},
+		{name: "404", err: &APIError{StatusCode: 404}},
+		{name: "429", err: &APIError{StatusCode: 429}, transient: true},
+		{name: "503 after retries", err: fmt.Errorf("failed after 6 attempts: %w", &APIError{StatusCode: 503}), transient: true},
+		{name: "rate limited", err: &rateLimitError{}, transient: true},
+		{name: "timeout", err: context.DeadlineExceeded, transient: true},
+		{name: "network", err: fmt.Errorf("executing request: %w", &net.DNSError{Err: "no such host"}), transient: true},
+		{name: "other", err: errors.New("decoding response: unexpected EOF")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.auth, IsAuthError(tt.err))
+			assert.Equal(t, tt.transient, IsTransientError(tt.err))
+		})
+	}
+}
+
+func TestAPIError_Message(t *testing.T) {
+	err := &APIError{StatusCode: 502, Body: "bad gateway"}
+	assert.Equal(t, "API request failed with status 502: bad gateway", err.Error())
+}
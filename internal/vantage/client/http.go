@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,12 +9,18 @@ import (
 	"io"
 	"math"
 	"math/rand/v2"
+	"net"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -27,33 +34,84 @@ const (
 // httpClient handles low-level HTTP operations with retry and rate limiting.
 type httpClient struct {
 	baseURL    string
-	token      string
+	tokens     TokenProvider
 	timeout    time.Duration
 	maxRetries int
 	logger     Logger
+	limiter    *RateLimiter
+	quota      *quotaTracker
 	httpClient *http.Client
+
+	// operationTimeout bounds one call, retries and backoff included.
+	operationTimeout time.Duration
+
+	// requests and retries back Client.RequestStats.
+	requests atomic.Int64
+	retries  atomic.Int64
+
+	// observer, when set, is told about every call.
+	observer RequestObserver
+
+	// userAgent and headers are sent with every request.
+	userAgent string
+	headers   map[string]string
+
+	// retryBudget caps retries across all requests; budgetUsed counts the
+	// retries taken against it.
+	retryBudget int64
+	budgetUsed  atomic.Int64
+
+	// cache, when set, stores GET responses on disk; cacheHits counts
+	// responses it served.
+	cache     *responseCache
+	cacheHits atomic.Int64
+
+	// api is the wire format of the costs and forecast endpoints; nil until
+	// negotiated when Config.APIVersion is auto.
+	api   VersionedAPI
+	apiMu sync.Mutex
 }
 
 // newHTTPClient creates a new HTTP client.
 func newHTTPClient(config Config) *httpClient {
 	return &httpClient{
 		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
-		token:      config.Token,
+		tokens:     config.TokenProvider,
 		timeout:    config.Timeout,
 		maxRetries: config.MaxRetries,
 		logger:     config.Logger,
+		limiter:    config.RateLimiter,
+		quota:      newQuotaTracker(config.RateLimitRemainingThreshold),
 		httpClient: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: config.Transport,
 		},
+		retryBudget:      int64(config.RetryBudget),
+		operationTimeout: config.OperationTimeout,
+		observer:         config.Observer,
+		userAgent:        config.UserAgent,
+		headers:          config.Headers,
 	}
 }
 
 // doCostsRequest performs a costs API request with retry logic.
-func (c *httpClient) doCostsRequest(ctx context.Context, query Query) (Page, error) {
+func (c *httpClient) doCostsRequest(ctx context.Context, query Query) (_ Page, err error) {
+	ctx, call := c.startCall(ctx, http.MethodGet, "costs_request")
+	defer func() { call.finish(err) }()
+
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	api, err := c.versionedAPI(ctx)
+	if err != nil {
+		return Page{}, c.operationError(ctx, err)
+	}
+
 	var lastErr error
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
+			call.setRetries(attempt)
 			c.logger.Info(ctx, "Retrying costs request", map[string]interface{}{
 				"adapter":     "vantage",
 				"operation":   "costs_request",
@@ -62,7 +120,8 @@ func (c *httpClient) doCostsRequest(ctx context.Context, query Query) (Page, err
 			})
 		}
 
-		page, err := c.doCostsRequestOnce(ctx, query)
+		var page Page
+		page, err = c.doCostsRequestOnce(ctx, api, query)
 		if err == nil {
 			if attempt > 0 {
 				c.logger.Info(ctx, "Costs request succeeded after retry", map[string]interface{}{
@@ -83,66 +142,41 @@ func (c *httpClient) doCostsRequest(ctx context.Context, query Query) (Page, err
 
 		// Wait before retrying.
 		if waitErr := c.waitBeforeRetry(ctx, attempt, err); waitErr != nil {
-			return Page{}, waitErr
+			return Page{}, c.operationError(ctx, waitErr)
 		}
 	}
 
-	return Page{}, fmt.Errorf("costs request failed after %d attempts: %w", c.maxRetries+1, lastErr)
+	return Page{}, c.operationError(ctx,
+		fmt.Errorf("costs request failed after %d attempts: %w", c.maxRetries+1, lastErr))
 }
 
 // doCostsRequestOnce performs a single costs API request.
-func (c *httpClient) doCostsRequestOnce(ctx context.Context, query Query) (Page, error) {
-	u, err := url.Parse(c.baseURL + "/costs")
+func (c *httpClient) doCostsRequestOnce(ctx context.Context, api VersionedAPI, query Query) (Page, error) {
+	u, err := url.Parse(c.baseURL + api.CostsPath())
 	if err != nil {
 		return Page{}, fmt.Errorf("parsing URL: %w", err)
 	}
-
-	// Build query parameters.
-	q := url.Values{}
-	if query.WorkspaceToken != "" {
-		q.Set("workspace_token", query.WorkspaceToken)
-	}
-	if query.CostReportToken != "" {
-		q.Set("cost_report_token", query.CostReportToken)
-	}
-	q.Set("start_at", query.StartAt.Format(time.RFC3339))
-	q.Set("end_at", query.EndAt.Format(time.RFC3339))
-	q.Set("granularity", query.Granularity)
-
-	for _, gb := range query.GroupBys {
-		q.Add("group_bys[]", gb)
-	}
-	for _, m := range query.Metrics {
-		q.Add("metrics[]", m)
-	}
-
-	if query.PageSize > 0 {
-		q.Set("page_size", strconv.Itoa(query.PageSize))
-	}
-	if query.Cursor != "" {
-		q.Set("cursor", query.Cursor)
-	}
-
-	u.RawQuery = q.Encode()
+	u.RawQuery = api.CostsParams(query).Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return Page{}, fmt.Errorf("creating request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "pulumicost-vantage/1.0")
+	if err := c.authorize(ctx, req); err != nil {
+		return Page{}, err
+	}
+	c.setDefaultHeaders(req)
 
 	c.logger.Debug(ctx, "Making costs request", map[string]interface{}{
 		"adapter":   "vantage",
 		"operation": "costs_request",
 		"attempt":   0,
-		"url":       c.redactURL(u.String()),
+		"url":       u.String(),
 		"method":    "GET",
 	})
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return Page{}, fmt.Errorf("executing request: %w", err)
 	}
@@ -171,18 +205,21 @@ func (c *httpClient) doCostsRequestOnce(ctx context.Context, query Query) (Page,
 			"operation":   "costs_request",
 			"attempt":     0,
 			"status_code": resp.StatusCode,
+			"request_id":  resp.Header.Get(RequestIDHeader),
 			"response":    string(body),
 		})
-		return Page{}, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return Page{}, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RequestID:  resp.Header.Get(RequestIDHeader),
+		}
 	}
 
-	var costsResp CostsResponse
-	if decodeErr := json.NewDecoder(resp.Body).Decode(&costsResp); decodeErr != nil {
-		return Page{}, fmt.Errorf("decoding response: %w", decodeErr)
+	page, err := api.DecodeCosts(query, resp.Body)
+	if err != nil {
+		return Page{}, err
 	}
 
-	page := Page(costsResp)
-
 	c.logger.Debug(ctx, "Costs response received", map[string]interface{}{
 		"adapter":     "vantage",
 		"operation":   "costs_request",
@@ -196,11 +233,27 @@ func (c *httpClient) doCostsRequestOnce(ctx context.Context, query Query) (Page,
 }
 
 // doForecastRequest performs a forecast API request.
-func (c *httpClient) doForecastRequest(ctx context.Context, reportToken string, query ForecastQuery) (Forecast, error) {
+func (c *httpClient) doForecastRequest(
+	ctx context.Context,
+	reportToken string,
+	query ForecastQuery,
+) (_ Forecast, err error) {
+	ctx, call := c.startCall(ctx, http.MethodGet, "forecast_request")
+	defer func() { call.finish(err) }()
+
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	api, err := c.versionedAPI(ctx)
+	if err != nil {
+		return Forecast{}, c.operationError(ctx, err)
+	}
+
 	var lastErr error
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
+			call.setRetries(attempt)
 			c.logger.Info(ctx, "Retrying forecast request", map[string]interface{}{
 				"adapter":     "vantage",
 				"operation":   "forecast_request",
@@ -209,7 +262,8 @@ func (c *httpClient) doForecastRequest(ctx context.Context, reportToken string,
 			})
 		}
 
-		forecast, err := c.doForecastRequestOnce(ctx, reportToken, query)
+		var forecast Forecast
+		forecast, err = c.doForecastRequestOnce(ctx, api, reportToken, query)
 		if err == nil {
 			if attempt > 0 {
 				c.logger.Info(ctx, "Forecast request succeeded after retry", map[string]interface{}{
@@ -230,50 +284,46 @@ func (c *httpClient) doForecastRequest(ctx context.Context, reportToken string,
 
 		// Wait before retrying.
 		if waitErr := c.waitBeforeRetry(ctx, attempt, err); waitErr != nil {
-			return Forecast{}, waitErr
+			return Forecast{}, c.operationError(ctx, waitErr)
 		}
 	}
 
-	return Forecast{}, fmt.Errorf("forecast request failed after %d attempts: %w", c.maxRetries+1, lastErr)
+	return Forecast{}, c.operationError(ctx,
+		fmt.Errorf("forecast request failed after %d attempts: %w", c.maxRetries+1, lastErr))
 }
 
 // doForecastRequestOnce performs a single forecast API request.
 func (c *httpClient) doForecastRequestOnce(
 	ctx context.Context,
+	api VersionedAPI,
 	reportToken string,
 	query ForecastQuery,
 ) (Forecast, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/cost_reports/%s/forecast", c.baseURL, reportToken))
+	u, err := url.Parse(c.baseURL + api.ForecastPath(reportToken))
 	if err != nil {
 		return Forecast{}, fmt.Errorf("parsing URL: %w", err)
 	}
-
-	// Build query parameters.
-	q := url.Values{}
-	q.Set("start_at", query.StartAt.Format(time.RFC3339))
-	q.Set("end_at", query.EndAt.Format(time.RFC3339))
-	q.Set("granularity", query.Granularity)
-
-	u.RawQuery = q.Encode()
+	u.RawQuery = api.ForecastParams(query).Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return Forecast{}, fmt.Errorf("creating request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", "pulumicost-vantage/1.0")
+	if err := c.authorize(ctx, req); err != nil {
+		return Forecast{}, err
+	}
+	c.setDefaultHeaders(req)
 
 	c.logger.Debug(ctx, "Making forecast request", map[string]interface{}{
 		"adapter":   "vantage",
 		"operation": "forecast_request",
 		"attempt":   0,
-		"url":       c.redactURL(u.String()),
+		"url":       u.String(),
 		"method":    "GET",
 	})
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return Forecast{}, fmt.Errorf("executing request: %w", err)
 	}
@@ -302,18 +352,21 @@ func (c *httpClient) doForecastRequestOnce(
 			"operation":   "forecast_request",
 			"attempt":     0,
 			"status_code": resp.StatusCode,
+			"request_id":  resp.Header.Get(RequestIDHeader),
 			"response":    string(body),
 		})
-		return Forecast{}, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return Forecast{}, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RequestID:  resp.Header.Get(RequestIDHeader),
+		}
 	}
 
-	var forecastResp ForecastResponse
-	if decodeErr := json.NewDecoder(resp.Body).Decode(&forecastResp); decodeErr != nil {
-		return Forecast{}, fmt.Errorf("decoding response: %w", decodeErr)
+	forecast, err := api.DecodeForecast(query, resp.Body)
+	if err != nil {
+		return Forecast{}, err
 	}
 
-	forecast := Forecast(forecastResp)
-
 	c.logger.Debug(ctx, "Forecast response received", map[string]interface{}{
 		"adapter":   "vantage",
 		"operation": "forecast_request",
@@ -324,6 +377,267 @@ func (c *httpClient) doForecastRequestOnce(
 	return forecast, nil
 }
 
+// apiRequest describes a JSON API call made through doRequest.
+type apiRequest struct {
+	operation string
+	method    string
+	path      string
+	params    url.Values
+	body      interface{} // JSON-encoded request body, nil for none
+	out       interface{} // JSON response target, nil to discard
+	noCache   bool        // bypass the response cache
+}
+
+// doGet performs a GET request against path with retry logic and decodes the
+// JSON response into out. A nil out discards the response body.
+func (c *httpClient) doGet(
+	ctx context.Context,
+	operation, path string,
+	params url.Values,
+	out interface{},
+) error {
+	return c.doRequest(ctx, apiRequest{
+		operation: operation,
+		method:    http.MethodGet,
+		path:      path,
+		params:    params,
+		out:       out,
+	})
+}
+
+// doRequest performs an API request with retry logic. Non-GET requests are
+// only retried when rate limited, since the server did not process them.
+func (c *httpClient) doRequest(ctx context.Context, r apiRequest) (err error) {
+	ctx, call := c.startCall(ctx, r.method, r.operation)
+	defer func() { call.finish(err) }()
+
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	var lastErr error
+
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			call.setRetries(attempt)
+			c.logger.Info(ctx, "Retrying request", map[string]interface{}{
+				"adapter":     "vantage",
+				"operation":   r.operation,
+				"attempt":     attempt,
+				"max_retries": c.maxRetries,
+			})
+		}
+
+		err = c.doRequestOnce(ctx, r)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+
+		// Check if we should retry.
+		var rateLimitErr *rateLimitError
+		if r.method != http.MethodGet && !errors.As(err, &rateLimitErr) {
+			break
+		}
+		if !c.shouldRetry(err, attempt) {
+			break
+		}
+
+		// Wait before retrying.
+		if waitErr := c.waitBeforeRetry(ctx, attempt, err); waitErr != nil {
+			return c.operationError(ctx, waitErr)
+		}
+	}
+
+	return c.operationError(ctx,
+		fmt.Errorf("%s failed after %d attempts: %w", r.operation, c.maxRetries+1, lastErr))
+}
+
+// doRequestOnce performs a single API request.
+func (c *httpClient) doRequestOnce(ctx context.Context, r apiRequest) error {
+	u, err := url.Parse(c.baseURL + r.path)
+	if err != nil {
+		return fmt.Errorf("parsing URL: %w", err)
+	}
+	if len(r.params) > 0 {
+		u.RawQuery = r.params.Encode()
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		payload, marshalErr := json.Marshal(r.body)
+		if marshalErr != nil {
+			return fmt.Errorf("encoding request body: %w", marshalErr)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(ctx, req); err != nil {
+		return err
+	}
+	c.setDefaultHeaders(req)
+	if r.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	c.logger.Debug(ctx, "Making request", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": r.operation,
+		"attempt":   0,
+		"url":       u.String(),
+		"method":    r.method,
+	})
+
+	send := c.do
+	if r.noCache {
+		send = c.send
+	}
+	resp, err := send(ctx, req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// Handle rate limiting.
+	if resp.StatusCode == http.StatusTooManyRequests {
+		resetTime := c.parseRateLimitReset(ctx, resp)
+		if resetTime > 0 {
+			return &rateLimitError{resetIn: time.Duration(resetTime) * time.Second}
+		}
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(resp.Body)
+		c.logger.Error(ctx, "Request failed", map[string]interface{}{
+			"adapter":     "vantage",
+			"operation":   r.operation,
+			"attempt":     0,
+			"status_code": resp.StatusCode,
+			"request_id":  resp.Header.Get(RequestIDHeader),
+			"response":    string(respBody),
+		})
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RequestID:  resp.Header.Get(RequestIDHeader),
+		}
+	}
+
+	if r.out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if decodeErr := json.NewDecoder(resp.Body).Decode(r.out); decodeErr != nil {
+		return fmt.Errorf("decoding response: %w", decodeErr)
+	}
+
+	return nil
+}
+
+// send sends req, and once more with a renewed token when the token
+// provider can replace one rejected with a 401.
+func (c *httpClient) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	resp, err := c.sendOnce(ctx, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	renewed, ok := c.reauthorize(ctx, req)
+	if !ok {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	return c.sendOnce(ctx, renewed)
+}
+
+// sendOnce sends req once the shared rate limiter admits it. Each call is one
+// span, so retries show up as siblings under the caller's span. Only the
+// redacted path is recorded; query parameters may carry tokens. Span
+// attributes do not pass through the logger, so they are redacted here.
+func (c *httpClient) sendOnce(ctx context.Context, req *http.Request) (_ *http.Response, err error) {
+	ctx, span := tracer().Start(ctx, "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("url.path", RedactString(req.URL.Path)),
+		))
+	defer func() { finishSpan(span, err) }()
+
+	paused, err := c.quota.wait(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for rate limit quota: %w", err)
+	}
+	if paused > 0 {
+		span.AddEvent("rate limit quota pause", trace.WithAttributes(
+			attribute.String("vantage.delay", paused.String()),
+		))
+		c.logger.Debug(ctx, "Paused for low rate limit quota", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "rate_limit_quota",
+			"attempt":   0,
+			"delay":     paused,
+		})
+	}
+
+	if limitErr := c.limiter.Wait(ctx); limitErr != nil {
+		return nil, fmt.Errorf("waiting for rate limiter: %w", limitErr)
+	}
+
+	req = req.WithContext(ctx)
+	if id := CorrelationID(ctx); id != "" {
+		req.Header = req.Header.Clone()
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	c.requests.Add(1)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	observeStatus(ctx, resp.StatusCode)
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+
+	if pause := c.quota.observe(resp); pause > 0 {
+		c.logger.Warn(ctx, "Rate limit quota low, slowing down", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "rate_limit_quota",
+			"attempt":   0,
+			"remaining": resp.Header.Get("X-RateLimit-Remaining"),
+			"limit":     resp.Header.Get("X-RateLimit-Limit"),
+			"delay":     pause,
+		})
+	}
+
+	return resp, nil
+}
+
+// operationContext bounds one call, retries included, by the operation
+// timeout. Each attempt is bounded separately by the request timeout.
+func (c *httpClient) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.operationTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, c.operationTimeout, ErrOperationTimeout)
+}
+
+// operationError marks err with ErrOperationTimeout when the operation
+// deadline, rather than the caller, ended the call.
+func (c *httpClient) operationError(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), ErrOperationTimeout) {
+		return fmt.Errorf("%w after %s: %w", ErrOperationTimeout, c.operationTimeout, err)
+	}
+	return err
+}
+
 // shouldRetry determines if an error should trigger a retry.
 func (c *httpClient) shouldRetry(err error, attempt int) bool {
 	// Always check attempt count first, regardless of error type.
@@ -337,16 +651,41 @@ func (c *httpClient) shouldRetry(err error, attempt int) bool {
 		return true
 	}
 
-	// Retry on 5xx errors and network errors.
-	errStr := err.Error()
-	return strings.Contains(errStr, "502") ||
-		strings.Contains(errStr, "503") ||
-		strings.Contains(errStr, "504") ||
-		strings.Contains(errStr, "500")
+	// Retry on transient 5xx responses, judged by status code alone: the
+	// message holds the response body and request ID, which may contain any
+	// digits. Other 5xx, such as 501 Not Implemented, fail the same way
+	// every time.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// Retry network errors, but not a request its context ended.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
 
 // waitBeforeRetry implements exponential backoff with jitter.
 func (c *httpClient) waitBeforeRetry(ctx context.Context, attempt int, lastErr error) error {
+	if c.retryBudget > 0 && c.budgetUsed.Add(1) > c.retryBudget {
+		c.logger.Warn(ctx, "Retry budget exhausted, not retrying", map[string]interface{}{
+			"adapter":      "vantage",
+			"operation":    "retry_budget",
+			"attempt":      attempt,
+			"retry_budget": c.retryBudget,
+		})
+		return fmt.Errorf("%w after %d retries: %w", ErrRetryBudgetExhausted, c.retryBudget, lastErr)
+	}
+
 	var delay time.Duration
 
 	// Check if this is a rate limit error with specific reset time.
@@ -385,6 +724,7 @@ func (c *httpClient) waitBeforeRetry(ctx context.Context, attempt int, lastErr e
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-time.After(delay):
+		c.retries.Add(1)
 		return nil
 	}
 }
@@ -414,30 +754,6 @@ func (c *httpClient) parseRateLimitReset(ctx context.Context, resp *http.Respons
 	return reset
 }
 
-// redactURL removes sensitive information from URLs for logging.
-func (c *httpClient) redactURL(rawURL string) string {
-	// Redact Authorization header values in query parameters if present
-	if strings.Contains(rawURL, "Authorization=") {
-		rawURL = strings.ReplaceAll(rawURL, "Authorization=Bearer%20"+c.token, "Authorization=Bearer%20****")
-	}
-
-	// Redact workspace_token and cost_report_token query parameters
-	rawURL = redactQueryParam(rawURL, "workspace_token")
-	rawURL = redactQueryParam(rawURL, "cost_report_token")
-
-	// Redact any report token segment in cost_reports path.
-	rePath := regexp.MustCompile(`/cost_reports/[^/?#]+`)
-	rawURL = rePath.ReplaceAllString(rawURL, "/cost_reports/****")
-
-	return rawURL
-}
-
-// redactQueryParam redacts a query parameter value from a URL.
-func redactQueryParam(rawURL, paramName string) string {
-	re := regexp.MustCompile("([?&])" + regexp.QuoteMeta(paramName) + "=([^&]*)")
-	return re.ReplaceAllString(rawURL, "$1"+paramName+"=****")
-}
-
 // rateLimitError represents a rate limiting error.
 type rateLimitError struct {
 	resetIn time.Duration
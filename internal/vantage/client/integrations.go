@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// ListIntegrations implements Client.ListIntegrations.
+func (c *client) ListIntegrations(ctx context.Context, workspaceToken string) ([]Integration, error) {
+	params := url.Values{}
+	if workspaceToken != "" {
+		params.Set("workspace_token", workspaceToken)
+	}
+
+	return listAll(ctx, c.httpClient, "list_integrations", "/integrations", params,
+		func(resp *integrationsResponse) ([]Integration, string) {
+			return resp.Integrations, resp.Links.Next
+		})
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListIntegrations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/integrations", r.URL.Path)
+
+		_, _ = w.Write([]byte(`{
+			"integrations": [{
+				"token": "accss_crdntl_1",
+				"provider": "aws",
+				"account_identifier": "123456789012",
+				"status": "connected",
+				"last_synced_at": "2024-03-01T06:00:00Z"
+			}],
+			"links": {}
+		}`))
+	}))
+	defer server.Close()
+
+	integrations, err := newTestClient(t, server.URL, 0).ListIntegrations(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, integrations, 1)
+	assert.Equal(t, "aws", integrations[0].Provider)
+	assert.Equal(t, "connected", integrations[0].Status)
+	assert.Equal(t, time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), integrations[0].LastSyncedAt)
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Known JSON field names of the rows whose unknown fields are captured.
+var (
+	costRowFields       = jsonFieldNames(reflect.TypeOf(CostRow{}))
+	forecastRowFields   = jsonFieldNames(reflect.TypeOf(ForecastRow{}))
+	v2CostRowFields     = jsonFieldNames(reflect.TypeOf(v2CostRow{}))
+	v2ForecastRowFields = jsonFieldNames(reflect.TypeOf(v2ForecastRow{}))
+)
+
+// jsonFieldNames returns the lower-cased JSON names encoding/json decodes
+// into t's fields. Names are lower-cased because encoding/json matches them
+// case-insensitively.
+func jsonFieldNames(t reflect.Type) map[string]struct{} {
+	names := make(map[string]struct{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[strings.ToLower(name)] = struct{}{}
+	}
+	return names
+}
+
+// unknownFields decodes the members of the JSON object data that known does
+// not list, or returns nil when there are none. New Vantage fields are
+// captured this way instead of being silently dropped.
+func unknownFields(data []byte, known map[string]struct{}) (map[string]interface{}, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	var extra map[string]interface{}
+	for name, raw := range members {
+		if _, ok := known[strings.ToLower(name)]; ok {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		if extra == nil {
+			extra = make(map[string]interface{})
+		}
+		extra[name] = value
+	}
+	return extra, nil
+}
+
+// UnmarshalJSON decodes a costs row, capturing unknown fields in Extra.
+func (r *CostRow) UnmarshalJSON(data []byte) error {
+	type plain CostRow
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, costRowFields)
+	r.Extra = extra
+	return err
+}
+
+// UnmarshalJSON decodes a forecast row, capturing unknown fields in Extra.
+func (r *ForecastRow) UnmarshalJSON(data []byte) error {
+	type plain ForecastRow
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, forecastRowFields)
+	r.Extra = extra
+	return err
+}
+
+// UnmarshalJSON decodes a v2 costs row, capturing unknown fields in Extra.
+func (r *v2CostRow) UnmarshalJSON(data []byte) error {
+	type plain v2CostRow
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, v2CostRowFields)
+	r.Extra = extra
+	return err
+}
+
+// UnmarshalJSON decodes a v2 forecast row, capturing unknown fields in
+// Extra.
+func (r *v2ForecastRow) UnmarshalJSON(data []byte) error {
+	type plain v2ForecastRow
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, v2ForecastRowFields)
+	r.Extra = extra
+	return err
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCosts_CapturesUnknownFields(t *testing.T) {
+	v1, err := v1API{}.DecodeCosts(Query{}, strings.NewReader(`{
+		"data": [
+			{"provider": "aws", "Cost": 1.5, "bucket_start": "2024-01-01T00:00:00Z",
+			 "charge_type": "Usage", "pricing": {"term": "OnDemand"}},
+			{"provider": "gcp", "cost": 2}
+		],
+		"has_more": false
+	}`))
+	require.NoError(t, err)
+	require.Len(t, v1.Data, 2)
+	// Known fields match case-insensitively, as encoding/json decodes them.
+	assert.InDelta(t, 1.5, v1.Data[0].Cost, 1e-9)
+	assert.Equal(t, map[string]interface{}{
+		"charge_type": "Usage",
+		"pricing":     map[string]interface{}{"term": "OnDemand"},
+	}, v1.Data[0].Extra)
+	assert.Nil(t, v1.Data[1].Extra)
+
+	v2, err := v2API{}.DecodeCosts(Query{Granularity: "day"}, strings.NewReader(`{
+		"links": {},
+		"costs": [{"accrued_at": "2024-01-01", "provider": "aws", "amount": "3.00", "charge_type": "Tax"}]
+	}`))
+	require.NoError(t, err)
+	require.Len(t, v2.Data, 1)
+	assert.Equal(t, map[string]interface{}{"charge_type": "Tax"}, v2.Data[0].Extra)
+}
+
+func TestDecodeForecast_CapturesUnknownFields(t *testing.T) {
+	v1, err := v1API{}.DecodeForecast(ForecastQuery{}, strings.NewReader(`{
+		"data": [{"bucket_start": "2024-02-01T00:00:00Z", "cost": 10, "confidence": 0.8}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"confidence": 0.8}, v1.Data[0].Extra)
+
+	v2, err := v2API{}.DecodeForecast(ForecastQuery{Granularity: "month"}, strings.NewReader(`{
+		"forecasted_costs": [{"date": "2024-02-01", "amount": "10", "lower_bound": "8"}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"lower_bound": "8"}, v2.Data[0].Extra)
+}
+
+func TestCostRow_UnmarshalJSONInvalid(t *testing.T) {
+	var row CostRow
+	require.Error(t, row.UnmarshalJSON([]byte(`{"cost": "ten"}`)))
+	require.Error(t, row.UnmarshalJSON([]byte(`[]`)))
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// maxListPages bounds pagination on list endpoints to avoid looping forever
+// on a misbehaving server.
+const maxListPages = 1000
+
+// listAll fetches every page of a page-numbered list endpoint. items extracts
+// the page's entries and the links.next value from a decoded response; an
+// empty next link or an empty page ends pagination.
+func listAll[R any, T any](
+	ctx context.Context,
+	h *httpClient,
+	operation, path string,
+	params url.Values,
+	items func(*R) ([]T, string),
+) ([]T, error) {
+	var all []T
+
+	for page := 1; page <= maxListPages; page++ {
+		pageParams := url.Values{}
+		for k, v := range params {
+			pageParams[k] = v
+		}
+		pageParams.Set("page", strconv.Itoa(page))
+
+		var resp R
+		if err := h.doGet(ctx, operation, path, pageParams, &resp); err != nil {
+			return nil, err
+		}
+
+		pageItems, next := items(&resp)
+		all = append(all, pageItems...)
+		if next == "" || len(pageItems) == 0 {
+			break
+		}
+	}
+
+	return all, nil
+}
@@ -2,6 +2,10 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
 )
 
 // Logger defines the minimal logging interface used by the client.
@@ -32,3 +36,148 @@ func (n *noopLogger) Error(_ context.Context, _ string, _ map[string]interface{}
 func NewNoopLogger() Logger {
 	return &noopLogger{}
 }
+
+// Level is the severity of a log message.
+type Level int
+
+// Levels in increasing severity. LevelOff is above every message, so a
+// filter at LevelOff discards everything.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelOff
+)
+
+var levelNames = []string{"debug", "info", "warn", "error", "off"}
+
+// String returns the level's name as accepted by ParseLevel.
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelOff {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses a level name, case-insensitively. "warning" is accepted
+// for LevelWarn.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		return LevelWarn, nil
+	}
+	if i := slices.Index(levelNames, name); i >= 0 {
+		return Level(i), nil
+	}
+	return 0, fmt.Errorf("invalid log level %q (valid: %s)", s, strings.Join(levelNames, ", "))
+}
+
+// levelFilter drops messages below min before they reach next.
+type levelFilter struct {
+	next Logger
+	min  Level
+}
+
+// NewLevelFilter returns a logger passing messages at or above min to next.
+func NewLevelFilter(next Logger, min Level) Logger {
+	return &levelFilter{next: next, min: min}
+}
+
+func (f *levelFilter) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.min <= LevelDebug {
+		f.next.Debug(ctx, msg, fields)
+	}
+}
+
+func (f *levelFilter) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.min <= LevelInfo {
+		f.next.Info(ctx, msg, fields)
+	}
+}
+
+func (f *levelFilter) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.min <= LevelWarn {
+		f.next.Warn(ctx, msg, fields)
+	}
+}
+
+func (f *levelFilter) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.min <= LevelError {
+		f.next.Error(ctx, msg, fields)
+	}
+}
+
+// LevelVar is a Level that can be changed while loggers filtering on it
+// are in use. The zero value is LevelDebug.
+type LevelVar struct {
+	level atomic.Int64
+}
+
+// Level returns the current level.
+func (v *LevelVar) Level() Level {
+	return Level(v.level.Load())
+}
+
+// Set changes the level.
+func (v *LevelVar) Set(level Level) {
+	v.level.Store(int64(level))
+}
+
+// levelVarFilter drops messages below the current level of a LevelVar.
+type levelVarFilter struct {
+	next  Logger
+	level *LevelVar
+}
+
+// NewLevelVarFilter returns a logger passing messages at or above level's
+// current value to next, so the level can be raised or lowered at runtime.
+func NewLevelVarFilter(next Logger, level *LevelVar) Logger {
+	return &levelVarFilter{next: next, level: level}
+}
+
+func (f *levelVarFilter) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.level.Level() <= LevelDebug {
+		f.next.Debug(ctx, msg, fields)
+	}
+}
+
+func (f *levelVarFilter) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.level.Level() <= LevelInfo {
+		f.next.Info(ctx, msg, fields)
+	}
+}
+
+func (f *levelVarFilter) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.level.Level() <= LevelWarn {
+		f.next.Warn(ctx, msg, fields)
+	}
+}
+
+func (f *levelVarFilter) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	if f.level.Level() <= LevelError {
+		f.next.Error(ctx, msg, fields)
+	}
+}
+
+// leadingFields are printed first, in this order, by the structured loggers;
+// every message carries them. Other fields follow sorted by key.
+var leadingFields = []string{"adapter", "operation", "attempt"}
+
+// orderedKeys returns the keys of fields with leadingFields first.
+func orderedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for _, key := range leadingFields {
+		if _, ok := fields[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	rest := make([]string, 0, len(fields))
+	for key := range fields {
+		if !slices.Contains(leadingFields, key) {
+			rest = append(rest, key)
+		}
+	}
+	slices.Sort(rest)
+	return append(keys, rest...)
+}
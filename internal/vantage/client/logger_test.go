@@ -0,0 +1,104 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]Level{
+		"debug":   LevelDebug,
+		"INFO":    LevelInfo,
+		"warn":    LevelWarn,
+		"warning": LevelWarn,
+		" error ": LevelError,
+		"off":     LevelOff,
+	} {
+		got, err := ParseLevel(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := ParseLevel("verbose")
+	require.ErrorContains(t, err, `invalid log level "verbose" (valid: debug, info, warn, error, off)`)
+	assert.Equal(t, "warn", LevelWarn.String())
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, LevelInfo)
+
+	logger.Debug(context.Background(), "dropped", nil)
+	logger.Warn(context.Background(), "Rate limit quota low", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "rate_limit_quota",
+		"attempt":   0,
+		"error":     errors.New("boom"),
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1, "debug is below the level")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "Rate limit quota low", entry["msg"])
+	assert.Equal(t, "vantage", entry["adapter"])
+	assert.Equal(t, "rate_limit_quota", entry["operation"])
+	assert.InDelta(t, 0, entry["attempt"], 0)
+	assert.Equal(t, "boom", entry["error"])
+}
+
+func TestConsoleLogger(t *testing.T) {
+	var buf bytes.Buffer
+	console := &consoleLogger{
+		w:   &buf,
+		now: func() time.Time { return time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC) },
+	}
+	logger := NewLevelFilter(console, LevelDebug)
+
+	logger.Info(context.Background(), "Fetched cost data", map[string]interface{}{
+		"records":   12,
+		"attempt":   0,
+		"adapter":   "vantage",
+		"operation": "fetch_cost_data",
+		"bookmark":  "",
+		"reason":    "end of range",
+		"delay":     1500 * time.Millisecond,
+	})
+
+	assert.Equal(t,
+		`09:30:00.000 INF Fetched cost data adapter=vantage operation=fetch_cost_data attempt=0 `+
+			`bookmark="" delay=1.5s reason="end of range" records=12`+"\n",
+		buf.String())
+}
+
+func TestLevelFilter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewConsoleLogger(&buf, LevelOff)
+
+	logger.Error(context.Background(), "hidden", nil)
+	assert.Empty(t, buf.String())
+}
+
+func TestLevelVarFilter(t *testing.T) {
+	var buf bytes.Buffer
+	var level LevelVar
+	level.Set(LevelWarn)
+	logger := NewLevelVarFilter(NewConsoleLogger(&buf, LevelDebug), &level)
+
+	logger.Info(context.Background(), "hidden", nil)
+	assert.Empty(t, buf.String())
+
+	level.Set(LevelInfo)
+	logger.Info(context.Background(), "shown", nil)
+	assert.Contains(t, buf.String(), "INF shown")
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request duration
+// histogram. They span a cached response to a costs page retried through
+// several backoffs.
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// RequestMetrics is a RequestObserver that aggregates calls per method and
+// endpoint, for the run summary and the Prometheus exporter. The zero value
+// is not usable; create one with NewRequestMetrics.
+type RequestMetrics struct {
+	mu        sync.Mutex
+	endpoints map[endpointKey]*endpointMetrics
+}
+
+type endpointKey struct {
+	method   string
+	endpoint string
+}
+
+type endpointMetrics struct {
+	calls    int64
+	errors   int64
+	retries  int64
+	statuses map[int]int64
+	// buckets counts calls at or under each latencyBuckets bound.
+	buckets    []int64
+	sumSeconds float64
+	maxSeconds float64
+}
+
+// EndpointStats is the aggregate of the calls to one endpoint.
+type EndpointStats struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	Calls    int64  `json:"calls"`
+	// Errors is how many calls failed after their retries.
+	Errors  int64 `json:"errors"`
+	Retries int64 `json:"retries"`
+	// Statuses counts calls by the HTTP status of their last response;
+	// "none" counts calls that received none.
+	Statuses            map[string]int64 `json:"statuses,omitempty"`
+	AvgLatencySeconds   float64          `json:"avg_latency_seconds"`
+	MaxLatencySeconds   float64          `json:"max_latency_seconds"`
+	TotalLatencySeconds float64          `json:"total_latency_seconds"`
+}
+
+// NewRequestMetrics creates an empty RequestMetrics.
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{endpoints: make(map[endpointKey]*endpointMetrics)}
+}
+
+// ObserveRequest implements RequestObserver.
+func (m *RequestMetrics) ObserveRequest(_ context.Context, request ObservedRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := endpointKey{method: request.Method, endpoint: request.Endpoint}
+	metrics, ok := m.endpoints[key]
+	if !ok {
+		metrics = &endpointMetrics{
+			statuses: make(map[int]int64),
+			buckets:  make([]int64, len(latencyBuckets)),
+		}
+		m.endpoints[key] = metrics
+	}
+
+	seconds := request.Latency.Seconds()
+	metrics.calls++
+	metrics.retries += int64(request.Retries)
+	metrics.statuses[request.Status]++
+	if request.Err != nil {
+		metrics.errors++
+	}
+	metrics.sumSeconds += seconds
+	metrics.maxSeconds = max(metrics.maxSeconds, seconds)
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			metrics.buckets[i]++
+		}
+	}
+}
+
+// Endpoints returns the aggregate of each endpoint called, sorted by
+// endpoint and method.
+func (m *RequestMetrics) Endpoints() []EndpointStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]EndpointStats, 0, len(m.endpoints))
+	for _, key := range m.sortedKeys() {
+		metrics := m.endpoints[key]
+		endpoint := EndpointStats{
+			Method:              key.method,
+			Endpoint:            key.endpoint,
+			Calls:               metrics.calls,
+			Errors:              metrics.errors,
+			Retries:             metrics.retries,
+			Statuses:            make(map[string]int64, len(metrics.statuses)),
+			AvgLatencySeconds:   metrics.sumSeconds / float64(metrics.calls),
+			MaxLatencySeconds:   metrics.maxSeconds,
+			TotalLatencySeconds: metrics.sumSeconds,
+		}
+		for status, count := range metrics.statuses {
+			endpoint.Statuses[statusLabel(status)] = count
+		}
+		stats = append(stats, endpoint)
+	}
+	return stats
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format, e.g. for the node_exporter textfile collector.
+func (m *RequestMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	keys := m.sortedKeys()
+
+	b.WriteString("# HELP vantage_client_requests_total Vantage API calls by the HTTP status of their last response.\n")
+	b.WriteString("# TYPE vantage_client_requests_total counter\n")
+	for _, key := range keys {
+		metrics := m.endpoints[key]
+		statuses := make([]int, 0, len(metrics.statuses))
+		for status := range metrics.statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(&b, "vantage_client_requests_total{%s,status=%q} %d\n",
+				key.labels(), statusLabel(status), metrics.statuses[status])
+		}
+	}
+
+	b.WriteString("# HELP vantage_client_request_errors_total Vantage API calls that failed after their retries.\n")
+	b.WriteString("# TYPE vantage_client_request_errors_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "vantage_client_request_errors_total{%s} %d\n", key.labels(), m.endpoints[key].errors)
+	}
+
+	b.WriteString("# HELP vantage_client_request_retries_total Retries made by Vantage API calls.\n")
+	b.WriteString("# TYPE vantage_client_request_retries_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "vantage_client_request_retries_total{%s} %d\n", key.labels(), m.endpoints[key].retries)
+	}
+
+	b.WriteString("# HELP vantage_client_request_duration_seconds Vantage API call latency, retries included.\n")
+	b.WriteString("# TYPE vantage_client_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		metrics := m.endpoints[key]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(&b, "vantage_client_request_duration_seconds_bucket{%s,le=%q} %d\n",
+				key.labels(), strconv.FormatFloat(bound, 'g', -1, 64), metrics.buckets[i])
+		}
+		fmt.Fprintf(&b, "vantage_client_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key.labels(), metrics.calls)
+		fmt.Fprintf(&b, "vantage_client_request_duration_seconds_sum{%s} %s\n",
+			key.labels(), strconv.FormatFloat(metrics.sumSeconds, 'g', -1, 64))
+		fmt.Fprintf(&b, "vantage_client_request_duration_seconds_count{%s} %d\n", key.labels(), metrics.calls)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sortedKeys returns the endpoints called, sorted by endpoint and method.
+// The caller holds m.mu.
+func (m *RequestMetrics) sortedKeys() []endpointKey {
+	keys := make([]endpointKey, 0, len(m.endpoints))
+	for key := range m.endpoints {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].method < keys[j].method
+	})
+	return keys
+}
+
+// labels returns the key as Prometheus labels.
+func (k endpointKey) labels() string {
+	return fmt.Sprintf("method=%q,endpoint=%q", k.method, k.endpoint)
+}
+
+// statusLabel returns status as a label value; zero is "none".
+func statusLabel(status int) string {
+	if status == 0 {
+		return "none"
+	}
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	metrics := NewRequestMetrics()
+	ctx := context.Background()
+	metrics.ObserveRequest(ctx, ObservedRequest{
+		Method: "GET", Endpoint: "costs_request", Status: 200, Latency: 200 * time.Millisecond,
+	})
+	metrics.ObserveRequest(ctx, ObservedRequest{
+		Method: "GET", Endpoint: "costs_request", Status: 200, Latency: 3 * time.Second, Retries: 2,
+	})
+	metrics.ObserveRequest(ctx, ObservedRequest{
+		Method: "GET", Endpoint: "budgets_request", Latency: time.Second, Err: errors.New("connection reset"),
+	})
+
+	endpoints := metrics.Endpoints()
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, EndpointStats{
+		Method:              "GET",
+		Endpoint:            "budgets_request",
+		Calls:               1,
+		Errors:              1,
+		Statuses:            map[string]int64{"none": 1},
+		AvgLatencySeconds:   1,
+		MaxLatencySeconds:   1,
+		TotalLatencySeconds: 1,
+	}, endpoints[0])
+
+	costs := endpoints[1]
+	assert.Equal(t, "costs_request", costs.Endpoint)
+	assert.Equal(t, int64(2), costs.Calls)
+	assert.Zero(t, costs.Errors)
+	assert.Equal(t, int64(2), costs.Retries)
+	assert.Equal(t, map[string]int64{"200": 2}, costs.Statuses)
+	assert.InDelta(t, 1.6, costs.AvgLatencySeconds, 1e-9)
+	assert.InDelta(t, 3, costs.MaxLatencySeconds, 1e-9)
+
+	var out strings.Builder
+	require.NoError(t, metrics.WritePrometheus(&out))
+	text := out.String()
+	for _, line := range []string{
+		"# TYPE vantage_client_requests_total counter",
+		`vantage_client_requests_total{method="GET",endpoint="costs_request",status="200"} 2`,
+		`vantage_client_requests_total{method="GET",endpoint="budgets_request",status="none"} 1`,
+		`vantage_client_request_errors_total{method="GET",endpoint="budgets_request"} 1`,
+		`vantage_client_request_retries_total{method="GET",endpoint="costs_request"} 2`,
+		"# TYPE vantage_client_request_duration_seconds histogram",
+		`vantage_client_request_duration_seconds_bucket{method="GET",endpoint="costs_request",le="0.25"} 1`,
+		`vantage_client_request_duration_seconds_bucket{method="GET",endpoint="costs_request",le="5"} 2`,
+		`vantage_client_request_duration_seconds_bucket{method="GET",endpoint="costs_request",le="+Inf"} 2`,
+		`vantage_client_request_duration_seconds_sum{method="GET",endpoint="costs_request"} 3.2`,
+		`vantage_client_request_duration_seconds_count{method="GET",endpoint="costs_request"} 2`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+}
@@ -0,0 +1,442 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MockScheme is the base URL scheme that makes New answer from the mock
+// transport instead of calling Vantage, e.g.
+// "mock://?providers=aws,gcp&rows_per_bucket=50&seed=7".
+const MockScheme = "mock"
+
+// Mock generator defaults.
+const (
+	defaultMockAccounts      = 2
+	defaultMockRowsPerBucket = 24
+	defaultMockDailyCost     = 1000
+	defaultMockPageSize      = 1000
+	// mockNoise is how far a line item's cost wanders from its expected
+	// cost from one day to the next, as a fraction.
+	mockNoise = 0.05
+)
+
+// MockConfig shapes the synthetic data of NewMockTransport. Zero fields use
+// the defaults.
+type MockConfig struct {
+	// Seed selects the data set; the same seed always yields the same
+	// rows.
+	Seed int64
+	// Providers defaults to aws, azure, and gcp.
+	Providers []string
+	// Services replaces each provider's built-in service catalog.
+	Services []string
+	// Accounts is how many accounts each provider spreads spend across.
+	Accounts int
+	// RowsPerBucket is how many line items every day or month bucket has.
+	RowsPerBucket int
+	// DailyCost is the expected spend of a weekday, across all line items.
+	DailyCost float64
+	// Seasonality is how much lower weekend spend is, as a fraction of
+	// weekday spend; zero keeps every day alike.
+	Seasonality float64
+}
+
+// mockServices are the built-in service catalogs by provider.
+var mockServices = map[string][]string{
+	"aws":   {"AmazonEC2", "AmazonS3", "AmazonRDS", "AWSLambda", "AmazonCloudFront"},
+	"azure": {"Virtual Machines", "Storage", "Azure SQL Database", "Azure App Service"},
+	"gcp":   {"Compute Engine", "Cloud Storage", "BigQuery", "Cloud Run"},
+}
+
+// mockRegions are the regions line items are placed in, by provider.
+var mockRegions = map[string][]string{
+	"aws":   {"us-east-1", "us-west-2", "eu-west-1"},
+	"azure": {"eastus", "westeurope"},
+	"gcp":   {"us-central1", "europe-west1"},
+}
+
+// mockTagValues are the values of the tags every line item carries.
+var mockTagValues = map[string][]string{
+	"env":  {"prod", "staging", "dev"},
+	"team": {"platform", "data", "web", "ml"},
+}
+
+// mockItem is one line item of the synthetic catalog. Its dimensions are
+// the same in every bucket; only its cost varies.
+type mockItem struct {
+	row    CostRow
+	weight float64
+}
+
+// mockTransport answers API requests from a deterministic generator.
+type mockTransport struct {
+	config MockConfig
+	items  []mockItem
+	now    func() time.Time
+}
+
+// NewMockTransport returns a transport that serves synthetic cost data
+// without network access, so sinks and pipelines can be exercised without
+// a Vantage account. Costs and forecasts are generated per day from a
+// fixed catalog of line items; a row depends only on the seed, its bucket,
+// and its line item, so overlapping requests agree. Group-bys and filters
+// are ignored. Cost reports, workspaces, and integrations list one mock
+// entry each, other list endpoints are empty, and writes are rejected.
+func NewMockTransport(config MockConfig) http.RoundTripper {
+	if len(config.Providers) == 0 {
+		config.Providers = []string{"aws", "azure", "gcp"}
+	}
+	if config.Accounts <= 0 {
+		config.Accounts = defaultMockAccounts
+	}
+	if config.RowsPerBucket <= 0 {
+		config.RowsPerBucket = defaultMockRowsPerBucket
+	}
+	if config.DailyCost <= 0 {
+		config.DailyCost = defaultMockDailyCost
+	}
+	config.Seasonality = min(max(config.Seasonality, 0), 1)
+	return &mockTransport{config: config, items: mockCatalog(config), now: time.Now}
+}
+
+// ParseMockURL reads a MockConfig from the query of a mock:// base URL.
+// Keys are seed, providers, services, accounts, rows_per_bucket,
+// daily_cost, and seasonality; lists are comma-separated.
+func ParseMockURL(raw string) (MockConfig, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return MockConfig{}, fmt.Errorf("invalid mock URL: %w", err)
+	}
+	if u.Scheme != MockScheme {
+		return MockConfig{}, fmt.Errorf("invalid mock URL %q: scheme must be %s", raw, MockScheme)
+	}
+
+	var config MockConfig
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "seed":
+			config.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "providers":
+			config.Providers = splitList(value)
+		case "services":
+			config.Services = splitList(value)
+		case "accounts":
+			config.Accounts, err = strconv.Atoi(value)
+		case "rows_per_bucket":
+			config.RowsPerBucket, err = strconv.Atoi(value)
+		case "daily_cost":
+			config.DailyCost, err = strconv.ParseFloat(value, 64)
+		case "seasonality":
+			config.Seasonality, err = strconv.ParseFloat(value, 64)
+		default:
+			return MockConfig{}, fmt.Errorf("invalid mock URL: unknown parameter %q", key)
+		}
+		if err != nil {
+			return MockConfig{}, fmt.Errorf("invalid mock URL parameter %s: %w", key, err)
+		}
+	}
+	return config, nil
+}
+
+// isMockURL reports whether baseURL selects the mock transport.
+func isMockURL(baseURL string) bool {
+	return strings.HasPrefix(baseURL, MockScheme+"://")
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// mockCatalog builds the line items, spreading them round-robin across
+// providers, with a long tail of small items behind a few large ones.
+func mockCatalog(config MockConfig) []mockItem {
+	items := make([]mockItem, config.RowsPerBucket)
+	var total float64
+	for i := range items {
+		rng := mockRand(config.Seed, uint64(i))
+		provider := config.Providers[i%len(config.Providers)]
+		services := config.Services
+		if len(services) == 0 {
+			services = mockServices[provider]
+		}
+		if len(services) == 0 {
+			services = []string{"Compute", "Storage"}
+		}
+		regions := mockRegions[provider]
+		if len(regions) == 0 {
+			regions = []string{"global"}
+		}
+		service := services[rng.IntN(len(services))]
+		account := rng.IntN(config.Accounts) + 1
+
+		items[i] = mockItem{
+			row: CostRow{
+				Provider:       provider,
+				Service:        service,
+				Account:        fmt.Sprintf("%s-account-%02d", provider, account),
+				BillingAccount: provider + "-billing",
+				Region:         regions[rng.IntN(len(regions))],
+				ResourceID:     fmt.Sprintf("mock-%s-%04d", provider, i),
+				Tags: map[string]string{
+					"env":  mockTagValues["env"][rng.IntN(len(mockTagValues["env"]))],
+					"team": mockTagValues["team"][rng.IntN(len(mockTagValues["team"]))],
+				},
+				UsageUnit: "Hrs",
+				Currency:  "USD",
+			},
+			weight: 0.2 + rng.ExpFloat64(),
+		}
+		total += items[i].weight
+	}
+	for i := range items {
+		items[i].weight /= total
+	}
+	return items
+}
+
+// mockRand returns the generator for one combination of seed and keys.
+func mockRand(seed int64, keys ...uint64) *rand.Rand {
+	stream := uint64(0x9e3779b97f4a7c15)
+	for _, key := range keys {
+		stream = stream*31 + key
+	}
+	return rand.New(rand.NewPCG(uint64(seed), stream))
+}
+
+// expectedCost is the cost of item on day before noise: its share of the
+// daily cost, lowered on weekends by the seasonality.
+func (t *mockTransport) expectedCost(item mockItem, day time.Time) float64 {
+	cost := t.config.DailyCost * item.weight
+	if weekday := day.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		cost *= 1 - t.config.Seasonality
+	}
+	return cost
+}
+
+// dailyCost is the cost of the i-th item on day, rounded to cents.
+func (t *mockTransport) dailyCost(i int, day time.Time) float64 {
+	rng := mockRand(t.config.Seed, uint64(i), uint64(day.Unix()/86400))
+	cost := t.expectedCost(t.items[i], day) * (1 + mockNoise*(2*rng.Float64()-1))
+	return math.Round(cost*100) / 100
+}
+
+// mockBucket is one bucket of a range: its bounds and the days of the
+// range it covers.
+type mockBucket struct {
+	start, end time.Time
+	days       []time.Time
+}
+
+// mockBuckets splits [start, end) into day or month buckets. Month buckets
+// span the calendar month but only cover the days inside the range.
+func mockBuckets(start, end time.Time, granularity string) []mockBucket {
+	var buckets []mockBucket
+	day := start.UTC().Truncate(24 * time.Hour)
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		bucketStart, bucketEnd := day, day.AddDate(0, 0, 1)
+		if granularity == "month" {
+			bucketStart = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+			bucketEnd = bucketStart.AddDate(0, 1, 0)
+		}
+		if n := len(buckets); n > 0 && buckets[n-1].start.Equal(bucketStart) {
+			buckets[n-1].days = append(buckets[n-1].days, day)
+			continue
+		}
+		buckets = append(buckets, mockBucket{start: bucketStart, end: bucketEnd, days: []time.Time{day}})
+	}
+	return buckets
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	if req.Method != http.MethodGet {
+		return mockResponse(req, http.StatusMethodNotAllowed, mockError("mock API is read-only"))
+	}
+
+	path := req.URL.Path
+	switch {
+	case path == "/costs":
+		return t.costs(req)
+	case strings.HasPrefix(path, "/cost_reports/") && strings.HasSuffix(path, "/forecast"):
+		return t.forecast(req)
+	case strings.HasPrefix(path, "/cost_reports/"):
+		token := strings.TrimPrefix(path, "/cost_reports/")
+		return mockResponse(req, http.StatusOK, t.costReport(token))
+	case path == "/cost_reports":
+		return mockResponse(req, http.StatusOK, costReportsResponse{CostReports: []CostReport{t.costReport("rprt_mock")}})
+	case path == "/workspaces":
+		return mockResponse(req, http.StatusOK, workspacesResponse{
+			Workspaces: []Workspace{{Token: "wrkspc_mock", Name: "Mock workspace"}},
+		})
+	case path == "/integrations":
+		return mockResponse(req, http.StatusOK, t.integrations())
+	case strings.HasPrefix(path, "/v2/"):
+		// Only the v1 wire format is generated, so auto negotiation
+		// settles on v1.
+		return mockResponse(req, http.StatusNotFound, mockError("not found"))
+	default:
+		return mockResponse(req, http.StatusOK, struct{}{})
+	}
+}
+
+// costs answers a costs request with a page of generated rows. The cursor
+// is the offset of the page's first row.
+func (t *mockTransport) costs(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	start, startErr := time.Parse(time.RFC3339, query.Get("start_at"))
+	end, endErr := time.Parse(time.RFC3339, query.Get("end_at"))
+	if startErr != nil || endErr != nil {
+		return mockResponse(req, http.StatusBadRequest, mockError("start_at and end_at must be RFC 3339 times"))
+	}
+	offset, pageSize := 0, defaultMockPageSize
+	if cursor := query.Get("cursor"); cursor != "" {
+		var err error
+		if offset, err = strconv.Atoi(cursor); err != nil || offset < 0 {
+			return mockResponse(req, http.StatusBadRequest, mockError("invalid cursor"))
+		}
+	}
+	if size, err := strconv.Atoi(query.Get("page_size")); err == nil && size > 0 {
+		pageSize = size
+	}
+
+	buckets := mockBuckets(start, end, query.Get("granularity"))
+	total := len(buckets) * len(t.items)
+	resp := CostsResponse{Data: []CostRow{}}
+	for n := offset; n < min(offset+pageSize, total); n++ {
+		bucket, i := buckets[n/len(t.items)], n%len(t.items)
+		row := t.items[i].row
+		row.Tags = maps.Clone(row.Tags)
+		for _, day := range bucket.days {
+			row.Cost += t.dailyCost(i, day)
+		}
+		row.Cost = math.Round(row.Cost*100) / 100
+		row.AmortizedCost = row.Cost
+		row.ListCost = math.Round(row.Cost*110) / 100
+		row.EffectiveUnitPrice = 0.1
+		row.UsageQuantity = math.Round(row.Cost*1000) / 100
+		row.BucketStart, row.BucketEnd = bucket.start, bucket.end
+		resp.Data = append(resp.Data, row)
+	}
+	if offset+pageSize < total {
+		resp.HasMore = true
+		resp.NextCursor = strconv.Itoa(offset + pageSize)
+	}
+	return mockResponse(req, http.StatusOK, resp)
+}
+
+// forecast answers a forecast request with the expected cost of every
+// bucket, split by provider and service when requested.
+func (t *mockTransport) forecast(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	start, startErr := time.Parse(time.RFC3339, query.Get("start_at"))
+	end, endErr := time.Parse(time.RFC3339, query.Get("end_at"))
+	if startErr != nil || endErr != nil {
+		return mockResponse(req, http.StatusBadRequest, mockError("start_at and end_at must be RFC 3339 times"))
+	}
+	groupBys := query["group_bys[]"]
+	byProvider := slices.Contains(groupBys, "provider")
+	byService := slices.Contains(groupBys, "service")
+
+	resp := ForecastResponse{Data: []ForecastRow{}}
+	for _, bucket := range mockBuckets(start, end, query.Get("granularity")) {
+		index := make(map[[2]string]int)
+		for _, item := range t.items {
+			var key [2]string
+			if byProvider {
+				key[0] = item.row.Provider
+			}
+			if byService {
+				key[1] = item.row.Service
+			}
+			n, ok := index[key]
+			if !ok {
+				n = len(resp.Data)
+				index[key] = n
+				resp.Data = append(resp.Data, ForecastRow{
+					BucketStart: bucket.start,
+					BucketEnd:   bucket.end,
+					Currency:    "USD",
+					Provider:    key[0],
+					Service:     key[1],
+				})
+			}
+			for _, day := range bucket.days {
+				resp.Data[n].Cost += t.expectedCost(item, day)
+			}
+		}
+	}
+	for i := range resp.Data {
+		resp.Data[i].Cost = math.Round(resp.Data[i].Cost*100) / 100
+	}
+	return mockResponse(req, http.StatusOK, resp)
+}
+
+// costReport is the mock cost report with the given token.
+func (t *mockTransport) costReport(token string) CostReport {
+	return CostReport{Token: token, Title: "Mock cost report", WorkspaceToken: "wrkspc_mock"}
+}
+
+// integrations lists one freshly synced integration per provider.
+func (t *mockTransport) integrations() integrationsResponse {
+	var resp integrationsResponse
+	synced := t.now().UTC().Truncate(time.Hour)
+	for _, provider := range t.config.Providers {
+		resp.Integrations = append(resp.Integrations, Integration{
+			Token:             "accss_crdntl_mock_" + provider,
+			Provider:          provider,
+			AccountIdentifier: provider + "-billing",
+			Status:            "connected",
+			LastSyncedAt:      synced,
+		})
+	}
+	return resp
+}
+
+// mockError is an API error body.
+func mockError(message string) map[string][]string {
+	return map[string][]string{"errors": {message}}
+}
+
+// mockResponse encodes body as a JSON response to req.
+func mockResponse(req *http.Request, status int, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encoding mock response: %w", err)
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockCosts(t *testing.T, c Client, start, end time.Time, granularity string) []CostRow {
+	t.Helper()
+	rows, err := NewPager(c, Query{
+		CostReportToken: "cr_test",
+		StartAt:         start,
+		EndAt:           end,
+		Granularity:     granularity,
+		PageSize:        7,
+	}, NewNoopLogger()).AllPages(context.Background())
+	require.NoError(t, err)
+	return rows
+}
+
+func TestMockTransport_Costs(t *testing.T) {
+	c, err := New(Config{BaseURL: "mock://?seed=7&providers=aws,gcp&rows_per_bucket=5"})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	rows := mockCosts(t, c, start, start.AddDate(0, 0, 3), "day")
+	require.Len(t, rows, 15)
+	for _, row := range rows {
+		assert.Contains(t, []string{"aws", "gcp"}, row.Provider)
+		assert.NotEmpty(t, row.Service)
+		assert.NotEmpty(t, row.Account)
+		assert.Positive(t, row.Cost)
+		assert.Equal(t, row.BucketStart.AddDate(0, 0, 1), row.BucketEnd)
+	}
+
+	// Overlapping ranges and other clients with the same seed agree.
+	again, err := New(Config{BaseURL: "mock://?seed=7&providers=aws,gcp&rows_per_bucket=5"})
+	require.NoError(t, err)
+	assert.Equal(t, rows[5:10], mockCosts(t, again, start.AddDate(0, 0, 1), start.AddDate(0, 0, 2), "day"))
+
+	other, err := New(Config{BaseURL: "mock://?seed=8&providers=aws,gcp&rows_per_bucket=5"})
+	require.NoError(t, err)
+	assert.NotEqual(t, rows, mockCosts(t, other, start, start.AddDate(0, 0, 3), "day"))
+}
+
+func TestMockTransport_MonthBuckets(t *testing.T) {
+	c, err := New(Config{BaseURL: "mock://?rows_per_bucket=4"})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)
+	months := mockCosts(t, c, start, end, "month")
+	days := mockCosts(t, c, start, end, "day")
+	require.Len(t, months, 8)
+	require.Len(t, days, 16)
+
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), months[0].BucketStart)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), months[4].BucketStart)
+	// January's bucket covers the 30th and 31st only.
+	assert.InDelta(t, days[0].Cost+days[4].Cost, months[0].Cost, 0.011)
+}
+
+func TestMockTransport_Seasonality(t *testing.T) {
+	c, err := New(Config{BaseURL: "mock://?rows_per_bucket=10&daily_cost=700&seasonality=0.5"})
+	require.NoError(t, err)
+
+	// 2024-03-08 is a Friday.
+	friday := time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)
+	total := func(day time.Time) float64 {
+		var sum float64
+		for _, row := range mockCosts(t, c, day, day.AddDate(0, 0, 1), "day") {
+			sum += row.Cost
+		}
+		return sum
+	}
+	assert.InDelta(t, 700, total(friday), 700*mockNoise)
+	assert.InDelta(t, 350, total(friday.AddDate(0, 0, 1)), 350*mockNoise)
+}
+
+func TestMockTransport_Forecast(t *testing.T) {
+	c, err := New(Config{BaseURL: "mock://?providers=aws,azure&daily_cost=100"})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	forecast, err := c.Forecast(context.Background(), "rprt_mock", ForecastQuery{
+		StartAt:     start,
+		EndAt:       start.AddDate(0, 1, 0),
+		Granularity: "month",
+		GroupBys:    []string{"provider"},
+	})
+	require.NoError(t, err)
+	require.Len(t, forecast.Data, 2)
+
+	var total float64
+	for _, row := range forecast.Data {
+		assert.Contains(t, []string{"aws", "azure"}, row.Provider)
+		assert.Empty(t, row.Service)
+		total += row.Cost
+	}
+	assert.InDelta(t, 3000, total, 0.05)
+}
+
+func TestMockTransport_OtherEndpoints(t *testing.T) {
+	c, err := New(Config{BaseURL: "mock://?providers=aws", APIVersion: APIVersionAuto})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	report, err := c.GetCostReport(ctx, "rprt_abc")
+	require.NoError(t, err)
+	assert.Equal(t, "rprt_abc", report.Token)
+
+	integrations, err := c.ListIntegrations(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, integrations, 1)
+	assert.Equal(t, "aws", integrations[0].Provider)
+
+	budgets, err := c.Budgets(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, budgets)
+
+	// Auto negotiation falls back to v1, the format the mock generates.
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	assert.Len(t, mockCosts(t, c, start, start.AddDate(0, 0, 1), "day"), defaultMockRowsPerBucket)
+
+	resp, err := NewMockTransport(MockConfig{}).RoundTrip(
+		httptestRequest(t, http.MethodPost, "mock://vantage/cost_reports"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestParseMockURL(t *testing.T) {
+	config, err := ParseMockURL("mock://?seed=3&providers=aws,%20gcp&services=EC2&accounts=4" +
+		"&rows_per_bucket=9&daily_cost=12.5&seasonality=0.3")
+	require.NoError(t, err)
+	assert.Equal(t, MockConfig{
+		Seed:          3,
+		Providers:     []string{"aws", "gcp"},
+		Services:      []string{"EC2"},
+		Accounts:      4,
+		RowsPerBucket: 9,
+		DailyCost:     12.5,
+		Seasonality:   0.3,
+	}, config)
+
+	_, err = ParseMockURL("mock://?rows=3")
+	assert.ErrorContains(t, err, `unknown parameter "rows"`)
+	_, err = ParseMockURL("mock://?seed=abc")
+	assert.ErrorContains(t, err, "invalid mock URL parameter seed")
+	_, err = ParseMockURL("https://api.vantage.sh")
+	assert.ErrorContains(t, err, "scheme must be mock")
+}
+
+func httptestRequest(t *testing.T, method, target string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, target, nil)
+	require.NoError(t, err)
+	return req
+}
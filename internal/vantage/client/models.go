@@ -15,6 +15,13 @@ type Query struct {
 	Metrics         []string  `json:"metrics"`
 	PageSize        int       `json:"page_size,omitempty"`
 	Cursor          string    `json:"cursor,omitempty"`
+	// Filter is a VQL expression narrowing the costs returned, e.g.
+	// "costs.provider = 'aws' AND costs.service = 'AmazonEC2'".
+	Filter string `json:"filter,omitempty"`
+	// IncludeUnallocated asks for spend that cost allocation could not
+	// attribute, such as untagged or shared costs, as extra rows marked
+	// CostRow.Unallocated.
+	IncludeUnallocated bool `json:"include_unallocated,omitempty"`
 }
 
 // ForecastQuery represents parameters for the /forecast endpoint.
@@ -22,13 +29,27 @@ type ForecastQuery struct {
 	StartAt     time.Time `json:"start_at"`
 	EndAt       time.Time `json:"end_at"`
 	Granularity string    `json:"granularity"` // "day" or "month"
+	// GroupBys splits the forecast by dimensions; only those in
+	// SupportedForecastGroupBys are accepted by the API.
+	GroupBys []string `json:"group_bys,omitempty"`
 }
 
-// CostRow represents a single cost data row from Vantage.
+// SupportedForecastGroupBys returns the dimensions a forecast can be grouped
+// by.
+func SupportedForecastGroupBys() []string {
+	return []string{"provider", "service"}
+}
+
+// CostRow represents a single cost data row from Vantage. Account is the
+// account the spend was incurred in (an AWS linked account, an Azure
+// subscription, or a GCP billing account) and BillingAccount the account
+// invoiced for it (an AWS payer account or an Azure billing account), when
+// Vantage reports one.
 type CostRow struct {
 	Provider           string            `json:"provider,omitempty"`
 	Service            string            `json:"service,omitempty"`
 	Account            string            `json:"account,omitempty"`
+	BillingAccount     string            `json:"billing_account,omitempty"`
 	Project            string            `json:"project,omitempty"`
 	Region             string            `json:"region,omitempty"`
 	ResourceID         string            `json:"resource_id,omitempty"`
@@ -45,6 +66,12 @@ type CostRow struct {
 	Currency           string            `json:"currency,omitempty"`
 	BucketStart        time.Time         `json:"bucket_start"`
 	BucketEnd          time.Time         `json:"bucket_end"`
+	// Unallocated marks a row of spend that cost allocation could not
+	// attribute; only returned when Query.IncludeUnallocated is set.
+	Unallocated bool `json:"unallocated,omitempty"`
+	// Extra holds response fields this client does not map, keyed by their
+	// JSON name.
+	Extra map[string]interface{} `json:"-"`
 }
 
 // CostsResponse represents the response from /costs endpoint.
@@ -60,6 +87,12 @@ type ForecastRow struct {
 	BucketEnd   time.Time `json:"bucket_end"`
 	Cost        float64   `json:"cost"`
 	Currency    string    `json:"currency,omitempty"`
+	// Group dimensions, set when ForecastQuery.GroupBys requested them.
+	Provider string `json:"provider,omitempty"`
+	Service  string `json:"service,omitempty"`
+	// Extra holds response fields this client does not map, keyed by their
+	// JSON name.
+	Extra map[string]interface{} `json:"-"`
 }
 
 // ForecastResponse represents the response from /forecast endpoint.
@@ -72,9 +105,164 @@ type Page struct {
 	Data       []CostRow
 	NextCursor string
 	HasMore    bool
+	// Attempts is how many times the pager requested the page; more than
+	// one means it was retried after a failure.
+	Attempts int
 }
 
 // Forecast represents forecast data.
 type Forecast struct {
 	Data []ForecastRow
 }
+
+// CostReport represents a Vantage cost report.
+type CostReport struct {
+	Token             string    `json:"token"`
+	Title             string    `json:"title"`
+	FolderToken       string    `json:"folder_token,omitempty"`
+	WorkspaceToken    string    `json:"workspace_token,omitempty"`
+	Filter            string    `json:"filter,omitempty"`
+	SavedFilterTokens []string  `json:"saved_filter_tokens,omitempty"`
+	Groupings         string    `json:"groupings,omitempty"`
+	CreatedAt         time.Time `json:"created_at,omitempty"`
+}
+
+// CreateCostReportRequest represents the body of a POST /cost_reports request.
+type CreateCostReportRequest struct {
+	Title             string   `json:"title"`
+	WorkspaceToken    string   `json:"workspace_token,omitempty"`
+	FolderToken       string   `json:"folder_token,omitempty"`
+	Filter            string   `json:"filter,omitempty"`
+	SavedFilterTokens []string `json:"saved_filter_tokens,omitempty"`
+	Groupings         string   `json:"groupings,omitempty"`
+}
+
+// pageLinks holds the pagination links returned by list endpoints.
+type pageLinks struct {
+	Next string `json:"next,omitempty"`
+}
+
+// costReportsResponse represents the response from /cost_reports endpoint.
+type costReportsResponse struct {
+	CostReports []CostReport `json:"cost_reports"`
+	Links       pageLinks    `json:"links"`
+}
+
+// Workspace represents a Vantage workspace.
+type Workspace struct {
+	Token     string    `json:"token"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// workspacesResponse represents the response from /workspaces endpoint.
+type workspacesResponse struct {
+	Workspaces []Workspace `json:"workspaces"`
+	Links      pageLinks   `json:"links"`
+}
+
+// Budget represents a Vantage budget attached to a cost report.
+type Budget struct {
+	Token           string              `json:"token"`
+	Name            string              `json:"name"`
+	CostReportToken string              `json:"cost_report_token,omitempty"`
+	WorkspaceToken  string              `json:"workspace_token,omitempty"`
+	Currency        string              `json:"currency,omitempty"`
+	Periods         []BudgetPeriod      `json:"periods,omitempty"`
+	Performance     []BudgetPerformance `json:"performance,omitempty"`
+}
+
+// BudgetPeriod is a budgeted amount for a time period.
+type BudgetPeriod struct {
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+	Amount  float64   `json:"amount"`
+}
+
+// BudgetPerformance reports actual spend against the budget at a point in time.
+type BudgetPerformance struct {
+	Date   time.Time `json:"date"`
+	Actual float64   `json:"actual"`
+	Amount float64   `json:"amount"`
+}
+
+// budgetsResponse represents the response from /budgets endpoint.
+type budgetsResponse struct {
+	Budgets []Budget  `json:"budgets"`
+	Links   pageLinks `json:"links"`
+}
+
+// Recommendation categories accepted by the /recommendations endpoint.
+const (
+	RecommendationCategoryReservedInstances = "reserved_instances"
+	RecommendationCategorySavingsPlans      = "savings_plans"
+	RecommendationCategoryRightsizing       = "rightsizing"
+)
+
+// RecommendationQuery represents filters for the /recommendations endpoint.
+type RecommendationQuery struct {
+	WorkspaceToken string `json:"workspace_token,omitempty"`
+	Provider       string `json:"provider,omitempty"`
+	Category       string `json:"category,omitempty"`
+}
+
+// Recommendation represents a Vantage savings or commitment recommendation.
+type Recommendation struct {
+	Token             string    `json:"token"`
+	Category          string    `json:"category"`
+	Provider          string    `json:"provider,omitempty"`
+	ProviderAccountID string    `json:"provider_account_id,omitempty"`
+	Service           string    `json:"service,omitempty"`
+	ResourceID        string    `json:"resource_id,omitempty"`
+	Description       string    `json:"description,omitempty"`
+	PotentialSavings  float64   `json:"potential_savings"` // estimated monthly savings
+	Currency          string    `json:"currency,omitempty"`
+	CreatedAt         time.Time `json:"created_at,omitempty"`
+}
+
+// recommendationsResponse represents the response from /recommendations endpoint.
+type recommendationsResponse struct {
+	Recommendations []Recommendation `json:"recommendations"`
+	Links           pageLinks        `json:"links"`
+}
+
+// Tag represents a tag key available in a workspace.
+type Tag struct {
+	Key       string   `json:"tag_key"`
+	Hidden    bool     `json:"hidden,omitempty"`
+	Providers []string `json:"providers,omitempty"`
+}
+
+// TagValue represents a value seen for a tag key.
+type TagValue struct {
+	Value     string   `json:"tag_value"`
+	Providers []string `json:"providers,omitempty"`
+}
+
+// tagsResponse represents the response from /tags endpoint.
+type tagsResponse struct {
+	Tags  []Tag     `json:"tags"`
+	Links pageLinks `json:"links"`
+}
+
+// tagValuesResponse represents the response from /tags/{key}/values endpoint.
+type tagValuesResponse struct {
+	TagValues []TagValue `json:"tag_values"`
+	Links     pageLinks  `json:"links"`
+}
+
+// Integration represents a provider account connected to Vantage.
+type Integration struct {
+	Token             string    `json:"token"`
+	Provider          string    `json:"provider"`
+	AccountIdentifier string    `json:"account_identifier,omitempty"`
+	Status            string    `json:"status"`
+	LastSyncedAt      time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt         time.Time `json:"created_at,omitempty"`
+}
+
+// integrationsResponse represents the response from /integrations endpoint.
+type integrationsResponse struct {
+	Integrations []Integration `json:"integrations"`
+	Links        pageLinks     `json:"links"`
+}
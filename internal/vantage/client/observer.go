@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// RequestObserver is told about every API call the client makes, so request
+// metrics can be collected without the client depending on a metrics
+// library. ObserveRequest is called once per call, after its retries, and
+// may be called concurrently.
+type RequestObserver interface {
+	ObserveRequest(ctx context.Context, request ObservedRequest)
+}
+
+// ObservedRequest describes one finished API call.
+type ObservedRequest struct {
+	Method string
+	// Endpoint names the call by its operation, such as "costs_request";
+	// paths are not used since they carry report tokens.
+	Endpoint string
+	// Status is the HTTP status of the last response the API sent, or zero
+	// when none was received: a network error, or a response served from
+	// the cache without revalidation.
+	Status int
+	// Latency is the whole call, retries and backoff included.
+	Latency time.Duration
+	Retries int
+	Err     error
+}
+
+// observedCallKey is the context key of the call a request belongs to.
+type observedCallKey struct{}
+
+// observedCall collects what the observer is told about one call. Attempts
+// of a call run one after another, so its fields need no locking.
+type observedCall struct {
+	// ctx is the caller's context, before any operation timeout.
+	ctx      context.Context
+	observer RequestObserver
+	request  ObservedRequest
+	start    time.Time
+}
+
+// startCall begins observing a call, returning a context that carries it
+// to sendOnce. The call is nil, and its methods no-ops, without an
+// observer.
+func (c *httpClient) startCall(ctx context.Context, method, endpoint string) (context.Context, *observedCall) {
+	if c.observer == nil {
+		return ctx, nil
+	}
+	call := &observedCall{
+		ctx:      ctx,
+		observer: c.observer,
+		request:  ObservedRequest{Method: method, Endpoint: endpoint},
+		start:    time.Now(),
+	}
+	return context.WithValue(ctx, observedCallKey{}, call), call
+}
+
+// setRetries records how many retries the call has made so far.
+func (call *observedCall) setRetries(retries int) {
+	if call != nil {
+		call.request.Retries = retries
+	}
+}
+
+// finish reports the call with its outcome.
+func (call *observedCall) finish(err error) {
+	if call == nil {
+		return
+	}
+	call.request.Latency = time.Since(call.start)
+	call.request.Err = err
+	call.observer.ObserveRequest(call.ctx, call.request)
+}
+
+// observeStatus records status on the call ctx belongs to, if any.
+func observeStatus(ctx context.Context, status int) {
+	if call, ok := ctx.Value(observedCallKey{}).(*observedCall); ok {
+		call.request.Status = status
+	}
+}
+
+// observers tells each of its observers about every call.
+type observers []RequestObserver
+
+// Observers returns a RequestObserver that tells each non-nil observer
+// about every call, or nil when there are none.
+func Observers(list ...RequestObserver) RequestObserver {
+	var all observers
+	for _, observer := range list {
+		if observer != nil {
+			all = append(all, observer)
+		}
+	}
+	switch len(all) {
+	case 0:
+		return nil
+	case 1:
+		return all[0]
+	}
+	return all
+}
+
+// ObserveRequest implements RequestObserver.
+func (o observers) ObserveRequest(ctx context.Context, request ObservedRequest) {
+	for _, observer := range o {
+		observer.ObserveRequest(ctx, request)
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver keeps every observed call.
+type recordingObserver struct {
+	mu       sync.Mutex
+	requests []ObservedRequest
+}
+
+func (o *recordingObserver) ObserveRequest(_ context.Context, request ObservedRequest) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests = append(o.requests, request)
+}
+
+func TestClient_Observer(t *testing.T) {
+	costsCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/costs":
+			costsCalls++
+			if costsCalls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	c, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		Timeout:    5 * time.Second,
+		MaxRetries: 1,
+		Logger:     NewNoopLogger(),
+		Observer:   Observers(nil, observer),
+	})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.NoError(t, err)
+	require.Error(t, c.Ping(context.Background()))
+
+	require.Len(t, observer.requests, 2)
+
+	costs := observer.requests[0]
+	assert.Equal(t, http.MethodGet, costs.Method)
+	assert.Equal(t, "costs_request", costs.Endpoint)
+	assert.Equal(t, http.StatusOK, costs.Status)
+	assert.Equal(t, 1, costs.Retries)
+	assert.Positive(t, costs.Latency)
+	assert.NoError(t, costs.Err)
+
+	ping := observer.requests[1]
+	assert.Equal(t, "ping_request", ping.Endpoint)
+	assert.Equal(t, http.StatusNotFound, ping.Status)
+	assert.Zero(t, ping.Retries)
+	assert.Error(t, ping.Err)
+}
+
+func TestObservers(t *testing.T) {
+	assert.Nil(t, Observers())
+	assert.Nil(t, Observers(nil, nil))
+
+	first, second := &recordingObserver{}, &recordingObserver{}
+	assert.Same(t, first, Observers(nil, first))
+
+	Observers(first, second).ObserveRequest(context.Background(), ObservedRequest{Endpoint: "ping_request"})
+	assert.Len(t, first.requests, 1)
+	assert.Len(t, second.requests, 1)
+}
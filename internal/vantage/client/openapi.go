@@ -0,0 +1,21 @@
+package client
+
+import "errors"
+
+// Client kinds accepted by Config.Kind. ClientKindOpenAPI sends costs and
+// forecast requests through the client generated from Vantage's OpenAPI
+// spec (see the openapi package); it speaks the v2 API and is only built
+// into binaries built with -tags openapi.
+const (
+	ClientKindHandwritten = "handwritten"
+	ClientKindOpenAPI     = "openapi"
+)
+
+// ErrOpenAPINotBuilt is returned by New for ClientKindOpenAPI when the
+// binary was built without the openapi tag.
+var ErrOpenAPINotBuilt = errors.New("the openapi client is not built in; build with -tags openapi")
+
+// SupportedClientKinds returns the accepted Config.Kind values.
+func SupportedClientKinds() []string {
+	return []string{ClientKindHandwritten, ClientKindOpenAPI}
+}
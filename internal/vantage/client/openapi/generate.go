@@ -0,0 +1,6 @@
+// Package openapi is the Vantage API client generated from the OpenAPI spec
+// in vantage-v2.yaml. The client package wraps it, behind the openapi build
+// tag, as an alternative to the handwritten client.
+package openapi
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.5.1 -config config.yaml vantage-v2.yaml
@@ -0,0 +1,1160 @@
+// Package openapi provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.1 DO NOT EDIT.
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for GetForecastedCostsParamsDateBin.
+const (
+	GetForecastedCostsParamsDateBinDay   GetForecastedCostsParamsDateBin = "day"
+	GetForecastedCostsParamsDateBinMonth GetForecastedCostsParamsDateBin = "month"
+	GetForecastedCostsParamsDateBinWeek  GetForecastedCostsParamsDateBin = "week"
+)
+
+// Defines values for GetCostsParamsDateBin.
+const (
+	GetCostsParamsDateBinDay   GetCostsParamsDateBin = "day"
+	GetCostsParamsDateBinMonth GetCostsParamsDateBin = "month"
+	GetCostsParamsDateBinWeek  GetCostsParamsDateBin = "week"
+)
+
+// Amount Decimal amount, sent as a string.
+type Amount = json.Number
+
+// Cost defines model for Cost.
+type Cost struct {
+	AccountId *string            `json:"account_id,omitempty"`
+	AccruedAt openapi_types.Date `json:"accrued_at"`
+
+	// AmortizedAmount Decimal amount, sent as a string.
+	AmortizedAmount *Amount `json:"amortized_amount,omitempty"`
+
+	// Amount Decimal amount, sent as a string.
+	Amount           *Amount `json:"amount,omitempty"`
+	BillingAccountId *string `json:"billing_account_id,omitempty"`
+
+	// CreditAmount Decimal amount, sent as a string.
+	CreditAmount *Amount `json:"credit_amount,omitempty"`
+	Currency     *string `json:"currency,omitempty"`
+
+	// ListAmount Decimal amount, sent as a string.
+	ListAmount *Amount `json:"list_amount,omitempty"`
+	Project    *string `json:"project,omitempty"`
+	Provider   *string `json:"provider,omitempty"`
+
+	// RefundAmount Decimal amount, sent as a string.
+	RefundAmount *Amount            `json:"refund_amount,omitempty"`
+	Region       *string            `json:"region,omitempty"`
+	ResourceId   *string            `json:"resource_id,omitempty"`
+	Service      *string            `json:"service,omitempty"`
+	Tags         *map[string]string `json:"tags,omitempty"`
+
+	// TaxAmount Decimal amount, sent as a string.
+	TaxAmount   *Amount `json:"tax_amount,omitempty"`
+	Unallocated *bool   `json:"unallocated,omitempty"`
+
+	// UsageQuantity Decimal amount, sent as a string.
+	UsageQuantity        *Amount                `json:"usage_quantity,omitempty"`
+	UsageUnit            *string                `json:"usage_unit,omitempty"`
+	AdditionalProperties map[string]interface{} `json:"-"`
+}
+
+// Costs defines model for Costs.
+type Costs struct {
+	Costs []Cost `json:"costs"`
+	Links *Links `json:"links,omitempty"`
+}
+
+// ForecastedCost defines model for ForecastedCost.
+type ForecastedCost struct {
+	// Amount Decimal amount, sent as a string.
+	Amount               *Amount                `json:"amount,omitempty"`
+	Currency             *string                `json:"currency,omitempty"`
+	Date                 openapi_types.Date     `json:"date"`
+	Provider             *string                `json:"provider,omitempty"`
+	Service              *string                `json:"service,omitempty"`
+	AdditionalProperties map[string]interface{} `json:"-"`
+}
+
+// ForecastedCosts defines model for ForecastedCosts.
+type ForecastedCosts struct {
+	ForecastedCosts []ForecastedCost `json:"forecasted_costs"`
+	Links           *Links           `json:"links,omitempty"`
+}
+
+// Links defines model for Links.
+type Links struct {
+	Next *string `json:"next"`
+}
+
+// GetForecastedCostsParams defines parameters for GetForecastedCosts.
+type GetForecastedCostsParams struct {
+	StartDate openapi_types.Date               `form:"start_date" json:"start_date"`
+	EndDate   openapi_types.Date               `form:"end_date" json:"end_date"`
+	DateBin   *GetForecastedCostsParamsDateBin `form:"date_bin,omitempty" json:"date_bin,omitempty"`
+	Groupings *[]string                        `form:"groupings,omitempty" json:"groupings,omitempty"`
+}
+
+// GetForecastedCostsParamsDateBin defines parameters for GetForecastedCosts.
+type GetForecastedCostsParamsDateBin string
+
+// GetCostsParams defines parameters for GetCosts.
+type GetCostsParams struct {
+	WorkspaceToken                  *string                `form:"workspace_token,omitempty" json:"workspace_token,omitempty"`
+	CostReportToken                 *string                `form:"cost_report_token,omitempty" json:"cost_report_token,omitempty"`
+	StartDate                       openapi_types.Date     `form:"start_date" json:"start_date"`
+	EndDate                         openapi_types.Date     `form:"end_date" json:"end_date"`
+	DateBin                         *GetCostsParamsDateBin `form:"date_bin,omitempty" json:"date_bin,omitempty"`
+	Groupings                       *[]string              `form:"groupings,omitempty" json:"groupings,omitempty"`
+	Metrics                         *[]string              `form:"metrics,omitempty" json:"metrics,omitempty"`
+	Limit                           *int                   `form:"limit,omitempty" json:"limit,omitempty"`
+	Page                            *string                `form:"page,omitempty" json:"page,omitempty"`
+	Filter                          *string                `form:"filter,omitempty" json:"filter,omitempty"`
+	SettingsIncludeUnallocatedCosts *bool                  `form:"settings[include_unallocated_costs],omitempty" json:"settings[include_unallocated_costs],omitempty"`
+}
+
+// GetCostsParamsDateBin defines parameters for GetCosts.
+type GetCostsParamsDateBin string
+
+// Getter for additional properties for Cost. Returns the specified
+// element and whether it was found
+func (a Cost) Get(fieldName string) (value interface{}, found bool) {
+	if a.AdditionalProperties != nil {
+		value, found = a.AdditionalProperties[fieldName]
+	}
+	return
+}
+
+// Setter for additional properties for Cost
+func (a *Cost) Set(fieldName string, value interface{}) {
+	if a.AdditionalProperties == nil {
+		a.AdditionalProperties = make(map[string]interface{})
+	}
+	a.AdditionalProperties[fieldName] = value
+}
+
+// Override default JSON handling for Cost to handle AdditionalProperties
+func (a *Cost) UnmarshalJSON(b []byte) error {
+	object := make(map[string]json.RawMessage)
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+
+	if raw, found := object["account_id"]; found {
+		err = json.Unmarshal(raw, &a.AccountId)
+		if err != nil {
+			return fmt.Errorf("error reading 'account_id': %w", err)
+		}
+		delete(object, "account_id")
+	}
+
+	if raw, found := object["accrued_at"]; found {
+		err = json.Unmarshal(raw, &a.AccruedAt)
+		if err != nil {
+			return fmt.Errorf("error reading 'accrued_at': %w", err)
+		}
+		delete(object, "accrued_at")
+	}
+
+	if raw, found := object["amortized_amount"]; found {
+		err = json.Unmarshal(raw, &a.AmortizedAmount)
+		if err != nil {
+			return fmt.Errorf("error reading 'amortized_amount': %w", err)
+		}
+		delete(object, "amortized_amount")
+	}
+
+	if raw, found := object["amount"]; found {
+		err = json.Unmarshal(raw, &a.Amount)
+		if err != nil {
+			return fmt.Errorf("error reading 'amount': %w", err)
+		}
+		delete(object, "amount")
+	}
+
+	if raw, found := object["billing_account_id"]; found {
+		err = json.Unmarshal(raw, &a.BillingAccountId)
+		if err != nil {
+			return fmt.Errorf("error reading 'billing_account_id': %w", err)
+		}
+		delete(object, "billing_account_id")
+	}
+
+	if raw, found := object["credit_amount"]; found {
+		err = json.Unmarshal(raw, &a.CreditAmount)
+		if err != nil {
+			return fmt.Errorf("error reading 'credit_amount': %w", err)
+		}
+		delete(object, "credit_amount")
+	}
+
+	if raw, found := object["currency"]; found {
+		err = json.Unmarshal(raw, &a.Currency)
+		if err != nil {
+			return fmt.Errorf("error reading 'currency': %w", err)
+		}
+		delete(object, "currency")
+	}
+
+	if raw, found := object["list_amount"]; found {
+		err = json.Unmarshal(raw, &a.ListAmount)
+		if err != nil {
+			return fmt.Errorf("error reading 'list_amount': %w", err)
+		}
+		delete(object, "list_amount")
+	}
+
+	if raw, found := object["project"]; found {
+		err = json.Unmarshal(raw, &a.Project)
+		if err != nil {
+			return fmt.Errorf("error reading 'project': %w", err)
+		}
+		delete(object, "project")
+	}
+
+	if raw, found := object["provider"]; found {
+		err = json.Unmarshal(raw, &a.Provider)
+		if err != nil {
+			return fmt.Errorf("error reading 'provider': %w", err)
+		}
+		delete(object, "provider")
+	}
+
+	if raw, found := object["refund_amount"]; found {
+		err = json.Unmarshal(raw, &a.RefundAmount)
+		if err != nil {
+			return fmt.Errorf("error reading 'refund_amount': %w", err)
+		}
+		delete(object, "refund_amount")
+	}
+
+	if raw, found := object["region"]; found {
+		err = json.Unmarshal(raw, &a.Region)
+		if err != nil {
+			return fmt.Errorf("error reading 'region': %w", err)
+		}
+		delete(object, "region")
+	}
+
+	if raw, found := object["resource_id"]; found {
+		err = json.Unmarshal(raw, &a.ResourceId)
+		if err != nil {
+			return fmt.Errorf("error reading 'resource_id': %w", err)
+		}
+		delete(object, "resource_id")
+	}
+
+	if raw, found := object["service"]; found {
+		err = json.Unmarshal(raw, &a.Service)
+		if err != nil {
+			return fmt.Errorf("error reading 'service': %w", err)
+		}
+		delete(object, "service")
+	}
+
+	if raw, found := object["tags"]; found {
+		err = json.Unmarshal(raw, &a.Tags)
+		if err != nil {
+			return fmt.Errorf("error reading 'tags': %w", err)
+		}
+		delete(object, "tags")
+	}
+
+	if raw, found := object["tax_amount"]; found {
+		err = json.Unmarshal(raw, &a.TaxAmount)
+		if err != nil {
+			return fmt.Errorf("error reading 'tax_amount': %w", err)
+		}
+		delete(object, "tax_amount")
+	}
+
+	if raw, found := object["unallocated"]; found {
+		err = json.Unmarshal(raw, &a.Unallocated)
+		if err != nil {
+			return fmt.Errorf("error reading 'unallocated': %w", err)
+		}
+		delete(object, "unallocated")
+	}
+
+	if raw, found := object["usage_quantity"]; found {
+		err = json.Unmarshal(raw, &a.UsageQuantity)
+		if err != nil {
+			return fmt.Errorf("error reading 'usage_quantity': %w", err)
+		}
+		delete(object, "usage_quantity")
+	}
+
+	if raw, found := object["usage_unit"]; found {
+		err = json.Unmarshal(raw, &a.UsageUnit)
+		if err != nil {
+			return fmt.Errorf("error reading 'usage_unit': %w", err)
+		}
+		delete(object, "usage_unit")
+	}
+
+	if len(object) != 0 {
+		a.AdditionalProperties = make(map[string]interface{})
+		for fieldName, fieldBuf := range object {
+			var fieldVal interface{}
+			err := json.Unmarshal(fieldBuf, &fieldVal)
+			if err != nil {
+				return fmt.Errorf("error unmarshaling field %s: %w", fieldName, err)
+			}
+			a.AdditionalProperties[fieldName] = fieldVal
+		}
+	}
+	return nil
+}
+
+// Override default JSON handling for Cost to handle AdditionalProperties
+func (a Cost) MarshalJSON() ([]byte, error) {
+	var err error
+	object := make(map[string]json.RawMessage)
+
+	if a.AccountId != nil {
+		object["account_id"], err = json.Marshal(a.AccountId)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'account_id': %w", err)
+		}
+	}
+
+	object["accrued_at"], err = json.Marshal(a.AccruedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling 'accrued_at': %w", err)
+	}
+
+	if a.AmortizedAmount != nil {
+		object["amortized_amount"], err = json.Marshal(a.AmortizedAmount)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'amortized_amount': %w", err)
+		}
+	}
+
+	if a.Amount != nil {
+		object["amount"], err = json.Marshal(a.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'amount': %w", err)
+		}
+	}
+
+	if a.BillingAccountId != nil {
+		object["billing_account_id"], err = json.Marshal(a.BillingAccountId)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'billing_account_id': %w", err)
+		}
+	}
+
+	if a.CreditAmount != nil {
+		object["credit_amount"], err = json.Marshal(a.CreditAmount)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'credit_amount': %w", err)
+		}
+	}
+
+	if a.Currency != nil {
+		object["currency"], err = json.Marshal(a.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'currency': %w", err)
+		}
+	}
+
+	if a.ListAmount != nil {
+		object["list_amount"], err = json.Marshal(a.ListAmount)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'list_amount': %w", err)
+		}
+	}
+
+	if a.Project != nil {
+		object["project"], err = json.Marshal(a.Project)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'project': %w", err)
+		}
+	}
+
+	if a.Provider != nil {
+		object["provider"], err = json.Marshal(a.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'provider': %w", err)
+		}
+	}
+
+	if a.RefundAmount != nil {
+		object["refund_amount"], err = json.Marshal(a.RefundAmount)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'refund_amount': %w", err)
+		}
+	}
+
+	if a.Region != nil {
+		object["region"], err = json.Marshal(a.Region)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'region': %w", err)
+		}
+	}
+
+	if a.ResourceId != nil {
+		object["resource_id"], err = json.Marshal(a.ResourceId)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'resource_id': %w", err)
+		}
+	}
+
+	if a.Service != nil {
+		object["service"], err = json.Marshal(a.Service)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'service': %w", err)
+		}
+	}
+
+	if a.Tags != nil {
+		object["tags"], err = json.Marshal(a.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'tags': %w", err)
+		}
+	}
+
+	if a.TaxAmount != nil {
+		object["tax_amount"], err = json.Marshal(a.TaxAmount)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'tax_amount': %w", err)
+		}
+	}
+
+	if a.Unallocated != nil {
+		object["unallocated"], err = json.Marshal(a.Unallocated)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'unallocated': %w", err)
+		}
+	}
+
+	if a.UsageQuantity != nil {
+		object["usage_quantity"], err = json.Marshal(a.UsageQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'usage_quantity': %w", err)
+		}
+	}
+
+	if a.UsageUnit != nil {
+		object["usage_unit"], err = json.Marshal(a.UsageUnit)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'usage_unit': %w", err)
+		}
+	}
+
+	for fieldName, field := range a.AdditionalProperties {
+		object[fieldName], err = json.Marshal(field)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling '%s': %w", fieldName, err)
+		}
+	}
+	return json.Marshal(object)
+}
+
+// Getter for additional properties for ForecastedCost. Returns the specified
+// element and whether it was found
+func (a ForecastedCost) Get(fieldName string) (value interface{}, found bool) {
+	if a.AdditionalProperties != nil {
+		value, found = a.AdditionalProperties[fieldName]
+	}
+	return
+}
+
+// Setter for additional properties for ForecastedCost
+func (a *ForecastedCost) Set(fieldName string, value interface{}) {
+	if a.AdditionalProperties == nil {
+		a.AdditionalProperties = make(map[string]interface{})
+	}
+	a.AdditionalProperties[fieldName] = value
+}
+
+// Override default JSON handling for ForecastedCost to handle AdditionalProperties
+func (a *ForecastedCost) UnmarshalJSON(b []byte) error {
+	object := make(map[string]json.RawMessage)
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+
+	if raw, found := object["amount"]; found {
+		err = json.Unmarshal(raw, &a.Amount)
+		if err != nil {
+			return fmt.Errorf("error reading 'amount': %w", err)
+		}
+		delete(object, "amount")
+	}
+
+	if raw, found := object["currency"]; found {
+		err = json.Unmarshal(raw, &a.Currency)
+		if err != nil {
+			return fmt.Errorf("error reading 'currency': %w", err)
+		}
+		delete(object, "currency")
+	}
+
+	if raw, found := object["date"]; found {
+		err = json.Unmarshal(raw, &a.Date)
+		if err != nil {
+			return fmt.Errorf("error reading 'date': %w", err)
+		}
+		delete(object, "date")
+	}
+
+	if raw, found := object["provider"]; found {
+		err = json.Unmarshal(raw, &a.Provider)
+		if err != nil {
+			return fmt.Errorf("error reading 'provider': %w", err)
+		}
+		delete(object, "provider")
+	}
+
+	if raw, found := object["service"]; found {
+		err = json.Unmarshal(raw, &a.Service)
+		if err != nil {
+			return fmt.Errorf("error reading 'service': %w", err)
+		}
+		delete(object, "service")
+	}
+
+	if len(object) != 0 {
+		a.AdditionalProperties = make(map[string]interface{})
+		for fieldName, fieldBuf := range object {
+			var fieldVal interface{}
+			err := json.Unmarshal(fieldBuf, &fieldVal)
+			if err != nil {
+				return fmt.Errorf("error unmarshaling field %s: %w", fieldName, err)
+			}
+			a.AdditionalProperties[fieldName] = fieldVal
+		}
+	}
+	return nil
+}
+
+// Override default JSON handling for ForecastedCost to handle AdditionalProperties
+func (a ForecastedCost) MarshalJSON() ([]byte, error) {
+	var err error
+	object := make(map[string]json.RawMessage)
+
+	if a.Amount != nil {
+		object["amount"], err = json.Marshal(a.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'amount': %w", err)
+		}
+	}
+
+	if a.Currency != nil {
+		object["currency"], err = json.Marshal(a.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'currency': %w", err)
+		}
+	}
+
+	object["date"], err = json.Marshal(a.Date)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling 'date': %w", err)
+	}
+
+	if a.Provider != nil {
+		object["provider"], err = json.Marshal(a.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'provider': %w", err)
+		}
+	}
+
+	if a.Service != nil {
+		object["service"], err = json.Marshal(a.Service)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'service': %w", err)
+		}
+	}
+
+	for fieldName, field := range a.AdditionalProperties {
+		object[fieldName], err = json.Marshal(field)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling '%s': %w", fieldName, err)
+		}
+	}
+	return json.Marshal(object)
+}
+
+// RequestEditorFn  is the function signature for the RequestEditor callback function
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// Doer performs HTTP requests.
+//
+// The standard http.Client implements this interface.
+type HttpRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client which conforms to the OpenAPI3 specification for this service.
+type Client struct {
+	// The endpoint of the server conforming to this interface, with scheme,
+	// https://api.deepmap.com for example. This can contain a path relative
+	// to the server, such as https://api.deepmap.com/dev-test, and all the
+	// paths in the swagger spec will be appended to the server.
+	Server string
+
+	// Doer for performing requests, typically a *http.Client with any
+	// customized settings, such as certificate chains.
+	Client HttpRequestDoer
+
+	// A list of callbacks for modifying requests which are generated before sending over
+	// the network.
+	RequestEditors []RequestEditorFn
+}
+
+// ClientOption allows setting custom parameters during construction
+type ClientOption func(*Client) error
+
+// Creates a new Client, with reasonable defaults
+func NewClient(server string, opts ...ClientOption) (*Client, error) {
+	// create a client with sane default values
+	client := Client{
+		Server: server,
+	}
+	// mutate client and add all optional params
+	for _, o := range opts {
+		if err := o(&client); err != nil {
+			return nil, err
+		}
+	}
+	// ensure the server URL always has a trailing slash
+	if !strings.HasSuffix(client.Server, "/") {
+		client.Server += "/"
+	}
+	// create httpClient, if not already present
+	if client.Client == nil {
+		client.Client = &http.Client{}
+	}
+	return &client, nil
+}
+
+// WithHTTPClient allows overriding the default Doer, which is
+// automatically created using http.Client. This is useful for tests.
+func WithHTTPClient(doer HttpRequestDoer) ClientOption {
+	return func(c *Client) error {
+		c.Client = doer
+		return nil
+	}
+}
+
+// WithRequestEditorFn allows setting up a callback function, which will be
+// called right before sending the request. This can be used to mutate the request.
+func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
+	return func(c *Client) error {
+		c.RequestEditors = append(c.RequestEditors, fn)
+		return nil
+	}
+}
+
+// The interface specification for the client above.
+type ClientInterface interface {
+	// GetForecastedCosts request
+	GetForecastedCosts(ctx context.Context, costReportToken string, params *GetForecastedCostsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetCosts request
+	GetCosts(ctx context.Context, params *GetCostsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+}
+
+func (c *Client) GetForecastedCosts(ctx context.Context, costReportToken string, params *GetForecastedCostsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetForecastedCostsRequest(c.Server, costReportToken, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetCosts(ctx context.Context, params *GetCostsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetCostsRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+// NewGetForecastedCostsRequest generates requests for GetForecastedCosts
+func NewGetForecastedCostsRequest(server string, costReportToken string, params *GetForecastedCostsParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "cost_report_token", runtime.ParamLocationPath, costReportToken)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/v2/cost_reports/%s/forecasted_costs", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "start_date", runtime.ParamLocationQuery, params.StartDate); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "end_date", runtime.ParamLocationQuery, params.EndDate); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		if params.DateBin != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "date_bin", runtime.ParamLocationQuery, *params.DateBin); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Groupings != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", false, "groupings", runtime.ParamLocationQuery, *params.Groupings); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetCostsRequest generates requests for GetCosts
+func NewGetCostsRequest(server string, params *GetCostsParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/v2/costs")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.WorkspaceToken != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "workspace_token", runtime.ParamLocationQuery, *params.WorkspaceToken); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.CostReportToken != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "cost_report_token", runtime.ParamLocationQuery, *params.CostReportToken); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "start_date", runtime.ParamLocationQuery, params.StartDate); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "end_date", runtime.ParamLocationQuery, params.EndDate); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		if params.DateBin != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "date_bin", runtime.ParamLocationQuery, *params.DateBin); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Groupings != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", false, "groupings", runtime.ParamLocationQuery, *params.Groupings); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Metrics != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", false, "metrics", runtime.ParamLocationQuery, *params.Metrics); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Limit != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "limit", runtime.ParamLocationQuery, *params.Limit); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Page != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "page", runtime.ParamLocationQuery, *params.Page); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Filter != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "filter", runtime.ParamLocationQuery, *params.Filter); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.SettingsIncludeUnallocatedCosts != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "settings[include_unallocated_costs]", runtime.ParamLocationQuery, *params.SettingsIncludeUnallocatedCosts); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// GetForecastedCostsWithResponse request
+	GetForecastedCostsWithResponse(ctx context.Context, costReportToken string, params *GetForecastedCostsParams, reqEditors ...RequestEditorFn) (*GetForecastedCostsResponse, error)
+
+	// GetCostsWithResponse request
+	GetCostsWithResponse(ctx context.Context, params *GetCostsParams, reqEditors ...RequestEditorFn) (*GetCostsResponse, error)
+}
+
+type GetForecastedCostsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ForecastedCosts
+}
+
+// Status returns HTTPResponse.Status
+func (r GetForecastedCostsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetForecastedCostsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetCostsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Costs
+}
+
+// Status returns HTTPResponse.Status
+func (r GetCostsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetCostsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// GetForecastedCostsWithResponse request returning *GetForecastedCostsResponse
+func (c *ClientWithResponses) GetForecastedCostsWithResponse(ctx context.Context, costReportToken string, params *GetForecastedCostsParams, reqEditors ...RequestEditorFn) (*GetForecastedCostsResponse, error) {
+	rsp, err := c.GetForecastedCosts(ctx, costReportToken, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetForecastedCostsResponse(rsp)
+}
+
+// GetCostsWithResponse request returning *GetCostsResponse
+func (c *ClientWithResponses) GetCostsWithResponse(ctx context.Context, params *GetCostsParams, reqEditors ...RequestEditorFn) (*GetCostsResponse, error) {
+	rsp, err := c.GetCosts(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetCostsResponse(rsp)
+}
+
+// ParseGetForecastedCostsResponse parses an HTTP response from a GetForecastedCostsWithResponse call
+func ParseGetForecastedCostsResponse(rsp *http.Response) (*GetForecastedCostsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetForecastedCostsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ForecastedCosts
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetCostsResponse parses an HTTP response from a GetCostsWithResponse call
+func ParseGetCostsResponse(rsp *http.Response) (*GetCostsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetCostsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Costs
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
@@ -0,0 +1,344 @@
+//go:build openapi
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client/openapi"
+)
+
+// openapiClient is the client whose costs and forecast requests go through
+// the client generated from Vantage's OpenAPI spec. Every other endpoint,
+// and the authorization, rate limiting, caching, retries, and tracing
+// around each request, are the handwritten client's.
+type openapiClient struct {
+	*client
+
+	api *openapi.ClientWithResponses
+}
+
+// newOpenAPIClient returns c with its costs and forecast requests sent by
+// the generated client.
+func newOpenAPIClient(c *client) (Client, error) {
+	api, err := openapi.NewClientWithResponses(c.httpClient.baseURL,
+		openapi.WithHTTPClient(openapiDoer{httpClient: c.httpClient}))
+	if err != nil {
+		return nil, fmt.Errorf("creating openapi client: %w", err)
+	}
+	return &openapiClient{client: c, api: api}, nil
+}
+
+// openapiDoer sends the generated client's requests the way the handwritten
+// client sends its own.
+type openapiDoer struct {
+	httpClient *httpClient
+}
+
+// Do implements openapi.HttpRequestDoer.
+func (d openapiDoer) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := d.httpClient.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	d.httpClient.setDefaultHeaders(req)
+
+	d.httpClient.logger.Debug(ctx, "Making request", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "openapi_request",
+		"attempt":   0,
+		"url":       req.URL.String(),
+		"method":    req.Method,
+	})
+	return d.httpClient.do(ctx, req)
+}
+
+// Costs implements Client.Costs.
+func (c *openapiClient) Costs(ctx context.Context, query Query) (_ Page, err error) {
+	ctx, call := c.httpClient.startCall(ctx, http.MethodGet, "costs_request")
+	defer func() { call.finish(err) }()
+
+	ctx, cancel := c.httpClient.operationContext(ctx)
+	defer cancel()
+
+	var page Page
+	err = c.withRetries(ctx, call, "costs_request", func(ctx context.Context) error {
+		var onceErr error
+		page, onceErr = c.costsOnce(ctx, query)
+		return onceErr
+	})
+	return page, err
+}
+
+// costsOnce performs a single costs request.
+func (c *openapiClient) costsOnce(ctx context.Context, query Query) (Page, error) {
+	params := &openapi.GetCostsParams{
+		WorkspaceToken:  optional(query.WorkspaceToken),
+		CostReportToken: optional(query.CostReportToken),
+		StartDate:       openapi_types.Date{Time: query.StartAt},
+		EndDate:         openapi_types.Date{Time: query.EndAt},
+		DateBin:         optional(openapi.GetCostsParamsDateBin(query.Granularity)),
+		Groupings:       optionalList(query.GroupBys),
+		Metrics:         optionalList(query.Metrics),
+		Page:            optional(query.Cursor),
+		Filter:          optional(query.Filter),
+	}
+	if query.PageSize > 0 {
+		params.Limit = &query.PageSize
+	}
+	if query.IncludeUnallocated {
+		params.SettingsIncludeUnallocatedCosts = &query.IncludeUnallocated
+	}
+
+	resp, err := c.api.GetCostsWithResponse(ctx, params)
+	if err != nil {
+		return Page{}, fmt.Errorf("executing request: %w", err)
+	}
+	if err := c.checkResponse(ctx, "costs_request", resp.HTTPResponse, resp.Body); err != nil {
+		return Page{}, err
+	}
+	costs := resp.JSON200
+	if costs == nil {
+		// The generated parser skips bodies not labelled as JSON.
+		costs = &openapi.Costs{}
+		if err := json.Unmarshal(resp.Body, costs); err != nil {
+			return Page{}, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+
+	page := Page{Data: make([]CostRow, 0, len(costs.Costs))}
+	for i := range costs.Costs {
+		row, err := openapiCostRow(&costs.Costs[i], query.Granularity)
+		if err != nil {
+			return Page{}, fmt.Errorf("decoding response: row %d: %w", i, err)
+		}
+		page.Data = append(page.Data, row)
+	}
+	if costs.Links != nil {
+		next, err := v2NextPage(value(costs.Links.Next))
+		if err != nil {
+			return Page{}, fmt.Errorf("decoding response: %w", err)
+		}
+		page.NextCursor = next
+		page.HasMore = next != ""
+	}
+	return page, nil
+}
+
+// Forecast implements Client.Forecast.
+func (c *openapiClient) Forecast(ctx context.Context, reportToken string, query ForecastQuery) (_ Forecast, err error) {
+	ctx, call := c.httpClient.startCall(ctx, http.MethodGet, "forecast_request")
+	defer func() { call.finish(err) }()
+
+	ctx, cancel := c.httpClient.operationContext(ctx)
+	defer cancel()
+
+	var forecast Forecast
+	err = c.withRetries(ctx, call, "forecast_request", func(ctx context.Context) error {
+		var onceErr error
+		forecast, onceErr = c.forecastOnce(ctx, reportToken, query)
+		return onceErr
+	})
+	return forecast, err
+}
+
+// forecastOnce performs a single forecast request. Forecasts are short, so
+// a next link is not followed.
+func (c *openapiClient) forecastOnce(ctx context.Context, reportToken string, query ForecastQuery) (Forecast, error) {
+	params := &openapi.GetForecastedCostsParams{
+		StartDate: openapi_types.Date{Time: query.StartAt},
+		EndDate:   openapi_types.Date{Time: query.EndAt},
+		DateBin:   optional(openapi.GetForecastedCostsParamsDateBin(query.Granularity)),
+		Groupings: optionalList(query.GroupBys),
+	}
+
+	resp, err := c.api.GetForecastedCostsWithResponse(ctx, reportToken, params)
+	if err != nil {
+		return Forecast{}, fmt.Errorf("executing request: %w", err)
+	}
+	if err := c.checkResponse(ctx, "forecast_request", resp.HTTPResponse, resp.Body); err != nil {
+		return Forecast{}, err
+	}
+	forecasted := resp.JSON200
+	if forecasted == nil {
+		forecasted = &openapi.ForecastedCosts{}
+		if err := json.Unmarshal(resp.Body, forecasted); err != nil {
+			return Forecast{}, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+
+	forecast := Forecast{Data: make([]ForecastRow, 0, len(forecasted.ForecastedCosts))}
+	for i, r := range forecasted.ForecastedCosts {
+		cost, err := v2Amount(value(r.Amount))
+		if err != nil {
+			return Forecast{}, fmt.Errorf("decoding response: row %d: parsing amount: %w", i, err)
+		}
+		forecast.Data = append(forecast.Data, ForecastRow{
+			BucketStart: r.Date.Time,
+			BucketEnd:   bucketEnd(r.Date.Time, query.Granularity),
+			Cost:        cost,
+			Currency:    value(r.Currency),
+			Provider:    value(r.Provider),
+			Service:     value(r.Service),
+			Extra:       extraFields(r.AdditionalProperties),
+		})
+	}
+	return forecast, nil
+}
+
+// checkResponse returns the error a non-200 response stands for, as the
+// handwritten client does.
+func (c *openapiClient) checkResponse(ctx context.Context, operation string, resp *http.Response, body []byte) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if resetTime := c.httpClient.parseRateLimitReset(ctx, resp); resetTime > 0 {
+			return &rateLimitError{resetIn: time.Duration(resetTime) * time.Second}
+		}
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	c.logger.Error(ctx, "Request failed", map[string]interface{}{
+		"adapter":     "vantage",
+		"operation":   operation,
+		"attempt":     0,
+		"status_code": resp.StatusCode,
+		"request_id":  resp.Header.Get(RequestIDHeader),
+		"response":    string(body),
+	})
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RequestID:  resp.Header.Get(RequestIDHeader),
+	}
+}
+
+// withRetries calls once until it succeeds or fails with an error not worth
+// retrying, with the handwritten client's backoff between attempts.
+func (c *openapiClient) withRetries(
+	ctx context.Context,
+	call *observedCall,
+	operation string,
+	once func(context.Context) error,
+) error {
+	h := c.httpClient
+	var lastErr error
+
+	for attempt := 0; attempt <= h.maxRetries; attempt++ {
+		if attempt > 0 {
+			call.setRetries(attempt)
+			c.logger.Info(ctx, "Retrying request", map[string]interface{}{
+				"adapter":     "vantage",
+				"operation":   operation,
+				"attempt":     attempt,
+				"max_retries": h.maxRetries,
+			})
+		}
+
+		err := once(ctx)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !h.shouldRetry(err, attempt) {
+			break
+		}
+		if waitErr := h.waitBeforeRetry(ctx, attempt, err); waitErr != nil {
+			return h.operationError(ctx, waitErr)
+		}
+	}
+
+	return h.operationError(ctx,
+		fmt.Errorf("%s failed after %d attempts: %w", operation, h.maxRetries+1, lastErr))
+}
+
+// openapiCostRow converts a generated cost row to a CostRow, with a bucket
+// granularity ("day" or "month") long.
+func openapiCostRow(r *openapi.Cost, granularity string) (CostRow, error) {
+	start := r.AccruedAt.Time
+	row := CostRow{
+		Provider:       value(r.Provider),
+		Service:        value(r.Service),
+		Account:        value(r.AccountId),
+		BillingAccount: value(r.BillingAccountId),
+		Project:        value(r.Project),
+		Region:         value(r.Region),
+		ResourceID:     value(r.ResourceId),
+		UsageUnit:      value(r.UsageUnit),
+		Currency:       value(r.Currency),
+		BucketStart:    start,
+		BucketEnd:      bucketEnd(start, granularity),
+		Unallocated:    value(r.Unallocated),
+		Extra:          extraFields(r.AdditionalProperties),
+	}
+	if r.Tags != nil {
+		row.Tags = *r.Tags
+	}
+
+	amounts := []struct {
+		name  string
+		value *openapi.Amount
+		dest  *float64
+	}{
+		{"amount", r.Amount, &row.Cost},
+		{"usage_quantity", r.UsageQuantity, &row.UsageQuantity},
+		{"list_amount", r.ListAmount, &row.ListCost},
+		{"amortized_amount", r.AmortizedAmount, &row.AmortizedCost},
+		{"tax_amount", r.TaxAmount, &row.Tax},
+		{"credit_amount", r.CreditAmount, &row.Credit},
+		{"refund_amount", r.RefundAmount, &row.Refund},
+	}
+	for _, amount := range amounts {
+		var err error
+		if *amount.dest, err = v2Amount(value(amount.value)); err != nil {
+			return CostRow{}, fmt.Errorf("parsing %s: %w", amount.name, err)
+		}
+	}
+	if row.UsageQuantity != 0 {
+		row.EffectiveUnitPrice = row.Cost / row.UsageQuantity
+	}
+	return row, nil
+}
+
+// extraFields returns the unknown fields of a generated row, or nil when
+// there are none, as unknownFields does for the handwritten rows.
+func extraFields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// optional returns a pointer to v, or nil for the zero value, which the
+// generated client leaves out of the query.
+func optional[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
+// optionalList returns a pointer to list, or nil when it is empty.
+func optionalList(list []string) *[]string {
+	if len(list) == 0 {
+		return nil
+	}
+	return &list
+}
+
+// value returns *p, or the zero value when p is nil.
+func value[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
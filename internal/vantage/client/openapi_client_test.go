@@ -0,0 +1,158 @@
+//go:build openapi
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKindClient returns a v2 client of kind against baseURL.
+func newKindClient(t *testing.T, kind, baseURL string) Client {
+	t.Helper()
+	c, err := New(Config{BaseURL: baseURL, Token: "test-token", APIVersion: APIVersionV2, Kind: kind})
+	require.NoError(t, err)
+	return c
+}
+
+func TestOpenAPIClient_CostsMatchHandwritten(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/costs", r.URL.Path)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		q := r.URL.Query()
+		assert.Equal(t, "cr_test", q.Get("cost_report_token"))
+		assert.Equal(t, "2024-01-01", q.Get("start_date"))
+		assert.Equal(t, "2024-02-01", q.Get("end_date"))
+		assert.Equal(t, "day", q.Get("date_bin"))
+		assert.Equal(t, "provider,service", q.Get("groupings"))
+		assert.Equal(t, "100", q.Get("limit"))
+		assert.Equal(t, "true", q.Get("settings[include_unallocated_costs]"))
+		assert.False(t, q.Has("workspace_token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("page") == "" {
+			_, _ = w.Write([]byte(`{
+				"links": {"next": "https://api.vantage.sh/v2/costs?page=2"},
+				"costs": [{"accrued_at": "2024-01-01", "provider": "aws", "service": "EC2",
+					"account_id": "123", "billing_account_id": "100", "tags": {"env": "prod"},
+					"amount": "100.50", "usage_quantity": "10", "currency": "USD", "unallocated": true,
+					"cost_category": "compute"}]
+			}`))
+			return
+		}
+		assert.Equal(t, "2", q.Get("page"))
+		_, _ = w.Write([]byte(`{"links": {"next": null}, "costs": [{"accrued_at": "2024-01-02", "amount": 7}]}`))
+	}))
+	defer server.Close()
+
+	query := Query{
+		CostReportToken:    "cr_test",
+		StartAt:            time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:              time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Granularity:        "day",
+		GroupBys:           []string{"provider", "service"},
+		PageSize:           100,
+		IncludeUnallocated: true,
+	}
+	rows := make(map[string][]CostRow)
+	for _, kind := range SupportedClientKinds() {
+		pager := NewPager(newKindClient(t, kind, server.URL), query, NewNoopLogger())
+		var err error
+		rows[kind], err = pager.AllPages(context.Background())
+		require.NoError(t, err, kind)
+	}
+
+	got := rows[ClientKindOpenAPI]
+	require.Len(t, got, 2)
+	assert.Equal(t, "123", got[0].Account)
+	assert.Equal(t, map[string]string{"env": "prod"}, got[0].Tags)
+	assert.InDelta(t, 10.05, got[0].EffectiveUnitPrice, 1e-9)
+	assert.Equal(t, map[string]interface{}{"cost_category": "compute"}, got[0].Extra)
+	assert.InDelta(t, 7.0, got[1].Cost, 1e-9)
+	assert.Equal(t, rows[ClientKindHandwritten], got)
+}
+
+func TestOpenAPIClient_ForecastMatchesHandwritten(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/cost_reports/cr_test/forecasted_costs", r.URL.Path)
+		assert.Equal(t, "month", r.URL.Query().Get("date_bin"))
+		assert.Equal(t, "2024-02-01", r.URL.Query().Get("start_date"))
+		// No Content-Type: the body is decoded all the same.
+		_, _ = w.Write([]byte(`{"forecasted_costs": [
+			{"date": "2024-02-01", "amount": "300", "currency": "USD", "provider": "aws", "confidence": 0.9}]}`))
+	}))
+	defer server.Close()
+
+	query := ForecastQuery{
+		StartAt:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Granularity: "month",
+	}
+	forecasts := make(map[string]Forecast)
+	for _, kind := range SupportedClientKinds() {
+		var err error
+		forecasts[kind], err = newKindClient(t, kind, server.URL).Forecast(context.Background(), "cr_test", query)
+		require.NoError(t, err, kind)
+	}
+
+	got := forecasts[ClientKindOpenAPI]
+	require.Len(t, got.Data, 1)
+	assert.InDelta(t, 300.0, got.Data[0].Cost, 1e-9)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), got.Data[0].BucketEnd)
+	assert.Equal(t, forecasts[ClientKindHandwritten], got)
+}
+
+func TestOpenAPIClient_Errors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set(RequestIDHeader, "req_404")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors": ["cost report not found"]}`))
+	}))
+	defer server.Close()
+
+	c := newKindClient(t, ClientKindOpenAPI, server.URL)
+	_, err := c.Costs(context.Background(), Query{Granularity: "day"})
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "req_404", apiErr.RequestID)
+	assert.Contains(t, apiErr.Body, "cost report not found")
+	assert.Equal(t, int32(1), calls.Load(), "a 4xx is not retried")
+}
+
+func TestOpenAPIClient_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"costs": [{"accrued_at": "2024-01-01", "amount": "1"}]}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		MaxRetries: 1,
+		Kind:       ClientKindOpenAPI,
+	})
+	require.NoError(t, err)
+
+	page, err := c.Costs(context.Background(), Query{Granularity: "day"})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int64(1), c.RequestStats().Retries)
+}
@@ -0,0 +1,8 @@
+//go:build !openapi
+
+package client
+
+// newOpenAPIClient fails, since this build has no generated client.
+func newOpenAPIClient(*client) (Client, error) {
+	return nil, ErrOpenAPINotBuilt
+}
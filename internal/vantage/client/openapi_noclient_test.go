@@ -0,0 +1,17 @@
+//go:build !openapi
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_OpenAPIClientNotBuilt(t *testing.T) {
+	config := DefaultConfig("test-token")
+	config.Kind = ClientKindOpenAPI
+
+	_, err := New(config)
+	require.ErrorIs(t, err, ErrOpenAPINotBuilt)
+}
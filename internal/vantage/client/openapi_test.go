@@ -0,0 +1,19 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_InvalidClientKind(t *testing.T) {
+	_, err := New(Config{Token: "test-token", Kind: "generated"})
+	require.ErrorContains(t, err, "invalid client kind: generated")
+}
+
+func TestNew_OpenAPIClientNeedsV2(t *testing.T) {
+	for _, version := range []string{APIVersionV1, APIVersionAuto} {
+		_, err := New(Config{Token: "test-token", Kind: ClientKindOpenAPI, APIVersion: version})
+		require.ErrorContains(t, err, "supports only API version v2")
+	}
+}
@@ -4,49 +4,185 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Pager provides cursor-based pagination for cost queries.
+// errMissingCursor is returned for a page that reports more results without
+// a cursor to fetch them by.
+var errMissingCursor = errors.New("costs page has more results but no cursor")
+
+// pageRetryDelay is the pause before the first page-level retry; each
+// further retry waits one more multiple of it.
+const pageRetryDelay = time.Second
+
+// Pager iterates the pages of a cost query by cursor.
 type Pager struct {
-	client     Client
-	query      Query
-	logger     Logger
-	hasStarted bool
+	client      Client
+	query       Query
+	logger      Logger
+	prefetch    bool
+	pageRetries int
+	retryDelay  time.Duration
 }
 
-// NewPager creates a new pager for the given query.
-func NewPager(client Client, query Query, logger Logger) *Pager {
-	return &Pager{
-		client: client,
-		query:  query,
-		logger: logger,
+// PagerOption configures a Pager.
+type PagerOption func(*Pager)
+
+// WithPrefetch fetches each next page concurrently while the caller handles
+// the current one, so mapping and fetching overlap.
+func WithPrefetch(prefetch bool) PagerOption {
+	return func(p *Pager) {
+		p.prefetch = prefetch
 	}
 }
 
-// NextPage fetches the next page of cost data.
-func (p *Pager) NextPage(ctx context.Context) (Page, error) {
-	// If we've already started and there's no cursor, we've exhausted all pages.
-	if p.hasStarted && p.query.Cursor == "" {
-		return Page{}, errors.New("no more pages available")
+// WithPageRetries requests a page up to retries more times, with the same
+// cursor, when fetching it fails after the client's own request retries.
+// Retried pages report their Attempts, so callers can check the cursor
+// still continued where the previous page ended.
+func WithPageRetries(retries int) PagerOption {
+	return func(p *Pager) {
+		p.pageRetries = max(retries, 0)
 	}
+}
 
-	currentQuery := p.query
-	if p.query.Cursor != "" {
-		currentQuery.Cursor = p.query.Cursor
+// NewPager creates a new pager for the given query, starting at its cursor.
+func NewPager(client Client, query Query, logger Logger, opts ...PagerOption) *Pager {
+	p := &Pager{
+		client:     client,
+		query:      query,
+		logger:     logger,
+		retryDelay: pageRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
+}
+
+// pageResult is a fetched page or the error fetching it.
+type pageResult struct {
+	page Page
+	err  error
+}
+
+// Pages iterates the query's pages in order:
+//
+//	for page, err := range pager.Pages(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The first error ends the iteration. Each call to Pages starts again from
+// the query's cursor. With prefetch, stopping early cancels the page being
+// fetched ahead and waits for it before Pages returns.
+func (p *Pager) Pages(ctx context.Context) iter.Seq2[Page, error] {
+	return func(yield func(Page, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		query := p.query
+		page, err := p.fetchPage(ctx, query, true)
+		for {
+			if err != nil {
+				yield(Page{}, err)
+				return
+			}
+			if page.HasMore && page.NextCursor == "" {
+				yield(Page{}, errMissingCursor)
+				return
+			}
+			query.Cursor = page.NextCursor
+
+			var ahead chan pageResult
+			if page.HasMore && p.prefetch {
+				ahead = make(chan pageResult, 1)
+				go func(query Query) {
+					page, err := p.fetchPage(ctx, query, false)
+					ahead <- pageResult{page, err}
+				}(query)
+			}
 
-	page, err := p.client.Costs(ctx, currentQuery)
+			if !yield(page, nil) {
+				if ahead != nil {
+					cancel()
+					<-ahead
+				}
+				return
+			}
+			if !page.HasMore {
+				return
+			}
+
+			if ahead != nil {
+				result := <-ahead
+				page, err = result.page, result.err
+			} else {
+				page, err = p.fetchPage(ctx, query, false)
+			}
+		}
+	}
+}
+
+// fetchPage fetches the page of query at its cursor, retrying a failed
+// fetch up to the pager's page retries.
+func (p *Pager) fetchPage(ctx context.Context, query Query, first bool) (Page, error) {
+	for attempt := 1; ; attempt++ {
+		page, err := p.fetchPageOnce(ctx, query, first)
+		if err == nil {
+			page.Attempts = attempt
+			if attempt > 1 {
+				p.logger.Info(ctx, "Fetched costs page after retrying", map[string]interface{}{
+					"cursor":   query.Cursor,
+					"attempts": attempt,
+				})
+			}
+			return page, nil
+		}
+		if attempt > p.pageRetries || ctx.Err() != nil {
+			return Page{}, err
+		}
+
+		p.logger.Warn(ctx, "Retrying costs page", map[string]interface{}{
+			"error":        err,
+			"cursor":       query.Cursor,
+			"attempt":      attempt,
+			"page_retries": p.pageRetries,
+		})
+		select {
+		case <-ctx.Done():
+			return Page{}, err
+		case <-time.After(time.Duration(attempt) * p.retryDelay):
+		}
+	}
+}
+
+// fetchPageOnce requests the page of query at its cursor once.
+func (p *Pager) fetchPageOnce(ctx context.Context, query Query, first bool) (_ Page, err error) {
+	ctx, span := tracer().Start(ctx, "vantage.costs_page", trace.WithAttributes(
+		attribute.Bool("vantage.first_page", first),
+	))
+	defer func() { finishSpan(span, err) }()
+
+	page, err := p.client.Costs(ctx, query)
 	if err != nil {
 		p.logger.Error(ctx, "Failed to fetch costs page", map[string]interface{}{
 			"error":  err,
-			"cursor": currentQuery.Cursor,
+			"cursor": query.Cursor,
 		})
 		return Page{}, fmt.Errorf("fetching costs page: %w", err)
 	}
 
-	// Mark that we've started paging and update cursor for next page.
-	p.hasStarted = true
-	p.query.Cursor = page.NextCursor
+	span.SetAttributes(
+		attribute.Int("vantage.rows", len(page.Data)),
+		attribute.Bool("vantage.has_more", page.HasMore),
+	)
 
 	p.logger.Debug(ctx, "Fetched costs page", map[string]interface{}{
 		"rows":        len(page.Data),
@@ -57,27 +193,16 @@ func (p *Pager) NextPage(ctx context.Context) (Page, error) {
 	return page, nil
 }
 
-// HasMore returns true if there are more pages to fetch.
-func (p *Pager) HasMore() bool {
-	return p.query.Cursor != ""
-}
-
 // AllPages fetches all pages and returns them as a single slice.
 // Note: This can be memory-intensive for large datasets.
 func (p *Pager) AllPages(ctx context.Context) ([]CostRow, error) {
 	var allRows []CostRow
 
-	for p.HasMore() || p.query.Cursor == "" {
-		page, err := p.NextPage(ctx)
+	for page, err := range p.Pages(ctx) {
 		if err != nil {
 			return nil, err
 		}
-
 		allRows = append(allRows, page.Data...)
-
-		if !page.HasMore {
-			break
-		}
 	}
 
 	p.logger.Info(ctx, "Fetched all cost pages", map[string]interface{}{
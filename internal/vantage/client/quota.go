@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultQuotaPause is used when the remaining quota is low but the response
+// does not say when the window resets.
+const defaultQuotaPause = 1 * time.Second
+
+// quotaTracker follows X-RateLimit-Remaining/X-RateLimit-Limit headers and
+// schedules a pause before the next request once the remaining quota drops
+// below a threshold, so requests slow down before Vantage starts returning 429s.
+type quotaTracker struct {
+	threshold int
+
+	mu         sync.Mutex
+	remaining  int
+	limit      int
+	pauseUntil time.Time
+	serverTime time.Time // Date header of the last response
+	observedAt time.Time // local time the last response was observed
+	now        func() time.Time
+}
+
+// newQuotaTracker creates a tracker. A non-positive threshold disables pausing.
+func newQuotaTracker(threshold int) *quotaTracker {
+	return &quotaTracker{
+		threshold: threshold,
+		remaining: -1,
+		limit:     -1,
+		now:       time.Now,
+	}
+}
+
+// wait blocks until any scheduled pause has elapsed or ctx is done.
+func (q *quotaTracker) wait(ctx context.Context) (time.Duration, error) {
+	q.mu.Lock()
+	delay := q.pauseUntil.Sub(q.now())
+	q.mu.Unlock()
+
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+		return delay, nil
+	}
+}
+
+// observe records the quota headers from resp and returns the pause scheduled
+// before the next request (zero when quota is healthy or headers are absent).
+func (q *quotaTracker) observe(resp *http.Response) time.Duration {
+	q.observeDate(resp.Header)
+
+	remaining, ok := parseHeaderInt(resp.Header, "X-RateLimit-Remaining")
+	if !ok {
+		return 0
+	}
+	limit, hasLimit := parseHeaderInt(resp.Header, "X-RateLimit-Limit")
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.remaining = remaining
+	if hasLimit {
+		q.limit = limit
+	}
+
+	if q.threshold <= 0 || remaining >= q.threshold {
+		return 0
+	}
+
+	// Spread the remaining requests evenly over the rest of the window when
+	// the reset time is known; otherwise fall back to a fixed pause.
+	pause := defaultQuotaPause
+	if resetSeconds, hasReset := parseHeaderInt(resp.Header, "X-RateLimit-Reset"); hasReset && resetSeconds > 0 {
+		pause = time.Duration(resetSeconds) * time.Second / time.Duration(remaining+1)
+	}
+
+	if until := q.now().Add(pause); until.After(q.pauseUntil) {
+		q.pauseUntil = until
+	}
+	return pause
+}
+
+// observeDate records the server's Date header for clock skew detection.
+func (q *quotaTracker) observeDate(h http.Header) {
+	serverTime, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.serverTime = serverTime
+	q.observedAt = q.now()
+}
+
+// status returns the last observed quota and server time.
+func (q *quotaTracker) status() ServerStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return ServerStatus{
+		RateLimitRemaining: q.remaining,
+		RateLimitLimit:     q.limit,
+		ServerTime:         q.serverTime,
+		ObservedAt:         q.observedAt,
+	}
+}
+
+// parseHeaderInt parses an integer header value.
+func parseHeaderInt(h http.Header, name string) (int, bool) {
+	value := h.Get(name)
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token-bucket rate limiter shared by all requests made
+// through a client. A single RateLimiter may also be shared between several
+// clients (for example parallel backfill workers) so that their combined
+// request rate stays under the Vantage API limits.
+type RateLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // tokens added per second
+	burst    float64 // maximum tokens in the bucket
+	tokens   float64
+	lastFill time.Time
+	now      func() time.Time
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond sustained
+// requests with bursts of up to burst requests. A non-positive
+// requestsPerSecond returns nil, which disables limiting.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:     requestsPerSecond,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Wait blocks until a token is available or ctx is done. A nil limiter never blocks.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	for {
+		delay := r.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available and returns zero, otherwise it
+// returns how long to wait before the next token is expected.
+func (r *RateLimiter) reserve() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	elapsed := now.Sub(r.lastFill).Seconds()
+	r.tokens = math.Min(r.burst, r.tokens+elapsed*r.rate)
+	r.lastFill = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+
+	missing := 1 - r.tokens
+	return time.Duration(missing / r.rate * float64(time.Second))
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// Recommendations implements Client.Recommendations.
+func (c *client) Recommendations(ctx context.Context, query RecommendationQuery) ([]Recommendation, error) {
+	params := url.Values{}
+	if query.WorkspaceToken != "" {
+		params.Set("workspace_token", query.WorkspaceToken)
+	}
+	if query.Provider != "" {
+		params.Set("provider", query.Provider)
+	}
+	if query.Category != "" {
+		params.Set("category", query.Category)
+	}
+
+	return listAll(ctx, c.httpClient, "list_recommendations", "/recommendations", params,
+		func(resp *recommendationsResponse) ([]Recommendation, string) {
+			return resp.Recommendations, resp.Links.Next
+		})
+}
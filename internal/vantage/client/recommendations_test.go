@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Recommendations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/recommendations", r.URL.Path)
+		assert.Equal(t, "wrkspc_1", r.URL.Query().Get("workspace_token"))
+		assert.Equal(t, RecommendationCategoryRightsizing, r.URL.Query().Get("category"))
+		assert.Empty(t, r.URL.Query().Get("provider"))
+
+		_, _ = w.Write([]byte(`{
+			"recommendations": [{
+				"token": "rcmmndtn_1",
+				"category": "rightsizing",
+				"provider": "aws",
+				"resource_id": "i-0abc",
+				"potential_savings": 142.5
+			}],
+			"links": {}
+		}`))
+	}))
+	defer server.Close()
+
+	recs, err := newTestClient(t, server.URL, 0).Recommendations(context.Background(), RecommendationQuery{
+		WorkspaceToken: "wrkspc_1",
+		Category:       RecommendationCategoryRightsizing,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, recs, 1)
+	assert.Equal(t, "i-0abc", recs[0].ResourceID)
+	assert.InEpsilon(t, 142.5, recs[0].PotentialSavings, 0.001)
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// redacted replaces masked secrets.
+const redacted = "****"
+
+// maxLearnedSecrets bounds the tokens a redacting logger learns from a
+// token provider. Providers renew tokens over a long run; the most recent
+// ones are the ones still likely to turn up in responses and errors.
+const maxLearnedSecrets = 8
+
+var (
+	// sensitiveKeyPattern matches field keys and query parameter names whose
+	// values are secrets: token, workspace_token, cost_report_token,
+	// Authorization, and the like.
+	sensitiveKeyPattern = regexp.MustCompile(`(?i)token|authorization|secret|password|api[_-]?key|credential`)
+
+	// sensitiveParamPattern matches sensitive query parameters in a URL.
+	sensitiveParamPattern = regexp.MustCompile(
+		`(?i)([?&])([^=&#\s]*(?:token|authorization|secret|password|api[_-]?key|credential)[^=&#\s]*)=([^&#\s"]*)`)
+
+	// bearerPattern matches bearer credentials, in headers or URL-encoded.
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer)(\s+|%20|\+)[^\s&"]+`)
+
+	// reportPathPattern matches the report token segment of cost report paths.
+	reportPathPattern = regexp.MustCompile(`/cost_reports/[^/?#\s"]+`)
+)
+
+// redactingLogger masks secrets in fields before passing them to next.
+type redactingLogger struct {
+	next    Logger
+	secrets []string
+
+	// learned holds the most recent tokens a token provider supplied,
+	// masked like secrets.
+	mu      sync.RWMutex
+	learned []string
+}
+
+// NewRedactingLogger returns a Logger that masks secrets in every message's
+// fields before next sees them. Values of keys naming a token,
+// authorization, password, or API key are replaced outright; string and
+// error values have token query parameters, bearer credentials, cost report
+// path tokens, and any of secrets masked. Wrapping a redacting logger again
+// adds secrets to the existing one rather than redacting twice.
+func NewRedactingLogger(next Logger, secrets ...string) Logger {
+	var known []string
+	if r, ok := next.(*redactingLogger); ok {
+		next = r.next
+		known = slices.Clone(r.secrets)
+	}
+	for _, secret := range secrets {
+		if secret != "" && !slices.Contains(known, secret) {
+			known = append(known, secret)
+		}
+	}
+	return &redactingLogger{next: next, secrets: known}
+}
+
+func (r *redactingLogger) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	r.next.Debug(ctx, msg, r.redactFields(fields))
+}
+
+func (r *redactingLogger) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	r.next.Info(ctx, msg, r.redactFields(fields))
+}
+
+func (r *redactingLogger) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	r.next.Warn(ctx, msg, r.redactFields(fields))
+}
+
+func (r *redactingLogger) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	r.next.Error(ctx, msg, r.redactFields(fields))
+}
+
+// redactFields returns a masked copy of fields; the caller's map is left as is.
+func (r *redactingLogger) redactFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	masked := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		masked[key] = r.redactField(key, value)
+	}
+	return masked
+}
+
+func (r *redactingLogger) redactField(key string, value interface{}) interface{} {
+	if sensitiveKeyPattern.MatchString(key) {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.mask(v)
+	case error:
+		return r.mask(v.Error())
+	case *url.URL:
+		// A typed nil URL matches this case and would panic in String.
+		if v == nil {
+			return v
+		}
+		return r.mask(v.String())
+	case map[string]string:
+		masked := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			masked[k] = r.redactField(k, s)
+		}
+		return masked
+	case map[string]interface{}:
+		return r.redactFields(v)
+	default:
+		return value
+	}
+}
+
+// learn adds secret, a token obtained after the logger was created, to the
+// secrets masked, forgetting the oldest learned one past maxLearnedSecrets.
+func (r *redactingLogger) learn(secret string) {
+	if secret == "" || slices.Contains(r.secrets, secret) {
+		return
+	}
+	r.mu.RLock()
+	known := slices.Contains(r.learned, secret)
+	r.mu.RUnlock()
+	if known {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Contains(r.learned, secret) {
+		return
+	}
+	if len(r.learned) == maxLearnedSecrets {
+		r.learned = slices.Delete(r.learned, 0, 1)
+	}
+	r.learned = append(r.learned, secret)
+}
+
+// mask replaces the known secrets in s, then applies RedactString.
+func (r *redactingLogger) mask(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	r.mu.RLock()
+	for _, secret := range r.learned {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	r.mu.RUnlock()
+	return RedactString(s)
+}
+
+// RedactString masks sensitive query parameters, bearer credentials, and
+// cost report tokens in URL paths. Loggers apply it to every message; use it
+// directly for output that bypasses a Logger, such as error text.
+func RedactString(s string) string {
+	s = sensitiveParamPattern.ReplaceAllString(s, "${1}${2}="+redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1}${2}"+redacted)
+	return reportPathPattern.ReplaceAllString(s, "/cost_reports/"+redacted)
+}
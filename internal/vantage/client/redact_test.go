@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger keeps every message's fields for inspection.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []map[string]interface{}
+}
+
+func (l *recordingLogger) record(fields map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fields)
+}
+
+func (l *recordingLogger) Debug(_ context.Context, _ string, fields map[string]interface{}) {
+	l.record(fields)
+}
+
+func (l *recordingLogger) Info(_ context.Context, _ string, fields map[string]interface{}) {
+	l.record(fields)
+}
+
+func (l *recordingLogger) Warn(_ context.Context, _ string, fields map[string]interface{}) {
+	l.record(fields)
+}
+
+func (l *recordingLogger) Error(_ context.Context, _ string, fields map[string]interface{}) {
+	l.record(fields)
+}
+
+func TestRedactString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{
+			input: "https://api.vantage.sh/costs?param=value",
+			want:  "https://api.vantage.sh/costs?param=value",
+		},
+		{
+			input: "https://api.vantage.sh/costs?Authorization=Bearer%20secret-token&param=value",
+			want:  "https://api.vantage.sh/costs?Authorization=****&param=value",
+		},
+		{
+			input: "https://api.vantage.sh/costs?cost_report_token=rprt_1&workspace_token=wrkspc_1&granularity=day",
+			want:  "https://api.vantage.sh/costs?cost_report_token=****&workspace_token=****&granularity=day",
+		},
+		{
+			input: "https://api.vantage.sh/cost_reports/rprt_1/forecast?start_at=x",
+			want:  "https://api.vantage.sh/cost_reports/****/forecast?start_at=x",
+		},
+		{
+			input: "Authorization: Bearer abc.def",
+			want:  "Authorization: Bearer ****",
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, RedactString(tt.input), tt.input)
+	}
+}
+
+func TestRedactingLogger(t *testing.T) {
+	recorder := &recordingLogger{}
+	logger := NewRedactingLogger(recorder, "secret-token")
+
+	fields := map[string]interface{}{
+		"adapter":           "vantage",
+		"attempt":           2,
+		"token":             "secret-token",
+		"workspace_token":   "wrkspc_1",
+		"cost_report_token": "rprt_1",
+		"Authorization":     "Bearer secret-token",
+		"url":               "https://api.vantage.sh/costs?cost_report_token=rprt_1",
+		"endpoint":          &url.URL{Scheme: "https", Host: "api.vantage.sh", Path: "/cost_reports/rprt_1"},
+		"error":             errors.New("request with secret-token failed"),
+		"response":          `{"echo":"secret-token"}`,
+		"headers":           map[string]string{"X-Api-Key": "k", "Accept": "application/json"},
+	}
+	logger.Info(context.Background(), "msg", fields)
+
+	require.Len(t, recorder.entries, 1)
+	got := recorder.entries[0]
+	assert.Equal(t, "vantage", got["adapter"])
+	assert.Equal(t, 2, got["attempt"])
+	assert.Equal(t, redacted, got["token"])
+	assert.Equal(t, redacted, got["workspace_token"])
+	assert.Equal(t, redacted, got["cost_report_token"])
+	assert.Equal(t, redacted, got["Authorization"])
+	assert.Equal(t, "https://api.vantage.sh/costs?cost_report_token=****", got["url"])
+	assert.Equal(t, "https://api.vantage.sh/cost_reports/****", got["endpoint"])
+	assert.Equal(t, "request with **** failed", got["error"])
+	assert.Equal(t, `{"echo":"****"}`, got["response"])
+	assert.Equal(t, map[string]interface{}{"X-Api-Key": redacted, "Accept": "application/json"}, got["headers"])
+
+	assert.Equal(t, "secret-token", fields["token"], "the caller's fields are not modified")
+}
+
+func TestRedactingLogger_NilValues(t *testing.T) {
+	recorder := &recordingLogger{}
+	logger := NewRedactingLogger(recorder, "secret-token")
+
+	var endpoint *url.URL
+	logger.Info(context.Background(), "msg", map[string]interface{}{"endpoint": endpoint, "error": nil})
+
+	require.Len(t, recorder.entries, 1)
+	got := recorder.entries[0]
+	assert.Nil(t, got["endpoint"], "a typed nil URL is passed through")
+	assert.Nil(t, got["error"])
+}
+
+func TestNewRedactingLogger_Rewrap(t *testing.T) {
+	recorder := &recordingLogger{}
+	logger := NewRedactingLogger(NewRedactingLogger(recorder, "first"), "second")
+
+	r, ok := logger.(*redactingLogger)
+	require.True(t, ok)
+	assert.Same(t, recorder, r.next, "a redacting logger is not wrapped twice")
+	assert.Equal(t, []string{"first", "second"}, r.secrets)
+}
+
+func TestRedactingLogger_Learn(t *testing.T) {
+	recorder := &recordingLogger{}
+	logger := NewRedactingLogger(recorder, "static-token")
+	r, ok := logger.(*redactingLogger)
+	require.True(t, ok)
+
+	for i := range maxLearnedSecrets + 1 {
+		r.learn(fmt.Sprintf("renewed-token-%d", i))
+	}
+	r.learn("static-token")
+	r.learn("")
+	assert.Len(t, r.learned, maxLearnedSecrets, "learned tokens are bounded")
+
+	logger.Error(context.Background(), "msg", map[string]interface{}{
+		"error": errors.New("rejected renewed-token-8 and static-token, once renewed-token-0"),
+	})
+	assert.Equal(t, "rejected **** and ****, once renewed-token-0", recorder.entries[0]["error"],
+		"the oldest learned token is forgotten")
+}
+
+func TestClient_LogsRedactProviderTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":["invalid token ` + bearerToken(r) + `"]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_VANTAGE_TOKEN", "env-provided-token")
+	recorder := &recordingLogger{}
+	c, err := New(Config{
+		BaseURL:       server.URL,
+		TokenProvider: NewEnvTokenProvider("TEST_VANTAGE_TOKEN", ""),
+		Timeout:       5 * time.Second,
+		Logger:        recorder,
+	})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.Error(t, err)
+
+	require.NotEmpty(t, recorder.entries)
+	for _, entry := range recorder.entries {
+		for key, value := range entry {
+			assert.NotContains(t, fmt.Sprint(value), "env-provided-token", key)
+		}
+	}
+}
+
+func TestClient_LogsAreRedacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":["invalid token secret-token"]}`))
+	}))
+	defer server.Close()
+
+	recorder := &recordingLogger{}
+	c, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "secret-token",
+		Timeout:    5 * time.Second,
+		MaxRetries: 0,
+		Logger:     recorder,
+	})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{
+		WorkspaceToken:  "wrkspc_1",
+		CostReportToken: "rprt_1",
+		Granularity:     "day",
+	})
+	require.Error(t, err)
+
+	require.NotEmpty(t, recorder.entries)
+	for _, entry := range recorder.entries {
+		for key, value := range entry {
+			s, _ := value.(string)
+			assert.NotContains(t, s, "secret-token", key)
+			assert.NotContains(t, s, "wrkspc_1", key)
+			assert.NotContains(t, s, "rprt_1", key)
+			if key == "url" {
+				assert.True(t, strings.HasSuffix(s, "?cost_report_token=****&end_at=0001-01-01T00%3A00%3A00Z&"+
+					"granularity=day&start_at=0001-01-01T00%3A00%3A00Z&workspace_token=****"), s)
+			}
+		}
+	}
+}
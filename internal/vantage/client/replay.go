@@ -0,0 +1,230 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNoFixture is returned in replay mode for a request that was never
+// recorded.
+var ErrNoFixture = errors.New("no replay fixture")
+
+// fixtureHeaders are the response headers kept in fixtures. Rate-limit and
+// Date headers are left out so replays never pause or report clock skew
+// from the recording session.
+var fixtureHeaders = []string{"Content-Type", "ETag", "Last-Modified"}
+
+// fixtureNamePattern matches characters not kept in fixture file names.
+var fixtureNamePattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// fixture is one recorded response. Request is the method and redacted
+// path and query; JSON holds a JSON body and Text any other body.
+type fixture struct {
+	Request string            `json:"request"`
+	Status  int               `json:"status"`
+	Header  map[string]string `json:"header,omitempty"`
+	JSON    json.RawMessage   `json:"json,omitempty"`
+	Text    string            `json:"text,omitempty"`
+}
+
+// fixtureKey identifies req independently of host and tokens, so fixtures
+// recorded against the live API replay with any base URL or credentials.
+func fixtureKey(req *http.Request) string {
+	return req.Method + " " + RedactString(req.URL.RequestURI())
+}
+
+// fixturePath is the file holding the fixture for key: a readable prefix
+// from the path plus a digest of the whole key.
+func fixturePath(dir string, req *http.Request, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := strings.Trim(fixtureNamePattern.ReplaceAllString(RedactString(req.URL.Path), "-"), "-")
+	return filepath.Join(dir, fmt.Sprintf("%s_%s_%s.json", req.Method, name, hex.EncodeToString(sum[:6])))
+}
+
+// recordingTransport sends requests through next and saves each response as
+// a sanitized fixture.
+type recordingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// NewRecordingTransport returns a transport that sends requests through next
+// (http.DefaultTransport when nil) and writes every response to a fixture in
+// dir for NewReplayTransport. Fixtures carry no tokens: query parameters and
+// path segments naming tokens are masked, the bearer token is masked wherever
+// it appears in a body, and JSON fields naming tokens or secrets are masked.
+func NewRecordingTransport(dir string, next http.RoundTripper) (http.RoundTripper, error) {
+	if dir == "" {
+		return nil, errors.New("fixture directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, cacheDirPerm); err != nil {
+		return nil, fmt.Errorf("creating fixture directory: %w", err)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{dir: dir, next: next}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	key := fixtureKey(req)
+	fx := fixture{Request: key, Status: resp.StatusCode, Header: map[string]string{}}
+	for _, name := range fixtureHeaders {
+		if v := resp.Header.Get(name); v != "" {
+			fx.Header[name] = v
+		}
+	}
+	sanitized := sanitizeBody(body, bearerToken(req))
+	if json.Valid(sanitized) {
+		fx.JSON = sanitized
+	} else {
+		fx.Text = string(sanitized)
+	}
+
+	data, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding fixture: %w", err)
+	}
+	if err := os.WriteFile(fixturePath(t.dir, req, key), append(data, '\n'), cacheFilePerm); err != nil {
+		return nil, fmt.Errorf("writing fixture: %w", err)
+	}
+	return resp, nil
+}
+
+// replayTransport answers requests from recorded fixtures.
+type replayTransport struct {
+	dir string
+}
+
+// NewReplayTransport returns a transport that answers every request from the
+// fixtures in dir written by NewRecordingTransport, without network access.
+// A request with no fixture fails with ErrNoFixture.
+func NewReplayTransport(dir string) (http.RoundTripper, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("opening fixture directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("fixture path %s is not a directory", dir)
+	}
+	return &replayTransport{dir: dir}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	key := fixtureKey(req)
+	data, err := os.ReadFile(fixturePath(t.dir, req, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s", ErrNoFixture, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading fixture: %w", err)
+	}
+
+	var fx fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, fmt.Errorf("decoding fixture for %s: %w", key, err)
+	}
+
+	body := []byte(fx.Text)
+	if len(fx.JSON) > 0 {
+		body = fx.JSON
+	}
+	header := make(http.Header)
+	for name, v := range fx.Header {
+		header.Set(name, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fx.Status, http.StatusText(fx.Status)),
+		StatusCode:    fx.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// bearerToken returns the token from req's Authorization header.
+func bearerToken(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return auth[len("Bearer "):]
+	}
+	return ""
+}
+
+// sanitizeBody masks secrets in a response body: the bearer token anywhere,
+// token query parameters and report paths in embedded URLs, and, for JSON,
+// the values of fields whose names mark them as secrets.
+func sanitizeBody(body []byte, token string) []byte {
+	s := string(body)
+	if token != "" {
+		s = strings.ReplaceAll(s, token, redacted)
+	}
+	s = RedactString(s)
+
+	// UseNumber keeps numbers exactly as the API sent them.
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	var doc interface{}
+	if decoder.Decode(&doc) != nil || decoder.More() {
+		return []byte(s)
+	}
+	masked, err := json.Marshal(maskJSON(doc))
+	if err != nil {
+		return []byte(s)
+	}
+	return masked
+}
+
+// maskJSON replaces string values under sensitive keys throughout v.
+func maskJSON(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if _, isString := value.(string); isString && sensitiveKeyPattern.MatchString(key) {
+				node[key] = redacted
+				continue
+			}
+			node[key] = maskJSON(value)
+		}
+		return node
+	case []interface{}:
+		for i, value := range node {
+			node[i] = maskJSON(value)
+		}
+		return node
+	default:
+		return v
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("Date", "Mon, 01 Jan 2024 00:00:00 GMT")
+		if r.URL.Query().Get("cursor") == "" {
+			_, _ = w.Write([]byte(`{
+				"data": [{"service": "ec2", "cost": 1.25, "workspace_token": "wrkspc_secret"}],
+				"links": {"next": "https://api.vantage.sh/v2/costs?cost_report_token=cr_secret&cursor=abc"},
+				"echo": "Bearer live-token",
+				"next_cursor": "abc",
+				"has_more": true
+			}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"service": "s3", "cost": 2.5}]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder, err := NewRecordingTransport(dir, nil)
+	require.NoError(t, err)
+
+	fetchAll := func(c Client) []Page {
+		t.Helper()
+		query := Query{
+			CostReportToken: "cr_secret",
+			StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndAt:           time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Granularity:     "day",
+		}
+		first, err := c.Costs(context.Background(), query)
+		require.NoError(t, err)
+		query.Cursor = first.NextCursor
+		second, err := c.Costs(context.Background(), query)
+		require.NoError(t, err)
+		return []Page{first, second}
+	}
+
+	live, err := New(Config{BaseURL: server.URL, Token: "live-token", Transport: recorder})
+	require.NoError(t, err)
+	recorded := fetchAll(live)
+	require.Len(t, recorded[0].Data, 1)
+	assert.Equal(t, "ec2", recorded[0].Data[0].Service)
+
+	// Fixtures hold neither the bearer token nor any resource token, and
+	// drop rate-limit and Date headers.
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		data, readErr := os.ReadFile(file)
+		require.NoError(t, readErr)
+		for _, secret := range []string{"live-token", "cr_secret", "wrkspc_secret", "X-Ratelimit", "Date"} {
+			assert.NotContains(t, string(data), secret, file)
+		}
+	}
+
+	// Replay works offline, with a different base URL and token.
+	server.Close()
+	replayer, err := NewReplayTransport(dir)
+	require.NoError(t, err)
+	offline, err := New(Config{BaseURL: "https://replay.invalid", Token: "other-token", Transport: replayer})
+	require.NoError(t, err)
+	replayed := fetchAll(offline)
+	assert.Equal(t, recorded[0].Data[0].Cost, replayed[0].Data[0].Cost)
+	assert.Equal(t, "s3", replayed[1].Data[0].Service)
+	assert.Equal(t, -1, offline.ServerStatus().RateLimitRemaining, "recorded quota headers are not replayed")
+}
+
+func TestReplay_MissingFixture(t *testing.T) {
+	replayer, err := NewReplayTransport(t.TempDir())
+	require.NoError(t, err)
+	c, err := New(Config{BaseURL: "https://replay.invalid", Token: "token", MaxRetries: 0, Transport: replayer})
+	require.NoError(t, err)
+
+	err = c.Ping(context.Background())
+	require.ErrorIs(t, err, ErrNoFixture)
+	assert.Contains(t, err.Error(), "GET /ping")
+}
+
+func TestNewReplayTransport_MissingDir(t *testing.T) {
+	_, err := NewReplayTransport(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestSanitizeBody(t *testing.T) {
+	body := []byte(`{"token": "abc", "id": 12345678901234567890, "nested": [{"api_key": "k", "name": "n"}]}`)
+	got := string(sanitizeBody(body, ""))
+	assert.Contains(t, got, `"token":"****"`)
+	assert.Contains(t, got, `"api_key":"****"`)
+	assert.Contains(t, got, `"name":"n"`)
+	assert.Contains(t, got, "12345678901234567890", "numbers keep their precision")
+
+	assert.Equal(t, "plain ****", string(sanitizeBody([]byte("plain secret"), "secret")))
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RequestIDHeader carries the run's correlation ID on every request, and
+// Vantage's own ID for the request on its response.
+const RequestIDHeader = "X-Request-Id"
+
+// correlationIDKey is the context key of the correlation ID.
+type correlationIDKey struct{}
+
+// NewCorrelationID returns a random correlation ID.
+func NewCorrelationID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// WithCorrelationID returns ctx carrying id. The client sends it as the
+// X-Request-Id header of every request made with ctx, and the loggers add
+// it to every message as correlation_id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID ctx carries, or "".
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// withCorrelationField returns fields with ctx's correlation ID added, or
+// fields itself when ctx carries none. The caller's map is left as is.
+func withCorrelationField(ctx context.Context, fields map[string]interface{}) map[string]interface{} {
+	id := CorrelationID(ctx)
+	if id == "" {
+		return fields
+	}
+	if _, ok := fields["correlation_id"]; ok {
+		return fields
+	}
+	withID := make(map[string]interface{}, len(fields)+1)
+	for key, value := range fields {
+		withID[key] = value
+	}
+	withID["correlation_id"] = id
+	return withID
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CorrelationID(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, "req_vantage_42")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error": "bad filter"}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	c, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		Timeout:    5 * time.Second,
+		MaxRetries: 0,
+		Logger:     NewConsoleLogger(&logs, LevelDebug),
+	})
+	require.NoError(t, err)
+
+	ctx := WithCorrelationID(context.Background(), "run-1234")
+	_, err = c.Costs(ctx, Query{Granularity: "day"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"run-1234"}, received)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "req_vantage_42", apiErr.RequestID)
+	assert.Contains(t, err.Error(), "request ID req_vantage_42")
+
+	assert.Contains(t, logs.String(), "correlation_id=run-1234")
+	assert.Contains(t, logs.String(), "request_id=req_vantage_42")
+
+	// Without a correlation ID no header is sent.
+	received = nil
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.Error(t, err)
+	assert.Equal(t, []string{""}, received)
+}
+
+func TestNewCorrelationID(t *testing.T) {
+	id := NewCorrelationID()
+	assert.Len(t, id, 32)
+	assert.NotEqual(t, id, NewCorrelationID())
+	assert.Empty(t, CorrelationID(context.Background()))
+	assert.Equal(t, id, CorrelationID(WithCorrelationID(context.Background(), id)))
+}
+
+func TestWithCorrelationField(t *testing.T) {
+	fields := map[string]interface{}{"operation": "sync"}
+	assert.Equal(t, fields, withCorrelationField(context.Background(), fields))
+
+	ctx := WithCorrelationID(context.Background(), "run-1234")
+	assert.Equal(t, map[string]interface{}{"operation": "sync", "correlation_id": "run-1234"},
+		withCorrelationField(ctx, fields))
+	assert.Equal(t, map[string]interface{}{"operation": "sync"}, fields, "the caller's map is unchanged")
+	assert.Equal(t, map[string]interface{}{"correlation_id": "run-1234"}, withCorrelationField(ctx, nil))
+}
+
+func TestClient_NoRetryOn4xxWithStatusDigitsInRequestID(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set(RequestIDHeader, "ab500c3f9e1d4b7a8c2e6f0d1a3b5c7e")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": "report 503 not found"}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		Timeout:    5 * time.Second,
+		MaxRetries: 3,
+		Logger:     NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request ID ab500c")
+	assert.Equal(t, 1, calls, "a 4xx is not retried whatever its request ID or body holds")
+}
+
+func TestHTTPClient_ShouldRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "500", err: &APIError{StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "502", err: &APIError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "503", err: &APIError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "504", err: &APIError{StatusCode: http.StatusGatewayTimeout}, want: true},
+		{name: "501", err: &APIError{StatusCode: http.StatusNotImplemented}, want: false},
+		{name: "505", err: &APIError{StatusCode: http.StatusHTTPVersionNotSupported}, want: false},
+		{name: "404", err: &APIError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "wrapped 503", err: fmt.Errorf("costs: %w", &APIError{StatusCode: 503}), want: true},
+		{name: "rate limited", err: &rateLimitError{resetIn: time.Second}, want: true},
+		{name: "network", err: fmt.Errorf("executing request: %w", &net.OpError{Op: "dial"}), want: true},
+		{name: "canceled", err: fmt.Errorf("executing request: %w", context.Canceled), want: false},
+	}
+
+	c := &httpClient{maxRetries: 3}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, c.shouldRetry(tt.err, 0))
+		})
+	}
+}
+
+func TestClient_RetryOnNetworkError(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls == 1 {
+			// Drop the connection without a response.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"costs": [], "links": {}}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		Timeout:    5 * time.Second,
+		MaxRetries: 1,
+		Logger:     NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
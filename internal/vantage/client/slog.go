@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// slogLogger adapts a *slog.Logger to Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger emitting through l, so output format and
+// destination are up to l's handler. Fields become attributes; errors are
+// rendered with their message.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{logger: l}
+}
+
+// NewJSONLogger returns a Logger writing one JSON object per message to w,
+// dropping messages below level.
+func NewJSONLogger(w io.Writer, level Level) Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return NewLevelFilter(NewSlogLogger(slog.New(handler)), level)
+}
+
+func (s *slogLogger) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, slog.LevelDebug, msg, fields)
+}
+
+func (s *slogLogger) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, slog.LevelInfo, msg, fields)
+}
+
+func (s *slogLogger) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, slog.LevelWarn, msg, fields)
+}
+
+func (s *slogLogger) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, slog.LevelError, msg, fields)
+}
+
+func (s *slogLogger) log(ctx context.Context, level slog.Level, msg string, fields map[string]interface{}) {
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+	fields = withCorrelationField(ctx, fields)
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, key := range orderedKeys(fields) {
+		value := fields[key]
+		if err, ok := value.(error); ok && err != nil {
+			value = err.Error()
+		}
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	s.logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// ListTags implements Client.ListTags.
+func (c *client) ListTags(ctx context.Context, workspaceToken string) ([]Tag, error) {
+	params := url.Values{}
+	if workspaceToken != "" {
+		params.Set("workspace_token", workspaceToken)
+	}
+
+	return listAll(ctx, c.httpClient, "list_tags", "/tags", params,
+		func(resp *tagsResponse) ([]Tag, string) {
+			return resp.Tags, resp.Links.Next
+		})
+}
+
+// ListTagValues implements Client.ListTagValues.
+func (c *client) ListTagValues(ctx context.Context, workspaceToken, key string) ([]TagValue, error) {
+	if key == "" {
+		return nil, errors.New("tag key is required")
+	}
+
+	params := url.Values{}
+	if workspaceToken != "" {
+		params.Set("workspace_token", workspaceToken)
+	}
+
+	return listAll(ctx, c.httpClient, "list_tag_values", "/tags/"+url.PathEscape(key)+"/values", params,
+		func(resp *tagValuesResponse) ([]TagValue, string) {
+			return resp.TagValues, resp.Links.Next
+		})
+}
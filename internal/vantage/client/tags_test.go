@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tags", r.URL.Path)
+		assert.Equal(t, "wrkspc_1", r.URL.Query().Get("workspace_token"))
+
+		_, _ = w.Write([]byte(`{"tags": [{"tag_key": "team", "providers": ["aws"]}], "links": {}}`))
+	}))
+	defer server.Close()
+
+	tags, err := newTestClient(t, server.URL, 0).ListTags(context.Background(), "wrkspc_1")
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "team", tags[0].Key)
+	assert.Equal(t, []string{"aws"}, tags[0].Providers)
+}
+
+func TestClient_ListTagValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tags/cost center/values", r.URL.Path)
+		assert.Equal(t, "/tags/cost%20center/values", r.URL.EscapedPath())
+
+		_, _ = w.Write([]byte(`{"tag_values": [{"tag_value": "platform"}, {"tag_value": "data"}], "links": {}}`))
+	}))
+	defer server.Close()
+
+	values, err := newTestClient(t, server.URL, 0).ListTagValues(context.Background(), "", "cost center")
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	assert.Equal(t, "platform", values[0].Value)
+
+	_, err = newTestClient(t, server.URL, 0).ListTagValues(context.Background(), "", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oauth2ExpiryMargin renews OAuth2 tokens this long before they expire,
+	// so a request never starts with a token about to lapse.
+	oauth2ExpiryMargin = 30 * time.Second
+
+	// oauth2Timeout bounds a token request when OAuth2Config.HTTPClient is
+	// not set.
+	oauth2Timeout = 30 * time.Second
+)
+
+// TokenProvider supplies the bearer token sent with each request.
+type TokenProvider interface {
+	// Token returns the token to send, renewing it first when it has
+	// expired or was invalidated.
+	Token(ctx context.Context) (string, error)
+	// Invalidate reports that token was rejected with a 401, so the next
+	// Token call renews it. Invalidating a token other than the current one
+	// is a no-op, so concurrent rejections renew only once.
+	Invalidate(token string)
+}
+
+// staticToken is a token that never changes.
+type staticToken string
+
+// NewStaticTokenProvider returns a provider always supplying token.
+func NewStaticTokenProvider(token string) TokenProvider {
+	return staticToken(token)
+}
+
+func (t staticToken) Token(context.Context) (string, error) {
+	if t == "" {
+		return "", errors.New("token is required")
+	}
+	return string(t), nil
+}
+
+func (staticToken) Invalidate(string) {}
+
+// envToken reads the token from an environment variable on every request,
+// so a host process that rotates the variable is picked up without a
+// restart.
+type envToken struct {
+	name     string
+	fallback string
+}
+
+// NewEnvTokenProvider returns a provider reading the token from the
+// environment variable name, or fallback while it is unset or empty.
+func NewEnvTokenProvider(name, fallback string) TokenProvider {
+	return &envToken{name: name, fallback: fallback}
+}
+
+func (e *envToken) Token(context.Context) (string, error) {
+	if token := os.Getenv(e.name); token != "" {
+		return token, nil
+	}
+	if e.fallback != "" {
+		return e.fallback, nil
+	}
+	return "", fmt.Errorf("environment variable %s is not set", e.name)
+}
+
+func (*envToken) Invalidate(string) {}
+
+// OAuth2Config configures the OAuth2 client-credentials flow.
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// HTTPClient sends token requests; nil uses a client with a 30 second
+	// timeout over Transport, so token requests take the same proxy and TLS
+	// settings as API requests.
+	HTTPClient *http.Client
+	Transport  http.RoundTripper
+}
+
+// oauth2Token obtains tokens with the client-credentials flow and keeps the
+// current one until shortly before it expires. Renewals are serialized, so
+// callers arriving while one is in flight wait for it and share its token.
+type oauth2Token struct {
+	config OAuth2Config
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewOAuth2TokenProvider returns a provider obtaining tokens from
+// config.TokenURL with the client-credentials grant.
+func NewOAuth2TokenProvider(config OAuth2Config) (TokenProvider, error) {
+	if config.TokenURL == "" || config.ClientID == "" || config.ClientSecret == "" {
+		return nil, errors.New("oauth2 token_url, client_id, and client_secret are required")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: oauth2Timeout, Transport: config.Transport}
+	}
+	return &oauth2Token{config: config, now: time.Now}, nil
+}
+
+func (o *oauth2Token) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != "" && (o.expires.IsZero() || o.now().Before(o.expires)) {
+		return o.token, nil
+	}
+
+	token, expiresIn, err := o.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	o.token = token
+	o.expires = time.Time{}
+	if expiresIn > 0 {
+		o.expires = o.now().Add(expiresIn - oauth2ExpiryMargin)
+	}
+	return token, nil
+}
+
+func (o *oauth2Token) Invalidate(token string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if token == o.token {
+		o.token = ""
+	}
+}
+
+// fetch requests a new token, returning it with its lifetime (zero when
+// the server does not say).
+func (o *oauth2Token) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(o.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("creating oauth2 token request: %w", err)
+	}
+	req.SetBasicAuth(url.QueryEscape(o.config.ClientID), url.QueryEscape(o.config.ClientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.config.HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("requesting oauth2 token: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", 0, fmt.Errorf("requesting oauth2 token: %w",
+			&APIError{StatusCode: resp.StatusCode, Body: RedactString(string(body))})
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", 0, fmt.Errorf("decoding oauth2 token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", 0, errors.New("oauth2 token response has no access_token")
+	}
+	return tokenResp.AccessToken, time.Duration(tokenResp.ExpiresIn) * time.Second, nil
+}
+
+// authorize sets req's bearer token from the client's token provider.
+func (c *httpClient) authorize(ctx context.Context, req *http.Request) error {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("getting API token: %w", err)
+	}
+	c.learnToken(token)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// learnToken has the client's logger mask token, which may come from a
+// provider renewing it, wherever it is echoed in a logged response or error.
+func (c *httpClient) learnToken(token string) {
+	if r, ok := c.logger.(*redactingLogger); ok {
+		r.learn(token)
+	}
+}
+
+// reauthorize prepares req to be sent again after a 401: the rejected
+// token is invalidated and req cloned with a renewed one. It reports false
+// when no different token is available or req's body cannot be replayed.
+func (c *httpClient) reauthorize(ctx context.Context, req *http.Request) (*http.Request, bool) {
+	rejected := bearerToken(req)
+	c.tokens.Invalidate(rejected)
+
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "Failed to renew API token", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "token_renewal",
+			"attempt":   0,
+			"error":     err,
+		})
+		return nil, false
+	}
+	if token == rejected {
+		return nil, false
+	}
+	c.learnToken(token)
+
+	renewed := req.Clone(ctx)
+	if req.Body != nil {
+		if req.GetBody == nil {
+			return nil, false
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		renewed.Body = body
+	}
+	renewed.Header.Set("Authorization", "Bearer "+token)
+
+	c.logger.Info(ctx, "Renewed API token after 401", map[string]interface{}{
+		"adapter":   "vantage",
+		"operation": "token_renewal",
+		"attempt":   0,
+	})
+	return renewed, true
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oauth2Server issues token-1, token-2, ... and counts the grants.
+func oauth2Server(t *testing.T, expiresIn int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var grants atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client", id)
+		assert.Equal(t, "secret", secret)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "costs:read", r.PostForm.Get("scope"))
+
+		n := grants.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": %d}`, n, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+	return server, &grants
+}
+
+func TestOAuth2TokenProvider(t *testing.T) {
+	server, grants := oauth2Server(t, 3600)
+	provider, err := NewOAuth2TokenProvider(OAuth2Config{
+		TokenURL: server.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"costs:read"},
+	})
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider.(*oauth2Token).now = func() time.Time { return now }
+
+	ctx := context.Background()
+	token, err := provider.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	token, _ = provider.Token(ctx)
+	assert.Equal(t, "token-1", token)
+
+	provider.Invalidate("token-0")
+	token, _ = provider.Token(ctx)
+	assert.Equal(t, "token-1", token, "invalidating a stale token keeps the current one")
+
+	now = now.Add(time.Hour - oauth2ExpiryMargin)
+	token, _ = provider.Token(ctx)
+	assert.Equal(t, "token-2", token, "renewed shortly before expiry")
+
+	provider.Invalidate("token-2")
+	token, _ = provider.Token(ctx)
+	assert.Equal(t, "token-3", token)
+	assert.Equal(t, int32(3), grants.Load())
+
+	_, err = NewOAuth2TokenProvider(OAuth2Config{TokenURL: server.URL})
+	require.Error(t, err)
+}
+
+func TestEnvTokenProvider(t *testing.T) {
+	provider := NewEnvTokenProvider("TEST_VANTAGE_TOKEN", "fallback")
+
+	t.Setenv("TEST_VANTAGE_TOKEN", "")
+	token, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", token)
+
+	t.Setenv("TEST_VANTAGE_TOKEN", "rotated")
+	token, err = provider.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", token)
+
+	_, err = NewEnvTokenProvider("TEST_VANTAGE_TOKEN_UNSET", "").Token(context.Background())
+	require.ErrorContains(t, err, "TEST_VANTAGE_TOKEN_UNSET")
+}
+
+func TestClient_RenewsTokenOnceOn401(t *testing.T) {
+	tokenServer, grants := oauth2Server(t, 0)
+	provider, err := NewOAuth2TokenProvider(OAuth2Config{
+		TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"costs:read"},
+	})
+	require.NoError(t, err)
+
+	var rejected atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "Bearer token-2", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer api.Close()
+
+	c, err := New(Config{BaseURL: api.URL, TokenProvider: provider})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, costsErr := c.Costs(context.Background(), Query{Granularity: "day"})
+			assert.NoError(t, costsErr)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), grants.Load(), "concurrent 401s renew the token once")
+	assert.Equal(t, int32(5), rejected.Load())
+}
+
+func TestClient_StaticToken401IsNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, Token: "test-token"})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.Error(t, err)
+	assert.True(t, IsAuthError(err))
+	assert.Equal(t, int32(1), requests.Load())
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the instrumentation scope of these spans.
+const tracerName = "github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+
+// tracer creates the client's spans. The global provider is looked up on
+// each call, so spans are no-ops until tracing is set up and follow a
+// provider replaced between profiles.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// finishSpan records err on span, if any, and ends it.
+func finishSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
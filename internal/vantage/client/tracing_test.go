@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHTTPClient_TracesRequests(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		BaseURL:    server.URL,
+		Token:      "test-token",
+		Timeout:    5 * time.Second,
+		MaxRetries: 0,
+		Logger:     NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	pager := NewPager(c, Query{CostReportToken: "cr_secret", Granularity: "day"}, NewNoopLogger())
+	_, err = pager.AllPages(context.Background())
+	require.NoError(t, err)
+	_, err = c.Forecast(context.Background(), "cr_secret", ForecastQuery{Granularity: "day"})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	// The costs request is a child of the page span.
+	assert.Equal(t, "HTTP GET", spans[0].Name())
+	assert.Equal(t, "vantage.costs_page", spans[1].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[2].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "/cost_reports/****/forecast", attrs["url.path"].AsString(), "tokens are redacted")
+	assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	for _, span := range spans {
+		for _, kv := range span.Attributes() {
+			assert.NotContains(t, kv.Value.Emit(), "cr_secret")
+			assert.NotContains(t, kv.Value.Emit(), "test-token")
+		}
+	}
+}
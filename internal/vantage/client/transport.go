@@ -0,0 +1,127 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost keeps enough idle connections to the API
+	// for paging and concurrent requests to reuse; Go's default is 2.
+	DefaultMaxIdleConnsPerHost = 10
+
+	defaultIdleConnTimeout = 90 * time.Second
+
+	// HTTP/2 health checks: a connection that has received nothing for
+	// http2SendPingTimeout is pinged, and closed if the ping goes
+	// unanswered for http2PingTimeout, so a half-dead connection fails fast
+	// instead of stalling a sync until the request timeout.
+	http2SendPingTimeout = 30 * time.Second
+	http2PingTimeout     = 15 * time.Second
+)
+
+// NewTransport returns the HTTP transport the client uses unless
+// Config.Transport is set. Responses are requested gzip-compressed and
+// decompressed transparently unless Config.DisableCompression is set; cost
+// pages are large, repetitive JSON and compress well. HTTP/2 is attempted
+// with health-check pings, and idle connections are kept per
+// Config.MaxIdleConnsPerHost.
+//
+// Requests go through Config.ProxyURL when set, or otherwise the proxy
+// named by the HTTPS_PROXY and NO_PROXY environment variables. Config.CABundle
+// adds certificate authorities to the system pool, e.g. that of a proxy
+// intercepting TLS, and Config.ClientCert and Config.ClientKey present a
+// client certificate. An error is returned when any of them cannot be used.
+func NewTransport(config Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.ProxyURL != "" {
+		proxy, err := ParseProxyURL(config.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	if transport.MaxIdleConnsPerHost <= 0 {
+		transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = transport.MaxIdleConnsPerHost
+	}
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+
+	// Leaving Accept-Encoding to the transport is what makes it decompress
+	// responses itself, so requests must not set that header.
+	transport.DisableCompression = config.DisableCompression
+
+	transport.ForceAttemptHTTP2 = true
+	transport.HTTP2 = &http.HTTP2Config{
+		SendPingTimeout: http2SendPingTimeout,
+		PingTimeout:     http2PingTimeout,
+	}
+	return transport, nil
+}
+
+// ParseProxyURL parses a proxy URL, which must be absolute with an http,
+// https, or socks5 scheme.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https, or socks5", proxy.Redacted())
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy.Redacted())
+	}
+	return proxy, nil
+}
+
+// newTLSConfig returns the TLS settings of config's CA bundle and client
+// certificate.
+func newTLSConfig(config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CABundle != "" {
+		pem, err := os.ReadFile(config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", config.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (config.ClientCert == "") != (config.ClientKey == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+	if config.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
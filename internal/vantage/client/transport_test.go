@@ -0,0 +1,172 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gzipServer compresses costs responses when the client accepts gzip and
+// reports whether the last request did.
+func gzipServer(t *testing.T, acceptedGzip *bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*acceptedGzip = strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		resp := CostsResponse{Data: []CostRow{{Service: "ec2", Cost: 1.5}}}
+		if !*acceptedGzip {
+			_ = json.NewEncoder(w).Encode(resp)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_ = json.NewEncoder(gz).Encode(resp)
+		_ = gz.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_Gzip(t *testing.T) {
+	query := Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Granularity:     "day",
+	}
+
+	for _, disable := range []bool{false, true} {
+		var acceptedGzip bool
+		server := gzipServer(t, &acceptedGzip)
+		c, err := New(Config{BaseURL: server.URL, Token: "test-token", DisableCompression: disable})
+		require.NoError(t, err)
+
+		page, err := c.Costs(context.Background(), query)
+		require.NoError(t, err)
+		require.Len(t, page.Data, 1)
+		assert.Equal(t, "ec2", page.Data[0].Service)
+		assert.Equal(t, !disable, acceptedGzip)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.False(t, transport.DisableCompression)
+	assert.True(t, transport.ForceAttemptHTTP2)
+	require.NotNil(t, transport.HTTP2)
+	assert.Equal(t, http2SendPingTimeout, transport.HTTP2.SendPingTimeout)
+
+	transport, err = NewTransport(Config{MaxIdleConnsPerHost: 500, DisableCompression: true})
+	require.NoError(t, err)
+	assert.Equal(t, 500, transport.MaxIdleConnsPerHost)
+	assert.GreaterOrEqual(t, transport.MaxIdleConns, 500)
+	assert.True(t, transport.DisableCompression)
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CostsResponse{Data: []CostRow{{Service: "ec2"}}})
+	}))
+	t.Cleanup(proxy.Close)
+
+	c, err := New(Config{BaseURL: "http://api.vantage.test", Token: "test-token", ProxyURL: proxy.URL})
+	require.NoError(t, err)
+	page, err := c.Costs(context.Background(), Query{CostReportToken: "cr_test", Granularity: "day"})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, "api.vantage.test", proxiedHost)
+
+	for _, raw := range []string{"ftp://proxy.test", "proxy.test:3128", "http://"} {
+		_, err = NewTransport(Config{ProxyURL: raw})
+		assert.ErrorContains(t, err, "invalid proxy URL", raw)
+	}
+}
+
+func TestNewTransport_CABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CostsResponse{})
+	}))
+	t.Cleanup(server.Close)
+	query := Query{CostReportToken: "cr_test", Granularity: "day"}
+
+	// The test server's certificate is not trusted without the bundle.
+	c, err := New(Config{BaseURL: server.URL, Token: "test-token", MaxRetries: 1})
+	require.NoError(t, err)
+	_, err = c.Costs(context.Background(), query)
+	require.Error(t, err)
+
+	bundle := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	c, err = New(Config{BaseURL: server.URL, Token: "test-token", CABundle: bundle})
+	require.NoError(t, err)
+	_, err = c.Costs(context.Background(), query)
+	require.NoError(t, err)
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+	_, err = NewTransport(Config{CABundle: empty})
+	assert.ErrorContains(t, err, "contains no PEM certificates")
+
+	_, err = NewTransport(Config{CABundle: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "reading CA bundle")
+}
+
+func TestNewTransport_ClientCert(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pulumicost-vantage"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile := writePEM(t, "client.pem", "CERTIFICATE", der)
+	keyFile := writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER)
+
+	transport, err := NewTransport(Config{ClientCert: certFile, ClientKey: keyFile})
+	require.NoError(t, err)
+	require.Len(t, transport.TLSClientConfig.Certificates, 1)
+
+	_, err = NewTransport(Config{ClientCert: certFile})
+	assert.ErrorContains(t, err, "must be set together")
+
+	_, err = NewTransport(Config{ClientCert: certFile, ClientKey: certFile})
+	assert.ErrorContains(t, err, "loading client certificate")
+}
+
+// writePEM writes a PEM block of the given type to a temporary file and
+// returns its path.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
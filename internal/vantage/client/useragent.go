@@ -0,0 +1,62 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// userAgentProduct names the plugin in the User-Agent header.
+const userAgentProduct = "pulumicost-vantage"
+
+// reservedHeaders are set by the client itself and cannot be overridden by
+// Config.Headers.
+var reservedHeaders = []string{"Authorization", "User-Agent", RequestIDHeader, "Content-Type", "Accept", "Host"}
+
+// UserAgent builds the User-Agent header from the plugin version, the Go
+// runtime and platform, and an optional operator-supplied suffix naming
+// the caller:
+//
+//	pulumicost-vantage/1.4.0 (go1.24.9; linux/amd64) acme-finops/2.1
+func UserAgent(version, suffix string) string {
+	if version == "" {
+		version = "dev"
+	}
+	ua := fmt.Sprintf("%s/%s (%s; %s/%s)", userAgentProduct, version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		ua += " " + suffix
+	}
+	return ua
+}
+
+// ValidateHeaders checks that headers are well-formed and that none of them
+// is one the client sets itself.
+func ValidateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsFunc(name, func(r rune) bool {
+			return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+		}) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("header %s: value cannot contain line breaks", name)
+		}
+		for _, reserved := range reservedHeaders {
+			if strings.EqualFold(name, reserved) {
+				return fmt.Errorf("header %s is set by the client and cannot be overridden", reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// setDefaultHeaders sets the headers every API request carries: the
+// User-Agent, Accept, and the configured extra headers.
+func (c *httpClient) setDefaultHeaders(req *http.Request) {
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgent(t *testing.T) {
+	platform := " (" + runtime.Version() + "; " + runtime.GOOS + "/" + runtime.GOARCH + ")"
+	assert.Equal(t, "pulumicost-vantage/1.4.0"+platform, UserAgent("1.4.0", ""))
+	assert.Equal(t, "pulumicost-vantage/dev"+platform, UserAgent("", " "))
+	assert.Equal(t, "pulumicost-vantage/1.4.0"+platform+" acme-finops/2.1", UserAgent("1.4.0", " acme-finops/2.1 "))
+}
+
+func TestValidateHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "gateway headers", headers: map[string]string{"X-Api-Gateway-Key": "abc", "X-Team": "finops"}},
+		{name: "invalid name", headers: map[string]string{"X Team": "finops"}, wantErr: `invalid header name "X Team"`},
+		{name: "line break", headers: map[string]string{"X-Team": "a\r\nb"}, wantErr: "line breaks"},
+		{name: "reserved", headers: map[string]string{"authorization": "Bearer x"}, wantErr: "Authorization is set by the client"},
+		{name: "user agent", headers: map[string]string{"User-Agent": "x"}, wantErr: "User-Agent is set by the client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHeaders(tt.headers)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestClient_DefaultHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		BaseURL:   server.URL,
+		Token:     "test-token",
+		Timeout:   5 * time.Second,
+		Logger:    NewNoopLogger(),
+		UserAgent: UserAgent("1.4.0", "acme-finops/2.1"),
+		Headers:   map[string]string{"X-Api-Gateway-Key": "abc"},
+	})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.NoError(t, err)
+	assert.Equal(t, UserAgent("1.4.0", "acme-finops/2.1"), got.Get("User-Agent"))
+	assert.Equal(t, "abc", got.Get("X-Api-Gateway-Key"))
+	assert.Equal(t, "Bearer test-token", got.Get("Authorization"))
+
+	_, err = New(Config{Token: "test-token", Headers: map[string]string{"Authorization": "x"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,414 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// API versions accepted by Config.APIVersion. APIVersionAuto uses v2 when
+// the API answers on it and v1 otherwise.
+const (
+	APIVersionV1   = "v1"
+	APIVersionV2   = "v2"
+	APIVersionAuto = "auto"
+)
+
+// SupportedAPIVersions returns the accepted Config.APIVersion values.
+func SupportedAPIVersions() []string {
+	return []string{APIVersionV1, APIVersionV2, APIVersionAuto}
+}
+
+// VersionedAPI is the wire format of the costs and forecast endpoints in one
+// Vantage API version: where they live, how a query is encoded, and how a
+// response decodes. Every other endpoint is the same in all versions.
+type VersionedAPI interface {
+	// Version is the version name, e.g. "v1".
+	Version() string
+
+	// CostsPath and CostsParams build a costs request; DecodeCosts reads
+	// its response.
+	CostsPath() string
+	CostsParams(query Query) url.Values
+	DecodeCosts(query Query, body io.Reader) (Page, error)
+
+	// ForecastPath and ForecastParams build a forecast request;
+	// DecodeForecast reads its response.
+	ForecastPath(reportToken string) string
+	ForecastParams(query ForecastQuery) url.Values
+	DecodeForecast(query ForecastQuery, body io.Reader) (Forecast, error)
+}
+
+// NewVersionedAPI returns the wire format of version, which must be
+// APIVersionV1 or APIVersionV2.
+func NewVersionedAPI(version string) (VersionedAPI, error) {
+	switch version {
+	case APIVersionV1:
+		return v1API{}, nil
+	case APIVersionV2:
+		return v2API{}, nil
+	default:
+		return nil, fmt.Errorf("invalid API version: %s (valid: %s, %s)", version, APIVersionV1, APIVersionV2)
+	}
+}
+
+// v1API is the original wire format: RFC 3339 bounds, array parameters,
+// and a data/next_cursor/has_more envelope.
+type v1API struct{}
+
+func (v1API) Version() string { return APIVersionV1 }
+
+func (v1API) CostsPath() string { return "/costs" }
+
+func (v1API) CostsParams(query Query) url.Values {
+	q := url.Values{}
+	if query.WorkspaceToken != "" {
+		q.Set("workspace_token", query.WorkspaceToken)
+	}
+	if query.CostReportToken != "" {
+		q.Set("cost_report_token", query.CostReportToken)
+	}
+	q.Set("start_at", query.StartAt.Format(time.RFC3339))
+	q.Set("end_at", query.EndAt.Format(time.RFC3339))
+	q.Set("granularity", query.Granularity)
+
+	for _, gb := range query.GroupBys {
+		q.Add("group_bys[]", gb)
+	}
+	for _, m := range query.Metrics {
+		q.Add("metrics[]", m)
+	}
+
+	if query.PageSize > 0 {
+		q.Set("page_size", strconv.Itoa(query.PageSize))
+	}
+	if query.Cursor != "" {
+		q.Set("cursor", query.Cursor)
+	}
+	if query.Filter != "" {
+		q.Set("filter", query.Filter)
+	}
+	if query.IncludeUnallocated {
+		q.Set("settings[unallocated]", "true")
+	}
+	return q
+}
+
+func (v1API) DecodeCosts(_ Query, body io.Reader) (Page, error) {
+	var costsResp CostsResponse
+	if err := json.NewDecoder(body).Decode(&costsResp); err != nil {
+		return Page{}, fmt.Errorf("decoding response: %w", err)
+	}
+	return Page{Data: costsResp.Data, NextCursor: costsResp.NextCursor, HasMore: costsResp.HasMore}, nil
+}
+
+func (v1API) ForecastPath(reportToken string) string {
+	return "/cost_reports/" + reportToken + "/forecast"
+}
+
+func (v1API) ForecastParams(query ForecastQuery) url.Values {
+	q := url.Values{}
+	q.Set("start_at", query.StartAt.Format(time.RFC3339))
+	q.Set("end_at", query.EndAt.Format(time.RFC3339))
+	q.Set("granularity", query.Granularity)
+	for _, gb := range query.GroupBys {
+		q.Add("group_bys[]", gb)
+	}
+	return q
+}
+
+func (v1API) DecodeForecast(_ ForecastQuery, body io.Reader) (Forecast, error) {
+	var forecastResp ForecastResponse
+	if err := json.NewDecoder(body).Decode(&forecastResp); err != nil {
+		return Forecast{}, fmt.Errorf("decoding response: %w", err)
+	}
+	return Forecast(forecastResp), nil
+}
+
+// v2API is the /v2 wire format: date-only bounds, comma-separated lists,
+// amounts as decimal strings, and a links envelope whose next link carries
+// the page to fetch.
+type v2API struct{}
+
+// v2Links is the pagination envelope of v2 list responses.
+type v2Links struct {
+	Next string `json:"next"`
+}
+
+// v2CostRow is one row of a v2 costs response. Buckets are identified by
+// their start date only.
+type v2CostRow struct {
+	AccruedAt        string            `json:"accrued_at"`
+	Provider         string            `json:"provider"`
+	Service          string            `json:"service"`
+	AccountID        string            `json:"account_id"`
+	BillingAccountID string            `json:"billing_account_id"`
+	Project          string            `json:"project"`
+	Region           string            `json:"region"`
+	ResourceID       string            `json:"resource_id"`
+	Tags             map[string]string `json:"tags"`
+	Amount           json.Number       `json:"amount"`
+	UsageQuantity    json.Number       `json:"usage_quantity"`
+	UsageUnit        string            `json:"usage_unit"`
+	ListAmount       json.Number       `json:"list_amount"`
+	AmortizedAmount  json.Number       `json:"amortized_amount"`
+	TaxAmount        json.Number       `json:"tax_amount"`
+	CreditAmount     json.Number       `json:"credit_amount"`
+	RefundAmount     json.Number       `json:"refund_amount"`
+	Currency         string            `json:"currency"`
+	Unallocated      bool              `json:"unallocated"`
+
+	Extra map[string]interface{} `json:"-"`
+}
+
+// v2CostsResponse is the v2 costs response envelope.
+type v2CostsResponse struct {
+	Links v2Links     `json:"links"`
+	Costs []v2CostRow `json:"costs"`
+}
+
+// v2ForecastRow is one row of a v2 forecast response.
+type v2ForecastRow struct {
+	Date     string      `json:"date"`
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+	Provider string      `json:"provider"`
+	Service  string      `json:"service"`
+
+	Extra map[string]interface{} `json:"-"`
+}
+
+// v2ForecastResponse is the v2 forecast response envelope.
+type v2ForecastResponse struct {
+	Links           v2Links         `json:"links"`
+	ForecastedCosts []v2ForecastRow `json:"forecasted_costs"`
+}
+
+func (v2API) Version() string { return APIVersionV2 }
+
+func (v2API) CostsPath() string { return "/v2/costs" }
+
+func (v2API) CostsParams(query Query) url.Values {
+	q := url.Values{}
+	if query.WorkspaceToken != "" {
+		q.Set("workspace_token", query.WorkspaceToken)
+	}
+	if query.CostReportToken != "" {
+		q.Set("cost_report_token", query.CostReportToken)
+	}
+	q.Set("start_date", query.StartAt.Format(time.DateOnly))
+	q.Set("end_date", query.EndAt.Format(time.DateOnly))
+	q.Set("date_bin", query.Granularity)
+	if len(query.GroupBys) > 0 {
+		q.Set("groupings", strings.Join(query.GroupBys, ","))
+	}
+	if len(query.Metrics) > 0 {
+		q.Set("metrics", strings.Join(query.Metrics, ","))
+	}
+
+	if query.PageSize > 0 {
+		q.Set("limit", strconv.Itoa(query.PageSize))
+	}
+	if query.Cursor != "" {
+		q.Set("page", query.Cursor)
+	}
+	if query.Filter != "" {
+		q.Set("filter", query.Filter)
+	}
+	if query.IncludeUnallocated {
+		q.Set("settings[include_unallocated_costs]", "true")
+	}
+	return q
+}
+
+func (v2API) DecodeCosts(query Query, body io.Reader) (Page, error) {
+	var costsResp v2CostsResponse
+	if err := json.NewDecoder(body).Decode(&costsResp); err != nil {
+		return Page{}, fmt.Errorf("decoding response: %w", err)
+	}
+
+	page := Page{Data: make([]CostRow, 0, len(costsResp.Costs))}
+	for i := range costsResp.Costs {
+		row, err := costsResp.Costs[i].costRow(query.Granularity)
+		if err != nil {
+			return Page{}, fmt.Errorf("decoding response: row %d: %w", i, err)
+		}
+		page.Data = append(page.Data, row)
+	}
+
+	next, err := v2NextPage(costsResp.Links.Next)
+	if err != nil {
+		return Page{}, fmt.Errorf("decoding response: %w", err)
+	}
+	page.NextCursor = next
+	page.HasMore = next != ""
+	return page, nil
+}
+
+// costRow converts the row to the version-independent CostRow, with a bucket
+// granularity ("day" or "month") long.
+func (r *v2CostRow) costRow(granularity string) (CostRow, error) {
+	start, err := time.Parse(time.DateOnly, r.AccruedAt)
+	if err != nil {
+		return CostRow{}, fmt.Errorf("parsing accrued_at: %w", err)
+	}
+
+	row := CostRow{
+		Provider:       r.Provider,
+		Service:        r.Service,
+		Account:        r.AccountID,
+		BillingAccount: r.BillingAccountID,
+		Project:        r.Project,
+		Region:         r.Region,
+		ResourceID:     r.ResourceID,
+		Tags:           r.Tags,
+		UsageUnit:      r.UsageUnit,
+		Currency:       r.Currency,
+		BucketStart:    start,
+		BucketEnd:      bucketEnd(start, granularity),
+		Unallocated:    r.Unallocated,
+		Extra:          r.Extra,
+	}
+	amounts := []struct {
+		name  string
+		value json.Number
+		dest  *float64
+	}{
+		{"amount", r.Amount, &row.Cost},
+		{"usage_quantity", r.UsageQuantity, &row.UsageQuantity},
+		{"list_amount", r.ListAmount, &row.ListCost},
+		{"amortized_amount", r.AmortizedAmount, &row.AmortizedCost},
+		{"tax_amount", r.TaxAmount, &row.Tax},
+		{"credit_amount", r.CreditAmount, &row.Credit},
+		{"refund_amount", r.RefundAmount, &row.Refund},
+	}
+	for _, amount := range amounts {
+		if *amount.dest, err = v2Amount(amount.value); err != nil {
+			return CostRow{}, fmt.Errorf("parsing %s: %w", amount.name, err)
+		}
+	}
+	if row.UsageQuantity != 0 {
+		row.EffectiveUnitPrice = row.Cost / row.UsageQuantity
+	}
+	return row, nil
+}
+
+func (v2API) ForecastPath(reportToken string) string {
+	return "/v2/cost_reports/" + reportToken + "/forecasted_costs"
+}
+
+func (v2API) ForecastParams(query ForecastQuery) url.Values {
+	q := url.Values{}
+	q.Set("start_date", query.StartAt.Format(time.DateOnly))
+	q.Set("end_date", query.EndAt.Format(time.DateOnly))
+	q.Set("date_bin", query.Granularity)
+	if len(query.GroupBys) > 0 {
+		q.Set("groupings", strings.Join(query.GroupBys, ","))
+	}
+	return q
+}
+
+// DecodeForecast reads a forecast response. Forecasts are short, so a next
+// link is not followed.
+func (v2API) DecodeForecast(query ForecastQuery, body io.Reader) (Forecast, error) {
+	var forecastResp v2ForecastResponse
+	if err := json.NewDecoder(body).Decode(&forecastResp); err != nil {
+		return Forecast{}, fmt.Errorf("decoding response: %w", err)
+	}
+
+	forecast := Forecast{Data: make([]ForecastRow, 0, len(forecastResp.ForecastedCosts))}
+	for i, r := range forecastResp.ForecastedCosts {
+		start, err := time.Parse(time.DateOnly, r.Date)
+		if err != nil {
+			return Forecast{}, fmt.Errorf("decoding response: row %d: parsing date: %w", i, err)
+		}
+		cost, err := v2Amount(r.Amount)
+		if err != nil {
+			return Forecast{}, fmt.Errorf("decoding response: row %d: parsing amount: %w", i, err)
+		}
+		forecast.Data = append(forecast.Data, ForecastRow{
+			BucketStart: start,
+			BucketEnd:   bucketEnd(start, query.Granularity),
+			Cost:        cost,
+			Currency:    r.Currency,
+			Provider:    r.Provider,
+			Service:     r.Service,
+			Extra:       r.Extra,
+		})
+	}
+	return forecast, nil
+}
+
+// v2Amount parses a v2 decimal amount, treating an omitted one as 0.
+func v2Amount(n json.Number) (float64, error) {
+	if n == "" {
+		return 0, nil
+	}
+	return n.Float64()
+}
+
+// v2NextPage extracts the page parameter of a next link, or "" when there
+// is no next page.
+func v2NextPage(next string) (string, error) {
+	if next == "" {
+		return "", nil
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("parsing next link: %w", err)
+	}
+	return u.Query().Get("page"), nil
+}
+
+// bucketEnd returns the end of the granularity ("day" or "month") bucket
+// starting at start.
+func bucketEnd(start time.Time, granularity string) time.Time {
+	if granularity == "month" {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// versionedAPI returns the wire format to use, negotiating it on first use
+// when the client was configured with APIVersionAuto. A 404 from the v2
+// ping means the API predates v2; any other failure is returned and the
+// negotiation retried on the next call.
+func (c *httpClient) versionedAPI(ctx context.Context) (VersionedAPI, error) {
+	c.apiMu.Lock()
+	defer c.apiMu.Unlock()
+
+	if c.api != nil {
+		return c.api, nil
+	}
+
+	api := VersionedAPI(v2API{})
+	err := c.doRequest(ctx, apiRequest{
+		operation: "api_version_negotiation",
+		method:    http.MethodGet,
+		path:      "/v2/ping",
+		noCache:   true,
+	})
+	if err != nil {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			return nil, fmt.Errorf("negotiating API version: %w", err)
+		}
+		api = v1API{}
+	}
+
+	c.logger.Info(ctx, "Negotiated API version", map[string]interface{}{
+		"adapter":     "vantage",
+		"operation":   "api_version_negotiation",
+		"attempt":     0,
+		"api_version": api.Version(),
+	})
+	c.api = api
+	return api, nil
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CostsV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/costs", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "cr_test", q.Get("cost_report_token"))
+		assert.Equal(t, "2024-01-01", q.Get("start_date"))
+		assert.Equal(t, "2024-02-01", q.Get("end_date"))
+		assert.Equal(t, "day", q.Get("date_bin"))
+		assert.Equal(t, "provider,service", q.Get("groupings"))
+		assert.Equal(t, "100", q.Get("limit"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("page") == "" {
+			_, _ = w.Write([]byte(`{
+				"links": {"next": "https://api.vantage.sh/v2/costs?page=2"},
+				"costs": [{"accrued_at": "2024-01-01", "provider": "aws", "service": "EC2",
+					"account_id": "123", "billing_account_id": "100", "amount": "100.50", "usage_quantity": "10", "currency": "USD"}]
+			}`))
+			return
+		}
+		assert.Equal(t, "2", q.Get("page"))
+		_, _ = w.Write([]byte(`{"links": {"next": null}, "costs": [{"accrued_at": "2024-01-02", "amount": 7}]}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, Token: "test-token", APIVersion: APIVersionV2})
+	require.NoError(t, err)
+
+	pager := NewPager(c, Query{
+		CostReportToken: "cr_test",
+		StartAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:           time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Granularity:     "day",
+		GroupBys:        []string{"provider", "service"},
+		PageSize:        100,
+	}, NewNoopLogger())
+	rows, err := pager.AllPages(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, "123", rows[0].Account)
+	assert.Equal(t, "100", rows[0].BillingAccount)
+	assert.InDelta(t, 100.50, rows[0].Cost, 1e-9)
+	assert.InDelta(t, 10.05, rows[0].EffectiveUnitPrice, 1e-9)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), rows[0].BucketEnd)
+	assert.InDelta(t, 7.0, rows[1].Cost, 1e-9)
+}
+
+func TestClient_ForecastV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/cost_reports/cr_test/forecasted_costs", r.URL.Path)
+		assert.Equal(t, "month", r.URL.Query().Get("date_bin"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"forecasted_costs": [{"date": "2024-02-01", "amount": "300", "currency": "USD"}]}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, Token: "test-token", APIVersion: APIVersionV2})
+	require.NoError(t, err)
+
+	forecast, err := c.Forecast(context.Background(), "cr_test", ForecastQuery{
+		StartAt:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Granularity: "month",
+	})
+	require.NoError(t, err)
+	require.Len(t, forecast.Data, 1)
+	assert.InDelta(t, 300.0, forecast.Data[0].Cost, 1e-9)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), forecast.Data[0].BucketEnd)
+}
+
+func TestClient_APIVersionNegotiation(t *testing.T) {
+	tests := []struct {
+		name       string
+		pingStatus int
+		wantPath   string
+	}{
+		{name: "v2 available", pingStatus: http.StatusOK, wantPath: "/v2/costs"},
+		{name: "v2 missing", pingStatus: http.StatusNotFound, wantPath: "/costs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pings atomic.Int32
+			var costsPath atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/ping" {
+					pings.Add(1)
+					w.WriteHeader(tt.pingStatus)
+					return
+				}
+				costsPath.Store(r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			c, err := New(Config{BaseURL: server.URL, Token: "test-token", APIVersion: APIVersionAuto})
+			require.NoError(t, err)
+
+			for range 2 {
+				_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPath, costsPath.Load())
+			assert.Equal(t, int32(1), pings.Load())
+		})
+	}
+}
+
+func TestClient_APIVersionNegotiationFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, Token: "test-token", APIVersion: APIVersionAuto})
+	require.NoError(t, err)
+
+	_, err = c.Costs(context.Background(), Query{Granularity: "day"})
+	require.ErrorContains(t, err, "negotiating API version")
+	assert.True(t, IsAuthError(err))
+}
+
+func TestNew_InvalidAPIVersion(t *testing.T) {
+	_, err := New(Config{Token: "test-token", APIVersion: "v3"})
+	require.ErrorContains(t, err, "invalid API version: v3")
+}
@@ -0,0 +1,13 @@
+package client
+
+import (
+	"context"
+)
+
+// ListWorkspaces implements Client.ListWorkspaces.
+func (c *client) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
+	return listAll(ctx, c.httpClient, "list_workspaces", "/workspaces", nil,
+		func(resp *workspacesResponse) ([]Workspace, string) {
+			return resp.Workspaces, resp.Links.Next
+		})
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ListWorkspaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/workspaces", r.URL.Path)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+		resp := workspacesResponse{}
+		if r.URL.Query().Get("page") == "1" {
+			resp.Workspaces = []Workspace{{Token: "wrkspc_1", Name: "Production"}}
+			resp.Links.Next = "https://api.vantage.sh/v2/workspaces?page=2"
+		} else {
+			resp.Workspaces = []Workspace{{Token: "wrkspc_2", Name: "Staging"}}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	workspaces, err := newTestClient(t, server.URL, 0).ListWorkspaces(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, workspaces, 2)
+	assert.Equal(t, "wrkspc_1", workspaces[0].Token)
+	assert.Equal(t, "Staging", workspaces[1].Name)
+}
+
+func TestClient_ListWorkspaces_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server.URL, 0).ListWorkspaces(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 401")
+}
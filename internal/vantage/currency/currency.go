@@ -0,0 +1,103 @@
+// Package currency converts cost amounts into a target currency using a
+// pluggable exchange-rate provider.
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// codePattern matches an ISO 4217 alphabetic currency code.
+var codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCode upper-cases code and checks it is a three-letter ISO 4217 code.
+func NormalizeCode(code string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if !codePattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid currency code: %q", code)
+	}
+	return normalized, nil
+}
+
+// Table is a set of exchange rates against one base currency. Rates follow
+// the ECB convention: units of the currency per one unit of Base.
+type Table struct {
+	Base  string
+	Date  time.Time // zero for undated tables
+	Rates map[string]float64
+}
+
+// Cross returns the multiplier that converts one unit of from into to.
+func (t Table) Cross(from, to string) (float64, error) {
+	fromRate, err := t.perBase(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := t.perBase(to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+// perBase returns how many units of code one unit of the base buys.
+func (t Table) perBase(code string) (float64, error) {
+	if code == t.Base {
+		return 1, nil
+	}
+	rate, ok := t.Rates[code]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no %s rate against %s", code, t.Base)
+	}
+	return rate, nil
+}
+
+// RateProvider supplies the rate table in effect on a given date.
+type RateProvider interface {
+	Rates(ctx context.Context, date time.Time) (Table, error)
+}
+
+// Converter converts amounts into a fixed target currency.
+type Converter struct {
+	provider RateProvider
+	target   string
+}
+
+// NewConverter creates a converter into target using provider's rates.
+func NewConverter(provider RateProvider, target string) (*Converter, error) {
+	if provider == nil {
+		return nil, errors.New("rate provider cannot be nil")
+	}
+	code, err := NormalizeCode(target)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{provider: provider, target: code}, nil
+}
+
+// Target returns the currency amounts are converted into.
+func (c *Converter) Target() string {
+	return c.target
+}
+
+// Rate returns the multiplier converting one unit of from into the target
+// currency on date.
+func (c *Converter) Rate(ctx context.Context, from string, date time.Time) (float64, error) {
+	code, err := NormalizeCode(from)
+	if err != nil {
+		return 0, err
+	}
+	if code == c.target {
+		return 1, nil
+	}
+
+	table, err := c.provider.Rates(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+	return table.Cross(code, c.target)
+}
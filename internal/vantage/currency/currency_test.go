@@ -0,0 +1,93 @@
+package currency
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCode(t *testing.T) {
+	code, err := NormalizeCode(" usd ")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", code)
+
+	for _, invalid := range []string{"", "US", "USDD", "U$D"} {
+		_, err := NormalizeCode(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestTable_Cross(t *testing.T) {
+	table := Table{Base: "EUR", Rates: map[string]float64{"USD": 1.25, "GBP": 0.5}}
+
+	rate, err := table.Cross("EUR", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.25, rate, 1e-12)
+
+	rate, err = table.Cross("USD", "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8, rate, 1e-12)
+
+	rate, err = table.Cross("GBP", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 2.5, rate, 1e-12)
+
+	_, err = table.Cross("JPY", "USD")
+	require.ErrorContains(t, err, "JPY")
+}
+
+func TestConverter_Rate(t *testing.T) {
+	provider, err := NewStatic("usd", map[string]float64{"eur": 0.8})
+	require.NoError(t, err)
+	converter, err := NewConverter(provider, "usd")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", converter.Target())
+
+	ctx := context.Background()
+	rate, err := converter.Rate(ctx, "EUR", time.Now())
+	require.NoError(t, err)
+	assert.InDelta(t, 1.25, rate, 1e-12)
+
+	rate, err = converter.Rate(ctx, "usd", time.Now())
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, rate, 1e-12)
+}
+
+func TestNewConverter_Validation(t *testing.T) {
+	_, err := NewConverter(nil, "USD")
+	require.Error(t, err)
+
+	provider, err := NewStatic("USD", map[string]float64{"EUR": 0.8})
+	require.NoError(t, err)
+	_, err = NewConverter(provider, "dollars")
+	require.Error(t, err)
+}
+
+func TestNewStatic_Validation(t *testing.T) {
+	_, err := NewStatic("USD", nil)
+	require.Error(t, err)
+
+	_, err = NewStatic("USD", map[string]float64{"EUR": 0})
+	require.ErrorContains(t, err, "positive")
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"base": "EUR", "rates": {"USD": 1.1, "JPY": 160}}`), 0o600))
+
+	provider, err := LoadFile(path)
+	require.NoError(t, err)
+
+	table, err := provider.Rates(context.Background(), time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", table.Base)
+	assert.InDelta(t, 160.0, table.Rates["JPY"], 1e-12)
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
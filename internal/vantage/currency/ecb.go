@@ -0,0 +1,115 @@
+package currency
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultECBURL serves the ECB euro reference rates for the last 90 days.
+const DefaultECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"
+
+// maxECBResponseBytes caps how much of the rate feed is read.
+const maxECBResponseBytes = 4 << 20
+
+// ECB serves the European Central Bank's daily euro reference rates. The
+// feed is fetched once and cached for the provider's lifetime. Dates without
+// a fixing (weekends, holidays) use the most recent earlier fixing.
+type ECB struct {
+	url        string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	tables []Table // ascending by date
+}
+
+// NewECB creates a provider reading url, or DefaultECBURL when url is empty.
+func NewECB(httpClient *http.Client, url string) *ECB {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if url == "" {
+		url = DefaultECBURL
+	}
+	return &ECB{url: url, httpClient: httpClient}
+}
+
+// Rates implements RateProvider.
+func (e *ECB) Rates(ctx context.Context, date time.Time) (Table, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.tables == nil {
+		tables, err := e.fetch(ctx)
+		if err != nil {
+			return Table{}, err
+		}
+		e.tables = tables
+	}
+
+	day := date.UTC().Truncate(24 * time.Hour)
+	i := sort.Search(len(e.tables), func(i int) bool { return e.tables[i].Date.After(day) })
+	if i == 0 {
+		return Table{}, fmt.Errorf("no ECB rates on or before %s", day.Format("2006-01-02"))
+	}
+	return e.tables[i-1], nil
+}
+
+// ecbEnvelope mirrors the eurofxref XML feed.
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// fetch downloads and parses the rate feed.
+func (e *ECB) fetch(ctx context.Context) ([]Table, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating ECB request: %w", err)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ECB rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching ECB rates: unexpected status %d", resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if decodeErr := xml.NewDecoder(io.LimitReader(resp.Body, maxECBResponseBytes)).Decode(&envelope); decodeErr != nil {
+		return nil, fmt.Errorf("parsing ECB rates: %w", decodeErr)
+	}
+
+	tables := make([]Table, 0, len(envelope.Days))
+	for _, day := range envelope.Days {
+		date, parseErr := time.Parse("2006-01-02", day.Time)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parsing ECB rate date %q: %w", day.Time, parseErr)
+		}
+		table := Table{Base: "EUR", Date: date, Rates: make(map[string]float64, len(day.Rates))}
+		for _, rate := range day.Rates {
+			table.Rates[rate.Currency] = rate.Rate
+		}
+		tables = append(tables, table)
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("ECB rate feed contained no rates")
+	}
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Date.Before(tables[j].Date) })
+	return tables, nil
+}
@@ -0,0 +1,79 @@
+package currency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ecbFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-01-03">
+			<Cube currency="USD" rate="1.0919"/>
+			<Cube currency="GBP" rate="0.8630"/>
+		</Cube>
+		<Cube time="2024-01-05">
+			<Cube currency="USD" rate="1.0921"/>
+			<Cube currency="GBP" rate="0.8600"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECB_Rates(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(ecbFeed))
+	}))
+	defer server.Close()
+
+	provider := NewECB(server.Client(), server.URL)
+	ctx := context.Background()
+
+	table, err := provider.Rates(ctx, time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", table.Base)
+	assert.InDelta(t, 1.0921, table.Rates["USD"], 1e-12)
+
+	// Days without a fixing fall back to the previous fixing.
+	table, err = provider.Rates(ctx, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), table.Date)
+
+	_, err = provider.Rates(ctx, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.ErrorContains(t, err, "no ECB rates")
+
+	assert.Equal(t, int32(1), requests.Load(), "feed should be fetched once")
+}
+
+func TestECB_Converter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(ecbFeed))
+	}))
+	defer server.Close()
+
+	converter, err := NewConverter(NewECB(server.Client(), server.URL), "GBP")
+	require.NoError(t, err)
+
+	rate, err := converter.Rate(context.Background(), "USD", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8630/1.0919, rate, 1e-12)
+}
+
+func TestECB_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := NewECB(server.Client(), server.URL).Rates(context.Background(), time.Now())
+	require.ErrorContains(t, err, "503")
+}
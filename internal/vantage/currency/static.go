@@ -0,0 +1,66 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Static serves one fixed rate table regardless of date.
+type Static struct {
+	table Table
+}
+
+// NewStatic creates a provider from rates expressed as units of each
+// currency per one unit of base.
+func NewStatic(base string, rates map[string]float64) (*Static, error) {
+	baseCode, err := NormalizeCode(base)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("static rate table cannot be empty")
+	}
+
+	table := Table{Base: baseCode, Rates: make(map[string]float64, len(rates))}
+	for code, rate := range rates {
+		normalized, codeErr := NormalizeCode(code)
+		if codeErr != nil {
+			return nil, codeErr
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate for %s must be positive, got %g", normalized, rate)
+		}
+		table.Rates[normalized] = rate
+	}
+	return &Static{table: table}, nil
+}
+
+// rateFile is the on-disk format read by LoadFile.
+type rateFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// LoadFile reads a static rate table from a JSON file of the form
+// {"base": "EUR", "rates": {"USD": 1.09, "GBP": 0.86}}.
+func LoadFile(path string) (*Static, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rate file: %w", err)
+	}
+
+	var file rateFile
+	if unmarshalErr := json.Unmarshal(data, &file); unmarshalErr != nil {
+		return nil, fmt.Errorf("parsing rate file: %w", unmarshalErr)
+	}
+	return NewStatic(file.Base, file.Rates)
+}
+
+// Rates implements RateProvider.
+func (s *Static) Rates(_ context.Context, _ time.Time) (Table, error) {
+	return s.table, nil
+}
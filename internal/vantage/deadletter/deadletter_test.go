@@ -0,0 +1,147 @@
+package deadletter_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/deadletter"
+)
+
+var (
+	_ adapter.DeadLetterQueue = (*deadletter.File)(nil)
+	_ adapter.DeadLetterQueue = (*deadletter.SQLite)(nil)
+)
+
+// queue is the listing and replacing every queue in this package provides.
+type queue interface {
+	adapter.DeadLetterQueue
+	DeadLetters(ctx context.Context) ([]adapter.DeadLetter, error)
+	ReplaceDeadLetters(ctx context.Context, letters []adapter.DeadLetter) error
+}
+
+func letter(id string, attempts int) adapter.DeadLetter {
+	cost := 12.5
+	return adapter.DeadLetter{
+		Record: adapter.CostRecord{
+			SchemaVersion: adapter.CurrentSchemaVersion,
+			LineItemID:    id,
+			Provider:      "aws",
+			Service:       "EC2",
+			NetCost:       &cost,
+			Currency:      "USD",
+			Labels:        map[string]string{"team": "core"},
+		},
+		Error:         "value too long for label",
+		Rejected:      attempts == 1,
+		Attempts:      attempts,
+		FailedAt:      time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+		CorrelationID: "corr-" + id,
+	}
+}
+
+// exerciseQueue checks the shared add/list/replace contract.
+func exerciseQueue(t *testing.T, q queue) {
+	t.Helper()
+	ctx := context.Background()
+
+	letters, err := q.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+
+	require.NoError(t, q.AddDeadLetters(ctx, []adapter.DeadLetter{letter("a", 1), letter("b", 3)}))
+	require.NoError(t, q.AddDeadLetters(ctx, nil))
+	require.NoError(t, q.AddDeadLetters(ctx, []adapter.DeadLetter{letter("c", 3)}))
+
+	letters, err = q.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []adapter.DeadLetter{letter("a", 1), letter("b", 3), letter("c", 3)}, letters)
+
+	require.NoError(t, q.ReplaceDeadLetters(ctx, []adapter.DeadLetter{letter("c", 6)}))
+	letters, err = q.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []adapter.DeadLetter{letter("c", 6)}, letters)
+
+	require.NoError(t, q.ReplaceDeadLetters(ctx, nil))
+	letters, err = q.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq", "dead_letter.ndjson")
+	q, err := deadletter.NewFile(path)
+	require.NoError(t, err)
+	exerciseQueue(t, q)
+
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "an emptied queue removes its file")
+}
+
+func TestFile_UpgradesRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead_letter.ndjson")
+	line := `{"record":{"line_item_id":"old","net_cost":1,"currency":"USD"},"error":"throttled","attempts":3,` +
+		`"failed_at":"2024-03-01T00:00:00Z"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(line), 0o600))
+
+	q, err := deadletter.NewFile(path)
+	require.NoError(t, err)
+	letters, err := q.DeadLetters(context.Background())
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "old", letters[0].Record.LineItemID)
+	assert.Equal(t, adapter.CurrentSchemaVersion, letters[0].Record.SchemaVersion)
+	assert.Equal(t, 3, letters[0].Attempts)
+}
+
+func TestFile_CorruptLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead_letter.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte("{not json\n"), 0o600))
+
+	q, err := deadletter.NewFile(path)
+	require.NoError(t, err)
+	_, err = q.DeadLetters(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dead letter 1")
+}
+
+func TestNewFile_EmptyPath(t *testing.T) {
+	_, err := deadletter.NewFile("")
+	require.Error(t, err)
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq", "dead_letter.db")
+	q, err := deadletter.NewSQLite(context.Background(), path, "pulumicost_dead_letters")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = q.Close() })
+	exerciseQueue(t, q)
+}
+
+func TestSQLite_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dead_letter.db")
+	q, err := deadletter.NewSQLite(ctx, path, "dlq")
+	require.NoError(t, err)
+	require.NoError(t, q.AddDeadLetters(ctx, []adapter.DeadLetter{letter("a", 3)}))
+	require.NoError(t, q.Close())
+
+	q, err = deadletter.NewSQLite(ctx, path, "dlq")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = q.Close() })
+	letters, err := q.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []adapter.DeadLetter{letter("a", 3)}, letters)
+}
+
+func TestNewSQLite_InvalidTable(t *testing.T) {
+	_, err := deadletter.NewSQLite(context.Background(), filepath.Join(t.TempDir(), "dlq.db"), "bad; DROP")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid dead-letter table name")
+}
@@ -0,0 +1,140 @@
+// Package deadletter provides dead-letter queues for records a sink failed
+// to write (see adapter.DeadLetterQueue), kept so replay-dlq can write them
+// again.
+package deadletter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	dirPerm  = 0o750
+	filePerm = 0o600
+
+	// maxLineSize bounds one dead letter, whose record may carry many labels.
+	maxLineSize = 16 << 20
+)
+
+// File keeps dead letters as newline-delimited JSON in a single file.
+type File struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFile creates a file queue at path, creating its directory if needed.
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("dead-letter file path cannot be empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return nil, fmt.Errorf("creating dead-letter directory: %w", err)
+	}
+	return &File{path: path}, nil
+}
+
+// AddDeadLetters implements adapter.DeadLetterQueue.
+func (f *File) AddDeadLetters(_ context.Context, letters []adapter.DeadLetter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("opening dead-letter file: %w", err)
+	}
+	if writeErr := writeLetters(file, letters); writeErr != nil {
+		_ = file.Close()
+		return writeErr
+	}
+	return file.Close()
+}
+
+// DeadLetters returns every stored dead letter, oldest first. A queue that
+// has never been written to is empty.
+func (f *File) DeadLetters(_ context.Context) ([]adapter.DeadLetter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening dead-letter file: %w", err)
+	}
+	defer file.Close()
+
+	var letters []adapter.DeadLetter
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		letter, decodeErr := adapter.DecodeDeadLetter(scanner.Bytes())
+		if decodeErr != nil {
+			return nil, fmt.Errorf("dead letter %d: %w", line, decodeErr)
+		}
+		letters = append(letters, letter)
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, fmt.Errorf("reading dead-letter file: %w", scanErr)
+	}
+	return letters, nil
+}
+
+// ReplaceDeadLetters replaces the stored dead letters with letters,
+// removing the file when there are none.
+func (f *File) ReplaceDeadLetters(_ context.Context, letters []adapter.DeadLetter) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(letters) == 0 {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing dead-letter file: %w", err)
+		}
+		return nil
+	}
+
+	// Write to a temp file and rename so a crash never leaves a torn file.
+	tmp := f.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePerm)
+	if err != nil {
+		return fmt.Errorf("writing dead-letter file: %w", err)
+	}
+	if writeErr := writeLetters(file, letters); writeErr != nil {
+		_ = file.Close()
+		return writeErr
+	}
+	if closeErr := file.Close(); closeErr != nil {
+		return fmt.Errorf("writing dead-letter file: %w", closeErr)
+	}
+	if renameErr := os.Rename(tmp, f.path); renameErr != nil {
+		return fmt.Errorf("replacing dead-letter file: %w", renameErr)
+	}
+	return nil
+}
+
+// writeLetters writes letters to file as NDJSON.
+func writeLetters(file *os.File, letters []adapter.DeadLetter) error {
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for i := range letters {
+		if err := enc.Encode(&letters[i]); err != nil {
+			return fmt.Errorf("encoding dead letter: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("writing dead letters: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package deadletter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" driver
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// validTableName restricts table names to plain identifiers, since they are
+// interpolated into SQL statements.
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLite keeps dead letters in a table of a local SQLite database, one row
+// per record with its error columns queryable alongside the record JSON.
+type SQLite struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLite opens (or creates) the database at path and ensures the
+// dead-letter table exists.
+func NewSQLite(ctx context.Context, path, table string) (*SQLite, error) {
+	if path == "" {
+		return nil, errors.New("dead-letter database path cannot be empty")
+	}
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid dead-letter table name: %q", table)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return nil, fmt.Errorf("creating dead-letter directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening dead-letter database: %w", err)
+	}
+
+	create := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s ("+
+			"id INTEGER PRIMARY KEY AUTOINCREMENT, "+
+			"line_item_id TEXT NOT NULL, "+
+			"error TEXT NOT NULL, "+
+			"rejected INTEGER NOT NULL, "+
+			"attempts INTEGER NOT NULL, "+
+			"failed_at TEXT NOT NULL, "+
+			"correlation_id TEXT NOT NULL, "+
+			"record TEXT NOT NULL)",
+		table,
+	)
+	if _, execErr := db.ExecContext(ctx, create); execErr != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating dead-letter table: %w", execErr)
+	}
+	return &SQLite{db: db, table: table}, nil
+}
+
+// AddDeadLetters implements adapter.DeadLetterQueue.
+func (s *SQLite) AddDeadLetters(ctx context.Context, letters []adapter.DeadLetter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("writing dead letters: %w", err)
+	}
+	if insertErr := s.insert(ctx, tx, letters); insertErr != nil {
+		_ = tx.Rollback()
+		return insertErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return fmt.Errorf("writing dead letters: %w", commitErr)
+	}
+	return nil
+}
+
+// DeadLetters returns every stored dead letter, oldest first.
+func (s *SQLite) DeadLetters(ctx context.Context) ([]adapter.DeadLetter, error) {
+	query := fmt.Sprintf(
+		"SELECT id, error, rejected, attempts, failed_at, correlation_id, record FROM %s ORDER BY id",
+		s.table,
+	)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []adapter.DeadLetter
+	for rows.Next() {
+		var (
+			id       int64
+			letter   adapter.DeadLetter
+			failedAt string
+			record   []byte
+		)
+		if scanErr := rows.Scan(
+			&id, &letter.Error, &letter.Rejected, &letter.Attempts, &failedAt, &letter.CorrelationID, &record,
+		); scanErr != nil {
+			return nil, fmt.Errorf("listing dead letters: %w", scanErr)
+		}
+		if letter.FailedAt, err = time.Parse(time.RFC3339Nano, failedAt); err != nil {
+			return nil, fmt.Errorf("dead letter %d: %w", id, err)
+		}
+		if letter.Record, err = adapter.DecodeRecord(record); err != nil {
+			return nil, fmt.Errorf("dead letter %d: %w", id, err)
+		}
+		letters = append(letters, letter)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("listing dead letters: %w", rowsErr)
+	}
+	return letters, nil
+}
+
+// ReplaceDeadLetters replaces the stored dead letters with letters in one
+// transaction.
+func (s *SQLite) ReplaceDeadLetters(ctx context.Context, letters []adapter.DeadLetter) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("replacing dead letters: %w", err)
+	}
+	if _, execErr := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", s.table)); execErr != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("replacing dead letters: %w", execErr)
+	}
+	if insertErr := s.insert(ctx, tx, letters); insertErr != nil {
+		_ = tx.Rollback()
+		return insertErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return fmt.Errorf("replacing dead letters: %w", commitErr)
+	}
+	return nil
+}
+
+// insert adds letters to the table within tx.
+func (s *SQLite) insert(ctx context.Context, tx *sql.Tx, letters []adapter.DeadLetter) error {
+	insert := fmt.Sprintf(
+		"INSERT INTO %s (line_item_id, error, rejected, attempts, failed_at, correlation_id, record) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?)",
+		s.table,
+	)
+	for i := range letters {
+		letter := &letters[i]
+		record, err := json.Marshal(&letter.Record)
+		if err != nil {
+			return fmt.Errorf("encoding dead letter: %w", err)
+		}
+		if _, err = tx.ExecContext(ctx, insert,
+			letter.Record.LineItemID,
+			letter.Error,
+			letter.Rejected,
+			letter.Attempts,
+			letter.FailedAt.UTC().Format(time.RFC3339Nano),
+			letter.CorrelationID,
+			string(record),
+		); err != nil {
+			return fmt.Errorf("writing dead letter: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close releases the database handle.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// curTagPrefix starts every resource tag column of a CUR export.
+const curTagPrefix = "resourceTags/"
+
+// curColumn is one fixed AWS Cost and Usage Report column and how it is
+// filled from a record. A nil value leaves the column empty.
+type curColumn struct {
+	name  string
+	value func(p period, r *adapter.CostRecord) string
+}
+
+// curColumns lists the fixed CUR columns in output order. Resource tag
+// columns follow them, one per tag key seen in the export.
+var curColumns = []curColumn{
+	{"identity/LineItemId", func(_ period, r *adapter.CostRecord) string { return r.LineItemID }},
+	{"identity/TimeInterval", func(p period, r *adapter.CostRecord) string {
+		return formatTime(r.Timestamp) + "/" + formatTime(p.end(r))
+	}},
+	{"bill/InvoiceId", nil},
+	{"bill/BillingEntity", nil},
+	{"bill/BillType", func(_ period, _ *adapter.CostRecord) string { return "Anniversary" }},
+	{"bill/PayerAccountId", func(_ period, r *adapter.CostRecord) string { return r.BillingAccountID }},
+	{"bill/BillingPeriodStartDate", func(_ period, r *adapter.CostRecord) string {
+		return formatTime(billingPeriodStart(r))
+	}},
+	{"bill/BillingPeriodEndDate", func(_ period, r *adapter.CostRecord) string {
+		return formatTime(billingPeriodStart(r).AddDate(0, 1, 0))
+	}},
+	{"lineItem/UsageAccountId", func(_ period, r *adapter.CostRecord) string { return r.SubAccountID }},
+	{"lineItem/LineItemType", func(_ period, _ *adapter.CostRecord) string { return "Usage" }},
+	{"lineItem/UsageStartDate", func(_ period, r *adapter.CostRecord) string { return formatTime(r.Timestamp) }},
+	{"lineItem/UsageEndDate", func(p period, r *adapter.CostRecord) string { return formatTime(p.end(r)) }},
+	{"lineItem/ProductCode", func(_ period, r *adapter.CostRecord) string { return r.Service }},
+	{"lineItem/UsageType", nil},
+	{"lineItem/Operation", nil},
+	{"lineItem/AvailabilityZone", nil},
+	{"lineItem/ResourceId", func(_ period, r *adapter.CostRecord) string { return r.ResourceID }},
+	{"lineItem/UsageAmount", func(_ period, r *adapter.CostRecord) string { return formatOptional(r.UsageAmount) }},
+	{"lineItem/CurrencyCode", func(_ period, r *adapter.CostRecord) string { return r.Currency }},
+	{"lineItem/UnblendedRate", func(_ period, r *adapter.CostRecord) string { return unitRate(r) }},
+	{"lineItem/UnblendedCost", func(_ period, r *adapter.CostRecord) string { return formatAmount(billedCost(r)) }},
+	{"lineItem/BlendedRate", func(_ period, r *adapter.CostRecord) string { return unitRate(r) }},
+	{"lineItem/BlendedCost", func(_ period, r *adapter.CostRecord) string { return formatAmount(billedCost(r)) }},
+	{"lineItem/LineItemDescription", func(_ period, r *adapter.CostRecord) string { return r.Service }},
+	{"product/ProductName", func(_ period, r *adapter.CostRecord) string { return r.Service }},
+	{"product/region", func(_ period, r *adapter.CostRecord) string { return r.Region }},
+	{"pricing/unit", func(_ period, r *adapter.CostRecord) string { return pricingUnit(r) }},
+	{"pricing/publicOnDemandCost", func(_ period, r *adapter.CostRecord) string { return formatOptional(r.ListCost) }},
+}
+
+// CURColumns returns the header WriteCUR emits for records: the fixed CUR
+// columns followed by one sorted resourceTags/ column per tag key.
+func CURColumns(records []adapter.CostRecord) []string {
+	names := make([]string, 0, len(curColumns))
+	for _, column := range curColumns {
+		names = append(names, column.name)
+	}
+	return append(names, curTagColumns(records)...)
+}
+
+// WriteCUR writes the cost records in records as CSV in the AWS Cost and
+// Usage Report layout. granularity ("day" or "month") is the bucket size of
+// the records. It returns the number of rows written.
+func WriteCUR(w io.Writer, records []adapter.CostRecord, granularity string) (int, error) {
+	costs := make([]adapter.CostRecord, 0, len(records))
+	for _, record := range records {
+		if record.MetricType == "" || record.MetricType == metricTypeCost {
+			costs = append(costs, record)
+		}
+	}
+
+	out := csv.NewWriter(w)
+	header := CURColumns(costs)
+	if err := out.Write(header); err != nil {
+		return 0, fmt.Errorf("writing CUR header: %w", err)
+	}
+
+	p := period{granularity: granularity}
+	tagColumns := header[len(curColumns):]
+	row := make([]string, len(header))
+	for i := range costs {
+		record := &costs[i]
+		for j, column := range curColumns {
+			row[j] = ""
+			if column.value != nil {
+				row[j] = column.value(p, record)
+			}
+		}
+
+		tags := curTags(record)
+		for j, name := range tagColumns {
+			row[len(curColumns)+j] = tags[name]
+		}
+
+		if err := out.Write(row); err != nil {
+			return i, fmt.Errorf("writing CUR row: %w", err)
+		}
+	}
+
+	out.Flush()
+	return len(costs), out.Error()
+}
+
+// curTagColumns returns the sorted resource tag columns for records.
+func curTagColumns(records []adapter.CostRecord) []string {
+	columns := make(map[string]struct{})
+	for i := range records {
+		for name := range curTags(&records[i]) {
+			columns[name] = struct{}{}
+		}
+	}
+	return slices.Sorted(maps.Keys(columns))
+}
+
+// curTags maps a record's tags to CUR column names. Original tag keys from
+// labels_raw are preferred, since CUR queries use the provider's casing.
+// Keys without an aws: or user: prefix are treated as user tags.
+func curTags(r *adapter.CostRecord) map[string]string {
+	labels := r.Labels
+	if len(r.LabelsRaw) > 0 {
+		labels = r.LabelsRaw
+	}
+
+	tags := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if !strings.HasPrefix(key, "user:") && !strings.HasPrefix(key, "aws:") {
+			key = "user:" + key
+		}
+		tags[curTagPrefix+key] = value
+	}
+	return tags
+}
+
+// unitRate is the record's effective unit price, or else the billed cost
+// per usage unit when usage is known.
+func unitRate(r *adapter.CostRecord) string {
+	if r.EffectiveUnitPrice != nil {
+		return formatAmount(*r.EffectiveUnitPrice)
+	}
+	if r.UsageAmount == nil || *r.UsageAmount == 0 {
+		return ""
+	}
+	return formatAmount(billedCost(r) / *r.UsageAmount)
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// readCUR parses the CSV output into one column-name -> value map per row.
+func readCUR(t *testing.T, data []byte) ([]string, []map[string]string) {
+	t.Helper()
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+
+	result := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		m := make(map[string]string, len(row))
+		for i, value := range row {
+			m[rows[0][i]] = value
+		}
+		result = append(result, m)
+	}
+	return rows[0], result
+}
+
+func TestWriteCUR(t *testing.T) {
+	records := []adapter.CostRecord{
+		{
+			Timestamp:        time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+			Service:          "AmazonEC2",
+			AccountID:        "123456789012",
+			BillingAccountID: "111111111111",
+			SubAccountID:     "123456789012",
+			Region:           "us-east-1",
+			ResourceID:       "i-abc",
+			Labels:           map[string]string{"team": "core", "user:env": "prod"},
+			UsageAmount:      float64Ptr(4),
+			UsageUnit:        "Hrs",
+			NetCost:          float64Ptr(10),
+			ListCost:         float64Ptr(12),
+			Currency:         "USD",
+			LineItemID:       "li-1",
+			MetricType:       "cost",
+		},
+		{
+			Timestamp:  time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+			Labels:     map[string]string{"cost-center": "eng"},
+			LabelsRaw:  map[string]string{"CostCenter": "eng"},
+			NetCost:    float64Ptr(1),
+			LineItemID: "li-2",
+		},
+		{LineItemID: "budget-1", MetricType: "budget"},
+	}
+
+	var buf bytes.Buffer
+	written, err := WriteCUR(&buf, records, "day")
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+
+	header, rows := readCUR(t, buf.Bytes())
+	assert.Equal(t, CURColumns(records[:2]), header)
+	assert.Equal(t, []string{
+		"resourceTags/user:CostCenter",
+		"resourceTags/user:env",
+		"resourceTags/user:team",
+	}, header[len(header)-3:])
+	require.Len(t, rows, 2)
+
+	row := rows[0]
+	assert.Equal(t, "li-1", row["identity/LineItemId"])
+	assert.Equal(t, "2024-03-15T00:00:00Z/2024-03-16T00:00:00Z", row["identity/TimeInterval"])
+	assert.Equal(t, "2024-03-01T00:00:00Z", row["bill/BillingPeriodStartDate"])
+	assert.Equal(t, "111111111111", row["bill/PayerAccountId"])
+	assert.Equal(t, "123456789012", row["lineItem/UsageAccountId"])
+	assert.Equal(t, "AmazonEC2", row["lineItem/ProductCode"])
+	assert.Equal(t, "10", row["lineItem/UnblendedCost"])
+	assert.Equal(t, "2.5", row["lineItem/UnblendedRate"])
+	assert.Equal(t, "12", row["pricing/publicOnDemandCost"])
+	assert.Equal(t, "us-east-1", row["product/region"])
+	assert.Equal(t, "core", row["resourceTags/user:team"])
+	assert.Equal(t, "prod", row["resourceTags/user:env"])
+	assert.Empty(t, row["resourceTags/user:CostCenter"])
+
+	row = rows[1]
+	assert.Equal(t, "eng", row["resourceTags/user:CostCenter"], "labels_raw keeps provider casing")
+	assert.Empty(t, row["lineItem/UnblendedRate"], "no rate without usage")
+}
+
+func TestWriteCUR_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	written, err := WriteCUR(&buf, nil, "month")
+	require.NoError(t, err)
+	assert.Zero(t, written)
+
+	header, rows := readCUR(t, buf.Bytes())
+	assert.Len(t, header, len(curColumns))
+	assert.Empty(t, rows)
+}
@@ -0,0 +1,55 @@
+// Package export converts synced cost records into interchange formats used
+// by other FinOps tools.
+package export
+
+import (
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// Reconcile reduces an append-only record log to its current view: later
+// records with the same LineItemID replace earlier ones, restated records
+// replace the record they restate, and deletion tombstones remove theirs.
+// Records keep the position where their LineItemID first appeared.
+func Reconcile(records []adapter.CostRecord) []adapter.CostRecord {
+	order := make([]string, 0, len(records))
+	current := make(map[string]adapter.CostRecord, len(records))
+	seen := make(map[string]bool, len(records))
+
+	for _, record := range records {
+		if record.RestatesLineItemID != "" {
+			delete(current, record.RestatesLineItemID)
+		}
+		if record.MetricType == adapter.MetricTypeDeletion {
+			delete(current, record.LineItemID)
+			continue
+		}
+		if !seen[record.LineItemID] {
+			seen[record.LineItemID] = true
+			order = append(order, record.LineItemID)
+		}
+		current[record.LineItemID] = record
+	}
+
+	reconciled := make([]adapter.CostRecord, 0, len(current))
+	for _, id := range order {
+		if record, ok := current[id]; ok {
+			reconciled = append(reconciled, record)
+		}
+	}
+	return reconciled
+}
+
+// period is the bucket size of the exported records.
+type period struct {
+	granularity string
+}
+
+// end returns the exclusive end of the record's bucket.
+func (p period) end(r *adapter.CostRecord) time.Time {
+	if p.granularity == "month" {
+		return r.Timestamp.AddDate(0, 1, 0)
+	}
+	return r.Timestamp.AddDate(0, 0, 1)
+}
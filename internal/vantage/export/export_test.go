@@ -0,0 +1,34 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func lineItemIDs(records []adapter.CostRecord) []string {
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.LineItemID
+	}
+	return ids
+}
+
+func TestReconcile(t *testing.T) {
+	records := []adapter.CostRecord{
+		{LineItemID: "a", Service: "v1"},
+		{LineItemID: "b"},
+		{LineItemID: "c"},
+		{LineItemID: "a", Service: "v2"},            // re-written by a backfill re-run
+		{LineItemID: "b2", RestatesLineItemID: "b"}, // restatement replaces b
+		{LineItemID: "c", MetricType: "deletion"},   // tombstone removes c
+		{LineItemID: "f", MetricType: "forecast"},   // kept; exporters filter by type
+		{LineItemID: "x", MetricType: "deletion"},   // tombstone for an unknown record
+	}
+
+	reconciled := Reconcile(records)
+	assert.Equal(t, []string{"a", "b2", "f"}, lineItemIDs(reconciled))
+	assert.Equal(t, "v2", reconciled[0].Service)
+}
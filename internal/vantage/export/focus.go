@@ -0,0 +1,233 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// metricTypeCost is the metric type of billed cost records. Forecast, budget,
+// recommendation, and deletion records are not charges and are not exported.
+const metricTypeCost = "cost"
+
+// focusColumn is one FOCUS 1.2 column and how it is filled from a record.
+// A nil value leaves the column empty (null).
+type focusColumn struct {
+	name  string
+	value func(p period, r *adapter.CostRecord) string
+}
+
+// focusColumns lists the FOCUS 1.2 columns in output order. Columns Vantage
+// has no data for are still emitted, empty, so the header matches the spec.
+var focusColumns = []focusColumn{
+	{"AvailabilityZone", nil},
+	{"BilledCost", func(_ period, r *adapter.CostRecord) string { return formatAmount(billedCost(r)) }},
+	{"BillingAccountId", func(_ period, r *adapter.CostRecord) string { return r.BillingAccountID }},
+	{"BillingAccountName", nil},
+	{"BillingAccountType", nil},
+	{"BillingCurrency", func(_ period, r *adapter.CostRecord) string { return r.Currency }},
+	{"BillingPeriodEnd", func(_ period, r *adapter.CostRecord) string {
+		return formatTime(billingPeriodStart(r).AddDate(0, 1, 0))
+	}},
+	{"BillingPeriodStart", func(_ period, r *adapter.CostRecord) string {
+		return formatTime(billingPeriodStart(r))
+	}},
+	{"ChargeCategory", func(_ period, _ *adapter.CostRecord) string { return "Usage" }},
+	{"ChargeClass", nil},
+	{"ChargeDescription", func(_ period, r *adapter.CostRecord) string { return r.Service }},
+	{"ChargeFrequency", func(_ period, _ *adapter.CostRecord) string { return "Usage-Based" }},
+	{"ChargePeriodEnd", func(p period, r *adapter.CostRecord) string { return formatTime(p.end(r)) }},
+	{"ChargePeriodStart", func(_ period, r *adapter.CostRecord) string { return formatTime(r.Timestamp) }},
+	{"CommitmentDiscountCategory", nil},
+	{"CommitmentDiscountId", nil},
+	{"CommitmentDiscountName", nil},
+	{"CommitmentDiscountQuantity", nil},
+	{"CommitmentDiscountStatus", nil},
+	{"CommitmentDiscountType", nil},
+	{"CommitmentDiscountUnit", nil},
+	{"ConsumedQuantity", func(_ period, r *adapter.CostRecord) string { return formatOptional(r.UsageAmount) }},
+	{"ConsumedUnit", func(_ period, r *adapter.CostRecord) string { return r.UsageUnit }},
+	{"ContractedCost", func(_ period, r *adapter.CostRecord) string { return formatAmount(billedCost(r)) }},
+	{"ContractedUnitPrice", nil},
+	{"EffectiveCost", func(_ period, r *adapter.CostRecord) string { return formatAmount(effectiveCost(r)) }},
+	{"InvoiceId", nil},
+	{"InvoiceIssuerName", func(_ period, r *adapter.CostRecord) string { return r.Provider }},
+	{"ListCost", func(_ period, r *adapter.CostRecord) string { return formatAmount(listCost(r)) }},
+	{"ListUnitPrice", nil},
+	{"PricingCategory", nil},
+	{"PricingCurrency", nil},
+	{"PricingCurrencyContractedUnitPrice", nil},
+	{"PricingCurrencyEffectiveCost", nil},
+	{"PricingCurrencyListUnitPrice", nil},
+	{"PricingQuantity", func(_ period, r *adapter.CostRecord) string { return formatOptional(r.UsageAmount) }},
+	{"PricingUnit", func(_ period, r *adapter.CostRecord) string { return pricingUnit(r) }},
+	{"ProviderName", func(_ period, r *adapter.CostRecord) string { return r.Provider }},
+	{"PublisherName", func(_ period, r *adapter.CostRecord) string { return r.Provider }},
+	{"RegionId", func(_ period, r *adapter.CostRecord) string { return r.Region }},
+	{"RegionName", func(_ period, r *adapter.CostRecord) string { return r.Region }},
+	{"ResourceId", func(_ period, r *adapter.CostRecord) string { return r.ResourceID }},
+	{"ResourceName", func(_ period, r *adapter.CostRecord) string { return r.ResourceName }},
+	{"ResourceType", func(_ period, r *adapter.CostRecord) string { return r.ResourceType }},
+	{"ServiceCategory", func(_ period, r *adapter.CostRecord) string { return serviceCategory(r) }},
+	{"ServiceName", func(_ period, r *adapter.CostRecord) string { return r.Service }},
+	{"ServiceSubcategory", nil},
+	{"SkuId", nil},
+	{"SkuMeter", nil},
+	{"SkuPriceDetails", nil},
+	{"SkuPriceId", nil},
+	{"SubAccountId", func(_ period, r *adapter.CostRecord) string { return r.SubAccountID }},
+	{"SubAccountName", nil},
+	{"SubAccountType", nil},
+	{"Tags", func(_ period, r *adapter.CostRecord) string { return encodeTags(r.Labels) }},
+	{"x_LineItemId", func(_ period, r *adapter.CostRecord) string { return r.LineItemID }},
+	{"x_PulumiUrn", func(_ period, r *adapter.CostRecord) string { return r.PulumiURN }},
+}
+
+// FOCUSColumns returns the CSV header written by FOCUSWriter.
+func FOCUSColumns() []string {
+	names := make([]string, len(focusColumns))
+	for i, column := range focusColumns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// FOCUSWriter writes cost records as FOCUS 1.2 CSV rows.
+type FOCUSWriter struct {
+	csv         *csv.Writer
+	period      period
+	wroteHeader bool
+}
+
+// NewFOCUSWriter returns a writer emitting CSV to w. granularity ("day" or
+// "month") is the bucket size of the records and sets ChargePeriodEnd.
+func NewFOCUSWriter(w io.Writer, granularity string) *FOCUSWriter {
+	return &FOCUSWriter{csv: csv.NewWriter(w), period: period{granularity: granularity}}
+}
+
+// Write writes records, preceded by the header on the first call. Records
+// other than billed costs are skipped. It returns the number of rows written.
+func (w *FOCUSWriter) Write(records []adapter.CostRecord) (int, error) {
+	if err := w.writeHeader(); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	row := make([]string, len(focusColumns))
+	for i := range records {
+		record := &records[i]
+		if record.MetricType != "" && record.MetricType != metricTypeCost {
+			continue
+		}
+
+		for j, column := range focusColumns {
+			row[j] = ""
+			if column.value != nil {
+				row[j] = column.value(w.period, record)
+			}
+		}
+		if err := w.csv.Write(row); err != nil {
+			return written, fmt.Errorf("writing FOCUS row: %w", err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+// Flush writes any buffered rows to the underlying writer. An export with no
+// rows still gets a header.
+func (w *FOCUSWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *FOCUSWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	if err := w.csv.Write(FOCUSColumns()); err != nil {
+		return fmt.Errorf("writing FOCUS header: %w", err)
+	}
+	w.wroteHeader = true
+	return nil
+}
+
+// encodeTags encodes labels as the JSON object FOCUS expects.
+func encodeTags(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(labels) // a map[string]string always encodes
+	return string(encoded)
+}
+
+func billingPeriodStart(r *adapter.CostRecord) time.Time {
+	t := r.Timestamp.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// billedCost is the net cost charged; FOCUS requires it to be non-null.
+func billedCost(r *adapter.CostRecord) float64 {
+	if r.NetCost != nil {
+		return *r.NetCost
+	}
+	return 0
+}
+
+// effectiveCost is the amortized cost, falling back to the billed cost.
+func effectiveCost(r *adapter.CostRecord) float64 {
+	if r.AmortizedCost != nil {
+		return *r.AmortizedCost
+	}
+	return billedCost(r)
+}
+
+// serviceCategory is the record's FOCUS service category, or Other when no
+// table listed its service.
+func serviceCategory(r *adapter.CostRecord) string {
+	if r.ServiceCategory != "" {
+		return r.ServiceCategory
+	}
+	return adapter.ServiceCategoryOther
+}
+
+// pricingUnit is the unit the record's price is quoted in, falling back to
+// the usage unit.
+func pricingUnit(r *adapter.CostRecord) string {
+	if r.PricingUnit != "" {
+		return r.PricingUnit
+	}
+	return r.UsageUnit
+}
+
+// listCost is the public-price cost, falling back to the billed cost when
+// Vantage did not report one.
+func listCost(r *adapter.CostRecord) float64 {
+	if r.ListCost != nil {
+		return *r.ListCost
+	}
+	return billedCost(r)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatAmount(*v)
+}
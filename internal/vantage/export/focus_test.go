@@ -0,0 +1,123 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+// readFOCUS parses the CSV output into one column-name -> value map per row.
+func readFOCUS(t *testing.T, data []byte) []map[string]string {
+	t.Helper()
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+	require.Equal(t, FOCUSColumns(), rows[0])
+
+	result := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		m := make(map[string]string, len(row))
+		for i, value := range row {
+			m[rows[0][i]] = value
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+func TestFOCUSWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewFOCUSWriter(&buf, "day")
+
+	written, err := w.Write([]adapter.CostRecord{
+		{
+			Timestamp:        time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+			Provider:         "aws",
+			Service:          "AmazonEC2",
+			AccountID:        "123456789012",
+			BillingAccountID: "111111111111",
+			ServiceCategory:  "Compute",
+			ResourceType:     "ec2:instance",
+			ResourceName:     "i-abc",
+			SubAccountID:     "123456789012",
+			Region:           "us-east-1",
+			ResourceID:       "i-abc",
+			Labels:           map[string]string{"team": "core"},
+			UsageAmount:      float64Ptr(24),
+			UsageUnit:        "Hrs",
+			NetCost:          float64Ptr(10.5),
+			AmortizedCost:    float64Ptr(9.25),
+			ListCost:         float64Ptr(12),
+			Currency:         "USD",
+			LineItemID:       "li-1",
+			PulumiURN:        "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web",
+			MetricType:       "cost",
+		},
+		{LineItemID: "fc-1", MetricType: "forecast"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+	require.NoError(t, w.Flush())
+
+	rows := readFOCUS(t, buf.Bytes())
+	require.Len(t, rows, 1)
+	row := rows[0]
+	assert.Equal(t, "10.5", row["BilledCost"])
+	assert.Equal(t, "9.25", row["EffectiveCost"])
+	assert.Equal(t, "12", row["ListCost"])
+	assert.Equal(t, "10.5", row["ContractedCost"])
+	assert.Equal(t, "USD", row["BillingCurrency"])
+	assert.Equal(t, "2024-03-15T00:00:00Z", row["ChargePeriodStart"])
+	assert.Equal(t, "2024-03-16T00:00:00Z", row["ChargePeriodEnd"])
+	assert.Equal(t, "2024-03-01T00:00:00Z", row["BillingPeriodStart"])
+	assert.Equal(t, "2024-04-01T00:00:00Z", row["BillingPeriodEnd"])
+	assert.Equal(t, "Usage", row["ChargeCategory"])
+	assert.Equal(t, "24", row["ConsumedQuantity"])
+	assert.Equal(t, "aws", row["ProviderName"])
+	assert.Equal(t, "AmazonEC2", row["ServiceName"])
+	assert.Equal(t, "111111111111", row["BillingAccountId"])
+	assert.Equal(t, "Compute", row["ServiceCategory"])
+	assert.Equal(t, "ec2:instance", row["ResourceType"])
+	assert.Equal(t, "i-abc", row["ResourceName"])
+	assert.Equal(t, "123456789012", row["SubAccountId"])
+	assert.Equal(t, `{"team":"core"}`, row["Tags"])
+	assert.Equal(t, "li-1", row["x_LineItemId"])
+	assert.Equal(t, "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web", row["x_PulumiUrn"])
+	assert.Empty(t, row["CommitmentDiscountId"])
+}
+
+func TestFOCUSWriter_Fallbacks(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewFOCUSWriter(&buf, "month")
+
+	_, err := w.Write([]adapter.CostRecord{{
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NetCost:   float64Ptr(3),
+	}})
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	row := readFOCUS(t, buf.Bytes())[0]
+	assert.Equal(t, "3", row["EffectiveCost"], "falls back to billed cost")
+	assert.Equal(t, "Other", row["ServiceCategory"])
+	assert.Equal(t, "3", row["ListCost"], "falls back to billed cost")
+	assert.Equal(t, "2024-02-01T00:00:00Z", row["ChargePeriodEnd"])
+	assert.Empty(t, row["ConsumedQuantity"])
+	assert.Empty(t, row["Tags"])
+}
+
+func TestFOCUSWriter_EmptyExportHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewFOCUSWriter(&buf, "day").Flush())
+	assert.Empty(t, readFOCUS(t, buf.Bytes()))
+}
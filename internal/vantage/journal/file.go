@@ -0,0 +1,191 @@
+// Package journal provides write-ahead journals of the batches a sync writes
+// to its sink (see adapter.Journal).
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	dirPerm  = 0o750
+	filePerm = 0o600
+)
+
+// File journals each key to <dir>/<key>.wal as newline-delimited JSON: one
+// line per batch appended, holding its records, and one per commit. Every
+// line is synced to disk before Append or Commit returns.
+type File struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFile creates a file journal in dir, creating the directory if needed.
+func NewFile(dir string) (*File, error) {
+	if dir == "" {
+		return nil, errors.New("journal directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("creating journal directory: %w", err)
+	}
+	return &File{dir: dir}, nil
+}
+
+// commitLine marks an entry committed.
+type commitLine struct {
+	Seq       uint64 `json:"seq"`
+	Committed bool   `json:"committed"`
+}
+
+// Append implements adapter.Journal. Records JSON cannot encode, such as
+// one with a NaN cost, are left out of the entry; no JSON sink could write
+// them either.
+func (f *File) Append(_ context.Context, key string, entry adapter.JournalEntry) error {
+	entry.Committed = false
+	line, err := json.Marshal(&entry)
+	if err != nil {
+		entry.Records = encodableRecords(entry.Records)
+		if line, err = json.Marshal(&entry); err != nil {
+			return fmt.Errorf("encoding journal entry: %w", err)
+		}
+	}
+	return f.appendLine(key, line)
+}
+
+// Commit implements adapter.Journal.
+func (f *File) Commit(_ context.Context, key string, seq uint64) error {
+	line, err := json.Marshal(commitLine{Seq: seq, Committed: true})
+	if err != nil {
+		return fmt.Errorf("encoding journal commit: %w", err)
+	}
+	return f.appendLine(key, line)
+}
+
+// Entries implements adapter.Journal. A line torn by a crash while it was
+// appended is dropped from the end of the file; its batch was never handed
+// to the sink.
+func (f *File) Entries(_ context.Context, key string) ([]adapter.JournalEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path(key), os.O_RDWR, filePerm)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening journal: %w", err)
+	}
+	defer file.Close()
+
+	var entries []adapter.JournalEntry
+	index := make(map[uint64]int)
+	r := bufio.NewReader(file)
+	var offset int64
+	for n := 1; ; n++ {
+		line, readErr := r.ReadBytes('\n')
+		if errors.Is(readErr, io.EOF) {
+			if len(line) > 0 {
+				if truncErr := file.Truncate(offset); truncErr != nil {
+					return nil, fmt.Errorf("dropping torn journal line: %w", truncErr)
+				}
+			}
+			return entries, nil
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("reading journal: %w", readErr)
+		}
+		offset += int64(len(line))
+
+		entry, decodeErr := decodeEntry(bytes.TrimSpace(line))
+		if decodeErr != nil {
+			return nil, fmt.Errorf("journal line %d: %w", n, decodeErr)
+		}
+		if entry.Committed {
+			if i, ok := index[entry.Seq]; ok {
+				entries[i].Committed = true
+			}
+			continue
+		}
+		index[entry.Seq] = len(entries)
+		entries = append(entries, entry)
+	}
+}
+
+// Reset implements adapter.Journal.
+func (f *File) Reset(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.Remove(f.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing journal: %w", err)
+	}
+	return nil
+}
+
+// path returns the journal file of key.
+func (f *File) path(key string) string {
+	return filepath.Join(f.dir, key+".wal")
+}
+
+// appendLine appends line to the journal of key and syncs it to disk.
+func (f *File) appendLine(key string, line []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path(key), os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("opening journal: %w", err)
+	}
+	if _, err = file.Write(append(line, '\n')); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
+
+// decodeEntry decodes a journal line, upgrading its records to the current
+// schema as adapter.DecodeRecord does.
+func decodeEntry(line []byte) (adapter.JournalEntry, error) {
+	var raw struct {
+		adapter.JournalEntry
+		Records []json.RawMessage `json:"records"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return adapter.JournalEntry{}, err
+	}
+	entry := raw.JournalEntry
+	for _, data := range raw.Records {
+		record, err := adapter.DecodeRecord(data)
+		if err != nil {
+			return adapter.JournalEntry{}, err
+		}
+		entry.Records = append(entry.Records, record)
+	}
+	return entry, nil
+}
+
+// encodableRecords returns the records JSON can encode.
+func encodableRecords(records []adapter.CostRecord) []adapter.CostRecord {
+	kept := make([]adapter.CostRecord, 0, len(records))
+	for i := range records {
+		if _, err := json.Marshal(&records[i]); err == nil {
+			kept = append(kept, records[i])
+		}
+	}
+	return kept
+}
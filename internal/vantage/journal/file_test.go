@@ -0,0 +1,118 @@
+package journal_test
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/journal"
+)
+
+var _ adapter.Journal = (*journal.File)(nil)
+
+func records(ids ...string) []adapter.CostRecord {
+	records := make([]adapter.CostRecord, len(ids))
+	for i, id := range ids {
+		records[i] = adapter.CostRecord{SchemaVersion: adapter.CurrentSchemaVersion, LineItemID: id}
+	}
+	return records
+}
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "journal")
+	j, err := journal.NewFile(dir)
+	require.NoError(t, err)
+
+	entries, err := j.Entries(ctx, "report")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, j.Append(ctx, "report", adapter.JournalEntry{Seq: 1, Checkpoint: "0", Records: records("a", "b")}))
+	require.NoError(t, j.Commit(ctx, "report", 1))
+	require.NoError(t, j.Append(ctx, "report", adapter.JournalEntry{Seq: 2, Checkpoint: "120", Records: records("c")}))
+	require.NoError(t, j.Append(ctx, "other", adapter.JournalEntry{Seq: 1, Records: records("x")}))
+
+	entries, err = j.Entries(ctx, "report")
+	require.NoError(t, err)
+	assert.Equal(t, []adapter.JournalEntry{
+		{Seq: 1, Checkpoint: "0", Records: records("a", "b"), Committed: true},
+		{Seq: 2, Checkpoint: "120", Records: records("c")},
+	}, entries)
+
+	require.NoError(t, j.Reset(ctx, "report"))
+	require.NoError(t, j.Reset(ctx, "report"), "resetting an empty journal is a no-op")
+	entries, err = j.Entries(ctx, "report")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = j.Entries(ctx, "other")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "keys are journaled separately")
+}
+
+func TestFile_DropsTornLine(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	j, err := journal.NewFile(dir)
+	require.NoError(t, err)
+	require.NoError(t, j.Append(ctx, "report", adapter.JournalEntry{Seq: 1, Records: records("a")}))
+
+	// A crash part way through appending the next entry.
+	path := filepath.Join(dir, "report.wal")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"seq":2,"records":[{"line_it`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	entries, err := j.Entries(ctx, "report")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, j.Commit(ctx, "report", 1))
+	entries, err = j.Entries(ctx, "report")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Committed)
+}
+
+func TestFile_UnencodableRecordsLeftOut(t *testing.T) {
+	ctx := context.Background()
+	j, err := journal.NewFile(t.TempDir())
+	require.NoError(t, err)
+
+	batch := records("a", "nan")
+	nan := math.NaN()
+	batch[1].NetCost = &nan
+	require.NoError(t, j.Append(ctx, "report", adapter.JournalEntry{Seq: 1, Records: batch}))
+
+	entries, err := j.Entries(ctx, "report")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, records("a"), entries[0].Records)
+}
+
+func TestFile_UpgradesRecords(t *testing.T) {
+	dir := t.TempDir()
+	line := `{"seq":1,"records":[{"line_item_id":"old"}]}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "report.wal"), []byte(line), 0o600))
+
+	j, err := journal.NewFile(dir)
+	require.NoError(t, err)
+	entries, err := j.Entries(context.Background(), "report")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, records("old"), entries[0].Records)
+}
+
+func TestNewFile_EmptyDir(t *testing.T) {
+	_, err := journal.NewFile("")
+	require.Error(t, err)
+}
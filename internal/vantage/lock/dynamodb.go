@@ -0,0 +1,211 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB attribute names. The table's partition key must be "key" (string),
+// so the bookmarks table can hold locks too.
+const (
+	dynamoKeyAttr     = "key"
+	dynamoOwnerAttr   = "owner"
+	dynamoExpiresAttr = "lease_expires_at"
+)
+
+// DefaultLeaseDuration is how long a DynamoDB lease lasts without renewal.
+const DefaultLeaseDuration = time.Minute
+
+// DynamoDBAPI is the subset of the DynamoDB client used by the locker.
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(
+		ctx context.Context,
+		in *dynamodb.DeleteItemInput,
+		opts ...func(*dynamodb.Options),
+	) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDB locks with leases stored as items in a DynamoDB table. A lease is
+// renewed every third of its duration while held; a holder that crashes
+// stops renewing and the lease can be taken once it expires.
+type DynamoDB struct {
+	api   DynamoDBAPI
+	table string
+	lease time.Duration
+}
+
+// NewDynamoDB creates a locker over an existing table. A non-positive lease
+// uses DefaultLeaseDuration.
+func NewDynamoDB(api DynamoDBAPI, table string, lease time.Duration) (*DynamoDB, error) {
+	if api == nil {
+		return nil, errors.New("dynamodb client cannot be nil")
+	}
+	if table == "" {
+		return nil, errors.New("dynamodb table cannot be empty")
+	}
+	if lease <= 0 {
+		lease = DefaultLeaseDuration
+	}
+	return &DynamoDB{api: api, table: table, lease: lease}, nil
+}
+
+// Acquire implements Locker. It takes the lease when no item exists for key
+// or the existing lease has expired.
+func (d *DynamoDB) Acquire(ctx context.Context, key string) (Lock, error) {
+	owner, err := newOwnerID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = d.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.table),
+		Item:                d.leaseItem(key, owner, now),
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expires < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#key":     dynamoKeyAttr,
+			"#expires": dynamoExpiresAttr,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": unixMilliAttr(now),
+		},
+	})
+	if isConditionFailed(err) {
+		return nil, ErrHeld
+	}
+	if err != nil {
+		return nil, fmt.Errorf("taking dynamodb lease: %w", err)
+	}
+
+	l := &dynamoLock{locker: d, key: key, owner: owner, lost: make(chan struct{}), stop: make(chan struct{})}
+	go l.renew()
+	return l, nil
+}
+
+// leaseItem builds the item recording owner's lease on key from now.
+func (d *DynamoDB) leaseItem(key, owner string, now time.Time) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		dynamoKeyAttr:     &types.AttributeValueMemberS{Value: key},
+		dynamoOwnerAttr:   &types.AttributeValueMemberS{Value: owner},
+		dynamoExpiresAttr: unixMilliAttr(now.Add(d.lease)),
+	}
+}
+
+// ownerCondition restricts a write to items still leased by owner.
+func ownerCondition(owner string) (*string, map[string]string, map[string]types.AttributeValue) {
+	return aws.String("#owner = :owner"),
+		map[string]string{"#owner": dynamoOwnerAttr},
+		map[string]types.AttributeValue{":owner": &types.AttributeValueMemberS{Value: owner}}
+}
+
+type dynamoLock struct {
+	locker *DynamoDB
+	key    string
+	owner  string
+	lost   chan struct{}
+	stop   chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// renew extends the lease until Release, closing lost once the lease is
+// taken over or can no longer be renewed before it expires.
+func (l *dynamoLock) renew() {
+	interval := l.locker.lease / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	expires := time.Now().Add(l.locker.lease)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			err := l.extend(now)
+			if err == nil {
+				expires = now.Add(l.locker.lease)
+				continue
+			}
+			// Transient errors are retried until the lease would lapse.
+			if isConditionFailed(err) || !now.Add(interval).Before(expires) {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// extend rewrites the lease from now if it is still held by this owner.
+func (l *dynamoLock) extend(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.locker.lease/3)
+	defer cancel()
+
+	condition, names, values := ownerCondition(l.owner)
+	_, err := l.locker.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(l.locker.table),
+		Item:                      l.locker.leaseItem(l.key, l.owner, now),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+// Lost implements Lock.
+func (l *dynamoLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release implements Lock. A lease already taken over by another holder is
+// left alone.
+func (l *dynamoLock) Release(ctx context.Context) error {
+	l.once.Do(func() {
+		close(l.stop)
+		condition, names, values := ownerCondition(l.owner)
+		_, err := l.locker.api.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(l.locker.table),
+			Key: map[string]types.AttributeValue{
+				dynamoKeyAttr: &types.AttributeValueMemberS{Value: l.key},
+			},
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		})
+		if err != nil && !isConditionFailed(err) {
+			l.err = fmt.Errorf("releasing dynamodb lease: %w", err)
+		}
+	})
+	return l.err
+}
+
+// newOwnerID returns a random id identifying one lease holder.
+func newOwnerID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating lease owner: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// unixMilliAttr encodes t as Unix milliseconds.
+func unixMilliAttr(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMilli(), 10)}
+}
+
+// isConditionFailed reports whether a conditional write was rejected.
+func isConditionFailed(err error) bool {
+	var failed *types.ConditionalCheckFailedException
+	return errors.As(err, &failed)
+}
@@ -0,0 +1,74 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	dirPerm  = 0o750
+	filePerm = 0o600
+)
+
+// File locks with an OS file lock on <dir>/<key>.lock. The OS drops the lock
+// when the holding process exits, so a crashed run never leaves a stale
+// lock. It coordinates processes on one host or sharing one local volume;
+// network filesystems often do not honor the lock, so use Postgres or
+// DynamoDB across hosts.
+type File struct {
+	dir string
+}
+
+// NewFile creates a file locker in dir, creating the directory if needed.
+func NewFile(dir string) (*File, error) {
+	if dir == "" {
+		return nil, errors.New("lock directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+	return &File{dir: dir}, nil
+}
+
+// Acquire implements Locker.
+func (f *File) Acquire(_ context.Context, key string) (Lock, error) {
+	path := filepath.Join(f.dir, key+".lock")
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, filePerm)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	if err := tryLockFile(file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+type fileLock struct {
+	file *os.File
+	once sync.Once
+	err  error
+}
+
+// Lost implements Lock. The OS holds the lock until release or exit.
+func (l *fileLock) Lost() <-chan struct{} {
+	return nil
+}
+
+// Release implements Lock. The lock file is left in place; deleting it
+// would let a waiter lock an unlinked file while a newcomer creates another.
+func (l *fileLock) Release(_ context.Context) error {
+	l.once.Do(func() {
+		unlockErr := unlockFile(l.file)
+		closeErr := l.file.Close()
+		if err := errors.Join(unlockErr, closeErr); err != nil {
+			l.err = fmt.Errorf("releasing file lock: %w", err)
+		}
+	})
+	return l.err
+}
@@ -0,0 +1,51 @@
+// Package lock provides exclusive locks that keep overlapping sync runs
+// against the same report from double-writing records or racing on
+// bookmarks, whether they run in one process, on one host, or across hosts.
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrHeld is returned by Acquire when another holder has the lock.
+var ErrHeld = errors.New("lock is held by another process")
+
+// pollInterval is how often AcquireWithin retries a held lock.
+const pollInterval = 2 * time.Second
+
+// Locker takes exclusive locks by key.
+type Locker interface {
+	// Acquire takes the lock for key without blocking, returning ErrHeld
+	// when it is taken.
+	Acquire(ctx context.Context, key string) (Lock, error)
+}
+
+// Lock is a held lock.
+type Lock interface {
+	// Lost is closed when the lock is lost before Release, for example when
+	// a lease cannot be renewed or the database session drops.
+	Lost() <-chan struct{}
+
+	// Release gives up the lock. It is safe to call more than once.
+	Release(ctx context.Context) error
+}
+
+// AcquireWithin takes the lock for key, retrying while it is held for up to
+// wait. A zero wait tries once.
+func AcquireWithin(ctx context.Context, locker Locker, key string, wait time.Duration) (Lock, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		held, err := locker.Acquire(ctx, key)
+		if !errors.Is(err, ErrHeld) || !time.Now().Add(pollInterval).Before(deadline) {
+			return held, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
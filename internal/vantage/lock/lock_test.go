@@ -0,0 +1,261 @@
+package lock_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/lock"
+)
+
+var (
+	_ lock.Locker = (*lock.Memory)(nil)
+	_ lock.Locker = (*lock.File)(nil)
+	_ lock.Locker = (*lock.Postgres)(nil)
+	_ lock.Locker = (*lock.DynamoDB)(nil)
+)
+
+// exerciseLocker checks the shared acquire/exclude/release contract.
+func exerciseLocker(t *testing.T, locker lock.Locker) {
+	t.Helper()
+	ctx := context.Background()
+
+	held, err := locker.Acquire(ctx, "vantage_lock_abc")
+	require.NoError(t, err)
+
+	_, err = locker.Acquire(ctx, "vantage_lock_abc")
+	require.ErrorIs(t, err, lock.ErrHeld)
+
+	other, err := locker.Acquire(ctx, "vantage_lock_def")
+	require.NoError(t, err)
+	require.NoError(t, other.Release(ctx))
+
+	require.NoError(t, held.Release(ctx))
+	require.NoError(t, held.Release(ctx), "release is idempotent")
+
+	again, err := locker.Acquire(ctx, "vantage_lock_abc")
+	require.NoError(t, err)
+	require.NoError(t, again.Release(ctx))
+}
+
+func TestMemory(t *testing.T) {
+	exerciseLocker(t, lock.NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := lock.NewFile(dir)
+	require.NoError(t, err)
+	exerciseLocker(t, locker)
+
+	// A second locker over the same directory, as another process would
+	// open, sees the lock.
+	held, err := locker.Acquire(context.Background(), "vantage_lock_abc")
+	require.NoError(t, err)
+	defer func() { _ = held.Release(context.Background()) }()
+
+	other, err := lock.NewFile(dir)
+	require.NoError(t, err)
+	_, err = other.Acquire(context.Background(), "vantage_lock_abc")
+	require.ErrorIs(t, err, lock.ErrHeld)
+}
+
+func TestNewFile_EmptyDir(t *testing.T) {
+	_, err := lock.NewFile("")
+	require.Error(t, err)
+}
+
+func TestAcquireWithin(t *testing.T) {
+	ctx := context.Background()
+	locker := lock.NewMemory()
+	held, err := locker.Acquire(ctx, "key")
+	require.NoError(t, err)
+	defer func() { _ = held.Release(ctx) }()
+
+	t.Run("zero wait tries once", func(t *testing.T) {
+		_, err := lock.AcquireWithin(ctx, locker, "key", 0)
+		require.ErrorIs(t, err, lock.ErrHeld)
+	})
+
+	t.Run("stops when the context ends", func(t *testing.T) {
+		cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := lock.AcquireWithin(cancelCtx, locker, "key", time.Minute)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("free lock", func(t *testing.T) {
+		free, err := lock.AcquireWithin(ctx, locker, "other", time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, free.Release(ctx))
+	})
+}
+
+func TestAdvisoryKey(t *testing.T) {
+	assert.Equal(t, lock.AdvisoryKey("vantage_lock_abc"), lock.AdvisoryKey("vantage_lock_abc"))
+	assert.NotEqual(t, lock.AdvisoryKey("vantage_lock_abc"), lock.AdvisoryKey("vantage_lock_def"))
+}
+
+func TestNewPostgres_NilDB(t *testing.T) {
+	_, err := lock.NewPostgres(nil)
+	require.Error(t, err)
+}
+
+// fakeDynamoDB evaluates the two conditions the locker writes: a free or
+// expired lease (":now") and an owner match (":owner").
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: make(map[string]map[string]types.AttributeValue)}
+}
+
+func (f *fakeDynamoDB) PutItem(
+	_ context.Context,
+	in *dynamodb.PutItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := attrString(in.Item["key"])
+	if !f.conditionHolds(f.items[key], in.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	f.items[key] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(
+	_ context.Context,
+	in *dynamodb.DeleteItemInput,
+	_ ...func(*dynamodb.Options),
+) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := attrString(in.Key["key"])
+	if !f.conditionHolds(f.items[key], in.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	delete(f.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) conditionHolds(item, values map[string]types.AttributeValue) bool {
+	if now, ok := values[":now"]; ok {
+		return item == nil || attrInt(item["lease_expires_at"]) < attrInt(now)
+	}
+	if owner, ok := values[":owner"]; ok {
+		return item != nil && attrString(item["owner"]) == attrString(owner)
+	}
+	return true
+}
+
+// put stores a lease for key held by owner until expires.
+func (f *fakeDynamoDB) put(key, owner string, expires time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items[key] = map[string]types.AttributeValue{
+		"key":              &types.AttributeValueMemberS{Value: key},
+		"owner":            &types.AttributeValueMemberS{Value: owner},
+		"lease_expires_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(expires.UnixMilli(), 10)},
+	}
+}
+
+func (f *fakeDynamoDB) owner(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return attrString(f.items[key]["owner"])
+}
+
+func attrString(v types.AttributeValue) string {
+	if s, ok := v.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+func attrInt(v types.AttributeValue) int64 {
+	if n, ok := v.(*types.AttributeValueMemberN); ok {
+		i, _ := strconv.ParseInt(n.Value, 10, 64)
+		return i
+	}
+	return 0
+}
+
+func TestDynamoDB(t *testing.T) {
+	locker, err := lock.NewDynamoDB(newFakeDynamoDB(), "pulumicost_locks", 0)
+	require.NoError(t, err)
+	exerciseLocker(t, locker)
+}
+
+func TestDynamoDB_TakesExpiredLease(t *testing.T) {
+	api := newFakeDynamoDB()
+	locker, err := lock.NewDynamoDB(api, "pulumicost_locks", time.Minute)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	api.put("live", "other-runner", time.Now().Add(time.Minute))
+	_, err = locker.Acquire(ctx, "live")
+	require.ErrorIs(t, err, lock.ErrHeld)
+
+	api.put("crashed", "other-runner", time.Now().Add(-time.Second))
+	held, err := locker.Acquire(ctx, "crashed")
+	require.NoError(t, err)
+	assert.NotEqual(t, "other-runner", api.owner("crashed"))
+	require.NoError(t, held.Release(ctx))
+}
+
+func TestDynamoDB_RenewsLease(t *testing.T) {
+	api := newFakeDynamoDB()
+	locker, err := lock.NewDynamoDB(api, "pulumicost_locks", 150*time.Millisecond)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	held, err := locker.Acquire(ctx, "key")
+	require.NoError(t, err)
+	defer func() { _ = held.Release(ctx) }()
+
+	// Well past the lease duration, renewals keep others out.
+	time.Sleep(400 * time.Millisecond)
+	_, err = locker.Acquire(ctx, "key")
+	require.ErrorIs(t, err, lock.ErrHeld)
+}
+
+func TestDynamoDB_LostWhenTakenOver(t *testing.T) {
+	api := newFakeDynamoDB()
+	locker, err := lock.NewDynamoDB(api, "pulumicost_locks", 150*time.Millisecond)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	held, err := locker.Acquire(ctx, "key")
+	require.NoError(t, err)
+
+	api.put("key", "other-runner", time.Now().Add(time.Minute))
+	select {
+	case <-held.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lock was not reported lost")
+	}
+
+	// Releasing leaves the new holder's lease alone.
+	require.NoError(t, held.Release(ctx))
+	assert.Equal(t, "other-runner", api.owner("key"))
+}
+
+func TestNewDynamoDB_Validation(t *testing.T) {
+	_, err := lock.NewDynamoDB(nil, "pulumicost_locks", 0)
+	require.Error(t, err)
+	_, err = lock.NewDynamoDB(newFakeDynamoDB(), "", 0)
+	require.Error(t, err)
+}
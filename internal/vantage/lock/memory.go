@@ -0,0 +1,51 @@
+package lock
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory locks within a single process. It suits tests and embedding the
+// adapter in a long-running service that runs syncs concurrently.
+type Memory struct {
+	mu   sync.Mutex
+	held map[string]struct{}
+}
+
+// NewMemory creates a locker with no locks held.
+func NewMemory() *Memory {
+	return &Memory{held: make(map[string]struct{})}
+}
+
+// Acquire implements Locker.
+func (m *Memory) Acquire(_ context.Context, key string) (Lock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.held[key]; ok {
+		return nil, ErrHeld
+	}
+	m.held[key] = struct{}{}
+	return &memoryLock{locker: m, key: key}, nil
+}
+
+type memoryLock struct {
+	locker *Memory
+	key    string
+	once   sync.Once
+}
+
+// Lost implements Lock. An in-process lock is never lost.
+func (l *memoryLock) Lost() <-chan struct{} {
+	return nil
+}
+
+// Release implements Lock.
+func (l *memoryLock) Release(_ context.Context) error {
+	l.once.Do(func() {
+		l.locker.mu.Lock()
+		defer l.locker.mu.Unlock()
+		delete(l.locker.held, l.key)
+	})
+	return nil
+}
@@ -0,0 +1,285 @@
+// Package opencost serves synced cost records through an OpenCost-compatible
+// allocation API, so dashboards built for OpenCost can read Vantage-derived
+// Kubernetes costs.
+package opencost
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	// Unallocated names allocations missing an aggregated property.
+	Unallocated = "__unallocated__"
+
+	kubernetesLabelPrefix = "kubernetes.io/"
+	labelAggregatePrefix  = "label:"
+)
+
+// Properties are the Kubernetes properties an allocation is keyed by.
+type Properties struct {
+	Cluster    string            `json:"cluster,omitempty"`
+	Node       string            `json:"node,omitempty"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Controller string            `json:"controller,omitempty"`
+	Pod        string            `json:"pod,omitempty"`
+	Container  string            `json:"container,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// Allocation is the cost of one aggregate over one window, in the OpenCost
+// allocation shape. Vantage reports a single cost per row, so the resource
+// breakdown (CPU, RAM, ...) is zero and the whole cost is in TotalCost.
+type Allocation struct {
+	Name             string     `json:"name"`
+	Properties       Properties `json:"properties"`
+	Window           Window     `json:"window"`
+	Start            time.Time  `json:"start"`
+	End              time.Time  `json:"end"`
+	Minutes          float64    `json:"minutes"`
+	CPUCost          float64    `json:"cpuCost"`
+	GPUCost          float64    `json:"gpuCost"`
+	RAMCost          float64    `json:"ramCost"`
+	PVCost           float64    `json:"pvCost"`
+	NetworkCost      float64    `json:"networkCost"`
+	LoadBalancerCost float64    `json:"loadBalancerCost"`
+	SharedCost       float64    `json:"sharedCost"`
+	ExternalCost     float64    `json:"externalCost"`
+	TotalCost        float64    `json:"totalCost"`
+}
+
+// AllocationSet maps allocation names to allocations for one step.
+type AllocationSet map[string]*Allocation
+
+// Query selects and groups records for an allocation request.
+type Query struct {
+	Window Window
+	// Step splits the window into one set per step; zero means one set.
+	Step time.Duration
+	// Aggregate lists the properties allocations are grouped by: cluster,
+	// node, namespace, controller, pod, container, or label:<name>. Empty
+	// groups by cluster, node, namespace, pod, and container.
+	Aggregate []string
+	// Granularity ("day" or "month") is the bucket size of the records.
+	Granularity string
+}
+
+// defaultAggregate is OpenCost's default allocation identity.
+var defaultAggregate = []string{"cluster", "node", "namespace", "pod", "container"}
+
+// ValidateAggregate rejects unknown aggregation properties.
+func ValidateAggregate(aggregate []string) error {
+	for _, property := range aggregate {
+		if strings.HasPrefix(property, labelAggregatePrefix) {
+			if strings.TrimPrefix(property, labelAggregatePrefix) == "" {
+				return fmt.Errorf("invalid aggregate %q: missing label name", property)
+			}
+			continue
+		}
+		if !slices.Contains(SupportedAggregates(), property) {
+			return fmt.Errorf("invalid aggregate %q (valid: %s, label:<name>)",
+				property, strings.Join(SupportedAggregates(), ", "))
+		}
+	}
+	return nil
+}
+
+// SupportedAggregates returns the property names accepted by aggregate.
+func SupportedAggregates() []string {
+	return []string{"cluster", "node", "namespace", "controller", "pod", "container"}
+}
+
+// Compute groups the Kubernetes cost records overlapping q.Window into one
+// AllocationSet per step. A record counts in full towards the step its bucket
+// starts in (or the first step, when it starts before the window). Records
+// without any Kubernetes property are not Kubernetes costs and are skipped.
+func Compute(records []adapter.CostRecord, q Query) []AllocationSet {
+	aggregate := q.Aggregate
+	if len(aggregate) == 0 {
+		aggregate = defaultAggregate
+	}
+
+	windows := steps(q.Window, q.Step)
+	sets := make([]AllocationSet, len(windows))
+	for i := range sets {
+		sets[i] = make(AllocationSet)
+	}
+
+	for i := range records {
+		record := &records[i]
+		if record.MetricType != "" && record.MetricType != "cost" {
+			continue
+		}
+		if !overlaps(record, q) {
+			continue
+		}
+
+		props, ok := propertiesOf(record)
+		if !ok {
+			continue
+		}
+
+		step := stepIndex(windows, record.Timestamp)
+		name, grouped := group(props, aggregate)
+		alloc := sets[step][name]
+		if alloc == nil {
+			w := windows[step]
+			alloc = &Allocation{
+				Name:       name,
+				Properties: grouped,
+				Window:     w,
+				Start:      w.Start,
+				End:        w.End,
+				Minutes:    w.End.Sub(w.Start).Minutes(),
+			}
+			sets[step][name] = alloc
+		}
+		if record.NetCost != nil {
+			alloc.TotalCost += *record.NetCost
+		}
+	}
+
+	return sets
+}
+
+// Accumulate merges sets into a single set spanning all of them.
+func Accumulate(sets []AllocationSet, window Window) AllocationSet {
+	merged := make(AllocationSet)
+	for _, set := range sets {
+		for _, name := range slices.Sorted(maps.Keys(set)) {
+			alloc := set[name]
+			total, ok := merged[name]
+			if !ok {
+				copied := *alloc
+				copied.Window = window
+				copied.Start, copied.End = window.Start, window.End
+				copied.Minutes = window.End.Sub(window.Start).Minutes()
+				merged[name] = &copied
+				continue
+			}
+			total.TotalCost += alloc.TotalCost
+		}
+	}
+	return merged
+}
+
+// propertiesOf reads the Kubernetes properties of a record from its labels.
+// Each property is read from kubernetes.io/<property>, then <property>, so
+// labels merged by tags.merge_policy are found too. The remaining labels
+// become the allocation's labels, with kubernetes.io/ stripped; a prefixed
+// label wins over an unprefixed one of the same name.
+func propertiesOf(record *adapter.CostRecord) (Properties, bool) {
+	lookup := func(property string) string {
+		if v := record.Labels[kubernetesLabelPrefix+property]; v != "" {
+			return v
+		}
+		return record.Labels[property]
+	}
+
+	props := Properties{
+		Cluster:    lookup("cluster"),
+		Node:       lookup("node"),
+		Namespace:  lookup("namespace"),
+		Controller: lookup("controller"),
+		Pod:        lookup("pod"),
+		Container:  lookup("container"),
+	}
+	if props.Cluster == "" && props.Node == "" && props.Namespace == "" &&
+		props.Controller == "" && props.Pod == "" && props.Container == "" {
+		return props, false
+	}
+
+	for key, value := range record.Labels {
+		name, prefixed := strings.CutPrefix(key, kubernetesLabelPrefix)
+		if slices.Contains(SupportedAggregates(), name) {
+			continue
+		}
+		if _, taken := props.Labels[name]; taken && !prefixed {
+			continue
+		}
+		if props.Labels == nil {
+			props.Labels = make(map[string]string)
+		}
+		props.Labels[name] = value
+	}
+	return props, true
+}
+
+// group returns the allocation name for props under aggregate, along with
+// the properties shared by every member of the group.
+func group(props Properties, aggregate []string) (string, Properties) {
+	var grouped Properties
+	parts := make([]string, 0, len(aggregate))
+	for _, property := range aggregate {
+		value := ""
+		switch property {
+		case "cluster":
+			value, grouped.Cluster = props.Cluster, props.Cluster
+		case "node":
+			value, grouped.Node = props.Node, props.Node
+		case "namespace":
+			value, grouped.Namespace = props.Namespace, props.Namespace
+		case "controller":
+			value, grouped.Controller = props.Controller, props.Controller
+		case "pod":
+			value, grouped.Pod = props.Pod, props.Pod
+		case "container":
+			value, grouped.Container = props.Container, props.Container
+		default:
+			label := strings.TrimPrefix(property, labelAggregatePrefix)
+			value = props.Labels[label]
+			if value != "" {
+				if grouped.Labels == nil {
+					grouped.Labels = make(map[string]string)
+				}
+				grouped.Labels[label] = value
+			}
+		}
+		if value == "" {
+			value = Unallocated
+		}
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, "/"), grouped
+}
+
+// steps splits w into consecutive windows of length step.
+func steps(w Window, step time.Duration) []Window {
+	if step <= 0 || step >= w.End.Sub(w.Start) {
+		return []Window{w}
+	}
+	var windows []Window
+	for start := w.Start; start.Before(w.End); start = start.Add(step) {
+		end := start.Add(step)
+		if end.After(w.End) {
+			end = w.End
+		}
+		windows = append(windows, Window{start, end})
+	}
+	return windows
+}
+
+// stepIndex returns the window containing t, or the first one when t is
+// before every window.
+func stepIndex(windows []Window, t time.Time) int {
+	for i := len(windows) - 1; i > 0; i-- {
+		if !t.Before(windows[i].Start) {
+			return i
+		}
+	}
+	return 0
+}
+
+// overlaps reports whether the record's bucket overlaps the query window.
+func overlaps(record *adapter.CostRecord, q Query) bool {
+	end := record.Timestamp.AddDate(0, 0, 1)
+	if q.Granularity == "month" {
+		end = record.Timestamp.AddDate(0, 1, 0)
+	}
+	return record.Timestamp.Before(q.Window.End) && end.After(q.Window.Start)
+}
@@ -0,0 +1,95 @@
+package opencost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func costRecord(day int, cost float64, labels map[string]string) adapter.CostRecord {
+	return adapter.CostRecord{
+		Timestamp:  time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC),
+		NetCost:    &cost,
+		Labels:     labels,
+		MetricType: "cost",
+	}
+}
+
+func testRecords() []adapter.CostRecord {
+	return []adapter.CostRecord{
+		costRecord(1, 10, map[string]string{
+			"kubernetes.io/cluster":   "prod",
+			"kubernetes.io/namespace": "web",
+			"kubernetes.io/app":       "frontend",
+		}),
+		costRecord(2, 5, map[string]string{
+			"kubernetes.io/cluster":   "prod",
+			"kubernetes.io/namespace": "web",
+			"kubernetes.io/app":       "frontend",
+		}),
+		// Labels merged into the provider namespace by tags.merge_policy.
+		costRecord(2, 7, map[string]string{"cluster": "prod", "namespace": "api", "app": "backend"}),
+		costRecord(2, 3, map[string]string{"kubernetes.io/cluster": "dev"}),
+		// Not a Kubernetes cost.
+		costRecord(2, 100, map[string]string{"team": "core"}),
+		// Outside the window.
+		costRecord(9, 50, map[string]string{"kubernetes.io/namespace": "web"}),
+	}
+}
+
+func TestCompute_Aggregate(t *testing.T) {
+	window := Window{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)}
+	sets := Compute(testRecords(), Query{Window: window, Aggregate: []string{"namespace"}, Granularity: "day"})
+	require.Len(t, sets, 1)
+
+	set := sets[0]
+	require.Len(t, set, 3)
+	assert.InDelta(t, 15.0, set["web"].TotalCost, 1e-9)
+	assert.Equal(t, "web", set["web"].Properties.Namespace)
+	assert.Empty(t, set["web"].Properties.Cluster, "only aggregated properties are kept")
+	assert.InDelta(t, 7.0, set["api"].TotalCost, 1e-9)
+	assert.InDelta(t, 3.0, set[Unallocated].TotalCost, 1e-9)
+	assert.Equal(t, window, set["web"].Window)
+	assert.InDelta(t, 2880.0, set["web"].Minutes, 1e-9)
+}
+
+func TestCompute_LabelAggregateAndSteps(t *testing.T) {
+	window := Window{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)}
+	sets := Compute(testRecords(), Query{
+		Window:      window,
+		Step:        24 * time.Hour,
+		Aggregate:   []string{"cluster", "label:app"},
+		Granularity: "day",
+	})
+	require.Len(t, sets, 2)
+
+	assert.InDelta(t, 10.0, sets[0]["prod/frontend"].TotalCost, 1e-9)
+	assert.Equal(t, map[string]string{"app": "frontend"}, sets[0]["prod/frontend"].Properties.Labels)
+	assert.InDelta(t, 5.0, sets[1]["prod/frontend"].TotalCost, 1e-9)
+	assert.InDelta(t, 7.0, sets[1]["prod/backend"].TotalCost, 1e-9)
+	assert.InDelta(t, 3.0, sets[1]["dev/"+Unallocated].TotalCost, 1e-9)
+
+	merged := Accumulate(sets, window)
+	assert.InDelta(t, 15.0, merged["prod/frontend"].TotalCost, 1e-9)
+	assert.Equal(t, window, merged["prod/frontend"].Window)
+	assert.InDelta(t, 10.0, sets[0]["prod/frontend"].TotalCost, 1e-9, "accumulating does not modify the input")
+}
+
+func TestCompute_MonthBucketsOverlapWindow(t *testing.T) {
+	window := Window{time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)}
+	records := []adapter.CostRecord{costRecord(1, 30, map[string]string{"kubernetes.io/namespace": "web"})}
+
+	assert.Empty(t, Compute(records, Query{Window: window, Aggregate: []string{"namespace"}, Granularity: "day"})[0])
+	set := Compute(records, Query{Window: window, Aggregate: []string{"namespace"}, Granularity: "month"})[0]
+	assert.InDelta(t, 30.0, set["web"].TotalCost, 1e-9)
+}
+
+func TestValidateAggregate(t *testing.T) {
+	require.NoError(t, ValidateAggregate([]string{"cluster", "namespace", "label:app"}))
+	require.ErrorContains(t, ValidateAggregate([]string{"deployment"}), "invalid aggregate")
+	require.ErrorContains(t, ValidateAggregate([]string{"label:"}), "missing label name")
+}
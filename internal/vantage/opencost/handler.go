@@ -0,0 +1,123 @@
+package opencost
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// RecordSource returns the current cost records to serve.
+type RecordSource func(ctx context.Context) ([]adapter.CostRecord, error)
+
+// Handler serves the OpenCost allocation API:
+//
+//	GET /allocation?window=7d&aggregate=namespace&step=1d&accumulate=false
+//
+// /allocation/compute is served as an alias.
+type Handler struct {
+	source      RecordSource
+	granularity string
+	logger      client.Logger
+	now         func() time.Time
+	mux         *http.ServeMux
+}
+
+// response is the OpenCost response envelope.
+type response struct {
+	Code    int             `json:"code"`
+	Data    []AllocationSet `json:"data,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// NewHandler returns a handler serving records from source. granularity
+// ("day" or "month") is the bucket size of the records.
+func NewHandler(source RecordSource, granularity string, logger client.Logger) *Handler {
+	if logger == nil {
+		logger = client.NewNoopLogger()
+	}
+	h := &Handler{
+		source:      source,
+		granularity: granularity,
+		logger:      logger,
+		now:         time.Now,
+		mux:         http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /allocation", h.allocation)
+	h.mux.HandleFunc("GET /allocation/compute", h.allocation)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) allocation(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	windowParam := params.Get("window")
+	if windowParam == "" {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Message: "missing window parameter"})
+		return
+	}
+	window, err := ParseWindow(windowParam, h.now())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	q := Query{Window: window, Granularity: h.granularity}
+	if aggregate := params.Get("aggregate"); aggregate != "" {
+		q.Aggregate = strings.Split(aggregate, ",")
+		if err := ValidateAggregate(q.Aggregate); err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+	}
+	if step := params.Get("step"); step != "" {
+		if q.Step, err = ParseDuration(step); err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+	}
+	accumulate := false
+	if v := params.Get("accumulate"); v != "" {
+		if accumulate, err = strconv.ParseBool(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Code: http.StatusBadRequest, Message: "invalid accumulate parameter"})
+			return
+		}
+	}
+
+	records, err := h.source(r.Context())
+	if err != nil {
+		h.logger.Error(r.Context(), "Reading records for allocation request failed", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "opencost_allocation",
+			"attempt":   0,
+			"error":     err.Error(),
+		})
+		writeJSON(w, http.StatusInternalServerError, response{
+			Code:    http.StatusInternalServerError,
+			Message: "reading records failed",
+		})
+		return
+	}
+
+	sets := Compute(records, q)
+	if accumulate {
+		sets = []AllocationSet{Accumulate(sets, window)}
+	}
+	writeJSON(w, http.StatusOK, response{Code: http.StatusOK, Data: sets})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
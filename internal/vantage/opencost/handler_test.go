@@ -0,0 +1,75 @@
+package opencost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func newTestHandler(source RecordSource) *Handler {
+	h := NewHandler(source, "day", nil)
+	h.now = func() time.Time { return time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC) }
+	return h
+}
+
+func get(t *testing.T, h http.Handler, target string) (int, response) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var body response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestHandler_Allocation(t *testing.T) {
+	h := newTestHandler(func(context.Context) ([]adapter.CostRecord, error) {
+		return testRecords(), nil
+	})
+
+	code, body := get(t, h, "/allocation?window=2d&aggregate=namespace&step=1d")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, http.StatusOK, body.Code)
+	require.Len(t, body.Data, 2)
+	assert.InDelta(t, 10.0, body.Data[0]["web"].TotalCost, 1e-9)
+
+	code, body = get(t, h, "/allocation/compute?window=2d&aggregate=namespace&step=1d&accumulate=true")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, body.Data, 1)
+	assert.InDelta(t, 15.0, body.Data[0]["web"].TotalCost, 1e-9)
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	h := newTestHandler(func(context.Context) ([]adapter.CostRecord, error) { return nil, nil })
+
+	for _, target := range []string{
+		"/allocation",
+		"/allocation?window=forever",
+		"/allocation?window=1d&aggregate=deployment",
+		"/allocation?window=1d&step=1x",
+		"/allocation?window=1d&accumulate=maybe",
+	} {
+		code, body := get(t, h, target)
+		assert.Equal(t, http.StatusBadRequest, code, target)
+		assert.NotEmpty(t, body.Message, target)
+	}
+}
+
+func TestHandler_SourceError(t *testing.T) {
+	h := newTestHandler(func(context.Context) ([]adapter.CostRecord, error) {
+		return nil, errors.New("disk on fire")
+	})
+
+	code, body := get(t, h, "/allocation?window=1d")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.NotContains(t, body.Message, "disk on fire", "internal errors are not leaked")
+}
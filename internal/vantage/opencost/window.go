@@ -0,0 +1,119 @@
+package opencost
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// Window is a half-open time range [Start, End).
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ParseWindow parses an OpenCost window parameter relative to now:
+// "today", "yesterday", "week", "month", "lastweek", "lastmonth", a duration
+// such as "7d" or "24h" ending at the next day or hour boundary, or an
+// explicit "start,end" pair of RFC 3339 timestamps or Unix seconds.
+func ParseWindow(s string, now time.Time) (Window, error) {
+	now = now.UTC()
+	today := now.Truncate(day)
+
+	switch strings.ToLower(s) {
+	case "today":
+		return Window{today, today.Add(day)}, nil
+	case "yesterday":
+		return Window{today.Add(-day), today}, nil
+	case "week":
+		return Window{startOfWeek(today), today.Add(day)}, nil
+	case "lastweek":
+		start := startOfWeek(today)
+		return Window{start.AddDate(0, 0, -7), start}, nil
+	case "month":
+		return Window{startOfMonth(today), today.Add(day)}, nil
+	case "lastmonth":
+		start := startOfMonth(today)
+		return Window{start.AddDate(0, -1, 0), start}, nil
+	}
+
+	if start, end, ok := strings.Cut(s, ","); ok {
+		return parseRange(start, end)
+	}
+
+	d, err := ParseDuration(s)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q", s)
+	}
+	end := today.Add(day)
+	if d%day != 0 {
+		end = now.Truncate(time.Hour).Add(time.Hour)
+	}
+	return Window{end.Add(-d), end}, nil
+}
+
+// ParseDuration parses the "<n>d", "<n>h", and "<n>m" durations OpenCost
+// accepts for windows and steps.
+func ParseDuration(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	switch s[len(s)-1] {
+	case 'd':
+		return time.Duration(n) * day, nil
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'm':
+		return time.Duration(n) * time.Minute, nil
+	default:
+		return 0, fmt.Errorf("invalid duration %q: unit must be d, h, or m", s)
+	}
+}
+
+// parseRange parses an explicit "start,end" window.
+func parseRange(startStr, endStr string) (Window, error) {
+	start, err := parseTime(startStr)
+	if err != nil {
+		return Window{}, err
+	}
+	end, err := parseTime(endStr)
+	if err != nil {
+		return Window{}, err
+	}
+	if !end.After(start) {
+		return Window{}, fmt.Errorf("invalid window: end %s is not after start %s",
+			end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	return Window{start, end}, nil
+}
+
+// parseTime parses an RFC 3339 timestamp or Unix seconds.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid window time %q: expected RFC 3339 or Unix seconds", s)
+	}
+	return t.UTC(), nil
+}
+
+// startOfWeek returns the Sunday starting the week of t, a UTC midnight.
+func startOfWeek(t time.Time) time.Time {
+	return t.AddDate(0, 0, -int(t.Weekday()))
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
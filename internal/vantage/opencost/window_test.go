@@ -0,0 +1,45 @@
+package opencost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindow(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2024, 3, 13, 15, 30, 0, 0, time.UTC)
+	date := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		window string
+		want   Window
+	}{
+		{"today", Window{date(3, 13), date(3, 14)}},
+		{"yesterday", Window{date(3, 12), date(3, 13)}},
+		{"week", Window{date(3, 10), date(3, 14)}},
+		{"lastweek", Window{date(3, 3), date(3, 10)}},
+		{"month", Window{date(3, 1), date(3, 14)}},
+		{"lastmonth", Window{date(2, 1), date(3, 1)}},
+		{"7d", Window{date(3, 7), date(3, 14)}},
+		{"6h", Window{now.Truncate(time.Hour).Add(-5 * time.Hour), now.Truncate(time.Hour).Add(time.Hour)}},
+		{"2024-03-01T00:00:00Z,2024-03-05T00:00:00Z", Window{date(3, 1), date(3, 5)}},
+		{"1709251200,1709596800", Window{date(3, 1), date(3, 5)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			got, err := ParseWindow(tt.window, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, invalid := range []string{"", "7x", "0d", "forever", "2024-03-05T00:00:00Z,2024-03-01T00:00:00Z"} {
+		_, err := ParseWindow(invalid, now)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,331 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/stack"
+)
+
+const (
+	// CostSourceServiceName is the fully-qualified gRPC service name of the
+	// cost source service.
+	CostSourceServiceName = "pulumicost.vantage.v1.CostSource"
+
+	// ProjectedCostMethod is the full gRPC method name of the projected cost
+	// RPC.
+	ProjectedCostMethod = "/" + CostSourceServiceName + "/GetProjectedCost"
+
+	defaultProjectionLookbackDays = 30
+)
+
+// ErrNoCostReport is returned for projected costs when the plugin was not
+// configured with a cost report to forecast.
+var ErrNoCostReport = errors.New("projected costs require params.cost_report_token")
+
+// Projection configures how GetProjectedCost apportions the report
+// forecast to resources.
+type Projection struct {
+	// Strategy is one of adapter.SupportedProjectionStrategies; empty means
+	// adapter.ProjectionProportional.
+	Strategy string
+	// LookbackDays is how many days of actual spend shares are computed
+	// over; zero means 30.
+	LookbackDays int
+}
+
+// ResourceRequest names a resource to project the cost of.
+type ResourceRequest struct {
+	URN string
+	// ResourceID is the cloud identifier Vantage reports the resource's
+	// spend under: an ARN, Azure resource ID, GCP self-link, or provider ID.
+	ResourceID string
+}
+
+// ProjectedCostRequest lists the resources to project the cost of.
+type ProjectedCostRequest struct {
+	Resources []ResourceRequest
+}
+
+// ProjectedCost is one resource's share of the forecast.
+type ProjectedCost struct {
+	URN        string
+	ResourceID string
+	// Cost is the resource's share of the forecast for the period.
+	Cost float64
+	// Share is the fraction of lookback spend the resource incurred.
+	Share float64
+	// ActualCost is the spend the share was computed from.
+	ActualCost float64
+	// Matched reports whether any lookback spend was found for the
+	// resource; unmatched resources project zero.
+	Matched bool
+}
+
+// ProjectedCostResponse apportions the forecast for the next calendar month
+// to the requested resources.
+type ProjectedCostResponse struct {
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	Currency      string
+	Strategy      string
+	ForecastTotal float64
+	Resources     []ProjectedCost
+}
+
+// SetProjection changes how the running server apportions forecasts,
+// starting with the next request.
+func (s *Server) SetProjection(projection Projection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.Projection = projection
+}
+
+// projection returns the current projection settings with defaults filled
+// in.
+func (s *Server) projection() Projection {
+	s.mu.RLock()
+	projection := s.config.Projection
+	s.mu.RUnlock()
+	if projection.Strategy == "" {
+		projection.Strategy = adapter.ProjectionProportional
+	}
+	if projection.LookbackDays <= 0 {
+		projection.LookbackDays = defaultProjectionLookbackDays
+	}
+	return projection
+}
+
+// ProjectedCost apportions the cost report's forecast for the next calendar
+// month to the requested resources by their share of recent actual spend.
+// Spend Vantage reports without a resource ID counts toward the total, so
+// shares of the forecast are never overstated.
+func (s *Server) ProjectedCost(ctx context.Context, req ProjectedCostRequest) (ProjectedCostResponse, error) {
+	if s.config.CostReportToken == "" {
+		return ProjectedCostResponse{}, ErrNoCostReport
+	}
+	if err := req.validate(); err != nil {
+		return ProjectedCostResponse{}, err
+	}
+
+	projection := s.projection()
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	periodStart := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	resp := ProjectedCostResponse{
+		PeriodStart: periodStart,
+		PeriodEnd:   periodStart.AddDate(0, 1, 0),
+		Strategy:    projection.Strategy,
+	}
+
+	forecast, err := s.client.Forecast(ctx, s.config.CostReportToken, client.ForecastQuery{
+		StartAt:     resp.PeriodStart,
+		EndAt:       resp.PeriodEnd,
+		Granularity: "month",
+	})
+	if err != nil {
+		return ProjectedCostResponse{}, fmt.Errorf("fetching forecast: %w", err)
+	}
+	for _, row := range forecast.Data {
+		resp.ForecastTotal += row.Cost
+		if resp.Currency == "" {
+			resp.Currency = row.Currency
+		}
+	}
+
+	rows, err := client.NewPager(s.client, client.Query{
+		WorkspaceToken:  s.config.WorkspaceToken,
+		CostReportToken: s.config.CostReportToken,
+		StartAt:         today.AddDate(0, 0, -projection.LookbackDays),
+		EndAt:           today,
+		Granularity:     "day",
+		GroupBys:        []string{"resource_id"},
+		Metrics:         []string{"cost"},
+	}, s.logger).AllPages(ctx)
+	if err != nil {
+		return ProjectedCostResponse{}, fmt.Errorf("fetching actual costs: %w", err)
+	}
+
+	resp.Resources = apportion(req.Resources, projectionRows(rows, projection.Strategy), resp.ForecastTotal)
+	return resp, nil
+}
+
+// validate checks every resource names the ID its spend is reported under.
+func (req ProjectedCostRequest) validate() error {
+	for i, resource := range req.Resources {
+		if resource.ResourceID == "" {
+			return fmt.Errorf("resources[%d]: resource_id is required", i)
+		}
+	}
+	return nil
+}
+
+// projectionRows returns the rows a strategy computes shares from: all of
+// them, or for ProjectionLatest those of the latest day.
+func projectionRows(rows []client.CostRow, strategy string) []client.CostRow {
+	if strategy != adapter.ProjectionLatest {
+		return rows
+	}
+	var latest time.Time
+	for _, row := range rows {
+		if row.BucketStart.After(latest) {
+			latest = row.BucketStart
+		}
+	}
+	kept := make([]client.CostRow, 0, len(rows))
+	for _, row := range rows {
+		if row.BucketStart.Equal(latest) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// apportion splits total across resources by their share of the rows'
+// spend. A row is matched to a resource by its resource ID, as
+// stack.NormalizeID compares them, or by the name parsed from it, so an
+// instance requested by ID matches spend reported under its ARN.
+func apportion(resources []ResourceRequest, rows []client.CostRow, total float64) []ProjectedCost {
+	byID := make(map[string][]int, len(resources))
+	for i, resource := range resources {
+		key := stack.NormalizeID(resource.ResourceID)
+		byID[key] = append(byID[key], i)
+	}
+
+	actual := make([]float64, len(resources))
+	matched := make([]bool, len(resources))
+	var spend float64
+	for _, row := range rows {
+		spend += row.Cost
+		if row.ResourceID == "" {
+			continue
+		}
+		indexes, ok := byID[stack.NormalizeID(row.ResourceID)]
+		if !ok {
+			if ref, parsed := adapter.ParseResourceID(row.ResourceID); parsed {
+				indexes = byID[stack.NormalizeID(ref.Name)]
+			}
+		}
+		for _, i := range indexes {
+			actual[i] += row.Cost
+			matched[i] = true
+		}
+	}
+
+	projected := make([]ProjectedCost, len(resources))
+	for i, resource := range resources {
+		projected[i] = ProjectedCost{
+			URN:        resource.URN,
+			ResourceID: resource.ResourceID,
+			ActualCost: actual[i],
+			Matched:    matched[i],
+		}
+		if spend > 0 {
+			projected[i].Share = actual[i] / spend
+			projected[i].Cost = total * projected[i].Share
+		}
+	}
+	return projected
+}
+
+// getProjectedCost handles the GetProjectedCost RPC. The request is
+// {"resources": [{"urn": ..., "resource_id": ...}]}.
+func (s *Server) getProjectedCost(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req ProjectedCostRequest
+	for _, value := range in.GetFields()["resources"].GetListValue().GetValues() {
+		fields := value.GetStructValue().GetFields()
+		req.Resources = append(req.Resources, ResourceRequest{
+			URN:        fields["urn"].GetStringValue(),
+			ResourceID: fields["resource_id"].GetStringValue(),
+		})
+	}
+	if err := req.validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp, err := s.ProjectedCost(ctx, req)
+	switch {
+	case errors.Is(err, ErrNoCostReport):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	resources := make([]interface{}, 0, len(resp.Resources))
+	for _, r := range resp.Resources {
+		resources = append(resources, map[string]interface{}{
+			"urn":            r.URN,
+			"resource_id":    r.ResourceID,
+			"projected_cost": r.Cost,
+			"share":          r.Share,
+			"actual_cost":    r.ActualCost,
+			"matched":        r.Matched,
+		})
+	}
+	return structpb.NewStruct(map[string]interface{}{
+		"period_start":   resp.PeriodStart.Format(time.RFC3339),
+		"period_end":     resp.PeriodEnd.Format(time.RFC3339),
+		"currency":       resp.Currency,
+		"strategy":       resp.Strategy,
+		"forecast_total": resp.ForecastTotal,
+		"resources":      resources,
+	})
+}
+
+// costSourceServiceDesc describes the CostSource service. Like PluginInfo,
+// it exchanges well-known protobuf types so no generated code is required.
+//
+//nolint:gochecknoglobals // gRPC service descriptors are conventionally package-level.
+var costSourceServiceDesc = grpc.ServiceDesc{
+	ServiceName: CostSourceServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetProjectedCost",
+			Handler:    getProjectedCostHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pulumicost/vantage/v1/cost_source.proto",
+}
+
+// getProjectedCostHandler adapts getProjectedCost to the gRPC unary handler
+// signature.
+func getProjectedCostHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	s, ok := srv.(*Server)
+	if !ok {
+		return nil, errors.New("unexpected service implementation")
+	}
+	if interceptor == nil {
+		return s.getProjectedCost(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectedCostMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		request, _ := req.(*structpb.Struct)
+		return s.getProjectedCost(ctx, request)
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// costClient serves fixed cost rows and a fixed forecast.
+type costClient struct {
+	fakeClient
+
+	rows        []client.CostRow
+	forecast    []client.ForecastRow
+	forecastErr error
+	queries     []client.Query
+}
+
+func (c *costClient) Costs(_ context.Context, query client.Query) (client.Page, error) {
+	c.queries = append(c.queries, query)
+	return client.Page{Data: c.rows}, nil
+}
+
+func (c *costClient) Forecast(_ context.Context, _ string, _ client.ForecastQuery) (client.Forecast, error) {
+	return client.Forecast{Data: c.forecast}, c.forecastErr
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newCostClient() *costClient {
+	return &costClient{
+		rows: []client.CostRow{
+			{ResourceID: "arn:aws:ec2:us-east-1:123456789012:instance/i-0abc", Cost: 30, BucketStart: day(1)},
+			{ResourceID: "arn:aws:ec2:us-east-1:123456789012:instance/i-0abc", Cost: 10, BucketStart: day(2)},
+			{ResourceID: "shop-assets", Cost: 10, BucketStart: day(1)},
+			{ResourceID: "shop-assets", Cost: 30, BucketStart: day(2)},
+			// Spend without a resource ID still counts toward the total.
+			{Cost: 20, BucketStart: day(1)},
+		},
+		forecast: []client.ForecastRow{{Cost: 500, Currency: "USD"}, {Cost: 100, Currency: "USD"}},
+	}
+}
+
+func projectedRequest() ProjectedCostRequest {
+	return ProjectedCostRequest{Resources: []ResourceRequest{
+		{URN: "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web", ResourceID: "i-0abc"},
+		{URN: "urn:pulumi:dev::shop::aws:s3/bucket:Bucket::assets", ResourceID: "Shop-Assets"},
+		{URN: "urn:pulumi:dev::shop::aws:s3/bucket:Bucket::logs", ResourceID: "shop-logs"},
+	}}
+}
+
+func TestServer_ProjectedCost_Proportional(t *testing.T) {
+	c := newCostClient()
+	srv := NewServer(c, nil, Config{CostReportToken: "cr_1", WorkspaceToken: "wrkspc_1"})
+
+	resp, err := srv.ProjectedCost(context.Background(), projectedRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, adapter.ProjectionProportional, resp.Strategy)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.InDelta(t, 600, resp.ForecastTotal, 1e-9)
+	assert.Equal(t, 1, resp.PeriodStart.Day())
+	assert.True(t, resp.PeriodStart.After(time.Now()))
+	assert.Equal(t, resp.PeriodStart.AddDate(0, 1, 0), resp.PeriodEnd)
+
+	require.Len(t, resp.Resources, 3)
+	// The instance matches its ARN by name: 40 of 100 spent.
+	assert.True(t, resp.Resources[0].Matched)
+	assert.InDelta(t, 40, resp.Resources[0].ActualCost, 1e-9)
+	assert.InDelta(t, 0.4, resp.Resources[0].Share, 1e-9)
+	assert.InDelta(t, 240, resp.Resources[0].Cost, 1e-9)
+	assert.Equal(t, "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web", resp.Resources[0].URN)
+	assert.InDelta(t, 240, resp.Resources[1].Cost, 1e-9)
+	assert.False(t, resp.Resources[2].Matched)
+	assert.Zero(t, resp.Resources[2].Cost)
+
+	require.Len(t, c.queries, 1)
+	query := c.queries[0]
+	assert.Equal(t, "cr_1", query.CostReportToken)
+	assert.Equal(t, "wrkspc_1", query.WorkspaceToken)
+	assert.Equal(t, []string{"resource_id"}, query.GroupBys)
+	assert.Equal(t, 30*24*time.Hour, query.EndAt.Sub(query.StartAt))
+}
+
+func TestServer_ProjectedCost_Latest(t *testing.T) {
+	c := newCostClient()
+	srv := NewServer(c, nil, Config{CostReportToken: "cr_1"})
+	srv.SetProjection(Projection{Strategy: adapter.ProjectionLatest, LookbackDays: 7})
+
+	resp, err := srv.ProjectedCost(context.Background(), projectedRequest())
+	require.NoError(t, err)
+
+	// Only the latest day counts: the instance spent 10 of 40.
+	assert.Equal(t, adapter.ProjectionLatest, resp.Strategy)
+	assert.InDelta(t, 0.25, resp.Resources[0].Share, 1e-9)
+	assert.InDelta(t, 150, resp.Resources[0].Cost, 1e-9)
+	assert.InDelta(t, 450, resp.Resources[1].Cost, 1e-9)
+	assert.Equal(t, 7*24*time.Hour, c.queries[0].EndAt.Sub(c.queries[0].StartAt))
+}
+
+func TestServer_ProjectedCost_Errors(t *testing.T) {
+	_, err := NewServer(newCostClient(), nil, Config{}).ProjectedCost(context.Background(), projectedRequest())
+	require.ErrorIs(t, err, ErrNoCostReport)
+
+	srv := NewServer(newCostClient(), nil, Config{CostReportToken: "cr_1"})
+	_, err = srv.ProjectedCost(context.Background(), ProjectedCostRequest{Resources: []ResourceRequest{{URN: "urn"}}})
+	require.EqualError(t, err, "resources[0]: resource_id is required")
+
+	c := newCostClient()
+	c.forecastErr = errors.New("429 too many requests")
+	_, err = NewServer(c, nil, Config{CostReportToken: "cr_1"}).ProjectedCost(context.Background(), projectedRequest())
+	require.ErrorContains(t, err, "fetching forecast: 429 too many requests")
+}
+
+func TestServer_GetProjectedCost(t *testing.T) {
+	conn := startServerConfig(t, newCostClient(), Config{CostReportToken: "cr_1"})
+
+	in, err := structpb.NewStruct(map[string]interface{}{
+		"resources": []interface{}{
+			map[string]interface{}{"urn": "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web", "resource_id": "i-0abc"},
+		},
+	})
+	require.NoError(t, err)
+
+	var out structpb.Struct
+	require.NoError(t, conn.Invoke(context.Background(), ProjectedCostMethod, in, &out))
+
+	fields := out.AsMap()
+	assert.Equal(t, "USD", fields["currency"])
+	assert.Equal(t, "proportional", fields["strategy"])
+	assert.InDelta(t, 600, fields["forecast_total"], 1e-9)
+	resources, ok := fields["resources"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, resources, 1)
+	resource, ok := resources[0].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web", resource["urn"])
+	assert.InDelta(t, 240, resource["projected_cost"], 1e-9)
+	assert.Equal(t, true, resource["matched"])
+}
+
+func TestServer_GetProjectedCost_Codes(t *testing.T) {
+	invoke := func(conn *grpc.ClientConn, resources ...interface{}) error {
+		in, err := structpb.NewStruct(map[string]interface{}{"resources": resources})
+		require.NoError(t, err)
+		return conn.Invoke(context.Background(), ProjectedCostMethod, in, &structpb.Struct{})
+	}
+
+	err := invoke(startServerConfig(t, newCostClient(), Config{}))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	conn := startServerConfig(t, newCostClient(), Config{CostReportToken: "cr_1"})
+	err = invoke(conn, map[string]interface{}{"urn": "urn"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	c := newCostClient()
+	c.forecastErr = errors.New("503 service unavailable")
+	err = invoke(startServerConfig(t, c, Config{CostReportToken: "cr_1"}))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,287 @@
+// Package plugin provides the gRPC serve mode used when pulumicost-core runs
+// the Vantage adapter as a plugin process.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+const (
+	// Name is the plugin name reported in metadata.
+	Name = "vantage"
+
+	// MetadataServiceName is the fully-qualified gRPC service name of the metadata service.
+	MetadataServiceName = "pulumicost.vantage.v1.PluginInfo"
+
+	// MetadataMethod is the full gRPC method name of the metadata RPC.
+	MetadataMethod = "/" + MetadataServiceName + "/GetMetadata"
+
+	defaultProbeInterval = 30 * time.Second
+	defaultProbeTimeout  = 10 * time.Second
+)
+
+// Metadata describes the plugin and its current readiness.
+type Metadata struct {
+	Name              string    `json:"name"`
+	Version           string    `json:"version"`
+	SupportedGroupBys []string  `json:"supported_group_bys"`
+	SupportedMetrics  []string  `json:"supported_metrics"`
+	APIReachable      bool      `json:"api_reachable"`
+	APIError          string    `json:"api_error,omitempty"`
+	CheckedAt         time.Time `json:"checked_at"`
+}
+
+// Config holds plugin server configuration.
+type Config struct {
+	// Version is the plugin version reported in metadata.
+	Version string
+	// ProbeInterval controls how often Vantage API reachability is re-checked.
+	ProbeInterval time.Duration
+	// ProbeTimeout bounds a single reachability probe.
+	ProbeTimeout time.Duration
+	// CostReportToken and WorkspaceToken scope the forecast and actual
+	// spend GetProjectedCost apportions.
+	CostReportToken string
+	WorkspaceToken  string
+	// Projection configures how GetProjectedCost apportions the forecast.
+	Projection Projection
+}
+
+// Server exposes gRPC health checks, plugin metadata, and projected costs.
+type Server struct {
+	client client.Client
+	logger client.Logger
+	config Config
+	health *health.Server
+	grpc   *grpc.Server
+
+	mu       sync.RWMutex
+	metadata Metadata
+
+	// intervals carries probe interval changes to the probe loop.
+	intervals chan time.Duration
+}
+
+// NewServer creates a new plugin server backed by the given Vantage client.
+func NewServer(c client.Client, logger client.Logger, config Config) *Server {
+	if logger == nil {
+		logger = client.NewNoopLogger()
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = defaultProbeInterval
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = defaultProbeTimeout
+	}
+
+	s := &Server{
+		client: c,
+		logger: logger,
+		config: config,
+		health: health.NewServer(),
+		grpc:   grpc.NewServer(),
+		metadata: Metadata{
+			Name:              Name,
+			Version:           config.Version,
+			SupportedGroupBys: adapter.SupportedGroupBys(),
+			SupportedMetrics:  adapter.SupportedMetrics(),
+		},
+		intervals: make(chan time.Duration, 1),
+	}
+
+	// Report NOT_SERVING until the first probe succeeds.
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	s.health.SetServingStatus(MetadataServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	s.health.SetServingStatus(CostSourceServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	s.grpc.RegisterService(&metadataServiceDesc, s)
+	s.grpc.RegisterService(&costSourceServiceDesc, s)
+
+	return s
+}
+
+// Serve probes the Vantage API and serves gRPC requests on lis until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, lis net.Listener) error {
+	s.Probe(ctx)
+
+	probeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.probeLoop(probeCtx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.grpc.Serve(lis)
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.health.Shutdown()
+		s.grpc.GracefulStop()
+		return nil
+	case err := <-errCh:
+		if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			return fmt.Errorf("serving gRPC: %w", err)
+		}
+		return nil
+	}
+}
+
+// Probe checks Vantage API reachability and updates health status and metadata.
+func (s *Server) Probe(ctx context.Context) Metadata {
+	probeCtx, cancel := context.WithTimeout(ctx, s.config.ProbeTimeout)
+	defer cancel()
+
+	err := s.client.Ping(probeCtx)
+
+	s.mu.Lock()
+	s.metadata.APIReachable = err == nil
+	s.metadata.APIError = ""
+	if err != nil {
+		s.metadata.APIError = err.Error()
+	}
+	s.metadata.CheckedAt = time.Now().UTC()
+	md := s.metadata
+	s.mu.Unlock()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+		s.logger.Warn(ctx, "Vantage API unreachable", map[string]interface{}{
+			"adapter":   "vantage",
+			"operation": "plugin_probe",
+			"attempt":   0,
+			"error":     err.Error(),
+		})
+	}
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(MetadataServiceName, status)
+	s.health.SetServingStatus(CostSourceServiceName, status)
+
+	return md
+}
+
+// Metadata returns the metadata captured by the most recent probe.
+func (s *Server) Metadata() Metadata {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.metadata
+}
+
+// SetProbeInterval changes how often the running server re-probes the
+// Vantage API, starting from the next tick. Non-positive intervals are
+// ignored.
+func (s *Server) SetProbeInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	// Replace a change the probe loop has not picked up yet.
+	select {
+	case <-s.intervals:
+	default:
+	}
+	s.intervals <- interval
+}
+
+// probeLoop periodically re-probes the Vantage API until ctx is cancelled.
+func (s *Server) probeLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case interval := <-s.intervals:
+			ticker.Reset(interval)
+		case <-ticker.C:
+			s.Probe(ctx)
+		}
+	}
+}
+
+// getMetadata handles the GetMetadata RPC.
+func (s *Server) getMetadata(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	md := s.Probe(ctx)
+
+	groupBys := make([]interface{}, 0, len(md.SupportedGroupBys))
+	for _, gb := range md.SupportedGroupBys {
+		groupBys = append(groupBys, gb)
+	}
+	metrics := make([]interface{}, 0, len(md.SupportedMetrics))
+	for _, m := range md.SupportedMetrics {
+		metrics = append(metrics, m)
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"name":                md.Name,
+		"version":             md.Version,
+		"supported_group_bys": groupBys,
+		"supported_metrics":   metrics,
+		"api_reachable":       md.APIReachable,
+		"api_error":           md.APIError,
+		"checked_at":          md.CheckedAt.Format(time.RFC3339),
+	})
+}
+
+// metadataServiceDesc describes the PluginInfo service. Messages use
+// well-known protobuf types so no generated code is required.
+//
+//nolint:gochecknoglobals // gRPC service descriptors are conventionally package-level.
+var metadataServiceDesc = grpc.ServiceDesc{
+	ServiceName: MetadataServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetMetadata",
+			Handler:    getMetadataHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pulumicost/vantage/v1/plugin_info.proto",
+}
+
+// getMetadataHandler adapts getMetadata to the gRPC unary handler signature.
+func getMetadataHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	s, ok := srv.(*Server)
+	if !ok {
+		return nil, errors.New("unexpected service implementation")
+	}
+	if interceptor == nil {
+		return s.getMetadata(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		empty, _ := req.(*emptypb.Empty)
+		return s.getMetadata(ctx, empty)
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// fakeClient implements client.Client with a configurable Ping result.
+type fakeClient struct {
+	client.Client
+
+	pingErr error
+	pings   atomic.Int32
+}
+
+func (f *fakeClient) Ping(_ context.Context) error {
+	f.pings.Add(1)
+	return f.pingErr
+}
+
+func startServer(t *testing.T, c client.Client) *grpc.ClientConn {
+	t.Helper()
+	return startServerConfig(t, c, Config{})
+}
+
+// startServerConfig serves config, with a version and an hourly probe
+// interval, over an in-memory listener.
+func startServerConfig(t *testing.T, c client.Client, config Config) *grpc.ClientConn {
+	t.Helper()
+
+	config.Version = "v1.2.3"
+	config.ProbeInterval = time.Hour
+	lis := bufconn.Listen(1024 * 1024)
+	srv := NewServer(c, client.NewNoopLogger(), config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Serve(ctx, lis)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func TestServer_HealthServing(t *testing.T) {
+	conn := startServer(t, &fakeClient{})
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func TestServer_HealthNotServingWhenAPIUnreachable(t *testing.T) {
+	conn := startServer(t, &fakeClient{pingErr: errors.New("connection refused")})
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: MetadataServiceName,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestServer_GetMetadata(t *testing.T) {
+	conn := startServer(t, &fakeClient{})
+
+	var out structpb.Struct
+	err := conn.Invoke(context.Background(), MetadataMethod, &emptypb.Empty{}, &out)
+	require.NoError(t, err)
+
+	fields := out.AsMap()
+	assert.Equal(t, "vantage", fields["name"])
+	assert.Equal(t, "v1.2.3", fields["version"])
+	assert.Equal(t, true, fields["api_reachable"])
+	assert.Contains(t, fields["supported_group_bys"], "provider")
+	assert.Contains(t, fields["supported_metrics"], "cost")
+}
+
+func TestServer_Probe(t *testing.T) {
+	srv := NewServer(&fakeClient{pingErr: errors.New("401 unauthorized")}, nil, Config{})
+
+	md := srv.Probe(context.Background())
+	assert.False(t, md.APIReachable)
+	assert.Equal(t, "401 unauthorized", md.APIError)
+	assert.False(t, md.CheckedAt.IsZero())
+	assert.Equal(t, md, srv.Metadata())
+}
+
+func TestServer_SetProbeInterval(t *testing.T) {
+	fake := &fakeClient{}
+	srv := NewServer(fake, nil, Config{ProbeInterval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Serve(ctx, bufconn.Listen(1024))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	srv.SetProbeInterval(10 * time.Millisecond)
+	assert.Eventually(t, func() bool { return fake.pings.Load() >= 3 }, 5*time.Second, 10*time.Millisecond)
+}
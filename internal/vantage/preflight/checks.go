@@ -0,0 +1,156 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// SinkChecker is implemented by sinks that can verify they are reachable and
+// writable without persisting records.
+type SinkChecker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckConfig validates cfg.
+func CheckConfig(cfg *adapter.Config) Result {
+	if err := adapter.ValidateConfig(cfg); err != nil {
+		return Result{
+			Name:        "config",
+			Status:      StatusFail,
+			Message:     err.Error(),
+			Remediation: "Fix the config file; see docs/CONFIG.md for every parameter",
+		}
+	}
+	return Result{Name: "config", Status: StatusPass, Message: "configuration is valid"}
+}
+
+// CheckAPI performs a cheap authenticated request against the Vantage API.
+func CheckAPI(ctx context.Context, c client.Client) Result {
+	if err := c.Ping(ctx); err != nil {
+		return Result{
+			Name:        "api",
+			Status:      StatusFail,
+			Message:     err.Error(),
+			Remediation: apiRemediation(err),
+		}
+	}
+	return Result{Name: "api", Status: StatusPass, Message: "authenticated request succeeded"}
+}
+
+// apiRemediation suggests a fix based on the failure's HTTP status.
+func apiRemediation(err error) string {
+	msg := err.Error()
+	switch {
+	case client.IsAuthError(err):
+		return "Check credentials.token or PULUMICOST_VANTAGE_TOKEN; the token is invalid or lacks API access"
+	case strings.Contains(msg, "status 429"):
+		return "The token is being rate limited; lower params.requests_per_second or retry later"
+	default:
+		return "Check network access to api.vantage.sh and any proxy settings"
+	}
+}
+
+// CheckTokens verifies that the configured cost report and workspace exist
+// and are visible to the API token. A cost_report_name must match exactly
+// one report title.
+func CheckTokens(ctx context.Context, c client.Client, cfg *adapter.Config) []Result {
+	var results []Result
+
+	if cfg.CostReportToken == "" && cfg.CostReportName != "" {
+		token, err := adapter.ResolveCostReportName(ctx, c, cfg.WorkspaceToken, cfg.CostReportName)
+		if err != nil {
+			results = append(results, Result{
+				Name:        "cost_report_name",
+				Status:      StatusFail,
+				Message:     err.Error(),
+				Remediation: "Match the report title shown in the Vantage console, or set params.cost_report_token",
+			})
+		} else {
+			results = append(results, Result{
+				Name:    "cost_report_name",
+				Status:  StatusPass,
+				Message: fmt.Sprintf("resolved %q to %s", cfg.CostReportName, token),
+			})
+		}
+	}
+
+	if cfg.CostReportToken != "" {
+		report, err := c.GetCostReport(ctx, cfg.CostReportToken)
+		if err != nil {
+			results = append(results, Result{
+				Name:        "cost_report_token",
+				Status:      StatusFail,
+				Message:     err.Error(),
+				Remediation: "Copy the report token from the Vantage console URL or check the token's workspace access",
+			})
+		} else {
+			results = append(results, Result{
+				Name:    "cost_report_token",
+				Status:  StatusPass,
+				Message: fmt.Sprintf("found report %q", report.Title),
+			})
+		}
+	}
+
+	if cfg.WorkspaceToken != "" {
+		results = append(results, checkWorkspace(ctx, c, cfg.WorkspaceToken))
+	}
+
+	return results
+}
+
+// checkWorkspace looks the workspace token up in the workspace list.
+func checkWorkspace(ctx context.Context, c client.Client, token string) Result {
+	workspaces, err := c.ListWorkspaces(ctx)
+	if err != nil {
+		return Result{
+			Name:        "workspace_token",
+			Status:      StatusFail,
+			Message:     err.Error(),
+			Remediation: "Check that the API token can list workspaces",
+		}
+	}
+
+	for _, ws := range workspaces {
+		if ws.Token == token {
+			return Result{
+				Name:    "workspace_token",
+				Status:  StatusPass,
+				Message: fmt.Sprintf("found workspace %q", ws.Name),
+			}
+		}
+	}
+
+	return Result{
+		Name:        "workspace_token",
+		Status:      StatusFail,
+		Message:     fmt.Sprintf("workspace %s is not visible to this API token", token),
+		Remediation: "Run `pulumicost-vantage workspaces` to list valid workspace tokens",
+	}
+}
+
+// CheckSink verifies the sink is writable when it supports checking.
+func CheckSink(ctx context.Context, s adapter.Sink) Result {
+	checker, ok := s.(SinkChecker)
+	if !ok {
+		return Result{
+			Name:    "sink",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("%T does not support connectivity checks", s),
+		}
+	}
+
+	if err := checker.Check(ctx); err != nil {
+		return Result{
+			Name:        "sink",
+			Status:      StatusFail,
+			Message:     err.Error(),
+			Remediation: "Check the sink's path or dataset exists and is writable by the user running the sync",
+		}
+	}
+	return Result{Name: "sink", Status: StatusPass, Message: "sink is writable"}
+}
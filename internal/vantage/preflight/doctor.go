@@ -0,0 +1,226 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+const (
+	// DefaultMaxClockSkew is the largest local/server clock difference that
+	// passes; larger skews shift the D-3..D-1 window across day boundaries.
+	DefaultMaxClockSkew = time.Minute
+	// DefaultMaxBookmarkAge is how old the newest bookmark may be before
+	// scheduled pulls are assumed to have stopped.
+	DefaultMaxBookmarkAge = 72 * time.Hour
+	// DefaultQualityRuns is how many recent syncs the quality trend shows,
+	// and DefaultMinQualityScore the latest score below which it warns.
+	DefaultQualityRuns     = 10
+	DefaultMinQualityScore = 80.0
+)
+
+// BookmarkLister is implemented by bookmark stores that can enumerate their
+// bookmarks.
+type BookmarkLister interface {
+	Bookmarks(ctx context.Context) (map[string]string, error)
+}
+
+// scopeProbe is one read permission the adapter relies on.
+type scopeProbe struct {
+	name  string
+	probe func(ctx context.Context) error
+}
+
+// CheckScopes probes each API endpoint the configured sync depends on. A
+// 401/403 fails the check; other errors only warn since they are usually
+// transient.
+func CheckScopes(ctx context.Context, c client.Client, cfg *adapter.Config) []Result {
+	probes := []scopeProbe{
+		{name: "workspaces", probe: func(ctx context.Context) error {
+			_, err := c.ListWorkspaces(ctx)
+			return err
+		}},
+		{name: "cost_reports", probe: func(ctx context.Context) error {
+			_, err := c.ListCostReports(ctx, cfg.WorkspaceToken)
+			return err
+		}},
+	}
+	if cfg.IncludeBudgets {
+		probes = append(probes, scopeProbe{name: "budgets", probe: func(ctx context.Context) error {
+			_, err := c.Budgets(ctx, cfg.WorkspaceToken)
+			return err
+		}})
+	}
+	if cfg.DiscoverTags {
+		probes = append(probes, scopeProbe{name: "tags", probe: func(ctx context.Context) error {
+			_, err := c.ListTags(ctx, cfg.WorkspaceToken)
+			return err
+		}})
+	}
+
+	results := make([]Result, 0, len(probes))
+	for _, p := range probes {
+		name := "scope " + p.name
+		err := p.probe(ctx)
+		switch {
+		case err == nil:
+			results = append(results, Result{Name: name, Status: StatusPass, Message: "read access granted"})
+		case client.IsAuthError(err):
+			results = append(results, Result{
+				Name:        name,
+				Status:      StatusFail,
+				Message:     err.Error(),
+				Remediation: fmt.Sprintf("Grant the API token read access to %s in the Vantage console", p.name),
+			})
+		default:
+			results = append(results, Result{Name: name, Status: StatusWarn, Message: err.Error()})
+		}
+	}
+	return results
+}
+
+// CheckRateLimit reports the remaining request quota seen on the last
+// response. It must run after at least one API call.
+func CheckRateLimit(status client.ServerStatus, threshold int) Result {
+	if status.RateLimitRemaining < 0 {
+		return Result{
+			Name:    "rate limit headroom",
+			Status:  StatusWarn,
+			Message: "no X-RateLimit-Remaining header seen",
+		}
+	}
+
+	quota := strconv.Itoa(status.RateLimitRemaining)
+	if status.RateLimitLimit > 0 {
+		quota = fmt.Sprintf("%d/%d", status.RateLimitRemaining, status.RateLimitLimit)
+	}
+
+	if status.RateLimitRemaining < threshold {
+		return Result{
+			Name:        "rate limit headroom",
+			Status:      StatusWarn,
+			Message:     quota + " requests remaining in the current window",
+			Remediation: "Another job may be sharing this token; stagger schedules or lower params.requests_per_second",
+		}
+	}
+	return Result{
+		Name:    "rate limit headroom",
+		Status:  StatusPass,
+		Message: quota + " requests remaining",
+	}
+}
+
+// CheckClockSkew compares the local clock with the server's Date header.
+func CheckClockSkew(status client.ServerStatus, maxSkew time.Duration) Result {
+	if status.ServerTime.IsZero() {
+		return Result{Name: "clock skew", Status: StatusWarn, Message: "server did not send a Date header"}
+	}
+
+	skew := status.ClockSkew()
+	if skew.Abs() > maxSkew {
+		return Result{
+			Name:        "clock skew",
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("local clock differs from Vantage by %s", skew.Truncate(time.Second)),
+			Remediation: "Enable NTP on the host; sync windows are computed from the local clock",
+		}
+	}
+	return Result{
+		Name:    "clock skew",
+		Status:  StatusPass,
+		Message: "local clock within " + maxSkew.String() + " of Vantage (" + skew.Truncate(time.Second).String() + ")",
+	}
+}
+
+// CheckBookmarks reports the newest sync bookmark in the bookmark store.
+func CheckBookmarks(ctx context.Context, store adapter.BookmarkStore, maxAge time.Duration, now time.Time) Result {
+	lister, ok := store.(BookmarkLister)
+	if !ok {
+		return Result{
+			Name:    "bookmarks",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("%T does not support listing bookmarks", store),
+		}
+	}
+
+	bookmarks, err := lister.Bookmarks(ctx)
+	if err != nil {
+		return Result{
+			Name:        "bookmarks",
+			Status:      StatusFail,
+			Message:     err.Error(),
+			Remediation: "The bookmark store is unreadable; incremental pulls will not resume correctly",
+		}
+	}
+
+	var newest time.Time
+	for _, value := range bookmarks {
+		if parsed, parseErr := time.Parse(time.RFC3339, value); parseErr == nil && parsed.After(newest) {
+			newest = parsed
+		}
+	}
+
+	if newest.IsZero() {
+		return Result{
+			Name:    "bookmarks",
+			Status:  StatusWarn,
+			Message: "no bookmarks found; the next pull starts from the default window",
+		}
+	}
+
+	lag := now.Sub(newest)
+	msg := fmt.Sprintf("%d bookmarks, newest %s", len(bookmarks), newest.UTC().Format(time.RFC3339))
+	if maxAge > 0 && lag > maxAge {
+		return Result{
+			Name:        "bookmarks",
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("%s (%s behind)", msg, lag.Truncate(time.Hour)),
+			Remediation: "Scheduled pulls may be failing; check the scheduler logs for recent runs",
+		}
+	}
+	return Result{Name: "bookmarks", Status: StatusPass, Message: msg}
+}
+
+// CheckQualityTrend reports the data quality scores of the last runs syncs
+// of the configured report, oldest first, warning when the latest is below
+// minScore.
+func CheckQualityTrend(ctx context.Context, store adapter.BookmarkStore, cfg *adapter.Config, runs int, minScore float64) Result {
+	history, err := adapter.ReadQualityHistory(ctx, store, adapter.QualityHistoryKey(*cfg))
+	if err != nil {
+		return Result{Name: "data quality", Status: StatusWarn, Message: err.Error()}
+	}
+	if len(history) == 0 {
+		return Result{
+			Name:    "data quality",
+			Status:  StatusWarn,
+			Message: "no quality history yet; it is recorded after each successful sync",
+		}
+	}
+	if runs > 0 && len(history) > runs {
+		history = history[len(history)-runs:]
+	}
+
+	scores := make([]string, len(history))
+	var total float64
+	for i, run := range history {
+		scores[i] = strconv.FormatFloat(run.Score, 'f', 1, 64)
+		total += run.Score
+	}
+	latest := history[len(history)-1]
+	msg := fmt.Sprintf("last %d syncs: %s (average %.1f)", len(history), strings.Join(scores, ", "), total/float64(len(history)))
+
+	if latest.Score < minScore {
+		return Result{
+			Name:        "data quality",
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("latest score %.1f is below %.1f; %s", latest.Score, minScore, msg),
+			Remediation: "Check the sync diagnostics for missing fields and warnings, or set diagnostics.report_path for a breakdown",
+		}
+	}
+	return Result{Name: "data quality", Status: StatusPass, Message: msg}
+}
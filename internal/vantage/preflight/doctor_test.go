@@ -0,0 +1,131 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// scopeClient returns per-endpoint errors for CheckScopes.
+type scopeClient struct {
+	client.Client
+
+	workspacesErr  error
+	costReportsErr error
+	budgetsErr     error
+}
+
+func (s *scopeClient) ListWorkspaces(_ context.Context) ([]client.Workspace, error) {
+	return nil, s.workspacesErr
+}
+
+func (s *scopeClient) ListCostReports(_ context.Context, _ string) ([]client.CostReport, error) {
+	return nil, s.costReportsErr
+}
+
+func (s *scopeClient) Budgets(_ context.Context, _ string) ([]client.Budget, error) {
+	return nil, s.budgetsErr
+}
+
+func TestCheckScopes(t *testing.T) {
+	c := &scopeClient{
+		costReportsErr: &client.APIError{StatusCode: 403, Body: "forbidden"},
+		budgetsErr:     &client.APIError{StatusCode: 502, Body: "bad gateway"},
+	}
+
+	results := CheckScopes(context.Background(), c, &adapter.Config{IncludeBudgets: true})
+	require.Len(t, results, 3)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Equal(t, "scope cost_reports", results[1].Name)
+	assert.Equal(t, StatusFail, results[1].Status)
+	assert.Contains(t, results[1].Remediation, "cost_reports")
+	assert.Equal(t, StatusWarn, results[2].Status)
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	assert.Equal(t, StatusWarn, CheckRateLimit(client.ServerStatus{RateLimitRemaining: -1}, 5).Status)
+	assert.Equal(t, StatusWarn, CheckRateLimit(client.ServerStatus{RateLimitRemaining: 2, RateLimitLimit: 50}, 5).Status)
+
+	res := CheckRateLimit(client.ServerStatus{RateLimitRemaining: 40, RateLimitLimit: 50}, 5)
+	assert.Equal(t, StatusPass, res.Status)
+	assert.Contains(t, res.Message, "40/50")
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	server := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, StatusWarn, CheckClockSkew(client.ServerStatus{}, time.Minute).Status)
+	assert.Equal(t, StatusPass, CheckClockSkew(client.ServerStatus{
+		ServerTime: server, ObservedAt: server.Add(5 * time.Second),
+	}, time.Minute).Status)
+	assert.Equal(t, StatusWarn, CheckClockSkew(client.ServerStatus{
+		ServerTime: server, ObservedAt: server.Add(-5 * time.Minute),
+	}, time.Minute).Status)
+}
+
+// bookmarkSink is a BookmarkStore that also implements BookmarkLister.
+type bookmarkSink struct {
+	adapter.BookmarkStore
+
+	bookmarks map[string]string
+	err       error
+}
+
+func (b *bookmarkSink) Bookmarks(_ context.Context) (map[string]string, error) {
+	return b.bookmarks, b.err
+}
+
+func TestCheckBookmarks(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, StatusWarn, CheckBookmarks(ctx, &bookmarkSink{}, DefaultMaxBookmarkAge, now).Status)
+	assert.Equal(t, StatusFail, CheckBookmarks(ctx, &bookmarkSink{err: errors.New("corrupt")}, 0, now).Status)
+
+	fresh := &bookmarkSink{bookmarks: map[string]string{
+		"vantage_a": "2024-03-01T00:00:00Z",
+		"vantage_b": "2024-03-09T00:00:00Z",
+	}}
+	res := CheckBookmarks(ctx, fresh, DefaultMaxBookmarkAge, now)
+	assert.Equal(t, StatusPass, res.Status)
+	assert.Contains(t, res.Message, "2024-03-09")
+
+	res = CheckBookmarks(ctx, fresh, DefaultMaxBookmarkAge, now.Add(7*24*time.Hour))
+	assert.Equal(t, StatusWarn, res.Status)
+	assert.NotEmpty(t, res.Remediation)
+}
+
+// historyStore serves one bookmark value for CheckQualityTrend.
+type historyStore struct {
+	adapter.BookmarkStore
+
+	value string
+}
+
+func (h *historyStore) GetBookmark(_ context.Context, _ string) (string, error) {
+	return h.value, nil
+}
+
+func TestCheckQualityTrend(t *testing.T) {
+	ctx := context.Background()
+	cfg := &adapter.Config{CostReportToken: "cr_test", Granularity: "day"}
+
+	assert.Equal(t, StatusWarn, CheckQualityTrend(ctx, &historyStore{}, cfg, DefaultQualityRuns, DefaultMinQualityScore).Status)
+
+	history := &historyStore{value: `[{"score":50},{"score":97.5},{"score":92},{"score":88.25}]`}
+	res := CheckQualityTrend(ctx, history, cfg, 3, DefaultMinQualityScore)
+	assert.Equal(t, StatusPass, res.Status)
+	assert.Equal(t, "last 3 syncs: 97.5, 92.0, 88.2 (average 92.6)", res.Message)
+
+	res = CheckQualityTrend(ctx, history, cfg, 3, 90)
+	assert.Equal(t, StatusWarn, res.Status)
+	assert.Contains(t, res.Message, "latest score 88.2 is below 90.0")
+	require.NotEmpty(t, res.Remediation)
+}
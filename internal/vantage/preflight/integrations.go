@@ -0,0 +1,101 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// DefaultMaxDataAge is how stale an integration's last sync may be before it
+// is reported as a warning.
+const DefaultMaxDataAge = 48 * time.Hour
+
+// isHealthyIntegrationStatus reports whether a Vantage status means active.
+func isHealthyIntegrationStatus(status string) bool {
+	switch strings.ToLower(status) {
+	case "connected", "active":
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckIntegrations verifies that every provider integration connected to
+// Vantage is active and has received data within maxAge of now.
+func CheckIntegrations(
+	ctx context.Context,
+	c client.Client,
+	workspaceToken string,
+	maxAge time.Duration,
+	now time.Time,
+) []Result {
+	integrations, err := c.ListIntegrations(ctx, workspaceToken)
+	if err != nil {
+		return []Result{{
+			Name:        "integrations",
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("listing integrations: %v", err),
+			Remediation: "Check that the API token has read access to integrations",
+		}}
+	}
+
+	if len(integrations) == 0 {
+		return []Result{{
+			Name:        "integrations",
+			Status:      StatusFail,
+			Message:     "no provider integrations are connected",
+			Remediation: "Connect an AWS, GCP, Azure, or other provider account in the Vantage console",
+		}}
+	}
+
+	results := make([]Result, 0, len(integrations))
+	for _, integration := range integrations {
+		results = append(results, checkIntegration(integration, maxAge, now))
+	}
+	return results
+}
+
+// checkIntegration evaluates one integration's status and data freshness.
+func checkIntegration(integration client.Integration, maxAge time.Duration, now time.Time) Result {
+	name := "integration " + integration.Provider
+	if integration.AccountIdentifier != "" {
+		name += " (" + integration.AccountIdentifier + ")"
+	}
+
+	if !isHealthyIntegrationStatus(integration.Status) {
+		return Result{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("status is %q", integration.Status),
+			Remediation: "Reconnect the integration in the Vantage console; rows from this account will be missing",
+		}
+	}
+
+	if integration.LastSyncedAt.IsZero() {
+		return Result{
+			Name:    name,
+			Status:  StatusWarn,
+			Message: "active, but Vantage has not reported a data sync yet",
+		}
+	}
+
+	age := now.Sub(integration.LastSyncedAt)
+	if maxAge > 0 && age > maxAge {
+		return Result{
+			Name:   name,
+			Status: StatusWarn,
+			Message: fmt.Sprintf("active, but data is %s old (last sync %s)",
+				age.Truncate(time.Minute), integration.LastSyncedAt.UTC().Format(time.RFC3339)),
+			Remediation: "Vantage has not imported recent billing data for this account; recent days may be incomplete",
+		}
+	}
+
+	return Result{
+		Name:    name,
+		Status:  StatusPass,
+		Message: "active, last sync " + integration.LastSyncedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,91 @@
+// Package preflight provides connectivity and configuration checks used by the
+// doctor and validate commands.
+package preflight
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	// StatusPass means the check succeeded.
+	StatusPass Status = "PASS"
+	// StatusWarn means the check found something that may cause problems.
+	StatusWarn Status = "WARN"
+	// StatusFail means the check found a problem that will break syncs.
+	StatusFail Status = "FAIL"
+)
+
+const tabPadding = 2
+
+// Result is the outcome of one check.
+type Result struct {
+	Name        string `json:"name"`
+	Status      Status `json:"status"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Report collects check results in the order they ran.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Add appends results to the report.
+func (r *Report) Add(results ...Result) {
+	r.Results = append(r.Results, results...)
+}
+
+// Failed reports whether any check failed.
+func (r *Report) Failed() bool {
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the report as a table followed by remediation hints for
+// checks that did not pass.
+func (r *Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, tabPadding, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAILS")
+	for _, res := range r.Results {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", res.Name, res.Status, res.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	first := true
+	for _, res := range r.Results {
+		if res.Status == StatusPass || res.Remediation == "" {
+			continue
+		}
+		if first {
+			_, _ = fmt.Fprintln(w, "\nRemediation:")
+			first = false
+		}
+		_, _ = fmt.Fprintf(w, "  - %s: %s\n", res.Name, res.Remediation)
+	}
+	return nil
+}
+
+// PrintJSON writes the report as indented JSON with an overall "passed" flag.
+func (r *Report) PrintJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Passed  bool     `json:"passed"`
+		Results []Result `json:"results"`
+	}{
+		Passed:  !r.Failed(),
+		Results: r.Results,
+	})
+}
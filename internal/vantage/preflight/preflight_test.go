@@ -0,0 +1,185 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/client"
+)
+
+// fakeClient implements client.Client with canned responses for the methods
+// the checks call.
+type fakeClient struct {
+	client.Client
+
+	pingErr         error
+	report          client.CostReport
+	reportErr       error
+	reports         []client.CostReport
+	workspaces      []client.Workspace
+	integrations    []client.Integration
+	integrationsErr error
+}
+
+func (f *fakeClient) Ping(_ context.Context) error {
+	return f.pingErr
+}
+
+func (f *fakeClient) GetCostReport(_ context.Context, _ string) (client.CostReport, error) {
+	return f.report, f.reportErr
+}
+
+func (f *fakeClient) ListCostReports(_ context.Context, _ string) ([]client.CostReport, error) {
+	return f.reports, nil
+}
+
+func (f *fakeClient) ListWorkspaces(_ context.Context) ([]client.Workspace, error) {
+	return f.workspaces, nil
+}
+
+func (f *fakeClient) ListIntegrations(_ context.Context, _ string) ([]client.Integration, error) {
+	return f.integrations, f.integrationsErr
+}
+
+func TestReport_PrintAndFailed(t *testing.T) {
+	var report Report
+	report.Add(
+		Result{Name: "api", Status: StatusPass, Message: "reachable"},
+		Result{Name: "sink", Status: StatusFail, Message: "not writable", Remediation: "fix permissions"},
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Print(&buf))
+
+	out := buf.String()
+	assert.Contains(t, out, "CHECK")
+	assert.Contains(t, out, "api")
+	assert.Contains(t, out, "FAIL")
+	assert.Contains(t, out, "Remediation:")
+	assert.Contains(t, out, "sink: fix permissions")
+	assert.True(t, report.Failed())
+}
+
+func TestCheckIntegrations(t *testing.T) {
+	now := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	c := &fakeClient{integrations: []client.Integration{
+		{Provider: "aws", AccountIdentifier: "123", Status: "connected", LastSyncedAt: now.Add(-time.Hour)},
+		{Provider: "gcp", Status: "Active", LastSyncedAt: now.Add(-72 * time.Hour)},
+		{Provider: "azure", Status: "error"},
+		{Provider: "datadog", Status: "connected"},
+	}}
+
+	results := CheckIntegrations(context.Background(), c, "", DefaultMaxDataAge, now)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, "integration aws (123)", results[0].Name)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Equal(t, StatusWarn, results[1].Status)
+	assert.Contains(t, results[1].Message, "72h0m0s")
+	assert.Equal(t, StatusFail, results[2].Status)
+	assert.NotEmpty(t, results[2].Remediation)
+	assert.Equal(t, StatusWarn, results[3].Status)
+}
+
+func TestCheckIntegrations_Errors(t *testing.T) {
+	now := time.Now()
+
+	results := CheckIntegrations(context.Background(), &fakeClient{integrationsErr: errors.New("401")}, "", 0, now)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusFail, results[0].Status)
+
+	results = CheckIntegrations(context.Background(), &fakeClient{}, "", 0, now)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusFail, results[0].Status)
+	assert.Contains(t, results[0].Message, "no provider integrations")
+}
+
+func TestCheckConfig(t *testing.T) {
+	assert.Equal(t, StatusFail, CheckConfig(&adapter.Config{}).Status)
+
+	cfg := &adapter.Config{
+		Token:           "token",
+		CostReportToken: "rprt_1",
+		Granularity:     "day",
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		PageSize:        100,
+		Timeout:         time.Minute,
+	}
+	assert.Equal(t, StatusPass, CheckConfig(cfg).Status)
+}
+
+func TestCheckAPI(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Equal(t, StatusPass, CheckAPI(ctx, &fakeClient{}).Status)
+
+	res := CheckAPI(ctx, &fakeClient{pingErr: &client.APIError{StatusCode: 401, Body: "unauthorized"}})
+	assert.Equal(t, StatusFail, res.Status)
+	assert.Contains(t, res.Remediation, "credentials.token")
+}
+
+func TestCheckTokens(t *testing.T) {
+	ctx := context.Background()
+	cfg := &adapter.Config{CostReportToken: "rprt_1", WorkspaceToken: "wrkspc_2"}
+
+	results := CheckTokens(ctx, &fakeClient{
+		report:     client.CostReport{Token: "rprt_1", Title: "Prod"},
+		workspaces: []client.Workspace{{Token: "wrkspc_1", Name: "Other"}},
+	}, cfg)
+	require.Len(t, results, 2)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Contains(t, results[0].Message, "Prod")
+	assert.Equal(t, StatusFail, results[1].Status)
+	assert.Contains(t, results[1].Remediation, "workspaces")
+
+	results = CheckTokens(ctx, &fakeClient{reportErr: errors.New("status 404")}, &adapter.Config{
+		CostReportToken: "rprt_missing",
+	})
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusFail, results[0].Status)
+
+	results = CheckTokens(ctx, &fakeClient{
+		reports: []client.CostReport{{Token: "rprt_3", Title: "Engineering Monthly"}},
+	}, &adapter.Config{CostReportName: "engineering monthly"})
+	require.Len(t, results, 1)
+	assert.Equal(t, "cost_report_name", results[0].Name)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Contains(t, results[0].Message, "rprt_3")
+}
+
+// checkingSink is a Sink that also implements SinkChecker.
+type checkingSink struct {
+	adapter.Sink
+
+	err error
+}
+
+func (c *checkingSink) Check(_ context.Context) error {
+	return c.err
+}
+
+func TestCheckSink(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Equal(t, StatusPass, CheckSink(ctx, &checkingSink{}).Status)
+	assert.Equal(t, StatusFail, CheckSink(ctx, &checkingSink{err: errors.New("read-only")}).Status)
+
+	var plain struct{ adapter.Sink }
+	assert.Equal(t, StatusWarn, CheckSink(ctx, plain).Status)
+}
+
+func TestReport_PrintJSON(t *testing.T) {
+	report := Report{Results: []Result{{Name: "api", Status: StatusPass, Message: "ok"}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, report.PrintJSON(&buf))
+	assert.Contains(t, buf.String(), `"passed": true`)
+	assert.Contains(t, buf.String(), `"status": "PASS"`)
+}
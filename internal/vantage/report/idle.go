@@ -0,0 +1,232 @@
+package report
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// IdleResource is a resource that cost money on at least MinDays days of
+// the window without reporting any usage.
+type IdleResource struct {
+	Provider   string  `json:"provider,omitempty"`
+	Service    string  `json:"service,omitempty"`
+	ResourceID string  `json:"resource_id"`
+	AccountID  string  `json:"account_id,omitempty"`
+	Region     string  `json:"region,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	Cost       float64 `json:"cost"`
+	// CostDays is the number of days the resource had spend.
+	CostDays  int       `json:"cost_days"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// IdleGroup totals the idle resources of one provider and service.
+type IdleGroup struct {
+	Provider  string  `json:"provider,omitempty"`
+	Service   string  `json:"service,omitempty"`
+	Currency  string  `json:"currency,omitempty"`
+	Resources int     `json:"resources"`
+	Cost      float64 `json:"cost"`
+}
+
+// IdleReport lists the idle resources of [Start, End), grouped by provider
+// and service with the costliest group first, and the costliest resource
+// first within a group.
+type IdleReport struct {
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	MinDays   int            `json:"min_days"`
+	Resources []IdleResource `json:"resources"`
+	Groups    []IdleGroup    `json:"groups"`
+}
+
+// BuildIdle finds the resources whose records in [start, end) add up to a
+// positive net cost on at least minDays distinct days while reporting zero
+// usage. A resource counts as reporting zero usage only when some record
+// carries a usage amount and none carries a positive one, so records synced
+// without the usage metric never flag a resource. Records without a
+// resource ID are skipped, as are records that are not charges.
+func BuildIdle(records []adapter.CostRecord, start, end time.Time, minDays int) IdleReport {
+	type resourceKey struct {
+		provider, service, resourceID, currency string
+	}
+	type resourceState struct {
+		IdleResource
+		days      map[time.Time]bool
+		usageSeen bool
+		used      bool
+	}
+
+	resources := make(map[resourceKey]*resourceState)
+	for i := range records {
+		record := &records[i]
+		if record.MetricType != "" && record.MetricType != "cost" {
+			continue
+		}
+		if record.ResourceID == "" || record.Timestamp.Before(start) || !record.Timestamp.Before(end) {
+			continue
+		}
+
+		key := resourceKey{record.Provider, record.Service, record.ResourceID, record.Currency}
+		state, ok := resources[key]
+		if !ok {
+			state = &resourceState{
+				IdleResource: IdleResource{
+					Provider:   record.Provider,
+					Service:    record.Service,
+					ResourceID: record.ResourceID,
+					AccountID:  record.AccountID,
+					Region:     record.Region,
+					Currency:   record.Currency,
+					FirstSeen:  record.Timestamp,
+					LastSeen:   record.Timestamp,
+				},
+				days: make(map[time.Time]bool),
+			}
+			resources[key] = state
+		}
+
+		if record.UsageAmount != nil {
+			state.usageSeen = true
+			if *record.UsageAmount > 0 {
+				state.used = true
+			}
+		}
+		cost := valueOf(record.NetCost)
+		state.Cost += cost
+		if cost > 0 {
+			day := record.Timestamp.UTC().Truncate(24 * time.Hour)
+			state.days[day] = true
+		}
+		if record.Timestamp.Before(state.FirstSeen) {
+			state.FirstSeen = record.Timestamp
+		}
+		if record.Timestamp.After(state.LastSeen) {
+			state.LastSeen = record.Timestamp
+		}
+	}
+
+	idle := IdleReport{Start: start, End: end, MinDays: minDays, Resources: []IdleResource{}, Groups: []IdleGroup{}}
+	groups := make(map[[3]string]*IdleGroup)
+	for _, state := range resources {
+		if state.used || !state.usageSeen || state.Cost <= 0 || len(state.days) < minDays {
+			continue
+		}
+		state.CostDays = len(state.days)
+		idle.Resources = append(idle.Resources, state.IdleResource)
+
+		key := [3]string{state.Provider, state.Service, state.Currency}
+		group, ok := groups[key]
+		if !ok {
+			group = &IdleGroup{Provider: state.Provider, Service: state.Service, Currency: state.Currency}
+			groups[key] = group
+		}
+		group.Resources++
+		group.Cost += state.Cost
+	}
+	for _, group := range groups {
+		idle.Groups = append(idle.Groups, *group)
+	}
+
+	slices.SortFunc(idle.Groups, func(a, b IdleGroup) int {
+		return cmp.Or(
+			cmp.Compare(a.Currency, b.Currency),
+			cmp.Compare(b.Cost, a.Cost),
+			cmp.Compare(a.Provider, b.Provider),
+			cmp.Compare(a.Service, b.Service),
+		)
+	})
+	rank := make(map[[3]string]int, len(idle.Groups))
+	for i, group := range idle.Groups {
+		rank[[3]string{group.Provider, group.Service, group.Currency}] = i
+	}
+	slices.SortFunc(idle.Resources, func(a, b IdleResource) int {
+		return cmp.Or(
+			cmp.Compare(rank[[3]string{a.Provider, a.Service, a.Currency}], rank[[3]string{b.Provider, b.Service, b.Currency}]),
+			cmp.Compare(b.Cost, a.Cost),
+			cmp.Compare(a.ResourceID, b.ResourceID),
+		)
+	})
+	return idle
+}
+
+// idleColumns are the idle report columns shared by the CSV and Markdown
+// formats.
+var idleColumns = []string{
+	"provider", "service", "resource_id", "account_id", "region", "currency", "cost", "cost_days", "first_seen", "last_seen",
+}
+
+// cells returns the resource's values in idleColumns order.
+func (r *IdleResource) cells() []string {
+	return []string{
+		r.Provider,
+		r.Service,
+		r.ResourceID,
+		r.AccountID,
+		r.Region,
+		r.Currency,
+		formatAmount(r.Cost),
+		strconv.Itoa(r.CostDays),
+		r.FirstSeen.Format(time.DateOnly),
+		r.LastSeen.Format(time.DateOnly),
+	}
+}
+
+// WriteIdle renders the idle report in format (see SupportedFormats). CSV
+// has one line per resource, ready for a cleanup tracker; Markdown adds a
+// table of group totals.
+func WriteIdle(w io.Writer, idle IdleReport, format string) error {
+	switch format {
+	case FormatCSV:
+		out := csv.NewWriter(w)
+		if err := out.Write(idleColumns); err != nil {
+			return fmt.Errorf("writing report header: %w", err)
+		}
+		for i := range idle.Resources {
+			if err := out.Write(idle.Resources[i].cells()); err != nil {
+				return fmt.Errorf("writing report row: %w", err)
+			}
+		}
+		out.Flush()
+		return out.Error()
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(idle)
+	case FormatMarkdown:
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Idle resources: %s to %s\n\n", idle.Start.Format(time.DateOnly),
+			idle.End.AddDate(0, 0, -1).Format(time.DateOnly))
+		b.WriteString("| provider | service | currency | resources | cost |\n")
+		b.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, group := range idle.Groups {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", escapeMarkdown(group.Provider), escapeMarkdown(group.Service),
+				group.Currency, group.Resources, formatAmount(group.Cost))
+		}
+		b.WriteString("\n| " + strings.Join(idleColumns, " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(idleColumns)) + "\n")
+		for i := range idle.Resources {
+			cells := idle.Resources[i].cells()
+			for j := range cells {
+				cells[j] = escapeMarkdown(cells[j])
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid format: %s (valid: %s)", format, strings.Join(SupportedFormats(), ", "))
+	}
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func idleRecords() []adapter.CostRecord {
+	var records []adapter.CostRecord
+	add := func(resourceID, service string, days int, cost float64, usage *float64) {
+		for d := range days {
+			records = append(records, adapter.CostRecord{
+				Timestamp:   january.AddDate(0, 0, d),
+				MetricType:  "cost",
+				Currency:    "USD",
+				Provider:    "aws",
+				Service:     service,
+				ResourceID:  resourceID,
+				NetCost:     float(cost),
+				UsageAmount: usage,
+			})
+		}
+	}
+	add("vol-idle", "EBS", 10, 2, float(0))
+	add("vol-idle-2", "EBS", 8, 1, float(0))
+	add("eip-idle", "EC2", 10, 3.6, float(0))
+	// Used, too short-lived, and without usage data.
+	add("i-busy", "EC2", 10, 5, float(24))
+	add("vol-brief", "EBS", 2, 2, float(0))
+	add("vol-unknown", "EBS", 10, 2, nil)
+	// No resource ID, and outside the window.
+	add("", "EBS", 10, 2, float(0))
+	records = append(records, adapter.CostRecord{
+		Timestamp: february, MetricType: "cost", Currency: "USD", Provider: "aws", Service: "EBS",
+		ResourceID: "vol-idle", NetCost: float(2), UsageAmount: float(0),
+	})
+	return records
+}
+
+func TestBuildIdle(t *testing.T) {
+	idle := BuildIdle(idleRecords(), january, february, 7)
+
+	var ids []string
+	for _, resource := range idle.Resources {
+		ids = append(ids, resource.ResourceID)
+	}
+	assert.Equal(t, []string{"eip-idle", "vol-idle", "vol-idle-2"}, ids)
+
+	first := idle.Resources[0]
+	assert.InDelta(t, 36.0, first.Cost, 1e-9)
+	assert.Equal(t, 10, first.CostDays)
+	assert.Equal(t, january, first.FirstSeen)
+	assert.Equal(t, january.AddDate(0, 0, 9), first.LastSeen)
+
+	require.Len(t, idle.Groups, 2)
+	assert.Equal(t, "EC2", idle.Groups[0].Service)
+	assert.Equal(t, 1, idle.Groups[0].Resources)
+	assert.InDelta(t, 36.0, idle.Groups[0].Cost, 1e-9)
+	assert.Equal(t, "EBS", idle.Groups[1].Service)
+	assert.Equal(t, 2, idle.Groups[1].Resources)
+	assert.InDelta(t, 28.0, idle.Groups[1].Cost, 1e-9)
+
+	assert.Len(t, BuildIdle(idleRecords(), january, february, 9).Resources, 2)
+}
+
+func TestWriteIdle_CSV(t *testing.T) {
+	idle := BuildIdle(idleRecords(), january, february, 10)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteIdle(&buf, idle, FormatCSV))
+
+	assert.Equal(t,
+		"provider,service,resource_id,account_id,region,currency,cost,cost_days,first_seen,last_seen\n"+
+			"aws,EC2,eip-idle,,,USD,36.00,10,2024-01-01,2024-01-10\n"+
+			"aws,EBS,vol-idle,,,USD,20.00,10,2024-01-01,2024-01-10\n",
+		buf.String())
+}
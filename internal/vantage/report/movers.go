@@ -0,0 +1,218 @@
+package report
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// Periods a movers analysis can compare.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// SupportedPeriods returns the periods MoverPeriods accepts.
+func SupportedPeriods() []string {
+	return []string{PeriodDay, PeriodWeek, PeriodMonth}
+}
+
+// MoverPeriods returns the bounds of the last complete period ending on or
+// before end, [currentStart, currentEnd), and of the period before it,
+// [previousStart, currentStart). Weeks start on Monday; months are calendar
+// months.
+func MoverPeriods(period string, end time.Time) (previousStart, currentStart, currentEnd time.Time, err error) {
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodDay:
+		return end.AddDate(0, 0, -2), end.AddDate(0, 0, -1), end, nil
+	case PeriodWeek:
+		monday := end.AddDate(0, 0, -((int(end.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, -14), monday.AddDate(0, 0, -7), monday, nil
+	case PeriodMonth:
+		first := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first.AddDate(0, -2, 0), first.AddDate(0, -1, 0), first, nil
+	default:
+		return time.Time{}, time.Time{}, time.Time{},
+			fmt.Errorf("invalid period: %s (valid: %s)", period, strings.Join(SupportedPeriods(), ", "))
+	}
+}
+
+// Mover is the change in one group's net cost between two periods.
+type Mover struct {
+	Group    string  `json:"group"`
+	Currency string  `json:"currency,omitempty"`
+	Previous float64 `json:"previous"`
+	Current  float64 `json:"current"`
+	// Change is Current minus Previous; ChangePercent is Change as a
+	// percentage of Previous, and nil for a group without previous spend.
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+}
+
+// Movers lists the groups whose net cost changed the most between
+// [PreviousStart, CurrentStart) and [CurrentStart, CurrentEnd): by absolute
+// change, and by percentage change among groups with previous spend.
+type Movers struct {
+	GroupBy       string    `json:"group_by"`
+	PreviousStart time.Time `json:"previous_start"`
+	CurrentStart  time.Time `json:"current_start"`
+	CurrentEnd    time.Time `json:"current_end"`
+	ByAbsolute    []Mover   `json:"by_absolute"`
+	ByPercent     []Mover   `json:"by_percent"`
+}
+
+// BuildMovers compares the net cost of each group, read for groupBy as in
+// Build, between the two periods and keeps the top largest changes of each
+// kind, either direction. Groups are compared within one currency; groups
+// that did not change are left out. Records are expected to be reconciled
+// already; records that are not charges are skipped.
+func BuildMovers(records []adapter.CostRecord, groupBy string, previousStart, currentStart, currentEnd time.Time, top int) Movers {
+	value := groupValue(groupBy)
+
+	groups := make(map[[2]string]*Mover)
+	for i := range records {
+		record := &records[i]
+		if record.MetricType != "" && record.MetricType != "cost" {
+			continue
+		}
+		if record.Timestamp.Before(previousStart) || !record.Timestamp.Before(currentEnd) {
+			continue
+		}
+
+		group := value(record)
+		if group == "" {
+			group = Unlabeled
+		}
+		key := [2]string{group, record.Currency}
+		mover, ok := groups[key]
+		if !ok {
+			mover = &Mover{Group: group, Currency: record.Currency}
+			groups[key] = mover
+		}
+		if record.Timestamp.Before(currentStart) {
+			mover.Previous += valueOf(record.NetCost)
+		} else {
+			mover.Current += valueOf(record.NetCost)
+		}
+	}
+
+	movers := Movers{
+		GroupBy:       groupBy,
+		PreviousStart: previousStart,
+		CurrentStart:  currentStart,
+		CurrentEnd:    currentEnd,
+		ByAbsolute:    []Mover{},
+		ByPercent:     []Mover{},
+	}
+	for _, mover := range groups {
+		mover.Change = mover.Current - mover.Previous
+		if mover.Change == 0 {
+			continue
+		}
+		if mover.Previous != 0 {
+			percent := mover.Change / math.Abs(mover.Previous) * 100
+			mover.ChangePercent = &percent
+			movers.ByPercent = append(movers.ByPercent, *mover)
+		}
+		movers.ByAbsolute = append(movers.ByAbsolute, *mover)
+	}
+
+	slices.SortFunc(movers.ByAbsolute, func(a, b Mover) int {
+		return cmp.Or(
+			cmp.Compare(math.Abs(b.Change), math.Abs(a.Change)),
+			cmp.Compare(a.Group, b.Group),
+			cmp.Compare(a.Currency, b.Currency),
+		)
+	})
+	slices.SortFunc(movers.ByPercent, func(a, b Mover) int {
+		return cmp.Or(
+			cmp.Compare(math.Abs(*b.ChangePercent), math.Abs(*a.ChangePercent)),
+			cmp.Compare(math.Abs(b.Change), math.Abs(a.Change)),
+			cmp.Compare(a.Group, b.Group),
+		)
+	})
+	if top > 0 {
+		movers.ByAbsolute = movers.ByAbsolute[:min(top, len(movers.ByAbsolute))]
+		movers.ByPercent = movers.ByPercent[:min(top, len(movers.ByPercent))]
+	}
+	return movers
+}
+
+// moverColumns are the movers report columns shared by the CSV and Markdown
+// formats, after the group column.
+var moverColumns = []string{"currency", "previous", "current", "change", "change_percent"}
+
+// cells returns the mover's values in moverColumns order.
+func (m *Mover) cells() []string {
+	percent := ""
+	if m.ChangePercent != nil {
+		percent = strconv.FormatFloat(*m.ChangePercent, 'f', 1, 64)
+	}
+	return []string{m.Currency, formatAmount(m.Previous), formatAmount(m.Current), formatAmount(m.Change), percent}
+}
+
+// WriteMovers renders the movers report in format (see SupportedFormats).
+// The CSV format has a leading ranking column, "absolute" or "percent".
+func WriteMovers(w io.Writer, movers Movers, format string) error {
+	rankings := []struct {
+		name  string
+		title string
+		rows  []Mover
+	}{
+		{"absolute", "Largest changes", movers.ByAbsolute},
+		{"percent", "Largest percentage changes", movers.ByPercent},
+	}
+
+	switch format {
+	case FormatCSV:
+		out := csv.NewWriter(w)
+		if err := out.Write(append([]string{"ranking", movers.GroupBy}, moverColumns...)); err != nil {
+			return fmt.Errorf("writing report header: %w", err)
+		}
+		for _, ranking := range rankings {
+			for i := range ranking.rows {
+				if err := out.Write(append([]string{ranking.name, ranking.rows[i].Group}, ranking.rows[i].cells()...)); err != nil {
+					return fmt.Errorf("writing report row: %w", err)
+				}
+			}
+		}
+		out.Flush()
+		return out.Error()
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(movers)
+	case FormatMarkdown:
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Cost movers by %s: %s to %s vs %s to %s\n", movers.GroupBy,
+			movers.CurrentStart.Format(time.DateOnly), movers.CurrentEnd.AddDate(0, 0, -1).Format(time.DateOnly),
+			movers.PreviousStart.Format(time.DateOnly), movers.CurrentStart.AddDate(0, 0, -1).Format(time.DateOnly))
+		header := append([]string{movers.GroupBy}, moverColumns...)
+		for _, ranking := range rankings {
+			fmt.Fprintf(&b, "\n### %s\n\n", ranking.title)
+			b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+			b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+			for i := range ranking.rows {
+				cells := append([]string{escapeMarkdown(ranking.rows[i].Group)}, ranking.rows[i].cells()...)
+				b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+			}
+		}
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid format: %s (valid: %s)", format, strings.Join(SupportedFormats(), ", "))
+	}
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func TestMoverPeriods(t *testing.T) {
+	// A Thursday.
+	end := time.Date(2024, 3, 14, 15, 0, 0, 0, time.UTC)
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		period                             string
+		wantPrevious, wantCurrent, wantEnd time.Time
+	}{
+		{PeriodDay, day(3, 12), day(3, 13), day(3, 14)},
+		{PeriodWeek, day(2, 26), day(3, 4), day(3, 11)},
+		{PeriodMonth, day(1, 1), day(2, 1), day(3, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			previousStart, currentStart, currentEnd, err := MoverPeriods(tt.period, end)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPrevious, previousStart)
+			assert.Equal(t, tt.wantCurrent, currentStart)
+			assert.Equal(t, tt.wantEnd, currentEnd)
+		})
+	}
+
+	_, _, _, err := MoverPeriods("year", end)
+	require.EqualError(t, err, "invalid period: year (valid: day, week, month)")
+}
+
+func moverRecords() []adapter.CostRecord {
+	record := func(ts time.Time, service string, cost float64) adapter.CostRecord {
+		return adapter.CostRecord{Timestamp: ts, MetricType: "cost", Currency: "USD", Service: service, NetCost: float(cost)}
+	}
+	december := january.AddDate(0, -1, 0)
+	return []adapter.CostRecord{
+		record(december, "EC2", 100),
+		record(january.AddDate(0, 0, 3), "EC2", 150),
+		record(december.AddDate(0, 0, 5), "S3", 10),
+		record(january.AddDate(0, 0, 5), "S3", 30),
+		record(december, "RDS", 500),
+		record(january, "RDS", 420),
+		record(january, "Lambda", 60),
+		record(december, "CloudFront", 5),
+		record(january, "CloudFront", 5),
+		// Outside both periods, and not a charge.
+		record(february, "EC2", 1000),
+		{Timestamp: january, MetricType: "forecast", Currency: "USD", Service: "EC2", NetCost: float(1000)},
+	}
+}
+
+func TestBuildMovers(t *testing.T) {
+	december := january.AddDate(0, -1, 0)
+
+	movers := BuildMovers(moverRecords(), "service", december, january, february, 0)
+
+	groups := func(rows []Mover) []string {
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.Group)
+		}
+		return names
+	}
+	// CloudFront did not change; Lambda had no previous spend.
+	assert.Equal(t, []string{"RDS", "Lambda", "EC2", "S3"}, groups(movers.ByAbsolute))
+	assert.Equal(t, []string{"S3", "EC2", "RDS"}, groups(movers.ByPercent))
+
+	rds := movers.ByAbsolute[0]
+	assert.InDelta(t, 500.0, rds.Previous, 1e-9)
+	assert.InDelta(t, 420.0, rds.Current, 1e-9)
+	assert.InDelta(t, -80.0, rds.Change, 1e-9)
+	require.NotNil(t, rds.ChangePercent)
+	assert.InDelta(t, -16.0, *rds.ChangePercent, 1e-9)
+	assert.Nil(t, movers.ByAbsolute[1].ChangePercent)
+
+	top := BuildMovers(moverRecords(), "service", december, january, february, 2)
+	assert.Equal(t, []string{"RDS", "Lambda"}, groups(top.ByAbsolute))
+	assert.Equal(t, []string{"S3", "EC2"}, groups(top.ByPercent))
+}
+
+func TestWriteMovers_CSV(t *testing.T) {
+	december := january.AddDate(0, -1, 0)
+	movers := BuildMovers(moverRecords(), "service", december, january, february, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMovers(&buf, movers, FormatCSV))
+
+	assert.Equal(t,
+		"ranking,service,currency,previous,current,change,change_percent\n"+
+			"absolute,RDS,USD,500.00,420.00,-80.00,-16.0\n"+
+			"percent,S3,USD,10.00,30.00,20.00,200.0\n",
+		buf.String())
+}
+
+func TestWriteMovers_Markdown(t *testing.T) {
+	december := january.AddDate(0, -1, 0)
+	movers := BuildMovers(moverRecords(), "service", december, january, february, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMovers(&buf, movers, FormatMarkdown))
+
+	out := buf.String()
+	assert.Contains(t, out, "## Cost movers by service: 2024-01-01 to 2024-01-31 vs 2023-12-01 to 2023-12-31\n")
+	assert.Contains(t, out, "### Largest changes\n")
+	assert.Contains(t, out, "| RDS | USD | 500.00 | 420.00 | -80.00 | -16.0 |\n")
+	assert.Contains(t, out, "### Largest percentage changes\n")
+}
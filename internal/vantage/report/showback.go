@@ -0,0 +1,259 @@
+// Package report builds showback and chargeback reports from synced cost
+// records.
+package report
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// Output formats for a showback report.
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// SupportedFormats returns the formats Write accepts.
+func SupportedFormats() []string {
+	return []string{FormatCSV, FormatJSON, FormatMarkdown}
+}
+
+// labelPrefix forces a group key to be read as a label, as in "label.project".
+const labelPrefix = "label."
+
+// Unlabeled is the group of records without the grouping label.
+const Unlabeled = "(unlabeled)"
+
+// dimensions are the record fields a report can group by besides labels.
+var dimensions = map[string]func(r *adapter.CostRecord) string{
+	"provider":         func(r *adapter.CostRecord) string { return r.Provider },
+	"service":          func(r *adapter.CostRecord) string { return r.Service },
+	"account_id":       func(r *adapter.CostRecord) string { return r.AccountID },
+	"project":          func(r *adapter.CostRecord) string { return r.Project },
+	"region":           func(r *adapter.CostRecord) string { return r.Region },
+	"region_continent": func(r *adapter.CostRecord) string { return r.RegionContinent },
+	"region_country":   func(r *adapter.CostRecord) string { return r.RegionCountry },
+}
+
+// SupportedDimensions returns the record fields a report can group by; any
+// other key groups by that label.
+func SupportedDimensions() []string {
+	return []string{"provider", "service", "account_id", "project", "region", "region_continent", "region_country"}
+}
+
+// Row is the spend of one group in one currency.
+type Row struct {
+	Group         string  `json:"group"`
+	Currency      string  `json:"currency,omitempty"`
+	NetCost       float64 `json:"net_cost"`
+	AmortizedCost float64 `json:"amortized_cost"`
+	ListCost      float64 `json:"list_cost"`
+	TaxCost       float64 `json:"tax_cost"`
+	CreditAmount  float64 `json:"credit_amount"`
+	RefundAmount  float64 `json:"refund_amount"`
+	Records       int     `json:"records"`
+	// Share is the row's fraction of its currency's total net cost.
+	Share float64 `json:"share"`
+}
+
+// Showback is the spend of each group over [Start, End), largest net cost
+// first, with one total per currency.
+type Showback struct {
+	GroupBy string    `json:"group_by"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Rows    []Row     `json:"rows"`
+	Totals  []Row     `json:"totals"`
+}
+
+// Build aggregates the cost records whose timestamp falls in [start, end)
+// by groupBy: a dimension (see SupportedDimensions), or else a label key,
+// optionally written "label.<key>". Records are expected to be reconciled
+// already; forecast, budget, recommendation, and deletion records are not
+// charges and are skipped.
+func Build(records []adapter.CostRecord, groupBy string, start, end time.Time) Showback {
+	value := groupValue(groupBy)
+
+	rows := make(map[[2]string]*Row)
+	totals := make(map[string]*Row)
+	for i := range records {
+		record := &records[i]
+		if record.MetricType != "" && record.MetricType != "cost" {
+			continue
+		}
+		if record.Timestamp.Before(start) || !record.Timestamp.Before(end) {
+			continue
+		}
+
+		group := value(record)
+		if group == "" {
+			group = Unlabeled
+		}
+		key := [2]string{group, record.Currency}
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{Group: group, Currency: record.Currency}
+			rows[key] = row
+		}
+		total, ok := totals[record.Currency]
+		if !ok {
+			total = &Row{Group: "Total", Currency: record.Currency}
+			totals[record.Currency] = total
+		}
+		row.add(record)
+		total.add(record)
+	}
+
+	showback := Showback{GroupBy: groupBy, Start: start, End: end, Rows: []Row{}, Totals: []Row{}}
+	for _, row := range rows {
+		if total := totals[row.Currency].NetCost; total != 0 {
+			row.Share = row.NetCost / total
+		}
+		showback.Rows = append(showback.Rows, *row)
+	}
+	for _, total := range totals {
+		total.Share = 1
+		showback.Totals = append(showback.Totals, *total)
+	}
+
+	slices.SortFunc(showback.Rows, func(a, b Row) int {
+		return cmp.Or(
+			cmp.Compare(a.Currency, b.Currency),
+			cmp.Compare(b.NetCost, a.NetCost),
+			cmp.Compare(a.Group, b.Group),
+		)
+	})
+	slices.SortFunc(showback.Totals, func(a, b Row) int { return cmp.Compare(a.Currency, b.Currency) })
+	return showback
+}
+
+// groupValue returns how a record's group is read for groupBy.
+func groupValue(groupBy string) func(r *adapter.CostRecord) string {
+	if value, ok := dimensions[groupBy]; ok {
+		return value
+	}
+	key := strings.TrimPrefix(groupBy, labelPrefix)
+	return func(r *adapter.CostRecord) string { return r.Labels[key] }
+}
+
+// add sums record's metrics into the row.
+func (row *Row) add(record *adapter.CostRecord) {
+	row.NetCost += valueOf(record.NetCost)
+	row.AmortizedCost += valueOf(record.AmortizedCost)
+	row.ListCost += valueOf(record.ListCost)
+	row.TaxCost += valueOf(record.TaxCost)
+	row.CreditAmount += valueOf(record.CreditAmount)
+	row.RefundAmount += valueOf(record.RefundAmount)
+	row.Records++
+}
+
+func valueOf(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// columns are the report columns shared by the CSV and Markdown formats.
+var columns = []string{
+	"currency", "net_cost", "amortized_cost", "list_cost", "tax_cost",
+	"credit_amount", "refund_amount", "share_percent", "records",
+}
+
+// cells returns the row's values in columns order.
+func (row *Row) cells() []string {
+	return []string{
+		row.Currency,
+		formatAmount(row.NetCost),
+		formatAmount(row.AmortizedCost),
+		formatAmount(row.ListCost),
+		formatAmount(row.TaxCost),
+		formatAmount(row.CreditAmount),
+		formatAmount(row.RefundAmount),
+		strconv.FormatFloat(row.Share*100, 'f', 1, 64),
+		strconv.Itoa(row.Records),
+	}
+}
+
+// Write renders the report in format (see SupportedFormats).
+func Write(w io.Writer, showback Showback, format string) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, showback)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(showback)
+	case FormatMarkdown:
+		return writeMarkdown(w, showback)
+	default:
+		return fmt.Errorf("invalid format: %s (valid: %s)", format, strings.Join(SupportedFormats(), ", "))
+	}
+}
+
+// writeCSV writes one line per row followed by the totals, whose group
+// column is "Total".
+func writeCSV(w io.Writer, showback Showback) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(append([]string{showback.GroupBy}, columns...)); err != nil {
+		return fmt.Errorf("writing report header: %w", err)
+	}
+	for _, rows := range [][]Row{showback.Rows, showback.Totals} {
+		for i := range rows {
+			if err := out.Write(append([]string{rows[i].Group}, rows[i].cells()...)); err != nil {
+				return fmt.Errorf("writing report row: %w", err)
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeMarkdown writes the report as a titled Markdown table with bold
+// totals.
+func writeMarkdown(w io.Writer, showback Showback) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Showback by %s: %s to %s\n\n", showback.GroupBy,
+		showback.Start.Format(time.DateOnly), showback.End.AddDate(0, 0, -1).Format(time.DateOnly))
+
+	header := append([]string{showback.GroupBy}, columns...)
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for i := range showback.Rows {
+		cells := append([]string{escapeMarkdown(showback.Rows[i].Group)}, showback.Rows[i].cells()...)
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	for i := range showback.Totals {
+		cells := append([]string{"Total"}, showback.Totals[i].cells()...)
+		for j := range cells {
+			if cells[j] != "" {
+				cells[j] = "**" + cells[j] + "**"
+			}
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+// escapeMarkdown keeps a label value from breaking the table.
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
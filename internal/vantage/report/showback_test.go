@@ -0,0 +1,102 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func float(v float64) *float64 { return &v }
+
+var (
+	january  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	february = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testRecords() []adapter.CostRecord {
+	return []adapter.CostRecord{
+		{
+			Timestamp: january, Currency: "USD", MetricType: "cost", Provider: "aws",
+			Labels:  map[string]string{"team": "platform"},
+			NetCost: float(60), AmortizedCost: float(55), TaxCost: float(6), CreditAmount: float(-5),
+		},
+		{
+			Timestamp: january.AddDate(0, 0, 10), Currency: "USD", MetricType: "cost", Provider: "gcp",
+			Labels:  map[string]string{"team": "payments"},
+			NetCost: float(30), RefundAmount: float(-2),
+		},
+		{Timestamp: january.AddDate(0, 0, 20), Currency: "USD", Provider: "aws", NetCost: float(10)},
+		// Outside the period, or not a charge.
+		{Timestamp: february, Currency: "USD", Labels: map[string]string{"team": "platform"}, NetCost: float(1000)},
+		{Timestamp: january, Currency: "USD", MetricType: "forecast", NetCost: float(500)},
+	}
+}
+
+func TestBuild_ByLabel(t *testing.T) {
+	showback := Build(testRecords(), "team", january, february)
+
+	require.Len(t, showback.Rows, 3)
+	assert.Equal(t, "platform", showback.Rows[0].Group)
+	assert.InDelta(t, 60.0, showback.Rows[0].NetCost, 1e-9)
+	assert.InDelta(t, 55.0, showback.Rows[0].AmortizedCost, 1e-9)
+	assert.InDelta(t, 6.0, showback.Rows[0].TaxCost, 1e-9)
+	assert.InDelta(t, -5.0, showback.Rows[0].CreditAmount, 1e-9)
+	assert.InDelta(t, 0.6, showback.Rows[0].Share, 1e-9)
+	assert.Equal(t, "payments", showback.Rows[1].Group)
+	assert.InDelta(t, -2.0, showback.Rows[1].RefundAmount, 1e-9)
+	assert.Equal(t, Unlabeled, showback.Rows[2].Group)
+
+	require.Len(t, showback.Totals, 1)
+	assert.InDelta(t, 100.0, showback.Totals[0].NetCost, 1e-9)
+	assert.Equal(t, 3, showback.Totals[0].Records)
+}
+
+func TestBuild_ByDimensionAndCurrency(t *testing.T) {
+	records := append(testRecords(), adapter.CostRecord{Timestamp: january, Currency: "EUR", Provider: "aws", NetCost: float(5)})
+	showback := Build(records, "provider", january, february)
+
+	require.Len(t, showback.Rows, 3)
+	assert.Equal(t, Row{Group: "aws", Currency: "EUR", NetCost: 5, Records: 1, Share: 1}, showback.Rows[0])
+	assert.Equal(t, "aws", showback.Rows[1].Group)
+	assert.InDelta(t, 70.0, showback.Rows[1].NetCost, 1e-9)
+	assert.Equal(t, "gcp", showback.Rows[2].Group)
+	require.Len(t, showback.Totals, 2)
+
+	// label.<key> reads a label even when a dimension has the same name.
+	records[0].Labels["provider"] = "shared"
+	showback = Build(records, "label.provider", january, february)
+	assert.Equal(t, "shared", showback.Rows[1].Group)
+}
+
+func TestWrite(t *testing.T) {
+	showback := Build(testRecords(), "team", january, february)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, showback, FormatCSV))
+	assert.Equal(t, `team,currency,net_cost,amortized_cost,list_cost,tax_cost,credit_amount,refund_amount,share_percent,records
+platform,USD,60.00,55.00,0.00,6.00,-5.00,0.00,60.0,1
+payments,USD,30.00,0.00,0.00,0.00,0.00,-2.00,30.0,1
+(unlabeled),USD,10.00,0.00,0.00,0.00,0.00,0.00,10.0,1
+Total,USD,100.00,55.00,0.00,6.00,-5.00,-2.00,100.0,3
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, showback, FormatMarkdown))
+	assert.Contains(t, buf.String(), "## Showback by team: 2024-01-01 to 2024-01-31\n")
+	assert.Contains(t, buf.String(), "| platform | USD | 60.00 | 55.00 |")
+	assert.Contains(t, buf.String(), "| **Total** | **USD** | **100.00** |")
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, showback, FormatJSON))
+	var decoded Showback
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, showback, decoded)
+
+	require.ErrorContains(t, Write(&buf, showback, "xlsx"), "invalid format: xlsx")
+}
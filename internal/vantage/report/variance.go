@@ -0,0 +1,201 @@
+package report
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// VarianceRow compares one forecast snapshot's prediction for a bucket with
+// the bucket's realized net cost.
+type VarianceRow struct {
+	BucketStart  time.Time `json:"bucket_start"`
+	SnapshotDate string    `json:"snapshot_date"`
+	// LeadDays is how many days before the bucket started the forecast was
+	// made; negative when the snapshot fell inside the bucket.
+	LeadDays int     `json:"lead_days"`
+	Currency string  `json:"currency,omitempty"`
+	Forecast float64 `json:"forecast"`
+	Actual   float64 `json:"actual"`
+	// Variance is Actual minus Forecast; VariancePercent is Variance as a
+	// percentage of Forecast, and nil when the forecast was zero.
+	Variance        float64  `json:"variance"`
+	VariancePercent *float64 `json:"variance_percent,omitempty"`
+}
+
+// ForecastVariance is the variance of every forecast snapshot for buckets
+// starting in [Start, End) that have ended, earliest bucket first.
+type ForecastVariance struct {
+	Start time.Time     `json:"start"`
+	End   time.Time     `json:"end"`
+	Rows  []VarianceRow `json:"rows"`
+	// MeanAbsolutePercentError averages |VariancePercent| over the rows
+	// that have one, as a single reliability figure.
+	MeanAbsolutePercentError float64 `json:"mean_absolute_percent_error"`
+}
+
+// BuildVariance compares forecast records with the cost records of the
+// buckets they predicted. A snapshot's forecast for a bucket is the sum of
+// its records, so forecasts grouped by provider or service compare as
+// totals. Buckets are granularity ("day" or "month") long and count only
+// once ended by now and with at least one cost record, so an unsynced
+// bucket never reads as a miss. Forecast records without a snapshot date,
+// written by older plugin versions, are skipped. Records are expected to be
+// reconciled already.
+func BuildVariance(records []adapter.CostRecord, granularity string, start, end, now time.Time) ForecastVariance {
+	bucketEnd := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	if granularity == "month" {
+		bucketEnd = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	}
+
+	type bucketKey struct {
+		start    time.Time
+		currency string
+	}
+	forecasts := make(map[bucketKey]map[string]float64)
+	for i := range records {
+		record := &records[i]
+		if record.MetricType != "forecast" || record.ForecastSnapshotDate == "" {
+			continue
+		}
+		bucket := record.Timestamp.UTC()
+		if bucket.Before(start) || !bucket.Before(end) || bucketEnd(bucket).After(now) {
+			continue
+		}
+		key := bucketKey{start: bucket, currency: record.Currency}
+		if forecasts[key] == nil {
+			forecasts[key] = make(map[string]float64)
+		}
+		forecasts[key][record.ForecastSnapshotDate] += valueOf(record.NetCost)
+	}
+
+	actuals := make(map[bucketKey]float64, len(forecasts))
+	seen := make(map[bucketKey]bool, len(forecasts))
+	for key := range forecasts {
+		keyEnd := bucketEnd(key.start)
+		for i := range records {
+			record := &records[i]
+			if record.MetricType != "" && record.MetricType != "cost" {
+				continue
+			}
+			if record.Currency != key.currency || record.Timestamp.Before(key.start) || !record.Timestamp.Before(keyEnd) {
+				continue
+			}
+			actuals[key] += valueOf(record.NetCost)
+			seen[key] = true
+		}
+	}
+
+	variance := ForecastVariance{Start: start, End: end, Rows: []VarianceRow{}}
+	var errorSum float64
+	var errorCount int
+	for key, snapshots := range forecasts {
+		if !seen[key] {
+			continue
+		}
+		for snapshotDate, forecast := range snapshots {
+			row := VarianceRow{
+				BucketStart:  key.start,
+				SnapshotDate: snapshotDate,
+				Currency:     key.currency,
+				Forecast:     forecast,
+				Actual:       actuals[key],
+			}
+			if snapshot, err := time.Parse(time.DateOnly, snapshotDate); err == nil {
+				row.LeadDays = int(key.start.Sub(snapshot).Hours() / 24)
+			}
+			row.Variance = row.Actual - row.Forecast
+			if row.Forecast != 0 {
+				percent := row.Variance / row.Forecast * 100
+				row.VariancePercent = &percent
+				errorSum += math.Abs(percent)
+				errorCount++
+			}
+			variance.Rows = append(variance.Rows, row)
+		}
+	}
+	if errorCount > 0 {
+		variance.MeanAbsolutePercentError = errorSum / float64(errorCount)
+	}
+
+	slices.SortFunc(variance.Rows, func(a, b VarianceRow) int {
+		return cmp.Or(
+			a.BucketStart.Compare(b.BucketStart),
+			cmp.Compare(a.Currency, b.Currency),
+			cmp.Compare(a.SnapshotDate, b.SnapshotDate),
+		)
+	})
+	return variance
+}
+
+// varianceColumns are the variance report columns shared by the CSV and
+// Markdown formats.
+var varianceColumns = []string{
+	"bucket_start", "snapshot_date", "lead_days", "currency", "forecast", "actual", "variance", "variance_percent",
+}
+
+// cells returns the row's values in varianceColumns order.
+func (row *VarianceRow) cells() []string {
+	percent := ""
+	if row.VariancePercent != nil {
+		percent = strconv.FormatFloat(*row.VariancePercent, 'f', 1, 64)
+	}
+	return []string{
+		row.BucketStart.Format(time.DateOnly),
+		row.SnapshotDate,
+		strconv.Itoa(row.LeadDays),
+		row.Currency,
+		formatAmount(row.Forecast),
+		formatAmount(row.Actual),
+		formatAmount(row.Variance),
+		percent,
+	}
+}
+
+// WriteVariance renders the variance report in format (see
+// SupportedFormats).
+func WriteVariance(w io.Writer, variance ForecastVariance, format string) error {
+	switch format {
+	case FormatCSV:
+		out := csv.NewWriter(w)
+		if err := out.Write(varianceColumns); err != nil {
+			return fmt.Errorf("writing report header: %w", err)
+		}
+		for i := range variance.Rows {
+			if err := out.Write(variance.Rows[i].cells()); err != nil {
+				return fmt.Errorf("writing report row: %w", err)
+			}
+		}
+		out.Flush()
+		return out.Error()
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(variance)
+	case FormatMarkdown:
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Forecast variance: %s to %s\n\n",
+			variance.Start.Format(time.DateOnly), variance.End.AddDate(0, 0, -1).Format(time.DateOnly))
+		b.WriteString("| " + strings.Join(varianceColumns, " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(varianceColumns)) + "\n")
+		for i := range variance.Rows {
+			b.WriteString("| " + strings.Join(variance.Rows[i].cells(), " | ") + " |\n")
+		}
+		fmt.Fprintf(&b, "\nMean absolute percent error: %.1f%%\n", variance.MeanAbsolutePercentError)
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid format: %s (valid: %s)", format, strings.Join(SupportedFormats(), ", "))
+	}
+}
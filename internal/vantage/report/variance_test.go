@@ -0,0 +1,80 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func varianceRecords() []adapter.CostRecord {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	return []adapter.CostRecord{
+		{Timestamp: day(10), MetricType: "forecast", Currency: "USD", ForecastSnapshotDate: "2024-01-03", Provider: "aws", NetCost: float(70)},
+		{Timestamp: day(10), MetricType: "forecast", Currency: "USD", ForecastSnapshotDate: "2024-01-03", Provider: "gcp", NetCost: float(30)},
+		{Timestamp: day(10), MetricType: "forecast", Currency: "USD", ForecastSnapshotDate: "2024-01-09", NetCost: float(125)},
+		{Timestamp: day(10), MetricType: "cost", Currency: "USD", Provider: "aws", NetCost: float(80)},
+		{Timestamp: day(10), MetricType: "cost", Currency: "USD", Provider: "gcp", NetCost: float(40)},
+		// No actuals synced for the 11th, and the 20th has not ended yet.
+		{Timestamp: day(11), MetricType: "forecast", Currency: "USD", ForecastSnapshotDate: "2024-01-03", NetCost: float(100)},
+		{Timestamp: day(20), MetricType: "forecast", Currency: "USD", ForecastSnapshotDate: "2024-01-03", NetCost: float(100)},
+		{Timestamp: day(20), MetricType: "cost", Currency: "USD", NetCost: float(10)},
+		// Written before snapshot dates were recorded.
+		{Timestamp: day(10), MetricType: "forecast", Currency: "USD", NetCost: float(999)},
+	}
+}
+
+func TestBuildVariance(t *testing.T) {
+	now := time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)
+
+	variance := BuildVariance(varianceRecords(), "day", january, february, now)
+
+	require.Len(t, variance.Rows, 2)
+	first := variance.Rows[0]
+	assert.Equal(t, "2024-01-03", first.SnapshotDate)
+	assert.Equal(t, 7, first.LeadDays)
+	assert.InDelta(t, 100.0, first.Forecast, 1e-9)
+	assert.InDelta(t, 120.0, first.Actual, 1e-9)
+	assert.InDelta(t, 20.0, first.Variance, 1e-9)
+	require.NotNil(t, first.VariancePercent)
+	assert.InDelta(t, 20.0, *first.VariancePercent, 1e-9)
+
+	second := variance.Rows[1]
+	assert.Equal(t, 1, second.LeadDays)
+	assert.InDelta(t, -4.0, *second.VariancePercent, 1e-9)
+	assert.InDelta(t, 12.0, variance.MeanAbsolutePercentError, 1e-9)
+}
+
+func TestBuildVariance_MonthlyBuckets(t *testing.T) {
+	records := []adapter.CostRecord{
+		{Timestamp: january, MetricType: "forecast", Currency: "USD", ForecastSnapshotDate: "2023-12-15", NetCost: float(3000)},
+		{Timestamp: january.AddDate(0, 0, 4), MetricType: "cost", Currency: "USD", NetCost: float(1000)},
+		{Timestamp: january.AddDate(0, 0, 25), MetricType: "cost", Currency: "USD", NetCost: float(1400)},
+	}
+
+	assert.Empty(t, BuildVariance(records, "month", january, february, january.AddDate(0, 0, 27)).Rows)
+
+	variance := BuildVariance(records, "month", january, february, february)
+	require.Len(t, variance.Rows, 1)
+	assert.Equal(t, 17, variance.Rows[0].LeadDays)
+	assert.InDelta(t, 2400.0, variance.Rows[0].Actual, 1e-9)
+	assert.InDelta(t, -20.0, *variance.Rows[0].VariancePercent, 1e-9)
+}
+
+func TestWriteVariance_CSV(t *testing.T) {
+	now := time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)
+	variance := BuildVariance(varianceRecords(), "day", january, february, now)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteVariance(&buf, variance, FormatCSV))
+
+	assert.Equal(t,
+		"bucket_start,snapshot_date,lead_days,currency,forecast,actual,variance,variance_percent\n"+
+			"2024-01-10,2024-01-03,7,USD,100.00,120.00,20.00,20.0\n"+
+			"2024-01-10,2024-01-09,1,USD,125.00,120.00,-5.00,-4.0\n",
+		buf.String())
+}
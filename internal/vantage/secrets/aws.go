@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// AWSSecretsManager resolves aws-sm://<secret-id>[#field] references with
+// GetSecretValue, signed with the standard AWS environment credentials
+// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN). The region
+// is taken from a secret ARN, or else AWS_REGION.
+type AWSSecretsManager struct {
+	// Endpoint overrides https://secretsmanager.<region>.amazonaws.com.
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// Resolve implements SecretResolver.
+func (a *AWSSecretsManager) Resolve(ctx context.Context, ref Ref) (string, error) {
+	region := os.Getenv("AWS_REGION")
+	if parts := strings.Split(ref.Path, ":"); len(parts) > 3 && parts[0] == "arn" {
+		region = parts[3]
+	}
+	if region == "" {
+		return "", errors.New("AWS_REGION must be set")
+	}
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return "", errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": ref.Path})
+	if err != nil {
+		return "", fmt.Errorf("encoding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	creds := aws.Credentials{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Source:          "environment",
+	}
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]),
+		"secretsmanager", region, time.Now()); err != nil {
+		return "", fmt.Errorf("signing request: %w", err)
+	}
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := doJSON(a.HTTPClient, req, &resp); err != nil {
+		return "", fmt.Errorf("getting secret value: %w", err)
+	}
+
+	secret := resp.SecretString
+	if secret == "" && resp.SecretBinary != "" {
+		decoded, err := base64.StdEncoding.DecodeString(resp.SecretBinary)
+		if err != nil {
+			return "", fmt.Errorf("decoding secret binary: %w", err)
+		}
+		secret = string(decoded)
+	}
+	return field(secret, ref.Field)
+}
@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Command resolves cmd://<command line> references by running the command
+// and reading the secret from its standard output, e.g.
+// cmd://op read op://vault/vantage/token. The command line is split on
+// whitespace and run without a shell.
+type Command struct{}
+
+// Resolve implements SecretResolver.
+func (Command) Resolve(ctx context.Context, ref Ref) (string, error) {
+	args := strings.Fields(ref.Path)
+	if len(args) == 0 {
+		return "", errors.New("command is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // the command comes from the operator's own config.
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("running %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("running %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
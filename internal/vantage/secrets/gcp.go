@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	defaultGCPSecretManagerURL = "https://secretmanager.googleapis.com"
+	defaultGCPMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCPSecretManager resolves
+// gcp-sm://projects/<project>/secrets/<name>[/versions/<version>][#field]
+// references, reading the latest version unless one is given. It
+// authenticates with GOOGLE_OAUTH_ACCESS_TOKEN when set, and otherwise with
+// the metadata server's default service account.
+type GCPSecretManager struct {
+	// Endpoint and MetadataTokenURL override the Secret Manager API and
+	// metadata server token URLs.
+	Endpoint         string
+	MetadataTokenURL string
+	HTTPClient       *http.Client
+}
+
+// Resolve implements SecretResolver.
+func (g *GCPSecretManager) Resolve(ctx context.Context, ref Ref) (string, error) {
+	name := strings.Trim(ref.Path, "/")
+	if !strings.HasPrefix(name, "projects/") || !strings.Contains(name, "/secrets/") {
+		return "", fmt.Errorf("expected projects/<project>/secrets/<name>, got %s", name)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = defaultGCPSecretManagerURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doJSON(g.HTTPClient, req, &resp); err != nil {
+		return "", fmt.Errorf("accessing secret version: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("decoding secret payload: %w", err)
+	}
+	return field(string(data), ref.Field)
+}
+
+// accessToken returns the OAuth2 token Secret Manager calls are sent with.
+func (g *GCPSecretManager) accessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	url := g.MetadataTokenURL
+	if url == "" {
+		url = defaultGCPMetadataTokenURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating metadata token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(g.HTTPClient, req, &resp); err != nil {
+		return "", fmt.Errorf("getting metadata server token (or set GOOGLE_OAUTH_ACCESS_TOKEN): %w", err)
+	}
+	return resp.AccessToken, nil
+}
@@ -0,0 +1,164 @@
+// Package secrets resolves credential references, such as
+// "vault://secret/data/vantage#token", to the secrets they name, so tokens
+// need not be written into configuration files.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reference schemes.
+const (
+	SchemeAWSSecretsManager = "aws-sm"
+	SchemeGCPSecretManager  = "gcp-sm"
+	SchemeVault             = "vault"
+	SchemeCommand           = "cmd"
+)
+
+const (
+	// resolveTimeout bounds resolving one reference.
+	resolveTimeout = 30 * time.Second
+
+	// maxSecretBytes caps how much of a secret response is read.
+	maxSecretBytes = 1 << 20
+)
+
+// SupportedSchemes returns the accepted reference schemes.
+func SupportedSchemes() []string {
+	return []string{SchemeAWSSecretsManager, SchemeGCPSecretManager, SchemeVault, SchemeCommand}
+}
+
+// Ref is a parsed secret reference, scheme://path#field. Field, when set,
+// picks one key out of a secret holding a JSON object.
+type Ref struct {
+	Scheme string
+	Path   string
+	Field  string
+}
+
+func (r Ref) String() string {
+	if r.Field == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "#" + r.Field
+}
+
+// ParseRef parses a reference. A cmd:// reference is a command line taken
+// as is, so it never has a field.
+func ParseRef(ref string) (Ref, error) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(ref), "://")
+	if !ok || rest == "" {
+		return Ref{}, fmt.Errorf("invalid secret reference: %q (expected scheme://path)", ref)
+	}
+	scheme = strings.ToLower(scheme)
+	if !slices.Contains(SupportedSchemes(), scheme) {
+		return Ref{}, fmt.Errorf("invalid secret reference scheme: %s (valid: %s)",
+			scheme, strings.Join(SupportedSchemes(), ", "))
+	}
+	if scheme == SchemeCommand {
+		return Ref{Scheme: scheme, Path: rest}, nil
+	}
+
+	path, field, _ := strings.Cut(rest, "#")
+	if path == "" {
+		return Ref{}, fmt.Errorf("invalid secret reference: %q has no path", ref)
+	}
+	return Ref{Scheme: scheme, Path: path, Field: field}, nil
+}
+
+// SecretResolver fetches the secrets of one reference scheme.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref Ref) (string, error)
+}
+
+// Resolvers maps reference schemes to their resolvers.
+type Resolvers map[string]SecretResolver
+
+// DefaultResolvers returns resolvers for every supported scheme, configured
+// from the standard environment of each backend.
+func DefaultResolvers() Resolvers {
+	return Resolvers{
+		SchemeAWSSecretsManager: &AWSSecretsManager{},
+		SchemeGCPSecretManager:  &GCPSecretManager{},
+		SchemeVault:             &Vault{},
+		SchemeCommand:           &Command{},
+	}
+}
+
+// Resolve parses ref and fetches the secret it names, failing when the
+// secret is empty. Errors name the reference but never the secret.
+func (r Resolvers) Resolve(ctx context.Context, ref string) (string, error) {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	resolver, ok := r[parsed.Scheme]
+	if !ok {
+		return "", fmt.Errorf("no resolver for secret reference scheme %s", parsed.Scheme)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	secret, err := resolver.Resolve(ctx, parsed)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", parsed, err)
+	}
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", fmt.Errorf("resolving %s: secret is empty", parsed)
+	}
+	return secret, nil
+}
+
+// field returns secret itself, or with a field the field's value in secret
+// read as a JSON object.
+func field(secret, name string) (string, error) {
+	if name == "" {
+		return secret, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", errors.New("secret is not a JSON object")
+	}
+	value, ok := fields[name]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("secret field %q is not a string", name)
+	}
+	return s, nil
+}
+
+// doJSON sends req and decodes a 200 response into out. Error bodies are
+// not included, since some backends echo request details.
+func doJSON(httpClient *http.Client, req *http.Request, out interface{}) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSecretBytes)).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRef(t *testing.T) {
+	ref, err := ParseRef("Vault://secret/data/vantage#api_token")
+	require.NoError(t, err)
+	assert.Equal(t, Ref{Scheme: SchemeVault, Path: "secret/data/vantage", Field: "api_token"}, ref)
+	assert.Equal(t, "vault://secret/data/vantage#api_token", ref.String())
+
+	ref, err = ParseRef("cmd://pass show vantage#prod")
+	require.NoError(t, err)
+	assert.Equal(t, Ref{Scheme: SchemeCommand, Path: "pass show vantage#prod"}, ref)
+
+	for _, invalid := range []string{"", "secret/data/vantage", "ssm://token", "vault://#token"} {
+		_, err := ParseRef(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestResolvers_Resolve(t *testing.T) {
+	ctx := context.Background()
+
+	secret, err := DefaultResolvers().Resolve(ctx, "cmd://echo  vantage-token ")
+	require.NoError(t, err)
+	assert.Equal(t, "vantage-token", secret)
+
+	_, err = DefaultResolvers().Resolve(ctx, "cmd://true")
+	require.ErrorContains(t, err, "secret is empty")
+
+	_, err = DefaultResolvers().Resolve(ctx, "cmd://false")
+	require.ErrorContains(t, err, "resolving cmd://false")
+
+	_, err = Resolvers{}.Resolve(ctx, "vault://secret/data/vantage")
+	require.ErrorContains(t, err, "no resolver")
+}
+
+func TestVault_Resolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		switch r.URL.Path {
+		case "/v1/secret/data/vantage":
+			_, _ = w.Write([]byte(`{"data": {"data": {"token": "kv2-token"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/vantage":
+			_, _ = w.Write([]byte(`{"data": {"api": "kv1-token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	resolvers := Resolvers{SchemeVault: &Vault{Addr: server.URL, Token: "vault-token"}}
+	ctx := context.Background()
+
+	secret, err := resolvers.Resolve(ctx, "vault://secret/data/vantage")
+	require.NoError(t, err)
+	assert.Equal(t, "kv2-token", secret)
+
+	secret, err = resolvers.Resolve(ctx, "vault://kv/vantage#api")
+	require.NoError(t, err)
+	assert.Equal(t, "kv1-token", secret)
+
+	_, err = resolvers.Resolve(ctx, "vault://kv/missing")
+	require.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestAWSSecretsManager_Resolve(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), r.Header.Get("Authorization"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-west-2/secretsmanager/aws4_request")
+		_, _ = w.Write([]byte(`{"SecretString": "{\"token\": \"aws-token\"}"}`))
+	}))
+	defer server.Close()
+
+	resolvers := Resolvers{SchemeAWSSecretsManager: &AWSSecretsManager{Endpoint: server.URL}}
+	secret, err := resolvers.Resolve(context.Background(),
+		"aws-sm://arn:aws:secretsmanager:us-west-2:123456789012:secret:vantage#token")
+	require.NoError(t, err)
+	assert.Equal(t, "aws-token", secret)
+}
+
+func TestGCPSecretManager_Resolve(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_, _ = w.Write([]byte(`{"access_token": "gcp-access"}`))
+			return
+		}
+		assert.Equal(t, "/v1/projects/acme/secrets/vantage/versions/latest:access", r.URL.Path)
+		assert.Equal(t, "Bearer gcp-access", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"payload": {"data": "` + base64.StdEncoding.EncodeToString([]byte("gcp-token\n")) + `"}}`))
+	}))
+	defer server.Close()
+
+	resolvers := Resolvers{SchemeGCPSecretManager: &GCPSecretManager{
+		Endpoint: server.URL, MetadataTokenURL: server.URL + "/token",
+	}}
+	secret, err := resolvers.Resolve(context.Background(), "gcp-sm://projects/acme/secrets/vantage")
+	require.NoError(t, err)
+	assert.Equal(t, "gcp-token", secret)
+}
@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultVaultField is the field read when a vault:// reference names none.
+const defaultVaultField = "token"
+
+// Vault resolves vault://<path>[#field] references against HashiCorp Vault
+// at VAULT_ADDR with VAULT_TOKEN. The path is the full API path after /v1/,
+// e.g. secret/data/vantage for a KV version 2 mount; KV version 1 responses
+// are read too. The field defaults to "token".
+type Vault struct {
+	// Addr and Token override VAULT_ADDR and VAULT_TOKEN.
+	Addr       string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// Resolve implements SecretResolver.
+func (v *Vault) Resolve(ctx context.Context, ref Ref) (string, error) {
+	addr := v.Addr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	token := v.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if addr == "" || token == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+
+	url := strings.TrimSuffix(addr, "/") + "/v1/" + strings.TrimPrefix(ref.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := doJSON(v.HTTPClient, req, &resp); err != nil {
+		return "", fmt.Errorf("reading secret: %w", err)
+	}
+
+	// KV version 2 nests the secret's fields one level deeper.
+	fields := resp.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+
+	name := ref.Field
+	if name == "" {
+		name = defaultVaultField
+	}
+	value, ok := fields[name].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string field %q", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,157 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// avroTypes are the Avro types of the record columns. Every field is a
+// union with null, so empty values encode as null.
+var avroTypes = map[columnType]any{
+	columnString:    "string",
+	columnFloat:     "double",
+	columnInt:       "long",
+	columnTimestamp: map[string]string{"type": "long", "logicalType": "timestamp-micros"},
+	columnJSON:      "string",
+}
+
+// avroRecordSchema is the Avro schema records are encoded with: a record of
+// the record columns, each a union of null and its type.
+var avroRecordSchema = buildAvroSchema()
+
+func buildAvroSchema() string {
+	type field struct {
+		Name    string `json:"name"`
+		Type    []any  `json:"type"`
+		Default any    `json:"default"`
+	}
+	fields := make([]field, len(recordColumns))
+	for i, col := range recordColumns {
+		fields[i] = field{Name: col.name, Type: []any{"null", avroTypes[col.typ]}}
+	}
+	schema, err := json.Marshal(map[string]any{
+		"type":      "record",
+		"name":      "CostRecord",
+		"namespace": "com.github.rshade.pulumicost.vantage",
+		"fields":    fields,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return string(schema)
+}
+
+// encodeAvro encodes record in the Avro binary encoding of avroRecordSchema.
+func encodeAvro(record *adapter.CostRecord) ([]byte, error) {
+	values, err := rowValues(record)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, value := range values {
+		if value == nil {
+			writeAvroLong(&buf, 0) // the null branch of the union
+			continue
+		}
+		writeAvroLong(&buf, 1)
+		switch value := value.(type) {
+		case string:
+			writeAvroLong(&buf, int64(len(value)))
+			buf.WriteString(value)
+		case float64:
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(value))
+			buf.Write(b[:])
+		case int64:
+			writeAvroLong(&buf, value)
+		case time.Time:
+			writeAvroLong(&buf, value.UnixMicro())
+		default:
+			return nil, fmt.Errorf("unsupported avro value type %T", value)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeAvroLong writes v as a zig-zag varint.
+func writeAvroLong(buf *bytes.Buffer, v int64) {
+	buf.Write(binary.AppendUvarint(nil, uint64(v<<1)^uint64(v>>63))) //nolint:gosec // zig-zag encoding
+}
+
+// schemaRegistry registers avroRecordSchema with a Confluent-compatible
+// schema registry.
+type schemaRegistry struct {
+	url        string
+	subject    string
+	httpClient *http.Client
+
+	mu sync.Mutex
+	id uint32
+}
+
+// schemaID registers the schema under the subject, once; registering a
+// schema the subject already has returns its existing ID.
+func (r *schemaRegistry) schemaID(ctx context.Context) (uint32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.id != 0 {
+		return r.id, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"schemaType": "AVRO", "schema": avroRecordSchema})
+	if err != nil {
+		return 0, fmt.Errorf("encoding schema: %w", err)
+	}
+	target := strings.TrimRight(r.url, "/") + "/subjects/" + url.PathEscape(r.subject) + "/versions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	httpClient := r.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("registering schema: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return 0, fmt.Errorf("registering schema: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return 0, fmt.Errorf("registering schema: unexpected status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return 0, fmt.Errorf("registering schema: unexpected status %d", resp.StatusCode)
+	}
+
+	var registered struct {
+		ID uint32 `json:"id"`
+	}
+	if decodeErr := json.Unmarshal(data, &registered); decodeErr != nil {
+		return 0, fmt.Errorf("decoding schema registry response: %w", decodeErr)
+	}
+	r.id = registered.ID
+	return r.id, nil
+}
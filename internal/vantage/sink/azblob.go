@@ -0,0 +1,401 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	// azureStorageVersion is the Blob service REST API version requests
+	// are made with.
+	azureStorageVersion = "2021-08-06"
+
+	defaultAzureIMDSTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token" +
+		"?api-version=2018-02-01&resource=https%3A%2F%2Fstorage.azure.com%2F"
+
+	azureBlobTypeBlock  = "BlockBlob"
+	azureBlobTypeAppend = "AppendBlob"
+)
+
+// AzureBlobBucket is a Bucket of the blobs under a prefix in an Azure Blob
+// Storage container, over the Blob service REST API. Record files are
+// append blobs, so batches are appended in place. It authenticates with
+// AZURE_STORAGE_SAS_TOKEN when set, then with the account key in
+// AZURE_STORAGE_KEY, and otherwise with the managed identity's token from
+// the instance metadata service.
+type AzureBlobBucket struct {
+	account   string
+	container string
+	prefix    string
+	sasToken  string
+	key       []byte
+
+	// Endpoint and MetadataTokenURL override the account's blob endpoint,
+	// https://<account>.blob.core.windows.net, and the metadata service
+	// token URL.
+	Endpoint         string
+	MetadataTokenURL string
+	HTTPClient       *http.Client
+
+	metadataToken metadataToken
+}
+
+// NewAzureBlobBucket returns the bucket of the blobs under prefix in
+// container, in the storage account named account.
+func NewAzureBlobBucket(account, container, prefix string) (*AzureBlobBucket, error) {
+	if account == "" {
+		return nil, errors.New("azure storage account cannot be empty (set AZURE_STORAGE_ACCOUNT)")
+	}
+	if container == "" {
+		return nil, errors.New("azure blob container cannot be empty")
+	}
+	b := &AzureBlobBucket{
+		account:   account,
+		container: container,
+		prefix:    prefix,
+		sasToken:  strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+	}
+	if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("decoding AZURE_STORAGE_KEY: %w", err)
+		}
+		b.key = decoded
+	}
+	return b, nil
+}
+
+// String returns the bucket's URL.
+func (b *AzureBlobBucket) String() string {
+	return strings.TrimSuffix(adapter.SinkBucketSchemeAzureBlob+"://"+b.container+"/"+b.prefix, "/")
+}
+
+// List implements Bucket.List.
+func (b *AzureBlobBucket) List(ctx context.Context) ([]string, error) {
+	prefix := objectName(b.prefix, "")
+	var names []string
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}, "delimiter": {"/"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp, err := b.send(ctx, http.MethodGet, b.containerURL()+"?"+query.Encode(), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Blobs struct {
+				Blob []struct {
+					Name string `xml:"Name"`
+				} `xml:"Blob"`
+			} `xml:"Blobs"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		err = b.decodeXML(resp, "", &list)
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range list.Blobs.Blob {
+			names = append(names, strings.TrimPrefix(blob.Name, prefix))
+		}
+		if list.NextMarker == "" {
+			return names, nil
+		}
+		marker = list.NextMarker
+	}
+}
+
+// Size implements Bucket.Size.
+func (b *AzureBlobBucket) Size(ctx context.Context, name string) (int64, error) {
+	resp, err := b.send(ctx, http.MethodHead, b.blobURL(name), nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, azureError(resp, name)
+	}
+	return resp.ContentLength, nil
+}
+
+// Read implements Bucket.Read.
+func (b *AzureBlobBucket) Read(ctx context.Context, name string, offset int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("x-ms-range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := b.send(ctx, http.MethodGet, b.blobURL(name), header, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		_ = resp.Body.Close()
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	default:
+		defer resp.Body.Close()
+		return nil, azureError(resp, name)
+	}
+}
+
+// Append implements Bucket.Append, creating name as an append blob when it
+// is missing.
+func (b *AzureBlobBucket) Append(ctx context.Context, name string, data []byte) (int64, error) {
+	size, err := b.appendBlock(ctx, name, data)
+	if !errors.Is(err, os.ErrNotExist) {
+		return size, err
+	}
+	header := http.Header{"x-ms-blob-type": {azureBlobTypeAppend}, "If-None-Match": {"*"}}
+	if err := b.expect(ctx, http.MethodPut, b.blobURL(name), name, header, nil, http.StatusCreated,
+		http.StatusConflict, http.StatusPreconditionFailed); err != nil {
+		return 0, err
+	}
+	return b.appendBlock(ctx, name, data)
+}
+
+// Put implements Bucket.Put, as a block blob.
+func (b *AzureBlobBucket) Put(ctx context.Context, name string, data []byte) error {
+	header := http.Header{"x-ms-blob-type": {azureBlobTypeBlock}}
+	return b.expect(ctx, http.MethodPut, b.blobURL(name), name, header, data, http.StatusCreated)
+}
+
+// Truncate implements Bucket.Truncate, replacing name with a new append
+// blob holding its first size bytes.
+func (b *AzureBlobBucket) Truncate(ctx context.Context, name string, size int64) error {
+	r, err := b.Read(ctx, name, 0)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	_ = r.Close()
+	if err != nil {
+		return err
+	}
+	header := http.Header{"x-ms-blob-type": {azureBlobTypeAppend}}
+	if err := b.expect(ctx, http.MethodPut, b.blobURL(name), name, header, nil, http.StatusCreated); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	_, err = b.appendBlock(ctx, name, data)
+	return err
+}
+
+// Delete implements Bucket.Delete.
+func (b *AzureBlobBucket) Delete(ctx context.Context, name string) error {
+	return b.expect(ctx, http.MethodDelete, b.blobURL(name), name, nil, nil, http.StatusAccepted)
+}
+
+// appendBlock appends data to the append blob name, returning its new size.
+func (b *AzureBlobBucket) appendBlock(ctx context.Context, name string, data []byte) (int64, error) {
+	resp, err := b.send(ctx, http.MethodPut, b.blobURL(name)+"?comp=appendblock", nil, data)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return 0, azureError(resp, name)
+	}
+	offset, err := strconv.ParseInt(resp.Header.Get("x-ms-blob-append-offset"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("reading append offset: %w", err)
+	}
+	return offset + int64(len(data)), nil
+}
+
+// expect sends a request about blob name and fails unless the response has
+// one of the expected statuses.
+func (b *AzureBlobBucket) expect(
+	ctx context.Context,
+	method, target, name string,
+	header http.Header,
+	body []byte,
+	statuses ...int,
+) error {
+	resp, err := b.send(ctx, method, target, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if !slices.Contains(statuses, resp.StatusCode) {
+		return azureError(resp, name)
+	}
+	return nil
+}
+
+// decodeXML decodes the XML body of a successful response into out.
+func (b *AzureBlobBucket) decodeXML(resp *http.Response, name string, out any) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return azureError(resp, name)
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// send sends an authorized request. The caller closes the response body.
+func (b *AzureBlobBucket) send(
+	ctx context.Context,
+	method, target string,
+	header http.Header,
+	body []byte,
+) (*http.Response, error) {
+	httpClient := orDefaultClient(b.HTTPClient)
+	if b.sasToken != "" {
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + b.sasToken
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+
+	switch {
+	case b.sasToken != "":
+	case b.key != nil:
+		req.Header.Set("Authorization", "SharedKey "+b.account+":"+b.sharedKeySignature(req))
+	default:
+		token, tokenErr := b.accessToken(ctx, httpClient)
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return httpClient.Do(req)
+}
+
+// accessToken returns the managed identity's storage token from the
+// instance metadata service, cached until shortly before it expires.
+func (b *AzureBlobBucket) accessToken(ctx context.Context, httpClient *http.Client) (string, error) {
+	tokenURL := b.MetadataTokenURL
+	if tokenURL == "" {
+		tokenURL = defaultAzureIMDSTokenURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating metadata token request: %w", err)
+	}
+	req.Header.Set("Metadata", "true")
+
+	token, err := b.metadataToken.get(httpClient, req)
+	if err != nil {
+		return "", fmt.Errorf(
+			"getting managed identity token (or set AZURE_STORAGE_SAS_TOKEN or AZURE_STORAGE_KEY): %w", err)
+	}
+	return token, nil
+}
+
+// sharedKeySignature signs req with the account key, as the Blob service's
+// Shared Key authorization scheme defines.
+func (b *AzureBlobBucket) sharedKeySignature(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	parts := []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date; x-ms-date is sent instead.
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}
+
+	var headers []string
+	for key, values := range req.Header {
+		if key = strings.ToLower(key); strings.HasPrefix(key, "x-ms-") {
+			headers = append(headers, key+":"+strings.Join(values, ","))
+		}
+	}
+	slices.Sort(headers)
+
+	resource := "/" + b.account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		values := slices.Clone(query[key])
+		slices.Sort(values)
+		resource += "\n" + strings.ToLower(key) + ":" + strings.Join(values, ",")
+	}
+
+	stringToSign := strings.Join(parts, "\n") + "\n" + strings.Join(headers, "\n") + "\n" + resource
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// azureError returns the error a failed response stands for, wrapping
+// os.ErrNotExist for a missing blob.
+func azureError(resp *http.Response, name string) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	if code := resp.Header.Get("x-ms-error-code"); code != "" {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, code)
+	}
+	return fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+func (b *AzureBlobBucket) containerURL() string {
+	endpoint := "https://" + b.account + ".blob.core.windows.net"
+	if b.Endpoint != "" {
+		endpoint = strings.TrimSuffix(b.Endpoint, "/")
+	}
+	return endpoint + "/" + url.PathEscape(b.container)
+}
+
+func (b *AzureBlobBucket) blobURL(name string) string {
+	return b.containerURL() + "/" + escapeBlobName(objectName(b.prefix, name))
+}
+
+// escapeBlobName escapes each segment of a blob name, keeping its slashes.
+func escapeBlobName(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,236 @@
+package sink
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// fakeAzureBlob is a blob held by fakeAzure.
+type fakeAzureBlob struct {
+	blobType string
+	data     []byte
+}
+
+// fakeAzure serves the parts of the Blob service REST API AzureBlobBucket
+// uses from memory, recording the authorization of each request.
+type fakeAzure struct {
+	t             *testing.T
+	mu            sync.Mutex
+	blobs         map[string]*fakeAzureBlob
+	authorization []string
+	signatures    []string
+}
+
+func newFakeAzure(t *testing.T) (*fakeAzure, *httptest.Server) {
+	fake := &fakeAzure{t: t, blobs: make(map[string]*fakeAzureBlob)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Equal(f.t, azureStorageVersion, r.Header.Get("x-ms-version"))
+	f.authorization = append(f.authorization, r.Header.Get("Authorization"))
+	f.signatures = append(f.signatures, r.URL.Query().Get("sig"))
+
+	query := r.URL.Query()
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/ctr"), "/")
+	blob, ok := f.blobs[name]
+	switch {
+	case query.Get("comp") == "list":
+		var list struct {
+			XMLName xml.Name `xml:"EnumerationResults"`
+			Blobs   struct {
+				Blob []struct {
+					Name string `xml:"Name"`
+				} `xml:"Blob"`
+			} `xml:"Blobs"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		for blobName := range f.blobs {
+			rest, found := strings.CutPrefix(blobName, query.Get("prefix"))
+			if found && !strings.Contains(rest, "/") {
+				list.Blobs.Blob = append(list.Blobs.Blob, struct {
+					Name string `xml:"Name"`
+				}{Name: blobName})
+			}
+		}
+		_ = xml.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodPut && query.Get("comp") == "appendblock":
+		if !ok || blob.blobType != azureBlobTypeAppend {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		w.Header().Set("x-ms-blob-append-offset", strconv.Itoa(len(blob.data)))
+		blob.data = append(blob.data, data...)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		if ok && r.Header.Get("If-None-Match") == "*" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		f.blobs[name] = &fakeAzureBlob{blobType: r.Header.Get("x-ms-blob-type"), data: data}
+		w.WriteHeader(http.StatusCreated)
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodDelete:
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodHead:
+		w.Header().Set("Content-Length", strconv.Itoa(len(blob.data)))
+		w.WriteHeader(http.StatusOK)
+	default:
+		offset := 0
+		if rangeHeader := r.Header.Get("x-ms-range"); rangeHeader != "" {
+			offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+		}
+		if offset >= len(blob.data) && offset > 0 {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		_, _ = w.Write(blob.data[offset:])
+	}
+}
+
+func newTestAzureBlobBucket(t *testing.T, prefix string) (*fakeAzure, *AzureBlobBucket) {
+	fake, server := newFakeAzure(t)
+	bucket, err := NewAzureBlobBucket("acct", "ctr", prefix)
+	require.NoError(t, err)
+	bucket.Endpoint = server.URL
+	return fake, bucket
+}
+
+func TestAzureBlobBucket_File(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2021-08-06&sig=test-sig")
+	fake, bucket := newTestAzureBlobBucket(t, "vantage/prod")
+	ctx := context.Background()
+
+	s, err := NewFileWithBucket(bucket, FileOptions{Manifest: true})
+	require.NoError(t, err)
+	assert.Equal(t, "azblob://ctr/vantage/prod", s.Dir())
+	require.NoError(t, s.Check(ctx))
+
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{LineItemID: "a"}}))
+	checkpoint, err := s.Checkpoint(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{LineItemID: "b"}}))
+
+	require.Contains(t, fake.blobs, "vantage/prod/"+RecordsFileName)
+	assert.Equal(t, azureBlobTypeAppend, fake.blobs["vantage/prod/"+RecordsFileName].blobType)
+	require.Contains(t, fake.blobs, "vantage/prod/"+ManifestFileName)
+	assert.Equal(t, azureBlobTypeBlock, fake.blobs["vantage/prod/"+ManifestFileName].blobType)
+
+	var read []string
+	require.NoError(t, s.ReadRecords(ctx, func(record adapter.CostRecord) error {
+		read = append(read, record.LineItemID)
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "b"}, read)
+
+	written, err := s.RecordsSince(ctx, checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"b": 1}, written)
+
+	for i := range fake.signatures {
+		assert.Equal(t, "test-sig", fake.signatures[i], "every request carries the SAS token")
+		assert.Empty(t, fake.authorization[i])
+	}
+}
+
+func TestAzureBlobBucket_Truncate(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "sig=test-sig")
+	fake, bucket := newTestAzureBlobBucket(t, "")
+	ctx := context.Background()
+
+	size, err := bucket.Append(ctx, "part", []byte("abcdef"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), size)
+	require.NoError(t, bucket.Truncate(ctx, "part", 2))
+	assert.Equal(t, "ab", string(fake.blobs["part"].data))
+
+	size, err = bucket.Append(ctx, "part", []byte("cd"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), size, "appends continue after a truncate")
+
+	size, err = bucket.Size(ctx, "part")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), size)
+}
+
+func TestAzureBlobBucket_Missing(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "sig=test-sig")
+	_, bucket := newTestAzureBlobBucket(t, "")
+	ctx := context.Background()
+
+	_, err := bucket.Size(ctx, "missing")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = bucket.Read(ctx, "missing", 0)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.ErrorIs(t, bucket.Delete(ctx, "missing"), os.ErrNotExist)
+}
+
+func TestAzureBlobBucket_SharedKey(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+	t.Setenv("AZURE_STORAGE_KEY", "a2V5")
+	fake, bucket := newTestAzureBlobBucket(t, "")
+
+	require.NoError(t, bucket.Put(context.Background(), "probe", []byte("x")))
+	require.Len(t, fake.authorization, 1)
+	assert.True(t, strings.HasPrefix(fake.authorization[0], "SharedKey acct:"), fake.authorization[0])
+
+	req, err := http.NewRequest(http.MethodGet, "https://acct.blob.core.windows.net/ctr?restype=container&comp=list", nil)
+	require.NoError(t, err)
+	req.Header.Set("x-ms-date", "Fri, 16 Oct 2026 00:00:00 GMT")
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	// The string to sign for this request is
+	//   GET\n\n\n\n\n\n\n\n\n\n\n\nx-ms-date:...\nx-ms-version:...\n/acct/ctr\ncomp:list\nrestype:container
+	// signed with the key "key".
+	assert.Equal(t, "oJGkOAn1z3auyP6mKCEjpQ6gbP4KJYRoNJWRz9d4tbI=", bucket.sharedKeySignature(req))
+}
+
+func TestAzureBlobBucket_ManagedIdentity(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+	t.Setenv("AZURE_STORAGE_KEY", "")
+	var tokenRequests int
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		tokenRequests++
+		_, _ = w.Write([]byte(`{"access_token":"test-token","expires_in":"3599"}`))
+	}))
+	t.Cleanup(metadataServer.Close)
+	fake, bucket := newTestAzureBlobBucket(t, "")
+	bucket.MetadataTokenURL = metadataServer.URL
+
+	for range 2 {
+		require.NoError(t, bucket.Put(context.Background(), "probe", nil))
+	}
+	assert.Equal(t, 1, tokenRequests, "the token is cached until it expires")
+	assert.Equal(t, []string{"Bearer test-token", "Bearer test-token"}, fake.authorization)
+}
+
+func TestNewAzureBlobBucket_Errors(t *testing.T) {
+	_, err := NewAzureBlobBucket("", "ctr", "")
+	require.ErrorContains(t, err, "AZURE_STORAGE_ACCOUNT")
+
+	t.Setenv("AZURE_STORAGE_KEY", "not base64!")
+	_, err = NewAzureBlobBucket("acct", "ctr", "")
+	require.ErrorContains(t, err, "decoding AZURE_STORAGE_KEY")
+}
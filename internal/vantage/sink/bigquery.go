@@ -0,0 +1,252 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// BigQuery column types of the record columns.
+var bigQueryTypes = map[columnType]string{
+	columnString:    "STRING",
+	columnFloat:     "FLOAT64",
+	columnInt:       "INT64",
+	columnTimestamp: "TIMESTAMP",
+	columnJSON:      "JSON",
+}
+
+// BigQuery tables are partitioned by day on the record timestamp and
+// clustered by the columns cost queries most often filter on.
+const bigQueryPartitionColumn = "timestamp"
+
+var bigQueryClusterColumns = []string{"provider", "service"}
+
+// validTableName restricts table names to plain identifiers, since they
+// are interpolated into SQL statements.
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// BigQueryField is a column of a BigQuery table.
+type BigQueryField struct {
+	Name string
+	Type string // STRING, FLOAT64, INT64, TIMESTAMP, or JSON
+}
+
+// BigQueryTable describes a table for BigQueryAPI.CreateTable.
+type BigQueryTable struct {
+	Name   string
+	Schema []BigQueryField
+	// PartitionColumn, when set, partitions the table by day on that
+	// TIMESTAMP column, and ClusterColumns clusters it.
+	PartitionColumn string
+	ClusterColumns  []string
+}
+
+// BigQueryAPI is the subset of BigQuery used by the sink. Tables are named
+// within the client's dataset.
+type BigQueryAPI interface {
+	// CreateTable creates a table, doing nothing when it already exists.
+	CreateTable(ctx context.Context, table BigQueryTable) error
+	// AppendRows appends rows of values in schema order to table through
+	// the Storage Write API, returning the errors of rows not appended by
+	// row index. BigQuery refuses a whole append when it rejects any of its
+	// rows: rejected rows fail with adapter.ErrRecordRejected, and the
+	// others with a retryable error.
+	AppendRows(ctx context.Context, table string, schema []BigQueryField, rows [][]any) (map[int]error, error)
+	// Query runs a GoogleSQL statement and waits for it to finish.
+	Query(ctx context.Context, sql string) error
+}
+
+// BigQuery is a Sink that writes records as rows of a BigQuery table,
+// created on first write, with one column per record field. By default
+// records are appended, keeping every version as the file sink does. In
+// upsert mode each batch is appended to a <table>_staging table and merged
+// into the table, so a restated record replaces the one it restates and a
+// deletion tombstone removes its record: the table holds the current view
+// that export.Reconcile derives from an append-only log.
+type BigQuery struct {
+	api     BigQueryAPI
+	table   string
+	staging string
+	upsert  bool
+	schema  []BigQueryField
+	merge   string
+
+	// mu guards created, and serializes upserts, which share the staging
+	// table.
+	mu      sync.Mutex
+	created bool
+}
+
+// NewBigQuery creates a sink writing to table through api.
+func NewBigQuery(api BigQueryAPI, table string, upsert bool) (*BigQuery, error) {
+	if api == nil {
+		return nil, errors.New("bigquery client cannot be nil")
+	}
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid bigquery table name: %q", table)
+	}
+
+	schema := make([]BigQueryField, len(recordColumns))
+	for i, col := range recordColumns {
+		schema[i] = BigQueryField{Name: col.name, Type: bigQueryTypes[col.typ]}
+	}
+	b := &BigQuery{api: api, table: table, staging: table + "_staging", upsert: upsert, schema: schema}
+	b.merge = b.mergeStatement()
+	return b, nil
+}
+
+// WriteRecords implements adapter.Sink. Records that cannot be encoded, and
+// rows BigQuery rejects, are rejected in an adapter.PartialWriteError.
+func (b *BigQuery) WriteRecords(ctx context.Context, records []adapter.CostRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := b.createTables(ctx); err != nil {
+		return err
+	}
+
+	failed := make(map[int]error)
+	rows := make([][]any, 0, len(records))
+	owners := make([][]int, 0, len(records)) // indexes of the records each row writes
+	byID := make(map[string]int)
+	for i := range records {
+		values, err := rowValues(&records[i])
+		if err != nil {
+			failed[i] = fmt.Errorf("%w: encoding record: %w", adapter.ErrRecordRejected, err)
+			continue
+		}
+		// A merge takes one row per LineItemID, so in upsert mode a later
+		// record replaces an earlier one in the same batch.
+		if row, ok := byID[records[i].LineItemID]; ok && b.upsert {
+			rows[row] = values
+			owners[row] = append(owners[row], i)
+			continue
+		}
+		byID[records[i].LineItemID] = len(rows)
+		rows = append(rows, values)
+		owners = append(owners, []int{i})
+	}
+
+	if len(rows) > 0 {
+		rowErrors, err := b.writeRows(ctx, rows)
+		if err != nil {
+			return err
+		}
+		for row, rowErr := range rowErrors {
+			for _, i := range owners[row] {
+				failed[i] = rowErr
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return &adapter.PartialWriteError{Failed: failed}
+	}
+	return nil
+}
+
+// Check implements preflight.SinkChecker by creating the sink's tables,
+// which verifies the credentials can reach and write to the dataset.
+func (b *BigQuery) Check(ctx context.Context) error {
+	return b.createTables(ctx)
+}
+
+// writeRows appends rows to the table, or in upsert mode merges them into
+// it, returning the errors of rows not written.
+func (b *BigQuery) writeRows(ctx context.Context, rows [][]any) (map[int]error, error) {
+	if !b.upsert {
+		rowErrors, err := b.api.AppendRows(ctx, b.table, b.schema, rows)
+		if err != nil {
+			return nil, fmt.Errorf("appending rows: %w", err)
+		}
+		return rowErrors, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Rows a failed upsert left in the staging table are cleared first;
+	// the adapter writes that batch again.
+	if err := b.api.Query(ctx, fmt.Sprintf("DELETE FROM `%s` WHERE TRUE", b.staging)); err != nil {
+		return nil, fmt.Errorf("clearing staging table: %w", err)
+	}
+	rowErrors, err := b.api.AppendRows(ctx, b.staging, b.schema, rows)
+	if err != nil {
+		return nil, fmt.Errorf("appending rows to staging table: %w", err)
+	}
+	if len(rowErrors) > 0 {
+		return rowErrors, nil
+	}
+	if mergeErr := b.api.Query(ctx, b.merge); mergeErr != nil {
+		return nil, fmt.Errorf("merging staged rows: %w", mergeErr)
+	}
+	return nil, nil
+}
+
+// createTables creates the table, and in upsert mode the staging table,
+// once per sink.
+func (b *BigQuery) createTables(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.created {
+		return nil
+	}
+	if err := b.api.CreateTable(ctx, BigQueryTable{
+		Name:            b.table,
+		Schema:          b.schema,
+		PartitionColumn: bigQueryPartitionColumn,
+		ClusterColumns:  bigQueryClusterColumns,
+	}); err != nil {
+		return fmt.Errorf("creating table %s: %w", b.table, err)
+	}
+	if b.upsert {
+		if err := b.api.CreateTable(ctx, BigQueryTable{Name: b.staging, Schema: b.schema}); err != nil {
+			return fmt.Errorf("creating table %s: %w", b.staging, err)
+		}
+	}
+	b.created = true
+	return nil
+}
+
+// mergeStatement builds the MERGE that applies the staged rows as
+// export.Reconcile does: each row replaces the row with its line_item_id
+// and deletes the row it restates, and a deletion tombstone deletes its
+// row. Each staged row is joined once on its own line_item_id and once,
+// when it restates another, on the restated one.
+func (b *BigQuery) mergeStatement() string {
+	names := make([]string, len(b.schema))
+	sets := make([]string, len(b.schema))
+	values := make([]string, len(b.schema))
+	for i, field := range b.schema {
+		names[i] = "`" + field.Name + "`"
+		sets[i] = fmt.Sprintf("`%s` = S.`%s`", field.Name, field.Name)
+		values[i] = "S.`" + field.Name + "`"
+	}
+	deletion := fmt.Sprintf("S.metric_type = '%s'", adapter.MetricTypeDeletion)
+
+	return fmt.Sprintf(`MERGE `+"`%s`"+` T
+USING (
+  SELECT * FROM (
+    SELECT line_item_id AS merge_key, * FROM `+"`%s`"+`
+    UNION ALL
+    SELECT restates_line_item_id AS merge_key, * FROM `+"`%s`"+`
+    WHERE restates_line_item_id IS NOT NULL AND restates_line_item_id != line_item_id
+  )
+  WHERE TRUE
+  QUALIFY ROW_NUMBER() OVER (PARTITION BY merge_key ORDER BY merge_key = line_item_id DESC) = 1
+) S
+ON T.line_item_id = S.merge_key
+WHEN MATCHED AND (S.merge_key != S.line_item_id OR %s) THEN DELETE
+WHEN MATCHED THEN UPDATE SET %s
+WHEN NOT MATCHED AND S.merge_key = S.line_item_id AND NOT IFNULL(%s, FALSE) THEN
+  INSERT (%s) VALUES (%s)`,
+		b.table, b.staging, b.staging, deletion,
+		strings.Join(sets, ", "), deletion,
+		strings.Join(names, ", "), strings.Join(values, ", "))
+}
@@ -0,0 +1,394 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	defaultBigQueryEndpoint        = "https://bigquery.googleapis.com"
+	defaultBigQueryStorageEndpoint = "bigquerystorage.googleapis.com:443"
+	defaultGCPMetadataTokenURL     = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// maxAppendBytes keeps each AppendRows request under BigQuery's 10MB
+	// limit.
+	maxAppendBytes = 8 << 20
+
+	// queryWaitMillis is how long each call waits for a query to finish.
+	queryWaitMillis = 10000
+
+	maxResponseBytes = 1 << 20
+)
+
+// errRowNotAppended fails the rows of an append BigQuery refused because
+// other rows in it were invalid; they are written again on retry.
+var errRowNotAppended = errors.New("not appended: another row in the append was invalid")
+
+// BigQueryClient implements BigQueryAPI over the BigQuery REST API, for
+// tables and queries, and the Storage Write API's default stream, for rows.
+// It authenticates with GOOGLE_OAUTH_ACCESS_TOKEN when set, and otherwise
+// with the metadata server's default service account.
+type BigQueryClient struct {
+	project string
+	dataset string
+
+	// Endpoint and MetadataTokenURL override the REST API and metadata
+	// server token URLs.
+	Endpoint         string
+	MetadataTokenURL string
+	HTTPClient       *http.Client
+
+	conn  *grpc.ClientConn
+	write storagepb.BigQueryWriteClient
+
+	metadataToken metadataToken
+}
+
+// NewBigQueryClient creates a client for dataset in project. Close it when
+// done.
+func NewBigQueryClient(project, dataset string) (*BigQueryClient, error) {
+	if project == "" || dataset == "" {
+		return nil, errors.New("bigquery project and dataset cannot be empty")
+	}
+	c := &BigQueryClient{project: project, dataset: dataset}
+	conn, err := grpc.NewClient(
+		"dns:///"+defaultBigQueryStorageEndpoint,
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})),
+		grpc.WithPerRPCCredentials(tokenCredentials{c}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the storage write api: %w", err)
+	}
+	c.conn = conn
+	c.write = storagepb.NewBigQueryWriteClient(conn)
+	return c, nil
+}
+
+// Close closes the Storage Write API connection.
+func (c *BigQueryClient) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// CreateTable implements BigQueryAPI.
+func (c *BigQueryClient) CreateTable(ctx context.Context, table BigQueryTable) error {
+	type field struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		Mode string `json:"mode"`
+	}
+	body := map[string]any{
+		"tableReference": map[string]string{"projectId": c.project, "datasetId": c.dataset, "tableId": table.Name},
+	}
+	fields := make([]field, len(table.Schema))
+	for i, f := range table.Schema {
+		fields[i] = field{Name: f.Name, Type: f.Type, Mode: "NULLABLE"}
+	}
+	body["schema"] = map[string]any{"fields": fields}
+	if table.PartitionColumn != "" {
+		body["timePartitioning"] = map[string]string{"type": "DAY", "field": table.PartitionColumn}
+	}
+	if len(table.ClusterColumns) > 0 {
+		body["clustering"] = map[string]any{"fields": table.ClusterColumns}
+	}
+
+	status, err := c.do(ctx, http.MethodPost, c.datasetURL()+"/tables", body, nil)
+	if status == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+// Query implements BigQueryAPI.
+func (c *BigQueryClient) Query(ctx context.Context, sql string) error {
+	body := map[string]any{
+		"query":          sql,
+		"useLegacySql":   false,
+		"timeoutMs":      queryWaitMillis,
+		"defaultDataset": map[string]string{"projectId": c.project, "datasetId": c.dataset},
+	}
+	var resp struct {
+		JobComplete  bool `json:"jobComplete"`
+		JobReference struct {
+			JobID    string `json:"jobId"`
+			Location string `json:"location"`
+		} `json:"jobReference"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, c.projectURL()+"/queries", body, &resp); err != nil {
+		return err
+	}
+
+	for !resp.JobComplete {
+		query := url.Values{
+			"location":   {resp.JobReference.Location},
+			"timeoutMs":  {fmt.Sprint(queryWaitMillis)},
+			"maxResults": {"0"},
+		}
+		resultsURL := c.projectURL() + "/queries/" + url.PathEscape(resp.JobReference.JobID) + "?" + query.Encode()
+		if _, err := c.do(ctx, http.MethodGet, resultsURL, nil, &resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendRows implements BigQueryAPI. Rows are sent in appends of at most
+// 8MB; BigQuery appends all the rows of an append or none.
+func (c *BigQueryClient) AppendRows(
+	ctx context.Context,
+	table string,
+	schema []BigQueryField,
+	rows [][]any,
+) (map[int]error, error) {
+	descriptor, err := rowDescriptor(schema)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := encodeRows(descriptor, schema, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := fmt.Sprintf("projects/%s/datasets/%s/tables/%s/streams/_default", c.project, c.dataset, table)
+	failed := make(map[int]error)
+	for start := 0; start < len(encoded); {
+		end, size := start, 0
+		for end < len(encoded) && (end == start || size+len(encoded[end]) <= maxAppendBytes) {
+			size += len(encoded[end])
+			end++
+		}
+		rowErrors, appendErr := c.appendRows(ctx, stream, protodesc.ToDescriptorProto(descriptor), encoded[start:end])
+		if appendErr != nil {
+			return nil, appendErr
+		}
+		for i := start; i < end && len(rowErrors) > 0; i++ {
+			failed[i] = errRowNotAppended
+			if msg, ok := rowErrors[i-start]; ok {
+				failed[i] = fmt.Errorf("%w: %s", adapter.ErrRecordRejected, msg)
+			}
+		}
+		start = end
+	}
+	return failed, nil
+}
+
+// appendRows sends one append to stream, returning the messages of the
+// rows BigQuery rejected.
+func (c *BigQueryClient) appendRows(
+	ctx context.Context,
+	stream string,
+	descriptor *descriptorpb.DescriptorProto,
+	rows [][]byte,
+) (map[int]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-goog-request-params", "write_stream="+url.QueryEscape(stream))
+
+	client, err := c.write.AppendRows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("opening append stream: %w", err)
+	}
+	if sendErr := client.Send(&storagepb.AppendRowsRequest{
+		WriteStream: stream,
+		Rows: &storagepb.AppendRowsRequest_ProtoRows{ProtoRows: &storagepb.AppendRowsRequest_ProtoData{
+			WriterSchema: &storagepb.ProtoSchema{ProtoDescriptor: descriptor},
+			Rows:         &storagepb.ProtoRows{SerializedRows: rows},
+		}},
+	}); sendErr != nil {
+		return nil, fmt.Errorf("sending rows: %w", sendErr)
+	}
+	if closeErr := client.CloseSend(); closeErr != nil {
+		return nil, fmt.Errorf("sending rows: %w", closeErr)
+	}
+	resp, err := client.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("appending rows: %w", err)
+	}
+
+	if rowErrors := resp.GetRowErrors(); len(rowErrors) > 0 {
+		messages := make(map[int]string, len(rowErrors))
+		for _, rowErr := range rowErrors {
+			messages[int(rowErr.GetIndex())] = rowErr.GetMessage()
+		}
+		return messages, nil
+	}
+	if status := resp.GetError(); status != nil {
+		return nil, fmt.Errorf("appending rows: %s", status.GetMessage())
+	}
+	return nil, nil
+}
+
+// rowDescriptor builds the protobuf message rows of schema are encoded as.
+// Fields are optional, so a missing value appends NULL.
+func rowDescriptor(schema []BigQueryField) (protoreflect.MessageDescriptor, error) {
+	message := &descriptorpb.DescriptorProto{Name: proto.String("Row")}
+	for i, field := range schema {
+		typ := descriptorpb.FieldDescriptorProto_TYPE_STRING
+		switch field.Type {
+		case "FLOAT64":
+			typ = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+		case "INT64", "TIMESTAMP":
+			// TIMESTAMP values are microseconds since the epoch.
+			typ = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		}
+		message.Field = append(message.Field, &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(field.Name),
+			Number: proto.Int32(int32(i + 1)), //nolint:gosec // schemas have a few dozen columns
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   typ.Enum(),
+		})
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("row.proto"),
+		Syntax:      proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{message},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building row descriptor: %w", err)
+	}
+	return file.Messages().Get(0), nil
+}
+
+// encodeRows serializes rows of rowValues values as descriptor messages.
+func encodeRows(descriptor protoreflect.MessageDescriptor, schema []BigQueryField, rows [][]any) ([][]byte, error) {
+	fields := descriptor.Fields()
+	encoded := make([][]byte, len(rows))
+	for i, row := range rows {
+		message := dynamicpb.NewMessage(descriptor)
+		for j, value := range row {
+			var v protoreflect.Value
+			switch value := value.(type) {
+			case nil:
+				continue
+			case string:
+				v = protoreflect.ValueOfString(value)
+			case float64:
+				v = protoreflect.ValueOfFloat64(value)
+			case int64:
+				v = protoreflect.ValueOfInt64(value)
+			case time.Time:
+				v = protoreflect.ValueOfInt64(value.UnixMicro())
+			default:
+				return nil, fmt.Errorf("column %s: unsupported value type %T", schema[j].Name, value)
+			}
+			message.Set(fields.Get(j), v)
+		}
+		data, err := proto.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("encoding row %d: %w", i, err)
+		}
+		encoded[i] = data
+	}
+	return encoded, nil
+}
+
+func (c *BigQueryClient) projectURL() string {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = defaultBigQueryEndpoint
+	}
+	return endpoint + "/bigquery/v2/projects/" + url.PathEscape(c.project)
+}
+
+func (c *BigQueryClient) datasetURL() string {
+	return c.projectURL() + "/datasets/" + url.PathEscape(c.dataset)
+}
+
+// do sends a REST request with a JSON body, decoding the response into out
+// when set. It returns the response status alongside any error.
+func (c *BigQueryClient) do(ctx context.Context, method, target string, body, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("encoding request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := orDefaultClient(c.HTTPClient).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if out != nil {
+		if decodeErr := json.Unmarshal(data, out); decodeErr != nil {
+			return resp.StatusCode, fmt.Errorf("decoding response: %w", decodeErr)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// accessToken returns the OAuth2 token requests are sent with, caching a
+// metadata server token until shortly before it expires.
+func (c *BigQueryClient) accessToken(ctx context.Context) (string, error) {
+	return gcpAccessToken(ctx, &c.metadataToken, c.MetadataTokenURL, orDefaultClient(c.HTTPClient))
+}
+
+// tokenCredentials sends the client's access token with each Storage Write
+// API call.
+type tokenCredentials struct {
+	client *BigQueryClient
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := t.client.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
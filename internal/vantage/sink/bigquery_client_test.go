@@ -0,0 +1,251 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+var _ BigQueryAPI = (*BigQueryClient)(nil)
+
+// fakeWriteServer is a Storage Write API server that rejects rows whose
+// line_item_id is in reject.
+type fakeWriteServer struct {
+	storagepb.UnimplementedBigQueryWriteServer
+
+	reject   map[string]bool
+	requests []*storagepb.AppendRowsRequest
+	routing  []string
+}
+
+func (s *fakeWriteServer) AppendRows(stream storagepb.BigQueryWrite_AppendRowsServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	s.routing = append(s.routing, md.Get("x-goog-request-params")...)
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	s.requests = append(s.requests, req)
+
+	data := req.GetProtoRows()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("row.proto"),
+		Syntax:      proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{data.GetWriterSchema().GetProtoDescriptor()},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	descriptor := file.Messages().Get(0)
+	resp := &storagepb.AppendRowsResponse{}
+	for i, row := range data.GetRows().GetSerializedRows() {
+		message := dynamicpb.NewMessage(descriptor)
+		if unmarshalErr := proto.Unmarshal(row, message); unmarshalErr != nil {
+			return unmarshalErr
+		}
+		id := message.Get(descriptor.Fields().ByName("line_item_id")).String()
+		if s.reject[id] {
+			resp.RowErrors = append(resp.RowErrors, &storagepb.RowError{Index: int64(i), Message: "invalid value"})
+		}
+	}
+	return stream.Send(resp)
+}
+
+func newTestBigQueryClient(t *testing.T, server *fakeWriteServer, handler http.Handler) *BigQueryClient {
+	t.Helper()
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "test-token")
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	storagepb.RegisterBigQueryWriteServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	c := &BigQueryClient{project: "proj", dataset: "finops", conn: conn, write: storagepb.NewBigQueryWriteClient(conn)}
+	t.Cleanup(func() { _ = c.Close() })
+	if handler != nil {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+		c.Endpoint = srv.URL
+	}
+	return c
+}
+
+func TestBigQueryClient_AppendRows(t *testing.T) {
+	server := &fakeWriteServer{reject: map[string]bool{"b": true}}
+	c := newTestBigQueryClient(t, server, nil)
+	schema := []BigQueryField{
+		{Name: "timestamp", Type: "TIMESTAMP"},
+		{Name: "line_item_id", Type: "STRING"},
+		{Name: "net_cost", Type: "FLOAT64"},
+	}
+	ts := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	failed, err := c.AppendRows(context.Background(), "costs", schema, [][]any{{ts, "a", 1.5}, {ts, "c", nil}})
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+
+	require.Len(t, server.requests, 1)
+	req := server.requests[0]
+	assert.Equal(t, "projects/proj/datasets/finops/tables/costs/streams/_default", req.GetWriteStream())
+	assert.Equal(t, []string{"write_stream=projects%2Fproj%2Fdatasets%2Ffinops%2Ftables%2Fcosts%2Fstreams%2F_default"},
+		server.routing)
+	assert.Len(t, req.GetProtoRows().GetRows().GetSerializedRows(), 2)
+
+	failed, err = c.AppendRows(context.Background(), "costs", schema, [][]any{{ts, "a", 1.5}, {ts, "b", 2.0}})
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+	assert.ErrorIs(t, failed[1], adapter.ErrRecordRejected)
+	assert.Contains(t, failed[1].Error(), "invalid value")
+	assert.ErrorIs(t, failed[0], errRowNotAppended)
+}
+
+func TestEncodeRows(t *testing.T) {
+	schema := []BigQueryField{
+		{Name: "timestamp", Type: "TIMESTAMP"},
+		{Name: "provider", Type: "STRING"},
+		{Name: "net_cost", Type: "FLOAT64"},
+		{Name: "schema_version", Type: "INT64"},
+		{Name: "labels", Type: "JSON"},
+	}
+	descriptor, err := rowDescriptor(schema)
+	require.NoError(t, err)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	encoded, err := encodeRows(descriptor, schema, [][]any{{ts, "aws", 1.5, int64(3), `{"team":"x"}`}, {nil, nil, nil, int64(0), nil}})
+	require.NoError(t, err)
+	require.Len(t, encoded, 2)
+
+	message := dynamicpb.NewMessage(descriptor)
+	require.NoError(t, proto.Unmarshal(encoded[0], message))
+	fields := descriptor.Fields()
+	assert.Equal(t, ts.UnixMicro(), message.Get(fields.ByName("timestamp")).Int())
+	assert.Equal(t, "aws", message.Get(fields.ByName("provider")).String())
+	assert.InDelta(t, 1.5, message.Get(fields.ByName("net_cost")).Float(), 0)
+	assert.Equal(t, `{"team":"x"}`, message.Get(fields.ByName("labels")).String())
+
+	message = dynamicpb.NewMessage(descriptor)
+	require.NoError(t, proto.Unmarshal(encoded[1], message))
+	assert.False(t, message.Has(fields.ByName("provider")), "nil values are left unset, appending NULL")
+
+	_, err = encodeRows(descriptor, schema, [][]any{{true}})
+	require.Error(t, err)
+}
+
+func TestBigQueryClient_CreateTable(t *testing.T) {
+	var bodies []map[string]any
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bigquery/v2/projects/proj/datasets/finops/tables", r.URL.Path)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		if len(bodies) > 1 {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":{"message":"Already Exists"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	c := newTestBigQueryClient(t, &fakeWriteServer{}, handler)
+	table := BigQueryTable{
+		Name:            "costs",
+		Schema:          []BigQueryField{{Name: "timestamp", Type: "TIMESTAMP"}},
+		PartitionColumn: "timestamp",
+		ClusterColumns:  []string{"provider", "service"},
+	}
+
+	require.NoError(t, c.CreateTable(context.Background(), table))
+	require.NoError(t, c.CreateTable(context.Background(), table), "an existing table is not an error")
+	require.Len(t, bodies, 2)
+	assert.Equal(t, map[string]any{"type": "DAY", "field": "timestamp"}, bodies[0]["timePartitioning"])
+	assert.Equal(t, map[string]any{"fields": []any{"provider", "service"}}, bodies[0]["clustering"])
+	assert.Equal(t, map[string]any{"projectId": "proj", "datasetId": "finops", "tableId": "costs"}, bodies[0]["tableReference"])
+}
+
+func TestBigQueryClient_Query(t *testing.T) {
+	var polls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/bigquery/v2/projects/proj/queries":
+			var body map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "DELETE FROM `t` WHERE TRUE", body["query"])
+			assert.Equal(t, false, body["useLegacySql"])
+			_, _ = w.Write([]byte(`{"jobComplete":false,"jobReference":{"jobId":"job-1","location":"US"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/bigquery/v2/projects/proj/queries/job-1":
+			assert.Equal(t, "US", r.URL.Query().Get("location"))
+			polls++
+			_, _ = w.Write([]byte(`{"jobComplete":true}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	c := newTestBigQueryClient(t, &fakeWriteServer{}, handler)
+
+	require.NoError(t, c.Query(context.Background(), "DELETE FROM `t` WHERE TRUE"))
+	assert.Equal(t, 1, polls)
+}
+
+func TestBigQueryClient_QueryError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"Syntax error"}}`))
+	})
+	c := newTestBigQueryClient(t, &fakeWriteServer{}, handler)
+
+	err := c.Query(context.Background(), "MERGE")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 400: Syntax error")
+}
+
+func TestBigQueryClient_MetadataToken(t *testing.T) {
+	var tokenRequests int
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		tokenRequests++
+		_, _ = w.Write([]byte(`{"access_token":"metadata-token","expires_in":3600}`))
+	}))
+	t.Cleanup(metadataServer.Close)
+	c := &BigQueryClient{MetadataTokenURL: metadataServer.URL}
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+	for range 2 {
+		token, err := c.accessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "metadata-token", token)
+	}
+	assert.Equal(t, 1, tokenRequests, "the token is cached until it expires")
+}
+
+func TestNewBigQueryClient_RequiresDataset(t *testing.T) {
+	_, err := NewBigQueryClient("proj", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,221 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+var _ adapter.Sink = (*BigQuery)(nil)
+
+// fakeBigQuery records the calls the sink makes, failing appended rows
+// whose line_item_id is in reject.
+type fakeBigQuery struct {
+	tables   []BigQueryTable
+	queries  []string
+	appends  map[string][][]any
+	reject   map[string]bool
+	queryErr error
+}
+
+func (f *fakeBigQuery) CreateTable(_ context.Context, table BigQueryTable) error {
+	f.tables = append(f.tables, table)
+	return nil
+}
+
+func (f *fakeBigQuery) AppendRows(_ context.Context, table string, schema []BigQueryField, rows [][]any) (map[int]error, error) {
+	id := columnIndex(schema, "line_item_id")
+	failed := make(map[int]error)
+	for i, row := range rows {
+		if f.reject[row[id].(string)] {
+			failed[i] = errors.Join(adapter.ErrRecordRejected, errors.New("invalid row"))
+		}
+	}
+	if len(failed) > 0 {
+		for i := range rows {
+			if failed[i] == nil {
+				failed[i] = errRowNotAppended
+			}
+		}
+		return failed, nil
+	}
+	if f.appends == nil {
+		f.appends = make(map[string][][]any)
+	}
+	f.appends[table] = append(f.appends[table], rows...)
+	return nil, nil
+}
+
+func (f *fakeBigQuery) Query(_ context.Context, sql string) error {
+	f.queries = append(f.queries, sql)
+	return f.queryErr
+}
+
+func columnIndex(schema []BigQueryField, name string) int {
+	for i, field := range schema {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func bigQueryRecords(ids ...string) []adapter.CostRecord {
+	records := make([]adapter.CostRecord, len(ids))
+	for i, id := range ids {
+		cost := float64(i + 1)
+		records[i] = adapter.CostRecord{
+			Timestamp:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Provider:   "aws",
+			Service:    "EC2",
+			NetCost:    &cost,
+			LineItemID: id,
+		}
+	}
+	return records
+}
+
+func TestBigQuery_WriteRecordsAppends(t *testing.T) {
+	api := &fakeBigQuery{}
+	b, err := NewBigQuery(api, "costs", false)
+	require.NoError(t, err)
+
+	require.NoError(t, b.WriteRecords(context.Background(), bigQueryRecords("a", "b")))
+	require.NoError(t, b.WriteRecords(context.Background(), bigQueryRecords("c")))
+
+	require.Len(t, api.tables, 1, "the table is created once")
+	table := api.tables[0]
+	assert.Equal(t, "costs", table.Name)
+	assert.Equal(t, "timestamp", table.PartitionColumn)
+	assert.Equal(t, []string{"provider", "service"}, table.ClusterColumns)
+	assert.Contains(t, table.Schema, BigQueryField{Name: "timestamp", Type: "TIMESTAMP"})
+	assert.Contains(t, table.Schema, BigQueryField{Name: "net_cost", Type: "FLOAT64"})
+	assert.Contains(t, table.Schema, BigQueryField{Name: "labels", Type: "JSON"})
+
+	rows := api.appends["costs"]
+	require.Len(t, rows, 3)
+	id := columnIndex(table.Schema, "line_item_id")
+	assert.Equal(t, "c", rows[2][id])
+	assert.Empty(t, api.queries, "appends run no queries")
+}
+
+func TestBigQuery_WriteRecordsRejectsRows(t *testing.T) {
+	api := &fakeBigQuery{reject: map[string]bool{"b": true}}
+	b, err := NewBigQuery(api, "costs", false)
+	require.NoError(t, err)
+
+	records := bigQueryRecords("a", "b", "c")
+	err = b.WriteRecords(context.Background(), records)
+	var partial *adapter.PartialWriteError
+	require.ErrorAs(t, err, &partial)
+	require.Len(t, partial.Failed, 3)
+	assert.ErrorIs(t, partial.Failed[1], adapter.ErrRecordRejected)
+	assert.ErrorIs(t, partial.Failed[0], errRowNotAppended)
+	assert.NotErrorIs(t, partial.Failed[0], adapter.ErrRecordRejected)
+	assert.ErrorIs(t, partial.Failed[2], errRowNotAppended)
+	assert.Empty(t, api.appends)
+
+	// Retrying the rows that were not appended writes them.
+	require.NoError(t, b.WriteRecords(context.Background(), []adapter.CostRecord{records[0], records[2]}))
+	assert.Len(t, api.appends["costs"], 2)
+}
+
+func TestBigQuery_WriteRecordsUpserts(t *testing.T) {
+	api := &fakeBigQuery{}
+	b, err := NewBigQuery(api, "costs", true)
+	require.NoError(t, err)
+
+	records := bigQueryRecords("a", "b", "a")
+	require.NoError(t, b.WriteRecords(context.Background(), records))
+
+	require.Len(t, api.tables, 2)
+	assert.Equal(t, "costs_staging", api.tables[1].Name)
+	assert.Empty(t, api.tables[1].PartitionColumn)
+
+	rows := api.appends["costs_staging"]
+	require.Len(t, rows, 2, "a later record replaces an earlier one with its LineItemID")
+	cost := columnIndex(api.tables[0].Schema, "net_cost")
+	assert.InDelta(t, 3.0, rows[0][cost], 0)
+	assert.Empty(t, api.appends["costs"])
+
+	require.Len(t, api.queries, 2)
+	assert.Equal(t, "DELETE FROM `costs_staging` WHERE TRUE", api.queries[0])
+	merge := api.queries[1]
+	assert.Contains(t, merge, "MERGE `costs` T")
+	assert.Contains(t, merge, "ON T.line_item_id = S.merge_key")
+	assert.Contains(t, merge, "WHEN MATCHED AND (S.merge_key != S.line_item_id OR S.metric_type = 'deletion') THEN DELETE")
+	assert.Contains(t, merge, "`net_cost` = S.`net_cost`")
+}
+
+func TestBigQuery_UpsertSkipsMergeWhenRowsRejected(t *testing.T) {
+	api := &fakeBigQuery{reject: map[string]bool{"a": true}}
+	b, err := NewBigQuery(api, "costs", true)
+	require.NoError(t, err)
+
+	err = b.WriteRecords(context.Background(), bigQueryRecords("a", "b"))
+	var partial *adapter.PartialWriteError
+	require.ErrorAs(t, err, &partial)
+	assert.Len(t, partial.Failed, 2)
+	assert.Len(t, api.queries, 1, "only the staging table is cleared")
+}
+
+func TestBigQuery_UpsertQueryFailureFailsBatch(t *testing.T) {
+	api := &fakeBigQuery{queryErr: errors.New("quota exceeded")}
+	b, err := NewBigQuery(api, "costs", true)
+	require.NoError(t, err)
+
+	err = b.WriteRecords(context.Background(), bigQueryRecords("a"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "clearing staging table: quota exceeded")
+}
+
+func TestBigQuery_Check(t *testing.T) {
+	api := &fakeBigQuery{}
+	b, err := NewBigQuery(api, "costs", false)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Check(context.Background()))
+	require.NoError(t, b.WriteRecords(context.Background(), bigQueryRecords("a")))
+	assert.Len(t, api.tables, 1)
+}
+
+func TestNewBigQuery_InvalidTable(t *testing.T) {
+	_, err := NewBigQuery(&fakeBigQuery{}, "costs; DROP TABLE x", false)
+	require.Error(t, err)
+	_, err = NewBigQuery(nil, "costs", false)
+	require.Error(t, err)
+}
+
+func TestRowValues(t *testing.T) {
+	cost := 1.5
+	record := adapter.CostRecord{
+		SchemaVersion: 3,
+		Timestamp:     time.Date(2024, 1, 2, 0, 0, 0, 0, time.FixedZone("x", 3600)),
+		Provider:      "aws",
+		NetCost:       &cost,
+		Labels:        map[string]string{"team": "platform"},
+		LineItemID:    "a",
+	}
+	values, err := rowValues(&record)
+	require.NoError(t, err)
+
+	byName := make(map[string]any, len(values))
+	for i, col := range recordColumns {
+		byName[col.name] = values[i]
+	}
+	assert.Equal(t, int64(3), byName["schema_version"])
+	assert.Equal(t, time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), byName["timestamp"])
+	assert.Equal(t, "aws", byName["provider"])
+	assert.Nil(t, byName["service"], "empty strings are NULL")
+	assert.InDelta(t, 1.5, byName["net_cost"], 0)
+	assert.Nil(t, byName["list_cost"], "nil costs are NULL")
+	assert.JSONEq(t, `{"team":"platform"}`, byName["labels"].(string))
+	assert.Nil(t, byName["labels_raw"], "empty maps are NULL")
+	assert.Nil(t, byName["diagnostics"])
+}
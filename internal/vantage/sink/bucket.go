@@ -0,0 +1,160 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// Bucket stores the File sink's record files and manifest as named objects,
+// in a local directory or a cloud object store. A missing object is an
+// error wrapping os.ErrNotExist.
+type Bucket interface {
+	// List returns the names of the objects in the bucket.
+	List(ctx context.Context) ([]string, error)
+	// Size returns the size of object name.
+	Size(ctx context.Context, name string) (int64, error)
+	// Read returns the content of object name from offset on. Reading at
+	// or past the end returns no content.
+	Read(ctx context.Context, name string, offset int64) (io.ReadCloser, error)
+	// Append appends data to object name, creating it when missing, and
+	// returns its new size.
+	Append(ctx context.Context, name string, data []byte) (int64, error)
+	// Put replaces object name with data, so readers never see it half
+	// written.
+	Put(ctx context.Context, name string, data []byte) error
+	// Truncate cuts object name to size bytes.
+	Truncate(ctx context.Context, name string, size int64) error
+	// Delete removes object name.
+	Delete(ctx context.Context, name string) error
+}
+
+// OpenBucket opens the bucket at bucketURL: gs://<bucket>/<prefix>, or
+// azblob://<container>/<prefix> in the storage account named by
+// AZURE_STORAGE_ACCOUNT.
+func OpenBucket(bucketURL string) (Bucket, error) {
+	u, err := url.Parse(bucketURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing bucket URL: %w", err)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case adapter.SinkBucketSchemeGCS:
+		return NewGCSBucket(u.Host, prefix)
+	case adapter.SinkBucketSchemeAzureBlob:
+		return NewAzureBlobBucket(os.Getenv("AZURE_STORAGE_ACCOUNT"), u.Host, prefix)
+	default:
+		return nil, fmt.Errorf("unsupported bucket URL scheme: %q", u.Scheme)
+	}
+}
+
+// objectName joins prefix and name into an object name.
+func objectName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// DirBucket is a Bucket of the files in a local directory.
+type DirBucket struct {
+	dir string
+}
+
+// NewDirBucket returns the bucket of the files in dir, creating the
+// directory if needed.
+func NewDirBucket(dir string) (*DirBucket, error) {
+	if dir == "" {
+		return nil, errors.New("sink path cannot be empty")
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("creating sink directory: %w", err)
+	}
+	return &DirBucket{dir: dir}, nil
+}
+
+// String returns the directory.
+func (b *DirBucket) String() string {
+	return b.dir
+}
+
+// List implements Bucket.List.
+func (b *DirBucket) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Size implements Bucket.Size.
+func (b *DirBucket) Size(_ context.Context, name string) (int64, error) {
+	info, err := os.Stat(filepath.Join(b.dir, name))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Read implements Bucket.Read.
+func (b *DirBucket) Read(_ context.Context, name string, offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(b.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Append implements Bucket.Append.
+func (b *DirBucket) Append(_ context.Context, name string, data []byte) (int64, error) {
+	file, err := os.OpenFile(filepath.Join(b.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+	return info.Size(), file.Close()
+}
+
+// Put implements Bucket.Put, through a temporary file renamed into place.
+func (b *DirBucket) Put(_ context.Context, name string, data []byte) error {
+	path := filepath.Join(b.dir, name)
+	if err := os.WriteFile(path+".tmp", data, filePerm); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Truncate implements Bucket.Truncate.
+func (b *DirBucket) Truncate(_ context.Context, name string, size int64) error {
+	return os.Truncate(filepath.Join(b.dir, name), size)
+}
+
+// Delete implements Bucket.Delete.
+func (b *DirBucket) Delete(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(b.dir, name))
+}
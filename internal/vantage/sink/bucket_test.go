@@ -0,0 +1,26 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenBucket(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "acct")
+	t.Setenv("AZURE_STORAGE_KEY", "")
+
+	bucket, err := OpenBucket("gs://billing/vantage/prod/")
+	require.NoError(t, err)
+	require.IsType(t, &GCSBucket{}, bucket)
+	assert.Equal(t, "gs://billing/vantage/prod", bucket.(*GCSBucket).String())
+
+	bucket, err = OpenBucket("azblob://billing")
+	require.NoError(t, err)
+	require.IsType(t, &AzureBlobBucket{}, bucket)
+	assert.Equal(t, "azblob://billing", bucket.(*AzureBlobBucket).String())
+
+	_, err = OpenBucket("s3://billing")
+	require.ErrorContains(t, err, `unsupported bucket URL scheme: "s3"`)
+}
@@ -0,0 +1,325 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// ClickHouse column types of the record columns. Labels, raw labels, and
+// diagnostics are JSON text.
+var clickHouseTypes = map[columnType]string{
+	columnString:    "String",
+	columnFloat:     "Float64",
+	columnInt:       "Int64",
+	columnTimestamp: "DateTime64(6, 'UTC')",
+	columnJSON:      "String",
+}
+
+// clickHouseLowCardinality are the string columns with few distinct values,
+// stored dictionary-encoded so dashboards grouping by them scan less.
+var clickHouseLowCardinality = map[string]bool{
+	"provider":          true,
+	"service":           true,
+	"service_category":  true,
+	"region":            true,
+	"region_continent":  true,
+	"region_country":    true,
+	"resource_type":     true,
+	"usage_unit":        true,
+	"pricing_unit":      true,
+	"cost_basis":        true,
+	"currency":          true,
+	"original_currency": true,
+	"metric_type":       true,
+}
+
+// clickHouseSkipIndexed are the columns given set skip indexes, since cost
+// queries most often filter on them.
+var clickHouseSkipIndexed = []string{"provider", "service"}
+
+const (
+	// clickHouseTimestampFormat is the DateTime64 text format.
+	clickHouseTimestampFormat = "2006-01-02 15:04:05.000000"
+
+	// The version and deletion flag columns of the ReplacingMergeTree,
+	// which keeps the row with the highest version of each line_item_id and
+	// drops it when that row is flagged deleted.
+	clickHouseVersionColumn = "_version"
+	clickHouseDeletedColumn = "_deleted"
+)
+
+var (
+	// validClickHouseName restricts database and table names to plain
+	// identifiers, since they are interpolated into statements.
+	validClickHouseName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// validClickHouseCodec accepts a codec chain such as "Delta, ZSTD(3)".
+	validClickHouseCodec = regexp.MustCompile(`^[A-Za-z0-9]+(\(\d+\))?(, *[A-Za-z0-9]+(\(\d+\))?)*$`)
+)
+
+// ClickHouseOptions configures a ClickHouse sink.
+type ClickHouseOptions struct {
+	// URL is the server's HTTP interface, such as http://localhost:8123.
+	// A user and password in it authenticate requests.
+	URL      string
+	Database string
+	Table    string
+	// TTLDays, when positive, expires rows that many days after their
+	// timestamp.
+	TTLDays int
+	// Codec, when set, is the compression codec of every column, such as
+	// ZSTD(3); otherwise the server's default applies.
+	Codec string
+}
+
+// ClickHouse is a Sink that inserts records into a ClickHouse table over
+// the HTTP interface, one INSERT per batch. The table, created on first
+// write, is a ReplacingMergeTree ordered by line_item_id and partitioned by
+// month, so merges keep only the latest version of each record: a restated
+// record also inserts a deleted row for the record it restates, and a
+// deletion tombstone is inserted as a deleted row. Reading the table with
+// FINAL gives the current view that export.Reconcile derives.
+type ClickHouse struct {
+	endpoint   string
+	user       *url.Userinfo
+	database   string
+	table      string
+	create     string
+	insert     string
+	httpClient *http.Client
+
+	// now returns the time rows are versioned by.
+	now func() time.Time
+
+	mu      sync.Mutex // guards created
+	created bool
+}
+
+// NewClickHouse creates a sink writing to the table opts describes.
+func NewClickHouse(opts ClickHouseOptions) (*ClickHouse, error) {
+	endpoint, err := url.Parse(opts.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid clickhouse url: %q", opts.URL)
+	}
+	if !validClickHouseName.MatchString(opts.Database) {
+		return nil, fmt.Errorf("invalid clickhouse database name: %q", opts.Database)
+	}
+	if !validClickHouseName.MatchString(opts.Table) {
+		return nil, fmt.Errorf("invalid clickhouse table name: %q", opts.Table)
+	}
+	if opts.Codec != "" && !validClickHouseCodec.MatchString(opts.Codec) {
+		return nil, fmt.Errorf("invalid clickhouse codec: %q", opts.Codec)
+	}
+	if opts.TTLDays < 0 {
+		return nil, errors.New("clickhouse ttl days cannot be negative")
+	}
+
+	user := endpoint.User
+	endpoint.User = nil
+	c := &ClickHouse{
+		endpoint:   endpoint.String(),
+		user:       user,
+		database:   opts.Database,
+		table:      opts.Table,
+		create:     clickHouseCreateStatement(opts),
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		now:        time.Now,
+	}
+	names := make([]string, 0, len(recordColumns)+2)
+	for _, col := range recordColumns {
+		names = append(names, "`"+col.name+"`")
+	}
+	names = append(names, clickHouseVersionColumn, clickHouseDeletedColumn)
+	c.insert = fmt.Sprintf("INSERT INTO `%s` (%s) FORMAT JSONEachRow", c.table, strings.Join(names, ", "))
+	return c, nil
+}
+
+// WriteRecords implements adapter.Sink. Records that cannot be encoded are
+// rejected in an adapter.PartialWriteError. ClickHouse inserts a batch
+// atomically, so any other failure fails the whole batch.
+func (c *ClickHouse) WriteRecords(ctx context.Context, records []adapter.CostRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := c.createTable(ctx); err != nil {
+		return err
+	}
+
+	// Rows are versioned in write order, so a later record replaces an
+	// earlier one, in this batch or a previous one.
+	version := uint64(c.now().UnixNano()) //nolint:gosec // timestamps after 1970 are positive
+	failed := make(map[int]error)
+	var body bytes.Buffer
+	for i := range records {
+		row, err := c.encodeRow(&records[i], version)
+		if err != nil {
+			failed[i] = fmt.Errorf("%w: encoding record: %w", adapter.ErrRecordRejected, err)
+			continue
+		}
+		body.Write(row)
+		version += 2
+	}
+
+	if body.Len() > 0 {
+		if err := c.exec(ctx, c.insert, &body); err != nil {
+			return fmt.Errorf("inserting records: %w", err)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &adapter.PartialWriteError{Failed: failed}
+	}
+	return nil
+}
+
+// Check implements preflight.SinkChecker by creating the sink's table,
+// which verifies the credentials can reach and write to the database.
+func (c *ClickHouse) Check(ctx context.Context) error {
+	return c.createTable(ctx)
+}
+
+// encodeRow encodes record as JSONEachRow lines: a deleted row for the
+// record it restates, at version, then the record at version+1.
+func (c *ClickHouse) encodeRow(record *adapter.CostRecord, version uint64) ([]byte, error) {
+	values, err := rowValues(record)
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]any, len(values)+2)
+	for i, col := range recordColumns {
+		if ts, ok := values[i].(time.Time); ok {
+			values[i] = ts.Format(clickHouseTimestampFormat)
+		}
+		row[col.name] = values[i]
+	}
+	row[clickHouseVersionColumn] = version + 1
+	row[clickHouseDeletedColumn] = 0
+	if record.MetricType == adapter.MetricTypeDeletion {
+		row[clickHouseDeletedColumn] = 1
+	}
+	line, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	line = append(line, '\n')
+
+	if record.RestatesLineItemID == "" || record.RestatesLineItemID == record.LineItemID {
+		return line, nil
+	}
+	// The restated record is expected in the same monthly partition as the
+	// record restating it, since replacement only happens within one.
+	tombstone, err := json.Marshal(map[string]any{
+		"timestamp":             row["timestamp"],
+		"line_item_id":          record.RestatesLineItemID,
+		"metric_type":           adapter.MetricTypeDeletion,
+		clickHouseVersionColumn: version,
+		clickHouseDeletedColumn: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(append(tombstone, '\n'), line...), nil
+}
+
+// createTable creates the table once per sink.
+func (c *ClickHouse) createTable(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.created {
+		return nil
+	}
+	if err := c.exec(ctx, c.create, nil); err != nil {
+		return fmt.Errorf("creating table %s: %w", c.table, err)
+	}
+	c.created = true
+	return nil
+}
+
+// exec runs query in the sink's database, sending body as its input data.
+func (c *ClickHouse) exec(ctx context.Context, query string, body io.Reader) error {
+	params := url.Values{
+		"database": {c.database},
+		"query":    {query},
+		// Report errors in the status code rather than after a 200.
+		"wait_end_of_query": {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"?"+params.Encode(), body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if c.user != nil {
+		password, _ := c.user.Password()
+		req.SetBasicAuth(c.user.Username(), password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if message := strings.TrimSpace(string(data)); message != "" {
+			return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, message)
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// clickHouseCreateStatement builds the CREATE TABLE for opts. Every record
+// column but the timestamp and line_item_id, which key the table, is
+// nullable, so empty values load as NULL.
+func clickHouseCreateStatement(opts ClickHouseOptions) string {
+	codec := ""
+	if opts.Codec != "" {
+		codec = " CODEC(" + opts.Codec + ")"
+	}
+	columns := make([]string, 0, len(recordColumns)+4)
+	for _, col := range recordColumns {
+		typ := clickHouseTypes[col.typ]
+		switch {
+		case col.name == "timestamp" || col.name == "line_item_id":
+		case clickHouseLowCardinality[col.name]:
+			typ = "LowCardinality(Nullable(" + typ + "))"
+		default:
+			typ = "Nullable(" + typ + ")"
+		}
+		columns = append(columns, fmt.Sprintf("`%s` %s%s", col.name, typ, codec))
+	}
+	columns = append(columns,
+		fmt.Sprintf("`%s` UInt64%s", clickHouseVersionColumn, codec),
+		fmt.Sprintf("`%s` UInt8%s", clickHouseDeletedColumn, codec))
+	for _, name := range clickHouseSkipIndexed {
+		columns = append(columns, fmt.Sprintf("INDEX `%s_idx` `%s` TYPE set(100) GRANULARITY 4", name, name))
+	}
+
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS `+"`%s`"+` (
+  %s
+)
+ENGINE = ReplacingMergeTree(%s, %s)
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY line_item_id`,
+		opts.Table, strings.Join(columns, ",\n  "), clickHouseVersionColumn, clickHouseDeletedColumn)
+	if opts.TTLDays > 0 {
+		statement += fmt.Sprintf("\nTTL toDateTime(timestamp) + INTERVAL %d DAY", opts.TTLDays)
+	}
+	return statement
+}
@@ -0,0 +1,171 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+var _ adapter.Sink = (*ClickHouse)(nil)
+
+// fakeClickHouse records the queries and inserted rows the sink sends to
+// the HTTP interface.
+type fakeClickHouse struct {
+	t       *testing.T
+	queries []string
+	rows    []map[string]any
+	status  int
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(f.t, "finops", r.URL.Query().Get("database"))
+	user, password, ok := r.BasicAuth()
+	assert.True(f.t, ok)
+	assert.Equal(f.t, "writer", user)
+	assert.Equal(f.t, "secret", password)
+
+	query := r.URL.Query().Get("query")
+	f.queries = append(f.queries, query)
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		_, _ = io.WriteString(w, "Code: 27. DB::Exception: Cannot parse input\n")
+		return
+	}
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var row map[string]any
+		assert.NoError(f.t, json.Unmarshal(scanner.Bytes(), &row))
+		f.rows = append(f.rows, row)
+	}
+}
+
+func newTestClickHouse(t *testing.T, server *fakeClickHouse, opts ClickHouseOptions) *ClickHouse {
+	t.Helper()
+	server.t = t
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	opts.URL = strings.Replace(srv.URL, "http://", "http://writer:secret@", 1)
+	opts.Database = "finops"
+	opts.Table = "costs"
+	c, err := NewClickHouse(opts)
+	require.NoError(t, err)
+	c.now = func() time.Time { return time.Unix(0, 1000) }
+	return c
+}
+
+func TestClickHouse_WriteRecords(t *testing.T) {
+	server := &fakeClickHouse{}
+	c := newTestClickHouse(t, server, ClickHouseOptions{})
+
+	records := bigQueryRecords("a", "b", "gone")
+	records[1].RestatesLineItemID = "old"
+	records[2].MetricType = adapter.MetricTypeDeletion
+	require.NoError(t, c.WriteRecords(context.Background(), records))
+	require.NoError(t, c.WriteRecords(context.Background(), bigQueryRecords("c")))
+
+	require.Len(t, server.queries, 3, "the table is created once")
+	assert.True(t, strings.HasPrefix(server.queries[0], "CREATE TABLE IF NOT EXISTS `costs`"))
+	assert.True(t, strings.HasPrefix(server.queries[1], "INSERT INTO `costs` (`schema_version`, `timestamp`,"))
+	assert.True(t, strings.HasSuffix(server.queries[1], "_version, _deleted) FORMAT JSONEachRow"))
+
+	require.Len(t, server.rows, 5)
+	assert.Equal(t, "a", server.rows[0]["line_item_id"])
+	assert.Equal(t, "2024-01-02 00:00:00.000000", server.rows[0]["timestamp"])
+	assert.Equal(t, "EC2", server.rows[0]["service"])
+	assert.Nil(t, server.rows[0]["region"], "empty values are NULL")
+	assert.InDelta(t, 1001, server.rows[0]["_version"], 0)
+	assert.InDelta(t, 0, server.rows[0]["_deleted"], 0)
+
+	assert.Equal(t, map[string]any{
+		"timestamp":    "2024-01-02 00:00:00.000000",
+		"line_item_id": "old",
+		"metric_type":  adapter.MetricTypeDeletion,
+		"_version":     float64(1002),
+		"_deleted":     float64(1),
+	}, server.rows[1], "the restated record is deleted")
+	assert.Equal(t, "b", server.rows[2]["line_item_id"])
+	assert.InDelta(t, 1003, server.rows[2]["_version"], 0)
+
+	assert.Equal(t, "gone", server.rows[3]["line_item_id"])
+	assert.InDelta(t, 1, server.rows[3]["_deleted"], 0, "a deletion is inserted as a deleted row")
+	assert.Greater(t, server.rows[3]["_version"], server.rows[2]["_version"])
+}
+
+func TestClickHouse_WriteRecordsRejected(t *testing.T) {
+	server := &fakeClickHouse{}
+	c := newTestClickHouse(t, server, ClickHouseOptions{})
+
+	records := bigQueryRecords("a", "b")
+	nan := math.NaN()
+	records[1].NetCost = &nan
+
+	err := c.WriteRecords(context.Background(), records)
+	var partial *adapter.PartialWriteError
+	require.ErrorAs(t, err, &partial)
+	require.Len(t, partial.Failed, 1)
+	assert.ErrorIs(t, partial.Failed[1], adapter.ErrRecordRejected)
+	require.Len(t, server.rows, 1)
+	assert.Equal(t, "a", server.rows[0]["line_item_id"])
+}
+
+func TestClickHouse_WriteRecordsFailure(t *testing.T) {
+	server := &fakeClickHouse{status: http.StatusBadRequest}
+	c := newTestClickHouse(t, server, ClickHouseOptions{})
+	c.created = true
+
+	err := c.WriteRecords(context.Background(), bigQueryRecords("a"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "inserting records: unexpected status 400: Code: 27. DB::Exception: Cannot parse input")
+}
+
+func TestClickHouse_Check(t *testing.T) {
+	server := &fakeClickHouse{}
+	c := newTestClickHouse(t, server, ClickHouseOptions{TTLDays: 400, Codec: "ZSTD(3)"})
+
+	require.NoError(t, c.Check(context.Background()))
+	require.Len(t, server.queries, 1)
+	create := server.queries[0]
+	assert.Contains(t, create, "`timestamp` DateTime64(6, 'UTC') CODEC(ZSTD(3))")
+	assert.Contains(t, create, "`line_item_id` String CODEC(ZSTD(3))")
+	assert.Contains(t, create, "`provider` LowCardinality(Nullable(String)) CODEC(ZSTD(3))")
+	assert.Contains(t, create, "`net_cost` Nullable(Float64) CODEC(ZSTD(3))")
+	assert.Contains(t, create, "`labels` Nullable(String) CODEC(ZSTD(3))")
+	assert.Contains(t, create, "INDEX `service_idx` `service` TYPE set(100) GRANULARITY 4")
+	assert.Contains(t, create, "ENGINE = ReplacingMergeTree(_version, _deleted)\nPARTITION BY toYYYYMM(timestamp)\nORDER BY line_item_id")
+	assert.True(t, strings.HasSuffix(create, "\nTTL toDateTime(timestamp) + INTERVAL 400 DAY"))
+}
+
+func TestNewClickHouse_Errors(t *testing.T) {
+	valid := ClickHouseOptions{URL: "http://localhost:8123", Database: "default", Table: "costs"}
+	_, err := NewClickHouse(valid)
+	require.NoError(t, err)
+
+	for name, mutate := range map[string]func(*ClickHouseOptions){
+		"url":      func(o *ClickHouseOptions) { o.URL = "localhost:8123" },
+		"database": func(o *ClickHouseOptions) { o.Database = "a-b" },
+		"table":    func(o *ClickHouseOptions) { o.Table = "costs; DROP TABLE x" },
+		"codec":    func(o *ClickHouseOptions) { o.Codec = "ZSTD(3)) SETTINGS x" },
+		"ttl":      func(o *ClickHouseOptions) { o.TTLDays = -1 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts := valid
+			mutate(&opts)
+			_, err := NewClickHouse(opts)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package sink
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// columnType is the type of a record column, mapped to each warehouse's
+// own types.
+type columnType int
+
+const (
+	columnString columnType = iota
+	columnFloat
+	columnInt
+	columnTimestamp
+	// columnJSON holds maps and nested structs, encoded as JSON.
+	columnJSON
+)
+
+// column is one column of a flattened record.
+type column struct {
+	name  string
+	typ   columnType
+	field int // index of the CostRecord field
+}
+
+// recordColumns are the columns of a record flattened into a table row: one
+// per CostRecord field, named by its JSON key, in field order. Deriving them
+// from the struct keeps warehouse tables in step with the record schema.
+var recordColumns = buildRecordColumns()
+
+func buildRecordColumns() []column {
+	t := reflect.TypeFor[adapter.CostRecord]()
+	columns := make([]column, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		columns = append(columns, column{name: name, typ: columnTypeOf(field.Type), field: i})
+	}
+	return columns
+}
+
+// columnTypeOf maps a CostRecord field type to its column type.
+func columnTypeOf(t reflect.Type) columnType {
+	if t == reflect.TypeFor[time.Time]() {
+		return columnTimestamp
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return columnString
+	case reflect.Float32, reflect.Float64:
+		return columnFloat
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return columnInt
+	default:
+		return columnJSON
+	}
+}
+
+// rowValues returns the values of record's columns, in recordColumns
+// order: string, float64, int64, time.Time, or JSON text. Empty strings,
+// nil pointers, empty maps, and zero times are nil, so they load as NULL.
+func rowValues(record *adapter.CostRecord) ([]any, error) {
+	v := reflect.ValueOf(record).Elem()
+	values := make([]any, len(recordColumns))
+	for i, col := range recordColumns {
+		field := v.Field(col.field)
+		switch col.typ {
+		case columnTimestamp:
+			if ts := field.Interface().(time.Time); !ts.IsZero() {
+				values[i] = ts.UTC()
+			}
+		case columnString:
+			if s := field.String(); s != "" {
+				values[i] = s
+			}
+		case columnFloat:
+			if field.Kind() == reflect.Pointer {
+				if !field.IsNil() {
+					values[i] = field.Elem().Float()
+				}
+			} else {
+				values[i] = field.Float()
+			}
+		case columnInt:
+			values[i] = field.Int()
+		case columnJSON:
+			if field.IsZero() || (field.Kind() == reflect.Map && field.Len() == 0) {
+				continue
+			}
+			data, err := json.Marshal(field.Interface())
+			if err != nil {
+				return nil, err
+			}
+			values[i] = string(data)
+		}
+	}
+	return values, nil
+}
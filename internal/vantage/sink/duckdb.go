@@ -0,0 +1,249 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// DuckDB column types of the record columns.
+var duckDBTypes = map[columnType]string{
+	columnString:    "VARCHAR",
+	columnFloat:     "DOUBLE",
+	columnInt:       "BIGINT",
+	columnTimestamp: "TIMESTAMP",
+	columnJSON:      "JSON",
+}
+
+// DuckDB is a Sink that keeps records in a table of a local DuckDB
+// database, one column per record field, for ad hoc analysis with the query
+// command. The table, created on first write, holds the current view that
+// export.Reconcile derives: a record replaces the row with its LineItemID,
+// a restated record deletes the row it restates, and a deletion tombstone
+// deletes its row.
+//
+// DuckDB needs cgo, so the driver is only built in with the duckdb build
+// tag; without it opening the database fails.
+type DuckDB struct {
+	db     *sql.DB
+	table  string
+	insert string
+	delete string
+
+	// mu guards created, and serializes writes, since DuckDB aborts a
+	// transaction that conflicts with another.
+	mu      sync.Mutex
+	created bool
+}
+
+// NewDuckDB opens (or creates) the database at path, writing to table.
+// Close it when done.
+func NewDuckDB(path, table string) (*DuckDB, error) {
+	if path == "" {
+		return nil, errors.New("duckdb database path cannot be empty")
+	}
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid duckdb table name: %q", table)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return nil, fmt.Errorf("creating duckdb directory: %w", err)
+	}
+	db, err := openDuckDB(path, false)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(recordColumns))
+	params := make([]string, len(recordColumns))
+	for i, col := range recordColumns {
+		names[i] = `"` + col.name + `"`
+		params[i] = "?"
+	}
+	return &DuckDB{
+		db:    db,
+		table: table,
+		insert: fmt.Sprintf(`INSERT OR REPLACE INTO "%s" (%s) VALUES (%s)`,
+			table, strings.Join(names, ", "), strings.Join(params, ", ")),
+		delete: fmt.Sprintf(`DELETE FROM "%s" WHERE line_item_id = ?`, table),
+	}, nil
+}
+
+// WriteRecords implements adapter.Sink. Records that cannot be encoded are
+// rejected in an adapter.PartialWriteError; the rest are written in one
+// transaction.
+func (d *DuckDB) WriteRecords(ctx context.Context, records []adapter.CostRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.createTable(ctx); err != nil {
+		return err
+	}
+
+	// Each LineItemID is written once per batch, with its last version, as
+	// DuckDB's index cannot remove and re-add a key within a transaction.
+	failed := make(map[int]error)
+	rows := make(map[string][]any) // nil for a deleted row
+	var ids []string
+	set := func(id string, values []any) {
+		if _, ok := rows[id]; !ok {
+			ids = append(ids, id)
+		}
+		rows[id] = values
+	}
+	for i := range records {
+		record := &records[i]
+		values, err := rowValues(record)
+		if err != nil {
+			failed[i] = fmt.Errorf("%w: encoding record: %w", adapter.ErrRecordRejected, err)
+			continue
+		}
+		if record.RestatesLineItemID != "" && record.RestatesLineItemID != record.LineItemID {
+			set(record.RestatesLineItemID, nil)
+		}
+		if record.MetricType == adapter.MetricTypeDeletion {
+			values = nil
+		}
+		set(record.LineItemID, values)
+	}
+
+	if len(ids) > 0 {
+		if err := d.writeRows(ctx, ids, rows); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return &adapter.PartialWriteError{Failed: failed}
+	}
+	return nil
+}
+
+// Check implements preflight.SinkChecker by creating the sink's table.
+func (d *DuckDB) Check(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.createTable(ctx)
+}
+
+// Close closes the database.
+func (d *DuckDB) Close() error {
+	return d.db.Close()
+}
+
+// writeRows replaces or deletes the row of each id in one transaction.
+func (d *DuckDB) writeRows(ctx context.Context, ids []string, rows map[string][]any) (err error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	insert, err := tx.PrepareContext(ctx, d.insert)
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	del, err := tx.PrepareContext(ctx, d.delete)
+	if err != nil {
+		return fmt.Errorf("preparing delete: %w", err)
+	}
+	for _, id := range ids {
+		if values := rows[id]; values != nil {
+			if _, err = insert.ExecContext(ctx, values...); err != nil {
+				return fmt.Errorf("writing record %s: %w", id, err)
+			}
+			continue
+		}
+		if _, err = del.ExecContext(ctx, id); err != nil {
+			return fmt.Errorf("deleting record %s: %w", id, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing records: %w", err)
+	}
+	return nil
+}
+
+// createTable creates the table once per sink. d.mu must be held.
+func (d *DuckDB) createTable(ctx context.Context) error {
+	if d.created {
+		return nil
+	}
+	columns := make([]string, len(recordColumns))
+	for i, col := range recordColumns {
+		columns[i] = fmt.Sprintf(`"%s" %s`, col.name, duckDBTypes[col.typ])
+		if col.name == "line_item_id" {
+			columns[i] += " PRIMARY KEY"
+		}
+	}
+	create := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" (%s)`, d.table, strings.Join(columns, ", "))
+	if _, err := d.db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("creating table %s: %w", d.table, err)
+	}
+	d.created = true
+	return nil
+}
+
+// QueryResult is the result of a query: its column names and rows of
+// values as the driver returns them.
+type QueryResult struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// QueryDuckDB runs query against the database at path, opened read-only so
+// the query cannot change it.
+func QueryDuckDB(ctx context.Context, path, query string) (*QueryResult, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("opening duckdb database: %w", err)
+	}
+	db, err := openDuckDB(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("running query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("reading columns: %w", err)
+	}
+
+	result := &QueryResult{Columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if scanErr := rows.Scan(dest...); scanErr != nil {
+			return nil, fmt.Errorf("reading row: %w", scanErr)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, fmt.Errorf("reading rows: %w", rowsErr)
+	}
+	return result, nil
+}
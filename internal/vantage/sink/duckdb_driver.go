@@ -0,0 +1,23 @@
+//go:build duckdb
+
+package sink
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/marcboeker/go-duckdb/v2" // registers the "duckdb" driver
+)
+
+// openDuckDB opens the database at path, read-only when readOnly is set.
+func openDuckDB(path string, readOnly bool) (*sql.DB, error) {
+	dsn := path
+	if readOnly {
+		dsn += "?access_mode=READ_ONLY"
+	}
+	db, err := sql.Open("duckdb", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening duckdb database: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,17 @@
+//go:build !duckdb
+
+package sink
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// errDuckDBNotBuilt is returned when the binary was built without the
+// DuckDB driver, which needs cgo.
+var errDuckDBNotBuilt = errors.New("duckdb support is not built in; build with -tags duckdb (requires cgo)")
+
+// openDuckDB fails, since this build has no DuckDB driver.
+func openDuckDB(string, bool) (*sql.DB, error) {
+	return nil, errDuckDBNotBuilt
+}
@@ -0,0 +1,19 @@
+//go:build !duckdb
+
+package sink
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDuckDB_NotBuilt(t *testing.T) {
+	_, err := NewDuckDB(filepath.Join(t.TempDir(), "costs.duckdb"), "costs")
+	require.ErrorIs(t, err, errDuckDBNotBuilt)
+
+	_, err = QueryDuckDB(context.Background(), t.TempDir(), "select 1")
+	require.ErrorIs(t, err, errDuckDBNotBuilt)
+}
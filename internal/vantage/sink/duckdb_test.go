@@ -0,0 +1,77 @@
+//go:build duckdb
+
+package sink
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+var _ adapter.Sink = (*DuckDB)(nil)
+
+func newTestDuckDB(t *testing.T) (*DuckDB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "db", "costs.duckdb")
+	d, err := NewDuckDB(path, "costs")
+	require.NoError(t, err)
+	return d, path
+}
+
+func TestDuckDB_WriteRecords(t *testing.T) {
+	d, path := newTestDuckDB(t)
+	ctx := context.Background()
+
+	records := bigQueryRecords("a", "b", "c")
+	records[0].Labels = map[string]string{"team": "web"}
+	require.NoError(t, d.WriteRecords(ctx, records))
+
+	// A later batch replaces a, restates b as d, and deletes c.
+	later := bigQueryRecords("a", "d", "c")
+	cost := 10.0
+	later[0].NetCost = &cost
+	later[1].RestatesLineItemID = "b"
+	later[2].MetricType = adapter.MetricTypeDeletion
+	require.NoError(t, d.WriteRecords(ctx, later))
+	require.NoError(t, d.Close())
+
+	result, err := QueryDuckDB(ctx, path,
+		`select line_item_id, net_cost, labels->>'team' as team, timestamp from costs order by 1`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line_item_id", "net_cost", "team", "timestamp"}, result.Columns)
+	require.Len(t, result.Rows, 2)
+	assert.Equal(t, []any{"a", 10.0, nil, records[0].Timestamp}, result.Rows[0])
+	assert.Equal(t, "d", result.Rows[1][0])
+}
+
+func TestDuckDB_WriteRecordsSameBatch(t *testing.T) {
+	d, path := newTestDuckDB(t)
+	ctx := context.Background()
+
+	records := bigQueryRecords("a", "a", "b", "c")
+	records[1].Service = "S3"
+	records[3].RestatesLineItemID = "b"
+	require.NoError(t, d.WriteRecords(ctx, records))
+	require.NoError(t, d.Close())
+
+	result, err := QueryDuckDB(ctx, path, `select line_item_id, service from costs order by 1`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"a", "S3"}, {"c", "EC2"}}, result.Rows)
+}
+
+func TestQueryDuckDB_ReadOnly(t *testing.T) {
+	d, path := newTestDuckDB(t)
+	require.NoError(t, d.Check(context.Background()))
+	require.NoError(t, d.Close())
+
+	_, err := QueryDuckDB(context.Background(), path, `delete from costs`)
+	require.Error(t, err)
+
+	_, err = QueryDuckDB(context.Background(), filepath.Join(t.TempDir(), "missing.duckdb"), `select 1`)
+	require.Error(t, err)
+}
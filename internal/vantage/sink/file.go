@@ -0,0 +1,592 @@
+// Package sink provides Sink implementations for persisting Vantage cost records.
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	// RecordsFileName is the NDJSON file records are appended to.
+	RecordsFileName = "records.ndjson"
+	// ManifestFileName lists the record files written, when enabled.
+	ManifestFileName = "manifest.json"
+
+	dirPerm  = 0o750
+	filePerm = 0o600
+
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+// partFilePattern matches the numbered record files written when records
+// are compressed or rotated.
+var partFilePattern = regexp.MustCompile(`^records-(\d+)\.ndjson(\.gz|\.zst)?$`)
+
+// compressionExts are the file extensions of each compression.
+var compressionExts = map[string]string{"": "", compressionGzip: ".gz", compressionZstd: ".zst"}
+
+// FileOptions configures how a File sink lays out its records.
+type FileOptions struct {
+	// Compression is "gzip" or "zstd" to compress records, or "" or "none"
+	// for plain NDJSON.
+	Compression string
+	// MaxFileBytes, when positive, starts a new record file once the
+	// current one has reached that size.
+	MaxFileBytes int64
+	// Manifest keeps manifest.json up to date with each record file
+	// written, its row count, and its date range.
+	Manifest bool
+}
+
+// File is a Sink that appends records as newline-delimited JSON to
+// records.ndjson in a directory or cloud bucket. With compression or a
+// maximum file size, records go to numbered files,
+// records-000001.ndjson[.gz|.zst], each batch appended as one gzip member or
+// zstd frame, so the files stay readable by standard tools however many
+// batches they hold.
+type File struct {
+	bucket Bucket
+	opts   FileOptions
+	mu     sync.Mutex
+
+	// part is the number of the record file being written, once found;
+	// numbered files only.
+	part int
+	// manifest is loaded on the first write when enabled.
+	manifest *fileManifest
+	zstd     *zstd.Encoder
+}
+
+// fileManifest is the content of manifest.json.
+type fileManifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+// manifestEntry describes one record file.
+type manifestEntry struct {
+	Path        string `json:"path"`
+	Compression string `json:"compression,omitempty"`
+	Rows        int64  `json:"rows"`
+	Bytes       int64  `json:"bytes"`
+	MinDate     string `json:"min_date,omitempty"`
+	MaxDate     string `json:"max_date,omitempty"`
+	// Closed is set once records are no longer appended to the file.
+	Closed    bool      `json:"closed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewFile creates a file sink rooted at dir, creating the directory if needed.
+func NewFile(dir string) (*File, error) {
+	return NewFileWithOptions(dir, FileOptions{})
+}
+
+// NewFileWithOptions creates a file sink rooted at dir that compresses,
+// rotates, and lists its record files as opts sets.
+func NewFileWithOptions(dir string, opts FileOptions) (*File, error) {
+	bucket, err := NewDirBucket(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewFileWithBucket(bucket, opts)
+}
+
+// NewFileWithBucket creates a file sink writing its record files and
+// manifest to bucket, laid out as opts sets.
+func NewFileWithBucket(bucket Bucket, opts FileOptions) (*File, error) {
+	if opts.Compression == "none" {
+		opts.Compression = ""
+	}
+	if _, ok := compressionExts[opts.Compression]; !ok {
+		return nil, fmt.Errorf("unsupported sink compression: %s", opts.Compression)
+	}
+	if opts.MaxFileBytes < 0 {
+		return nil, errors.New("sink max file bytes cannot be negative")
+	}
+
+	f := &File{bucket: bucket, opts: opts}
+	if opts.Compression == compressionZstd {
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating zstd encoder: %w", err)
+		}
+		f.zstd = encoder
+	}
+	return f, nil
+}
+
+// Dir returns where the sink writes: its directory, or its bucket's URL.
+func (f *File) Dir() string {
+	return fmt.Sprint(f.bucket)
+}
+
+// WriteRecords implements adapter.Sink. Records that cannot be encoded, such
+// as one with a NaN cost, are rejected in an adapter.PartialWriteError while
+// the rest are written.
+func (f *File) WriteRecords(ctx context.Context, records []adapter.CostRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	// The encoder writes nothing for a record it fails to encode.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	var rejected map[int]error
+	var rows int64
+	var minDate, maxDate string
+	for i := range records {
+		if encErr := enc.Encode(&records[i]); encErr != nil {
+			if rejected == nil {
+				rejected = make(map[int]error)
+			}
+			rejected[i] = fmt.Errorf("%w: encoding record: %w", adapter.ErrRecordRejected, encErr)
+			continue
+		}
+		rows++
+		if ts := records[i].Timestamp; !ts.IsZero() {
+			date := ts.UTC().Format(time.DateOnly)
+			if minDate == "" || date < minDate {
+				minDate = date
+			}
+			maxDate = max(maxDate, date)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if buf.Len() > 0 {
+		name, err := f.currentFile(ctx)
+		if err != nil {
+			return err
+		}
+		data, err := f.compress(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("compressing records: %w", err)
+		}
+		size, err := f.bucket.Append(ctx, name, data)
+		if err != nil {
+			return fmt.Errorf("writing records: %w", err)
+		}
+		if f.opts.Manifest {
+			if err := f.loadManifest(ctx); err != nil {
+				return err
+			}
+			entry := f.manifestEntry(name)
+			entry.Rows += rows
+			entry.Bytes = size
+			if minDate != "" && (entry.MinDate == "" || minDate < entry.MinDate) {
+				entry.MinDate = minDate
+			}
+			entry.MaxDate = max(entry.MaxDate, maxDate)
+			entry.UpdatedAt = time.Now().UTC()
+			if err := f.writeManifest(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if rejected != nil {
+		return &adapter.PartialWriteError{Failed: rejected}
+	}
+	return nil
+}
+
+// numbered reports whether records go to numbered files.
+func (f *File) numbered() bool {
+	return f.opts.Compression != "" || f.opts.MaxFileBytes > 0
+}
+
+// partName returns the name of numbered record file n.
+func (f *File) partName(n int) string {
+	return fmt.Sprintf("records-%06d.ndjson%s", n, compressionExts[f.opts.Compression])
+}
+
+// currentFile returns the name of the file the next batch is appended to,
+// moving to a new numbered file when the current one is full or was
+// written with another compression. A compressed sink starts a new file
+// each time it is opened, so a batch torn by a crash is only ever at the
+// end of a file. f.mu must be held.
+func (f *File) currentFile(ctx context.Context) (string, error) {
+	if !f.numbered() {
+		return RecordsFileName, nil
+	}
+	if f.part == 0 {
+		parts, err := f.partFiles(ctx)
+		if err != nil {
+			return "", err
+		}
+		f.part = 1
+		if len(parts) > 0 {
+			last := parts[len(parts)-1]
+			f.part, _ = strconv.Atoi(partFilePattern.FindStringSubmatch(last)[1])
+			if last != f.partName(f.part) || f.opts.Compression != "" {
+				if err := f.closePart(ctx, last); err != nil {
+					return "", err
+				}
+				f.part++
+			}
+		}
+	}
+
+	name := f.partName(f.part)
+	if f.opts.MaxFileBytes > 0 {
+		size, err := f.bucket.Size(ctx, name)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("reading records file size: %w", err)
+		}
+		if err == nil && size >= f.opts.MaxFileBytes {
+			if err := f.closePart(ctx, name); err != nil {
+				return "", err
+			}
+			f.part++
+			name = f.partName(f.part)
+		}
+	}
+	return name, nil
+}
+
+// closePart marks a numbered file closed in the manifest.
+func (f *File) closePart(ctx context.Context, name string) error {
+	if !f.opts.Manifest {
+		return nil
+	}
+	if err := f.loadManifest(ctx); err != nil {
+		return err
+	}
+	for i := range f.manifest.Files {
+		if f.manifest.Files[i].Path == name {
+			f.manifest.Files[i].Closed = true
+			return f.writeManifest(ctx)
+		}
+	}
+	return nil
+}
+
+// compress compresses a batch of NDJSON as one gzip member or zstd frame.
+func (f *File) compress(data []byte) ([]byte, error) {
+	switch f.opts.Compression {
+	case compressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case compressionZstd:
+		return f.zstd.EncodeAll(data, nil), nil
+	default:
+		return data, nil
+	}
+}
+
+// partFiles returns the names of the numbered record files, in order.
+func (f *File) partFiles(ctx context.Context) ([]string, error) {
+	names, err := f.bucket.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing records files: %w", err)
+	}
+	var parts []string
+	for _, name := range names {
+		if partFilePattern.MatchString(name) {
+			parts = append(parts, name)
+		}
+	}
+	slices.SortFunc(parts, func(a, b string) int {
+		na, _ := strconv.Atoi(partFilePattern.FindStringSubmatch(a)[1])
+		nb, _ := strconv.Atoi(partFilePattern.FindStringSubmatch(b)[1])
+		return na - nb
+	})
+	return parts, nil
+}
+
+// recordFiles returns the names of every record file, in the order they
+// were written: records.ndjson, when present, then the numbered files.
+func (f *File) recordFiles(ctx context.Context) ([]string, error) {
+	parts, err := f.partFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.bucket.Size(ctx, RecordsFileName); err == nil {
+		parts = append([]string{RecordsFileName}, parts...)
+	}
+	return parts, nil
+}
+
+// ReadRecords calls fn for each record in the record files, in the order
+// they were written, upgrading records written by older plugin versions to
+// the current schema. A sink that has never been written to has no records.
+func (f *File) ReadRecords(ctx context.Context, fn func(adapter.CostRecord) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names, err := f.recordFiles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := f.readRecordFile(ctx, name, fn); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readRecordFile calls fn for each record in the record file name,
+// decompressing it by its extension.
+func (f *File) readRecordFile(ctx context.Context, name string, fn func(adapter.CostRecord) error) error {
+	file, err := f.bucket.Read(ctx, name, 0)
+	if err != nil {
+		return fmt.Errorf("opening records file: %w", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = bufio.NewReader(file)
+	compressed := true
+	switch path.Ext(name) {
+	case ".gz":
+		gz, gzErr := gzip.NewReader(r)
+		if errors.Is(gzErr, io.EOF) {
+			return nil
+		}
+		if gzErr != nil {
+			return fmt.Errorf("opening records file: %w", gzErr)
+		}
+		defer gz.Close()
+		r = gz
+	case ".zst":
+		zr, zErr := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if zErr != nil {
+			return fmt.Errorf("opening records file: %w", zErr)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		compressed = false
+	}
+
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		var raw json.RawMessage
+		// A compressed file ending part way through a batch was torn by a
+		// crash; the batch was written again to a later file.
+		if decErr := dec.Decode(&raw); errors.Is(decErr, io.EOF) || (compressed && errors.Is(decErr, io.ErrUnexpectedEOF)) {
+			return nil
+		} else if decErr != nil {
+			return fmt.Errorf("decoding record %d: %w", line, decErr)
+		}
+		record, decErr := adapter.DecodeRecord(raw)
+		if decErr != nil {
+			return fmt.Errorf("record %d: %w", line, decErr)
+		}
+		if fnErr := fn(record); fnErr != nil {
+			return fnErr
+		}
+	}
+}
+
+// Checkpoint implements adapter.CheckpointSink. The checkpoint is the size
+// of the records file, or, for numbered files, the name and size of the
+// last one. Compressed files cannot be resumed part way through a batch, so
+// their checkpoint is empty and an interrupted batch is written again.
+func (f *File) Checkpoint(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.opts.Compression != "" {
+		return "", nil
+	}
+	name := RecordsFileName
+	if f.numbered() {
+		parts, err := f.partFiles(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(parts) == 0 {
+			return ":0", nil
+		}
+		name = parts[len(parts)-1]
+	}
+
+	size, err := f.bucket.Size(ctx, name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("reading records file size: %w", err)
+	}
+	if f.numbered() {
+		return name + ":" + strconv.FormatInt(size, 10), nil
+	}
+	return strconv.FormatInt(size, 10), nil
+}
+
+// RecordsSince implements adapter.CheckpointSink. A last line left without
+// its newline by a crash part way through a write is truncated, so the
+// records written after it start on a line of their own.
+func (f *File) RecordsSince(ctx context.Context, checkpoint string) (map[string]int, error) {
+	name, offsetText, numbered := strings.Cut(checkpoint, ":")
+	if !numbered {
+		name, offsetText = RecordsFileName, checkpoint
+	}
+	offset, err := strconv.ParseInt(offsetText, 10, 64)
+	if err != nil || offset < 0 || (numbered && name != "" && !partFilePattern.MatchString(name)) {
+		return nil, fmt.Errorf("invalid file sink checkpoint: %q", checkpoint)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// The records written since a numbered checkpoint are in its file,
+	// after the offset, and in every later file.
+	names := []string{name}
+	if numbered {
+		parts, partsErr := f.partFiles(ctx)
+		if partsErr != nil {
+			return nil, partsErr
+		}
+		names = parts
+		if name != "" {
+			names = parts[:0]
+			for _, part := range parts {
+				if part == name || len(names) > 0 {
+					names = append(names, part)
+				}
+			}
+		}
+	}
+
+	written := make(map[string]int)
+	for i, file := range names {
+		start := offset
+		if i > 0 || (numbered && name == "") {
+			start = 0
+		}
+		if err := f.countRecordsSince(ctx, file, start, written); err != nil {
+			return nil, err
+		}
+	}
+	return written, nil
+}
+
+// countRecordsSince counts the records after offset in the plain record
+// file name by LineItemID, truncating a torn last line.
+func (f *File) countRecordsSince(ctx context.Context, name string, offset int64, written map[string]int) error {
+	file, err := f.bucket.Read(ctx, name, offset)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening records file: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if errors.Is(readErr, io.EOF) {
+			if len(line) > 0 {
+				if truncErr := f.bucket.Truncate(ctx, name, offset); truncErr != nil {
+					return fmt.Errorf("truncating torn record: %w", truncErr)
+				}
+			}
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("reading records file: %w", readErr)
+		}
+		offset += int64(len(line))
+
+		var record struct {
+			LineItemID string `json:"line_item_id"`
+		}
+		if decErr := json.Unmarshal(line, &record); decErr != nil {
+			return fmt.Errorf("decoding record at offset %d: %w", offset-int64(len(line)), decErr)
+		}
+		written[record.LineItemID]++
+	}
+}
+
+// manifestEntry returns the manifest entry of the record file name, adding
+// it when missing. f.mu must be held and the manifest loaded.
+func (f *File) manifestEntry(name string) *manifestEntry {
+	for i := range f.manifest.Files {
+		if f.manifest.Files[i].Path == name {
+			return &f.manifest.Files[i]
+		}
+	}
+	f.manifest.Files = append(f.manifest.Files, manifestEntry{Path: name, Compression: f.opts.Compression})
+	return &f.manifest.Files[len(f.manifest.Files)-1]
+}
+
+// loadManifest reads manifest.json, once. f.mu must be held.
+func (f *File) loadManifest(ctx context.Context) error {
+	if f.manifest != nil {
+		return nil
+	}
+	f.manifest = &fileManifest{}
+	file, err := f.bucket.Read(ctx, ManifestFileName, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, f.manifest); err != nil {
+		return fmt.Errorf("decoding manifest: %w", err)
+	}
+	return nil
+}
+
+// writeManifest replaces manifest.json as a whole, so readers never see it
+// half written. f.mu must be held.
+func (f *File) writeManifest(ctx context.Context) error {
+	if err := f.loadManifest(ctx); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := f.bucket.Put(ctx, ManifestFileName, append(data, '\n')); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+// Check verifies the sink directory or bucket is writable by creating and
+// removing a probe file.
+func (f *File) Check(ctx context.Context) error {
+	name := ".write-check-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := f.bucket.Put(ctx, name, nil); err != nil {
+		return fmt.Errorf("sink %s is not writable: %w", f.bucket, err)
+	}
+	return f.bucket.Delete(ctx, name)
+}
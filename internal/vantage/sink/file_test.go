@@ -0,0 +1,317 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func readRecords(t *testing.T, dir string) []adapter.CostRecord {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join(dir, RecordsFileName))
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []adapter.CostRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record adapter.CostRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestFile_WriteRecordsAppends(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	s, err := NewFile(dir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "a"}}))
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "b"}}))
+	require.NoError(t, s.WriteRecords(ctx, nil))
+
+	records := readRecords(t, dir)
+	require.Len(t, records, 2)
+	assert.Equal(t, "a", records[0].LineItemID)
+	assert.Equal(t, "b", records[1].LineItemID)
+}
+
+func TestFile_WriteRecordsRejectsUnencodable(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFile(dir)
+	require.NoError(t, err)
+
+	nan := math.NaN()
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	err = s.WriteRecords(context.Background(), []adapter.CostRecord{
+		{Timestamp: ts, LineItemID: "a"},
+		{Timestamp: ts, LineItemID: "b", NetCost: &nan},
+		{Timestamp: ts, LineItemID: "c"},
+	})
+
+	var partial *adapter.PartialWriteError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, []int{1}, slices.Collect(maps.Keys(partial.Failed)))
+	assert.ErrorIs(t, err, adapter.ErrRecordRejected)
+
+	records := readRecords(t, dir)
+	require.Len(t, records, 2)
+	assert.Equal(t, "a", records[0].LineItemID)
+	assert.Equal(t, "c", records[1].LineItemID)
+}
+
+func TestNewFile_EmptyPath(t *testing.T) {
+	_, err := NewFile("")
+	require.Error(t, err)
+}
+
+func TestFile_Check(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFile(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Check(context.Background()))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "probe file should be removed")
+}
+
+func TestFile_ReadRecords(t *testing.T) {
+	s, err := NewFile(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var read []adapter.CostRecord
+	collect := func(record adapter.CostRecord) error {
+		read = append(read, record)
+		return nil
+	}
+	require.NoError(t, s.ReadRecords(ctx, collect), "missing records file reads as empty")
+	assert.Empty(t, read)
+
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{LineItemID: "a"}, {LineItemID: "b"}}))
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{LineItemID: "c"}}))
+
+	require.NoError(t, s.ReadRecords(ctx, collect))
+	require.Len(t, read, 3)
+	assert.Equal(t, "c", read[2].LineItemID)
+
+	require.NoError(t, os.WriteFile(filepath.Join(s.Dir(), RecordsFileName), []byte("{\"line_item_id\":\"a\"}\n{bad\n"), 0o600))
+	err = s.ReadRecords(ctx, func(adapter.CostRecord) error { return nil })
+	require.ErrorContains(t, err, "decoding record 2")
+}
+
+func TestFile_ReadRecordsUpgradesSchema(t *testing.T) {
+	s, err := NewFile(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// A record from before schema versioning is upgraded.
+	path := filepath.Join(s.Dir(), RecordsFileName)
+	require.NoError(t, os.WriteFile(path, []byte("{\"line_item_id\":\"old\"}\n"), 0o600))
+
+	var read []adapter.CostRecord
+	require.NoError(t, s.ReadRecords(ctx, func(record adapter.CostRecord) error {
+		read = append(read, record)
+		return nil
+	}))
+	require.Len(t, read, 1)
+	assert.Equal(t, adapter.CurrentSchemaVersion, read[0].SchemaVersion)
+	assert.Equal(t, "old", read[0].LineItemID)
+
+	// A record from a newer plugin is rejected.
+	require.NoError(t, os.WriteFile(path, []byte("{\"schema_version\":99}\n"), 0o600))
+	err = s.ReadRecords(ctx, func(adapter.CostRecord) error { return nil })
+	require.ErrorIs(t, err, adapter.ErrUnsupportedSchemaVersion)
+	assert.Contains(t, err.Error(), "record 1")
+}
+
+var _ adapter.CheckpointSink = (*File)(nil)
+
+func TestFile_RecordsSince(t *testing.T) {
+	s, err := NewFile(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	checkpoint, err := s.Checkpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "0", checkpoint, "a sink never written to is at 0")
+
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "a"}}))
+	checkpoint, err = s.Checkpoint(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{
+		{Timestamp: ts, LineItemID: "b"},
+		{Timestamp: ts, LineItemID: "b"},
+		{Timestamp: ts, LineItemID: "c"},
+	}))
+
+	written, err := s.RecordsSince(ctx, checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"b": 2, "c": 1}, written)
+
+	_, err = s.RecordsSince(ctx, "-1")
+	require.ErrorContains(t, err, "invalid file sink checkpoint")
+}
+
+func TestFile_RecordsSinceTruncatesTornRecord(t *testing.T) {
+	s, err := NewFile(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "a"}}))
+	checkpoint, err := s.Checkpoint(ctx)
+	require.NoError(t, err)
+
+	// A crash part way through writing the next batch.
+	path := filepath.Join(s.Dir(), RecordsFileName)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString("{\"line_item_id\":\"b\"}\n{\"line_item_id\":\"c\",\"tim")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	written, err := s.RecordsSince(ctx, checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"b": 1}, written)
+
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "c"}}))
+	records := readRecords(t, s.Dir())
+	require.Len(t, records, 3)
+	assert.Equal(t, "c", records[2].LineItemID)
+}
+
+// readAll reads every record in the sink.
+func readAll(t *testing.T, s *File) []adapter.CostRecord {
+	t.Helper()
+	var read []adapter.CostRecord
+	require.NoError(t, s.ReadRecords(context.Background(), func(record adapter.CostRecord) error {
+		read = append(read, record)
+		return nil
+	}))
+	return read
+}
+
+func TestFile_Compression(t *testing.T) {
+	for compression, ext := range map[string]string{"gzip": ".gz", "zstd": ".zst"} {
+		t.Run(compression, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := NewFileWithOptions(dir, FileOptions{Compression: compression})
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "a"}}))
+			require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "b"}}))
+			_, err = os.Stat(filepath.Join(dir, "records-000001.ndjson"+ext))
+			require.NoError(t, err)
+			_, err = os.Stat(filepath.Join(dir, RecordsFileName))
+			require.ErrorIs(t, err, os.ErrNotExist)
+
+			// Reopening starts a new file, and a batch torn by a crash at
+			// the end of a file is ignored.
+			s, err = NewFileWithOptions(dir, FileOptions{Compression: compression})
+			require.NoError(t, err)
+			require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: ts, LineItemID: "c"}}))
+			path := filepath.Join(dir, "records-000002.ndjson"+ext)
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, append(data, data[:len(data)/2]...), 0o600))
+
+			read := readAll(t, s)
+			require.Len(t, read, 3)
+			assert.Equal(t, "a", read[0].LineItemID)
+			assert.Equal(t, "c", read[2].LineItemID)
+
+			checkpoint, err := s.Checkpoint(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, checkpoint, "a compressed batch is written again whole")
+		})
+	}
+
+	_, err := NewFileWithOptions(t.TempDir(), FileOptions{Compression: "lz4"})
+	require.ErrorContains(t, err, "unsupported sink compression: lz4")
+}
+
+func TestFile_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileWithOptions(dir, FileOptions{MaxFileBytes: 1, Manifest: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Records written before rotation was enabled are read first.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, RecordsFileName), []byte("{\"line_item_id\":\"old\"}\n"), 0o600))
+
+	checkpoint, err := s.Checkpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ":0", checkpoint)
+
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{
+		{Timestamp: day(3), LineItemID: "a"},
+		{Timestamp: day(2), LineItemID: "b"},
+	}))
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: day(5), LineItemID: "c"}}))
+
+	read := readAll(t, s)
+	require.Len(t, read, 4)
+	assert.Equal(t, []string{"old", "a", "b", "c"}, []string{
+		read[0].LineItemID, read[1].LineItemID, read[2].LineItemID, read[3].LineItemID,
+	})
+
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	require.NoError(t, err)
+	var manifest fileManifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	require.Len(t, manifest.Files, 2)
+	first, second := manifest.Files[0], manifest.Files[1]
+	assert.Equal(t, "records-000001.ndjson", first.Path)
+	assert.Equal(t, int64(2), first.Rows)
+	assert.Equal(t, "2024-01-02", first.MinDate)
+	assert.Equal(t, "2024-01-03", first.MaxDate)
+	assert.True(t, first.Closed, "a file is closed once the sink rotates away from it")
+	info, err := os.Stat(filepath.Join(dir, first.Path))
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), first.Bytes)
+	assert.Equal(t, "records-000002.ndjson", second.Path)
+	assert.Equal(t, int64(1), second.Rows)
+	assert.Equal(t, "2024-01-05", second.MinDate)
+	assert.False(t, second.Closed)
+
+	// A checkpoint covers the records after it in its file and every
+	// later file.
+	written, err := s.RecordsSince(ctx, checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, written)
+
+	checkpoint, err = s.Checkpoint(ctx)
+	require.NoError(t, err)
+	assert.Regexp(t, `^records-000002\.ndjson:\d+$`, checkpoint)
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{Timestamp: day(6), LineItemID: "d"}}))
+	written, err = s.RecordsSince(ctx, checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"d": 1}, written)
+
+	_, err = s.RecordsSince(ctx, "../etc/passwd:0")
+	require.ErrorContains(t, err, "invalid file sink checkpoint")
+}
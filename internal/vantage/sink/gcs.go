@@ -0,0 +1,338 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+
+	// maxComposeComponents is the most components a composite object can
+	// be built from.
+	maxComposeComponents = 1024
+
+	// gcsAppendSuffix names the object a batch is uploaded to before it is
+	// composed onto the end of its record file.
+	gcsAppendSuffix = ".append"
+)
+
+// GCSBucket is a Bucket of the objects under a prefix in a Google Cloud
+// Storage bucket, over the JSON API. Appends upload the data as its own
+// object and compose it onto the end of the target, rewriting the target
+// whole once it nears the composite object component limit. It
+// authenticates as BigQueryClient does: with GOOGLE_OAUTH_ACCESS_TOKEN when
+// set, and otherwise with the metadata server's default service account.
+type GCSBucket struct {
+	bucket string
+	prefix string
+
+	// Endpoint and MetadataTokenURL override the JSON API and metadata
+	// server token URLs.
+	Endpoint         string
+	MetadataTokenURL string
+	HTTPClient       *http.Client
+
+	metadataToken metadataToken
+}
+
+// gcsObject is the object metadata the JSON API returns.
+type gcsObject struct {
+	Name           string `json:"name"`
+	Size           int64  `json:"size,string"`
+	Generation     int64  `json:"generation,string"`
+	ComponentCount int    `json:"componentCount"`
+}
+
+// NewGCSBucket returns the bucket of the objects under prefix in the GCS
+// bucket named bucket.
+func NewGCSBucket(bucket, prefix string) (*GCSBucket, error) {
+	if bucket == "" {
+		return nil, errors.New("gcs bucket name cannot be empty")
+	}
+	return &GCSBucket{bucket: bucket, prefix: prefix}, nil
+}
+
+// String returns the bucket's URL.
+func (b *GCSBucket) String() string {
+	return strings.TrimSuffix(adapter.SinkBucketSchemeGCS+"://"+b.bucket+"/"+b.prefix, "/")
+}
+
+// List implements Bucket.List.
+func (b *GCSBucket) List(ctx context.Context) ([]string, error) {
+	prefix := objectName(b.prefix, "")
+	var names []string
+	pageToken := ""
+	for {
+		query := url.Values{"prefix": {prefix}, "delimiter": {"/"}, "fields": {"items(name),nextPageToken"}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var list struct {
+			Items         []gcsObject `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := b.doJSON(ctx, http.MethodGet, b.bucketURL()+"/o?"+query.Encode(), "", nil, &list); err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			names = append(names, strings.TrimPrefix(item.Name, prefix))
+		}
+		if list.NextPageToken == "" {
+			return names, nil
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
+// Size implements Bucket.Size.
+func (b *GCSBucket) Size(ctx context.Context, name string) (int64, error) {
+	object, err := b.stat(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return object.Size, nil
+}
+
+// Read implements Bucket.Read.
+func (b *GCSBucket) Read(ctx context.Context, name string, offset int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := b.send(ctx, http.MethodGet, b.objectURL(name)+"?alt=media", header, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		_ = resp.Body.Close()
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	default:
+		return nil, gcsError(resp, name)
+	}
+}
+
+// Append implements Bucket.Append.
+func (b *GCSBucket) Append(ctx context.Context, name string, data []byte) (int64, error) {
+	object, err := b.stat(ctx, name)
+	if errors.Is(err, os.ErrNotExist) {
+		object, err = b.upload(ctx, name, data, 0)
+		if err != nil {
+			return 0, err
+		}
+		return object.Size, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if max(object.ComponentCount, 1) >= maxComposeComponents {
+		existing, readErr := b.readAll(ctx, name)
+		if readErr != nil {
+			return 0, readErr
+		}
+		object, err = b.upload(ctx, name, append(existing, data...), object.Generation)
+		if err != nil {
+			return 0, err
+		}
+		return object.Size, nil
+	}
+
+	// An append object left by a failed append is replaced here.
+	if _, err := b.upload(ctx, name+gcsAppendSuffix, data, -1); err != nil {
+		return 0, err
+	}
+	compose := map[string]any{
+		"sourceObjects": []map[string]any{
+			{"name": objectName(b.prefix, name), "generation": strconv.FormatInt(object.Generation, 10)},
+			{"name": objectName(b.prefix, name+gcsAppendSuffix)},
+		},
+	}
+	query := url.Values{"ifGenerationMatch": {strconv.FormatInt(object.Generation, 10)}}
+	var composed gcsObject
+	target := b.objectURL(name) + "/compose?" + query.Encode()
+	if err := b.doJSON(ctx, http.MethodPost, target, name, compose, &composed); err != nil {
+		return 0, err
+	}
+	if err := b.Delete(ctx, name+gcsAppendSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return composed.Size, nil
+}
+
+// Put implements Bucket.Put.
+func (b *GCSBucket) Put(ctx context.Context, name string, data []byte) error {
+	_, err := b.upload(ctx, name, data, -1)
+	return err
+}
+
+// Truncate implements Bucket.Truncate.
+func (b *GCSBucket) Truncate(ctx context.Context, name string, size int64) error {
+	object, err := b.stat(ctx, name)
+	if err != nil {
+		return err
+	}
+	data, err := b.readAll(ctx, name)
+	if err != nil {
+		return err
+	}
+	_, err = b.upload(ctx, name, data[:min(size, int64(len(data)))], object.Generation)
+	return err
+}
+
+// Delete implements Bucket.Delete.
+func (b *GCSBucket) Delete(ctx context.Context, name string) error {
+	resp, err := b.send(ctx, http.MethodDelete, b.objectURL(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return gcsError(resp, name)
+	}
+	return nil
+}
+
+// stat returns the metadata of object name.
+func (b *GCSBucket) stat(ctx context.Context, name string) (gcsObject, error) {
+	var object gcsObject
+	err := b.doJSON(ctx, http.MethodGet, b.objectURL(name), name, nil, &object)
+	return object, err
+}
+
+// readAll returns the content of object name.
+func (b *GCSBucket) readAll(ctx context.Context, name string) ([]byte, error) {
+	r, err := b.Read(ctx, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// upload replaces object name with data. A generation of 0 requires the
+// object not to exist, a positive one that it is still at that generation,
+// and -1 replaces whatever is there.
+func (b *GCSBucket) upload(ctx context.Context, name string, data []byte, generation int64) (gcsObject, error) {
+	query := url.Values{"uploadType": {"media"}, "name": {objectName(b.prefix, name)}}
+	if generation >= 0 {
+		query.Set("ifGenerationMatch", strconv.FormatInt(generation, 10))
+	}
+	target := b.endpoint() + "/upload/storage/v1/b/" + url.PathEscape(b.bucket) + "/o?" + query.Encode()
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err := b.send(ctx, http.MethodPost, target, header, data)
+	if err != nil {
+		return gcsObject{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return gcsObject{}, gcsError(resp, name)
+	}
+	var object gcsObject
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&object); err != nil {
+		return gcsObject{}, fmt.Errorf("decoding response: %w", err)
+	}
+	return object, nil
+}
+
+// doJSON sends a request about object name with an optional JSON body and
+// decodes the JSON response into out.
+func (b *GCSBucket) doJSON(ctx context.Context, method, target, name string, body, out any) error {
+	var data []byte
+	header := http.Header{}
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.send(ctx, method, target, header, data)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return gcsError(resp, name)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// send sends an authorized request. The caller closes the response body.
+func (b *GCSBucket) send(
+	ctx context.Context,
+	method, target string,
+	header http.Header,
+	body []byte,
+) (*http.Response, error) {
+	httpClient := orDefaultClient(b.HTTPClient)
+	token, err := gcpAccessToken(ctx, &b.metadataToken, b.MetadataTokenURL, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return httpClient.Do(req)
+}
+
+// gcsError returns the error a failed response stands for, wrapping
+// os.ErrNotExist for a missing object.
+func gcsError(resp *http.Response, name string) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+	return fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+func (b *GCSBucket) endpoint() string {
+	if b.Endpoint != "" {
+		return strings.TrimSuffix(b.Endpoint, "/")
+	}
+	return defaultGCSEndpoint
+}
+
+func (b *GCSBucket) bucketURL() string {
+	return b.endpoint() + "/storage/v1/b/" + url.PathEscape(b.bucket)
+}
+
+func (b *GCSBucket) objectURL(name string) string {
+	return b.bucketURL() + "/o/" + url.PathEscape(objectName(b.prefix, name))
+}
@@ -0,0 +1,272 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// fakeGCSObject is an object held by fakeGCS.
+type fakeGCSObject struct {
+	data       []byte
+	generation int64
+	components int
+}
+
+// fakeGCS serves the parts of the GCS JSON API GCSBucket uses from memory.
+type fakeGCS struct {
+	t          *testing.T
+	mu         sync.Mutex
+	objects    map[string]*fakeGCSObject
+	generation int64
+	composes   int
+}
+
+func newFakeGCS(t *testing.T) (*fakeGCS, *httptest.Server) {
+	fake := &fakeGCS{t: t, objects: make(map[string]*fakeGCSObject)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Equal(f.t, "Bearer test-token", r.Header.Get("Authorization"))
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/bkt/o"):
+		data, _ := io.ReadAll(r.Body)
+		name := query.Get("name")
+		if !f.generationMatches(name, query) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.put(w, name, data, 1)
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/bkt/o":
+		var items []map[string]string
+		for name := range f.objects {
+			rest, ok := strings.CutPrefix(name, query.Get("prefix"))
+			if ok && !strings.Contains(rest, "/") {
+				items = append(items, map[string]string{"name": name})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case strings.HasSuffix(r.URL.Path, "/compose"):
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/storage/v1/b/bkt/o/"), "/compose")
+		if !f.generationMatches(name, query) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		var body struct {
+			SourceObjects []struct {
+				Name string `json:"name"`
+			} `json:"sourceObjects"`
+		}
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&body))
+		var data []byte
+		components := 0
+		for _, source := range body.SourceObjects {
+			object := f.objects[source.Name]
+			data = append(data, object.data...)
+			components += max(object.components, 1)
+		}
+		f.composes++
+		f.put(w, name, data, components)
+	default:
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/bkt/o/")
+		object, ok := f.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodDelete:
+			delete(f.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+		case query.Get("alt") == "media":
+			offset := 0
+			if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+				offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+			}
+			if offset >= len(object.data) && offset > 0 {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			_, _ = w.Write(object.data[offset:])
+		default:
+			f.writeMetadata(w, name, object)
+		}
+	}
+}
+
+func (f *fakeGCS) generationMatches(name string, query url.Values) bool {
+	want := query.Get("ifGenerationMatch")
+	if want == "" {
+		return true
+	}
+	generation := int64(0)
+	if object, ok := f.objects[name]; ok {
+		generation = object.generation
+	}
+	return want == strconv.FormatInt(generation, 10)
+}
+
+func (f *fakeGCS) put(w http.ResponseWriter, name string, data []byte, components int) {
+	f.generation++
+	object := &fakeGCSObject{data: data, generation: f.generation, components: components}
+	f.objects[name] = object
+	f.writeMetadata(w, name, object)
+}
+
+func (f *fakeGCS) writeMetadata(w http.ResponseWriter, name string, object *fakeGCSObject) {
+	metadata := map[string]any{
+		"name":       name,
+		"size":       strconv.Itoa(len(object.data)),
+		"generation": strconv.FormatInt(object.generation, 10),
+	}
+	if object.components > 1 {
+		metadata["componentCount"] = object.components
+	}
+	_ = json.NewEncoder(w).Encode(metadata)
+}
+
+func newTestGCSBucket(t *testing.T) (*fakeGCS, *GCSBucket) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "test-token")
+	fake, server := newFakeGCS(t)
+	bucket, err := NewGCSBucket("bkt", "vantage/prod")
+	require.NoError(t, err)
+	bucket.Endpoint = server.URL
+	return fake, bucket
+}
+
+func TestGCSBucket_File(t *testing.T) {
+	fake, bucket := newTestGCSBucket(t)
+	ctx := context.Background()
+
+	s, err := NewFileWithBucket(bucket, FileOptions{Manifest: true})
+	require.NoError(t, err)
+	assert.Equal(t, "gs://bkt/vantage/prod", s.Dir())
+	require.NoError(t, s.Check(ctx))
+
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{LineItemID: "a"}}))
+	checkpoint, err := s.Checkpoint(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{LineItemID: "b"}, {LineItemID: "c"}}))
+
+	assert.Equal(t, 1, fake.composes, "the second batch is composed onto the first")
+	assert.Contains(t, fake.objects, "vantage/prod/"+RecordsFileName)
+	assert.Contains(t, fake.objects, "vantage/prod/"+ManifestFileName)
+	assert.NotContains(t, fake.objects, "vantage/prod/"+RecordsFileName+gcsAppendSuffix)
+
+	var read []string
+	require.NoError(t, s.ReadRecords(ctx, func(record adapter.CostRecord) error {
+		read = append(read, record.LineItemID)
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "b", "c"}, read)
+
+	written, err := s.RecordsSince(ctx, checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"b": 1, "c": 1}, written)
+}
+
+func TestGCSBucket_NumberedFiles(t *testing.T) {
+	fake, bucket := newTestGCSBucket(t)
+	ctx := context.Background()
+
+	s, err := NewFileWithBucket(bucket, FileOptions{Compression: "gzip", MaxFileBytes: 1})
+	require.NoError(t, err)
+	for _, id := range []string{"a", "b"} {
+		require.NoError(t, s.WriteRecords(ctx, []adapter.CostRecord{{LineItemID: id}}))
+	}
+	assert.Contains(t, fake.objects, "vantage/prod/records-000001.ndjson.gz")
+	assert.Contains(t, fake.objects, "vantage/prod/records-000002.ndjson.gz")
+
+	reopened, err := NewFileWithBucket(bucket, FileOptions{Compression: "gzip", MaxFileBytes: 1})
+	require.NoError(t, err)
+	var read []string
+	require.NoError(t, reopened.ReadRecords(ctx, func(record adapter.CostRecord) error {
+		read = append(read, record.LineItemID)
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "b"}, read)
+}
+
+func TestGCSBucket_AppendRewritesAtComponentLimit(t *testing.T) {
+	fake, bucket := newTestGCSBucket(t)
+	ctx := context.Background()
+
+	_, err := bucket.Append(ctx, "part", []byte("ab"))
+	require.NoError(t, err)
+	fake.objects["vantage/prod/part"].components = maxComposeComponents
+
+	size, err := bucket.Append(ctx, "part", []byte("cd"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), size)
+	assert.Zero(t, fake.composes)
+	assert.Equal(t, "abcd", string(fake.objects["vantage/prod/part"].data))
+	assert.Equal(t, 1, fake.objects["vantage/prod/part"].components)
+}
+
+func TestGCSBucket_Truncate(t *testing.T) {
+	fake, bucket := newTestGCSBucket(t)
+	ctx := context.Background()
+
+	require.NoError(t, bucket.Put(ctx, "part", []byte("abcdef")))
+	require.NoError(t, bucket.Truncate(ctx, "part", 2))
+	assert.Equal(t, "ab", string(fake.objects["vantage/prod/part"].data))
+
+	r, err := bucket.Read(ctx, "part", 2)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Empty(t, data, "reading at the end returns no content")
+}
+
+func TestGCSBucket_Missing(t *testing.T) {
+	_, bucket := newTestGCSBucket(t)
+	ctx := context.Background()
+
+	_, err := bucket.Size(ctx, "missing")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = bucket.Read(ctx, "missing", 0)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.ErrorIs(t, bucket.Delete(ctx, "missing"), os.ErrNotExist)
+}
+
+func TestGCSBucket_MetadataToken(t *testing.T) {
+	var tokenRequests int
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		tokenRequests++
+		_, _ = w.Write([]byte(`{"access_token":"test-token","expires_in":3600}`))
+	}))
+	t.Cleanup(metadataServer.Close)
+	_, server := newFakeGCS(t)
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+	bucket, err := NewGCSBucket("bkt", "")
+	require.NoError(t, err)
+	bucket.Endpoint = server.URL
+	bucket.MetadataTokenURL = metadataServer.URL
+
+	for range 2 {
+		require.NoError(t, bucket.Put(context.Background(), "probe", nil))
+	}
+	assert.Equal(t, 1, tokenRequests, "the token is cached until it expires")
+}
@@ -0,0 +1,168 @@
+package sink
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+const (
+	// maxKafkaMessageBytes matches the broker's default message.max.bytes;
+	// larger records are rejected rather than failing their whole batch.
+	maxKafkaMessageBytes = 1 << 20
+
+	// kafkaBatchTimeout bounds how long the writer waits to fill a batch,
+	// since each WriteRecords call waits for its messages to be written.
+	kafkaBatchTimeout = 10 * time.Millisecond
+
+	kafkaFormatAvro = "avro"
+)
+
+// KafkaWriter is the subset of a kafka-go Writer used by the sink.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// NewKafkaWriter creates a writer producing to topic on brokers. Messages
+// are partitioned by a hash of their key, so every version of a record
+// lands on one partition, and each is acknowledged by all in-sync replicas.
+// Close it when done.
+func NewKafkaWriter(brokers []string, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: kafkaBatchTimeout,
+		BatchBytes:   maxKafkaMessageBytes,
+	}
+}
+
+// Kafka is a Sink that publishes records to a Kafka topic, keyed by
+// LineItemID so a compacted topic keeps the latest version of each record.
+// Values are the record as JSON, as the file sink writes it, or in Avro
+// framed for a Confluent-compatible schema registry. A deletion tombstone
+// is published as a Kafka tombstone, a message with no value, and a
+// restated record is preceded by a tombstone for the record it restates,
+// so compaction leaves the current view export.Reconcile derives.
+type Kafka struct {
+	writer   KafkaWriter
+	registry *schemaRegistry
+}
+
+// NewKafka creates a sink publishing through writer. format is "json" or
+// "avro"; Avro values use the schema registered for the subject
+// <topic>-value with the registry at schemaRegistryURL.
+func NewKafka(writer KafkaWriter, topic, format, schemaRegistryURL string) (*Kafka, error) {
+	if writer == nil {
+		return nil, errors.New("kafka writer cannot be nil")
+	}
+	k := &Kafka{writer: writer}
+	switch format {
+	case "json", "":
+	case kafkaFormatAvro:
+		if schemaRegistryURL == "" {
+			return nil, errors.New("avro values need a schema registry url")
+		}
+		k.registry = &schemaRegistry{url: schemaRegistryURL, subject: topic + "-value"}
+	default:
+		return nil, fmt.Errorf("unsupported kafka format: %s", format)
+	}
+	return k, nil
+}
+
+// WriteRecords implements adapter.Sink. Records that cannot be encoded, or
+// are larger than a message may be, and messages the broker rejects are
+// rejected in an adapter.PartialWriteError; other messages that failed are
+// reported in one too, so only they are written again.
+func (k *Kafka) WriteRecords(ctx context.Context, records []adapter.CostRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var schemaID uint32
+	if k.registry != nil {
+		id, err := k.registry.schemaID(ctx)
+		if err != nil {
+			return err
+		}
+		schemaID = id
+	}
+
+	failed := make(map[int]error)
+	messages := make([]kafka.Message, 0, len(records))
+	owners := make([]int, 0, len(records)) // index of the record each message publishes
+	for i := range records {
+		record := &records[i]
+		recordMessages := make([]kafka.Message, 0, 2)
+		if record.RestatesLineItemID != "" && record.RestatesLineItemID != record.LineItemID {
+			recordMessages = append(recordMessages, kafka.Message{Key: []byte(record.RestatesLineItemID)})
+		}
+		message := kafka.Message{Key: []byte(record.LineItemID)}
+		if record.MetricType != adapter.MetricTypeDeletion {
+			value, err := k.encode(record, schemaID)
+			if err != nil {
+				failed[i] = fmt.Errorf("%w: encoding record: %w", adapter.ErrRecordRejected, err)
+				continue
+			}
+			if size := len(message.Key) + len(value); size > maxKafkaMessageBytes {
+				failed[i] = fmt.Errorf("%w: message of %d bytes exceeds %d", adapter.ErrRecordRejected, size,
+					maxKafkaMessageBytes)
+				continue
+			}
+			message.Value = value
+		}
+		for _, m := range append(recordMessages, message) {
+			messages = append(messages, m)
+			owners = append(owners, i)
+		}
+	}
+
+	if len(messages) > 0 {
+		err := k.writer.WriteMessages(ctx, messages...)
+		var writeErrs kafka.WriteErrors
+		switch {
+		case errors.As(err, &writeErrs):
+			for j, msgErr := range writeErrs {
+				if msgErr == nil {
+					continue
+				}
+				if errors.Is(msgErr, kafka.MessageSizeTooLarge) || errors.Is(msgErr, kafka.InvalidRecord) ||
+					errors.Is(msgErr, kafka.InvalidMessage) {
+					msgErr = fmt.Errorf("%w: %w", adapter.ErrRecordRejected, msgErr)
+				}
+				failed[owners[j]] = msgErr
+			}
+		case err != nil:
+			return fmt.Errorf("publishing records: %w", err)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &adapter.PartialWriteError{Failed: failed}
+	}
+	return nil
+}
+
+// encode encodes a record as the message value.
+func (k *Kafka) encode(record *adapter.CostRecord, schemaID uint32) ([]byte, error) {
+	if k.registry == nil {
+		return json.Marshal(record)
+	}
+	value, err := encodeAvro(record)
+	if err != nil {
+		return nil, err
+	}
+	// The schema registry wire format: a zero magic byte and the
+	// big-endian schema ID before the Avro value.
+	framed := make([]byte, 5, 5+len(value))
+	binary.BigEndian.PutUint32(framed[1:], schemaID)
+	return append(framed, value...), nil
+}
@@ -0,0 +1,221 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+var _ adapter.Sink = (*Kafka)(nil)
+
+var _ KafkaWriter = (*kafka.Writer)(nil)
+
+// fakeKafkaWriter records published messages, failing those whose key is in
+// fail.
+type fakeKafkaWriter struct {
+	messages []kafka.Message
+	fail     map[string]error
+	err      error
+}
+
+func (w *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	errs := make(kafka.WriteErrors, len(msgs))
+	for i, msg := range msgs {
+		if err, ok := w.fail[string(msg.Key)]; ok {
+			errs[i] = err
+			continue
+		}
+		w.messages = append(w.messages, msg)
+	}
+	if errs.Count() > 0 {
+		return errs
+	}
+	return nil
+}
+
+func TestKafka_WriteRecordsJSON(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	k, err := NewKafka(writer, "costs", "json", "")
+	require.NoError(t, err)
+
+	records := bigQueryRecords("a", "b")
+	require.NoError(t, k.WriteRecords(context.Background(), records))
+
+	require.Len(t, writer.messages, 2)
+	assert.Equal(t, "a", string(writer.messages[0].Key))
+	var decoded adapter.CostRecord
+	require.NoError(t, json.Unmarshal(writer.messages[1].Value, &decoded))
+	assert.Equal(t, "b", decoded.LineItemID)
+	assert.Equal(t, "EC2", decoded.Service)
+}
+
+func TestKafka_WriteRecordsTombstones(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	k, err := NewKafka(writer, "costs", "json", "")
+	require.NoError(t, err)
+
+	records := bigQueryRecords("new", "gone")
+	records[0].RestatesLineItemID = "old"
+	records[1].MetricType = adapter.MetricTypeDeletion
+	require.NoError(t, k.WriteRecords(context.Background(), records))
+
+	require.Len(t, writer.messages, 3)
+	assert.Equal(t, "old", string(writer.messages[0].Key))
+	assert.Nil(t, writer.messages[0].Value, "the restated record is tombstoned")
+	assert.Equal(t, "new", string(writer.messages[1].Key))
+	assert.NotEmpty(t, writer.messages[1].Value)
+	assert.Equal(t, "gone", string(writer.messages[2].Key))
+	assert.Nil(t, writer.messages[2].Value, "a deletion is published as a tombstone")
+}
+
+func TestKafka_WriteRecordsPartialFailure(t *testing.T) {
+	writer := &fakeKafkaWriter{fail: map[string]error{
+		"b": kafka.LeaderNotAvailable,
+		"c": kafka.MessageSizeTooLarge,
+	}}
+	k, err := NewKafka(writer, "costs", "json", "")
+	require.NoError(t, err)
+
+	records := bigQueryRecords("a", "b", "c", "d", "e")
+	nan := math.NaN()
+	records[3].NetCost = &nan
+	records[4].ResourceID = strings.Repeat("x", maxKafkaMessageBytes)
+
+	err = k.WriteRecords(context.Background(), records)
+	var partial *adapter.PartialWriteError
+	require.ErrorAs(t, err, &partial)
+	require.Len(t, partial.Failed, 4)
+	assert.ErrorIs(t, partial.Failed[1], kafka.LeaderNotAvailable)
+	assert.NotErrorIs(t, partial.Failed[1], adapter.ErrRecordRejected)
+	assert.ErrorIs(t, partial.Failed[2], adapter.ErrRecordRejected)
+	assert.ErrorIs(t, partial.Failed[3], adapter.ErrRecordRejected)
+	assert.ErrorIs(t, partial.Failed[4], adapter.ErrRecordRejected)
+	assert.Contains(t, partial.Failed[4].Error(), "exceeds")
+	require.Len(t, writer.messages, 1)
+	assert.Equal(t, "a", string(writer.messages[0].Key))
+}
+
+func TestKafka_WriteRecordsFailure(t *testing.T) {
+	k, err := NewKafka(&fakeKafkaWriter{err: errors.New("no brokers")}, "costs", "json", "")
+	require.NoError(t, err)
+
+	err = k.WriteRecords(context.Background(), bigQueryRecords("a"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "publishing records: no brokers")
+}
+
+func TestKafka_WriteRecordsAvro(t *testing.T) {
+	var registrations int
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/subjects/costs-value/versions", r.URL.Path)
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "AVRO", body["schemaType"])
+		assert.JSONEq(t, avroRecordSchema, body["schema"])
+		registrations++
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	t.Cleanup(registry.Close)
+
+	writer := &fakeKafkaWriter{}
+	k, err := NewKafka(writer, "costs", "avro", registry.URL)
+	require.NoError(t, err)
+
+	require.NoError(t, k.WriteRecords(context.Background(), bigQueryRecords("a")))
+	require.NoError(t, k.WriteRecords(context.Background(), bigQueryRecords("b")))
+	assert.Equal(t, 1, registrations, "the schema is registered once")
+
+	require.Len(t, writer.messages, 2)
+	value := writer.messages[0].Value
+	assert.Equal(t, byte(0), value[0], "magic byte")
+	assert.Equal(t, uint32(42), binary.BigEndian.Uint32(value[1:5]))
+	expected, err := encodeAvro(&bigQueryRecords("a")[0])
+	require.NoError(t, err)
+	assert.Equal(t, expected, value[5:])
+}
+
+func TestKafka_SchemaRegistryFailure(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error_code":409,"message":"Schema being registered is incompatible"}`))
+	}))
+	t.Cleanup(registry.Close)
+
+	k, err := NewKafka(&fakeKafkaWriter{}, "costs", "avro", registry.URL)
+	require.NoError(t, err)
+
+	err = k.WriteRecords(context.Background(), bigQueryRecords("a"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 409: Schema being registered is incompatible")
+}
+
+func TestNewKafka_Errors(t *testing.T) {
+	_, err := NewKafka(nil, "costs", "json", "")
+	require.Error(t, err)
+	_, err = NewKafka(&fakeKafkaWriter{}, "costs", "avro", "")
+	require.Error(t, err)
+	_, err = NewKafka(&fakeKafkaWriter{}, "costs", "protobuf", "")
+	require.Error(t, err)
+}
+
+func TestEncodeAvro(t *testing.T) {
+	record := adapter.CostRecord{Timestamp: time.UnixMicro(5).UTC(), Provider: "aws"}
+	value, err := encodeAvro(&record)
+	require.NoError(t, err)
+
+	r := bytes.NewReader(value)
+	readLong := func() int64 {
+		v, readErr := binary.ReadVarint(r)
+		require.NoError(t, readErr)
+		return v
+	}
+	byName := make(map[string]any)
+	for _, col := range recordColumns {
+		if readLong() == 0 {
+			continue
+		}
+		switch col.typ {
+		case columnString, columnJSON:
+			buf := make([]byte, readLong())
+			_, readErr := r.Read(buf)
+			require.NoError(t, readErr)
+			byName[col.name] = string(buf)
+		case columnFloat:
+			var bits uint64
+			require.NoError(t, binary.Read(r, binary.LittleEndian, &bits))
+			byName[col.name] = math.Float64frombits(bits)
+		case columnInt, columnTimestamp:
+			byName[col.name] = readLong()
+		}
+	}
+	assert.Zero(t, r.Len(), "every byte is a field")
+	assert.Equal(t, map[string]any{"schema_version": int64(0), "timestamp": int64(5), "provider": "aws"}, byName)
+
+	var schema struct {
+		Fields []struct {
+			Name string `json:"name"`
+			Type []any  `json:"type"`
+		} `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(avroRecordSchema), &schema))
+	require.Len(t, schema.Fields, len(recordColumns))
+	assert.Equal(t, "timestamp", schema.Fields[1].Name)
+	assert.Equal(t, []any{"null", map[string]any{"type": "long", "logicalType": "timestamp-micros"}}, schema.Fields[1].Type)
+}
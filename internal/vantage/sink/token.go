@@ -0,0 +1,93 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// metadataToken caches an OAuth2 access token from a cloud metadata server
+// until shortly before it expires.
+type metadataToken struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// get returns the cached token, or fetches a new one with req when there is
+// none or it is about to expire.
+func (t *metadataToken) get(httpClient *http.Client, req *http.Request) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Now().Before(t.expiry) {
+		return t.token, nil
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	// Azure sends expires_in as a string, Google as a number.
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&token); decodeErr != nil {
+		return "", fmt.Errorf("decoding token: %w", decodeErr)
+	}
+	expiresIn, err := token.ExpiresIn.Int64()
+	if err != nil {
+		return "", fmt.Errorf("decoding token: expires_in: %w", err)
+	}
+	t.token = token.AccessToken
+	t.expiry = time.Now().Add(time.Duration(expiresIn)*time.Second - time.Minute)
+	return t.token, nil
+}
+
+// gcpAccessToken returns GOOGLE_OAUTH_ACCESS_TOKEN when set, and otherwise
+// the metadata server's default service account token, cached in cache.
+// tokenURL overrides the metadata server token URL.
+func gcpAccessToken(
+	ctx context.Context,
+	cache *metadataToken,
+	tokenURL string,
+	httpClient *http.Client,
+) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	if tokenURL == "" {
+		tokenURL = defaultGCPMetadataTokenURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating metadata token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	token, err := cache.get(httpClient, req)
+	if err != nil {
+		return "", fmt.Errorf("getting metadata server token (or set GOOGLE_OAUTH_ACCESS_TOKEN): %w", err)
+	}
+	return token, nil
+}
+
+// orDefaultClient returns httpClient, or http.DefaultClient when it is nil.
+func orDefaultClient(httpClient *http.Client) *http.Client {
+	if httpClient == nil {
+		return http.DefaultClient
+	}
+	return httpClient
+}
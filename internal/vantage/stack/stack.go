@@ -0,0 +1,187 @@
+// Package stack loads the resources of Pulumi stacks and indexes them by
+// URN and cloud resource ID, so cost records can be matched to the stack
+// resources that incurred them.
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Resource is one cloud resource a stack manages.
+type Resource struct {
+	URN  string
+	Type string // Pulumi type token, such as "aws:ec2/instance:Instance"
+	ID   string // provider ID
+	// IDs are every cloud identifier the resource is known by: its
+	// provider ID and the arn, id, and selfLink outputs it has.
+	IDs []string
+}
+
+// identifierOutputs are the outputs that hold a resource's cloud
+// identifiers across the AWS, Azure, and GCP providers.
+var identifierOutputs = []string{"arn", "id", "selfLink"}
+
+// rawResource is a resource as `pulumi stack export` writes it. Resource
+// descriptor files use the same shape.
+type rawResource struct {
+	URN     string                 `json:"urn"`
+	Type    string                 `json:"type"`
+	ID      string                 `json:"id"`
+	Outputs map[string]interface{} `json:"outputs"`
+}
+
+// rawFile is a stack export ({"deployment": {"resources": [...]}}) or a
+// resource descriptor file ({"resources": [...]}).
+type rawFile struct {
+	Deployment *struct {
+		Resources []rawResource `json:"resources"`
+	} `json:"deployment"`
+	Resources []rawResource `json:"resources"`
+}
+
+// Load reads the resources of a `pulumi stack export` file, or of a
+// resource descriptor file listing resources in the same shape, either as
+// a JSON array or under a top-level "resources" key. Resources without a
+// cloud identifier, such as the stack itself, components, and providers,
+// are skipped.
+func Load(path string) ([]Resource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading stack file: %w", err)
+	}
+	resources, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing stack file %s: %w", path, err)
+	}
+	return resources, nil
+}
+
+// Parse decodes the resources of a stack export or resource descriptor
+// file; see Load.
+func Parse(data []byte) ([]Resource, error) {
+	var raw []rawResource
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return nil, err
+		}
+	} else {
+		var file rawFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		raw = file.Resources
+		if file.Deployment != nil {
+			raw = file.Deployment.Resources
+		}
+	}
+
+	resources := make([]Resource, 0, len(raw))
+	for i, r := range raw {
+		if r.URN == "" {
+			return nil, fmt.Errorf("resources[%d]: urn is required", i)
+		}
+		if strings.HasPrefix(r.Type, "pulumi:providers:") {
+			// Provider IDs are Pulumi's own, not cloud identifiers.
+			continue
+		}
+		resource := Resource{URN: r.URN, Type: r.Type, ID: r.ID}
+		resource.IDs = appendID(resource.IDs, r.ID)
+		for _, key := range identifierOutputs {
+			if value, ok := r.Outputs[key].(string); ok {
+				resource.IDs = appendID(resource.IDs, value)
+			}
+		}
+		if len(resource.IDs) > 0 {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+// appendID adds id to ids unless it is empty or already listed.
+func appendID(ids []string, id string) []string {
+	if id == "" {
+		return ids
+	}
+	for _, existing := range ids {
+		if NormalizeID(existing) == NormalizeID(id) {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// NormalizeID returns the form of a cloud identifier IDs are matched by:
+// lower-cased, since Azure resource IDs are case-insensitive, and with a
+// GCP self-link or full resource name reduced to its "projects/..." path,
+// which is how the GCP provider reports IDs.
+func NormalizeID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if strings.HasPrefix(id, "https://") || strings.HasPrefix(id, "//") {
+		if i := strings.Index(id, "/projects/"); i >= 0 {
+			return id[i+1:]
+		}
+	}
+	return id
+}
+
+// Index finds stack resources by URN or cloud identifier.
+type Index struct {
+	byURN map[string]*Resource
+	// byID maps a normalized identifier to its resource, or to nil when
+	// several resources share it and a match would be a guess.
+	byID map[string]*Resource
+}
+
+// NewIndex indexes resources, typically from several stacks.
+func NewIndex(resources []Resource) *Index {
+	index := &Index{
+		byURN: make(map[string]*Resource, len(resources)),
+		byID:  make(map[string]*Resource, len(resources)),
+	}
+	for i := range resources {
+		resource := &resources[i]
+		index.byURN[resource.URN] = resource
+		for _, id := range resource.IDs {
+			key := NormalizeID(id)
+			if existing, ok := index.byID[key]; ok && existing != resource {
+				index.byID[key] = nil
+				continue
+			}
+			index.byID[key] = resource
+		}
+	}
+	return index
+}
+
+// Len returns the number of indexed resources.
+func (x *Index) Len() int {
+	return len(x.byURN)
+}
+
+// Lookup returns the resource with urn.
+func (x *Index) Lookup(urn string) (Resource, bool) {
+	if resource, ok := x.byURN[strings.TrimSpace(urn)]; ok {
+		return *resource, true
+	}
+	return Resource{}, false
+}
+
+// Match returns the resource known by the first of ids that exactly one
+// indexed resource has. IDs match case-insensitively, and GCP self-links
+// match the project paths the GCP provider reports.
+func (x *Index) Match(ids ...string) (Resource, bool) {
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if resource := x.byID[NormalizeID(id)]; resource != nil {
+			return *resource, true
+		}
+	}
+	return Resource{}, false
+}
@@ -0,0 +1,137 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stackExport = `{
+  "version": 3,
+  "deployment": {
+    "resources": [
+      {"urn": "urn:pulumi:dev::shop::pulumi:pulumi:Stack::shop-dev", "type": "pulumi:pulumi:Stack"},
+      {"urn": "urn:pulumi:dev::shop::pulumi:providers:aws::default", "type": "pulumi:providers:aws", "id": "04da6b54"},
+      {
+        "urn": "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web",
+        "type": "aws:ec2/instance:Instance",
+        "id": "i-0abc",
+        "outputs": {"arn": "arn:aws:ec2:us-east-1:123456789012:instance/i-0abc", "id": "i-0abc"}
+      },
+      {
+        "urn": "urn:pulumi:dev::shop::gcp:compute/instance:Instance::db",
+        "type": "gcp:compute/instance:Instance",
+        "id": "projects/shop/zones/us-central1-a/instances/db",
+        "outputs": {"selfLink": "https://www.googleapis.com/compute/v1/projects/shop/zones/us-central1-a/instances/db"}
+      },
+      {
+        "urn": "urn:pulumi:dev::shop::azure-native:resources:ResourceGroup::rg",
+        "type": "azure-native:resources:ResourceGroup",
+        "id": "/subscriptions/sub-1/resourceGroups/shop-rg",
+        "outputs": {"tags": {"owner": "platform"}}
+      }
+    ]
+  }
+}`
+
+func TestParse_StackExport(t *testing.T) {
+	resources, err := Parse([]byte(stackExport))
+	require.NoError(t, err)
+
+	// The stack and its provider carry no cloud identifier.
+	require.Len(t, resources, 3)
+	assert.Equal(t, Resource{
+		URN:  "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web",
+		Type: "aws:ec2/instance:Instance",
+		ID:   "i-0abc",
+		IDs:  []string{"i-0abc", "arn:aws:ec2:us-east-1:123456789012:instance/i-0abc"},
+	}, resources[0])
+	// The self-link names the same resource as the provider ID.
+	assert.Equal(t, []string{"projects/shop/zones/us-central1-a/instances/db"}, resources[1].IDs)
+	assert.Equal(t, []string{"/subscriptions/sub-1/resourceGroups/shop-rg"}, resources[2].IDs)
+}
+
+func TestParse_Descriptors(t *testing.T) {
+	descriptor := `{"urn": "urn:pulumi:prod::api::aws:s3/bucket:Bucket::assets", "type": "aws:s3/bucket:Bucket", "id": "assets-bucket"}`
+
+	for _, data := range []string{"[" + descriptor + "]", `{"resources": [` + descriptor + `]}`} {
+		resources, err := Parse([]byte(data))
+		require.NoError(t, err, data)
+		require.Len(t, resources, 1)
+		assert.Equal(t, "assets-bucket", resources[0].ID)
+	}
+
+	_, err := Parse([]byte(`[{"type": "aws:s3/bucket:Bucket", "id": "assets-bucket"}]`))
+	assert.EqualError(t, err, "resources[0]: urn is required")
+
+	_, err = Parse([]byte(`{"resources": `))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.json")
+	require.NoError(t, os.WriteFile(path, []byte(stackExport), 0o600))
+
+	resources, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, resources, 3)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "reading stack file")
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "parsing stack file "+path)
+}
+
+func TestIndex(t *testing.T) {
+	resources, err := Parse([]byte(stackExport))
+	require.NoError(t, err)
+	index := NewIndex(resources)
+	assert.Equal(t, 3, index.Len())
+
+	resource, ok := index.Lookup("urn:pulumi:dev::shop::aws:ec2/instance:Instance::web")
+	require.True(t, ok)
+	assert.Equal(t, "i-0abc", resource.ID)
+	_, ok = index.Lookup("urn:pulumi:prod::shop::aws:ec2/instance:Instance::web")
+	assert.False(t, ok)
+
+	tests := []struct {
+		ids      []string
+		expected string
+	}{
+		{[]string{"arn:aws:ec2:us-east-1:123456789012:instance/i-0abc"}, "web"},
+		{[]string{"", "unknown", "I-0ABC"}, "web"},
+		{[]string{"//compute.googleapis.com/projects/shop/zones/us-central1-a/instances/db"}, "db"},
+		{[]string{"https://www.googleapis.com/compute/v1/projects/shop/zones/us-central1-a/instances/db"}, "db"},
+		{[]string{"/SUBSCRIPTIONS/sub-1/resourcegroups/shop-rg"}, "rg"},
+		{[]string{"unknown"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		resource, ok := index.Match(tt.ids...)
+		assert.Equal(t, tt.expected != "", ok, tt.ids)
+		if ok {
+			assert.True(t, strings.HasSuffix(resource.URN, "::"+tt.expected), tt.ids)
+		}
+	}
+}
+
+func TestIndex_AmbiguousID(t *testing.T) {
+	index := NewIndex([]Resource{
+		{URN: "urn:pulumi:dev::a::aws:s3/bucket:Bucket::logs", IDs: []string{"logs"}},
+		{URN: "urn:pulumi:prod::a::aws:s3/bucket:Bucket::logs", IDs: []string{"logs"}},
+		{URN: "urn:pulumi:dev::a::aws:s3/bucket:Bucket::data", IDs: []string{"data"}},
+	})
+
+	_, ok := index.Match("logs")
+	assert.False(t, ok, "an ID two resources share matches neither")
+
+	resource, ok := index.Match("logs", "data")
+	require.True(t, ok)
+	assert.Equal(t, "urn:pulumi:dev::a::aws:s3/bucket:Bucket::data", resource.URN)
+}
@@ -0,0 +1,111 @@
+// Package tracing exports OpenTelemetry spans from sync runs over OTLP, so
+// slow backfills can be profiled in a trace viewer.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+// Setup installs a global tracer provider exporting spans as cfg describes.
+// The returned shutdown flushes pending spans and must be called before the
+// process exits. When tracing is disabled nothing is installed, spans stay
+// no-ops, and shutdown does nothing.
+func Setup(ctx context.Context, cfg adapter.TracingConfig, version string) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", cfg.ServiceName),
+			attribute.String("service.version", version),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// newExporter builds the OTLP exporter for cfg.Protocol. An endpoint with a
+// scheme is used as a URL; otherwise it is a host:port.
+func newExporter(ctx context.Context, cfg adapter.TracingConfig) (sdktrace.SpanExporter, error) {
+	hasScheme := strings.Contains(cfg.Endpoint, "://")
+
+	switch cfg.Protocol {
+	case adapter.TracingProtocolHTTP, "":
+		var opts []otlptracehttp.Option
+		switch {
+		case hasScheme:
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		case cfg.Endpoint != "":
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP HTTP exporter: %w", err)
+		}
+		return exporter, nil
+
+	case adapter.TracingProtocolGRPC:
+		var opts []otlptracegrpc.Option
+		switch {
+		case hasScheme:
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		case cfg.Endpoint != "":
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP gRPC exporter: %w", err)
+		}
+		return exporter, nil
+
+	default:
+		return nil, errors.New("unsupported tracing protocol: " + cfg.Protocol)
+	}
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"github.com/rshade/pulumicost-plugin-vantage/internal/vantage/adapter"
+)
+
+func TestSetup_Disabled(t *testing.T) {
+	previous := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), adapter.TracingConfig{}, "test")
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+	assert.Equal(t, previous, otel.GetTracerProvider(), "a disabled setup installs no provider")
+}
+
+func TestSetup_ExportsOverHTTP(t *testing.T) {
+	var exports atomic.Int32
+	var apiKey atomic.Value
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			exports.Add(1)
+			apiKey.Store(r.Header.Get("X-Api-Key"))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Setup(context.Background(), adapter.TracingConfig{
+		Enabled:     true,
+		Protocol:    adapter.TracingProtocolHTTP,
+		Endpoint:    collector.URL,
+		Headers:     map[string]string{"x-api-key": "secret"},
+		SampleRatio: 1,
+		ServiceName: "pulumicost-vantage",
+	}, "test")
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "vantage.sync")
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+	assert.Equal(t, int32(1), exports.Load())
+	assert.Equal(t, "secret", apiKey.Load())
+}
+
+func TestSetup_UnsupportedProtocol(t *testing.T) {
+	_, err := Setup(context.Background(), adapter.TracingConfig{Enabled: true, Protocol: "zipkin"}, "test")
+	require.ErrorContains(t, err, "unsupported tracing protocol: zipkin")
+}